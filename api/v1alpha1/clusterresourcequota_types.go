@@ -1,7 +1,11 @@
 package v1alpha1
 
 import (
+	"strings"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -11,6 +15,8 @@ type ResourceList corev1.ResourceList
 // ResourceQuotaStatus defines the enforced hard limits and observed use.
 type ResourceQuotaStatus struct {
 	// Hard is the set of enforced hard limits for each named resource (see ClusterResourceQuotaSpec for examples).
+	// In status.namespaces it is set only when the limits that namespace's usage counts
+	// against differ from status.total.hard, which applies to every namespace otherwise.
 	// +optional
 	Hard ResourceList `json:"hard,omitempty"`
 
@@ -18,6 +24,11 @@ type ResourceQuotaStatus struct {
 	// For object count quotas, this is the current count of each resource type (e.g., pods, services.loadbalancers, ingresses.nginx, etc.).
 	// +optional
 	Used ResourceList `json:"used,omitempty"`
+
+	// Reserved is the part of the namespaces' quota.powerapp.cloud/reserve
+	// reservations that their usage has not reached yet. It is included in Used.
+	// +optional
+	Reserved ResourceList `json:"reserved,omitempty"`
 }
 
 // ResourceQuotaStatusByNamespace gives status for a particular namespace
@@ -49,6 +60,13 @@ type ClusterResourceQuotaSpec struct {
 	// +required
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector"`
 
+	// ExcludeNamespaceSelector carves exceptions out of NamespaceSelector without relabeling namespaces.
+	// A namespace matched by both selectors is not governed by this quota.
+	// For example, namespaceSelector env=prod with excludeNamespaceSelector tier=system applies
+	// the quota to every production namespace except the system-tier ones.
+	// +optional
+	ExcludeNamespaceSelector *metav1.LabelSelector `json:"excludeNamespaceSelector,omitempty"`
+
 	// ScopeSelector is also a collection of filters like scopes that must match each object tracked by a quota
 	// but expressed using ScopeSelectorOperator in combination with possible values.
 	// For example, to select objects where any container has a resource request that exceeds 100m CPU,
@@ -67,8 +85,302 @@ type ClusterResourceQuotaSpec struct {
 	// - CrossNamespacePodAffinity: match pods that have cross-namespace pod affinity terms
 	// +optional
 	Scopes []corev1.ResourceQuotaScope `json:"scopes,omitempty"`
+
+	// Reserved maps priority class names to headroom that is kept free for pods of that class.
+	// Pods of any other priority class are denied when admitting them would leave less than
+	// the reserved amount available under the hard limit, so critical workloads can always schedule.
+	// For example:
+	// 'system-cluster-critical': {'requests.cpu': '2', 'pods': '5'}
+	// keeps 2 CPUs and 5 pod slots of the hard limits free for system-cluster-critical pods.
+	// +optional
+	Reserved map[string]ResourceList `json:"reserved,omitempty"`
+
+	// Mode selects what usage is compared against Hard.
+	// Requests (the default) sums pod requests and limits and enforces the quota at admission.
+	// Actual sums the live CPU and memory reported by metrics-server for the selected pods;
+	// violations are reported through events and metrics only, never by denying admission.
+	// +kubebuilder:validation:Enum=Requests;Actual
+	// +kubebuilder:default=Requests
+	// +optional
+	Mode QuotaMode `json:"mode,omitempty"`
+
+	// MissingRequests sets how pods whose containers leave a quota'd compute resource unset
+	// are handled. Without it such containers count as zero, so a pod without requests fits
+	// any quota. LimitRange defaults are applied by the API server before the pod is seen
+	// and are always counted; this covers namespaces without a LimitRange.
+	// +optional
+	MissingRequests *MissingRequestsPolicy `json:"missingRequests,omitempty"`
+
+	// EphemeralContainers is charged for every running ephemeral container, such as those
+	// kubectl debug adds, keyed by quota resource name. Ephemeral containers cannot set
+	// requests or limits, so without it they count as zero. For example:
+	// 'requests.cpu': '100m', 'requests.memory': '128Mi'
+	// charges 100m CPU and 128Mi memory requests per debug session.
+	// +optional
+	EphemeralContainers ResourceList `json:"ephemeralContainers,omitempty"`
+
+	// UsageWeights scales the usage charged against Hard, keyed by resource name, so quota
+	// math reflects the cluster's overcommit policy. Usage of a listed key is multiplied by
+	// its weight in status and at admission alike. For example:
+	// 'requests.cpu': '500m', 'count/jobs.batch': '2'
+	// charges each requested CPU as half a CPU, on nodes overcommitting CPU 2x, and each Job
+	// as two. Weights must be positive. pods.density/per-cpu cannot be weighted.
+	// +optional
+	UsageWeights ResourceList `json:"usageWeights,omitempty"`
+
+	// CreationRates caps how many objects the selected namespaces may create per minute,
+	// keyed by resource name. For example:
+	// 'pods': '50'
+	// admits at most 50 new pods a minute across the namespaces, in bursts of up to 50, so
+	// runaway jobs cannot flood the API server and controller with short-lived pods. Each
+	// webhook replica keeps its own allowance. Only pods are supported; rates must be positive.
+	// +optional
+	CreationRates ResourceList `json:"creationRates,omitempty"`
+
+	// Federation shares Hard with the ClusterResourceQuota of the same name in other clusters.
+	// Every cluster's controller reports its usage to a hub cluster, and admission compares
+	// the usage of all clusters against Hard. Apply the same spec in every cluster.
+	// +optional
+	Federation *FederationSpec `json:"federation,omitempty"`
+
+	// TopologyKey is a node label, such as topology.kubernetes.io/zone, that splits pod
+	// usage into one bucket per label value. A pod is attributed to the value of the node
+	// it runs on, or before scheduling to the value its node selector or required node
+	// affinity pins it to. Pods that could land on any value are not attributed.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+
+	// TopologyHard caps the pod usage of individual TopologyKey values, keyed by label value.
+	// For example, with topologyKey topology.kubernetes.io/zone:
+	// 'us-east-1a': {'requests.cpu': '20', 'pods': '50'}
+	// lets pods in us-east-1a request at most 20 CPUs, on top of the quota-wide Hard limits.
+	// Only pods and pod compute resources are supported.
+	// +optional
+	TopologyHard map[string]ResourceList `json:"topologyHard,omitempty"`
+
+	// NodeSelectorTerms limits the pods this quota counts to those confined to a dedicated
+	// node pool: pods whose node selector or required node affinity only allows nodes
+	// matching one of the terms. For example:
+	// [{matchExpressions: [{key: pool, operator: In, values: [gpu]}]}]
+	// charges only pods that select pool=gpu, so general workloads are not counted against
+	// the pool's quota. Pods pinned through spec.nodeName alone are not counted, and
+	// matchFields are not supported. Objects other than pods are counted as usual.
+	// +optional
+	NodeSelectorTerms []corev1.NodeSelectorTerm `json:"nodeSelectorTerms,omitempty"`
+
+	// EnforcementPolicy sets what individual Hard keys do, keyed by resource name. Keys
+	// not listed are enforced. For example:
+	// 'requests.memory': ReportOnly, 'configmaps': Off
+	// reports memory usage without denying pods for it, and stops counting ConfigMaps
+	// while keeping their limit in the spec.
+	// +optional
+	EnforcementPolicy map[corev1.ResourceName]EnforcementAction `json:"enforcementPolicy,omitempty"`
+
+	// DeletionPolicy sets what happens to the objects the controller creates in member
+	// namespaces for this quota, such as the status mirror ConfigMaps, when the quota is
+	// deleted. Delete (the default) removes them before the quota is gone; Orphan leaves
+	// them in place, detached from the quota.
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// SelectorPolicy sets whether NamespaceSelector can change after the quota is created.
+	// Mutable (the default) lets it be edited; Immutable freezes it, so the quota cannot be
+	// retargeted to another tenant by accident, and cannot itself be set back to Mutable.
+	// Retarget an Immutable quota by recreating it. Enforced by the ClusterResourceQuota
+	// webhook.
+	// +kubebuilder:validation:Enum=Mutable;Immutable
+	// +kubebuilder:default=Mutable
+	// +optional
+	SelectorPolicy SelectorPolicy `json:"selectorPolicy,omitempty"`
+
+	// StorageAuditLock denies the deletion of every PersistentVolumeClaim in the selected
+	// namespaces while set, holding their data for retention or audit. It only takes effect
+	// when the controller runs with --pvc-deletion-protection.
+	// +optional
+	StorageAuditLock bool `json:"storageAuditLock,omitempty"`
+
+	// HardLimitsFrom reads hard limits from a ConfigMap, for quota numbers managed by a
+	// pipeline outside the quota. The controller writes them into Hard, under its own field
+	// manager, whenever the ConfigMap changes; a key set both in Hard and in the ConfigMap
+	// takes the ConfigMap's value. While the ConfigMap cannot be read, Hard keeps the values
+	// read last and the HardLimitsResolved condition is False.
+	// +optional
+	HardLimitsFrom *HardLimitsSource `json:"hardLimitsFrom,omitempty"`
+}
+
+// HardLimitsSource is a ConfigMap holding hard limits of a ClusterResourceQuota.
+type HardLimitsSource struct {
+	// Namespace is the namespace of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Items maps ConfigMap keys to the resource whose hard limit they hold. Without it,
+	// every key of the ConfigMap is read as a resource name, e.g. requests.cpu: "20".
+	// +listType=map
+	// +listMapKey=key
+	// +optional
+	Items []HardLimitItem `json:"items,omitempty"`
+}
+
+// HardLimitItem maps a ConfigMap key to a hard limit.
+type HardLimitItem struct {
+	// Key is the ConfigMap key holding the limit.
+	Key string `json:"key"`
+
+	// Resource is the resource the limit applies to.
+	Resource corev1.ResourceName `json:"resource"`
+}
+
+// DeletionPolicy selects what happens to a quota's per-namespace objects when it is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the per-namespace objects with the quota.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the per-namespace objects, without their owner reference
+	// and controller label, so nothing deletes or rewrites them afterwards.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// SelectorPolicy selects whether a quota's namespace selector can change.
+type SelectorPolicy string
+
+const (
+	// SelectorPolicyMutable lets the namespace selector be edited.
+	SelectorPolicyMutable SelectorPolicy = "Mutable"
+	// SelectorPolicyImmutable freezes the namespace selector.
+	SelectorPolicyImmutable SelectorPolicy = "Immutable"
+)
+
+// EnforcementAction selects what a hard limit does.
+// +kubebuilder:validation:Enum=Enforce;ReportOnly;Off
+type EnforcementAction string
+
+const (
+	// EnforcementEnforce counts and reports usage, and denies requests exceeding the limit.
+	EnforcementEnforce EnforcementAction = "Enforce"
+	// EnforcementReportOnly counts and reports usage, with QuotaExceeded events and
+	// metrics, but never denies a request for it.
+	EnforcementReportOnly EnforcementAction = "ReportOnly"
+	// EnforcementOff neither counts, reports nor enforces the limit.
+	EnforcementOff EnforcementAction = "Off"
+)
+
+// Enforcement returns the EnforcementAction of a Hard key, Enforce unless
+// EnforcementPolicy says otherwise.
+func (s *ClusterResourceQuotaSpec) Enforcement(resourceName corev1.ResourceName) EnforcementAction {
+	if action, ok := s.EnforcementPolicy[resourceName]; ok && action != "" {
+		return action
+	}
+	return EnforcementEnforce
 }
 
+// TrackedHard returns the Hard limits whose usage is counted: every key not
+// turned Off by EnforcementPolicy.
+func (s *ClusterResourceQuotaSpec) TrackedHard() ResourceList {
+	if s.Hard == nil {
+		return nil
+	}
+	tracked := make(ResourceList, len(s.Hard))
+	for resourceName, limit := range s.Hard {
+		if s.Enforcement(resourceName) != EnforcementOff {
+			tracked[resourceName] = limit
+		}
+	}
+	return tracked
+}
+
+// HasTrackedLimits reports whether the spec limits anything: a Hard key not
+// turned Off by EnforcementPolicy, or a TopologyHard limit.
+func (s *ClusterResourceQuotaSpec) HasTrackedLimits() bool {
+	if len(s.TrackedHard()) > 0 {
+		return true
+	}
+	for _, limits := range s.TopologyHard {
+		if len(limits) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FederationSpec configures a ClusterResourceQuota shared across clusters.
+type FederationSpec struct {
+	// Slices caps the usage of individual clusters, keyed by the name each controller is
+	// started with (--federation-cluster-name). For example:
+	// 'us-east': {'requests.cpu': '60'}
+	// lets us-east use at most 60 CPUs of the shared hard limit. Clusters without a slice
+	// are bound by the global limit only.
+	// +optional
+	Slices map[string]ResourceList `json:"slices,omitempty"`
+
+	// ReportTTL is how long a cluster's report counts toward the shared limit. The usage of
+	// a cluster that has not reported for longer, such as one that was decommissioned or
+	// cut off from the hub, is left out of admission, and the StaleFederationReports
+	// condition names the cluster. Defaults to 5m.
+	// +optional
+	ReportTTL *metav1.Duration `json:"reportTTL,omitempty"`
+}
+
+// DefaultFederationReportTTL is the ReportTTL of a FederationSpec that sets none: ten
+// missed resyncs of a reporting cluster.
+const DefaultFederationReportTTL = 5 * time.Minute
+
+// EffectiveReportTTL returns ReportTTL, or DefaultFederationReportTTL when it is unset or
+// not positive.
+func (s *FederationSpec) EffectiveReportTTL() time.Duration {
+	if s == nil || s.ReportTTL == nil || s.ReportTTL.Duration <= 0 {
+		return DefaultFederationReportTTL
+	}
+	return s.ReportTTL.Duration
+}
+
+// MissingRequestsAction selects how containers without requests or limits are handled.
+type MissingRequestsAction string
+
+const (
+	// MissingRequestsIgnore counts unset requests and limits as zero.
+	MissingRequestsIgnore MissingRequestsAction = "Ignore"
+	// MissingRequestsDeny rejects new pods with a container that leaves a compute resource
+	// in Hard unset, as the built-in ResourceQuota does.
+	MissingRequestsDeny MissingRequestsAction = "Deny"
+	// MissingRequestsAssume charges MissingRequestsPolicy.Defaults for every container that
+	// leaves one of their resources unset.
+	MissingRequestsAssume MissingRequestsAction = "Assume"
+)
+
+// MissingRequestsPolicy configures how pods without requests or limits are charged.
+type MissingRequestsPolicy struct {
+	// Action is Ignore (the default), Deny or Assume.
+	// +kubebuilder:validation:Enum=Ignore;Deny;Assume
+	// +kubebuilder:default=Ignore
+	// +optional
+	Action MissingRequestsAction `json:"action,omitempty"`
+
+	// Defaults are charged per container under Assume, keyed by quota resource name.
+	// For example:
+	// 'requests.cpu': '100m', 'limits.memory': '256Mi'
+	// charges 100m CPU requests to every container that requests no CPU.
+	// +optional
+	Defaults ResourceList `json:"defaults,omitempty"`
+}
+
+// QuotaMode selects how compute usage is measured for a ClusterResourceQuota.
+type QuotaMode string
+
+const (
+	// QuotaModeRequests measures compute usage from pod requests and limits.
+	QuotaModeRequests QuotaMode = "Requests"
+	// QuotaModeActual measures compute usage from metrics-server pod metrics.
+	QuotaModeActual QuotaMode = "Actual"
+)
+
 // ClusterResourceQuotaStatus defines the observed state of ClusterResourceQuota.
 type ClusterResourceQuotaStatus struct {
 	// Total defines the actual enforced quota and its current usage across all namespaces
@@ -76,8 +388,202 @@ type ClusterResourceQuotaStatus struct {
 	Total ResourceQuotaStatus `json:"total"`
 
 	// Namespaces slices the usage by namespace
+	// +listType=map
+	// +listMapKey=namespace
 	// +optional
 	Namespaces []ResourceQuotaStatusByNamespace `json:"namespaces,omitempty"`
+
+	// Federation is the usage of every cluster sharing this quota, as last read from the hub.
+	// +optional
+	Federation *FederationStatus `json:"federation,omitempty"`
+
+	// Topology is the pod usage attributed to each value of spec.topologyKey, sorted by value.
+	// +listType=map
+	// +listMapKey=value
+	// +optional
+	Topology []TopologyUsage `json:"topology,omitempty"`
+
+	// Forecasts are when the usage of each growing resource reaches its hard
+	// limit, extrapolated from the usage of the last hour, sorted by resource.
+	// +listType=map
+	// +listMapKey=resource
+	// +optional
+	Forecasts []UsageForecast `json:"forecasts,omitempty"`
+
+	// LastDenied is the latest admission request denied for exceeding one of
+	// this quota's limits, as reported by the admission webhook.
+	// +optional
+	LastDenied *AdmissionDenial `json:"lastDenied,omitempty"`
+
+	// PendingDemand estimates how much more of each resource is being requested but
+	// denied, such as by Deployments that cannot create their pods. It sums, per
+	// namespace, the latest request denied for each resource, fading linearly to nothing
+	// over 15 minutes without another denial. Replicas denied with identical requests
+	// count once, so it is a lower bound.
+	// +optional
+	PendingDemand ResourceList `json:"pendingDemand,omitempty"`
+
+	// IncompleteResources lists, while IncompleteUsage is True, the resources
+	// whose usage could not be fully counted. Admission leaves requests for
+	// these resources to the webhook's failure policy and validates the others
+	// as usual.
+	// +listType=set
+	// +optional
+	IncompleteResources []corev1.ResourceName `json:"incompleteResources,omitempty"`
+
+	// Conditions report the state of the usage calculation. IncompleteUsage is
+	// True when the usage of some resources could not be fully counted,
+	// NoHardLimits when the quota limits nothing, and Paused while the quota
+	// is paused by its quota.powerapp.cloud/paused annotation.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ConditionIncompleteUsage is the condition type reporting that part of a
+// ClusterResourceQuota's usage could not be counted. While it is True the
+// usage in status is a lower bound, and admission applies
+// --incomplete-usage-policy to requests the quota limits.
+const ConditionIncompleteUsage = "IncompleteUsage"
+
+// ConditionNoHardLimits is the condition type reporting that a
+// ClusterResourceQuota limits nothing: spec.hard is empty or every key is
+// turned Off, and spec.topologyHard sets no limit. Such a quota is reconciled
+// without effect, often because of a typo.
+const ConditionNoHardLimits = "NoHardLimits"
+
+// ConditionHardLimitsResolved is the condition type reporting whether the
+// hard limits of spec.hardLimitsFrom could be read from their ConfigMap. It
+// is only set on quotas with spec.hardLimitsFrom.
+const ConditionHardLimitsResolved = "HardLimitsResolved"
+
+// ConditionPaused is the condition type reporting that a ClusterResourceQuota
+// is paused by AnnotationPaused.
+const ConditionPaused = "Paused"
+
+// AnnotationPaused, set to "true" on a ClusterResourceQuota, pauses it: the
+// controller stops recalculating its usage, leaving the status as it was, and
+// admission webhooks act as if the quota did not exist. Removing the
+// annotation resumes the quota.
+const AnnotationPaused = "quota.powerapp.cloud/paused"
+
+// ConditionEnforcementExempt is the condition type reporting whether a
+// ClusterResourceQuota is within an enforcement exemption window, set by
+// AnnotationEnforcementExemptUntil or --enforcement-exempt-until. It is only
+// reported while a window is set.
+const ConditionEnforcementExempt = "EnforcementExempt"
+
+// ConditionStaleFederationReports is the condition type reporting whether
+// other clusters sharing a federated ClusterResourceQuota have stopped
+// reporting: their last report is older than spec.federation.reportTTL, so
+// admission no longer counts their usage. It is only set on federated quotas.
+const ConditionStaleFederationReports = "StaleFederationReports"
+
+// AnnotationEnforcementExemptUntil, set to an RFC 3339 time on a
+// ClusterResourceQuota, exempts it from enforcement until then: admission
+// webhooks admit requests exceeding its limits with a warning instead of
+// denying them, while usage is counted and reported as usual. The quota is
+// enforced again once the time passes, whether or not the annotation is
+// removed.
+const AnnotationEnforcementExemptUntil = "quota.powerapp.cloud/enforcement-exempt-until"
+
+// AnnotationMetricLabels declares extra labels of the usage metrics of a
+// ClusterResourceQuota as comma-separated name=value pairs, such as
+// "costcenter=1234,team=payments", for chargeback. Only the names allowed by
+// --metrics-crq-labels are exported.
+const AnnotationMetricLabels = "quota.powerapp.cloud/metric-labels"
+
+// AnnotationResyncRequested records when a resync of a ClusterResourceQuota
+// was last requested through POST /admin/reconcile-all. Changing it makes the
+// leading controller replica recalculate the quota.
+const AnnotationResyncRequested = "quota.powerapp.cloud/resync-requested"
+
+// TopologyUsage is the pod usage attributed to one value of spec.topologyKey.
+type TopologyUsage struct {
+	// Value is the node label value.
+	Value string `json:"value"`
+
+	// Used is the usage of the pods attributed to Value, for every resource in
+	// spec.topologyHard.
+	// +optional
+	Used ResourceList `json:"used,omitempty"`
+}
+
+// UsageForecast is when the usage of one resource is expected to reach its
+// hard limit.
+type UsageForecast struct {
+	// Resource is the quota key the forecast is for.
+	Resource corev1.ResourceName `json:"resource"`
+
+	// ExhaustionTime is when usage reaches the hard limit if it keeps growing
+	// at its recent rate.
+	ExhaustionTime metav1.Time `json:"exhaustionTime"`
+}
+
+// AdmissionDenial is an admission request denied for exceeding a limit of a
+// ClusterResourceQuota.
+type AdmissionDenial struct {
+	// Time is when the request was last denied.
+	Time metav1.Time `json:"time"`
+
+	// Namespace is the namespace of the denied request.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Resource is the quota key whose limit the request exceeded.
+	Resource corev1.ResourceName `json:"resource"`
+
+	// Requested is how much of Resource the request asked for.
+	Requested resource.Quantity `json:"requested"`
+
+	// Message is the reason the request was denied for.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FederationStatus is the per-cluster usage of a federated ClusterResourceQuota.
+type FederationStatus struct {
+	// Cluster is the name of the cluster this object lives in. Its own entry in Clusters is
+	// already counted in Total, so admission adds only the other entries.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Clusters is the usage each cluster last reported to the hub.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Clusters []ClusterUsage `json:"clusters,omitempty"`
+}
+
+// ClusterUsage is the usage one cluster reported for a federated quota.
+type ClusterUsage struct {
+	// Name is the reporting cluster's --federation-cluster-name.
+	Name string `json:"name"`
+
+	// Used is the cluster's total usage across its selected namespaces.
+	// +optional
+	Used ResourceList `json:"used,omitempty"`
+
+	// LastReportTime is when the cluster last reported.
+	LastReportTime metav1.Time `json:"lastReportTime"`
+}
+
+// Stale reports whether the report is older than ttl at now.
+func (u ClusterUsage) Stale(ttl time.Duration, now time.Time) bool {
+	return now.Sub(u.LastReportTime.Time) > ttl
+}
+
+// NamespaceHard returns the hard limits the usage of ns counts against: its own
+// when its entry sets them, otherwise the quota's total hard limits.
+func (crqs *ClusterResourceQuotaStatus) NamespaceHard(ns *ResourceQuotaStatusByNamespace) ResourceList {
+	if ns != nil && ns.Status.Hard != nil {
+		return ns.Status.Hard
+	}
+	if crqs == nil {
+		return nil
+	}
+	return crqs.Total.Hard
 }
 
 func (crqs *ClusterResourceQuotaStatus) GetNamespaces() []string {
@@ -109,6 +615,44 @@ type ClusterResourceQuota struct {
 	Status ClusterResourceQuotaStatus `json:"status"`
 }
 
+// Paused reports whether crq is paused by AnnotationPaused.
+func (crq *ClusterResourceQuota) Paused() bool {
+	return crq.Annotations[AnnotationPaused] == "true"
+}
+
+// EnforcementExemptUntil returns when the enforcement exemption of crq ends:
+// the later of AnnotationEnforcementExemptUntil and clusterWide, the
+// cluster-wide exemption. It is zero when neither is set, and an annotation
+// that is not an RFC 3339 time is ignored.
+func (crq *ClusterResourceQuota) EnforcementExemptUntil(clusterWide time.Time) time.Time {
+	until := clusterWide
+	if value, ok := crq.Annotations[AnnotationEnforcementExemptUntil]; ok {
+		if annotated, err := time.Parse(time.RFC3339, value); err == nil && annotated.After(until) {
+			until = annotated
+		}
+	}
+	return until
+}
+
+// MetricLabels returns the name=value pairs of AnnotationMetricLabels. Pairs
+// without a "=" or a name are ignored.
+func (crq *ClusterResourceQuota) MetricLabels() map[string]string {
+	value, ok := crq.Annotations[AnnotationMetricLabels]
+	if !ok {
+		return nil
+	}
+	labels := make(map[string]string)
+	for pair := range strings.SplitSeq(value, ",") {
+		name, labelValue, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		labels[name] = strings.TrimSpace(labelValue)
+	}
+	return labels
+}
+
 // +kubebuilder:object:root=true
 
 // ClusterResourceQuotaList contains a list of ClusterResourceQuota.
@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuotaControllerConfigSpec holds controller behavior that can change without a restart.
+// Unset fields fall back to the controller's command-line flags.
+type QuotaControllerConfigSpec struct {
+	// ExcludeNamespaceLabelKey is the label key that marks namespaces the controller ignores.
+	// Overrides --exclude-namespace-label-key.
+	// +optional
+	ExcludeNamespaceLabelKey *string `json:"excludeNamespaceLabelKey,omitempty"`
+
+	// ExcludedNamespaces lists namespaces the controller ignores.
+	// Overrides --excluded-namespaces; an empty list excludes none.
+	// +optional
+	ExcludedNamespaces []string `json:"excludedNamespaces"`
+
+	// WatchKinds restricts the resource kinds the controller watches, or is ["auto"] to derive
+	// them from ClusterResourceQuota hard keys. Overrides --watch-kinds.
+	// +optional
+	WatchKinds []string `json:"watchKinds,omitempty"`
+
+	// WebhookFailurePolicy is applied to every webhook of the controller's
+	// ValidatingWebhookConfiguration. Unset leaves the installed policy untouched.
+	// +kubebuilder:validation:Enum=Ignore;Fail
+	// +optional
+	WebhookFailurePolicy *admissionregistrationv1.FailurePolicyType `json:"webhookFailurePolicy,omitempty"`
+
+	// OwnerPolicies change how pods are charged by the kind of their controller owner, for
+	// operators whose pods have unusual shapes, such as no requests or many tiny containers.
+	// The controller and the pod webhook both apply them. The first policy matching a pod's
+	// owner applies; invalid policies are ignored and reported in the Ready condition.
+	// +optional
+	OwnerPolicies []OwnerPodPolicy `json:"ownerPolicies,omitempty"`
+}
+
+// OwnerPodPolicy charges the pods whose controller owner is of one kind.
+type OwnerPodPolicy struct {
+	// Kind of the pods' controller owner reference, such as SparkApplication.
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// APIGroup of the owner, such as sparkoperator.k8s.io. Empty matches the kind in any group.
+	// +optional
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// ChargeFactor multiplies the requests and limits of every container, so pods of the kind
+	// are charged less (below 1) or more (above 1) than they ask for. It must be positive.
+	// +optional
+	ChargeFactor *resource.Quantity `json:"chargeFactor,omitempty"`
+
+	// ContainerMinimum is the least each container is charged, keyed by quota resource name,
+	// such as 'requests.cpu': '50m'. A container asking for less, or nothing, is charged the
+	// minimum instead.
+	// +optional
+	ContainerMinimum ResourceList `json:"containerMinimum,omitempty"`
+}
+
+// QuotaControllerConfigStatus defines the observed state of QuotaControllerConfig.
+type QuotaControllerConfigStatus struct {
+	// ObservedGeneration is the spec generation the controller last applied.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions report whether the spec was applied. The Ready condition is
+	// False with reason InvalidSpec when part of the spec was rejected.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=qcc
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// QuotaControllerConfig is the Schema for the quotacontrollerconfigs API.
+// The controller reads the object named by --controller-config-name and applies
+// changes to it without a restart, so controller behavior can be managed through GitOps.
+type QuotaControllerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   QuotaControllerConfigSpec   `json:"spec"`
+	Status QuotaControllerConfigStatus `json:"status"`
+}
+
+// +kubebuilder:object:root=true
+
+// QuotaControllerConfigList contains a list of QuotaControllerConfig.
+type QuotaControllerConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []QuotaControllerConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&QuotaControllerConfig{}, &QuotaControllerConfigList{})
+}
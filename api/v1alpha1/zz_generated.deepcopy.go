@@ -3,11 +3,29 @@
 package v1alpha1
 
 import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionDenial) DeepCopyInto(out *AdmissionDenial) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Requested = in.Requested.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionDenial.
+func (in *AdmissionDenial) DeepCopy() *AdmissionDenial {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionDenial)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterResourceQuota) DeepCopyInto(out *ClusterResourceQuota) {
 	*out = *in
@@ -82,6 +100,11 @@ func (in *ClusterResourceQuotaSpec) DeepCopyInto(out *ClusterResourceQuotaSpec)
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExcludeNamespaceSelector != nil {
+		in, out := &in.ExcludeNamespaceSelector, &out.ExcludeNamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ScopeSelector != nil {
 		in, out := &in.ScopeSelector, &out.ScopeSelector
 		*out = new(corev1.ScopeSelector)
@@ -92,6 +115,92 @@ func (in *ClusterResourceQuotaSpec) DeepCopyInto(out *ClusterResourceQuotaSpec)
 		*out = make([]corev1.ResourceQuotaScope, len(*in))
 		copy(*out, *in)
 	}
+	if in.Reserved != nil {
+		in, out := &in.Reserved, &out.Reserved
+		*out = make(map[string]ResourceList, len(*in))
+		for key, val := range *in {
+			var outVal ResourceList
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(ResourceList, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val.DeepCopy()
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.MissingRequests != nil {
+		in, out := &in.MissingRequests, &out.MissingRequests
+		*out = new(MissingRequestsPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EphemeralContainers != nil {
+		in, out := &in.EphemeralContainers, &out.EphemeralContainers
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.UsageWeights != nil {
+		in, out := &in.UsageWeights, &out.UsageWeights
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.CreationRates != nil {
+		in, out := &in.CreationRates, &out.CreationRates
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = new(FederationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologyHard != nil {
+		in, out := &in.TopologyHard, &out.TopologyHard
+		*out = make(map[string]ResourceList, len(*in))
+		for key, val := range *in {
+			var outVal ResourceList
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(ResourceList, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val.DeepCopy()
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NodeSelectorTerms != nil {
+		in, out := &in.NodeSelectorTerms, &out.NodeSelectorTerms
+		*out = make([]corev1.NodeSelectorTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnforcementPolicy != nil {
+		in, out := &in.EnforcementPolicy, &out.EnforcementPolicy
+		*out = make(map[corev1.ResourceName]EnforcementAction, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HardLimitsFrom != nil {
+		in, out := &in.HardLimitsFrom, &out.HardLimitsFrom
+		*out = new(HardLimitsSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResourceQuotaSpec.
@@ -115,6 +224,49 @@ func (in *ClusterResourceQuotaStatus) DeepCopyInto(out *ClusterResourceQuotaStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = new(FederationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = make([]TopologyUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Forecasts != nil {
+		in, out := &in.Forecasts, &out.Forecasts
+		*out = make([]UsageForecast, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastDenied != nil {
+		in, out := &in.LastDenied, &out.LastDenied
+		*out = new(AdmissionDenial)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingDemand != nil {
+		in, out := &in.PendingDemand, &out.PendingDemand
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.IncompleteResources != nil {
+		in, out := &in.IncompleteResources, &out.IncompleteResources
+		*out = make([]corev1.ResourceName, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResourceQuotaStatus.
@@ -127,6 +279,296 @@ func (in *ClusterResourceQuotaStatus) DeepCopy() *ClusterResourceQuotaStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterUsage) DeepCopyInto(out *ClusterUsage) {
+	*out = *in
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	in.LastReportTime.DeepCopyInto(&out.LastReportTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterUsage.
+func (in *ClusterUsage) DeepCopy() *ClusterUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationSpec) DeepCopyInto(out *FederationSpec) {
+	*out = *in
+	if in.Slices != nil {
+		in, out := &in.Slices, &out.Slices
+		*out = make(map[string]ResourceList, len(*in))
+		for key, val := range *in {
+			var outVal ResourceList
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make(ResourceList, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val.DeepCopy()
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.ReportTTL != nil {
+		in, out := &in.ReportTTL, &out.ReportTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationSpec.
+func (in *FederationSpec) DeepCopy() *FederationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationStatus) DeepCopyInto(out *FederationStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationStatus.
+func (in *FederationStatus) DeepCopy() *FederationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardLimitItem) DeepCopyInto(out *HardLimitItem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardLimitItem.
+func (in *HardLimitItem) DeepCopy() *HardLimitItem {
+	if in == nil {
+		return nil
+	}
+	out := new(HardLimitItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardLimitsSource) DeepCopyInto(out *HardLimitsSource) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HardLimitItem, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardLimitsSource.
+func (in *HardLimitsSource) DeepCopy() *HardLimitsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(HardLimitsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissingRequestsPolicy) DeepCopyInto(out *MissingRequestsPolicy) {
+	*out = *in
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissingRequestsPolicy.
+func (in *MissingRequestsPolicy) DeepCopy() *MissingRequestsPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MissingRequestsPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnerPodPolicy) DeepCopyInto(out *OwnerPodPolicy) {
+	*out = *in
+	if in.ChargeFactor != nil {
+		in, out := &in.ChargeFactor, &out.ChargeFactor
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ContainerMinimum != nil {
+		in, out := &in.ContainerMinimum, &out.ContainerMinimum
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnerPodPolicy.
+func (in *OwnerPodPolicy) DeepCopy() *OwnerPodPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnerPodPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaControllerConfig) DeepCopyInto(out *QuotaControllerConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaControllerConfig.
+func (in *QuotaControllerConfig) DeepCopy() *QuotaControllerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaControllerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaControllerConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaControllerConfigList) DeepCopyInto(out *QuotaControllerConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]QuotaControllerConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaControllerConfigList.
+func (in *QuotaControllerConfigList) DeepCopy() *QuotaControllerConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaControllerConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaControllerConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaControllerConfigSpec) DeepCopyInto(out *QuotaControllerConfigSpec) {
+	*out = *in
+	if in.ExcludeNamespaceLabelKey != nil {
+		in, out := &in.ExcludeNamespaceLabelKey, &out.ExcludeNamespaceLabelKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExcludedNamespaces != nil {
+		in, out := &in.ExcludedNamespaces, &out.ExcludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WatchKinds != nil {
+		in, out := &in.WatchKinds, &out.WatchKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WebhookFailurePolicy != nil {
+		in, out := &in.WebhookFailurePolicy, &out.WebhookFailurePolicy
+		*out = new(admissionregistrationv1.FailurePolicyType)
+		**out = **in
+	}
+	if in.OwnerPolicies != nil {
+		in, out := &in.OwnerPolicies, &out.OwnerPolicies
+		*out = make([]OwnerPodPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaControllerConfigSpec.
+func (in *QuotaControllerConfigSpec) DeepCopy() *QuotaControllerConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaControllerConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaControllerConfigStatus) DeepCopyInto(out *QuotaControllerConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaControllerConfigStatus.
+func (in *QuotaControllerConfigStatus) DeepCopy() *QuotaControllerConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaControllerConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in ResourceList) DeepCopyInto(out *ResourceList) {
 	{
@@ -165,6 +607,13 @@ func (in *ResourceQuotaStatus) DeepCopyInto(out *ResourceQuotaStatus) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.Reserved != nil {
+		in, out := &in.Reserved, &out.Reserved
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuotaStatus.
@@ -192,3 +641,41 @@ func (in *ResourceQuotaStatusByNamespace) DeepCopy() *ResourceQuotaStatusByNames
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyUsage) DeepCopyInto(out *TopologyUsage) {
+	*out = *in
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyUsage.
+func (in *TopologyUsage) DeepCopy() *TopologyUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageForecast) DeepCopyInto(out *UsageForecast) {
+	*out = *in
+	in.ExhaustionTime.DeepCopyInto(&out.ExhaustionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageForecast.
+func (in *UsageForecast) DeepCopy() *UsageForecast {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageForecast)
+	in.DeepCopyInto(out)
+	return out
+}
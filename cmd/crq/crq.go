@@ -0,0 +1,98 @@
+package crq
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/powerhome/pac-quota-controller/pkg/admin"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+// NewCRQCmd returns the parent command for operator actions on
+// ClusterResourceQuotas served by a running controller.
+func NewCRQCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crq",
+		Short: "Act on the ClusterResourceQuotas of a running controller",
+	}
+	cmd.AddCommand(newResyncCmd())
+	cmd.AddCommand(newTopCmd())
+	return cmd
+}
+
+// newResyncCmd calls POST /admin/reconcile-all on the webhook server, making
+// the controller recalculate every ClusterResourceQuota, e.g. after a backup
+// restore. It authenticates with the token in --admin-token-file.
+func newResyncCmd() *cobra.Command {
+	var (
+		server   string
+		caFile   string
+		insecure bool
+	)
+	cmd := &cobra.Command{
+		Use:   "resync",
+		Short: "Make the controller recalculate every ClusterResourceQuota",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.InitConfig()
+			if cfg.AdminTokenFile == "" {
+				return errors.New("--admin-token-file is required")
+			}
+			data, err := os.ReadFile(cfg.AdminTokenFile)
+			if err != nil {
+				return fmt.Errorf("failed to read admin token: %w", err)
+			}
+			httpClient, err := newHTTPClient(caFile, insecure)
+			if err != nil {
+				return err
+			}
+			return Run(cmd.Context(), httpClient, server, strings.TrimSpace(string(data)), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&server, "server", "https://localhost:9443",
+		"URL of the controller's webhook server, e.g. through kubectl port-forward")
+	cmd.Flags().StringVar(&caFile, "ca-file", "", "CA bundle verifying the webhook server's certificate")
+	cmd.Flags().BoolVar(&insecure, "insecure-skip-tls-verify", false,
+		"Do not verify the webhook server's certificate")
+	return cmd
+}
+
+func newHTTPClient(caFile string, insecure bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// Run requests a resync of every ClusterResourceQuota from server and reports
+// how many were requested to out.
+func Run(ctx context.Context, httpClient *http.Client, server, token string, out io.Writer) error {
+	resp, err := admin.ReconcileAll(ctx, httpClient, server, token)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "Requested a resync of %d ClusterResourceQuotas\n", resp.Requested)
+	return nil
+}
@@ -0,0 +1,65 @@
+package crq
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/powerhome/pac-quota-controller/pkg/admin"
+)
+
+func TestNewCRQCmd(t *testing.T) {
+	cmd := NewCRQCmd()
+	if cmd.Name() != "crq" {
+		t.Errorf("unexpected name %q", cmd.Name())
+	}
+	sub, _, err := cmd.Find([]string{"resync"})
+	if err != nil || sub.Name() != "resync" {
+		t.Fatalf("resync subcommand not registered: %v", err)
+	}
+	for _, flag := range []string{"server", "ca-file", "insecure-skip-tls-verify"} {
+		if sub.Flags().Lookup(flag) == nil {
+			t.Errorf("--%s flag not registered", flag)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != admin.ReconcileAllPath {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"requested":3}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), srv.Client(), srv.URL, "s3cret", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer s3cret" {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+	if !strings.Contains(out.String(), "Requested a resync of 3 ClusterResourceQuotas") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunReportsServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"valid bearer token required"}`))
+	}))
+	defer srv.Close()
+
+	err := Run(context.Background(), srv.Client(), srv.URL, "wrong", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "valid bearer token required") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package crq
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/manager"
+)
+
+// clearScreen moves the cursor home and clears the terminal, so each
+// refresh of crq top --watch replaces the previous table.
+const clearScreen = "\033[H\033[2J"
+
+// newTopCmd prints the percent of each hard limit used by every
+// ClusterResourceQuota, or the named ones. With --watch it redraws the table
+// whenever a quota's status changes, until interrupted.
+func newTopCmd() *cobra.Command {
+	var watchQuotas bool
+	cmd := &cobra.Command{
+		Use:   "top [clusterresourcequota...]",
+		Short: "Show the percent of each ClusterResourceQuota limit in use",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.InitConfig()
+			restConfig, err := manager.RESTConfig(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to load kubeconfig: %w", err)
+			}
+			c, err := client.NewWithWatch(restConfig, client.Options{Scheme: manager.InitScheme()})
+			if err != nil {
+				return fmt.Errorf("failed to create client: %w", err)
+			}
+			return Top(cmd.Context(), c, args, watchQuotas, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVarP(&watchQuotas, "watch", "w", false,
+		"Redraw the table whenever a ClusterResourceQuota's usage changes")
+	return cmd
+}
+
+// Top writes the usage of the ClusterResourceQuotas in names, or of all of
+// them when names is empty, to out. With watch it keeps watching the quotas,
+// clearing the terminal and redrawing the table on every change, and returns
+// nil once ctx is done.
+func Top(ctx context.Context, c client.WithWatch, names []string, watchQuotas bool, out io.Writer) error {
+	for {
+		list := &quotav1alpha1.ClusterResourceQuotaList{}
+		if err := c.List(ctx, list); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list ClusterResourceQuotas: %w", err)
+		}
+		quotas := map[string]quotav1alpha1.ClusterResourceQuota{}
+		for _, crq := range list.Items {
+			quotas[crq.Name] = crq
+		}
+		if !watchQuotas {
+			return WriteTop(out, selectQuotas(quotas, names))
+		}
+
+		w, err := c.Watch(ctx, &quotav1alpha1.ClusterResourceQuotaList{},
+			&client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: list.ResourceVersion}})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to watch ClusterResourceQuotas: %w", err)
+		}
+		err = follow(ctx, w, quotas, func() error {
+			_, _ = fmt.Fprintf(out, "%s%s\n\n", clearScreen, time.Now().Format(time.TimeOnly))
+			return WriteTop(out, selectQuotas(quotas, names))
+		})
+		w.Stop()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// The API server closes watches after a while; list again and resume.
+	}
+}
+
+// follow applies the events of w to quotas, calling redraw first and after
+// every change, until w closes or ctx is done.
+func follow(ctx context.Context, w watch.Interface, quotas map[string]quotav1alpha1.ClusterResourceQuota,
+	redraw func() error) error {
+	if err := redraw(); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type == watch.Error {
+				// Usually an expired resource version; list again.
+				return nil
+			}
+			crq, ok := event.Object.(*quotav1alpha1.ClusterResourceQuota)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				quotas[crq.Name] = *crq
+			case watch.Deleted:
+				delete(quotas, crq.Name)
+			default:
+				continue
+			}
+			if err := redraw(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func selectQuotas(quotas map[string]quotav1alpha1.ClusterResourceQuota,
+	names []string) []quotav1alpha1.ClusterResourceQuota {
+	selected := make([]quotav1alpha1.ClusterResourceQuota, 0, len(quotas))
+	for name, crq := range quotas {
+		if len(names) == 0 || slices.Contains(names, name) {
+			selected = append(selected, crq)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+// WriteTop writes one row per hard limit of each quota, sorted by quota and
+// resource name, with its used amount and the percent of the limit in use.
+func WriteTop(out io.Writer, quotas []quotav1alpha1.ClusterResourceQuota) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	_, _ = io.WriteString(tw, "NAME\tRESOURCE\tUSED\tHARD\t%USED\n")
+	for _, crq := range quotas {
+		hard := crq.Status.Total.Hard
+		resources := make([]string, 0, len(hard))
+		for name := range hard {
+			resources = append(resources, string(name))
+		}
+		sort.Strings(resources)
+		for _, name := range resources {
+			limit := hard[corev1.ResourceName(name)]
+			used := crq.Status.Total.Used[corev1.ResourceName(name)]
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				crq.Name, name, used.String(), limit.String(), percentUsed(used.AsApproximateFloat64(),
+					limit.AsApproximateFloat64()))
+		}
+	}
+	return tw.Flush()
+}
+
+// percentUsed formats used as a percent of hard. A zero limit is either
+// untouched or exceeded outright.
+func percentUsed(used, hard float64) string {
+	if hard == 0 {
+		if used > 0 {
+			return "exceeded"
+		}
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", used/hard*100)
+}
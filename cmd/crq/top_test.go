@@ -0,0 +1,132 @@
+package crq
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/manager"
+)
+
+func topQuota(name, used, hard string) *quotav1alpha1.ClusterResourceQuota {
+	return &quotav1alpha1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: quotav1alpha1.ClusterResourceQuotaStatus{Total: quotav1alpha1.ResourceQuotaStatus{
+			Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse(hard)},
+			Used: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse(used)},
+		}},
+	}
+}
+
+// syncBuffer lets the test read what a watching Top has written so far.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTopCmdRegistered(t *testing.T) {
+	sub, _, err := NewCRQCmd().Find([]string{"top"})
+	if err != nil || sub.Name() != "top" {
+		t.Fatalf("top subcommand not registered: %v", err)
+	}
+	if sub.Flags().Lookup("watch") == nil {
+		t.Error("--watch flag not registered")
+	}
+}
+
+func TestWriteTop(t *testing.T) {
+	crq := topQuota("team-a", "1500m", "2")
+	crq.Status.Total.Hard[corev1.ResourcePods] = resource.MustParse("0")
+	crq.Status.Total.Used[corev1.ResourcePods] = resource.MustParse("1")
+	crq.Status.Total.Hard[corev1.ResourceServices] = resource.MustParse("4")
+
+	var out bytes.Buffer
+	if err := WriteTop(&out, []quotav1alpha1.ClusterResourceQuota{*crq}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := [][]string{
+		{"NAME", "RESOURCE", "USED", "HARD", "%USED"},
+		{"team-a", "pods", "1", "0", "exceeded"},
+		{"team-a", "requests.cpu", "1500m", "2", "75%"},
+		{"team-a", "services", "0", "4", "0%"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	for i, line := range lines {
+		if got := strings.Fields(line); strings.Join(got, " ") != strings.Join(want[i], " ") {
+			t.Errorf("line %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestTopFiltersByName(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(manager.InitScheme()).
+		WithObjects(topQuota("team-a", "1", "2"), topQuota("team-b", "1", "4")).Build()
+
+	var out bytes.Buffer
+	if err := Top(context.Background(), c, []string{"team-b"}, false, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "team-a") || !strings.Contains(out.String(), "25%") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestTopWatchRedrawsOnChange(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(manager.InitScheme()).
+		WithObjects(topQuota("team-a", "1", "2")).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() { done <- Top(ctx, c, nil, true, out) }()
+
+	waitFor := func(s string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), s) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q in %q", s, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("50%")
+
+	if err := c.Create(ctx, topQuota("team-b", "3", "4")); err != nil {
+		t.Fatalf("failed to create quota: %v", err)
+	}
+	waitFor("75%")
+	if !strings.Contains(out.String(), clearScreen) {
+		t.Error("expected the watch to clear the screen between tables")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -12,12 +12,18 @@ import (
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
-	zapctrl "sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/powerhome/pac-quota-controller/cmd/crq"
+	"github.com/powerhome/pac-quota-controller/cmd/migrate"
+	"github.com/powerhome/pac-quota-controller/cmd/verify"
 	"github.com/powerhome/pac-quota-controller/cmd/version"
+	"github.com/powerhome/pac-quota-controller/internal/controller"
 	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
 	"github.com/powerhome/pac-quota-controller/pkg/manager"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+	"github.com/powerhome/pac-quota-controller/pkg/simulate"
 	"github.com/powerhome/pac-quota-controller/pkg/webhook"
 )
 
@@ -28,7 +34,7 @@ func main() {
 	}
 }
 
-// newRootCommand builds the controller-manager command tree (root + version),
+// newRootCommand builds the controller-manager command tree (root + version + verify + migrate),
 // wiring flags. Running the root with no subcommand starts the manager.
 func newRootCommand() *cobra.Command {
 	rootCmd := &cobra.Command{
@@ -40,6 +46,9 @@ func newRootCommand() *cobra.Command {
 		},
 	}
 	rootCmd.AddCommand(version.NewVersionCmd())
+	rootCmd.AddCommand(verify.NewVerifyCmd())
+	rootCmd.AddCommand(migrate.NewMigrateCmd())
+	rootCmd.AddCommand(crq.NewCRQCmd())
 	config.SetupFlags(rootCmd)
 	return rootCmd
 }
@@ -49,6 +58,7 @@ func newRootCommand() *cobra.Command {
 // nolint:gocyclo
 func runManager() {
 	cfg := config.InitConfig()
+	metrics.SetBuildInfo(version.Version, version.Commit)
 
 	pkglogger.Initialize(cfg)
 	logger := pkglogger.L()
@@ -64,7 +74,11 @@ func runManager() {
 		os.Exit(1)
 	}
 
-	ctrl.SetLogger(zapctrl.New(zapctrl.UseDevMode(false), zapctrl.JSONEncoder()))
+	if err := cfg.Validate(); err != nil {
+		logger.Error("invalid configuration", zap.Error(err))
+		fatal()
+	}
+	metrics.SetCRQLabelNames(cfg.MetricsCRQLabels)
 
 	// Use controller-runtime's signal handler — cancels context on SIGTERM/SIGINT
 	ctx := ctrl.SetupSignalHandler()
@@ -77,18 +91,27 @@ func runManager() {
 		fatal()
 	}
 
-	if err := manager.SetupControllers(ctx, mgr, cfg, logger); err != nil {
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		logger.Error("unable to create kubernetes clientset", zap.Error(err))
+		fatal()
+	}
+
+	crds := quota.NewCRDAvailability(clientset.Discovery(), logger)
+	if err := manager.SetupControllersWhenServed(ctx, mgr, cfg, crds, logger); err != nil {
 		logger.Error("unable to set up controllers", zap.Error(err))
 		fatal()
 	}
 
-	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	webhookServer, webhookCertWatcher, err := webhook.SetupGinWebhookServer(cfg, clientset, mgr.GetClient(), logger)
 	if err != nil {
-		logger.Error("unable to create kubernetes clientset", zap.Error(err))
+		logger.Error("unable to set up webhook server", zap.Error(err))
 		fatal()
 	}
-
-	webhookServer, webhookCertWatcher := webhook.SetupGinWebhookServer(cfg, clientset, mgr.GetClient(), logger)
+	if cfg.SimulationAPIEnable {
+		quotas := controller.NewSimulationQuotas(mgr.GetClient(), cfg, logger)
+		webhookServer.EnableSimulation(simulate.NewNamespaceMoveSimulator(mgr.GetClient(), quotas))
+	}
 
 	// Start webhook server and cert watcher in background goroutines.
 	// They respect context cancellation via <-ctx.Done() for graceful shutdown.
@@ -107,11 +130,13 @@ func runManager() {
 	}
 
 	// Flip the webhook's cache-sync readiness gate once the manager's
-	// informer cache has finished initial sync. Until then /readyz
-	// returns 503 so the apiserver does not route admission traffic to
-	// a webhook whose CRQ lookups would hit a cold cache.
+	// informer cache has finished initial sync and the usage of quota-selected
+	// namespaces is preloaded. Until then /readyz returns 503 so the apiserver
+	// does not route admission traffic to a webhook whose CRQ lookups would
+	// hit a cold cache.
 	go func() {
 		if mgr.GetCache().WaitForCacheSync(ctx) {
+			webhookServer.WarmCache(ctx)
 			webhookServer.MarkCacheSynced()
 		} else {
 			logger.Error("informer cache failed to sync; webhook /readyz will stay 503")
@@ -9,23 +9,48 @@ func TestNewRootCommand(t *testing.T) {
 		t.Errorf("unexpected Use %q", cmd.Use)
 	}
 
-	hasVersion := false
+	hasVersion, hasVerify, hasMigrate := false, false, false
 	for _, sub := range cmd.Commands() {
-		if sub.Name() == "version" {
+		switch sub.Name() {
+		case "version":
 			hasVersion = true
+		case "verify":
+			hasVerify = true
+		case "migrate":
+			hasMigrate = true
 		}
 	}
 	if !hasVersion {
 		t.Error("version subcommand not registered")
 	}
+	if !hasVerify {
+		t.Error("verify subcommand not registered")
+	}
+	if !hasMigrate {
+		t.Error("migrate subcommand not registered")
+	}
 
 	for _, flag := range []string{"leader-elect", "log-level", "webhook-port", "events-enable"} {
-		if cmd.Flags().Lookup(flag) == nil {
+		if cmd.PersistentFlags().Lookup(flag) == nil {
 			t.Errorf("flag %q not registered", flag)
 		}
 	}
 }
 
+// The verify subcommand must honor the manager's namespace exclusions.
+func TestSubcommandsInheritManagerFlags(t *testing.T) {
+	cmd := newRootCommand()
+	verifyCmd, _, err := cmd.Find([]string{"verify"})
+	if err != nil {
+		t.Fatalf("verify subcommand not found: %v", err)
+	}
+	for _, flag := range []string{"excluded-namespaces", "exclude-namespace-label-key", "kube-api-qps"} {
+		if verifyCmd.InheritedFlags().Lookup(flag) == nil {
+			t.Errorf("verify does not accept --%s", flag)
+		}
+	}
+}
+
 // Executing the version subcommand exercises the command wiring without starting
 // the manager (which would require a real cluster).
 func TestRootCommandRunsVersionSubcommand(t *testing.T) {
@@ -0,0 +1,315 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/manager"
+)
+
+// OpenShift quotas are read as unstructured so the importer does not depend
+// on the OpenShift API module.
+var (
+	openShiftCRQGroupKind = schema.GroupKind{Group: "quota.openshift.io", Kind: "ClusterResourceQuota"}
+	openShiftCRQListGVK   = schema.GroupVersionKind{Group: "quota.openshift.io", Version: "v1", Kind: "ClusterResourceQuotaList"}
+)
+
+// NewMigrateCmd returns the parent command for one-off migrations into
+// pac-quota-controller resources.
+func NewMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Convert quota objects from other systems into ClusterResourceQuotas",
+	}
+	cmd.AddCommand(newFromOpenShiftCmd())
+	return cmd
+}
+
+// newFromOpenShiftCmd converts quota.openshift.io/v1 ClusterResourceQuotas,
+// read from a file or the live cluster, and prints the equivalent
+// quota.powerapp.cloud/v1alpha1 objects as YAML. It never writes to the
+// cluster; review the output and apply it with kubectl.
+func newFromOpenShiftCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "from-openshift",
+		Short: "Print quota.powerapp.cloud ClusterResourceQuotas equivalent to OpenShift ones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sources []unstructured.Unstructured
+			var err error
+			if file != "" {
+				sources, err = readFile(file)
+			} else {
+				sources, err = readCluster(cmd.Context())
+			}
+			if err != nil {
+				return err
+			}
+			return Run(sources, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "",
+		"Read OpenShift ClusterResourceQuotas from a YAML or JSON file ('-' for stdin) instead of the cluster")
+	return cmd
+}
+
+func readCluster(ctx context.Context) ([]unstructured.Unstructured, error) {
+	cfg := config.InitConfig()
+	restConfig, err := manager.RESTConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: manager.InitScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(openShiftCRQListGVK)
+	if err := c.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list OpenShift ClusterResourceQuotas: %w", err)
+	}
+	return list.Items, nil
+}
+
+func readFile(path string) ([]unstructured.Unstructured, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	return decode(r)
+}
+
+// decode reads a multi-document YAML or JSON stream, flattening List kinds
+// (as produced by `oc get clusterresourcequota -o yaml`).
+func decode(r io.Reader) ([]unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 4096)
+	var out []unstructured.Unstructured
+	for {
+		obj := unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return out, nil
+			}
+			return nil, fmt.Errorf("failed to decode input: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.IsList() {
+			if err := obj.EachListItem(func(item runtime.Object) error {
+				out = append(out, *item.(*unstructured.Unstructured))
+				return nil
+			}); err != nil {
+				return nil, fmt.Errorf("failed to decode list: %w", err)
+			}
+			continue
+		}
+		out = append(out, obj)
+	}
+}
+
+// Run converts every OpenShift ClusterResourceQuota in sources and writes the
+// results to out as a multi-document YAML stream. Warnings about anything that
+// could not be carried over exactly go to warnOut. It returns an error if any
+// object could not be converted, after converting the rest.
+func Run(sources []unstructured.Unstructured, out, warnOut io.Writer) error {
+	var failed []string
+	converted := 0
+	for i := range sources {
+		src := &sources[i]
+		if src.GroupVersionKind().GroupKind() != openShiftCRQGroupKind {
+			_, _ = fmt.Fprintf(warnOut, "skipping %s %s: not a quota.openshift.io ClusterResourceQuota\n", src.GetKind(), src.GetName())
+			continue
+		}
+		crq, warnings, err := Convert(src)
+		for _, w := range warnings {
+			_, _ = fmt.Fprintf(warnOut, "%s: %s\n", src.GetName(), w)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(warnOut, "%s: %v\n", src.GetName(), err)
+			failed = append(failed, src.GetName())
+			continue
+		}
+		data, err := marshal(crq)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "---\n%s", data)
+		converted++
+	}
+	if converted > 1 {
+		_, _ = fmt.Fprintln(warnOut, overlapWarning)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to convert ClusterResourceQuotas: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// overlapWarning is printed when several quotas are converted. OpenShift lets
+// a namespace fall under many ClusterResourceQuotas; this controller does not.
+const overlapWarning = "warning: a namespace may be selected by only one ClusterResourceQuota here, " +
+	"unlike OpenShift; the webhook rejects quotas whose selections overlap, so check the converted selectors first"
+
+// openShiftCRQSpec mirrors the parts of quota.openshift.io/v1
+// ClusterResourceQuotaSpec the importer reads.
+type openShiftCRQSpec struct {
+	Selector struct {
+		Labels      *metav1.LabelSelector `json:"labels,omitempty"`
+		Annotations map[string]string     `json:"annotations,omitempty"`
+	} `json:"selector"`
+	Quota corev1.ResourceQuotaSpec `json:"quota"`
+}
+
+// Convert maps one OpenShift ClusterResourceQuota onto a ClusterResourceQuota.
+// Label selectors carry over unchanged. Annotation selectors have no
+// equivalent, so each annotation becomes a matchLabels entry and a warning:
+// namespaces must be labelled before the converted quota selects them.
+// Hard keys are renamed to their supported form and unsupported ones dropped.
+// Scoped quotas are refused, since scopes are not enforced.
+func Convert(src *unstructured.Unstructured) (*quotav1alpha1.ClusterResourceQuota, []string, error) {
+	rawSpec, _, err := unstructured.NestedMap(src.Object, "spec")
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed spec: %w", err)
+	}
+	var spec openShiftCRQSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return nil, nil, fmt.Errorf("malformed spec: %w", err)
+	}
+
+	// Scoped quotas would silently turn into quotas on every pod: this
+	// controller does not enforce scopes, so they are refused outright.
+	if len(spec.Quota.Scopes) > 0 || spec.Quota.ScopeSelector != nil {
+		return nil, nil, fmt.Errorf("scoped quotas cannot be converted: scopes and scopeSelector are not enforced " +
+			"by this controller; split the quota or drop its scopes in the source first")
+	}
+
+	var warnings []string
+	selector := spec.Selector.Labels.DeepCopy()
+	if selector == nil {
+		selector = &metav1.LabelSelector{}
+	}
+	for _, key := range slices.Sorted(maps.Keys(spec.Selector.Annotations)) {
+		value := spec.Selector.Annotations[key]
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, warnings, fmt.Errorf("annotation selector key %q cannot be used as a label: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, warnings, fmt.Errorf("annotation selector value %q cannot be used as a label: %s", value, strings.Join(errs, "; "))
+		}
+		if existing, ok := selector.MatchLabels[key]; ok && existing != value {
+			return nil, warnings, fmt.Errorf("annotation selector %s=%s conflicts with label selector %s=%s", key, value, key, existing)
+		}
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = map[string]string{}
+		}
+		selector.MatchLabels[key] = value
+		warnings = append(warnings, fmt.Sprintf(
+			"annotation selector %s=%s converted to a label selector; label the matching namespaces before applying", key, value))
+	}
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		warnings = append(warnings, "empty selector: the converted quota selects every namespace")
+	}
+
+	hard := quotav1alpha1.ResourceList{}
+	for _, name := range slices.Sorted(maps.Keys(spec.Quota.Hard)) {
+		mapped, ok := convertHardKey(name)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("hard key %s is not supported and was dropped", name))
+			continue
+		}
+		if mapped != name {
+			warnings = append(warnings, fmt.Sprintf("hard key %s renamed to %s", name, mapped))
+		}
+		if _, dup := hard[mapped]; dup {
+			return nil, warnings, fmt.Errorf("hard keys map to %s more than once", mapped)
+		}
+		hard[mapped] = spec.Quota.Hard[name]
+	}
+
+	crq := &quotav1alpha1.ClusterResourceQuota{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: quotav1alpha1.GroupVersion.String(),
+			Kind:       "ClusterResourceQuota",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   src.GetName(),
+			Labels: src.GetLabels(),
+		},
+		Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+			Hard:              hard,
+			NamespaceSelector: selector,
+		},
+	}
+	return crq, warnings, nil
+}
+
+// convertHardKey maps a core ResourceQuota hard key onto the key this
+// controller tracks. Bare compute names mean requests, as in core quota, and
+// count/<resource> object counts drop their prefix.
+func convertHardKey(name corev1.ResourceName) (corev1.ResourceName, bool) {
+	switch name {
+	case corev1.ResourceCPU:
+		name = usage.ResourceRequestsCPU
+	case corev1.ResourceMemory:
+		name = usage.ResourceRequestsMemory
+	case corev1.ResourceEphemeralStorage:
+		name = usage.ResourceRequestsEphemeralStorage
+	}
+	if counted, ok := strings.CutPrefix(string(name), "count/"); ok {
+		name = corev1.ResourceName(counted)
+	}
+
+	switch name {
+	case usage.ResourceRequestsCPU, usage.ResourceLimitsCPU,
+		usage.ResourceRequestsMemory, usage.ResourceLimitsMemory,
+		usage.ResourceRequestsEphemeralStorage, usage.ResourceLimitsEphemeralStorage,
+		usage.ResourceRequestsStorage, usage.ResourcePods, usage.ResourcePersistentVolumeClaims,
+		usage.ResourceServices, usage.ResourceServicesLoadBalancers, usage.ResourceServicesNodePorts:
+		return name, true
+	}
+	if usage.IsClassScoped(name) || usage.IsComputeResource(name) {
+		return name, true
+	}
+	return name, objectcount.Supports(name)
+}
+
+// marshal renders crq as YAML without the server-populated fields an empty
+// object would otherwise carry.
+func marshal(crq *quotav1alpha1.ClusterResourceQuota) ([]byte, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", crq.Name, err)
+	}
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj, "status")
+	return yaml.Marshal(obj)
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+const openShiftQuotas = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: quota.openshift.io/v1
+  kind: ClusterResourceQuota
+  metadata:
+    name: team-alpha
+    labels:
+      owner: platform
+  spec:
+    selector:
+      labels:
+        matchLabels:
+          team: alpha
+      annotations:
+        openshift.io/requester: alice
+    quota:
+      hard:
+        cpu: "10"
+        limits.memory: 20Gi
+        pods: "50"
+        count/deployments.apps: "20"
+        openshift.io/imagestreams: "5"
+  status:
+    total:
+      used:
+        pods: "3"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-a-quota
+`
+
+func TestNewMigrateCmd(t *testing.T) {
+	cmd := NewMigrateCmd()
+	if cmd.Name() != "migrate" {
+		t.Errorf("unexpected name %q", cmd.Name())
+	}
+	sub, _, err := cmd.Find([]string{"from-openshift"})
+	if err != nil || sub.Name() != "from-openshift" {
+		t.Fatalf("from-openshift subcommand not registered: %v", err)
+	}
+	if sub.Flags().Lookup("file") == nil {
+		t.Error("--file flag not registered")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	sources, err := decode(strings.NewReader(openShiftQuotas))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(sources))
+	}
+
+	crq, warnings, err := Convert(&sources[0])
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if crq.Name != "team-alpha" || crq.Labels["owner"] != "platform" {
+		t.Errorf("metadata not carried over: %+v", crq.ObjectMeta)
+	}
+	wantSelector := map[string]string{"team": "alpha", "openshift.io/requester": "alice"}
+	for k, v := range wantSelector {
+		if crq.Spec.NamespaceSelector.MatchLabels[k] != v {
+			t.Errorf("selector %s: want %q, got %q", k, v, crq.Spec.NamespaceSelector.MatchLabels[k])
+		}
+	}
+
+	wantHard := map[corev1.ResourceName]string{
+		usage.ResourceRequestsCPU:  "10",
+		usage.ResourceLimitsMemory: "20Gi",
+		usage.ResourcePods:         "50",
+		usage.ResourceDeployments:  "20",
+	}
+	if len(crq.Spec.Hard) != len(wantHard) {
+		t.Errorf("unexpected hard keys %v", crq.Spec.Hard)
+	}
+	for name, want := range wantHard {
+		got, ok := crq.Spec.Hard[name]
+		if !ok || got.Cmp(resource.MustParse(want)) != 0 {
+			t.Errorf("hard %s: want %s, got %s", name, want, got.String())
+		}
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{
+		"annotation selector openshift.io/requester=alice converted",
+		"hard key cpu renamed to requests.cpu",
+		"hard key count/deployments.apps renamed to deployments.apps",
+		"hard key openshift.io/imagestreams is not supported",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing warning %q in %q", want, joined)
+		}
+	}
+}
+
+func TestConvertRejectsAnnotationsThatAreNotLabels(t *testing.T) {
+	sources, err := decode(strings.NewReader(`
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: bad
+spec:
+  selector:
+    annotations:
+      openshift.io/description: "not a label value"
+  quota:
+    hard:
+      pods: "1"
+`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, _, err := Convert(&sources[0]); err == nil || !strings.Contains(err.Error(), "cannot be used as a label") {
+		t.Errorf("expected label validation error, got %v", err)
+	}
+}
+
+func TestConvertRefusesScopedQuotas(t *testing.T) {
+	sources, err := decode(strings.NewReader(`
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: scoped
+spec:
+  selector:
+    labels:
+      matchLabels:
+        team: alpha
+  quota:
+    hard:
+      pods: "10"
+    scopes:
+    - NotBestEffort
+`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, _, err := Convert(&sources[0]); err == nil || !strings.Contains(err.Error(), "scoped quotas cannot be converted") {
+		t.Errorf("expected scoped quota to be refused, got %v", err)
+	}
+}
+
+func TestRunWarnsAboutOverlappingSelections(t *testing.T) {
+	sources, err := decode(strings.NewReader(openShiftQuotas + `
+---
+apiVersion: quota.openshift.io/v1
+kind: ClusterResourceQuota
+metadata:
+  name: team-beta
+spec:
+  selector:
+    labels:
+      matchLabels:
+        team: beta
+  quota:
+    hard:
+      pods: "10"
+`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var out, warnOut bytes.Buffer
+	if err := Run(sources, &out, &warnOut); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(warnOut.String(), "selected by only one ClusterResourceQuota") {
+		t.Errorf("expected overlap warning, got %q", warnOut.String())
+	}
+}
+
+func TestRun(t *testing.T) {
+	sources, err := decode(strings.NewReader(openShiftQuotas))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var out, warnOut bytes.Buffer
+	if err := Run(sources, &out, &warnOut); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	yaml := out.String()
+	for _, want := range []string{
+		"apiVersion: quota.powerapp.cloud/v1alpha1",
+		"kind: ClusterResourceQuota",
+		"name: team-alpha",
+		"requests.cpu: \"10\"",
+	} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("output missing %q:\n%s", want, yaml)
+		}
+	}
+	for _, unwanted := range []string{"creationTimestamp", "status:", "openshift.io/imagestreams"} {
+		if strings.Contains(yaml, unwanted) {
+			t.Errorf("output must not contain %q:\n%s", unwanted, yaml)
+		}
+	}
+	if !strings.Contains(warnOut.String(), "skipping ConfigMap not-a-quota") {
+		t.Errorf("expected skip warning, got %q", warnOut.String())
+	}
+}
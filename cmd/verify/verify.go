@@ -0,0 +1,67 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/internal/controller"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+	"github.com/powerhome/pac-quota-controller/pkg/manager"
+)
+
+// NewVerifyCmd returns a cobra command that recomputes a ClusterResourceQuota's
+// usage from the live cluster and diffs it against the stored status. It exits
+// non-zero when discrepancies are found, so it can gate e2e runs.
+func NewVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify <clusterresourcequota>",
+		Short: "Diff a ClusterResourceQuota's stored status against recomputed usage",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.InitConfig()
+			pkglogger.Initialize(cfg)
+
+			restConfig, err := manager.RESTConfig(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to load kubeconfig: %w", err)
+			}
+			// A direct (uncached) client so the comparison reflects the API
+			// server, not an informer that may itself be stale.
+			c, err := client.New(restConfig, client.Options{Scheme: manager.InitScheme()})
+			if err != nil {
+				return fmt.Errorf("failed to create client: %w", err)
+			}
+
+			r := controller.NewUsageVerifier(c, cfg, pkglogger.L())
+			return Run(cmd.Context(), r, args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+// usageVerifier is the subset of the reconciler Run needs.
+type usageVerifier interface {
+	VerifyUsage(ctx context.Context, name string) ([]controller.UsageDiscrepancy, error)
+}
+
+// Run verifies the named CRQ and writes a report to out. It returns an error
+// when verification fails or any discrepancy is found.
+func Run(ctx context.Context, v usageVerifier, name string, out io.Writer) error {
+	discrepancies, err := v.VerifyUsage(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(discrepancies) == 0 {
+		_, _ = fmt.Fprintf(out, "ClusterResourceQuota %s: status matches recomputed usage\n", name)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "ClusterResourceQuota %s: %d discrepancies\n", name, len(discrepancies))
+	for _, d := range discrepancies {
+		_, _ = fmt.Fprintf(out, "  %s\n", d)
+	}
+	return fmt.Errorf("ClusterResourceQuota %s status does not match recomputed usage", name)
+}
@@ -0,0 +1,65 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/powerhome/pac-quota-controller/internal/controller"
+)
+
+type fakeVerifier struct {
+	discrepancies []controller.UsageDiscrepancy
+	err           error
+}
+
+func (f fakeVerifier) VerifyUsage(context.Context, string) ([]controller.UsageDiscrepancy, error) {
+	return f.discrepancies, f.err
+}
+
+func TestNewVerifyCmd(t *testing.T) {
+	cmd := NewVerifyCmd()
+	if cmd.Name() != "verify" {
+		t.Errorf("unexpected name %q", cmd.Name())
+	}
+	if err := cmd.Args(cmd, nil); err == nil {
+		t.Error("verify must require a ClusterResourceQuota name")
+	}
+}
+
+func TestRunReportsMatch(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run(context.Background(), fakeVerifier{}, "team-a", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "status matches") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunReportsDiscrepancies(t *testing.T) {
+	var out bytes.Buffer
+	v := fakeVerifier{discrepancies: []controller.UsageDiscrepancy{{
+		Namespace: "ns-a",
+		Resource:  "pods",
+		Stored:    resource.MustParse("2"),
+		Actual:    resource.MustParse("3"),
+	}}}
+	if err := Run(context.Background(), v, "team-a", &out); err == nil {
+		t.Fatal("expected an error when discrepancies are found")
+	}
+	if !strings.Contains(out.String(), "ns-a pods: stored 2, actual 3") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunPropagatesErrors(t *testing.T) {
+	err := Run(context.Background(), fakeVerifier{err: errors.New("boom")}, "team-a", &bytes.Buffer{})
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -15,14 +15,37 @@ var (
 	BuiltBy = "unknown"
 )
 
+// Info is the build information of the running binary, as served on /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	BuiltBy   string `json:"builtBy"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		BuiltBy:   BuiltBy,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
 // PrintInfo prints version information to stdout.
 func PrintInfo() {
-	fmt.Printf("Version:\t%s\n", Version)
-	fmt.Printf("Git Commit:\t%s\n", Commit)
-	fmt.Printf("Build Date:\t%s\n", Date)
-	fmt.Printf("Built By:\t%s\n", BuiltBy)
-	fmt.Printf("Go Version:\t%s\n", runtime.Version())
-	fmt.Printf("Platform:\t%s/%s\n", runtime.GOOS, runtime.GOARCH)
+	info := Get()
+	fmt.Printf("Version:\t%s\n", info.Version)
+	fmt.Printf("Git Commit:\t%s\n", info.Commit)
+	fmt.Printf("Build Date:\t%s\n", info.Date)
+	fmt.Printf("Built By:\t%s\n", info.BuiltBy)
+	fmt.Printf("Go Version:\t%s\n", info.GoVersion)
+	fmt.Printf("Platform:\t%s\n", info.Platform)
 }
 
 // NewVersionCmd returns a cobra command that displays version information.
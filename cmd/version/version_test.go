@@ -16,3 +16,13 @@ func TestPrintInfo(t *testing.T) {
 	// Smoke test: PrintInfo writes build info to stdout and must not panic.
 	PrintInfo()
 }
+
+func TestGet(t *testing.T) {
+	info := Get()
+	if info.Version != Version || info.Commit != Commit {
+		t.Errorf("unexpected build %s/%s", info.Version, info.Commit)
+	}
+	if info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("runtime information missing from %+v", info)
+	}
+}
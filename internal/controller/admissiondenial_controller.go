@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// DenialFieldManager is the server-side apply field manager owning
+// status.lastDenied and status.pendingDemand, apart from StatusFieldManager
+// so that neither reconciler's apply removes the other's fields.
+const DenialFieldManager = "pac-quota-controller-denials"
+
+const (
+	// pendingDemandWindow is how long a denied request keeps counting toward
+	// status.pendingDemand without another denial, fading linearly.
+	pendingDemandWindow = 15 * time.Minute
+	// pendingDemandRefresh is how often status.pendingDemand is recomputed
+	// while it fades.
+	pendingDemandRefresh = time.Minute
+)
+
+// AdmissionDenialReconciler reports in status.lastDenied of each
+// ClusterResourceQuota the latest request the admission webhook denied for
+// exceeding one of its limits, and in status.pendingDemand the demand those
+// denials leave unserved, read from the AdmissionDenied events the webhook
+// records on the quota. Events reach the leader from every webhook replica,
+// which the webhook itself could not coordinate.
+type AdmissionDenialReconciler struct {
+	client.Client
+	logger *zap.Logger
+	// now returns the current time; time.Now when nil.
+	now func() time.Time
+
+	mu sync.Mutex
+	// pending holds, per quota, the latest denial of each namespace and
+	// resource still counting toward status.pendingDemand.
+	pending map[string]map[pendingDemandKey]*quotav1alpha1.AdmissionDenial
+}
+
+// pendingDemandKey identifies the denials of one resource in one namespace.
+type pendingDemandKey struct {
+	namespace string
+	resource  corev1.ResourceName
+}
+
+// Reconcile applies the denial an AdmissionDenied event records to its
+// quota, unless the quota already reports a later one, and refreshes the
+// quota's pending demand until it has faded.
+func (r *AdmissionDenialReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	event := &eventsv1.Event{}
+	if err := r.Get(ctx, req.NamespacedName, event); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	denial, ok := events.ParseAdmissionDenied(event)
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	if err := r.Get(ctx, types.NamespacedName{Name: event.Regarding.Name}, crq); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(event.Regarding.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	denial.Requested = usage.Canonical(denial.Resource, denial.Requested)
+	demand, fading := r.recordDenial(crq.Name, denial)
+	var result ctrl.Result
+	if fading {
+		result.RequeueAfter = pendingDemandRefresh
+	}
+
+	lastDenied := crq.Status.LastDenied
+	if lastDenied == nil || denial.Time.After(lastDenied.Time.Time) {
+		lastDenied = denial
+	} else if equality.Semantic.DeepEqual(demand, crq.Status.PendingDemand) {
+		return result, nil
+	}
+
+	obj, err := denialsApplyConfiguration(crq.Name, lastDenied, demand)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.logger.Debug("Reporting admission denial",
+		zap.String("crq_name", crq.Name),
+		zap.String("namespace", denial.Namespace),
+		zap.String("resource", string(denial.Resource)))
+	return result, r.Status().Apply(ctx, obj, client.FieldOwner(DenialFieldManager), client.ForceOwnership)
+}
+
+// recordDenial keeps denial as the latest of its namespace and resource for
+// the quota named crqName, unless a later one is kept already, and returns
+// the quota's pending demand now and whether any of it is still fading.
+func (r *AdmissionDenialReconciler) recordDenial(
+	crqName string,
+	denial *quotav1alpha1.AdmissionDenial,
+) (quotav1alpha1.ResourceList, bool) {
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]map[pendingDemandKey]*quotav1alpha1.AdmissionDenial)
+	}
+	denials := r.pending[crqName]
+	if denials == nil {
+		denials = make(map[pendingDemandKey]*quotav1alpha1.AdmissionDenial)
+		r.pending[crqName] = denials
+	}
+	key := pendingDemandKey{namespace: denial.Namespace, resource: denial.Resource}
+	if kept, ok := denials[key]; !ok || denial.Time.After(kept.Time.Time) {
+		denials[key] = denial
+	}
+
+	demand := make(quotav1alpha1.ResourceList)
+	for key, kept := range denials {
+		age := max(now.Sub(kept.Time.Time), 0)
+		if age >= pendingDemandWindow {
+			delete(denials, key)
+			continue
+		}
+		remaining := 1 - float64(age)/float64(pendingDemandWindow)
+		milli := int64(math.Ceil(float64(kept.Requested.MilliValue()) * remaining))
+		q := demand[key.resource]
+		q.Add(*resource.NewMilliQuantity(milli, kept.Requested.Format))
+		demand[key.resource] = q
+	}
+	if len(denials) == 0 {
+		delete(r.pending, crqName)
+		return nil, false
+	}
+	for resourceName, q := range demand {
+		demand[resourceName] = usage.Canonical(resourceName, q)
+	}
+	return demand, true
+}
+
+// forget drops the denials kept for the deleted quota named crqName.
+func (r *AdmissionDenialReconciler) forget(crqName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, crqName)
+}
+
+// denialsApplyConfiguration is the apply configuration of a
+// ClusterResourceQuota carrying only its name, status.lastDenied and
+// status.pendingDemand. A nil pendingDemand removes it.
+func denialsApplyConfiguration(
+	name string,
+	denial *quotav1alpha1.AdmissionDenial,
+	pendingDemand quotav1alpha1.ResourceList,
+) (runtime.ApplyConfiguration, error) {
+	lastDenied, err := runtime.DefaultUnstructuredConverter.ToUnstructured(denial)
+	if err != nil {
+		return nil, err
+	}
+	status := map[string]any{"lastDenied": lastDenied}
+	if len(pendingDemand) > 0 {
+		demand := make(map[string]any, len(pendingDemand))
+		for resourceName, q := range pendingDemand {
+			demand[string(resourceName)] = q.String()
+		}
+		status["pendingDemand"] = demand
+	}
+	u := &unstructured.Unstructured{Object: map[string]any{"status": status}}
+	u.SetAPIVersion(quotav1alpha1.GroupVersion.String())
+	u.SetKind("ClusterResourceQuota")
+	u.SetName(name)
+	return client.ApplyConfigurationFromUnstructured(u), nil
+}
+
+// SetupWithManager registers the reconciler for the AdmissionDenied events
+// recorded on ClusterResourceQuotas.
+func (r *AdmissionDenialReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.logger == nil {
+		r.logger = zap.L().Named("admissiondenial-controller")
+	}
+	isDenial := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		event, ok := obj.(*eventsv1.Event)
+		return ok && event.Reason == events.ReasonAdmissionDenied
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&eventsv1.Event{}, builder.WithPredicates(isDenial)).
+		Named("admissiondenial").
+		Complete(r)
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+)
+
+var _ = Describe("AdmissionDenialReconciler", func() {
+	var (
+		ctx = context.Background()
+		c   client.Client
+		r   *AdmissionDenialReconciler
+	)
+	at := func(minute int) time.Time { return time.Date(2026, 3, 1, 12, minute, 0, 0, time.UTC) }
+
+	denialEvent := func(name string, when time.Time, note string) *eventsv1.Event {
+		return &eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: name},
+			Regarding:  corev1.ObjectReference{Kind: "ClusterResourceQuota", Name: "team-a"},
+			Reason:     events.ReasonAdmissionDenied,
+			Note:       note,
+			EventTime:  metav1.NewMicroTime(when),
+		}
+	}
+	reconcile := func(name string) {
+		GinkgoHelper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+			Namespace: metav1.NamespaceDefault, Name: name,
+		}})
+		Expect(err).NotTo(HaveOccurred())
+	}
+	lastDenied := func() *quotav1alpha1.AdmissionDenial {
+		GinkgoHelper()
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "team-a"}, crq)).To(Succeed())
+		return crq.Status.LastDenied
+	}
+
+	BeforeEach(func() {
+		Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(crq,
+				denialEvent("first", at(1), "Denied 2 of requests.cpu in namespace web: over the limit"),
+				denialEvent("second", at(5), "Denied 1 of pods in namespace api: too many pods"),
+			).
+			WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+			Build()
+		r = &AdmissionDenialReconciler{Client: c, logger: zap.NewNop()}
+	})
+
+	It("reports the denial an event records", func() {
+		reconcile("first")
+
+		denial := lastDenied()
+		Expect(denial).NotTo(BeNil())
+		Expect(denial.Time.Time).To(BeTemporally("==", at(1)))
+		Expect(denial.Namespace).To(Equal("web"))
+		Expect(denial.Resource).To(Equal(corev1.ResourceRequestsCPU))
+		Expect(denial.Requested.Cmp(resource.MustParse("2"))).To(BeZero())
+		Expect(denial.Message).To(Equal("over the limit"))
+	})
+
+	It("keeps a later denial over an older event", func() {
+		reconcile("second")
+		reconcile("first")
+
+		Expect(lastDenied().Namespace).To(Equal("api"))
+	})
+
+	It("reports the pending demand of recent denials, fading until it is gone", func() {
+		now := at(1)
+		r.now = func() time.Time { return now }
+		pendingDemand := func() quotav1alpha1.ResourceList {
+			GinkgoHelper()
+			crq := &quotav1alpha1.ClusterResourceQuota{}
+			Expect(c.Get(ctx, types.NamespacedName{Name: "team-a"}, crq)).To(Succeed())
+			return crq.Status.PendingDemand
+		}
+
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+			Namespace: metav1.NamespaceDefault, Name: "first",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(pendingDemandRefresh))
+		cpu := pendingDemand()[corev1.ResourceRequestsCPU]
+		Expect(cpu.String()).To(Equal("2"))
+
+		now = at(6)
+		reconcile("second")
+		cpu, pods := pendingDemand()[corev1.ResourceRequestsCPU], pendingDemand()[corev1.ResourcePods]
+		Expect(cpu.MilliValue()).To(Equal(int64(1334)))
+		Expect(pods.MilliValue()).To(Equal(int64(934)))
+		Expect(lastDenied().Namespace).To(Equal("api"))
+
+		now = at(30)
+		result, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+			Namespace: metav1.NamespaceDefault, Name: "first",
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(pendingDemand()).To(BeEmpty())
+		Expect(lastDenied().Namespace).To(Equal("api"))
+	})
+
+	It("ignores events of missing quotas", func() {
+		Expect(c.Create(ctx, &eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "gone"},
+			Regarding:  corev1.ObjectReference{Kind: "ClusterResourceQuota", Name: "deleted"},
+			Reason:     events.ReasonAdmissionDenied,
+			Note:       "Denied 1 of pods in namespace api: too many pods",
+			EventTime:  metav1.NewMicroTime(at(9)),
+		})).To(Succeed())
+		reconcile("gone")
+		reconcile("missing")
+
+		Expect(lastDenied()).To(BeNil())
+	})
+})
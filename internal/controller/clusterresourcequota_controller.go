@@ -2,34 +2,37 @@ package controller
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"slices"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/pkg/config"
 	"github.com/powerhome/pac-quota-controller/pkg/events"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/endpointslices"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/resourceclaims"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/services"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/storage"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
 	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
-	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv1 "k8s.io/api/autoscaling/v1"
-	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
-	networkingv1 "k8s.io/api/networking/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -64,12 +67,13 @@ func (resourceUpdatePredicate) Update(e event.UpdateEvent) bool {
 		return true
 	}
 
-	// Special handling for Pods: reconcile if the pod transitions to or from a terminal state
+	// Special handling for Pods: reconcile if the pod starts or stops counting toward quota
 	// or if there's a significant status change (like an init container finishing).
 	if podOld, ok := e.ObjectOld.(*corev1.Pod); ok {
 		if podNew, ok := e.ObjectNew.(*corev1.Pod); ok {
-			// Trigger on terminal state transition
-			if pod.IsPodTerminal(podOld) != pod.IsPodTerminal(podNew) {
+			// Trigger on quota state transition (e.g. terminal phase)
+			now := time.Now()
+			if pod.CountsTowardQuota(podOld, now) != pod.CountsTowardQuota(podNew, now) {
 				return true
 			}
 			// Trigger if any container (init or app) has terminated since last update
@@ -139,24 +143,70 @@ type ClusterResourceQuotaReconciler struct {
 	logger                   *zap.Logger
 	ExcludeNamespaceLabelKey string
 	ExcludedNamespaces       []string
-
-	// mu guards previousNamespacesByQuota and lastQuotaExceededAt across
-	// concurrent Reconcile calls (MaxConcurrentReconciles: 5).
+	// SystemNamespaces are excluded like ExcludedNamespaces, but a
+	// QuotaControllerConfig cannot bring them back into scope.
+	SystemNamespaces []string
+	// NamespaceLabelDenylist carves the namespaces it matches out of every
+	// CRQ; a QuotaControllerConfig cannot bring them back into scope either.
+	NamespaceLabelDenylist quota.NamespaceLabelDenylist
+	// namespaceLabelIndexed is set once namespaceLabelIndex is registered
+	// with the cache the client reads from.
+	namespaceLabelIndexed bool
+	// ExcludedOwners are the controller owners whose objects no CRQ counts.
+	ExcludedOwners objects.Owners
+	// ExcludeHeadlessServices leaves headless services out of the services key.
+	ExcludeHeadlessServices bool
+
+	// mu guards previousNamespacesByQuota, lastQuotaExceededAt,
+	// lastStatusMirrorAt, lastReportedUsage and usageHistory across concurrent
+	// Reconcile calls (MaxConcurrentReconciles: 5).
 	mu                        sync.RWMutex
 	previousNamespacesByQuota map[string][]string
 	lastQuotaExceededAt       map[string]time.Time
+	// lastStatusMirrorAt is when each namespace's status mirror was last written.
+	lastStatusMirrorAt map[string]time.Time
+	// lastReportedUsage is each quota's usage as of its last UsageChanged event.
+	lastReportedUsage map[string]quotav1alpha1.ResourceList
+	// usageHistory is each quota's recent usage, per resource, oldest first.
+	usageHistory map[string]map[corev1.ResourceName][]usageSample
+
+	// dynamicWatches is set when --watch-kinds=auto or ConfigName is set;
+	// nil otherwise.
+	dynamicWatches *dynamicWatches
+
+	// ConfigName is the QuotaControllerConfig whose spec overrides the flags
+	// above. Empty disables runtime configuration.
+	ConfigName string
+	// settingsMu guards settings, the flags merged with the config object.
+	settingsMu sync.RWMutex
+	settings   *controllerSettings
+
+	// ClusterName identifies this cluster on the federation hub. Empty
+	// disables federation.
+	ClusterName string
+	// HubClient reads and writes ClusterResourceQuotas on the federation hub,
+	// which is this cluster when no hub kubeconfig is given.
+	HubClient client.Client
 }
 
 // isNamespaceExcluded checks if a namespace should be ignored by the controller.
-// It checks if the namespace is the controller's own namespace, in the excluded list, or has the exclusion label.
+// It checks if the namespace is a system namespace, on the label denylist, in the excluded list, or has the
+// exclusion label.
 func (r *ClusterResourceQuotaReconciler) isNamespaceExcluded(ns *corev1.Namespace) bool {
-	if slices.Contains(r.ExcludedNamespaces, ns.Name) {
+	if slices.Contains(r.SystemNamespaces, ns.Name) {
+		return true
+	}
+	if _, denied := r.NamespaceLabelDenylist.Denies(ns); denied {
 		return true
 	}
-	if r.ExcludeNamespaceLabelKey == "" {
+	settings := r.currentSettings()
+	if slices.Contains(settings.excludedNamespaces, ns.Name) {
+		return true
+	}
+	if settings.excludeNamespaceLabelKey == "" {
 		return false
 	}
-	_, hasLabel := ns.Labels[r.ExcludeNamespaceLabelKey]
+	_, hasLabel := ns.Labels[settings.excludeNamespaceLabelKey]
 	return hasLabel
 }
 
@@ -171,20 +221,37 @@ func (r *ClusterResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl
 	r.logger.Info("Reconciling ClusterResourceQuota", zap.String("crq_name", req.Name))
 	metrics.QuotaReconcileTotal.WithLabelValues(req.Name, "started").Inc()
 	startTime := time.Now()
+	ctx, listCalls := withListCounter(ctx)
+	// namespaceCount stays negative unless the CRQ exists, so a deleted CRQ
+	// gets no reconcile metrics.
+	namespaceCount := -1
 	defer func() {
 		duration := time.Since(startTime)
 		r.logger.Info("Finished reconciliation",
 			zap.String("crq_name", req.Name),
 			zap.Duration("duration", duration),
 		)
+		if namespaceCount >= 0 {
+			observeReconcile(req.Name, duration.Seconds(), namespaceCount, listCalls.Load())
+		}
 	}()
 
+	// The QuotaControllerConfig watch keeps the settings current; read them
+	// here only if this reconcile beats its first event.
+	r.ensureSettings(ctx)
+
 	// Fetch the ClusterResourceQuota instance
 	crq := &quotav1alpha1.ClusterResourceQuota{}
 	if err := r.Get(ctx, req.NamespacedName, crq); err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, likely deleted, return without error
 			r.logger.Info("ClusterResourceQuota resource not found. Ignoring since object must have been deleted")
+			forgetOwnerKindUsage(req.Name)
+			forgetReconcileMetrics(req.Name)
+			metrics.ForgetCRQUsage(req.Name)
+			r.forgetReportedUsage(req.Name)
+			r.forgetUsageHistory(req.Name)
+			r.forgetNamespaces(req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request
@@ -194,42 +261,67 @@ func (r *ClusterResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, err
 	}
 
-	// Get the list of selected namespaces, filtering out excluded ones.
-	var selectedNamespaces []string
-	if crq.Spec.NamespaceSelector != nil {
-		selector, err := metav1.LabelSelectorAsSelector(crq.Spec.NamespaceSelector)
-		if err != nil {
-			r.logger.Error("Failed to create selector from CRQ spec", zap.Error(err), zap.String("crq_name", crq.Name))
-			r.EventRecorder.InvalidSelector(crq, err)
-			metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
-			metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "invalid_selector").Inc()
-			return ctrl.Result{}, fmt.Errorf("failed to create selector from CRQ spec: %w", err)
-		}
-
-		namespaceList := &corev1.NamespaceList{}
-		listOpts := &client.ListOptions{
-			LabelSelector: selector,
-		}
+	namespaceCount = 0
 
-		if err := r.List(ctx, namespaceList, listOpts); err != nil {
-			r.logger.Error("Failed to list namespaces", zap.Error(err), zap.String("crq_name", crq.Name))
-			r.EventRecorder.CalculationFailed(crq, err)
+	// A deleted quota only has its projected objects left to clean up.
+	if !crq.DeletionTimestamp.IsZero() {
+		if err := r.finalizeQuota(ctx, crq); err != nil {
+			r.logger.Error("Failed to finalize ClusterResourceQuota", zap.Error(err), zap.String("crq_name", crq.Name))
 			metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
 			metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
 			return ctrl.Result{}, err
 		}
+		return ctrl.Result{}, nil
+	}
+	if err := r.ensureFinalizer(ctx, crq); err != nil {
+		r.logger.Error("Failed to add finalizer", zap.Error(err), zap.String("crq_name", crq.Name))
+		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
+		return ctrl.Result{}, err
+	}
 
-		for _, ns := range namespaceList.Items {
-			if r.isNamespaceExcluded(&ns) {
-				continue
-			}
-			selectedNamespaces = append(selectedNamespaces, ns.Name)
+	// A paused quota keeps the status it had until it is resumed.
+	if err := r.recordPaused(ctx, crq); err != nil {
+		r.logger.Error("Failed to mark ClusterResourceQuota paused", zap.Error(err), zap.String("crq_name", crq.Name))
+		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "status_update_failed").Inc()
+		return ctrl.Result{}, err
+	}
+	if crq.Paused() {
+		r.logger.Info("ClusterResourceQuota is paused, skipping usage calculation", zap.String("crq_name", crq.Name))
+		namespaceCount = len(crq.Status.Namespaces)
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "paused").Inc()
+		return ctrl.Result{}, nil
+	}
+
+	// Read the limits of spec.hardLimitsFrom before they are enforced.
+	hardLimitsResolved, err := r.syncHardLimitsFrom(ctx, crq)
+	if err != nil {
+		r.logger.Error("Failed to apply hard limits from ConfigMap", zap.Error(err), zap.String("crq_name", crq.Name))
+		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
+		return ctrl.Result{}, err
+	}
+
+	// Get the list of selected namespaces, filtering out excluded ones.
+	selectedNamespaces, err := r.selectNamespaces(ctx, crq)
+	if err != nil {
+		var selErr *invalidSelectorError
+		if stderrors.As(err, &selErr) {
+			r.logger.Error("Failed to create selector from CRQ spec", zap.Error(err), zap.String("crq_name", crq.Name))
+			r.EventRecorder.InvalidSelector(crq, selErr.err)
+			metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+			metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "invalid_selector").Inc()
+			return ctrl.Result{}, err
 		}
-		sort.Strings(selectedNamespaces)
+		r.logger.Error("Failed to list namespaces", zap.Error(err), zap.String("crq_name", crq.Name))
+		r.EventRecorder.CalculationFailed(crq, err)
+		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
+		return ctrl.Result{}, err
 	}
 
-	// Check for namespace changes and emit events
-	r.handleNamespaceChanges(crq, selectedNamespaces)
+	namespaceCount = len(selectedNamespaces)
 
 	r.logger.Debug("Found namespaces matching selection criteria",
 		zap.Int("count", len(selectedNamespaces)),
@@ -237,32 +329,79 @@ func (r *ClusterResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl
 	)
 
 	// Calculate aggregated resource usage across all selected namespaces
-	totalUsage, usageByNamespace, err := r.calculateAndAggregateUsage(ctx, crq, selectedNamespaces)
+	u, err := r.calculateAndAggregateUsage(ctx, crq, selectedNamespaces)
 	if err != nil {
 		r.logger.Error("Failed to calculate resource usage", zap.Error(err), zap.String("crq_name", crq.Name))
 		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
 		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
 		return ctrl.Result{}, err
 	}
+	totalUsage, usageByNamespace := u.total, u.byNamespace
+	if len(u.incomplete) > 0 {
+		r.logger.Warn("Usage of some resources could not be fully counted",
+			zap.String("crq_name", crq.Name),
+			zap.String("incomplete", incompleteUsageMessage(u.incomplete)))
+	}
+
+	// Attribute pod usage to the values of spec.topologyKey
+	topology, err := r.topologyUsage(ctx, crq, u.pods)
+	if err != nil {
+		r.logger.Error("Failed to calculate topology usage", zap.Error(err), zap.String("crq_name", crq.Name))
+		metrics.QuotaReconcileErrors.WithLabelValues(crq.Name).Inc()
+		metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "failed").Inc()
+		return ctrl.Result{}, err
+	}
+
+	// Record namespaces joining or leaving with their usage at that moment
+	r.handleNamespaceChanges(crq, selectedNamespaces, totalUsage, usageByNamespace)
 
 	// Check for quota warnings and violations
 	r.checkQuotaThresholds(crq, totalUsage)
-
-	// Expose custom metrics: per-namespace and total usage as percent (0-1 float)
+	r.recordUsageChanges(crq, totalUsage)
+	forecasts := r.forecastExhaustion(crq, totalUsage, time.Now())
+
+	// Expose custom metrics: per-namespace and total usage as percent (0-1 float),
+	// with the extra labels the quota declares for --metrics-crq-labels.
+	// Series of namespaces that left the quota are dropped.
+	extraLabels := metrics.CRQLabelValues(crq.Name, crq.MetricLabels())
+	// There are no per-namespace limits: a namespace can use all of the
+	// quota's while the others use none.
+	trackedHard := crq.Spec.TrackedHard()
+	usedPercent := make(map[string]map[string]float64, len(usageByNamespace))
 	for _, nsUsage := range usageByNamespace {
-		ns := nsUsage.Namespace
+		byResource := make(map[string]float64, len(nsUsage.Status.Used))
 		for resourceName, used := range nsUsage.Status.Used {
-			hard := crq.Spec.Hard[resourceName]
-			metrics.CRQUsage.WithLabelValues(crq.Name, ns, string(resourceName)).Set(percentOfHard(used, hard))
+			byResource[string(resourceName)] = percentOfHard(used, trackedHard[resourceName])
 		}
+		usedPercent[nsUsage.Namespace] = byResource
 	}
+	totalPercent := make(map[string]float64, len(totalUsage))
 	for resourceName, total := range totalUsage {
-		hard := crq.Spec.Hard[resourceName]
-		metrics.CRQTotalUsage.WithLabelValues(crq.Name, string(resourceName)).Set(percentOfHard(total, hard))
+		totalPercent[string(resourceName)] = percentOfHard(total, crq.Spec.Hard[resourceName])
 	}
+	metrics.SetCRQUsage(crq.Name, extraLabels, usedPercent, totalPercent)
+
+	// Exchange usage with the other clusters sharing a federated quota.
+	federation := r.syncFederation(ctx, crq, totalUsage)
 
 	// Update the status of the ClusterResourceQuota
-	if err := r.updateStatus(ctx, crq, totalUsage, usageByNamespace); err != nil {
+	conditions := []metav1.Condition{
+		incompleteUsageCondition(crq.Generation, u.incomplete),
+		hardLimitsCondition(crq),
+		pausedCondition(crq),
+	}
+	if hardLimitsResolved != nil {
+		conditions = append(conditions, *hardLimitsResolved)
+	}
+	exempt, exemptionWait := r.enforcementExemption(crq, time.Now())
+	if exempt != nil {
+		conditions = append(conditions, *exempt)
+	}
+	if stale := staleReportsCondition(crq, federation, time.Now()); stale != nil {
+		conditions = append(conditions, *stale)
+	}
+	if err := r.updateStatus(ctx, crq, totalUsage, usageByNamespace, federation, topology, forecasts,
+		incompleteResources(u.incomplete), conditions...); err != nil {
 		if errors.IsNotFound(err) {
 			r.logger.Info("CRQ not found during status update, likely deleted. Skipping status update.", zap.String("crq_name", crq.Name))
 			return ctrl.Result{}, nil
@@ -274,7 +413,110 @@ func (r *ClusterResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	metrics.QuotaReconcileTotal.WithLabelValues(crq.Name, "success").Inc()
-	return ctrl.Result{}, nil
+	after := requeueAfter(crq, u.nextRelease, time.Now())
+	if retryIncompleteUsage(u.incomplete) && (after == 0 || after > incompleteUsageRetryInterval) {
+		after = incompleteUsageRetryInterval
+	}
+	// Copy the status into each member namespace for tenants who cannot read it.
+	if r.statusMirrorEnabled() {
+		wait := r.mirrorStatus(ctx, crq, selectedNamespaces, totalUsage, time.Now())
+		if wait > 0 && (after == 0 || wait < after) {
+			after = wait
+		}
+	}
+	// Publish quota health on the member namespaces.
+	if r.quotaExceededAnnotationEnabled() {
+		r.annotateQuotaExceeded(ctx, crq, selectedNamespaces, totalUsage)
+	}
+	if r.federated(crq) && (after == 0 || after > federationResyncInterval) {
+		after = federationResyncInterval
+	}
+	if exemptionWait > 0 && (after == 0 || exemptionWait < after) {
+		after = exemptionWait
+	}
+	return ctrl.Result{RequeueAfter: after}, nil
+}
+
+// requeueAfter returns when usage can next change without any watched object
+// changing: on the Actual-mode resync, and when a terminating pod outlives its
+// grace period and is released. Zero means no requeue.
+func requeueAfter(crq *quotav1alpha1.ClusterResourceQuota, nextRelease, now time.Time) time.Duration {
+	var after time.Duration
+	if crq.Spec.Mode == quotav1alpha1.QuotaModeActual {
+		after = actualUsageResyncInterval
+	}
+	if !nextRelease.IsZero() {
+		// Release happens strictly after the deadline; land just past it.
+		untilRelease := nextRelease.Sub(now) + time.Second
+		if untilRelease < time.Second {
+			untilRelease = time.Second
+		}
+		if after == 0 || untilRelease < after {
+			after = untilRelease
+		}
+	}
+	return after
+}
+
+// actualUsageResyncInterval is how often Actual-mode quotas re-read
+// metrics-server. It matches metrics-server's default scrape resolution.
+const actualUsageResyncInterval = time.Minute
+
+// invalidSelectorError marks a CRQ whose namespace or exclude selector cannot
+// be converted, so Reconcile can report it separately from list failures.
+type invalidSelectorError struct {
+	msg string
+	err error
+}
+
+func (e *invalidSelectorError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *invalidSelectorError) Unwrap() error { return e.err }
+
+// selectNamespaces returns the sorted names of namespaces governed by crq:
+// those matching namespaceSelector, minus excludeNamespaceSelector matches and
+// controller-level exclusions.
+func (r *ClusterResourceQuotaReconciler) selectNamespaces(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) ([]string, error) {
+	if crq.Spec.NamespaceSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(crq.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, &invalidSelectorError{msg: "failed to create selector from CRQ spec", err: err}
+	}
+
+	// Namespaces matched by excludeNamespaceSelector are carved out of the selection.
+	excludeSelector, err := quota.ExcludeNamespaceSelector(crq)
+	if err != nil {
+		return nil, &invalidSelectorError{msg: "failed to create exclude selector from CRQ spec", err: err}
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList, r.namespaceListOptions(selector)); err != nil {
+		return nil, err
+	}
+
+	var selectedNamespaces []string
+	for _, ns := range namespaceList.Items {
+		if entry, denied := r.NamespaceLabelDenylist.Denies(&ns); denied {
+			r.logger.Info("Skipping namespace on the label denylist",
+				zap.String("crq_name", crq.Name),
+				zap.String("namespace", ns.Name),
+				zap.String("denylist_entry", entry))
+			continue
+		}
+		if r.isNamespaceExcluded(&ns) {
+			continue
+		}
+		if excludeSelector != nil && excludeSelector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		selectedNamespaces = append(selectedNamespaces, ns.Name)
+	}
+	sort.Strings(selectedNamespaces)
+	return selectedNamespaces, nil
 }
 
 // percentOfHard returns used/hard as a 0..1 float, or 0 when hard is unset.
@@ -285,58 +527,211 @@ func percentOfHard(used, hard resource.Quantity) float64 {
 	return used.AsApproximateFloat64() / hard.AsApproximateFloat64()
 }
 
-// calculateAndAggregateUsage walks each namespace once, lists only the resource
-// kinds the CRQ tracks, and computes per-resource usage off the in-memory slices.
+// calculateAndAggregateUsage computes the CRQ's usage and records the
+// aggregation timings and owner-kind usage metrics along the way.
 func (r *ClusterResourceQuotaReconciler) calculateAndAggregateUsage(
 	ctx context.Context,
 	crq *quotav1alpha1.ClusterResourceQuota,
 	namespaces []string,
-) (quotav1alpha1.ResourceList, []quotav1alpha1.ResourceQuotaStatusByNamespace, error) {
-	r.logger.Debug("Calculating resource usage", zap.String("crq_name", crq.Name))
+) (*quotaUsage, error) {
 	timer := prometheus.NewTimer(metrics.QuotaAggregationDuration.WithLabelValues(crq.Name))
 	defer timer.ObserveDuration()
 
-	totalUsage := make(quotav1alpha1.ResourceList, len(crq.Spec.Hard))
-	usageByNamespace := make([]quotav1alpha1.ResourceQuotaStatusByNamespace, len(namespaces))
-	kinds := r.classifyKindsNeeded(crq.Spec.Hard)
+	u, err := r.computeUsage(ctx, crq, namespaces, func(step string, elapsed time.Duration) {
+		metrics.QuotaAggregationStepDuration.WithLabelValues(crq.Name, step).Observe(elapsed.Seconds())
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.recordOwnerKindUsage(crq, u.byOwnerKind)
+	return u, nil
+}
+
+// quotaUsage is the usage of one CRQ as computed by computeUsage.
+type quotaUsage struct {
+	total       quotav1alpha1.ResourceList
+	byNamespace []quotav1alpha1.ResourceQuotaStatusByNamespace
+	// byOwnerKind splits pod resource usage by the kind of the pods' owner.
+	byOwnerKind map[string]quotav1alpha1.ResourceList
+	// nextRelease is the earliest time a terminating pod stops counting, or zero.
+	nextRelease time.Time
+	// incomplete holds, per resource, why its usage could not be fully
+	// counted. The usage recorded for those resources is a lower bound.
+	incomplete map[corev1.ResourceName]error
+	// pods holds the charged pods of every namespace when spec.topologyHard
+	// needs them attributed to topology values; nil otherwise.
+	pods []corev1.Pod
+}
+
+// markIncomplete records that resourceName's usage in namespace could not be
+// counted. Only the first failure per resource is kept.
+func (u *quotaUsage) markIncomplete(resourceName corev1.ResourceName, namespace string, err error) {
+	if u.incomplete == nil {
+		u.incomplete = make(map[corev1.ResourceName]error)
+	}
+	if _, ok := u.incomplete[resourceName]; !ok {
+		u.incomplete[resourceName] = fmt.Errorf("namespace %s: %w", namespace, err)
+	}
+}
+
+// computeUsage walks each namespace once, lists only the resource kinds the
+// CRQ tracks, and computes per-resource usage off the in-memory slices. It only
+// reads the cluster; observeStep, if set, is told how long each step took.
+func (r *ClusterResourceQuotaReconciler) computeUsage(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespaces []string,
+	observeStep func(step string, elapsed time.Duration),
+) (*quotaUsage, error) {
+	r.logger.Debug("Calculating resource usage", zap.String("crq_name", crq.Name))
+
+	now := time.Now()
+	// Keys turned Off by spec.enforcementPolicy are not counted at all.
+	hard := crq.Spec.TrackedHard()
+	u := &quotaUsage{
+		total:       make(quotav1alpha1.ResourceList, len(hard)),
+		byNamespace: make([]quotav1alpha1.ResourceQuotaStatusByNamespace, len(namespaces)),
+		byOwnerKind: make(map[string]quotav1alpha1.ResourceList),
+	}
+	kinds := r.classifyKindsNeeded(hard)
+	ownerPolicies := r.currentSettings().ownerPolicies
+	actualMode := crq.Spec.Mode == quotav1alpha1.QuotaModeActual && hasObservedResource(hard)
+	if actualMode {
+		// Observed usage is attributed to the pods that count toward quota.
+		kinds.pods = true
+	}
+	keepPods := crq.Spec.TopologyKey != "" && len(crq.Spec.TopologyHard) > 0
+	if keepPods {
+		kinds.pods = true
+	}
+	// Host ports shared by pods of several namespaces count once in the total.
+	var hostPorts map[string]bool
+	if _, ok := hard[usage.ResourcePodHostPorts]; ok {
+		hostPorts = make(map[string]bool)
+	}
 
 	for i, nsName := range namespaces {
-		usageByNamespace[i] = quotav1alpha1.ResourceQuotaStatusByNamespace{
+		u.byNamespace[i] = quotav1alpha1.ResourceQuotaStatusByNamespace{
 			Namespace: nsName,
-			Status:    quotav1alpha1.ResourceQuotaStatus{Used: make(quotav1alpha1.ResourceList)},
+			Status: quotav1alpha1.ResourceQuotaStatus{
+				Used: make(quotav1alpha1.ResourceList),
+			},
 		}
 
 		pods, svcs, pvcs, err := r.listNamespaceResources(ctx, nsName, kinds)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
+		}
+		if kinds.pvcs {
+			// Claims of generic ephemeral volumes count from their pod's admission.
+			pvcs = append(pvcs, storage.PendingEphemeralPVCs(pods, pvcs, now)...)
+		}
+		reserved := r.namespaceReservation(ctx, nsName)
+		pods = projection.ChargedPods(crq, projection.ApplyOwnerPolicies(ownerPolicies, pods))
+		if keepPods {
+			u.pods = append(u.pods, pods...)
+		}
+		if hostPorts != nil {
+			pod.DistinctHostPorts(pods, hostPorts)
+		}
+		if release, ok := pod.NextQuotaRelease(pods, now); ok && (u.nextRelease.IsZero() || release.Before(u.nextRelease)) {
+			u.nextRelease = release
 		}
 
 		var pvcsByClass map[string][]corev1.PersistentVolumeClaim
 		if kinds.storageClasses {
 			pvcsByClass = bucketPVCsByStorageClass(pvcs)
 		}
+		var podsByOwnerKind map[string][]corev1.Pod
+		if kinds.pods {
+			podsByOwnerKind = pod.GroupPodsByOwnerKind(pods)
+		}
+		var observed corev1.ResourceList
+		var observedByPod map[string]corev1.ResourceList
+		if actualMode {
+			observedByPod, err = podmetrics.PodUsage(ctx, r.Client, nsName)
+			if err != nil {
+				return nil, err
+			}
+			observed = podmetrics.SumUsage(observedByPod, countedPodNames(pods))
+		}
 
-		for resourceName := range crq.Spec.Hard {
+		for resourceName := range hard {
 			stepStart := time.Now()
-			used, err := r.computeNamespaceResourceUsage(
-				ctx, nsName, resourceName, pods, svcs, pvcs, pvcsByClass,
-			)
-			metrics.QuotaAggregationStepDuration.
-				WithLabelValues(crq.Name, r.aggregationStepForResource(resourceName)).
-				Observe(time.Since(stepStart).Seconds())
-			if err != nil {
-				return nil, nil, err
+			var result usage.Result
+			if observedName, ok := podmetrics.ObservedResource(resourceName); ok && observed != nil {
+				result = usage.Complete(observed[observedName])
+			} else {
+				result = r.computeNamespaceResourceUsage(
+					ctx, nsName, resourceName, pods, svcs, pvcs, pvcsByClass,
+				)
 			}
+			if observeStep != nil {
+				observeStep(r.aggregationStepForResource(resourceName), time.Since(stepStart))
+			}
+			if !result.IsComplete() {
+				u.markIncomplete(resourceName, nsName, result.Err)
+			}
+			weighted := projection.Weigh(crq, resourceName, result.Used)
+			used := applyReservation(&u.byNamespace[i].Status, resourceName, weighted, reserved)
 
-			usageByNamespace[i].Status.Used[resourceName] = used
-			q := totalUsage[resourceName]
+			u.byNamespace[i].Status.Used[resourceName] = used
+			q := u.total[resourceName]
 			q.Add(used)
-			totalUsage[resourceName] = q
+			u.total[resourceName] = q
+
+			if podsByOwnerKind != nil && r.isPodResource(resourceName) {
+				addOwnerKindUsage(u.byOwnerKind, podsByOwnerKind, resourceName, observedByPod)
+			}
 		}
 	}
 
+	if hostPorts != nil {
+		u.total[usage.ResourcePodHostPorts] = projection.Weigh(crq, usage.ResourcePodHostPorts,
+			*resource.NewQuantity(int64(len(hostPorts)), resource.DecimalSI))
+	}
+	if _, ok := hard[usage.ResourcePodDensity]; ok {
+		u.total[usage.ResourcePodDensity] = densestNamespace(u.byNamespace)
+	}
+
 	r.logger.Debug("Usage calculation finished.")
-	return totalUsage, usageByNamespace, nil
+	return u, nil
+}
+
+// densestNamespace returns the highest pods.density/per-cpu usage of
+// byNamespace. Densities are ratios, so the total is the densest namespace.
+func densestNamespace(byNamespace []quotav1alpha1.ResourceQuotaStatusByNamespace) resource.Quantity {
+	densest := *resource.NewMilliQuantity(0, resource.DecimalSI)
+	for _, ns := range byNamespace {
+		if density, ok := ns.Status.Used[usage.ResourcePodDensity]; ok && density.Cmp(densest) > 0 {
+			densest = density
+		}
+	}
+	return densest
+}
+
+// hasObservedResource reports whether any hard key is measured from
+// metrics-server in Actual mode.
+func hasObservedResource(hard quotav1alpha1.ResourceList) bool {
+	for resourceName := range hard {
+		if _, ok := podmetrics.ObservedResource(resourceName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// countedPodNames returns the names of pods whose observed usage is charged to
+// the quota, using the same counting rules as request-based usage.
+func countedPodNames(pods []corev1.Pod) map[string]bool {
+	now := time.Now()
+	names := make(map[string]bool, len(pods))
+	for i := range pods {
+		if pod.CountsTowardQuota(&pods[i], now) {
+			names[pods[i].Name] = true
+		}
+	}
+	return names
 }
 
 // namespaceKinds enumerates the kinds of namespaced resources a CRQ requires
@@ -352,27 +747,37 @@ type namespaceKinds struct {
 func (r *ClusterResourceQuotaReconciler) classifyKindsNeeded(hard quotav1alpha1.ResourceList) namespaceKinds {
 	var k namespaceKinds
 	for resourceName := range hard {
-		resourceStr := string(resourceName)
 		switch resourceName {
 		case corev1.ResourceRequestsCPU,
 			corev1.ResourceRequestsMemory,
 			corev1.ResourceLimitsCPU,
 			corev1.ResourceLimitsMemory,
-			corev1.ResourcePods:
+			corev1.ResourcePods,
+			usage.ResourcePodHostPorts,
+			usage.ResourcePodDensity:
 			k.pods = true
 		case usage.ResourceServices,
 			usage.ResourceServicesLoadBalancers,
-			usage.ResourceServicesNodePorts:
+			usage.ResourceServicesNodePorts,
+			usage.ResourceServicesIPv4,
+			usage.ResourceServicesIPv6,
+			usage.ResourceServicesExternalIPs,
+			usage.ResourceServicesLoadBalancerCost,
+			usage.ResourceServicesHeadless:
 			k.services = true
 		case corev1.ResourceRequestsStorage, usage.ResourcePersistentVolumeClaims:
+			// Pods declare the claims of their generic ephemeral volumes.
 			k.pvcs = true
+			k.pods = true
 		default:
-			if r.isComputeResource(resourceName) {
+			if usage.IsComputeResource(resourceName) {
 				k.pods = true
-			} else if strings.HasSuffix(resourceStr, ".storageclass.storage.k8s.io/requests.storage") ||
-				strings.HasSuffix(resourceStr, ".storageclass.storage.k8s.io/persistentvolumeclaims") {
+			} else if usage.IsClassScoped(resourceName) {
 				k.pvcs = true
-				k.storageClasses = true
+				k.pods = true
+				if usage.ParseQuotaKey(resourceName).StorageClass != "" {
+					k.storageClasses = true
+				}
 			}
 		}
 	}
@@ -388,23 +793,24 @@ func (r *ClusterResourceQuotaReconciler) listNamespaceResources(
 	var svcs []corev1.Service
 	var pvcs []corev1.PersistentVolumeClaim
 
+	src := r.usageSource()
 	if kinds.pods {
 		list := &corev1.PodList{}
-		if err := r.List(ctx, list, client.InNamespace(nsName)); err != nil {
+		if err := src.List(ctx, list, client.InNamespace(nsName)); err != nil {
 			return nil, nil, nil, fmt.Errorf("failed to list pods in namespace %s: %w", nsName, err)
 		}
 		pods = list.Items
 	}
 	if kinds.services {
 		list := &corev1.ServiceList{}
-		if err := r.List(ctx, list, client.InNamespace(nsName)); err != nil {
+		if err := src.List(ctx, list, client.InNamespace(nsName)); err != nil {
 			return nil, nil, nil, fmt.Errorf("failed to list services in namespace %s: %w", nsName, err)
 		}
 		svcs = list.Items
 	}
 	if kinds.pvcs {
 		list := &corev1.PersistentVolumeClaimList{}
-		if err := r.List(ctx, list, client.InNamespace(nsName)); err != nil {
+		if err := src.List(ctx, list, client.InNamespace(nsName)); err != nil {
 			return nil, nil, nil, fmt.Errorf("failed to list pvcs in namespace %s: %w", nsName, err)
 		}
 		pvcs = list.Items
@@ -412,6 +818,12 @@ func (r *ClusterResourceQuotaReconciler) listNamespaceResources(
 	return pods, svcs, pvcs, nil
 }
 
+// usageSource is what usage is counted from: the client, less the objects of
+// ExcludedOwners.
+func (r *ClusterResourceQuotaReconciler) usageSource() objects.Source {
+	return objects.ExcludeOwned(r.Client, r.ExcludedOwners)
+}
+
 // bucketPVCsByStorageClass groups PVCs once per namespace so each storage-class
 // resource lookup is O(1) instead of a full PVC scan.
 func bucketPVCsByStorageClass(pvcs []corev1.PersistentVolumeClaim) map[string][]corev1.PersistentVolumeClaim {
@@ -437,34 +849,79 @@ func (r *ClusterResourceQuotaReconciler) computeNamespaceResourceUsage(
 	svcs []corev1.Service,
 	pvcs []corev1.PersistentVolumeClaim,
 	pvcsByClass map[string][]corev1.PersistentVolumeClaim,
-) (resource.Quantity, error) {
+) usage.Result {
 	switch resourceName {
 	case corev1.ResourceRequestsCPU,
 		corev1.ResourceRequestsMemory,
 		corev1.ResourceLimitsCPU,
 		corev1.ResourceLimitsMemory,
 		corev1.ResourcePods:
-		return pod.CalculateUsageFromPods(pods, resourceName), nil
+		return usage.Complete(pod.CalculateUsageFromPods(pods, resourceName))
+	case usage.ResourcePodHostPorts:
+		return usage.Complete(*resource.NewQuantity(int64(len(pod.DistinctHostPorts(pods, nil))), resource.DecimalSI))
+	case usage.ResourcePodDensity:
+		return usage.Complete(pod.Density(pods))
 	case corev1.ResourceRequestsStorage:
-		return storage.CalculateStorageUsageFromPVCs(pvcs, resourceName), nil
+		return usage.Complete(storage.CalculateStorageUsageFromPVCs(pvcs, resourceName))
 	case usage.ResourcePersistentVolumeClaims:
-		return storage.CalculatePVCCountUsageFromPVCs(pvcs), nil
+		return usage.Complete(storage.CalculatePVCCountUsageFromPVCs(pvcs))
 	case usage.ResourceServices,
 		usage.ResourceServicesLoadBalancers,
-		usage.ResourceServicesNodePorts:
-		return services.CalculateUsageFromServices(svcs, resourceName), nil
+		usage.ResourceServicesNodePorts,
+		usage.ResourceServicesIPv4,
+		usage.ResourceServicesIPv6,
+		usage.ResourceServicesExternalIPs,
+		usage.ResourceServicesLoadBalancerCost,
+		usage.ResourceServicesHeadless:
+		if resourceName == usage.ResourceServices && r.ExcludeHeadlessServices {
+			svcs = services.ExcludeHeadless(svcs)
+		}
+		return usage.Complete(services.CalculateUsageFromServices(svcs, resourceName))
 	}
 
-	resourceStr := string(resourceName)
-	if class, ok := strings.CutSuffix(resourceStr, ".storageclass.storage.k8s.io/requests.storage"); ok {
-		return storage.CalculateStorageUsageFromPVCs(pvcsByClass[class], corev1.ResourceRequestsStorage), nil
+	if key := usage.ParseQuotaKey(resourceName); key.StorageClass != "" {
+		if key.Resource == usage.ResourcePersistentVolumeClaims {
+			return usage.Complete(*resource.NewQuantity(int64(len(pvcsByClass[key.StorageClass])), resource.DecimalSI))
+		}
+		return usage.Complete(storage.CalculateStorageUsageFromPVCs(pvcsByClass[key.StorageClass], key.Resource))
 	}
-	if class, ok := strings.CutSuffix(resourceStr, ".storageclass.storage.k8s.io/persistentvolumeclaims"); ok {
-		return *resource.NewQuantity(int64(len(pvcsByClass[class])), resource.DecimalSI), nil
+	if key := usage.ParseQuotaKey(resourceName); key.VolumeAttributesClass != "" {
+		pvcs = storage.FilterByVolumeAttributesClass(pvcs, key.VolumeAttributesClass)
+		if key.Resource == usage.ResourcePersistentVolumeClaims {
+			return usage.Complete(storage.CalculatePVCCountUsageFromPVCs(pvcs))
+		}
+		return usage.Complete(storage.CalculateStorageUsageFromPVCs(pvcs, key.Resource))
 	}
 
-	if r.isComputeResource(resourceName) {
-		return pod.CalculateUsageFromPods(pods, resourceName), nil
+	if pod.IsExtendedQuotaResource(resourceName) {
+		used, err := pod.CalculateExtendedUsageFromPods(pods, resourceName)
+		if err != nil {
+			r.logger.Error("Failed to calculate extended resource usage",
+				zap.Error(err), zap.Stringer("resource", resourceName), zap.String("namespace", nsName))
+			return usage.Incomplete(err)
+		}
+		return usage.Complete(used)
+	}
+	if usage.IsComputeResource(resourceName) {
+		return usage.Complete(pod.CalculateUsageFromPods(pods, resourceName))
+	}
+	if resourceclaims.Supports(resourceName) {
+		used, err := resourceclaims.CalculateUsage(ctx, r.usageSource(), nsName, resourceName)
+		if err != nil {
+			r.logger.Error("Failed to calculate ResourceClaim usage",
+				zap.Error(err), zap.Stringer("resource", resourceName), zap.String("namespace", nsName))
+			return usage.Incomplete(err)
+		}
+		return usage.Complete(used)
+	}
+	if endpointslices.Supports(resourceName) {
+		used, err := endpointslices.CalculateUsage(ctx, r.usageSource(), nsName, resourceName)
+		if err != nil {
+			r.logger.Error("Failed to calculate endpoint usage",
+				zap.Error(err), zap.Stringer("resource", resourceName), zap.String("namespace", nsName))
+			return usage.Incomplete(err)
+		}
+		return usage.Complete(used)
 	}
 	return r.calculateObjectCount(ctx, nsName, resourceName)
 }
@@ -475,24 +932,29 @@ func (r *ClusterResourceQuotaReconciler) aggregationStepForResource(resourceName
 		corev1.ResourceRequestsMemory,
 		corev1.ResourceLimitsCPU,
 		corev1.ResourceLimitsMemory,
-		corev1.ResourcePods:
+		corev1.ResourcePods,
+		usage.ResourcePodHostPorts,
+		usage.ResourcePodDensity:
 		return "compute"
 	case corev1.ResourceRequestsStorage:
 		return "storage"
-	case usage.ResourceServices, usage.ResourceServicesLoadBalancers, usage.ResourceServicesNodePorts:
+	case usage.ResourceServices, usage.ResourceServicesLoadBalancers, usage.ResourceServicesNodePorts,
+		usage.ResourceServicesIPv4, usage.ResourceServicesIPv6, usage.ResourceServicesExternalIPs,
+		usage.ResourceServicesLoadBalancerCost, usage.ResourceServicesHeadless, usage.ResourceServicesEndpoints:
 		return "services"
 	default:
-		if r.isComputeResource(resourceName) {
+		if usage.IsComputeResource(resourceName) {
 			return "compute_extended"
 		}
 		return "object_count"
 	}
 }
 
-// calculateObjectCount calculates the usage for object count quotas.
+// calculateObjectCount calculates the usage for object count quotas. A failed
+// count is returned as an incomplete result rather than as zero usage.
 func (r *ClusterResourceQuotaReconciler) calculateObjectCount(
 	ctx context.Context, ns string, resourceName corev1.ResourceName,
-) (resource.Quantity, error) {
+) usage.Result {
 	// Use the correct calculator for each resource type
 	switch resourceName {
 	case usage.ResourceConfigMaps, usage.ResourceSecrets, usage.ResourceReplicationControllers,
@@ -502,40 +964,76 @@ func (r *ClusterResourceQuotaReconciler) calculateObjectCount(
 		if err != nil {
 			r.logger.Error("Failed to calculate object count usage",
 				zap.Error(err), zap.Stringer("resource", resourceName), zap.String("namespace", ns))
-			return resource.Quantity{}, err
+			return usage.Incomplete(err)
 		}
-		return objectCount, nil
+		return usage.Complete(objectCount)
 	default:
 		// CRQ tracks a resource we have no calculator for (typo or unsupported kind).
-		// Return zero to keep the rest of the reconcile working, but emit a Warn +
-		// metric so operators can detect the silent admit.
+		// The rest of the reconcile keeps working; the result marks the usage as
+		// incomplete so the IncompleteUsage condition reports it.
 		metrics.QuotaUnsupportedResource.WithLabelValues(string(resourceName)).Inc()
-		r.logger.Warn("Unsupported resource in CRQ; reporting zero usage",
+		r.logger.Warn("Unsupported resource in CRQ; its usage cannot be counted",
 			zap.Stringer("resource", resourceName),
 			zap.String("namespace", ns),
 		)
-		return resource.MustParse("0"), nil
+		return usage.Incomplete(fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName))
 	}
 }
 
 // updateStatus updates the status of the ClusterResourceQuota object.
+// federation replaces the stored federation status; nil clears it. So do
+// topology for the per-value topology usage, forecasts for the exhaustion
+// forecasts and incomplete for the resources whose usage is incomplete.
+// conditions are set among the stored conditions. Only these fields
+// are applied, so status fields of other writers are left alone, and nothing
+// is written when the stored status already holds them.
 func (r *ClusterResourceQuotaReconciler) updateStatus(
 	ctx context.Context,
 	crq *quotav1alpha1.ClusterResourceQuota,
 	totalUsage quotav1alpha1.ResourceList,
 	usageByNamespace []quotav1alpha1.ResourceQuotaStatusByNamespace,
+	federation *quotav1alpha1.FederationStatus,
+	topology []quotav1alpha1.TopologyUsage,
+	forecasts []quotav1alpha1.UsageForecast,
+	incomplete []corev1.ResourceName,
+	conditions ...metav1.Condition,
 ) error {
+	status := quotav1alpha1.ClusterResourceQuotaStatus{
+		Total: quotav1alpha1.ResourceQuotaStatus{
+			Hard:     crq.Spec.TrackedHard(),
+			Used:     totalUsage,
+			Reserved: totalReserved(usageByNamespace),
+		},
+		Namespaces:          usageByNamespace,
+		Federation:          federation,
+		Topology:            topology,
+		Forecasts:           forecasts,
+		IncompleteResources: incomplete,
+	}
+	status = canonicalStatus(status)
+
 	crqCopy := crq.DeepCopy()
-	crqCopy.Status.Total.Hard = crq.Spec.Hard
-	crqCopy.Status.Total.Used = totalUsage
-	crqCopy.Status.Namespaces = usageByNamespace
+	crqCopy.Status.Total = status.Total
+	crqCopy.Status.Namespaces = status.Namespaces
+	crqCopy.Status.Federation = status.Federation
+	crqCopy.Status.Topology = status.Topology
+	crqCopy.Status.Forecasts = status.Forecasts
+	crqCopy.Status.IncompleteResources = status.IncompleteResources
+	// SetStatusCondition keeps the transition time of an unchanged condition.
+	for _, condition := range conditions {
+		meta.SetStatusCondition(&crqCopy.Status.Conditions, condition)
+		status.Conditions = append(status.Conditions,
+			*meta.FindStatusCondition(crqCopy.Status.Conditions, condition.Type))
+	}
 
+	// Semantic equality compares quantities by value, so a status written
+	// as "1" by an older release still matches a computed "1000m".
 	if apiequality.Semantic.DeepEqual(crq.Status, crqCopy.Status) {
+		metrics.QuotaStatusUpdatesSkipped.WithLabelValues(crq.Name).Inc()
 		return nil
 	}
 
-	// Use Patch instead of Update to avoid conflicts
-	return r.Status().Patch(ctx, crqCopy, client.MergeFrom(crq))
+	return r.applyStatus(ctx, crq, status)
 }
 
 // findQuotasForObject maps objects (including Namespaces and other namespaced resources) to ClusterResourceQuota requests
@@ -599,38 +1097,20 @@ func (r *ClusterResourceQuotaReconciler) findQuotasForObject(ctx context.Context
 	return nil
 }
 
-// isComputeResource determines if a resource type should be calculated using the compute calculator.
-// This includes standard compute resources and extended resources (hugepages, GPUs, etc.)
-func (r *ClusterResourceQuotaReconciler) isComputeResource(resourceName corev1.ResourceName) bool {
-	resourceStr := string(resourceName)
-
-	// Standard compute resources (already handled in switch above, but included for completeness)
-	switch resourceName {
-	case corev1.ResourceRequestsCPU, corev1.ResourceRequestsMemory, corev1.ResourceLimitsCPU, corev1.ResourceLimitsMemory,
-		corev1.ResourceRequestsEphemeralStorage, corev1.ResourceLimitsEphemeralStorage:
-		return true
-	}
-
-	// Extended resources patterns
-	// Hugepages resources follow the pattern "hugepages-<size>"
-	if strings.HasPrefix(resourceStr, "hugepages-") {
-		return true
-	}
-
-	// Extended resources start with request.
-	if strings.HasPrefix(resourceStr, "requests.") {
-		return true
-	}
-
-	// If we can't categorize it, assume it's not a compute resource
-	return false
+// isPodResource reports whether usage for resourceName is derived from pods,
+// i.e. the pod count or any compute resource.
+func (r *ClusterResourceQuotaReconciler) isPodResource(resourceName corev1.ResourceName) bool {
+	return resourceName == corev1.ResourcePods || usage.IsComputeResource(resourceName)
 }
 
 // SetupWithManager sets up the controller with the Manager. It is a thin
-// orchestrator over three helpers so each concern (DI, background workers,
-// watch wiring) reads independently.
+// orchestrator over four helpers so each concern (DI, cache indexes,
+// background workers, watch wiring) reads independently.
 func (r *ClusterResourceQuotaReconciler) SetupWithManager(ctx context.Context, cfg *config.Config, mgr ctrl.Manager) error {
 	r.ensureDependencies(mgr)
+	if err := r.indexNamespaceLabels(ctx, mgr); err != nil {
+		return err
+	}
 	r.startBackgroundWorkers(ctx, mgr)
 	r.logger.Info("Setting up ClusterResourceQuota controller")
 	return r.installWatches(mgr)
@@ -642,11 +1122,19 @@ func (r *ClusterResourceQuotaReconciler) ensureDependencies(mgr ctrl.Manager) {
 	if r.logger == nil {
 		r.logger = zap.L().Named("clusterresourcequota-controller")
 	}
+	// Count the List calls of every reconcile, including those made by the
+	// collaborators built on r.Client below.
+	if _, ok := r.Client.(listCountingClient); !ok {
+		r.Client = listCountingClient{Client: r.Client}
+	}
 	if r.crqClient == nil {
-		r.crqClient = quota.NewCRQClient(r.Client, r.logger)
+		crqClient := quota.NewCRQClient(r.Client, r.logger)
+		crqClient.ExcludedNamespaces = r.SystemNamespaces
+		crqClient.DeniedNamespaceLabels = r.NamespaceLabelDenylist
+		r.crqClient = crqClient
 	}
 	if r.ObjectCountCalculator == nil {
-		r.ObjectCountCalculator = objectcount.NewObjectCountCalculator(r.Client, r.logger)
+		r.ObjectCountCalculator = objectcount.NewObjectCountCalculator(r.usageSource(), r.logger)
 	}
 	if r.EventRecorder == nil {
 		r.EventRecorder = events.NewEventRecorder(
@@ -660,6 +1148,9 @@ func (r *ClusterResourceQuotaReconciler) ensureDependencies(mgr ctrl.Manager) {
 	if r.lastQuotaExceededAt == nil {
 		r.lastQuotaExceededAt = make(map[string]time.Time)
 	}
+	if r.lastStatusMirrorAt == nil {
+		r.lastStatusMirrorAt = make(map[string]time.Time)
+	}
 }
 
 // startBackgroundWorkers fires the long-lived goroutines that outlive a
@@ -688,39 +1179,71 @@ func (r *ClusterResourceQuotaReconciler) resolveCleanupConfig() events.CleanupCo
 }
 
 // installWatches wires the CRQ owner watch plus every cross-resource watch
-// that should re-enqueue the matching CRQ.
+// that should re-enqueue the matching CRQ, limited to --watch-kinds when set.
+// With --watch-kinds=auto, or when a QuotaControllerConfig can change the
+// watch kinds, only the CRQ, Namespace and spec.hardLimitsFrom ConfigMap
+// watches are installed up front; the rest are started by syncDynamicWatches.
 func (r *ClusterResourceQuotaReconciler) installWatches(mgr ctrl.Manager) error {
-	resourcePredicate := resourceUpdatePredicate{}
-	watched := []struct {
-		obj   client.Object
-		preds []predicate.Predicate
-	}{
-		{&corev1.Namespace{}, nil},
-		{&corev1.Pod{}, []predicate.Predicate{resourcePredicate}},
-		{&corev1.PersistentVolumeClaim{}, nil},
-		{&corev1.Service{}, nil},
-		// Generic object count resources
-		{&corev1.ConfigMap{}, nil},
-		{&corev1.Secret{}, nil},
-		{&corev1.ReplicationController{}, nil},
-		{&appsv1.Deployment{}, nil},
-		{&appsv1.StatefulSet{}, nil},
-		{&appsv1.DaemonSet{}, nil},
-		{&batchv1.Job{}, nil},
-		{&batchv1.CronJob{}, nil},
-		{&autoscalingv1.HorizontalPodAutoscaler{}, nil},
-		{&networkingv1.Ingress{}, nil},
+	var requested []string
+	if r.Config != nil {
+		requested = r.Config.WatchKinds
+	}
+	auto := isAutoWatchKinds(requested)
+	var enabled map[string]bool
+	if !auto {
+		var err error
+		if enabled, err = enabledWatchKinds(requested); err != nil {
+			return err
+		}
 	}
+	dynamic := auto || r.ConfigName != ""
 
+	options := ctrlcontroller.Options{MaxConcurrentReconciles: 5}
+	if r.Config != nil && r.Config.ReconcileByHeadroom {
+		options.NewQueue = newHeadroomQueue(r.headroomPriority)
+	}
 	b := ctrl.NewControllerManagedBy(mgr).
 		For(&quotav1alpha1.ClusterResourceQuota{}).
-		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: 5})
-	for _, w := range watched {
+		WithOptions(options).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(countedMapFunc("namespaces", r.findQuotasForObject)))
+	// ConfigMaps read by spec.hardLimitsFrom are watched whatever the watch
+	// kinds, which decide what is counted rather than what is read.
+	b = b.Watches(&corev1.ConfigMap{},
+		handler.EnqueueRequestsFromMapFunc(countedMapFunc("hardlimitsfrom", r.findQuotasReadingConfigMap)))
+	if r.ConfigName != "" {
+		b = b.Watches(&quotav1alpha1.QuotaControllerConfig{}, handler.EnqueueRequestsFromMapFunc(r.findAllQuotas))
+	}
+	if dynamic {
+		b = b.Watches(&quotav1alpha1.ClusterResourceQuota{}, r.quotaSpecChanges())
+	}
+	objFor := func(w watchableKind) client.Object {
+		return servedWatchObject(mgr.GetRESTMapper(), mgr.GetScheme(), w)
+	}
+	for _, w := range watchableKinds {
+		if dynamic || (enabled != nil && !enabled[w.kind]) || (enabled == nil && optionalWatchKinds[w.kind]) {
+			continue
+		}
 		b = b.Watches(
-			w.obj,
-			handler.EnqueueRequestsFromMapFunc(r.findQuotasForObject),
+			objFor(w),
+			handler.EnqueueRequestsFromMapFunc(countedMapFunc(w.kind, r.findQuotasForObject)),
 			builder.WithPredicates(w.preds...),
 		)
 	}
-	return b.Named("clusterresourcequota").Complete(r)
+
+	c, err := b.Named("clusterresourcequota").Build(r)
+	if err != nil {
+		return err
+	}
+	if !dynamic {
+		return nil
+	}
+	r.dynamicWatches = newDynamicWatches(c, mgr.GetCache(), r.findQuotasForObject, objFor, r.logger)
+	if auto {
+		r.logger.Info("Deriving watched kinds from ClusterResourceQuota specs")
+		return nil
+	}
+	// Start the flag watch set now; the QuotaControllerConfig watch swaps
+	// in the config's kinds if it sets any.
+	r.dynamicWatches.sync(context.Background(), fixedWatchKinds(enabled))
+	return nil
 }
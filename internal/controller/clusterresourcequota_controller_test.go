@@ -10,7 +10,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/services"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/storage"
@@ -21,10 +24,13 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	k8sevents "k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -53,7 +59,7 @@ func (f *fakeStatusWriter) Create(ctx context.Context, obj client.Object, subRes
 	return nil
 }
 func (f *fakeStatusWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
-	return nil
+	return fmt.Errorf("apply error")
 }
 
 // Success status writer for happy path tests
@@ -73,11 +79,10 @@ func (f *successStatusWriter) Apply(ctx context.Context, obj runtime.ApplyConfig
 }
 
 type countingStatusWriter struct {
-	patchCalls int
+	applyCalls int
 }
 
 func (f *countingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
-	f.patchCalls++
 	return nil
 }
 func (f *countingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
@@ -87,6 +92,7 @@ func (f *countingStatusWriter) Create(ctx context.Context, obj client.Object, su
 	return nil
 }
 func (f *countingStatusWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	f.applyCalls++
 	return nil
 }
 
@@ -175,10 +181,50 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(result).To(Equal(ctrl.Result{}))
 		})
+
+		It("should drop namespaces matched by excludeNamespaceSelector", func() {
+			excludingQuota := testQuota.DeepCopy()
+			excludingQuota.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tier": "system"},
+			}
+			fakeClient := &fakeClient{
+				getFunc: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if crq, ok := obj.(*quotav1alpha1.ClusterResourceQuota); ok {
+						*crq = *excludingQuota
+					}
+					return nil
+				},
+				listFunc: func(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+					if nsList, ok := obj.(*corev1.NamespaceList); ok {
+						nsList.Items = []corev1.Namespace{
+							{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{"team": "test"}}},
+							{ObjectMeta: metav1.ObjectMeta{
+								Name:   "system",
+								Labels: map[string]string{"team": "test", "tier": "system"},
+							}},
+						}
+					}
+					return nil
+				},
+				statusWriter: &successStatusWriter{},
+			}
+
+			reconciler := &ClusterResourceQuotaReconciler{
+				Client:                    fakeClient,
+				logger:                    logger,
+				EventRecorder:             events.NewEventRecorder(k8sevents.NewFakeRecorder(10), logger),
+				previousNamespacesByQuota: make(map[string][]string),
+			}
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: excludingQuota.Name},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reconciler.previousNamespacesByQuota[excludingQuota.Name]).To(Equal([]string{"app"}))
+		})
 	})
 
 	Context("Status Updates", func() {
-		It("should skip patch when status is unchanged", func() {
+		It("should skip the apply when status is unchanged", func() {
 			statusWriter := &countingStatusWriter{}
 			reconciler := &ClusterResourceQuotaReconciler{
 				Client: &fakeClient{statusWriter: statusWriter},
@@ -214,15 +260,55 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 						Used: totalUsage,
 					},
 					Namespaces: usageByNamespace,
+					Conditions: []metav1.Condition{incompleteUsageCondition(0, nil)},
+				},
+			}
+
+			err := reconciler.updateStatus(ctx, crq, totalUsage, usageByNamespace, nil, nil, nil, nil,
+				incompleteUsageCondition(0, nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statusWriter.applyCalls).To(Equal(0))
+		})
+
+		It("should skip the apply when the stored status writes the same quantities differently", func() {
+			statusWriter := &countingStatusWriter{}
+			reconciler := &ClusterResourceQuotaReconciler{
+				Client: &fakeClient{statusWriter: statusWriter},
+				logger: logger,
+			}
+
+			totalUsage := quotav1alpha1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("500m"),
+			}
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "noop-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard: quotav1alpha1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("1"),
+					},
+				},
+				Status: quotav1alpha1.ClusterResourceQuotaStatus{
+					Total: quotav1alpha1.ResourceQuotaStatus{
+						Hard: quotav1alpha1.ResourceList{
+							corev1.ResourceRequestsCPU: resource.MustParse("1000m"),
+						},
+						Used: quotav1alpha1.ResourceList{
+							corev1.ResourceRequestsCPU: resource.MustParse("0.5"),
+						},
+					},
+					Conditions: []metav1.Condition{incompleteUsageCondition(0, nil)},
 				},
 			}
 
-			err := reconciler.updateStatus(ctx, crq, totalUsage, usageByNamespace)
+			skipped := promtestutil.ToFloat64(metrics.QuotaStatusUpdatesSkipped.WithLabelValues(crq.Name))
+			err := reconciler.updateStatus(ctx, crq, totalUsage, nil, nil, nil, nil, nil, incompleteUsageCondition(0, nil))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(statusWriter.patchCalls).To(Equal(0))
+			Expect(statusWriter.applyCalls).To(Equal(0))
+			Expect(promtestutil.ToFloat64(metrics.QuotaStatusUpdatesSkipped.WithLabelValues(crq.Name))).
+				To(Equal(skipped + 1))
 		})
 
-		It("should patch when status changes", func() {
+		It("should apply when status changes", func() {
 			statusWriter := &countingStatusWriter{}
 			reconciler := &ClusterResourceQuotaReconciler{
 				Client: &fakeClient{statusWriter: statusWriter},
@@ -252,9 +338,10 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 				},
 			}
 
-			err := reconciler.updateStatus(ctx, crq, totalUsage, usageByNamespace)
+			err := reconciler.updateStatus(ctx, crq, totalUsage, usageByNamespace, nil, nil, nil, nil,
+				incompleteUsageCondition(0, nil))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(statusWriter.patchCalls).To(Equal(1))
+			Expect(statusWriter.applyCalls).To(Equal(1))
 		})
 	})
 
@@ -1086,20 +1173,33 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 				},
 			}
 
-			got, err := reconciler.computeNamespaceResourceUsage(
+			got := reconciler.computeNamespaceResourceUsage(
 				ctx, "ns-a", corev1.ResourceRequestsCPU, pods, nil, nil, nil,
 			)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(got.String()).To(Equal("300m"))
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.String()).To(Equal("300m"))
 		})
 
 		It("returns zero for service quotas when no services were listed", func() {
 			reconciler := &ClusterResourceQuotaReconciler{logger: zap.NewNop()}
-			got, err := reconciler.computeNamespaceResourceUsage(
+			got := reconciler.computeNamespaceResourceUsage(
 				ctx, "ns-a", usage.ResourceServices, nil, nil, nil, nil,
 			)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(got.String()).To(Equal("0"))
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.String()).To(Equal("0"))
+		})
+
+		It("leaves headless services out of the services key when they are excluded", func() {
+			reconciler := &ClusterResourceQuotaReconciler{logger: zap.NewNop(), ExcludeHeadlessServices: true}
+			svcs := []corev1.Service{
+				{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone}},
+				{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
+			}
+
+			got := reconciler.computeNamespaceResourceUsage(ctx, "ns-a", usage.ResourceServices, nil, svcs, nil, nil)
+			Expect(got.Used.String()).To(Equal("1"))
+			got = reconciler.computeNamespaceResourceUsage(ctx, "ns-a", usage.ResourceServicesHeadless, nil, svcs, nil, nil)
+			Expect(got.Used.String()).To(Equal("1"))
 		})
 
 		It("computes extended compute usage (GPUs) from the in-memory pod slice", func() {
@@ -1117,13 +1217,38 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 				},
 			}
 
-			got, err := reconciler.computeNamespaceResourceUsage(
+			got := reconciler.computeNamespaceResourceUsage(
 				ctx, "ns-a",
 				corev1.ResourceName("requests.nvidia.com/gpu"),
 				pods, nil, nil, nil,
 			)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(got.String()).To(Equal("2"))
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.String()).To(Equal("2"))
+		})
+
+		It("reports fractional extended usage as incomplete instead of rounding it", func() {
+			reconciler := &ClusterResourceQuotaReconciler{logger: zap.NewNop()}
+			pods := []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "fractional", Namespace: "ns-a"},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("500m"),
+							},
+						},
+					}}},
+				},
+			}
+
+			got := reconciler.computeNamespaceResourceUsage(
+				ctx, "ns-a",
+				corev1.ResourceName("requests.nvidia.com/gpu"),
+				pods, nil, nil, nil,
+			)
+			Expect(got.IsComplete()).To(BeFalse())
+			Expect(got.Err).To(MatchError(ContainSubstring("must be whole numbers")))
 		})
 
 		It("computes ephemeral-storage limits from the in-memory pod slice", func() {
@@ -1141,11 +1266,40 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 				},
 			}
 
-			got, err := reconciler.computeNamespaceResourceUsage(
+			got := reconciler.computeNamespaceResourceUsage(
 				ctx, "ns-a", corev1.ResourceLimitsEphemeralStorage, pods, nil, nil, nil,
 			)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(got.Equal(resource.MustParse("2Gi"))).To(BeTrue())
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.Equal(resource.MustParse("2Gi"))).To(BeTrue())
+		})
+
+		It("counts the devices ResourceClaims request from a device class", func() {
+			claim := &resourcev1.ResourceClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "ns-a"},
+				Spec: resourcev1.ResourceClaimSpec{Devices: resourcev1.DeviceClaim{
+					Requests: []resourcev1.DeviceRequest{{Name: "gpus", Exactly: &resourcev1.ExactDeviceRequest{
+						DeviceClassName: "gpu.example.com",
+						AllocationMode:  resourcev1.DeviceAllocationModeExactCount,
+						Count:           2,
+					}}},
+				}},
+			}
+			reconciler := &ClusterResourceQuotaReconciler{
+				Client: fake.NewClientBuilder().WithObjects(claim).Build(),
+				logger: zap.NewNop(),
+			}
+
+			got := reconciler.computeNamespaceResourceUsage(
+				ctx, "ns-a", usage.DeviceClassFor("gpu.example.com"), nil, nil, nil, nil,
+			)
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.String()).To(Equal("2"))
+
+			got = reconciler.computeNamespaceResourceUsage(
+				ctx, "ns-a", usage.ResourceResourceClaims, nil, nil, nil, nil,
+			)
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.String()).To(Equal("1"))
 		})
 	})
 
@@ -1201,13 +1355,13 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 			Expect(kinds.storageClasses).To(BeFalse())
 		})
 
-		It("marks storage-class resources as needing pvcs and bucketing", func() {
+		It("marks storage-class resources as needing pvcs, bucketing and the pods declaring ephemeral claims", func() {
 			kinds := r.classifyKindsNeeded(quotav1alpha1.ResourceList{
 				corev1.ResourceName("fast-ssd.storageclass.storage.k8s.io/requests.storage"): resource.MustParse("10Gi"),
 			})
 			Expect(kinds.pvcs).To(BeTrue())
 			Expect(kinds.storageClasses).To(BeTrue())
-			Expect(kinds.pods).To(BeFalse())
+			Expect(kinds.pods).To(BeTrue())
 		})
 
 		It("flags no list kinds when only object-count resources are tracked", func() {
@@ -1332,6 +1486,7 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 		It("aggregates compute, services, storage, storage-class, and extended resources in one pass", func() {
 			fastClass := fastStorageClass
 			slowClass := slowStorageClass
+			highIOPS := "high-iops"
 
 			fakeClient := fake.NewClientBuilder().WithObjects(
 				&corev1.Pod{
@@ -1380,8 +1535,9 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 				&corev1.PersistentVolumeClaim{
 					ObjectMeta: metav1.ObjectMeta{Name: "pvc-slow", Namespace: "ns-a"},
 					Spec: corev1.PersistentVolumeClaimSpec{
-						StorageClassName: &slowClass,
-						Resources:        corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("8Gi")}},
+						StorageClassName:          &slowClass,
+						VolumeAttributesClassName: &highIOPS,
+						Resources:                 corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("8Gi")}},
 					},
 				},
 			).Build()
@@ -1394,6 +1550,8 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 
 			fastStorage := corev1.ResourceName("fast-ssd.storageclass.storage.k8s.io/requests.storage")
 			fastCount := corev1.ResourceName("fast-ssd.storageclass.storage.k8s.io/persistentvolumeclaims")
+			iopsStorage := usage.VolumeAttributesClassFor(highIOPS, usage.ResourceRequestsStorage)
+			iopsCount := usage.VolumeAttributesClassFor(highIOPS, usage.ResourcePersistentVolumeClaims)
 			gpu := corev1.ResourceName("requests.nvidia.com/gpu")
 
 			crq := &quotav1alpha1.ClusterResourceQuota{
@@ -1411,12 +1569,15 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 						gpu:                                  resource.MustParse("10"),
 						fastStorage:                          resource.MustParse("100Gi"),
 						fastCount:                            resource.MustParse("10"),
+						iopsStorage:                          resource.MustParse("100Gi"),
+						iopsCount:                            resource.MustParse("10"),
 					},
 				},
 			}
 
-			total, byNS, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+			u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
 			Expect(err).NotTo(HaveOccurred())
+			total, byNS := u.total, u.byNamespace
 			Expect(byNS).To(HaveLen(1))
 			Expect(byNS[0].Namespace).To(Equal("ns-a"))
 
@@ -1435,6 +1596,48 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 			Expect(str(gpu)).To(Equal("2"))
 			Expect(str(fastStorage)).To(Equal("3Gi"))
 			Expect(str(fastCount)).To(Equal("2"))
+			Expect(str(iopsStorage)).To(Equal("8Gi"))
+			Expect(str(iopsCount)).To(Equal("1"))
+		})
+
+		It("leaves out the objects of excluded owners", func() {
+			controller := true
+			ownedBy := func(apiVersion, kind string) []metav1.OwnerReference {
+				return []metav1.OwnerReference{
+					{APIVersion: apiVersion, Kind: kind, Name: "owner", UID: "uid", Controller: &controller},
+				}
+			}
+			running := corev1.PodStatus{Phase: corev1.PodRunning}
+			fakeClient := fake.NewClientBuilder().WithObjects(
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns-a",
+					OwnerReferences: ownedBy("apps/v1", "ReplicaSet")}, Status: running},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "ns-a",
+					OwnerReferences: ownedBy("monitoring.coreos.com/v1", "Prometheus")}, Status: running},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "ns-a"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "agent-rules", Namespace: "ns-a",
+					OwnerReferences: ownedBy("monitoring.coreos.com/v1", "Prometheus")}},
+			).Build()
+			r := &ClusterResourceQuotaReconciler{
+				Client:         fakeClient,
+				ExcludedOwners: objects.Owners{{Group: "monitoring.coreos.com"}},
+				logger:         zap.NewNop(),
+			}
+			r.ObjectCountCalculator = objectcount.NewObjectCountCalculator(r.usageSource(), zap.NewNop())
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "excluded-owners"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard: quotav1alpha1.ResourceList{
+						corev1.ResourcePods:      resource.MustParse("10"),
+						usage.ResourceConfigMaps: resource.MustParse("10"),
+					},
+				},
+			}
+
+			u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+			Expect(err).NotTo(HaveOccurred())
+			pods, configMaps := u.total[corev1.ResourcePods], u.total[usage.ResourceConfigMaps]
+			Expect(pods.String()).To(Equal("1"))
+			Expect(configMaps.String()).To(Equal("1"))
 		})
 
 		It("skips namespaces with an empty name without errors", func() {
@@ -1450,8 +1653,9 @@ var _ = Describe("ClusterResourceQuota Controller", Ordered, func() {
 					Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
 				},
 			}
-			total, byNS, err := r.calculateAndAggregateUsage(ctx, crq, []string{""})
+			u, err := r.calculateAndAggregateUsage(ctx, crq, []string{""})
 			Expect(err).NotTo(HaveOccurred())
+			total, byNS := u.total, u.byNamespace
 			Expect(byNS).To(HaveLen(1))
 			q := total[corev1.ResourceRequestsCPU]
 			Expect(q.IsZero()).To(BeTrue())
@@ -1635,7 +1839,7 @@ var _ = Describe("calculateAndAggregateUsage list efficiency", func() {
 			},
 		}
 
-		_, _, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		_, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect((*counts)["*v1.PodList"]).To(Equal(1), "pods listed exactly once for ns-a")
@@ -1682,7 +1886,7 @@ var _ = Describe("calculateAndAggregateUsage list efficiency", func() {
 			},
 		}
 
-		_, _, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		_, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect((*counts)["*v1.PersistentVolumeClaimList"]).To(Equal(1),
@@ -1690,6 +1894,390 @@ var _ = Describe("calculateAndAggregateUsage list efficiency", func() {
 	})
 })
 
+var _ = Describe("calculateAndAggregateUsage with OS-scoped keys", func() {
+	It("charges each OS-scoped key only for pods of that OS", func() {
+		cpuPod := func(name, cpu string, os corev1.OSName) *corev1.Pod {
+			p := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns-a"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "c",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+			if os != "" {
+				p.Spec.OS = &corev1.PodOS{Name: os}
+			}
+			return p
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			cpuPod("win-1", "2", corev1.Windows),
+			cpuPod("lin-1", "1", ""),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("10"),
+					"windows.requests.cpu":     resource.MustParse("4"),
+					"windows.pods":             resource.MustParse("5"),
+				},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		total := u.total
+		all := total[corev1.ResourceRequestsCPU]
+		windowsCPU := total["windows.requests.cpu"]
+		windowsPods := total["windows.pods"]
+		Expect(all.Cmp(resource.MustParse("3"))).To(Equal(0))
+		Expect(windowsCPU.Cmp(resource.MustParse("2"))).To(Equal(0))
+		Expect(windowsPods.Value()).To(Equal(int64(1)))
+	})
+})
+
+var _ = Describe("calculateAndAggregateUsage with nodeSelectorTerms", func() {
+	It("charges only pods confined to the node pool", func() {
+		cpuPod := func(name, cpu string, selector map[string]string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns-a"},
+				Spec: corev1.PodSpec{
+					NodeSelector: selector,
+					Containers: []corev1.Container{{
+						Name: "c",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+						},
+					}},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			cpuPod("train", "4", map[string]string{"pool": "gpu"}),
+			cpuPod("web", "1", nil),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("10"),
+					corev1.ResourcePods:        resource.MustParse("10"),
+				},
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}},
+					},
+				}},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		cpu := u.total[corev1.ResourceRequestsCPU]
+		pods := u.total[corev1.ResourcePods]
+		Expect(cpu.Cmp(resource.MustParse("4"))).To(Equal(0))
+		Expect(pods.Value()).To(Equal(int64(1)))
+	})
+})
+
+var _ = Describe("calculateAndAggregateUsage with host ports", func() {
+	It("counts each host port once across namespaces", func() {
+		hostPortPod := func(name, namespace string, ports ...int32) *corev1.Pod {
+			p := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "c"}}},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+			for _, port := range ports {
+				p.Spec.Containers[0].Ports = append(p.Spec.Containers[0].Ports,
+					corev1.ContainerPort{ContainerPort: port, HostPort: port})
+			}
+			return p
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			hostPortPod("proxy-a", "ns-a", 80, 443),
+			hostPortPod("proxy-b", "ns-b", 80),
+			hostPortPod("exporter", "ns-b", 9100),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{usage.ResourcePodHostPorts: resource.MustParse("5")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a", "ns-b"})
+		Expect(err).NotTo(HaveOccurred())
+		total, byNS := u.total, u.byNamespace
+		ports := total[usage.ResourcePodHostPorts]
+		Expect(ports.Value()).To(Equal(int64(3)))
+		for _, ns := range byNS {
+			used := ns.Status.Used[usage.ResourcePodHostPorts]
+			Expect(used.Value()).To(Equal(int64(2)), ns.Namespace)
+		}
+	})
+})
+
+var _ = Describe("calculateAndAggregateUsage with pod density", func() {
+	It("reports the densest namespace as the total", func() {
+		podRequesting := func(name, namespace, cpu string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				}}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			podRequesting("api", "ns-a", "2"),
+			podRequesting("worker-1", "ns-b", "100m"),
+			podRequesting("worker-2", "ns-b", "100m"),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{usage.ResourcePodDensity: resource.MustParse("20")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a", "ns-b"})
+		Expect(err).NotTo(HaveOccurred())
+		total := u.total[usage.ResourcePodDensity]
+		Expect(total.String()).To(Equal("10"))
+		nsA := u.byNamespace[0].Status.Used[usage.ResourcePodDensity]
+		Expect(nsA.String()).To(Equal("500m"))
+	})
+})
+
+var _ = Describe("calculateAndAggregateUsage in Actual mode", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	// metricsServer serves PodMetrics for ns-a and passes every other list through.
+	metricsServer := func(base client.WithWatch, cpuByPod map[string]string) client.Client {
+		return interceptor.NewClient(base, interceptor.Funcs{
+			List: func(ctx context.Context, w client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				u, ok := list.(*unstructured.UnstructuredList)
+				if !ok {
+					return w.List(ctx, list, opts...)
+				}
+				for name, cpu := range cpuByPod {
+					item := unstructured.Unstructured{Object: map[string]any{
+						"containers": []any{map[string]any{"name": "c", "usage": map[string]any{"cpu": cpu, "memory": "64Mi"}}},
+					}}
+					item.SetName(name)
+					u.Items = append(u.Items, item)
+				}
+				return nil
+			},
+		})
+	}
+
+	requestsPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns-a"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "c",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				},
+			}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	It("uses observed usage for CPU and memory and requests-based usage for everything else", func() {
+		base := fake.NewClientBuilder().WithObjects(
+			requestsPod("web-1", corev1.PodRunning),
+			requestsPod("web-2", corev1.PodRunning),
+			requestsPod("done-1", corev1.PodSucceeded),
+		).Build()
+		c := metricsServer(base, map[string]string{"web-1": "300m", "web-2": "200m", "done-1": "5"})
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Mode: quotav1alpha1.QuotaModeActual,
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+					corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+					corev1.ResourcePods:           resource.MustParse("10"),
+				},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		total := u.total
+		cpu := total[corev1.ResourceRequestsCPU]
+		Expect(cpu.Cmp(resource.MustParse("500m"))).To(Equal(0), "terminal pods are not charged")
+		mem := total[corev1.ResourceRequestsMemory]
+		Expect(mem.Cmp(resource.MustParse("128Mi"))).To(Equal(0))
+		pods := total[corev1.ResourcePods]
+		Expect(pods.Value()).To(Equal(int64(2)))
+	})
+
+	It("splits observed usage by owner kind from the same metrics as the total", func() {
+		base := fake.NewClientBuilder().WithObjects(
+			requestsPod("web-1", corev1.PodRunning),
+			requestsPod("web-2", corev1.PodRunning),
+			requestsPod("done-1", corev1.PodSucceeded),
+		).Build()
+		c := metricsServer(base, map[string]string{"web-1": "300m", "web-2": "200m", "done-1": "5"})
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq-owner-kind-actual"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Mode: quotav1alpha1.QuotaModeActual,
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+			},
+		}
+
+		_, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(promtestutil.ToFloat64(
+			metrics.CRQUsageByOwnerKind.WithLabelValues(crq.Name, "Pod", string(corev1.ResourceRequestsCPU)),
+		)).To(BeNumerically("~", 0.125, 0.0001), "500m observed of 4, not the 4 requested")
+	})
+
+	It("does not query metrics-server in Requests mode", func() {
+		base := fake.NewClientBuilder().WithObjects(requestsPod("web-1", corev1.PodRunning)).Build()
+		c := interceptor.NewClient(base, interceptor.Funcs{
+			List: func(ctx context.Context, w client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*unstructured.UnstructuredList); ok {
+					return errors.New("metrics-server must not be queried")
+				}
+				return w.List(ctx, list, opts...)
+			},
+		})
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		total := u.total
+		cpu := total[corev1.ResourceRequestsCPU]
+		Expect(cpu.Cmp(resource.MustParse("2"))).To(Equal(0))
+	})
+
+	It("leaves namespace limits matching the quota's to status.total.hard", func() {
+		c := fake.NewClientBuilder().WithObjects(requestsPod("web-1", corev1.PodRunning)).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq-ns-hard"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a", "ns-b"})
+		Expect(err).NotTo(HaveOccurred())
+		byNamespace := u.byNamespace
+		Expect(byNamespace).To(HaveLen(2))
+		for _, ns := range byNamespace {
+			Expect(ns.Status.Hard).To(BeNil(), ns.Namespace)
+		}
+	})
+
+	It("charges the assumed defaults for pods without requests", func() {
+		noRequests := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "ns-a"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}, {Name: "b"}}},
+		}
+		c := fake.NewClientBuilder().WithObjects(requestsPod("web-1", corev1.PodRunning), noRequests).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq-assume"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+				MissingRequests: &quotav1alpha1.MissingRequestsPolicy{
+					Action:   quotav1alpha1.MissingRequestsAssume,
+					Defaults: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("250m")},
+				},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		total := u.total
+		cpu := total[corev1.ResourceRequestsCPU]
+		Expect(cpu.Cmp(resource.MustParse("2500m"))).To(Equal(0), "2 requested plus 250m for each bare container")
+	})
+})
+
+var _ = Describe("requeueAfter", func() {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	requestsCRQ := &quotav1alpha1.ClusterResourceQuota{}
+	actualCRQ := &quotav1alpha1.ClusterResourceQuota{
+		Spec: quotav1alpha1.ClusterResourceQuotaSpec{Mode: quotav1alpha1.QuotaModeActual},
+	}
+
+	It("does not requeue a Requests-mode quota with nothing terminating", func() {
+		Expect(requeueAfter(requestsCRQ, time.Time{}, now)).To(BeZero())
+	})
+
+	It("requeues just past the next grace deadline", func() {
+		Expect(requeueAfter(requestsCRQ, now.Add(20*time.Second), now)).To(Equal(21 * time.Second))
+	})
+
+	It("requeues on whichever comes first in Actual mode", func() {
+		Expect(requeueAfter(actualCRQ, time.Time{}, now)).To(Equal(actualUsageResyncInterval))
+		Expect(requeueAfter(actualCRQ, now.Add(20*time.Second), now)).To(Equal(21 * time.Second))
+		Expect(requeueAfter(actualCRQ, now.Add(time.Hour), now)).To(Equal(actualUsageResyncInterval))
+	})
+
+	It("reports the release deadline from aggregation", func() {
+		deletedAt := metav1.NewTime(time.Now())
+		grace := int64(30)
+		c := fake.NewClientBuilder().WithObjects(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-1", Namespace: "ns-a",
+				DeletionTimestamp: &deletedAt, DeletionGracePeriodSeconds: &grace,
+				Finalizers: []string{"example.com/hold"},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		nextRelease := u.nextRelease
+		Expect(nextRelease).To(BeTemporally("~", deletedAt.Add(30*time.Second), time.Second))
+	})
+})
+
 var _ = Describe("percentOfHard", func() {
 	It("returns 0 when hard is zero or unset", func() {
 		Expect(percentOfHard(resource.MustParse("500m"), resource.Quantity{})).To(Equal(0.0))
@@ -1721,15 +2309,132 @@ var _ = Describe("calculateObjectCount with unsupported resource", func() {
 		reconciler = &ClusterResourceQuotaReconciler{logger: logger}
 	})
 
-	It("returns zero with no error and increments the unsupported-resource counter", func() {
+	It("returns an incomplete typed zero and increments the unsupported-resource counter", func() {
 		const typo = "congigmaps"
 		pre := promtestutil.ToFloat64(metrics.QuotaUnsupportedResource.WithLabelValues(typo))
 
-		got, err := reconciler.calculateObjectCount(context.Background(), "any-ns", corev1.ResourceName(typo))
-		Expect(err).ToNot(HaveOccurred())
-		Expect(got.Value()).To(Equal(int64(0)))
+		got := reconciler.calculateObjectCount(context.Background(), "any-ns", corev1.ResourceName(typo))
+		Expect(got.IsComplete()).To(BeFalse())
+		Expect(got.Err).To(MatchError(usage.ErrUnsupportedResource))
+		Expect(got.Used).To(Equal(resource.Quantity{}))
 
 		post := promtestutil.ToFloat64(metrics.QuotaUnsupportedResource.WithLabelValues(typo))
 		Expect(post - pre).To(Equal(float64(1)))
 	})
 })
+
+var _ = Describe("calculateAndAggregateUsage with generic ephemeral volumes", func() {
+	It("charges the claims of ephemeral volumes before they are created, once", func() {
+		claim := func(size string) corev1.PersistentVolumeClaimSpec {
+			return corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			}
+		}
+		ephemeral := func(name, size string) corev1.Volume {
+			return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{
+				Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{Spec: claim(size)},
+				},
+			}}
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns-a"},
+				Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+					ephemeral("cache", "1Gi"),
+					ephemeral("data", "2Gi"),
+				}},
+				Status: corev1.PodStatus{Phase: corev1.PodPending},
+			},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-cache", Namespace: "ns-a"},
+				Spec:       claim("1Gi"),
+			},
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsStorage:       resource.MustParse("10Gi"),
+					usage.ResourcePersistentVolumeClaims: resource.MustParse("5"),
+				},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a"})
+		Expect(err).NotTo(HaveOccurred())
+		requested := u.total[corev1.ResourceRequestsStorage]
+		claims := u.total[usage.ResourcePersistentVolumeClaims]
+		Expect(requested.Cmp(resource.MustParse("3Gi"))).To(Equal(0), requested.String())
+		Expect(claims.Value()).To(Equal(int64(2)))
+	})
+})
+
+var _ = Describe("selectNamespaces with a namespace label denylist", func() {
+	It("skips the namespaces the denylist matches, whatever the quota selects", func() {
+		ns := func(name string, labels map[string]string) *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+		}
+		c := fake.NewClientBuilder().WithObjects(
+			ns("payments", map[string]string{"team": "a", "pci": "true"}),
+			ns("web", map[string]string{"team": "a"}),
+		).Build()
+		denylist, err := config.ParseNamespaceLabelDenylist([]string{"pci=true"})
+		Expect(err).NotTo(HaveOccurred())
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop(), NamespaceLabelDenylist: denylist}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			},
+		}
+		selected, err := r.selectNamespaces(context.Background(), crq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(Equal([]string{"web"}))
+		Expect(r.isNamespaceExcluded(ns("payments", map[string]string{"pci": "true"}))).To(BeTrue())
+	})
+})
+
+var _ = Describe("calculateAndAggregateUsage with usage weights", func() {
+	It("charges usage weighted by spec.usageWeights in every namespace and the total", func() {
+		cpuPod := func(name, ns, cpu string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+		c := fake.NewClientBuilder().WithObjects(cpuPod("a", "ns-a", "3"), cpuPod("b", "ns-b", "1")).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("4"),
+					corev1.ResourcePods:        resource.MustParse("10"),
+				},
+				UsageWeights: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("500m")},
+			},
+		}
+
+		u, err := r.calculateAndAggregateUsage(context.Background(), crq, []string{"ns-a", "ns-b"})
+		Expect(err).NotTo(HaveOccurred())
+		nsCPU := u.byNamespace[0].Status.Used[corev1.ResourceRequestsCPU]
+		totalCPU := u.total[corev1.ResourceRequestsCPU]
+		pods := u.total[corev1.ResourcePods]
+		Expect(nsCPU.MilliValue()).To(Equal(int64(1500)))
+		Expect(totalCPU.MilliValue()).To(Equal(int64(2000)))
+		Expect(pods.Value()).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// ProjectedObjectsFinalizer holds a ClusterResourceQuota until the objects
+// the controller created for it in member namespaces are deleted or orphaned
+// according to spec.deletionPolicy, and its quota-exceeded annotations are
+// removed.
+const ProjectedObjectsFinalizer = "quota.powerapp.cloud/projected-objects"
+
+// projectsObjects reports whether the controller creates objects or
+// annotations in member namespaces, which is when quotas need
+// ProjectedObjectsFinalizer.
+func (r *ClusterResourceQuotaReconciler) projectsObjects() bool {
+	return r.statusMirrorEnabled() || r.quotaExceededAnnotationEnabled()
+}
+
+// ensureFinalizer adds ProjectedObjectsFinalizer to crq when the controller
+// projects objects for it.
+func (r *ClusterResourceQuotaReconciler) ensureFinalizer(ctx context.Context, crq *quotav1alpha1.ClusterResourceQuota) error {
+	if !r.projectsObjects() || controllerutil.ContainsFinalizer(crq, ProjectedObjectsFinalizer) {
+		return nil
+	}
+	base := crq.DeepCopy()
+	controllerutil.AddFinalizer(crq, ProjectedObjectsFinalizer)
+	if err := r.Patch(ctx, crq, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("adding finalizer: %w", err)
+	}
+	return nil
+}
+
+// finalizeQuota applies crq's deletion policy to its projected objects and
+// then releases the quota by removing ProjectedObjectsFinalizer. It runs
+// whether or not the projection features are still enabled, so turning one
+// off never strands a quota in Terminating.
+func (r *ClusterResourceQuotaReconciler) finalizeQuota(ctx context.Context, crq *quotav1alpha1.ClusterResourceQuota) error {
+	if !controllerutil.ContainsFinalizer(crq, ProjectedObjectsFinalizer) {
+		return nil
+	}
+	if err := r.cleanupProjectedObjects(ctx, crq); err != nil {
+		return err
+	}
+	// A deleted quota is exceeded by no one, whatever its deletion policy.
+	if err := r.clearQuotaExceeded(ctx, crq.Name); err != nil {
+		return fmt.Errorf("clearing quota-exceeded annotations: %w", err)
+	}
+	base := crq.DeepCopy()
+	controllerutil.RemoveFinalizer(crq, ProjectedObjectsFinalizer)
+	if err := r.Patch(ctx, crq, client.MergeFrom(base)); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("removing finalizer: %w", err)
+	}
+	return nil
+}
+
+// cleanupProjectedObjects deletes or orphans the status mirror ConfigMaps of
+// crq in every namespace, not only the ones it selects now.
+func (r *ClusterResourceQuotaReconciler) cleanupProjectedObjects(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) error {
+	mirrors := &corev1.ConfigMapList{}
+	if err := r.List(ctx, mirrors, client.MatchingLabels{LabelStatusMirror: crq.Name}); err != nil {
+		return fmt.Errorf("listing status mirrors: %w", err)
+	}
+	for i := range mirrors.Items {
+		cm := &mirrors.Items[i]
+		if cm.Name != StatusMirrorName {
+			continue
+		}
+		var err error
+		if crq.Spec.DeletionPolicy == quotav1alpha1.DeletionPolicyOrphan {
+			err = r.orphanStatusMirror(ctx, crq, cm)
+		} else {
+			err = r.deleteStatusMirror(ctx, crq.Name, cm.Namespace)
+		}
+		if err != nil {
+			return fmt.Errorf("cleaning up ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+	}
+	r.logger.Info("Cleaned up projected objects",
+		zap.String("crq_name", crq.Name),
+		zap.String("deletion_policy", string(crq.Spec.DeletionPolicy)),
+		zap.Int("count", len(mirrors.Items)))
+	return nil
+}
+
+// orphanStatusMirror detaches cm from crq: without the owner reference the
+// garbage collector keeps it, and without the label the controller no longer
+// rewrites or deletes it.
+func (r *ClusterResourceQuotaReconciler) orphanStatusMirror(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	cm *corev1.ConfigMap,
+) error {
+	delete(cm.Labels, LabelStatusMirror)
+	owners := cm.OwnerReferences[:0]
+	for _, ref := range cm.OwnerReferences {
+		if ref.UID != crq.UID {
+			owners = append(owners, ref)
+		}
+	}
+	cm.OwnerReferences = owners
+	if err := r.Update(ctx, cm); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.lastStatusMirrorAt, cm.Namespace)
+	r.mu.Unlock()
+	return nil
+}
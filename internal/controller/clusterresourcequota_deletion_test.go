@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler deletion policy", func() {
+	var (
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+		crq *quotav1alpha1.ClusterResourceQuota
+	)
+
+	BeforeEach(func() {
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "crq-uid"},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Hard: quotav1alpha1.ResourceList{}},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(crq).Build()
+		Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, crq)).To(Succeed())
+		r = &ClusterResourceQuotaReconciler{
+			Client: c,
+			Config: &config.Config{StatusMirrorEnable: true, StatusMirrorMinInterval: time.Minute},
+			logger: zap.NewNop(),
+		}
+	})
+
+	// deleteQuota deletes crq, which the finalizer keeps around, and returns
+	// it as the reconciler would see it.
+	deleteQuota := func() *quotav1alpha1.ClusterResourceQuota {
+		Expect(r.ensureFinalizer(ctx, crq)).To(Succeed())
+		r.mirrorStatus(ctx, crq, []string{"dev", "prod"}, nil, now)
+		Expect(c.Delete(ctx, crq)).To(Succeed())
+		deleted := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, deleted)).To(Succeed())
+		Expect(deleted.DeletionTimestamp).NotTo(BeNil())
+		return deleted
+	}
+
+	quotaGone := func() bool {
+		return errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: crq.Name}, &quotav1alpha1.ClusterResourceQuota{}))
+	}
+
+	It("adds the finalizer only when objects are projected", func() {
+		r.Config.StatusMirrorEnable = false
+		Expect(r.ensureFinalizer(ctx, crq)).To(Succeed())
+		Expect(crq.Finalizers).To(BeEmpty())
+
+		r.Config.StatusMirrorEnable = true
+		Expect(r.ensureFinalizer(ctx, crq)).To(Succeed())
+		stored := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, stored)).To(Succeed())
+		Expect(stored.Finalizers).To(ConsistOf(ProjectedObjectsFinalizer))
+	})
+
+	It("deletes the status mirrors by default", func() {
+		Expect(r.finalizeQuota(ctx, deleteQuota())).To(Succeed())
+
+		for _, ns := range []string{"dev", "prod"} {
+			err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: StatusMirrorName}, &corev1.ConfigMap{})
+			Expect(errors.IsNotFound(err)).To(BeTrue(), ns)
+		}
+		Expect(quotaGone()).To(BeTrue())
+	})
+
+	It("detaches the status mirrors under Orphan", func() {
+		crq.Spec.DeletionPolicy = quotav1alpha1.DeletionPolicyOrphan
+		Expect(c.Update(ctx, crq)).To(Succeed())
+
+		Expect(r.finalizeQuota(ctx, deleteQuota())).To(Succeed())
+
+		for _, ns := range []string{"dev", "prod"} {
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, types.NamespacedName{Namespace: ns, Name: StatusMirrorName}, cm)).To(Succeed())
+			Expect(cm.Labels).NotTo(HaveKey(LabelStatusMirror))
+			Expect(cm.OwnerReferences).To(BeEmpty())
+			Expect(cm.Data).To(HaveKeyWithValue("clusterResourceQuota", "team-a"))
+		}
+		Expect(quotaGone()).To(BeTrue())
+	})
+
+	It("releases a quota whose projection was turned off", func() {
+		deleted := deleteQuota()
+		r.Config.StatusMirrorEnable = false
+
+		Expect(r.finalizeQuota(ctx, deleted)).To(Succeed())
+		Expect(quotaGone()).To(BeTrue())
+	})
+})
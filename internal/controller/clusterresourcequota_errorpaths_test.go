@@ -3,9 +3,12 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
 	corev1 "k8s.io/api/core/v1"
@@ -24,16 +27,20 @@ import (
 	"github.com/powerhome/pac-quota-controller/pkg/events"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
 // fakeEventRecorder captures emitted events as "type/reason" strings.
 type fakeEventRecorder struct {
+	mu     sync.Mutex
 	events []string
 }
 
 func (f *fakeEventRecorder) Eventf(
 	regarding, related runtime.Object, eventtype, reason, action, note string, args ...any,
 ) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.events = append(f.events, eventtype+"/"+reason)
 }
 
@@ -56,7 +63,7 @@ func (w *errStatusWriter) Create(
 func (w *errStatusWriter) Apply(
 	_ context.Context, _ runtime.ApplyConfiguration, _ ...client.SubResourceApplyOption,
 ) error {
-	return nil
+	return w.err
 }
 
 var _ = Describe("Reconciler error paths", func() {
@@ -98,9 +105,10 @@ var _ = Describe("Reconciler error paths", func() {
 			r := newReconciler(&fakeClient{})
 			crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "q"}}
 
-			r.handleNamespaceChanges(crq, []string{"b", "a"})
+			r.handleNamespaceChanges(crq, []string{"b", "a"}, nil, nil)
 
-			Expect(rec.events).To(ConsistOf("Normal/NamespaceAdded", "Normal/NamespaceAdded"))
+			Expect(rec.events).To(ConsistOf(
+				"Normal/NamespaceAdded", "Normal/NamespaceAdded", "Normal/NamespaceAdded", "Normal/NamespaceAdded"))
 			Expect(r.previousNamespacesByQuota["q"]).To(Equal([]string{"a", "b"}))
 		})
 
@@ -108,13 +116,52 @@ var _ = Describe("Reconciler error paths", func() {
 			r := newReconciler(&fakeClient{})
 			crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "q"}}
 
-			r.handleNamespaceChanges(crq, []string{"a", "b"})
+			r.handleNamespaceChanges(crq, []string{"a", "b"}, nil, nil)
 			rec.events = nil // reset, only inspect the second transition
-			r.handleNamespaceChanges(crq, []string{"b", "c"})
+			r.handleNamespaceChanges(crq, []string{"b", "c"}, nil, nil)
 
-			Expect(rec.events).To(ConsistOf("Normal/NamespaceAdded", "Normal/NamespaceRemoved"))
+			// Each change is recorded on the CRQ and on the namespace.
+			Expect(rec.events).To(ConsistOf(
+				"Normal/NamespaceAdded", "Normal/NamespaceAdded", "Normal/NamespaceRemoved", "Normal/NamespaceRemoved"))
 			Expect(r.previousNamespacesByQuota["q"]).To(Equal([]string{"b", "c"}))
 		})
+
+		It("reports only the changes since the stored status after a leader failover", func() {
+			crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "q"}}
+			leader := newReconciler(&fakeClient{})
+			leader.handleNamespaceChanges(crq, []string{"a", "b"}, nil, nil)
+			// The old leader wrote the namespaces to status before it went away.
+			crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{Namespace: "a"}, {Namespace: "b"}}
+			rec.events = nil
+
+			successor := newReconciler(&fakeClient{})
+			successor.handleNamespaceChanges(crq, []string{"a", "b"}, nil, nil)
+			Expect(rec.events).To(BeEmpty())
+
+			successor.forgetNamespaces("q")
+			successor.handleNamespaceChanges(crq, []string{"b", "c"}, nil, nil)
+			Expect(rec.events).To(ConsistOf(
+				"Normal/NamespaceAdded", "Normal/NamespaceAdded", "Normal/NamespaceRemoved", "Normal/NamespaceRemoved"))
+		})
+
+		It("tracks concurrent reconciles of different quotas", func() {
+			r := newReconciler(&fakeClient{})
+			var wg sync.WaitGroup
+			for i := range 5 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("q%d", i)}}
+					for j := range 10 {
+						r.handleNamespaceChanges(crq, []string{fmt.Sprintf("ns-%d", j)}, nil, nil)
+					}
+				}()
+			}
+			wg.Wait()
+			for i := range 5 {
+				Expect(r.previousNamespacesByQuota[fmt.Sprintf("q%d", i)]).To(Equal([]string{"ns-9"}))
+			}
+		})
 	})
 
 	Describe("Reconcile", func() {
@@ -167,6 +214,34 @@ var _ = Describe("Reconciler error paths", func() {
 			Expect(result).To(Equal(ctrl.Result{}))
 		})
 
+		It("drops the owner-kind usage series of a deleted CRQ", func() {
+			metrics.CRQUsageByOwnerKind.WithLabelValues(req.Name, "Deployment", "pods").Set(0.5)
+			r := newReconciler(&fakeClient{
+				getFunc: func(_ context.Context, _ client.ObjectKey, _ client.Object) error {
+					return apierrors.NewNotFound(schema.GroupResource{Resource: "clusterresourcequotas"}, req.Name)
+				},
+			})
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metrics.CRQUsageByOwnerKind.DeletePartialMatch(prometheus.Labels{"crq_name": req.Name})).To(BeZero())
+		})
+
+		It("drops the usage series of a deleted CRQ", func() {
+			metrics.SetCRQUsage(req.Name, nil,
+				map[string]map[string]float64{"team-a": {"pods": 0.5}}, map[string]float64{"pods": 0.5})
+			r := newReconciler(&fakeClient{
+				getFunc: func(_ context.Context, _ client.ObjectKey, _ client.Object) error {
+					return apierrors.NewNotFound(schema.GroupResource{Resource: "clusterresourcequotas"}, req.Name)
+				},
+			})
+
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metrics.CRQUsage.DeletePartialMatch(prometheus.Labels{"crq_name": req.Name})).To(BeZero())
+			Expect(metrics.CRQTotalUsage.DeletePartialMatch(prometheus.Labels{"crq_name": req.Name})).To(BeZero())
+		})
+
 		It("returns an error when fetching the CRQ fails for a non-NotFound reason", func() {
 			r := newReconciler(&fakeClient{
 				getFunc: func(_ context.Context, _ client.ObjectKey, _ client.Object) error {
@@ -178,7 +253,7 @@ var _ = Describe("Reconciler error paths", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
-		It("ignores a NotFound error from the status patch", func() {
+		It("ignores a NotFound error from the status apply", func() {
 			crq := &quotav1alpha1.ClusterResourceQuota{
 				ObjectMeta: metav1.ObjectMeta{Name: "test-quota"},
 				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
@@ -200,7 +275,7 @@ var _ = Describe("Reconciler error paths", func() {
 			Expect(result).To(Equal(ctrl.Result{}))
 		})
 
-		It("returns an error for a non-NotFound status patch failure", func() {
+		It("returns an error for a non-NotFound status apply failure", func() {
 			crq := &quotav1alpha1.ClusterResourceQuota{
 				ObjectMeta: metav1.ObjectMeta{Name: "test-quota"},
 				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
@@ -320,7 +395,7 @@ var _ = Describe("Reconciler error paths", func() {
 			})
 			r := newReconciler(errClient)
 
-			_, _, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
+			_, err := r.calculateAndAggregateUsage(ctx, crq, []string{"ns-a"})
 			Expect(err).To(HaveOccurred())
 		})
 	})
@@ -333,12 +408,12 @@ var _ = Describe("Reconciler error paths", func() {
 			r := newReconciler(c)
 			r.ObjectCountCalculator = objectcount.NewObjectCountCalculator(c, logger)
 
-			q, err := r.calculateObjectCount(ctx, "ns-a", usage.ResourceConfigMaps)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(q.Value()).To(Equal(int64(1)))
+			got := r.calculateObjectCount(ctx, "ns-a", usage.ResourceConfigMaps)
+			Expect(got.Err).NotTo(HaveOccurred())
+			Expect(got.Used.Value()).To(Equal(int64(1)))
 		})
 
-		It("returns an error when the object-count calculator fails", func() {
+		It("returns an incomplete result when the object-count calculator fails", func() {
 			errClient := interceptor.NewClient(fake.NewClientBuilder().Build(), interceptor.Funcs{
 				List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
 					return errors.New("configmap list boom")
@@ -347,8 +422,9 @@ var _ = Describe("Reconciler error paths", func() {
 			r := newReconciler(errClient)
 			r.ObjectCountCalculator = objectcount.NewObjectCountCalculator(errClient, logger)
 
-			_, err := r.calculateObjectCount(ctx, "ns-a", usage.ResourceConfigMaps)
-			Expect(err).To(HaveOccurred())
+			got := r.calculateObjectCount(ctx, "ns-a", usage.ResourceConfigMaps)
+			Expect(got.IsComplete()).To(BeFalse())
+			Expect(got.Err).To(MatchError(ContainSubstring("configmap list boom")))
 		})
 	})
 
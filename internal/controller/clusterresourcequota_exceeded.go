@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+)
+
+const (
+	// AnnotationQuotaExceeded lists, comma-separated, the hard limits of its
+	// ClusterResourceQuota that a namespace's quota is over. Namespaces have
+	// no writable conditions, so quota health is published as annotations.
+	AnnotationQuotaExceeded = "quota.powerapp.cloud/quota-exceeded"
+	// AnnotationQuotaExceededBy names the ClusterResourceQuota that set
+	// AnnotationQuotaExceeded.
+	AnnotationQuotaExceededBy = "quota.powerapp.cloud/quota-exceeded-by"
+)
+
+// quotaExceededAnnotationEnabled reports whether
+// --quota-exceeded-annotation-enable is on.
+func (r *ClusterResourceQuotaReconciler) quotaExceededAnnotationEnabled() bool {
+	return r.Config != nil && r.Config.QuotaExceededAnnotationEnable
+}
+
+// exceededResources returns, sorted, the tracked hard limits of crq that used
+// is over.
+func exceededResources(crq *quotav1alpha1.ClusterResourceQuota, used quotav1alpha1.ResourceList) []string {
+	var exceeded []string
+	for _, resourceName := range projection.Exceeded(crq.Spec.TrackedHard(), used) {
+		exceeded = append(exceeded, string(resourceName))
+	}
+	return exceeded
+}
+
+// annotateQuotaExceeded sets the quota-exceeded annotations on every namespace
+// when crq is over a hard limit and removes them once it is not, or from
+// namespaces crq no longer selects. Failures are logged and do not fail the
+// reconcile: the annotations only report what the status already shows.
+func (r *ClusterResourceQuotaReconciler) annotateQuotaExceeded(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespaces []string,
+	used quotav1alpha1.ResourceList,
+) {
+	exceeded := exceededResources(crq, used)
+	for _, ns := range namespaces {
+		if err := r.setQuotaExceeded(ctx, crq.Name, ns, exceeded); err != nil {
+			r.logger.Warn("Failed to annotate namespace quota health",
+				zap.String("crq_name", crq.Name),
+				zap.String("namespace", ns),
+				zap.Error(err))
+		}
+	}
+	for _, nsStatus := range crq.Status.Namespaces {
+		if slices.Contains(namespaces, nsStatus.Namespace) {
+			continue
+		}
+		if err := r.setQuotaExceeded(ctx, crq.Name, nsStatus.Namespace, nil); err != nil {
+			r.logger.Warn("Failed to clear namespace quota health",
+				zap.String("crq_name", crq.Name),
+				zap.String("namespace", nsStatus.Namespace),
+				zap.Error(err))
+		}
+	}
+}
+
+// setQuotaExceeded patches namespace's quota-exceeded annotations to report
+// the exceeded resources of quotaName, removing them when there are none.
+// Annotations set by another quota are only replaced, never removed.
+func (r *ClusterResourceQuotaReconciler) setQuotaExceeded(
+	ctx context.Context,
+	quotaName, namespace string,
+	exceeded []string,
+) error {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting namespace %s: %w", namespace, err)
+	}
+
+	want := map[string]string{}
+	if len(exceeded) > 0 {
+		want[AnnotationQuotaExceeded] = strings.Join(exceeded, ",")
+		want[AnnotationQuotaExceededBy] = quotaName
+	} else if by, ok := ns.Annotations[AnnotationQuotaExceededBy]; !ok || by != quotaName {
+		return nil
+	}
+	have := map[string]string{}
+	for _, key := range []string{AnnotationQuotaExceeded, AnnotationQuotaExceededBy} {
+		if value, ok := ns.Annotations[key]; ok {
+			have[key] = value
+		}
+	}
+	if maps.Equal(have, want) {
+		return nil
+	}
+
+	updated := ns.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	delete(updated.Annotations, AnnotationQuotaExceeded)
+	delete(updated.Annotations, AnnotationQuotaExceededBy)
+	maps.Copy(updated.Annotations, want)
+	r.logger.Debug("Updating namespace quota health",
+		zap.String("crq_name", quotaName),
+		zap.String("namespace", namespace),
+		zap.Strings("exceeded", exceeded))
+	return r.Patch(ctx, updated, client.MergeFrom(ns))
+}
+
+// clearQuotaExceeded removes the quota-exceeded annotations quotaName set
+// from every namespace, not only the ones it selects now.
+func (r *ClusterResourceQuotaReconciler) clearQuotaExceeded(ctx context.Context, quotaName string) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces); err != nil {
+		return fmt.Errorf("listing namespaces: %w", err)
+	}
+	for i := range namespaces.Items {
+		if namespaces.Items[i].Annotations[AnnotationQuotaExceededBy] != quotaName {
+			continue
+		}
+		if err := r.setQuotaExceeded(ctx, quotaName, namespaces.Items[i].Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler quota-exceeded annotations", func() {
+	var (
+		ctx = context.Background()
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+		crq *quotav1alpha1.ClusterResourceQuota
+	)
+
+	used := func(cpu, pods string) quotav1alpha1.ResourceList {
+		return quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse(cpu),
+			corev1.ResourcePods:        resource.MustParse(pods),
+		}
+	}
+	annotations := func(name string) map[string]string {
+		ns := &corev1.Namespace{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, ns)).To(Succeed())
+		return ns.Annotations
+	}
+
+	BeforeEach(func() {
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Hard: used("10", "5")},
+		}
+		c = fake.NewClientBuilder().WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Annotations: map[string]string{"keep": "me"}}},
+		).Build()
+		r = &ClusterResourceQuotaReconciler{
+			Client: c,
+			Config: &config.Config{QuotaExceededAnnotationEnable: true},
+			logger: zap.NewNop(),
+		}
+	})
+
+	It("annotates every namespace with the exceeded limits", func() {
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev", "prod"}, used("12", "6"))
+
+		for _, ns := range []string{"dev", "prod"} {
+			Expect(annotations(ns)).To(HaveKeyWithValue(AnnotationQuotaExceeded, "pods,requests.cpu"))
+			Expect(annotations(ns)).To(HaveKeyWithValue(AnnotationQuotaExceededBy, "team-a"))
+		}
+		Expect(annotations("prod")).To(HaveKeyWithValue("keep", "me"))
+	})
+
+	It("removes the annotations once usage is within the limits", func() {
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev"}, used("12", "1"))
+		Expect(annotations("dev")).To(HaveKeyWithValue(AnnotationQuotaExceeded, "requests.cpu"))
+
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev"}, used("10", "1"))
+		Expect(annotations("dev")).NotTo(HaveKey(AnnotationQuotaExceeded))
+		Expect(annotations("dev")).NotTo(HaveKey(AnnotationQuotaExceededBy))
+	})
+
+	It("clears namespaces the quota no longer selects", func() {
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev", "prod"}, used("12", "1"))
+		crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{Namespace: "dev"}, {Namespace: "prod"}}
+
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev"}, used("12", "1"))
+		Expect(annotations("dev")).To(HaveKey(AnnotationQuotaExceeded))
+		Expect(annotations("prod")).NotTo(HaveKey(AnnotationQuotaExceeded))
+	})
+
+	It("leaves annotations set by another quota alone when within limits", func() {
+		other := crq.DeepCopy()
+		other.Name = "team-b"
+		r.annotateQuotaExceeded(ctx, other, []string{"dev"}, used("12", "1"))
+
+		r.annotateQuotaExceeded(ctx, crq, []string{"dev"}, used("1", "1"))
+		Expect(annotations("dev")).To(HaveKeyWithValue(AnnotationQuotaExceededBy, "team-b"))
+
+		Expect(r.clearQuotaExceeded(ctx, "team-a")).To(Succeed())
+		Expect(annotations("dev")).To(HaveKeyWithValue(AnnotationQuotaExceededBy, "team-b"))
+		Expect(r.clearQuotaExceeded(ctx, "team-b")).To(Succeed())
+		Expect(annotations("dev")).NotTo(HaveKey(AnnotationQuotaExceededBy))
+	})
+
+	It("needs the finalizer to clear annotations of deleted quotas", func() {
+		Expect(r.projectsObjects()).To(BeTrue())
+	})
+})
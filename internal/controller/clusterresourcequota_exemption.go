@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+const (
+	// ReasonExemptionWindowOpen marks a ClusterResourceQuota whose denials are
+	// turned into warnings until its exemption window closes.
+	ReasonExemptionWindowOpen = "ExemptionWindowOpen"
+	// ReasonExemptionWindowClosed marks a ClusterResourceQuota enforced again
+	// after its exemption window closed.
+	ReasonExemptionWindowClosed = "ExemptionWindowClosed"
+)
+
+// enforcementExemption returns the EnforcementExempt condition of crq at now,
+// nil for a quota that never had an exemption window, and how long until an
+// open window closes. The quota is exempt until the later of its
+// quota.powerapp.cloud/enforcement-exempt-until annotation and
+// --enforcement-exempt-until. Entering and leaving the window are recorded as
+// events, once each, by comparing with the stored condition.
+func (r *ClusterResourceQuotaReconciler) enforcementExemption(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	now time.Time,
+) (*metav1.Condition, time.Duration) {
+	var clusterWide time.Time
+	if r.Config != nil {
+		clusterWide = r.Config.EnforcementExemptUntilTime()
+	}
+	until := crq.EnforcementExemptUntil(clusterWide)
+	stored := meta.FindStatusCondition(crq.Status.Conditions, quotav1alpha1.ConditionEnforcementExempt)
+	if until.IsZero() && stored == nil {
+		return nil, 0
+	}
+	wasExempt := stored != nil && stored.Status == metav1.ConditionTrue
+
+	if !now.Before(until) {
+		if wasExempt {
+			r.logger.Info("Enforcement exemption window closed", zap.String("crq_name", crq.Name))
+			if r.EventRecorder != nil {
+				r.EventRecorder.EnforcementResumed(crq)
+			}
+		}
+		message := "No exemption window is set; requests exceeding the quota are denied"
+		if !until.IsZero() {
+			message = fmt.Sprintf("The exemption window closed at %s; requests exceeding the quota are denied",
+				until.UTC().Format(time.RFC3339))
+		}
+		return &metav1.Condition{
+			Type:               quotav1alpha1.ConditionEnforcementExempt,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonExemptionWindowClosed,
+			Message:            message,
+			ObservedGeneration: crq.Generation,
+		}, 0
+	}
+
+	if !wasExempt {
+		r.logger.Info("Enforcement exemption window open",
+			zap.String("crq_name", crq.Name),
+			zap.Time("until", until))
+		if r.EventRecorder != nil {
+			r.EventRecorder.EnforcementExempt(crq, until)
+		}
+	}
+	// Come back just past the deadline to close the window.
+	return &metav1.Condition{
+		Type:   quotav1alpha1.ConditionEnforcementExempt,
+		Status: metav1.ConditionTrue,
+		Reason: ReasonExemptionWindowOpen,
+		Message: fmt.Sprintf("Requests exceeding the quota are admitted with a warning until %s",
+			until.UTC().Format(time.RFC3339)),
+		ObservedGeneration: crq.Generation,
+	}, until.Sub(now) + time.Second
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sevents "k8s.io/client-go/tools/events"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+)
+
+var _ = Describe("ClusterResourceQuota enforcement exemption windows", func() {
+	var (
+		reconciler   *ClusterResourceQuotaReconciler
+		fakeRecorder *k8sevents.FakeRecorder
+		crq          *quotav1alpha1.ClusterResourceQuota
+		now          = time.Date(2026, 1, 2, 6, 0, 0, 0, time.UTC)
+	)
+
+	BeforeEach(func() {
+		fakeRecorder = k8sevents.NewFakeRecorder(10)
+		reconciler = &ClusterResourceQuotaReconciler{
+			Config:        &config.Config{},
+			EventRecorder: events.NewEventRecorder(fakeRecorder, zap.NewNop()),
+			logger:        zap.NewNop(),
+		}
+		crq = &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	})
+
+	// store keeps condition as the stored condition of crq.
+	store := func(condition *metav1.Condition) {
+		crq.Status.Conditions = []metav1.Condition{*condition}
+	}
+
+	It("sets no condition on a quota that never had a window", func() {
+		condition, wait := reconciler.enforcementExemption(crq, now)
+		Expect(condition).To(BeNil())
+		Expect(wait).To(BeZero())
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("opens the annotated window once and requeues just past its end", func() {
+		crq.Annotations = map[string]string{quotav1alpha1.AnnotationEnforcementExemptUntil: "2026-01-02T07:00:00Z"}
+
+		condition, wait := reconciler.enforcementExemption(crq, now)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonExemptionWindowOpen))
+		Expect(wait).To(Equal(time.Hour + time.Second))
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring(events.ReasonEnforcementExempt)))
+
+		store(condition)
+		_, _ = reconciler.enforcementExemption(crq, now.Add(time.Minute))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("opens the cluster-wide window for every quota", func() {
+		reconciler.Config.EnforcementExemptUntil = "2026-01-02T06:30:00Z"
+
+		condition, wait := reconciler.enforcementExemption(crq, now)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("2026-01-02T06:30:00Z"))
+		Expect(wait).To(Equal(30*time.Minute + time.Second))
+	})
+
+	It("closes the window once, with an event", func() {
+		crq.Annotations = map[string]string{quotav1alpha1.AnnotationEnforcementExemptUntil: "2026-01-02T07:00:00Z"}
+		condition, _ := reconciler.enforcementExemption(crq, now)
+		store(condition)
+		Expect(fakeRecorder.Events).To(Receive())
+
+		condition, wait := reconciler.enforcementExemption(crq, now.Add(2*time.Hour))
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ReasonExemptionWindowClosed))
+		Expect(wait).To(BeZero())
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring(events.ReasonEnforcementResumed)))
+
+		store(condition)
+		_, _ = reconciler.enforcementExemption(crq, now.Add(3*time.Hour))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("closes the window when the annotation is removed", func() {
+		crq.Annotations = map[string]string{quotav1alpha1.AnnotationEnforcementExemptUntil: "2026-01-02T07:00:00Z"}
+		condition, _ := reconciler.enforcementExemption(crq, now)
+		store(condition)
+		Expect(fakeRecorder.Events).To(Receive())
+
+		crq.Annotations = nil
+		condition, _ = reconciler.enforcementExemption(crq, now)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring(events.ReasonEnforcementResumed)))
+	})
+})
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+// federationResyncInterval is how often a federated quota is reconciled to
+// pick up usage changes in other clusters, which raise no local events. An
+// unchanged report is also refreshed this often so its time shows the cluster
+// is still reporting.
+const federationResyncInterval = 30 * time.Second
+
+const (
+	// ReasonFederationReportsStale marks a federated ClusterResourceQuota some
+	// of whose clusters stopped reporting, so admission leaves their usage out.
+	ReasonFederationReportsStale = "ReportsStale"
+	// ReasonFederationReportsCurrent marks a federated ClusterResourceQuota
+	// whose clusters all reported within spec.federation.reportTTL.
+	ReasonFederationReportsCurrent = "ReportsCurrent"
+	// ReasonNotFederated marks a ClusterResourceQuota that is no longer
+	// federated, clearing a stale-reports condition set before.
+	ReasonNotFederated = "NotFederated"
+)
+
+// federated reports whether crq's usage is exchanged with the federation hub.
+func (r *ClusterResourceQuotaReconciler) federated(crq *quotav1alpha1.ClusterResourceQuota) bool {
+	return crq.Spec.Federation != nil && r.ClusterName != "" && r.HubClient != nil
+}
+
+// syncFederation reports this cluster's usage to the hub and returns the
+// federation status to store locally. The stored status is kept when the hub
+// cannot be reached, so admission goes on counting the other clusters' last
+// known usage, and when this controller is not a federation member, so a hub
+// that only collects usage keeps the members' reports.
+func (r *ClusterResourceQuotaReconciler) syncFederation(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	used quotav1alpha1.ResourceList,
+) *quotav1alpha1.FederationStatus {
+	if crq.Spec.Federation == nil {
+		return nil
+	}
+	if !r.federated(crq) {
+		return crq.Status.Federation
+	}
+	status, err := r.reportFederatedUsage(ctx, crq.Name, used, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to report usage to the federation hub",
+			zap.String("crq_name", crq.Name),
+			zap.String("cluster", r.ClusterName),
+			zap.Error(err))
+		metrics.FederationReportErrors.WithLabelValues(crq.Name).Inc()
+		return crq.Status.Federation
+	}
+	return status
+}
+
+// staleReportsCondition reports the clusters of federation, other than this
+// one, whose last report is older than crq's spec.federation.reportTTL at now.
+// It is nil for quotas that were never federated.
+func staleReportsCondition(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	federation *quotav1alpha1.FederationStatus,
+	now time.Time,
+) *metav1.Condition {
+	condition := &metav1.Condition{
+		Type:               quotav1alpha1.ConditionStaleFederationReports,
+		ObservedGeneration: crq.Generation,
+	}
+	if crq.Spec.Federation == nil {
+		if meta.FindStatusCondition(crq.Status.Conditions, quotav1alpha1.ConditionStaleFederationReports) == nil {
+			return nil
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonNotFederated
+		condition.Message = "The quota is not federated"
+		return condition
+	}
+
+	ttl := crq.Spec.Federation.EffectiveReportTTL()
+	var stale []string
+	if federation != nil {
+		for _, reported := range federation.Clusters {
+			if reported.Name != federation.Cluster && reported.Stale(ttl, now) {
+				stale = append(stale, reported.Name)
+			}
+		}
+	}
+	if len(stale) == 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonFederationReportsCurrent
+		condition.Message = fmt.Sprintf("Every cluster reported within %s", ttl)
+		return condition
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = ReasonFederationReportsStale
+	condition.Message = fmt.Sprintf("No report for over %s, so admission leaves their usage out, from clusters: %s",
+		ttl, strings.Join(stale, ", "))
+	return condition
+}
+
+// reportFederatedUsage upserts this cluster's entry on the hub's copy of the
+// quota. Clusters report concurrently, so the patch carries the
+// resourceVersion and is retried on conflict rather than overwriting another
+// cluster's entry.
+func (r *ClusterResourceQuotaReconciler) reportFederatedUsage(
+	ctx context.Context,
+	name string,
+	used quotav1alpha1.ResourceList,
+	now time.Time,
+) (*quotav1alpha1.FederationStatus, error) {
+	var clusters []quotav1alpha1.ClusterUsage
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		hub := &quotav1alpha1.ClusterResourceQuota{}
+		if err := r.HubClient.Get(ctx, types.NamespacedName{Name: name}, hub); err != nil {
+			return fmt.Errorf("failed to get ClusterResourceQuota %q from the hub: %w", name, err)
+		}
+		updated := hub.DeepCopy()
+		if updated.Status.Federation == nil {
+			updated.Status.Federation = &quotav1alpha1.FederationStatus{}
+		}
+		changed := upsertClusterUsage(updated.Status.Federation, r.ClusterName, used, now)
+		clusters = updated.Status.Federation.Clusters
+		if !changed {
+			return nil
+		}
+		return r.HubClient.Status().Patch(ctx, updated,
+			client.MergeFromWithOptions(hub, client.MergeFromWithOptimisticLock{}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &quotav1alpha1.FederationStatus{Cluster: r.ClusterName, Clusters: clusters}, nil
+}
+
+// upsertClusterUsage sets cluster's entry in status, keeping entries sorted by
+// name. It reports whether anything changed: the usage moved, the cluster is
+// new, or its last report is older than federationResyncInterval.
+func upsertClusterUsage(
+	status *quotav1alpha1.FederationStatus,
+	cluster string,
+	used quotav1alpha1.ResourceList,
+	now time.Time,
+) bool {
+	entry := quotav1alpha1.ClusterUsage{
+		Name:           cluster,
+		Used:           used.DeepCopy(),
+		LastReportTime: metav1.NewTime(now),
+	}
+	for i := range status.Clusters {
+		current := &status.Clusters[i]
+		if current.Name != cluster {
+			continue
+		}
+		if apiequality.Semantic.DeepEqual(current.Used, entry.Used) &&
+			now.Sub(current.LastReportTime.Time) < federationResyncInterval {
+			return false
+		}
+		*current = entry
+		return true
+	}
+	status.Clusters = append(status.Clusters, entry)
+	sort.Slice(status.Clusters, func(i, j int) bool {
+		return status.Clusters[i].Name < status.Clusters[j].Name
+	})
+	return true
+}
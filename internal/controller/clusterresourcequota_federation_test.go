@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler federation", func() {
+	var (
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		hub client.Client
+		r   *ClusterResourceQuotaReconciler
+	)
+
+	cpu := func(q string) quotav1alpha1.ResourceList {
+		return quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse(q)}
+	}
+
+	newHub := func(clusters ...quotav1alpha1.ClusterUsage) {
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard:       cpu("10"),
+				Federation: &quotav1alpha1.FederationSpec{},
+			},
+		}
+		if len(clusters) > 0 {
+			crq.Status.Federation = &quotav1alpha1.FederationStatus{Clusters: clusters}
+		}
+		hub = fake.NewClientBuilder().
+			WithObjects(crq).
+			WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+			Build()
+		r = &ClusterResourceQuotaReconciler{ClusterName: "us-east", HubClient: hub, logger: zap.NewNop()}
+	}
+
+	hubClusters := func() []quotav1alpha1.ClusterUsage {
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(hub.Get(ctx, types.NamespacedName{Name: "shared"}, crq)).To(Succeed())
+		Expect(crq.Status.Federation).NotTo(BeNil())
+		return crq.Status.Federation.Clusters
+	}
+
+	It("adds this cluster's usage next to the other clusters' on the hub", func() {
+		newHub(quotav1alpha1.ClusterUsage{Name: "us-west", Used: cpu("4"), LastReportTime: metav1.NewTime(now)})
+
+		status, err := r.reportFederatedUsage(ctx, "shared", cpu("3"), now)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(status.Cluster).To(Equal("us-east"))
+		Expect(status.Clusters).To(HaveLen(2))
+		Expect(status.Clusters[0].Name).To(Equal("us-east"))
+		Expect(status.Clusters[1].Name).To(Equal("us-west"))
+		clusters := hubClusters()
+		Expect(clusters).To(HaveLen(2))
+		Expect(clusters[0].Used).To(HaveKeyWithValue(corev1.ResourceRequestsCPU, resource.MustParse("3")))
+	})
+
+	It("skips the hub write while an unchanged report is fresh", func() {
+		newHub(quotav1alpha1.ClusterUsage{Name: "us-east", Used: cpu("3"), LastReportTime: metav1.NewTime(now)})
+
+		_, err := r.reportFederatedUsage(ctx, "shared", cpu("3"), now.Add(10*time.Second))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hubClusters()[0].LastReportTime.Time).To(BeTemporally("==", now))
+
+		_, err = r.reportFederatedUsage(ctx, "shared", cpu("3"), now.Add(federationResyncInterval))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hubClusters()[0].LastReportTime.Time).To(BeTemporally("==", now.Add(federationResyncInterval)))
+	})
+
+	It("names the clusters that stopped reporting", func() {
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{Federation: &quotav1alpha1.FederationSpec{}},
+		}
+		lastReport := now.Add(-quotav1alpha1.DefaultFederationReportTTL - time.Second)
+		federation := &quotav1alpha1.FederationStatus{Cluster: "us-east", Clusters: []quotav1alpha1.ClusterUsage{
+			{Name: "eu-west", LastReportTime: metav1.NewTime(lastReport)},
+			{Name: "us-east", LastReportTime: metav1.NewTime(lastReport)},
+			{Name: "us-west", LastReportTime: metav1.NewTime(now)},
+		}}
+
+		condition := staleReportsCondition(crq, federation, now)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonFederationReportsStale))
+		Expect(condition.Message).To(HaveSuffix("from clusters: eu-west"))
+
+		crq.Spec.Federation.ReportTTL = &metav1.Duration{Duration: time.Hour}
+		Expect(staleReportsCondition(crq, federation, now).Status).To(Equal(metav1.ConditionFalse))
+
+		crq.Spec.Federation = nil
+		Expect(staleReportsCondition(crq, federation, now)).To(BeNil())
+		crq.Status.Conditions = []metav1.Condition{*condition}
+		Expect(staleReportsCondition(crq, federation, now).Reason).To(Equal(ReasonNotFederated))
+	})
+
+	It("keeps the last federation status when the hub has no such quota", func() {
+		newHub()
+		stored := &quotav1alpha1.FederationStatus{Cluster: "us-east"}
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing"},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Federation: &quotav1alpha1.FederationSpec{}},
+			Status:     quotav1alpha1.ClusterResourceQuotaStatus{Federation: stored},
+		}
+
+		Expect(r.syncFederation(ctx, crq, cpu("1"))).To(BeIdenticalTo(stored))
+	})
+
+	It("keeps the members' reports on a hub that is not a member itself", func() {
+		r = &ClusterResourceQuotaReconciler{logger: zap.NewNop()}
+		stored := &quotav1alpha1.FederationStatus{Clusters: []quotav1alpha1.ClusterUsage{{Name: "us-west"}}}
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			Spec:   quotav1alpha1.ClusterResourceQuotaSpec{Federation: &quotav1alpha1.FederationSpec{}},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{Federation: stored},
+		}
+
+		Expect(r.syncFederation(ctx, crq, cpu("1"))).To(BeIdenticalTo(stored))
+		crq.Spec.Federation = nil
+		Expect(r.syncFederation(ctx, crq, cpu("1"))).To(BeNil())
+	})
+})
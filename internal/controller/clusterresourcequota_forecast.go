@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+const (
+	// forecastWindow is how far back the usage a forecast extrapolates goes.
+	forecastWindow = time.Hour
+	// forecastSampleInterval is the least time between two usage samples of a
+	// resource. A later reconcile within it replaces the latest sample, so a
+	// burst of reconciles does not crowd the older samples out.
+	forecastSampleInterval = time.Minute
+	// forecastMinSamples is the fewest samples a forecast is made from.
+	forecastMinSamples = 3
+	// forecastTolerance is how far, as a fraction of the time remaining, a new
+	// exhaustion time must move from the stored one to replace it. Without it
+	// every reconcile would nudge the time and write the status again.
+	forecastTolerance = 0.1
+	// forecastHorizon is the furthest a resource is forecast to run out. Usage
+	// growing slower than that is treated as flat.
+	forecastHorizon = 365 * 24 * time.Hour
+)
+
+// usageSample is the total usage of a resource at one reconcile.
+type usageSample struct {
+	at   time.Time
+	used float64
+}
+
+// forecastExhaustion samples usage for every tracked resource of crq and
+// returns when each growing resource reaches its hard limit, sorted by
+// resource. The rate is the least-squares slope of the samples of the last
+// forecastWindow. CRQExhaustionETA is set for every forecast resource and
+// removed for the others.
+func (r *ClusterResourceQuotaReconciler) forecastExhaustion(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	usage quotav1alpha1.ResourceList,
+	now time.Time,
+) []quotav1alpha1.UsageForecast {
+	hard := crq.Spec.TrackedHard()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.usageHistory == nil {
+		r.usageHistory = make(map[string]map[corev1.ResourceName][]usageSample)
+	}
+	history := r.usageHistory[crq.Name]
+	if history == nil {
+		history = make(map[corev1.ResourceName][]usageSample)
+		r.usageHistory[crq.Name] = history
+	}
+	for resourceName := range history {
+		if _, ok := hard[resourceName]; !ok {
+			delete(history, resourceName)
+			metrics.CRQExhaustionETA.DeleteLabelValues(crq.Name, string(resourceName))
+		}
+	}
+
+	var forecasts []quotav1alpha1.UsageForecast
+	for resourceName, limit := range hard {
+		used := usage[resourceName]
+		samples := addUsageSample(history[resourceName], usageSample{at: now, used: used.AsApproximateFloat64()})
+		history[resourceName] = samples
+
+		eta, ok := exhaustionETA(samples, limit.AsApproximateFloat64())
+		if !ok {
+			metrics.CRQExhaustionETA.DeleteLabelValues(crq.Name, string(resourceName))
+			continue
+		}
+		metrics.CRQExhaustionETA.WithLabelValues(crq.Name, string(resourceName)).Set(eta.Seconds())
+		forecasts = append(forecasts, quotav1alpha1.UsageForecast{
+			Resource:       resourceName,
+			ExhaustionTime: stableExhaustionTime(crq.Status.Forecasts, resourceName, now, eta),
+		})
+	}
+	slices.SortFunc(forecasts, func(a, b quotav1alpha1.UsageForecast) int {
+		return strings.Compare(string(a.Resource), string(b.Resource))
+	})
+	return forecasts
+}
+
+// forgetUsageHistory drops the usage samples and exhaustion series of the
+// named CRQ.
+func (r *ClusterResourceQuotaReconciler) forgetUsageHistory(crqName string) {
+	r.mu.Lock()
+	for resourceName := range r.usageHistory[crqName] {
+		metrics.CRQExhaustionETA.DeleteLabelValues(crqName, string(resourceName))
+	}
+	delete(r.usageHistory, crqName)
+	r.mu.Unlock()
+}
+
+// addUsageSample appends sample to samples, replacing the latest one if it is
+// less than forecastSampleInterval older, and drops the samples that fell out
+// of forecastWindow.
+func addUsageSample(samples []usageSample, sample usageSample) []usageSample {
+	if n := len(samples); n > 0 && sample.at.Sub(samples[n-1].at) < forecastSampleInterval {
+		samples = samples[:n-1]
+	}
+	samples = append(samples, sample)
+	start := 0
+	for start < len(samples) && sample.at.Sub(samples[start].at) > forecastWindow {
+		start++
+	}
+	return samples[start:]
+}
+
+// exhaustionETA returns how long until the latest of samples reaches hard at
+// the least-squares rate of all of them. It reports false when there are too
+// few samples, usage is not growing, it already reached hard, or it would take
+// longer than forecastHorizon.
+func exhaustionETA(samples []usageSample, hard float64) (time.Duration, bool) {
+	if len(samples) < forecastMinSamples {
+		return 0, false
+	}
+	latest := samples[len(samples)-1].used
+	if latest >= hard {
+		return 0, false
+	}
+
+	origin := samples[0].at
+	var meanX, meanY float64
+	for _, s := range samples {
+		meanX += s.at.Sub(origin).Seconds()
+		meanY += s.used
+	}
+	meanX /= float64(len(samples))
+	meanY /= float64(len(samples))
+	var covariance, variance float64
+	for _, s := range samples {
+		dx := s.at.Sub(origin).Seconds() - meanX
+		covariance += dx * (s.used - meanY)
+		variance += dx * dx
+	}
+	if variance == 0 || covariance <= 0 {
+		return 0, false
+	}
+	seconds := (hard - latest) / (covariance / variance)
+	if seconds > forecastHorizon.Seconds() {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// stableExhaustionTime is now+eta to the minute, unless the exhaustion time
+// stored for resourceName is within forecastTolerance of the time remaining.
+func stableExhaustionTime(
+	stored []quotav1alpha1.UsageForecast,
+	resourceName corev1.ResourceName,
+	now time.Time,
+	eta time.Duration,
+) metav1.Time {
+	next := now.Add(eta).Truncate(time.Minute)
+	for _, forecast := range stored {
+		if forecast.Resource != resourceName {
+			continue
+		}
+		drift := forecast.ExhaustionTime.Sub(next).Abs()
+		if float64(drift) <= float64(eta)*forecastTolerance {
+			return forecast.ExhaustionTime
+		}
+	}
+	return metav1.NewTime(next)
+}
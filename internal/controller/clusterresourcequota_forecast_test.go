@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("ClusterResourceQuota exhaustion forecast", func() {
+	var (
+		reconciler *ClusterResourceQuotaReconciler
+		crq        *quotav1alpha1.ClusterResourceQuota
+		start      time.Time
+	)
+
+	pods := func(count string) quotav1alpha1.ResourceList {
+		return quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse(count)}
+	}
+
+	BeforeEach(func() {
+		reconciler = &ClusterResourceQuotaReconciler{logger: zap.NewNop()}
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "forecast-team"},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Hard: pods("100")},
+		}
+		start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		reconciler.forgetUsageHistory(crq.Name)
+	})
+
+	It("forecasts nothing until there are enough samples", func() {
+		Expect(reconciler.forecastExhaustion(crq, pods("10"), start)).To(BeEmpty())
+		Expect(reconciler.forecastExhaustion(crq, pods("20"), start.Add(time.Minute))).To(BeEmpty())
+	})
+
+	It("extrapolates steady growth to the hard limit", func() {
+		reconciler.forecastExhaustion(crq, pods("10"), start)
+		reconciler.forecastExhaustion(crq, pods("20"), start.Add(time.Minute))
+		forecasts := reconciler.forecastExhaustion(crq, pods("30"), start.Add(2*time.Minute))
+
+		Expect(forecasts).To(HaveLen(1))
+		Expect(forecasts[0].Resource).To(Equal(corev1.ResourcePods))
+		Expect(forecasts[0].ExhaustionTime.Time).To(Equal(start.Add(9 * time.Minute)))
+		eta := promtestutil.ToFloat64(metrics.CRQExhaustionETA.WithLabelValues(crq.Name, "pods"))
+		Expect(eta).To(BeNumerically("~", 420, 1))
+	})
+
+	It("forecasts nothing for flat or falling usage and removes the series", func() {
+		reconciler.forecastExhaustion(crq, pods("10"), start)
+		reconciler.forecastExhaustion(crq, pods("20"), start.Add(time.Minute))
+		Expect(reconciler.forecastExhaustion(crq, pods("30"), start.Add(2*time.Minute))).To(HaveLen(1))
+
+		reconciler.forecastExhaustion(crq, pods("20"), start.Add(3*time.Minute))
+		Expect(reconciler.forecastExhaustion(crq, pods("10"), start.Add(4*time.Minute))).To(BeEmpty())
+		Expect(promtestutil.CollectAndCount(metrics.CRQExhaustionETA, "pac_crq_exhaustion_eta_seconds")).To(Equal(0))
+	})
+
+	It("forecasts nothing once usage reached the hard limit", func() {
+		reconciler.forecastExhaustion(crq, pods("60"), start)
+		reconciler.forecastExhaustion(crq, pods("80"), start.Add(time.Minute))
+		Expect(reconciler.forecastExhaustion(crq, pods("100"), start.Add(2*time.Minute))).To(BeEmpty())
+	})
+
+	It("replaces the latest sample within the sample interval", func() {
+		reconciler.forecastExhaustion(crq, pods("10"), start)
+		reconciler.forecastExhaustion(crq, pods("20"), start.Add(time.Minute))
+		Expect(reconciler.forecastExhaustion(crq, pods("25"), start.Add(90*time.Second))).To(BeEmpty())
+	})
+
+	It("keeps the stored exhaustion time while the forecast barely moves", func() {
+		stored := metav1.NewTime(start.Add(9*time.Minute + 20*time.Second))
+		crq.Status.Forecasts = []quotav1alpha1.UsageForecast{
+			{Resource: corev1.ResourcePods, ExhaustionTime: stored},
+		}
+		reconciler.forecastExhaustion(crq, pods("10"), start)
+		reconciler.forecastExhaustion(crq, pods("20"), start.Add(time.Minute))
+		forecasts := reconciler.forecastExhaustion(crq, pods("30"), start.Add(2*time.Minute))
+
+		Expect(forecasts).To(HaveLen(1))
+		Expect(forecasts[0].ExhaustionTime).To(Equal(stored))
+	})
+
+	It("drops samples older than the forecast window", func() {
+		samples := addUsageSample(nil, usageSample{at: start, used: 1})
+		samples = addUsageSample(samples, usageSample{at: start.Add(30 * time.Minute), used: 2})
+		samples = addUsageSample(samples, usageSample{at: start.Add(forecastWindow + time.Minute), used: 3})
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[0].used).To(Equal(2.0))
+	})
+})
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+const (
+	// ReasonHardLimitsSet marks a ClusterResourceQuota that limits at least one resource.
+	ReasonHardLimitsSet = "HardLimitsSet"
+	// ReasonNoHardLimits marks a ClusterResourceQuota that limits nothing.
+	ReasonNoHardLimits = "NoHardLimits"
+)
+
+// hardLimitsCondition returns the NoHardLimits condition for crq. Quotas that
+// limit nothing are only admitted while --require-hard-limits is off, or were
+// created before it was turned on.
+func hardLimitsCondition(crq *quotav1alpha1.ClusterResourceQuota) metav1.Condition {
+	if crq.Spec.HasTrackedLimits() {
+		return metav1.Condition{
+			Type:               quotav1alpha1.ConditionNoHardLimits,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonHardLimitsSet,
+			Message:            "The quota limits at least one resource",
+			ObservedGeneration: crq.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:   quotav1alpha1.ConditionNoHardLimits,
+		Status: metav1.ConditionTrue,
+		Reason: ReasonNoHardLimits,
+		Message: "The quota limits nothing: spec.hard has no key that spec.enforcementPolicy leaves on " +
+			"and spec.topologyHard sets no limit",
+		ObservedGeneration: crq.Generation,
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("hardLimitsCondition", func() {
+	quotaWith := func(spec quotav1alpha1.ClusterResourceQuotaSpec) *quotav1alpha1.ClusterResourceQuota {
+		return &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "q", Generation: 3}, Spec: spec}
+	}
+
+	It("flags a quota without hard limits", func() {
+		condition := hardLimitsCondition(quotaWith(quotav1alpha1.ClusterResourceQuotaSpec{}))
+		Expect(condition.Type).To(Equal(quotav1alpha1.ConditionNoHardLimits))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonNoHardLimits))
+		Expect(condition.ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	It("flags a quota whose every hard limit is turned off", func() {
+		condition := hardLimitsCondition(quotaWith(quotav1alpha1.ClusterResourceQuotaSpec{
+			Hard: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+			EnforcementPolicy: map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+				corev1.ResourcePods: quotav1alpha1.EnforcementOff,
+			},
+		}))
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("clears the flag for hard or topology limits", func() {
+		Expect(hardLimitsCondition(quotaWith(quotav1alpha1.ClusterResourceQuotaSpec{
+			Hard: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+		})).Status).To(Equal(metav1.ConditionFalse))
+		Expect(hardLimitsCondition(quotaWith(quotav1alpha1.ClusterResourceQuotaSpec{
+			TopologyKey: "topology.kubernetes.io/zone",
+			TopologyHard: map[string]quotav1alpha1.ResourceList{
+				"us-east-1a": {corev1.ResourcePods: resource.MustParse("5")},
+			},
+		})).Status).To(Equal(metav1.ConditionFalse))
+	})
+})
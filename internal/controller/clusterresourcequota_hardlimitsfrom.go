@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// HardLimitsFieldManager is the server-side apply field manager owning the
+// spec.hard keys read from spec.hardLimitsFrom. Keys it stops applying, because
+// they left the ConfigMap or spec.hardLimitsFrom was removed, are removed from
+// spec.hard.
+const HardLimitsFieldManager = "pac-quota-controller-hard-limits-from"
+
+const (
+	// ReasonHardLimitsResolved marks hard limits read from their ConfigMap.
+	ReasonHardLimitsResolved = "Resolved"
+	// ReasonHardLimitsConfigMapNotFound marks a spec.hardLimitsFrom ConfigMap that does not exist.
+	ReasonHardLimitsConfigMapNotFound = "ConfigMapNotFound"
+	// ReasonHardLimitsInvalid marks a spec.hardLimitsFrom ConfigMap lacking a
+	// mapped key or holding a value that is not a quantity.
+	ReasonHardLimitsInvalid = "InvalidHardLimits"
+)
+
+// hardLimitsResolutionError is a spec.hardLimitsFrom ConfigMap the limits
+// cannot be read from. It is reported in the HardLimitsResolved condition
+// rather than retried: the ConfigMap watch brings the quota back once it is
+// fixed.
+type hardLimitsResolutionError struct {
+	reason  string
+	message string
+}
+
+func (e *hardLimitsResolutionError) Error() string { return e.message }
+
+// syncHardLimitsFrom applies the limits spec.hardLimitsFrom reads from its
+// ConfigMap to spec.hard, and to crq so this reconcile enforces them. It
+// returns the HardLimitsResolved condition, nil for a quota without
+// spec.hardLimitsFrom. A quota that dropped spec.hardLimitsFrom loses the
+// limits read before.
+func (r *ClusterResourceQuotaReconciler) syncHardLimitsFrom(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) (*metav1.Condition, error) {
+	owned := ownedHardKeys(crq)
+	source := crq.Spec.HardLimitsFrom
+	if source == nil {
+		if len(owned) == 0 {
+			return nil, nil
+		}
+		return nil, r.applyHardLimits(ctx, crq, owned, nil)
+	}
+
+	condition := &metav1.Condition{
+		Type:               quotav1alpha1.ConditionHardLimitsResolved,
+		ObservedGeneration: crq.Generation,
+	}
+	limits, err := r.resolveHardLimits(ctx, source)
+	var resolveErr *hardLimitsResolutionError
+	switch {
+	case stderrors.As(err, &resolveErr):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = resolveErr.reason
+		condition.Message = resolveErr.message + "; spec.hard keeps the limits read last"
+		return condition, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := r.applyHardLimits(ctx, crq, owned, limits); err != nil {
+		return nil, err
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = ReasonHardLimitsResolved
+	condition.Message = fmt.Sprintf("Read %d hard limits from ConfigMap %s/%s",
+		len(limits), source.Namespace, source.Name)
+	return condition, nil
+}
+
+// resolveHardLimits reads the limits of source from its ConfigMap.
+func (r *ClusterResourceQuotaReconciler) resolveHardLimits(
+	ctx context.Context,
+	source *quotav1alpha1.HardLimitsSource,
+) (quotav1alpha1.ResourceList, error) {
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: source.Namespace, Name: source.Name}
+	if err := r.Get(ctx, key, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, &hardLimitsResolutionError{
+				reason:  ReasonHardLimitsConfigMapNotFound,
+				message: fmt.Sprintf("ConfigMap %s not found", key),
+			}
+		}
+		return nil, err
+	}
+
+	items := source.Items
+	if len(items) == 0 {
+		for _, k := range slices.Sorted(maps.Keys(cm.Data)) {
+			items = append(items, quotav1alpha1.HardLimitItem{Key: k, Resource: corev1.ResourceName(k)})
+		}
+	}
+	limits := make(quotav1alpha1.ResourceList, len(items))
+	for _, item := range items {
+		value, ok := cm.Data[item.Key]
+		if !ok {
+			return nil, &hardLimitsResolutionError{
+				reason:  ReasonHardLimitsInvalid,
+				message: fmt.Sprintf("ConfigMap %s has no key %q", key, item.Key),
+			}
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(value))
+		if err != nil {
+			return nil, &hardLimitsResolutionError{
+				reason:  ReasonHardLimitsInvalid,
+				message: fmt.Sprintf("ConfigMap %s key %q is not a quantity: %q", key, item.Key, value),
+			}
+		}
+		limits[item.Resource] = q
+	}
+	return limits, nil
+}
+
+// applyHardLimits server-side applies limits as the HardLimitsFieldManager's
+// part of spec.hard, unless spec.hard already holds exactly them under it.
+// crq is updated to match.
+func (r *ClusterResourceQuotaReconciler) applyHardLimits(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	owned []corev1.ResourceName,
+	limits quotav1alpha1.ResourceList,
+) error {
+	if hardLimitsApplied(crq.Spec.Hard, owned, limits) {
+		return nil
+	}
+	hard := make(map[string]any, len(limits))
+	for resourceName, limit := range limits {
+		hard[string(resourceName)] = limit.String()
+	}
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": quotav1alpha1.GroupVersion.String(),
+		"kind":       "ClusterResourceQuota",
+		"metadata":   map[string]any{"name": crq.Name},
+	}}
+	if len(hard) > 0 {
+		obj.Object["spec"] = map[string]any{"hard": hard}
+	}
+	if err := r.Apply(ctx, client.ApplyConfigurationFromUnstructured(obj),
+		client.FieldOwner(HardLimitsFieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+	r.logger.Info("Applied hard limits from ConfigMap", zap.String("crq_name", crq.Name))
+
+	for _, resourceName := range owned {
+		delete(crq.Spec.Hard, resourceName)
+	}
+	if len(limits) > 0 && crq.Spec.Hard == nil {
+		crq.Spec.Hard = make(quotav1alpha1.ResourceList, len(limits))
+	}
+	for resourceName, limit := range limits {
+		crq.Spec.Hard[resourceName] = limit
+	}
+	return nil
+}
+
+// hardLimitsApplied reports whether hard holds limits, and the keys owned by
+// the HardLimitsFieldManager are exactly those of limits.
+func hardLimitsApplied(
+	hard quotav1alpha1.ResourceList,
+	owned []corev1.ResourceName,
+	limits quotav1alpha1.ResourceList,
+) bool {
+	if len(owned) != len(limits) {
+		return false
+	}
+	for _, resourceName := range owned {
+		limit, ok := limits[resourceName]
+		if !ok {
+			return false
+		}
+		current, ok := hard[resourceName]
+		if !ok || current.Cmp(limit) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ownedHardKeys returns the spec.hard keys the HardLimitsFieldManager owns.
+func ownedHardKeys(crq *quotav1alpha1.ClusterResourceQuota) []corev1.ResourceName {
+	var owned []corev1.ResourceName
+	for _, entry := range crq.ManagedFields {
+		if entry.Manager != HardLimitsFieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Spec struct {
+				Hard map[string]json.RawMessage `json:"f:hard"`
+			} `json:"f:spec"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for field := range fields.Spec.Hard {
+			if name, ok := strings.CutPrefix(field, "f:"); ok {
+				owned = append(owned, corev1.ResourceName(name))
+			}
+		}
+	}
+	slices.Sort(owned)
+	return owned
+}
+
+// findQuotasReadingConfigMap maps a ConfigMap to the ClusterResourceQuotas
+// whose spec.hardLimitsFrom reads it.
+func (r *ClusterResourceQuotaReconciler) findQuotasReadingConfigMap(
+	ctx context.Context,
+	obj client.Object,
+) []reconcile.Request {
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := r.List(ctx, crqList); err != nil {
+		r.logger.Error("Failed to list ClusterResourceQuotas for ConfigMap change", zap.Error(err))
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, crq := range crqList.Items {
+		source := crq.Spec.HardLimitsFrom
+		if source != nil && source.Namespace == obj.GetNamespace() && source.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: crq.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("ClusterResourceQuota hardLimitsFrom", func() {
+	var (
+		ctx = context.Background()
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+		cm  *corev1.ConfigMap
+	)
+
+	stored := func() *quotav1alpha1.ClusterResourceQuota {
+		GinkgoHelper()
+		obj := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "team-a"}, obj)).To(Succeed())
+		return obj
+	}
+
+	hardOf := func(crq *quotav1alpha1.ClusterResourceQuota) map[corev1.ResourceName]string {
+		hard := make(map[corev1.ResourceName]string, len(crq.Spec.Hard))
+		for resourceName, limit := range crq.Spec.Hard {
+			hard[resourceName] = limit.String()
+		}
+		return hard
+	}
+
+	BeforeEach(func() {
+		Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "finance", Name: "team-a-limits"},
+			Data:       map[string]string{"cpu": "20", "memory": " 64Gi "},
+		}
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("50")},
+				HardLimitsFrom: &quotav1alpha1.HardLimitsSource{
+					Namespace: "finance",
+					Name:      "team-a-limits",
+					Items: []quotav1alpha1.HardLimitItem{
+						{Key: "cpu", Resource: corev1.ResourceRequestsCPU},
+						{Key: "memory", Resource: corev1.ResourceRequestsMemory},
+					},
+				},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(crq, cm).
+			WithReturnManagedFields().
+			Build()
+		r = &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+	})
+
+	It("applies the mapped keys next to the other hard limits", func() {
+		crq := stored()
+		condition, err := r.syncHardLimitsFrom(ctx, crq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(ReasonHardLimitsResolved))
+
+		want := map[corev1.ResourceName]string{
+			corev1.ResourcePods:           "50",
+			corev1.ResourceRequestsCPU:    "20",
+			corev1.ResourceRequestsMemory: "64Gi",
+		}
+		Expect(hardOf(crq)).To(Equal(want))
+		Expect(hardOf(stored())).To(Equal(want))
+		Expect(ownedHardKeys(stored())).To(ConsistOf(corev1.ResourceRequestsCPU, corev1.ResourceRequestsMemory))
+	})
+
+	It("reads every key as a resource name without items", func() {
+		crq := stored()
+		crq.Spec.HardLimitsFrom.Items = nil
+		cm.Data = map[string]string{"requests.storage": "1Ti"}
+		Expect(c.Update(ctx, cm)).To(Succeed())
+
+		_, err := r.syncHardLimitsFrom(ctx, crq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hardOf(stored())).To(HaveKeyWithValue(corev1.ResourceRequestsStorage, "1Ti"))
+	})
+
+	It("removes keys that left the ConfigMap's mapping", func() {
+		_, err := r.syncHardLimitsFrom(ctx, stored())
+		Expect(err).NotTo(HaveOccurred())
+
+		crq := stored()
+		crq.Spec.HardLimitsFrom.Items = crq.Spec.HardLimitsFrom.Items[:1]
+		_, err = r.syncHardLimitsFrom(ctx, crq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hardOf(stored())).To(Equal(map[corev1.ResourceName]string{
+			corev1.ResourcePods:        "50",
+			corev1.ResourceRequestsCPU: "20",
+		}))
+	})
+
+	It("keeps the limits read last while the ConfigMap is invalid", func() {
+		_, err := r.syncHardLimitsFrom(ctx, stored())
+		Expect(err).NotTo(HaveOccurred())
+
+		cm.Data["cpu"] = "twenty"
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		condition, err := r.syncHardLimitsFrom(ctx, stored())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ReasonHardLimitsInvalid))
+		Expect(condition.Message).To(ContainSubstring(`"cpu"`))
+		Expect(hardOf(stored())).To(HaveKeyWithValue(corev1.ResourceRequestsCPU, "20"))
+
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+		condition, err = r.syncHardLimitsFrom(ctx, stored())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Reason).To(Equal(ReasonHardLimitsConfigMapNotFound))
+	})
+
+	It("drops the limits it read once hardLimitsFrom is removed", func() {
+		_, err := r.syncHardLimitsFrom(ctx, stored())
+		Expect(err).NotTo(HaveOccurred())
+
+		crq := stored()
+		crq.Spec.HardLimitsFrom = nil
+		condition, err := r.syncHardLimitsFrom(ctx, crq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition).To(BeNil())
+		Expect(hardOf(stored())).To(Equal(map[corev1.ResourceName]string{corev1.ResourcePods: "50"}))
+	})
+
+	It("maps a ConfigMap to the quotas reading it", func() {
+		Expect(r.findQuotasReadingConfigMap(ctx, cm)).To(ConsistOf(
+			HaveField("NamespacedName.Name", "team-a")))
+		other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "finance", Name: "other"}}
+		Expect(r.findQuotasReadingConfigMap(ctx, other)).To(BeEmpty())
+	})
+})
@@ -4,7 +4,13 @@ import (
 	"sort"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
 // quotaExceededCooldown is the minimum interval between QuotaExceeded events
@@ -13,10 +19,26 @@ import (
 const quotaExceededCooldown = 5 * time.Minute
 
 // handleNamespaceChanges detects and records namespace additions/removals.
-// Event emission happens outside the lock to avoid blocking reconciles.
-func (r *ClusterResourceQuotaReconciler) handleNamespaceChanges(crq *quotav1alpha1.ClusterResourceQuota, currentNamespaces []string) {
+// Added namespaces are reported with their freshly computed usage, removed
+// ones with the usage last stored in crq's status, both next to the new total.
+// A quota this process has not reconciled yet, as after a restart or a leader
+// failover, is compared with the namespaces of its stored status, so only
+// changes since the last status write are reported. Event emission happens
+// outside the lock to avoid blocking reconciles.
+func (r *ClusterResourceQuotaReconciler) handleNamespaceChanges(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	currentNamespaces []string,
+	totalUsage quotav1alpha1.ResourceList,
+	usageByNamespace []quotav1alpha1.ResourceQuotaStatusByNamespace,
+) {
 	r.mu.Lock()
-	previousNamespaces := r.previousNamespacesByQuota[crq.Name]
+	previousNamespaces, known := r.previousNamespacesByQuota[crq.Name]
+	if !known {
+		previousNamespaces = make([]string, 0, len(crq.Status.Namespaces))
+		for _, status := range crq.Status.Namespaces {
+			previousNamespaces = append(previousNamespaces, status.Namespace)
+		}
+	}
 
 	prevSet := make(map[string]bool, len(previousNamespaces))
 	for _, ns := range previousNamespaces {
@@ -47,18 +69,35 @@ func (r *ClusterResourceQuotaReconciler) handleNamespaceChanges(crq *quotav1alph
 	r.mu.Unlock()
 
 	for _, ns := range added {
-		r.EventRecorder.NamespaceAdded(crq, ns)
+		r.EventRecorder.NamespaceAdded(crq, ns, namespaceUsed(usageByNamespace, ns), totalUsage)
 	}
 	for _, ns := range removed {
-		r.EventRecorder.NamespaceRemoved(crq, ns)
+		r.EventRecorder.NamespaceRemoved(crq, ns, namespaceUsed(crq.Status.Namespaces, ns), totalUsage)
+	}
+}
+
+// forgetNamespaces drops the namespaces last seen for the named CRQ.
+func (r *ClusterResourceQuotaReconciler) forgetNamespaces(crqName string) {
+	r.mu.Lock()
+	delete(r.previousNamespacesByQuota, crqName)
+	r.mu.Unlock()
+}
+
+// namespaceUsed returns namespace's usage from statuses, or nil if absent.
+func namespaceUsed(statuses []quotav1alpha1.ResourceQuotaStatusByNamespace, namespace string) quotav1alpha1.ResourceList {
+	for i := range statuses {
+		if statuses[i].Namespace == namespace {
+			return statuses[i].Status.Used
+		}
 	}
+	return nil
 }
 
 // checkQuotaThresholds emits a QuotaExceeded event for each over-limit resource,
 // rate-limited to at most one event per CRQ+resource per quotaExceededCooldown.
 func (r *ClusterResourceQuotaReconciler) checkQuotaThresholds(crq *quotav1alpha1.ClusterResourceQuota, usage quotav1alpha1.ResourceList) {
 	now := time.Now()
-	for resourceName, limit := range crq.Spec.Hard {
+	for resourceName, limit := range crq.Spec.TrackedHard() {
 		used := usage[resourceName]
 		if limit.IsZero() || used.Cmp(limit) <= 0 {
 			continue
@@ -80,3 +119,52 @@ func (r *ClusterResourceQuotaReconciler) checkQuotaThresholds(crq *quotav1alpha1
 		r.EventRecorder.QuotaExceeded(crq, string(resourceName), used, limit)
 	}
 }
+
+// addOwnerKindUsage adds one namespace's usage of resourceName, split by owner
+// kind, into the running per-kind totals. When observedByPod is set (Actual
+// mode), CPU and memory come from it, the same source as the CRQ total, so the
+// per-kind values add up to the total.
+func addOwnerKindUsage(
+	totals map[string]quotav1alpha1.ResourceList,
+	podsByOwnerKind map[string][]corev1.Pod,
+	resourceName corev1.ResourceName,
+	observedByPod map[string]corev1.ResourceList,
+) {
+	observedName, observe := podmetrics.ObservedResource(resourceName)
+	observe = observe && observedByPod != nil
+	for kind, pods := range podsByOwnerKind {
+		if totals[kind] == nil {
+			totals[kind] = make(quotav1alpha1.ResourceList)
+		}
+		q := totals[kind][resourceName]
+		if observe {
+			for name := range countedPodNames(pods) {
+				q.Add(observedByPod[name][observedName])
+			}
+		} else {
+			q.Add(pod.CalculateUsageFromPods(pods, resourceName))
+		}
+		totals[kind][resourceName] = q
+	}
+}
+
+// recordOwnerKindUsage replaces the CRQ's owner-kind usage series so kinds
+// that no longer own any pods stop being reported.
+func (r *ClusterResourceQuotaReconciler) recordOwnerKindUsage(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	ownerKindUsage map[string]quotav1alpha1.ResourceList,
+) {
+	forgetOwnerKindUsage(crq.Name)
+	for kind, used := range ownerKindUsage {
+		for resourceName, q := range used {
+			metrics.CRQUsageByOwnerKind.
+				WithLabelValues(crq.Name, kind, string(resourceName)).
+				Set(percentOfHard(q, crq.Spec.Hard[resourceName]))
+		}
+	}
+}
+
+// forgetOwnerKindUsage drops every owner-kind usage series of the named CRQ.
+func forgetOwnerKindUsage(crqName string) {
+	metrics.CRQUsageByOwnerKind.DeletePartialMatch(prometheus.Labels{"crq_name": crqName})
+}
@@ -266,6 +266,22 @@ var _ = Describe("ClusterResourceQuota Helpers", func() {
 				Expect(fakeRecorder.Events).To(BeEmpty())
 			})
 
+			It("should not trigger violation for resources turned off by the enforcement policy", func() {
+				crqWithPolicy := testCRQ.DeepCopy()
+				crqWithPolicy.Spec.EnforcementPolicy = map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+					corev1.ResourceRequestsCPU: quotav1alpha1.EnforcementOff,
+				}
+
+				usage := quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("3"), // 3 > 2, but the key is not tracked
+				}
+
+				reconciler.checkQuotaThresholds(crqWithPolicy, usage)
+
+				Expect(fakeRecorder.Events).To(BeEmpty())
+				Expect(crqWithPolicy.Spec.TrackedHard()).NotTo(HaveKey(corev1.ResourceRequestsCPU))
+			})
+
 			It("should handle missing resources in usage (treats as zero)", func() {
 				usage := quotav1alpha1.ResourceList{
 					// Missing CPU resource, should be treated as zero
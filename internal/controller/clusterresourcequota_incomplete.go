@@ -0,0 +1,92 @@
+package controller
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReasonUsageCounted marks a ClusterResourceQuota whose usage was fully counted.
+	ReasonUsageCounted = "UsageCounted"
+	// ReasonCalculationFailed marks usage left incomplete by a failed calculation,
+	// such as a List call the API server rejected.
+	ReasonCalculationFailed = "CalculationFailed"
+	// ReasonUnsupportedResource marks usage left incomplete by hard keys no
+	// calculator counts.
+	ReasonUnsupportedResource = "UnsupportedResource"
+)
+
+// incompleteUsageRetryInterval is how soon a quota whose usage calculation
+// failed is reconciled again. Unsupported resources are not retried: they
+// cannot succeed until the spec changes.
+const incompleteUsageRetryInterval = 30 * time.Second
+
+// incompleteUsageCondition returns the IncompleteUsage condition for a quota
+// whose usage failed to count for the resources in incomplete.
+func incompleteUsageCondition(generation int64, incomplete map[corev1.ResourceName]error) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               quotav1alpha1.ConditionIncompleteUsage,
+		Status:             metav1.ConditionFalse,
+		Reason:             ReasonUsageCounted,
+		Message:            "Usage of every resource was counted",
+		ObservedGeneration: generation,
+	}
+	if len(incomplete) == 0 {
+		return condition
+	}
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = ReasonUnsupportedResource
+	if retryIncompleteUsage(incomplete) {
+		condition.Reason = ReasonCalculationFailed
+	}
+	condition.Message = incompleteUsageMessage(incomplete)
+	return condition
+}
+
+// incompleteResources lists the resources of incomplete, sorted, for
+// status.incompleteResources.
+func incompleteResources(incomplete map[corev1.ResourceName]error) []corev1.ResourceName {
+	if len(incomplete) == 0 {
+		return nil
+	}
+	names := make([]corev1.ResourceName, 0, len(incomplete))
+	for resourceName := range incomplete {
+		names = append(names, resourceName)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// incompleteUsageMessage lists the resources whose usage is incomplete and
+// why, sorted by resource so the message only changes when the failures do.
+func incompleteUsageMessage(incomplete map[corev1.ResourceName]error) string {
+	names := make([]string, 0, len(incomplete))
+	for resourceName := range incomplete {
+		names = append(names, string(resourceName))
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, incomplete[corev1.ResourceName(name)])
+	}
+	return "Usage could not be fully counted for " + strings.Join(parts, "; ")
+}
+
+// retryIncompleteUsage reports whether any failure in incomplete may clear on
+// a later reconcile, i.e. is not an unsupported resource.
+func retryIncompleteUsage(incomplete map[corev1.ResourceName]error) bool {
+	for _, err := range incomplete {
+		if !stderrors.Is(err, usage.ErrUnsupportedResource) {
+			return true
+		}
+	}
+	return false
+}
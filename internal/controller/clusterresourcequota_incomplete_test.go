@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler incomplete usage", func() {
+	ctx := context.Background()
+
+	Describe("computeUsage", func() {
+		It("keeps counting other resources and marks the failed one incomplete", func() {
+			c := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if _, ok := list.(*corev1.ConfigMapList); ok {
+						return errors.New("configmap list boom")
+					}
+					return c.List(ctx, list, opts...)
+				},
+			})
+			r := &ClusterResourceQuotaReconciler{
+				Client:                c,
+				ObjectCountCalculator: objectcount.NewObjectCountCalculator(c, zap.NewNop()),
+				logger:                zap.NewNop(),
+			}
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard: quotav1alpha1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("1"),
+						usage.ResourceConfigMaps:   resource.MustParse("5"),
+					},
+				},
+			}
+
+			u, err := r.computeUsage(ctx, crq, []string{"ns-a", "ns-b"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.incomplete).To(HaveLen(1))
+			Expect(u.incomplete[usage.ResourceConfigMaps]).To(MatchError(ContainSubstring("namespace ns-a: configmap list boom")))
+			Expect(u.total).To(HaveKey(corev1.ResourceRequestsCPU))
+			Expect(u.total[usage.ResourceConfigMaps]).To(Equal(resource.Quantity{}))
+		})
+	})
+
+	Describe("incompleteUsageCondition", func() {
+		It("is False when every resource was counted", func() {
+			condition := incompleteUsageCondition(3, nil)
+			Expect(condition.Type).To(Equal(quotav1alpha1.ConditionIncompleteUsage))
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(ReasonUsageCounted))
+			Expect(condition.ObservedGeneration).To(Equal(int64(3)))
+		})
+
+		It("reports unsupported resources, sorted, without retrying them", func() {
+			incomplete := map[corev1.ResourceName]error{
+				"widgets":    fmt.Errorf("namespace a: %w: widgets", usage.ErrUnsupportedResource),
+				"congigmaps": fmt.Errorf("namespace a: %w: congigmaps", usage.ErrUnsupportedResource),
+			}
+			condition := incompleteUsageCondition(1, incomplete)
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(ReasonUnsupportedResource))
+			Expect(condition.Message).To(MatchRegexp(`congigmaps: .*; widgets: `))
+			Expect(retryIncompleteUsage(incomplete)).To(BeFalse())
+		})
+
+		It("reports a failed calculation and retries it", func() {
+			incomplete := map[corev1.ResourceName]error{
+				"widgets":             fmt.Errorf("%w: widgets", usage.ErrUnsupportedResource),
+				usage.ResourceSecrets: errors.New("secret list boom"),
+			}
+			condition := incompleteUsageCondition(1, incomplete)
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(ReasonCalculationFailed))
+			Expect(condition.Message).To(ContainSubstring("secret list boom"))
+			Expect(retryIncompleteUsage(incomplete)).To(BeTrue())
+		})
+	})
+
+	Describe("updateStatus", func() {
+		It("applies the IncompleteUsage condition and keeps its transition time", func() {
+			Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard: quotav1alpha1.ResourceList{usage.ResourceSecrets: resource.MustParse("2")},
+				},
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+				WithObjects(crq).
+				WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+				Build()
+			r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+			stored := func() *quotav1alpha1.ClusterResourceQuota {
+				GinkgoHelper()
+				obj := &quotav1alpha1.ClusterResourceQuota{}
+				Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, obj)).To(Succeed())
+				return obj
+			}
+			condition := incompleteUsageCondition(0, map[corev1.ResourceName]error{
+				usage.ResourceSecrets: errors.New("secret list boom"),
+			})
+
+			Expect(r.updateStatus(ctx, stored(), quotav1alpha1.ResourceList{}, nil, nil, nil, nil, nil, condition)).To(Succeed())
+			first := meta.FindStatusCondition(stored().Status.Conditions, quotav1alpha1.ConditionIncompleteUsage)
+			Expect(first).NotTo(BeNil())
+			Expect(first.Status).To(Equal(metav1.ConditionTrue))
+			Expect(first.LastTransitionTime.IsZero()).To(BeFalse())
+
+			Expect(r.updateStatus(ctx, stored(), quotav1alpha1.ResourceList{}, nil, nil, nil, nil, nil, condition)).To(Succeed())
+			again := meta.FindStatusCondition(stored().Status.Conditions, quotav1alpha1.ConditionIncompleteUsage)
+			Expect(again.LastTransitionTime.Equal(&first.LastTransitionTime)).To(BeTrue())
+		})
+
+		It("lists the incomplete resources until they are counted", func() {
+			Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+			crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+				WithObjects(crq).
+				WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+				Build()
+			r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+			stored := func() *quotav1alpha1.ClusterResourceQuota {
+				GinkgoHelper()
+				obj := &quotav1alpha1.ClusterResourceQuota{}
+				Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, obj)).To(Succeed())
+				return obj
+			}
+			incomplete := map[corev1.ResourceName]error{
+				usage.ResourceSecrets: errors.New("secret list boom"),
+				"congigmaps":          fmt.Errorf("%w: congigmaps", usage.ErrUnsupportedResource),
+			}
+
+			Expect(r.updateStatus(ctx, stored(), quotav1alpha1.ResourceList{}, nil, nil, nil, nil,
+				incompleteResources(incomplete), incompleteUsageCondition(0, incomplete))).To(Succeed())
+			Expect(stored().Status.IncompleteResources).To(Equal([]corev1.ResourceName{"congigmaps", usage.ResourceSecrets}))
+
+			Expect(r.updateStatus(ctx, stored(), quotav1alpha1.ResourceList{}, nil, nil, nil, nil,
+				incompleteResources(nil), incompleteUsageCondition(0, nil))).To(Succeed())
+			Expect(stored().Status.IncompleteResources).To(BeEmpty())
+		})
+	})
+})
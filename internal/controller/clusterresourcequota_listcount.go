@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+type listCounterKey struct{}
+
+// withListCounter returns a context whose List calls through a
+// listCountingClient are counted in the returned counter.
+func withListCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	n := new(atomic.Int64)
+	return context.WithValue(ctx, listCounterKey{}, n), n
+}
+
+// listCountingClient counts the List calls made with a context from
+// withListCounter, so a reconcile can report how many it made whatever
+// helper made them.
+type listCountingClient struct {
+	client.Client
+}
+
+func (c listCountingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if n, ok := ctx.Value(listCounterKey{}).(*atomic.Int64); ok {
+		n.Add(1)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+// observeReconcile records the per-CRQ reconcile metrics.
+func observeReconcile(crqName string, seconds float64, namespaces int, listCalls int64) {
+	metrics.QuotaReconcileDuration.WithLabelValues(crqName).Observe(seconds)
+	metrics.QuotaReconcileNamespaces.WithLabelValues(crqName).Observe(float64(namespaces))
+	metrics.QuotaReconcileListCalls.WithLabelValues(crqName).Observe(float64(listCalls))
+}
+
+// forgetReconcileMetrics drops the per-CRQ reconcile series of the named CRQ.
+func forgetReconcileMetrics(crqName string) {
+	labels := prometheus.Labels{"crq_name": crqName}
+	metrics.QuotaReconcileDuration.DeletePartialMatch(labels)
+	metrics.QuotaReconcileNamespaces.DeletePartialMatch(labels)
+	metrics.QuotaReconcileListCalls.DeletePartialMatch(labels)
+	metrics.QuotaPaused.DeletePartialMatch(labels)
+	metrics.QuotaStatusUpdatesSkipped.DeletePartialMatch(labels)
+}
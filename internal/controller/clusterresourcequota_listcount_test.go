@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("reconcile metrics", func() {
+	It("counts only the List calls made with a counting context", func() {
+		c := listCountingClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+		ctx, listCalls := withListCounter(context.Background())
+		Expect(c.List(ctx, &corev1.NamespaceList{})).To(Succeed())
+		Expect(c.List(ctx, &corev1.PodList{})).To(Succeed())
+		Expect(c.List(context.Background(), &corev1.PodList{})).To(Succeed())
+
+		Expect(listCalls.Load()).To(Equal(int64(2)))
+	})
+
+	It("drops the series of a forgotten CRQ", func() {
+		observeReconcile("listcount-crq", 0.25, 3, 7)
+		Expect(testutil.CollectAndCount(metrics.QuotaReconcileListCalls)).To(BeNumerically(">=", 1))
+
+		forgetReconcileMetrics("listcount-crq")
+		Expect(metrics.QuotaReconcileDuration.DeleteLabelValues("listcount-crq")).To(BeFalse())
+		Expect(metrics.QuotaReconcileNamespaces.DeleteLabelValues("listcount-crq")).To(BeFalse())
+		Expect(metrics.QuotaReconcileListCalls.DeleteLabelValues("listcount-crq")).To(BeFalse())
+	})
+})
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+const (
+	// StatusMirrorName names the ConfigMap that mirrors a quota's status into
+	// each namespace it selects, for tenants who cannot read the
+	// cluster-scoped ClusterResourceQuota.
+	StatusMirrorName = "quota-status"
+	// LabelStatusMirror marks the mirror ConfigMaps; its value is the name of
+	// the mirrored ClusterResourceQuota.
+	LabelStatusMirror = "quota.powerapp.cloud/status-mirror"
+)
+
+// statusMirrorEnabled reports whether --status-mirror-enable is on.
+func (r *ClusterResourceQuotaReconciler) statusMirrorEnabled() bool {
+	return r.Config != nil && r.Config.StatusMirrorEnable
+}
+
+// mirrorStatus writes crq's hard, used and remaining totals to the
+// StatusMirrorName ConfigMap of every namespace, and deletes the ConfigMap
+// from namespaces crq no longer selects. A namespace's ConfigMap is rewritten
+// at most once per --status-mirror-min-interval; the returned duration is when
+// a skipped write is due, zero when none is. Failures are logged and do not
+// fail the reconcile: the ConfigMaps are a convenience copy of the status.
+func (r *ClusterResourceQuotaReconciler) mirrorStatus(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespaces []string,
+	used quotav1alpha1.ResourceList,
+	now time.Time,
+) time.Duration {
+	data := statusMirrorData(crq.Name, crq.Spec.TrackedHard(), used)
+	var wait time.Duration
+	for _, ns := range namespaces {
+		after, err := r.writeStatusMirror(ctx, crq, ns, data, now)
+		if err != nil {
+			r.logger.Warn("Failed to mirror quota status",
+				zap.String("crq_name", crq.Name),
+				zap.String("namespace", ns),
+				zap.Error(err))
+			continue
+		}
+		if after > 0 && (wait == 0 || after < wait) {
+			wait = after
+		}
+	}
+
+	for _, nsStatus := range crq.Status.Namespaces {
+		if slices.Contains(namespaces, nsStatus.Namespace) {
+			continue
+		}
+		if err := r.deleteStatusMirror(ctx, crq.Name, nsStatus.Namespace); err != nil {
+			r.logger.Warn("Failed to delete quota status mirror",
+				zap.String("crq_name", crq.Name),
+				zap.String("namespace", nsStatus.Namespace),
+				zap.Error(err))
+		}
+	}
+	return wait
+}
+
+// writeStatusMirror creates or updates the mirror ConfigMap in namespace. A
+// ConfigMap of that name the controller did not create for crq is left alone.
+func (r *ClusterResourceQuotaReconciler) writeStatusMirror(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespace string,
+	data map[string]string,
+	now time.Time,
+) (time.Duration, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: StatusMirrorName}, cm)
+	if errors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      StatusMirrorName,
+				Namespace: namespace,
+				Labels:    map[string]string{LabelStatusMirror: crq.Name},
+				// The garbage collector removes the mirrors with their quota.
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: quotav1alpha1.GroupVersion.String(),
+					Kind:       "ClusterResourceQuota",
+					Name:       crq.Name,
+					UID:        crq.UID,
+				}},
+			},
+			Data: data,
+		}
+		if err := r.Create(ctx, cm); err != nil {
+			return 0, fmt.Errorf("creating ConfigMap %s/%s: %w", namespace, StatusMirrorName, err)
+		}
+		r.markStatusMirrored(namespace, now)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("getting ConfigMap %s/%s: %w", namespace, StatusMirrorName, err)
+	}
+	if owner := cm.Labels[LabelStatusMirror]; owner != crq.Name {
+		r.logger.Debug("Not overwriting a ConfigMap the controller does not mirror this quota to",
+			zap.String("crq_name", crq.Name),
+			zap.String("namespace", namespace),
+			zap.String("mirrored_quota", owner))
+		return 0, nil
+	}
+	if maps.Equal(cm.Data, data) {
+		return 0, nil
+	}
+	if wait := r.statusMirrorWait(namespace, now); wait > 0 {
+		return wait, nil
+	}
+
+	cm.Data = data
+	if err := r.Update(ctx, cm); err != nil {
+		return 0, fmt.Errorf("updating ConfigMap %s/%s: %w", namespace, StatusMirrorName, err)
+	}
+	r.markStatusMirrored(namespace, now)
+	return 0, nil
+}
+
+// deleteStatusMirror deletes the mirror of quotaName from namespace, if any.
+func (r *ClusterResourceQuotaReconciler) deleteStatusMirror(ctx context.Context, quotaName, namespace string) error {
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: StatusMirrorName}, cm)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Labels[LabelStatusMirror] != quotaName {
+		return nil
+	}
+	if err := r.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.lastStatusMirrorAt, namespace)
+	r.mu.Unlock()
+	return nil
+}
+
+// statusMirrorWait returns how long until namespace's mirror may be rewritten.
+func (r *ClusterResourceQuotaReconciler) statusMirrorWait(namespace string, now time.Time) time.Duration {
+	r.mu.RLock()
+	last, ok := r.lastStatusMirrorAt[namespace]
+	r.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+	return max(last.Add(r.Config.StatusMirrorMinInterval).Sub(now), 0)
+}
+
+func (r *ClusterResourceQuotaReconciler) markStatusMirrored(namespace string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastStatusMirrorAt == nil {
+		r.lastStatusMirrorAt = make(map[string]time.Time)
+	}
+	r.lastStatusMirrorAt[namespace] = now
+}
+
+// statusMirrorData renders a quota's totals as ConfigMap data. Each list has
+// one "resource: quantity" line per hard limit, sorted by resource, so the
+// values read as YAML. Remaining is floored at zero.
+func statusMirrorData(quotaName string, hard, used quotav1alpha1.ResourceList) map[string]string {
+	remaining := make(quotav1alpha1.ResourceList, len(hard))
+	for resourceName, limit := range hard {
+		left := limit.DeepCopy()
+		left.Sub(used[resourceName])
+		if left.Sign() < 0 {
+			left = *resource.NewQuantity(0, limit.Format)
+		}
+		remaining[resourceName] = left
+	}
+	return map[string]string{
+		"clusterResourceQuota": quotaName,
+		"hard":                 formatResourceList(hard, hard),
+		"used":                 formatResourceList(hard, used),
+		"remaining":            formatResourceList(hard, remaining),
+	}
+}
+
+// formatResourceList lists values for the keys of hard, zero when missing.
+func formatResourceList(hard, values quotav1alpha1.ResourceList) string {
+	var b strings.Builder
+	for _, resourceName := range slices.Sorted(maps.Keys(hard)) {
+		q, ok := values[resourceName]
+		if !ok {
+			q = *resource.NewQuantity(0, resource.DecimalSI)
+		}
+		fmt.Fprintf(&b, "%s: %q\n", resourceName, q.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler status mirror", func() {
+	var (
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+		crq *quotav1alpha1.ClusterResourceQuota
+	)
+
+	cpu := func(q string) quotav1alpha1.ResourceList {
+		return quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse(q)}
+	}
+
+	mirror := func(namespace string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: StatusMirrorName}, cm)).To(Succeed())
+		return cm
+	}
+
+	BeforeEach(func() {
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "crq-uid"},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Hard: cpu("10")},
+		}
+		c = fake.NewClientBuilder().Build()
+		r = &ClusterResourceQuotaReconciler{
+			Client: c,
+			Config: &config.Config{StatusMirrorEnable: true, StatusMirrorMinInterval: time.Minute},
+			logger: zap.NewNop(),
+		}
+	})
+
+	It("writes the quota's totals to each namespace, owned by the quota", func() {
+		Expect(r.mirrorStatus(ctx, crq, []string{"dev", "prod"}, cpu("12"), now)).To(BeZero())
+
+		for _, ns := range []string{"dev", "prod"} {
+			cm := mirror(ns)
+			Expect(cm.Labels).To(HaveKeyWithValue(LabelStatusMirror, "team-a"))
+			Expect(cm.OwnerReferences).To(HaveLen(1))
+			Expect(cm.OwnerReferences[0].UID).To(Equal(crq.UID))
+			Expect(cm.Data).To(Equal(map[string]string{
+				"clusterResourceQuota": "team-a",
+				"hard":                 "requests.cpu: \"10\"\n",
+				"used":                 "requests.cpu: \"12\"\n",
+				"remaining":            "requests.cpu: \"0\"\n",
+			}))
+		}
+	})
+
+	It("rewrites a namespace's mirror at most once per interval", func() {
+		Expect(r.mirrorStatus(ctx, crq, []string{"dev"}, cpu("2"), now)).To(BeZero())
+
+		wait := r.mirrorStatus(ctx, crq, []string{"dev"}, cpu("3"), now.Add(20*time.Second))
+		Expect(wait).To(Equal(40 * time.Second))
+		Expect(mirror("dev").Data).To(HaveKeyWithValue("used", "requests.cpu: \"2\"\n"))
+
+		Expect(r.mirrorStatus(ctx, crq, []string{"dev"}, cpu("3"), now.Add(time.Minute))).To(BeZero())
+		Expect(mirror("dev").Data).To(HaveKeyWithValue("used", "requests.cpu: \"3\"\n"))
+	})
+
+	It("leaves ConfigMaps it does not mirror this quota to alone", func() {
+		Expect(c.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: StatusMirrorName},
+			Data:       map[string]string{"owner": "tenant"},
+		})).To(Succeed())
+
+		r.mirrorStatus(ctx, crq, []string{"dev"}, cpu("2"), now)
+		Expect(mirror("dev").Data).To(Equal(map[string]string{"owner": "tenant"}))
+	})
+
+	It("deletes the mirror of a namespace that left the quota", func() {
+		r.mirrorStatus(ctx, crq, []string{"dev", "prod"}, cpu("2"), now)
+		crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{Namespace: "dev"}, {Namespace: "prod"}}
+
+		r.mirrorStatus(ctx, crq, []string{"dev"}, cpu("2"), now)
+
+		err := c.Get(ctx, types.NamespacedName{Namespace: "prod", Name: StatusMirrorName}, &corev1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(mirror("dev").Data).To(HaveKey("used"))
+	})
+})
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceLabelIndex indexes cached Namespaces by each of their labels, both
+// as key=value and as the key alone. Label keys cannot contain "=", so the
+// two never collide.
+const namespaceLabelIndex = "metadata.labels"
+
+// namespaceLabelIndexValues returns the namespaceLabelIndex values of obj.
+func namespaceLabelIndexValues(obj client.Object) []string {
+	objLabels := obj.GetLabels()
+	values := make([]string, 0, 2*len(objLabels))
+	for key, value := range objLabels {
+		values = append(values, key, key+"="+value)
+	}
+	return values
+}
+
+// indexNamespaceLabels registers namespaceLabelIndex with mgr's cache, so
+// selectNamespaces reads only the namespaces carrying a label a CRQ's
+// selector requires rather than every namespace of the cluster.
+func (r *ClusterResourceQuotaReconciler) indexNamespaceLabels(ctx context.Context, mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx, &corev1.Namespace{}, namespaceLabelIndex, namespaceLabelIndexValues,
+	); err != nil {
+		return fmt.Errorf("failed to index namespaces by label: %w", err)
+	}
+	r.namespaceLabelIndexed = true
+	return nil
+}
+
+// namespaceListOptions returns the options listing the namespaces selector
+// matches. With the label index, the list reads only the namespaces carrying
+// one label the selector requires, preferring a label with a given value to
+// a label merely present; the selector still filters what it reads.
+func (r *ClusterResourceQuotaReconciler) namespaceListOptions(selector labels.Selector) *client.ListOptions {
+	opts := &client.ListOptions{LabelSelector: selector}
+	if !r.namespaceLabelIndexed {
+		return opts
+	}
+	if value, ok := namespaceLabelIndexLookup(selector); ok {
+		opts.FieldSelector = fields.OneTermEqualSelector(namespaceLabelIndex, value)
+	}
+	return opts
+}
+
+// namespaceLabelIndexLookup returns the namespaceLabelIndex value every
+// namespace selector matches carries. ok is false for selectors requiring no
+// label, such as those made only of NotIn or DoesNotExist requirements.
+func namespaceLabelIndexLookup(selector labels.Selector) (value string, ok bool) {
+	requirements, _ := selector.Requirements()
+	for _, req := range requirements {
+		values := req.Values()
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			return req.Key() + "=" + values.UnsortedList()[0], true
+		case selection.In:
+			if values.Len() == 1 {
+				return req.Key() + "=" + values.UnsortedList()[0], true
+			}
+		}
+	}
+	for _, req := range requirements {
+		switch req.Operator() {
+		case selection.Exists, selection.In:
+			return req.Key(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("namespace label index", func() {
+	DescribeTable("looks up the label every selected namespace carries",
+		func(selector, want string, wantOK bool) {
+			parsed, err := labels.Parse(selector)
+			Expect(err).NotTo(HaveOccurred())
+			value, ok := namespaceLabelIndexLookup(parsed)
+			Expect(ok).To(Equal(wantOK))
+			Expect(value).To(Equal(want))
+		},
+		Entry("a label value", "team=a", "team=a", true),
+		Entry("a single-value set", "env in (prod)", "env=prod", true),
+		Entry("a label value over a label present", "env,team=a", "team=a", true),
+		Entry("a label present", "env", "env", true),
+		Entry("a label in a set", "env in (prod,stage)", "env", true),
+		Entry("no required label", "!legacy,tier notin (shared)", "", false),
+	)
+
+	It("indexes each label as key=value and as the key alone", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}}}
+		Expect(namespaceLabelIndexValues(ns)).To(ConsistOf("team", "team=a"))
+	})
+
+	It("selects the same namespaces through the index", func() {
+		namespace := func(name string, nsLabels map[string]string) *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nsLabels}}
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithIndex(&corev1.Namespace{}, namespaceLabelIndex, namespaceLabelIndexValues).
+			WithObjects(
+				namespace("a-prod", map[string]string{"team": "a", "env": "prod"}),
+				namespace("a-dev", map[string]string{"team": "a", "env": "dev"}),
+				namespace("b-prod", map[string]string{"team": "b", "env": "prod"}),
+				namespace("unlabeled", nil),
+			).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, namespaceLabelIndexed: true, logger: zap.NewNop()}
+		selectWith := func(selector *metav1.LabelSelector) []string {
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "indexed"},
+				Spec:       quotav1alpha1.ClusterResourceQuotaSpec{NamespaceSelector: selector},
+			}
+			names, err := r.selectNamespaces(context.Background(), crq)
+			Expect(err).NotTo(HaveOccurred())
+			return names
+		}
+
+		Expect(selectWith(&metav1.LabelSelector{
+			MatchLabels: map[string]string{"team": "a"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev"}},
+			},
+		})).To(Equal([]string{"a-prod"}))
+		Expect(selectWith(&metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpExists}},
+		})).To(Equal([]string{"a-dev", "a-prod", "b-prod"}))
+		Expect(selectWith(&metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: metav1.LabelSelectorOpDoesNotExist},
+			},
+		})).To(Equal([]string{"unlabeled"}))
+	})
+
+	It("lists by label selector alone without the index", func() {
+		r := &ClusterResourceQuotaReconciler{}
+		opts := r.namespaceListOptions(labels.SelectorFromSet(labels.Set{"team": "a"}))
+		Expect(opts.FieldSelector).To(BeNil())
+		Expect(opts.LabelSelector.String()).To(Equal("team=a"))
+	})
+})
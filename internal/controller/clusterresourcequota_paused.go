@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+const (
+	// ReasonPausedByAnnotation marks a ClusterResourceQuota paused by its
+	// quota.powerapp.cloud/paused annotation.
+	ReasonPausedByAnnotation = "PausedByAnnotation"
+	// ReasonNotPaused marks a ClusterResourceQuota that is reconciled and enforced.
+	ReasonNotPaused = "NotPaused"
+)
+
+// pausedCondition returns the Paused condition for crq.
+func pausedCondition(crq *quotav1alpha1.ClusterResourceQuota) metav1.Condition {
+	if crq.Paused() {
+		return metav1.Condition{
+			Type:   quotav1alpha1.ConditionPaused,
+			Status: metav1.ConditionTrue,
+			Reason: ReasonPausedByAnnotation,
+			Message: "Usage is not recalculated and admission is not checked against the quota until the " +
+				quotav1alpha1.AnnotationPaused + " annotation is removed",
+			ObservedGeneration: crq.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               quotav1alpha1.ConditionPaused,
+		Status:             metav1.ConditionFalse,
+		Reason:             ReasonNotPaused,
+		Message:            "The quota is reconciled and enforced",
+		ObservedGeneration: crq.Generation,
+	}
+}
+
+// recordPaused sets the paused metric of crq and, when it is paused, marks its
+// status Paused while keeping the usage last calculated, so admission and
+// dashboards see the usage from before the pause once it is resumed.
+func (r *ClusterResourceQuotaReconciler) recordPaused(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) error {
+	if !crq.Paused() {
+		metrics.QuotaPaused.WithLabelValues(crq.Name).Set(0)
+		return nil
+	}
+	metrics.QuotaPaused.WithLabelValues(crq.Name).Set(1)
+	conditions := make([]metav1.Condition, 0, len(crq.Status.Conditions)+1)
+	for _, condition := range crq.Status.Conditions {
+		if condition.Type != quotav1alpha1.ConditionPaused {
+			conditions = append(conditions, condition)
+		}
+	}
+	conditions = append(conditions, pausedCondition(crq))
+	return r.updateStatus(ctx, crq, crq.Status.Total.Used, crq.Status.Namespaces,
+		crq.Status.Federation, crq.Status.Topology, crq.Status.Forecasts, crq.Status.IncompleteResources,
+		conditions...)
+}
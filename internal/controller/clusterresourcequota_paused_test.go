@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler paused quotas", func() {
+	var (
+		ctx = context.Background()
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+	)
+
+	stored := func() *quotav1alpha1.ClusterResourceQuota {
+		GinkgoHelper()
+		obj := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "team-a"}, obj)).To(Succeed())
+		return obj
+	}
+
+	BeforeEach(func() {
+		Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "team-a",
+				Annotations: map[string]string{quotav1alpha1.AnnotationPaused: "true"},
+			},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{usage.ResourceSecrets: resource.MustParse("2")},
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{usage.ResourceSecrets: resource.MustParse("1")},
+				},
+				Conditions: []metav1.Condition{{
+					Type:               quotav1alpha1.ConditionNoHardLimits,
+					Status:             metav1.ConditionFalse,
+					Reason:             ReasonHardLimitsSet,
+					LastTransitionTime: metav1.Now(),
+				}},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(crq).
+			WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+			Build()
+		r = &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+	})
+
+	It("marks a paused quota and keeps its usage and other conditions", func() {
+		Expect(r.recordPaused(ctx, stored())).To(Succeed())
+
+		crq := stored()
+		Expect(meta.IsStatusConditionTrue(crq.Status.Conditions, quotav1alpha1.ConditionPaused)).To(BeTrue())
+		Expect(meta.FindStatusCondition(crq.Status.Conditions, quotav1alpha1.ConditionNoHardLimits)).NotTo(BeNil())
+		used := crq.Status.Total.Used[usage.ResourceSecrets]
+		Expect(used.Value()).To(Equal(int64(1)))
+		Expect(testutil.ToFloat64(metrics.QuotaPaused.WithLabelValues("team-a"))).To(Equal(1.0))
+	})
+
+	It("reports resumed quotas as not paused", func() {
+		crq := stored()
+		delete(crq.Annotations, quotav1alpha1.AnnotationPaused)
+		Expect(r.recordPaused(ctx, crq)).To(Succeed())
+		Expect(testutil.ToFloat64(metrics.QuotaPaused.WithLabelValues("team-a"))).To(BeZero())
+
+		condition := pausedCondition(crq)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(ReasonNotPaused))
+	})
+
+	It("only pauses on \"true\"", func() {
+		crq := stored()
+		crq.Annotations[quotav1alpha1.AnnotationPaused] = "false"
+		Expect(crq.Paused()).To(BeFalse())
+	})
+})
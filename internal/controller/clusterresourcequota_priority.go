@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// maxHeadroomPriority is the priority of a quota with no headroom left.
+const maxHeadroomPriority = 100
+
+// headroomQueue is the controller-runtime priority queue, with every
+// reconcile prioritized by how little headroom its quota has left instead of
+// by the priority it is added with, so under a backlog the quotas closest to
+// their limits are refreshed first.
+type headroomQueue struct {
+	priorityqueue.PriorityQueue[reconcile.Request]
+	priority func(reconcile.Request) int
+}
+
+// newHeadroomQueue returns the NewQueue controller option ordering reconciles
+// by the priority priority gives them.
+func newHeadroomQueue(
+	priority func(reconcile.Request) int,
+) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(
+		name string,
+		rateLimiter workqueue.TypedRateLimiter[reconcile.Request],
+	) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		return &headroomQueue{
+			PriorityQueue: priorityqueue.New(name, func(o *priorityqueue.Opts[reconcile.Request]) {
+				o.RateLimiter = rateLimiter
+			}),
+			priority: priority,
+		}
+	}
+}
+
+func (q *headroomQueue) Add(item reconcile.Request) {
+	q.AddWithOpts(priorityqueue.AddOpts{}, item)
+}
+
+func (q *headroomQueue) AddAfter(item reconcile.Request, after time.Duration) {
+	q.AddWithOpts(priorityqueue.AddOpts{After: after}, item)
+}
+
+func (q *headroomQueue) AddRateLimited(item reconcile.Request) {
+	q.AddWithOpts(priorityqueue.AddOpts{RateLimited: true}, item)
+}
+
+// AddWithOpts adds each item at its headroom priority. The priority in o is
+// ignored: controller-runtime passes back the priority an item was last
+// dequeued at, which reflects the status before the reconcile.
+func (q *headroomQueue) AddWithOpts(o priorityqueue.AddOpts, items ...reconcile.Request) {
+	for _, item := range items {
+		opts := o
+		priority := q.priority(item)
+		opts.Priority = &priority
+		q.PriorityQueue.AddWithOpts(opts, item)
+	}
+}
+
+// headroomPriority returns the reconcile priority of the quota req names:
+// the highest percentage of a hard limit its last status reports used,
+// capped at maxHeadroomPriority. Quotas without usage in status yet, whose
+// admission fails open, and quotas missing from the cache come first.
+func (r *ClusterResourceQuotaReconciler) headroomPriority(req reconcile.Request) int {
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	if err := r.Get(context.Background(), req.NamespacedName, crq); err != nil {
+		return maxHeadroomPriority
+	}
+	if len(crq.Status.Total.Used) == 0 {
+		return maxHeadroomPriority
+	}
+	highest := 0.0
+	for resourceName, hard := range crq.Spec.TrackedHard() {
+		if used, ok := crq.Status.Total.Used[resourceName]; ok {
+			highest = max(highest, percentOfHard(used, hard))
+		}
+	}
+	return min(int(highest*100), maxHeadroomPriority)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("Reconciling by headroom", func() {
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}
+	}
+	usedCPU := func(name, used string) *quotav1alpha1.ClusterResourceQuota {
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("10"),
+					corev1.ResourcePods:        resource.MustParse("100"),
+				},
+			},
+		}
+		if used != "" {
+			crq.Status.Total.Used = quotav1alpha1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse(used),
+				corev1.ResourcePods:        resource.MustParse("5"),
+			}
+		}
+		return crq
+	}
+
+	It("prioritizes quotas by the largest share of a limit they use", func() {
+		Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			usedCPU("idle", "1"),
+			usedCPU("busy", "9"),
+			usedCPU("over", "12"),
+			usedCPU("new", ""),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		Expect(r.headroomPriority(request("idle"))).To(Equal(10))
+		Expect(r.headroomPriority(request("busy"))).To(Equal(90))
+		Expect(r.headroomPriority(request("over"))).To(Equal(maxHeadroomPriority))
+		Expect(r.headroomPriority(request("new"))).To(Equal(maxHeadroomPriority))
+		Expect(r.headroomPriority(request("deleted"))).To(Equal(maxHeadroomPriority))
+	})
+
+	It("hands out the reconciles with the highest priority first, whatever they were added with", func() {
+		priorities := map[string]int{"idle": 10, "busy": 90, "half": 50}
+		q := newHeadroomQueue(func(req reconcile.Request) int { return priorities[req.Name] })(
+			"headroom-test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		DeferCleanup(q.ShutDown)
+		pq, ok := q.(priorityqueue.PriorityQueue[reconcile.Request])
+		Expect(ok).To(BeTrue())
+
+		q.Add(request("idle"))
+		low := -100
+		pq.AddWithOpts(priorityqueue.AddOpts{Priority: &low}, request("busy"))
+		q.Add(request("half"))
+		Eventually(q.Len).Should(Equal(3))
+
+		var order []string
+		for range 3 {
+			item, priority, _ := pq.GetWithPriority()
+			Expect(priority).To(Equal(priorities[item.Name]))
+			order = append(order, item.Name)
+			q.Done(item)
+		}
+		Expect(order).To(Equal([]string{"busy", "half", "idle"}))
+	})
+})
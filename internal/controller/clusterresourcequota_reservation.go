@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/namespace"
+)
+
+// namespaceReservation returns the quota reserved by the namespace's
+// quota.powerapp.cloud/reserve annotation. A missing namespace or a malformed
+// annotation reserves nothing; the namespace webhook rejects malformed values.
+func (r *ClusterResourceQuotaReconciler) namespaceReservation(ctx context.Context, nsName string) corev1.ResourceList {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nsName}, ns); err != nil {
+		if !errors.IsNotFound(err) {
+			r.logger.Warn("Failed to read namespace reservation", zap.String("namespace", nsName), zap.Error(err))
+		}
+		return nil
+	}
+	reserved, err := namespace.Reservation(ns)
+	if err != nil {
+		r.logger.Warn("Ignoring namespace reservation", zap.String("namespace", nsName), zap.Error(err))
+		return nil
+	}
+	return reserved
+}
+
+// applyReservation returns the usage to charge for resourceName: used, or the
+// reservation while used has not reached it. The unused part of the
+// reservation is recorded in status.Reserved.
+func applyReservation(
+	status *quotav1alpha1.ResourceQuotaStatus,
+	resourceName corev1.ResourceName,
+	used resource.Quantity,
+	reserved corev1.ResourceList,
+) resource.Quantity {
+	reservedQty, ok := reserved[resourceName]
+	if !ok || reservedQty.Cmp(used) <= 0 {
+		return used
+	}
+	unused := reservedQty.DeepCopy()
+	unused.Sub(used)
+	if status.Reserved == nil {
+		status.Reserved = make(quotav1alpha1.ResourceList)
+	}
+	status.Reserved[resourceName] = unused
+	return reservedQty.DeepCopy()
+}
+
+// totalReserved sums the unused reservations of every namespace, or returns
+// nil when none has any.
+func totalReserved(byNamespace []quotav1alpha1.ResourceQuotaStatusByNamespace) quotav1alpha1.ResourceList {
+	var total quotav1alpha1.ResourceList
+	for _, ns := range byNamespace {
+		for resourceName, q := range ns.Status.Reserved {
+			if total == nil {
+				total = make(quotav1alpha1.ResourceList)
+			}
+			sum := total[resourceName]
+			sum.Add(q)
+			total[resourceName] = sum
+		}
+	}
+	return total
+}
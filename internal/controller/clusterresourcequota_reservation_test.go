@@ -0,0 +1,53 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("Namespace reservations", func() {
+	reserved := corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")}
+
+	It("charges the reservation until usage reaches it", func() {
+		status := &quotav1alpha1.ResourceQuotaStatus{}
+		used := applyReservation(status, corev1.ResourceRequestsCPU, resource.MustParse("1500m"), reserved)
+		Expect(used.Cmp(resource.MustParse("4"))).To(Equal(0))
+		Expect(status.Reserved).To(HaveKey(corev1.ResourceRequestsCPU))
+		unused := status.Reserved[corev1.ResourceRequestsCPU]
+		Expect(unused.Cmp(resource.MustParse("2500m"))).To(Equal(0))
+	})
+
+	It("charges actual usage once it exceeds the reservation", func() {
+		status := &quotav1alpha1.ResourceQuotaStatus{}
+		used := applyReservation(status, corev1.ResourceRequestsCPU, resource.MustParse("5"), reserved)
+		Expect(used.Cmp(resource.MustParse("5"))).To(Equal(0))
+		Expect(status.Reserved).To(BeNil())
+	})
+
+	It("ignores resources the namespace did not reserve", func() {
+		status := &quotav1alpha1.ResourceQuotaStatus{}
+		used := applyReservation(status, corev1.ResourcePods, resource.MustParse("2"), reserved)
+		Expect(used.Cmp(resource.MustParse("2"))).To(Equal(0))
+		Expect(status.Reserved).To(BeNil())
+	})
+
+	It("totals the unused reservations of every namespace", func() {
+		total := totalReserved([]quotav1alpha1.ResourceQuotaStatusByNamespace{
+			{Status: quotav1alpha1.ResourceQuotaStatus{
+				Reserved: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
+			}},
+			{},
+			{Status: quotav1alpha1.ResourceQuotaStatus{
+				Reserved: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("500m")},
+			}},
+		})
+		Expect(total).To(HaveKey(corev1.ResourceRequestsCPU))
+		sum := total[corev1.ResourceRequestsCPU]
+		Expect(sum.Cmp(resource.MustParse("1500m"))).To(Equal(0))
+		Expect(totalReserved(nil)).To(BeNil())
+	})
+})
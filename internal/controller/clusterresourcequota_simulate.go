@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+)
+
+// NewSimulationQuotas returns a reconciler wired only to answer
+// simulate.Quotas, applying the same namespace and owner exclusions as the
+// controller running with cfg.
+func NewSimulationQuotas(c client.Client, cfg *config.Config, logger *zap.Logger) *ClusterResourceQuotaReconciler {
+	logger = logger.Named("clusterresourcequota-simulate")
+	excludedOwners := cfg.ExcludedOwnerKinds()
+	return &ClusterResourceQuotaReconciler{
+		Client:                   c,
+		Config:                   cfg,
+		ExcludeNamespaceLabelKey: cfg.ExcludeNamespaceLabelKey,
+		ExcludedNamespaces:       cfg.ExcludedNamespaces,
+		SystemNamespaces:         cfg.SystemNamespaces,
+		NamespaceLabelDenylist:   cfg.NamespaceLabelDenylistSelectors(),
+		ExcludedOwners:           excludedOwners,
+		ExcludeHeadlessServices:  cfg.ExcludeHeadlessServices,
+		ObjectCountCalculator:    objectcount.NewObjectCountCalculator(objects.ExcludeOwned(c, excludedOwners), logger),
+		logger:                   logger,
+	}
+}
+
+// SelectsNamespace reports whether crq governs ns, by the same rules as
+// selectNamespaces.
+func (r *ClusterResourceQuotaReconciler) SelectsNamespace(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	ns *corev1.Namespace,
+) (bool, error) {
+	if crq.Spec.NamespaceSelector == nil || r.isNamespaceExcluded(ns) {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(crq.Spec.NamespaceSelector)
+	if err != nil {
+		return false, &invalidSelectorError{msg: "failed to create selector from CRQ spec", err: err}
+	}
+	if !selector.Matches(labels.Set(ns.Labels)) {
+		return false, nil
+	}
+	excludeSelector, err := quota.ExcludeNamespaceSelector(crq)
+	if err != nil {
+		return false, &invalidSelectorError{msg: "failed to create exclude selector from CRQ spec", err: err}
+	}
+	return excludeSelector == nil || !excludeSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// NamespaceUsage counts namespace's usage of crq's resources as a reconcile
+// would, and lists the resources it could not fully count.
+func (r *ClusterResourceQuotaReconciler) NamespaceUsage(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespace string,
+) (quotav1alpha1.ResourceList, []corev1.ResourceName, error) {
+	u, err := r.computeUsage(ctx, crq, []string{namespace}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	incomplete := make([]corev1.ResourceName, 0, len(u.incomplete))
+	for resourceName := range u.incomplete {
+		incomplete = append(incomplete, resourceName)
+	}
+	return u.total, incomplete, nil
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/simulate"
+)
+
+var _ = Describe("SimulationQuotas", func() {
+	var (
+		r   *ClusterResourceQuotaReconciler
+		sim *simulate.NamespaceMoveSimulator
+	)
+
+	teamQuota := func(name, team string, hardPods, usedPods string) *quotav1alpha1.ClusterResourceQuota {
+		return &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": team}},
+				Hard:              quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse(hardPods)},
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse(usedPods)},
+				},
+			},
+		}
+	}
+
+	runningPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "move-me"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	BeforeEach(func() {
+		c := fake.NewClientBuilder().WithObjects(
+			teamQuota("team-a", "a", "10", "4"),
+			teamQuota("team-b", "b", "5", "4"),
+			teamQuota("env-prod", "a", "20", "4"),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "move-me", Labels: map[string]string{"team": "a"}}},
+			runningPod("p1"),
+			runningPod("p2"),
+		).Build()
+		r = NewSimulationQuotas(c, &config.Config{}, zap.NewNop())
+		sim = simulate.NewNamespaceMoveSimulator(c, r)
+	})
+
+	It("reports the quotas that gain and lose the namespace", func() {
+		result, err := sim.SimulateNamespaceMove(context.Background(), "move-me", map[string]string{"team": "b"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Unchanged).To(BeEmpty())
+		Expect(result.Changes).To(HaveLen(3))
+
+		Expect(result.Changes[0].Name).To(Equal("env-prod"))
+		Expect(result.Changes[0].Effect).To(Equal(simulate.EffectLose))
+
+		lose := result.Changes[1]
+		Expect(lose.Name).To(Equal("team-a"))
+		Expect(lose.Effect).To(Equal(simulate.EffectLose))
+		Expect(lose.Resources).To(HaveLen(1))
+		Expect(lose.Resources[0].NamespaceUsed.Value()).To(Equal(int64(2)))
+		Expect(lose.Resources[0].UsedAfter.Value()).To(Equal(int64(2)))
+		Expect(lose.Resources[0].RatioAfter).To(BeNumerically("~", 0.2))
+		Expect(lose.Exceeded).To(BeEmpty())
+
+		gain := result.Changes[2]
+		Expect(gain.Name).To(Equal("team-b"))
+		Expect(gain.Effect).To(Equal(simulate.EffectGain))
+		Expect(gain.Resources[0].UsedAfter.Value()).To(Equal(int64(6)))
+		Expect(gain.Resources[0].RatioAfter).To(BeNumerically("~", 1.2))
+		Expect(gain.Exceeded).To(ConsistOf(corev1.ResourcePods))
+	})
+
+	It("lists quotas that keep the namespace as unchanged", func() {
+		result, err := sim.SimulateNamespaceMove(context.Background(), "move-me",
+			map[string]string{"team": "a", "tier": "gold"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Changes).To(BeEmpty())
+		Expect(result.Unchanged).To(Equal([]string{"env-prod", "team-a"}))
+	})
+
+	It("never selects a namespace excluded by the controller", func() {
+		r.ExcludeNamespaceLabelKey = "skip"
+		result, err := sim.SimulateNamespaceMove(context.Background(), "move-me",
+			map[string]string{"team": "b", "skip": ""})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Changes).To(HaveLen(2))
+		for _, change := range result.Changes {
+			Expect(change.Effect).To(Equal(simulate.EffectLose))
+		}
+	})
+
+	It("returns a NotFound error for an unknown namespace", func() {
+		_, err := sim.SimulateNamespaceMove(context.Background(), "missing", nil)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
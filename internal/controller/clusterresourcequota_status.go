@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// StatusFieldManager is the server-side apply field manager owning the status
+// fields the reconciler computes: totals, namespaces, federation, topology and
+// forecasts. Other status writers apply under their own manager, so neither
+// removes or overwrites the other's fields.
+const StatusFieldManager = "pac-quota-controller"
+
+// applyStatus server-side applies status as the reconciler's part of crq's
+// status. Fields it applied before and leaves out of status are removed.
+func (r *ClusterResourceQuotaReconciler) applyStatus(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	status quotav1alpha1.ClusterResourceQuotaStatus,
+) error {
+	if err := r.upgradeStatusManagedFields(ctx, crq); err != nil {
+		return err
+	}
+	obj, err := statusApplyConfiguration(crq.Name, status)
+	if err != nil {
+		return err
+	}
+	return r.Status().Apply(ctx, obj, client.FieldOwner(StatusFieldManager), client.ForceOwnership)
+}
+
+// canonicalStatus returns a copy of status with every quantity in its
+// usage.Canonical format. Usage sums keep the format of whichever quantity
+// they started from, so without it the same usage could be written as
+// "1152Mi" by one reconcile and "1207959552" by the next.
+func canonicalStatus(status quotav1alpha1.ClusterResourceQuotaStatus) quotav1alpha1.ClusterResourceQuotaStatus {
+	status = *status.DeepCopy()
+	canonicalQuotaStatus(&status.Total)
+	for i := range status.Namespaces {
+		canonicalQuotaStatus(&status.Namespaces[i].Status)
+	}
+	for i := range status.Topology {
+		status.Topology[i].Used = canonicalList(status.Topology[i].Used)
+	}
+	if status.Federation != nil {
+		for i := range status.Federation.Clusters {
+			status.Federation.Clusters[i].Used = canonicalList(status.Federation.Clusters[i].Used)
+		}
+	}
+	return status
+}
+
+func canonicalQuotaStatus(status *quotav1alpha1.ResourceQuotaStatus) {
+	status.Hard = canonicalList(status.Hard)
+	status.Used = canonicalList(status.Used)
+	status.Reserved = canonicalList(status.Reserved)
+}
+
+func canonicalList(list quotav1alpha1.ResourceList) quotav1alpha1.ResourceList {
+	return quotav1alpha1.ResourceList(usage.CanonicalList(corev1.ResourceList(list)))
+}
+
+// statusApplyConfiguration is the apply configuration of a ClusterResourceQuota
+// carrying only its name and status.
+func statusApplyConfiguration(
+	name string,
+	status quotav1alpha1.ClusterResourceQuotaStatus,
+) (runtime.ApplyConfiguration, error) {
+	obj := &quotav1alpha1.ClusterResourceQuota{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: quotav1alpha1.GroupVersion.String(),
+			Kind:       "ClusterResourceQuota",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     status,
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	// Leave out the zero spec and creation timestamp the conversion adds, or
+	// the apply would claim them.
+	delete(u, "spec")
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	return client.ApplyConfigurationFromUnstructured(&unstructured.Unstructured{Object: u}), nil
+}
+
+// upgradeStatusManagedFields hands the status fields written by merge patches,
+// which is how releases before server-side apply wrote status, over to
+// StatusFieldManager. Otherwise a namespace leaving the quota would stay listed
+// in its status: no apply of ours could remove a field another manager owns.
+func (r *ClusterResourceQuotaReconciler) upgradeStatusManagedFields(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) error {
+	updaters := sets.New[string]()
+	for _, entry := range crq.ManagedFields {
+		if entry.Operation == metav1.ManagedFieldsOperationUpdate && entry.Subresource == "status" {
+			updaters.Insert(entry.Manager)
+		}
+	}
+	if updaters.Len() == 0 {
+		return nil
+	}
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(crq, updaters, StatusFieldManager,
+		csaupgrade.Subresource("status"))
+	if err != nil || patch == nil {
+		return err
+	}
+	return r.Patch(ctx, crq.DeepCopy(), client.RawPatch(types.JSONPatchType, patch))
+}
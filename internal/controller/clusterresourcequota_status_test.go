@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler status apply", func() {
+	var (
+		ctx = context.Background()
+		c   client.Client
+		r   *ClusterResourceQuotaReconciler
+		crq *quotav1alpha1.ClusterResourceQuota
+	)
+
+	nsUsage := func(namespace, cpu string) quotav1alpha1.ResourceQuotaStatusByNamespace {
+		return quotav1alpha1.ResourceQuotaStatusByNamespace{
+			Namespace: namespace,
+			Status: quotav1alpha1.ResourceQuotaStatus{
+				Used: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse(cpu)},
+			},
+		}
+	}
+
+	stored := func() *quotav1alpha1.ClusterResourceQuota {
+		GinkgoHelper()
+		obj := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: crq.Name}, obj)).To(Succeed())
+		return obj
+	}
+
+	BeforeEach(func() {
+		Expect(quotav1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")},
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(crq).
+			WithStatusSubresource(&quotav1alpha1.ClusterResourceQuota{}).
+			WithReturnManagedFields().
+			Build()
+		r = &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+	})
+
+	It("drops the namespaces it no longer reports", func() {
+		total := quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")}
+		Expect(r.updateStatus(ctx, stored(), total,
+			[]quotav1alpha1.ResourceQuotaStatusByNamespace{nsUsage("dev", "500m"), nsUsage("prod", "500m")},
+			nil, nil, nil, nil, incompleteUsageCondition(0, nil))).To(Succeed())
+		Expect(stored().Status.GetNamespaces()).To(ConsistOf("dev", "prod"))
+
+		Expect(r.updateStatus(ctx, stored(), total,
+			[]quotav1alpha1.ResourceQuotaStatusByNamespace{nsUsage("prod", "1")},
+			nil, nil, nil, nil, incompleteUsageCondition(0, nil))).To(Succeed())
+
+		status := stored().Status
+		Expect(status.GetNamespaces()).To(ConsistOf("prod"))
+		Expect(status.Total.Hard).To(HaveKey(corev1.ResourceRequestsCPU))
+	})
+
+	It("takes over the status fields written by merge patches of older releases", func() {
+		old := stored()
+		old.ManagedFields = []metav1.ManagedFieldsEntry{{
+			Manager:     "manager",
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			APIVersion:  quotav1alpha1.GroupVersion.String(),
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:namespaces":{}}}`)},
+			Subresource: "status",
+		}}
+		var patch []byte
+		r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Patch: func(_ context.Context, _ client.WithWatch, _ client.Object, p client.Patch, _ ...client.PatchOption) error {
+				patch, _ = p.Data(nil)
+				return nil
+			},
+		})
+
+		Expect(r.upgradeStatusManagedFields(ctx, old)).To(Succeed())
+
+		var ops []struct {
+			Path  string          `json:"path"`
+			Value json.RawMessage `json:"value"`
+		}
+		Expect(json.Unmarshal(patch, &ops)).To(Succeed())
+		Expect(ops[0].Path).To(Equal("/metadata/managedFields"))
+		var entries []metav1.ManagedFieldsEntry
+		Expect(json.Unmarshal(ops[0].Value, &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Manager).To(Equal(StatusFieldManager))
+		Expect(entries[0].Operation).To(Equal(metav1.ManagedFieldsOperationApply))
+	})
+
+	It("does not patch managed fields already applied", func() {
+		r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Patch: func(context.Context, client.WithWatch, client.Object, client.Patch, ...client.PatchOption) error {
+				Fail("unexpected patch")
+				return nil
+			},
+		})
+		Expect(r.upgradeStatusManagedFields(ctx, stored())).To(Succeed())
+	})
+
+	It("applies the status under its own field manager", func() {
+		Expect(r.updateStatus(ctx, stored(),
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
+			[]quotav1alpha1.ResourceQuotaStatusByNamespace{nsUsage("dev", "1")},
+			nil, nil, nil, nil, incompleteUsageCondition(0, nil))).To(Succeed())
+
+		var managers []string
+		for _, entry := range stored().ManagedFields {
+			managers = append(managers, entry.Manager+"/"+string(entry.Operation))
+		}
+		Expect(managers).To(ContainElement(StatusFieldManager + "/Apply"))
+	})
+
+	It("writes quantities in a stable format", func() {
+		total := quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU:    *resource.NewMilliQuantity(1500, resource.BinarySI),
+			corev1.ResourceRequestsMemory: *resource.NewQuantity(1207959552, resource.DecimalSI),
+		}
+		Expect(r.updateStatus(ctx, stored(), total, nil, nil, nil, nil, nil,
+			incompleteUsageCondition(0, nil))).To(Succeed())
+
+		obj, err := statusApplyConfiguration("team-a", canonicalStatus(quotav1alpha1.ClusterResourceQuotaStatus{
+			Total: quotav1alpha1.ResourceQuotaStatus{Used: total},
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		u := obj.(interface{ UnstructuredContent() map[string]any }).UnstructuredContent()
+		Expect(u["status"]).To(HaveKeyWithValue("total", HaveKeyWithValue("used", map[string]any{
+			"requests.cpu":    "1500m",
+			"requests.memory": "1152Mi",
+		})))
+		used := stored().Status.Total.Used[corev1.ResourceRequestsMemory]
+		Expect(used.String()).To(Equal("1152Mi"))
+	})
+
+	It("leaves out the spec and metadata it does not own", func() {
+		obj, err := statusApplyConfiguration("team-a", quotav1alpha1.ClusterResourceQuotaStatus{})
+		Expect(err).NotTo(HaveOccurred())
+
+		u := obj.(interface{ UnstructuredContent() map[string]any }).UnstructuredContent()
+		Expect(u).NotTo(HaveKey("spec"))
+		Expect(u["metadata"]).To(Equal(map[string]any{"name": "team-a"}))
+	})
+})
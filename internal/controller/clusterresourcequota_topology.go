@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+)
+
+// topologyUsage attributes pods, the charged pods computeUsage kept, to the
+// values of crq's spec.topologyKey, for every resource in spec.topologyHard.
+// Every value in spec.topologyHard gets an entry even when no pod is
+// attributed to it, so admission can tell an idle value from one the
+// controller has not counted.
+func (r *ClusterResourceQuotaReconciler) topologyUsage(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	pods []corev1.Pod,
+) ([]quotav1alpha1.TopologyUsage, error) {
+	key := crq.Spec.TopologyKey
+	if key == "" || len(crq.Spec.TopologyHard) == 0 {
+		return nil, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeLabels := make(map[string]map[string]string, len(nodes.Items))
+	for i := range nodes.Items {
+		nodeLabels[nodes.Items[i].Name] = nodes.Items[i].Labels
+	}
+
+	podsByValue := make(map[string][]corev1.Pod, len(crq.Spec.TopologyHard))
+	for value := range crq.Spec.TopologyHard {
+		podsByValue[value] = nil
+	}
+	for i := range pods {
+		value, ok := pod.TopologyValue(&pods[i], key, nodeLabels[pods[i].Spec.NodeName])
+		if ok {
+			podsByValue[value] = append(podsByValue[value], pods[i])
+		}
+	}
+
+	resourceNames := make(map[corev1.ResourceName]bool)
+	for _, limits := range crq.Spec.TopologyHard {
+		for resourceName := range limits {
+			resourceNames[resourceName] = true
+		}
+	}
+	usage := make([]quotav1alpha1.TopologyUsage, 0, len(podsByValue))
+	for value, pods := range podsByValue {
+		used := make(quotav1alpha1.ResourceList, len(resourceNames))
+		for resourceName := range resourceNames {
+			used[resourceName] = projection.Weigh(crq, resourceName, pod.CalculateUsageFromPods(pods, resourceName))
+		}
+		usage = append(usage, quotav1alpha1.TopologyUsage{Value: value, Used: used})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Value < usage[j].Value })
+	return usage, nil
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler topology usage", func() {
+	const zoneKey = "topology.kubernetes.io/zone"
+
+	cpuPod := func(name, cpu string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns-a"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "c",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	It("attributes pods to their node's or selector's value and skips unpinned pods", func() {
+		scheduled := cpuPod("scheduled", "1")
+		scheduled.Spec.NodeName = "node-a"
+		pinned := cpuPod("pinned", "500m")
+		pinned.Spec.NodeSelector = map[string]string{zoneKey: "zone-b"}
+		c := fake.NewClientBuilder().WithObjects(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{zoneKey: "zone-a"}}},
+			scheduled, pinned, cpuPod("anywhere", "2"),
+		).Build()
+		r := &ClusterResourceQuotaReconciler{Client: c, logger: zap.NewNop()}
+
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				TopologyKey: zoneKey,
+				TopologyHard: map[string]quotav1alpha1.ResourceList{
+					"zone-a": {corev1.ResourceRequestsCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("5")},
+					"zone-c": {corev1.ResourceRequestsCPU: resource.MustParse("4")},
+				},
+			},
+		}
+
+		u, err := r.computeUsage(context.Background(), crq, []string{"ns-a"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.pods).To(HaveLen(3), "pods are kept for topology even when spec.hard counts none")
+		topology, err := r.topologyUsage(context.Background(), crq, u.pods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topology).To(HaveLen(3))
+		Expect(topology[0].Value).To(Equal("zone-a"))
+		Expect(topology[1].Value).To(Equal("zone-b"))
+		Expect(topology[2].Value).To(Equal("zone-c"), "values with limits are reported even when idle")
+
+		zoneA := topology[0].Used[corev1.ResourceRequestsCPU]
+		zoneAPods := topology[0].Used[corev1.ResourcePods]
+		zoneB := topology[1].Used[corev1.ResourceRequestsCPU]
+		zoneC := topology[2].Used[corev1.ResourceRequestsCPU]
+		Expect(zoneA.Cmp(resource.MustParse("1"))).To(Equal(0))
+		Expect(zoneAPods.Value()).To(Equal(int64(1)))
+		Expect(zoneB.Cmp(resource.MustParse("500m"))).To(Equal(0))
+		Expect(zoneC.IsZero()).To(BeTrue())
+	})
+
+	It("reports nothing without a topology key", func() {
+		r := &ClusterResourceQuotaReconciler{Client: fake.NewClientBuilder().Build(), logger: zap.NewNop()}
+		topology, err := r.topologyUsage(context.Background(), &quotav1alpha1.ClusterResourceQuota{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topology).To(BeNil())
+	})
+})
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"math"
+	"slices"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// usageChangePercent returns --events-usage-change-percent; zero disables
+// UsageChanged events.
+func (r *ClusterResourceQuotaReconciler) usageChangePercent() int {
+	if r.Config == nil {
+		return 0
+	}
+	return r.Config.EventsUsageChangePercent
+}
+
+// recordUsageChanges logs and records a UsageChanged event for the tracked
+// resources of crq whose total usage moved significantly since they were last
+// reported; see significantUsageChange. Small changes accumulate until they
+// add up to a significant one. The first reconcile of a quota compares against
+// its stored status, so a restart does not report every quota again. Only the
+// events are gated: the status is always written.
+func (r *ClusterResourceQuotaReconciler) recordUsageChanges(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	usage quotav1alpha1.ResourceList,
+) {
+	percent := r.usageChangePercent()
+	if percent <= 0 {
+		return
+	}
+
+	previous := make(quotav1alpha1.ResourceList)
+	current := make(quotav1alpha1.ResourceList)
+	r.mu.Lock()
+	if r.lastReportedUsage == nil {
+		r.lastReportedUsage = make(map[string]quotav1alpha1.ResourceList)
+	}
+	reported, ok := r.lastReportedUsage[crq.Name]
+	if !ok {
+		reported = crq.Status.Total.Used.DeepCopy()
+		if reported == nil {
+			reported = make(quotav1alpha1.ResourceList)
+		}
+		r.lastReportedUsage[crq.Name] = reported
+	}
+	for resourceName, limit := range crq.Spec.TrackedHard() {
+		before, after := reported[resourceName], usage[resourceName]
+		if !significantUsageChange(before, after, limit, percent) {
+			continue
+		}
+		previous[resourceName] = before
+		current[resourceName] = after.DeepCopy()
+		reported[resourceName] = after.DeepCopy()
+	}
+	r.mu.Unlock()
+
+	if len(current) == 0 {
+		return
+	}
+	changed := make([]string, 0, len(current))
+	for resourceName := range current {
+		changed = append(changed, string(resourceName))
+	}
+	slices.Sort(changed)
+	r.logger.Info("ClusterResourceQuota usage changed",
+		zap.String("crq_name", crq.Name),
+		zap.Strings("resources", changed))
+	r.EventRecorder.UsageChanged(crq, previous, current)
+}
+
+// forgetReportedUsage drops the usage last reported for the named CRQ.
+func (r *ClusterResourceQuotaReconciler) forgetReportedUsage(crqName string) {
+	r.mu.Lock()
+	delete(r.lastReportedUsage, crqName)
+	r.mu.Unlock()
+}
+
+// significantUsageChange reports whether usage moving from before to after is
+// worth reporting against limit: it crossed the limit in either direction, or
+// moved by at least percent of the limit.
+func significantUsageChange(before, after, limit resource.Quantity, percent int) bool {
+	if before.Cmp(after) == 0 {
+		return false
+	}
+	if (before.Cmp(limit) > 0) != (after.Cmp(limit) > 0) {
+		return true
+	}
+	if limit.IsZero() {
+		return false
+	}
+	delta := math.Abs(after.AsApproximateFloat64() - before.AsApproximateFloat64())
+	return delta*100 >= limit.AsApproximateFloat64()*float64(percent)
+}
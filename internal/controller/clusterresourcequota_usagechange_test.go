@@ -0,0 +1,113 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sevents "k8s.io/client-go/tools/events"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+)
+
+var _ = Describe("ClusterResourceQuota usage change events", func() {
+	var (
+		reconciler   *ClusterResourceQuotaReconciler
+		fakeRecorder *k8sevents.FakeRecorder
+		crq          *quotav1alpha1.ClusterResourceQuota
+	)
+
+	usage := func(cpu, pods string) quotav1alpha1.ResourceList {
+		return quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse(cpu),
+			corev1.ResourcePods:        resource.MustParse(pods),
+		}
+	}
+
+	BeforeEach(func() {
+		fakeRecorder = k8sevents.NewFakeRecorder(10)
+		reconciler = &ClusterResourceQuotaReconciler{
+			Config:        &config.Config{EventsUsageChangePercent: 10},
+			EventRecorder: events.NewEventRecorder(fakeRecorder, zap.NewNop()),
+			logger:        zap.NewNop(),
+		}
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				Hard: usage("10", "100"),
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{Used: usage("2", "20")},
+			},
+		}
+	})
+
+	It("records nothing when disabled", func() {
+		reconciler.Config.EventsUsageChangePercent = 0
+		reconciler.recordUsageChanges(crq, usage("9", "90"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("compares the first reconcile against the stored status", func() {
+		reconciler.recordUsageChanges(crq, usage("2500m", "20"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+
+		reconciler.recordUsageChanges(crq, usage("3", "20"))
+		Expect(fakeRecorder.Events).To(HaveLen(1))
+		event := <-fakeRecorder.Events
+		Expect(event).To(ContainSubstring("Normal UsageChanged"))
+		Expect(event).To(ContainSubstring("Usage changed: requests.cpu 2 -> 3/10"))
+	})
+
+	It("accumulates small changes until they are significant", func() {
+		for _, pods := range []string{"24", "28"} {
+			reconciler.recordUsageChanges(crq, usage("2", pods))
+		}
+		Expect(fakeRecorder.Events).To(BeEmpty())
+
+		reconciler.recordUsageChanges(crq, usage("2", "31"))
+		Expect(fakeRecorder.Events).To(HaveLen(1))
+		Expect(<-fakeRecorder.Events).To(ContainSubstring("pods 20 -> 31/100"))
+
+		reconciler.recordUsageChanges(crq, usage("2", "35"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("reports crossing the hard limit however small the change", func() {
+		crq.Status.Total.Used = usage("9950m", "20")
+		reconciler.recordUsageChanges(crq, usage("10050m", "20"))
+		Expect(<-fakeRecorder.Events).To(ContainSubstring("requests.cpu 9950m -> 10050m/10"))
+
+		reconciler.recordUsageChanges(crq, usage("10", "20"))
+		Expect(<-fakeRecorder.Events).To(ContainSubstring("requests.cpu 10050m -> 10/10"))
+	})
+
+	It("starts over from the status after the quota is forgotten", func() {
+		reconciler.recordUsageChanges(crq, usage("5", "20"))
+		Expect(fakeRecorder.Events).To(HaveLen(1))
+		<-fakeRecorder.Events
+
+		reconciler.forgetReportedUsage(crq.Name)
+		crq.Status.Total.Used = usage("5", "20")
+		reconciler.recordUsageChanges(crq, usage("5", "20"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+})
+
+var _ = DescribeTable("significantUsageChange",
+	func(before, after, limit string, expected bool) {
+		Expect(significantUsageChange(resource.MustParse(before), resource.MustParse(after),
+			resource.MustParse(limit), 10)).To(Equal(expected))
+	},
+	Entry("unchanged", "4", "4", "10", false),
+	Entry("below the percentage", "4", "4900m", "10", false),
+	Entry("at the percentage", "4", "5", "10", true),
+	Entry("falling by the percentage", "5", "4", "10", true),
+	Entry("crossing the limit", "9990m", "10010m", "10", true),
+	Entry("any usage of a zero limit", "0", "1", "0", true),
+	Entry("changing over a zero limit", "1", "2", "0", false),
+)
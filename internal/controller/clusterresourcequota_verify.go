@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+)
+
+// UsageDiscrepancy is a single resource whose stored CRQ status differs from
+// usage recomputed against the live cluster. Namespace is empty for the
+// aggregated total.
+type UsageDiscrepancy struct {
+	Namespace string
+	Resource  corev1.ResourceName
+	Stored    resource.Quantity
+	Actual    resource.Quantity
+}
+
+func (d UsageDiscrepancy) String() string {
+	scope := d.Namespace
+	if scope == "" {
+		scope = "<total>"
+	}
+	return fmt.Sprintf("%s %s: stored %s, actual %s", scope, d.Resource, d.Stored.String(), d.Actual.String())
+}
+
+// NewUsageVerifier returns a reconciler wired for VerifyUsage only, applying
+// the same namespace and owner exclusions as the controller running with cfg.
+func NewUsageVerifier(c client.Client, cfg *config.Config, logger *zap.Logger) *ClusterResourceQuotaReconciler {
+	logger = logger.Named("clusterresourcequota-verify")
+	excludedOwners := cfg.ExcludedOwnerKinds()
+	return &ClusterResourceQuotaReconciler{
+		Client:                   c,
+		Config:                   cfg,
+		ExcludeNamespaceLabelKey: cfg.ExcludeNamespaceLabelKey,
+		ExcludedNamespaces:       cfg.ExcludedNamespaces,
+		SystemNamespaces:         cfg.SystemNamespaces,
+		NamespaceLabelDenylist:   cfg.NamespaceLabelDenylistSelectors(),
+		ExcludedOwners:           excludedOwners,
+		ExcludeHeadlessServices:  cfg.ExcludeHeadlessServices,
+		ObjectCountCalculator:    objectcount.NewObjectCountCalculator(objects.ExcludeOwned(c, excludedOwners), logger),
+		logger:                   logger,
+	}
+}
+
+// VerifyUsage recomputes the usage of the named CRQ from scratch, using the
+// same namespace selection and aggregation as Reconcile, and diffs it against
+// the stored status. It never writes to the cluster and records no metrics.
+// Namespaces present on only one side are reported resource by resource, with
+// zero on the missing side.
+func (r *ClusterResourceQuotaReconciler) VerifyUsage(ctx context.Context, name string) ([]UsageDiscrepancy, error) {
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, crq); err != nil {
+		return nil, fmt.Errorf("failed to get ClusterResourceQuota %s: %w", name, err)
+	}
+
+	namespaces, err := r.selectNamespaces(ctx, crq)
+	if err != nil {
+		return nil, err
+	}
+	u, err := r.computeUsage(ctx, crq, namespaces, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute usage: %w", err)
+	}
+	if len(u.incomplete) > 0 {
+		// A partial recount would show up as discrepancies that are not there.
+		return nil, fmt.Errorf("failed to recompute usage: %s", incompleteUsageMessage(u.incomplete))
+	}
+	totalUsage, usageByNamespace := u.total, u.byNamespace
+
+	discrepancies := diffUsage("", crq.Status.Total.Used, totalUsage)
+
+	stored := make(map[string]quotav1alpha1.ResourceList, len(crq.Status.Namespaces))
+	for _, ns := range crq.Status.Namespaces {
+		stored[ns.Namespace] = ns.Status.Used
+	}
+	for _, ns := range usageByNamespace {
+		discrepancies = append(discrepancies, diffUsage(ns.Namespace, stored[ns.Namespace], ns.Status.Used)...)
+		delete(stored, ns.Namespace)
+	}
+	for nsName, used := range stored {
+		discrepancies = append(discrepancies, diffUsage(nsName, used, nil)...)
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].Namespace != discrepancies[j].Namespace {
+			return discrepancies[i].Namespace < discrepancies[j].Namespace
+		}
+		return discrepancies[i].Resource < discrepancies[j].Resource
+	})
+	return discrepancies, nil
+}
+
+// diffUsage returns one discrepancy per resource whose quantities differ
+// between stored and actual. A resource missing on either side counts as zero.
+func diffUsage(namespace string, stored, actual quotav1alpha1.ResourceList) []UsageDiscrepancy {
+	var out []UsageDiscrepancy
+	seen := make(map[corev1.ResourceName]bool, len(actual))
+	for resourceName, actualQty := range actual {
+		seen[resourceName] = true
+		storedQty := stored[resourceName]
+		if storedQty.Cmp(actualQty) != 0 {
+			out = append(out, UsageDiscrepancy{Namespace: namespace, Resource: resourceName, Stored: storedQty, Actual: actualQty})
+		}
+	}
+	for resourceName, storedQty := range stored {
+		if seen[resourceName] || storedQty.IsZero() {
+			continue
+		}
+		out = append(out, UsageDiscrepancy{Namespace: namespace, Resource: resourceName, Stored: storedQty})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("VerifyUsage", func() {
+	var (
+		crq *quotav1alpha1.ClusterResourceQuota
+		r   *ClusterResourceQuotaReconciler
+	)
+
+	runningPod := func(name, ns string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	BeforeEach(func() {
+		crq = &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "verify-crq"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "verify"}},
+				Hard:              quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+				},
+				Namespaces: []quotav1alpha1.ResourceQuotaStatusByNamespace{{
+					Namespace: "verify-a",
+					Status: quotav1alpha1.ResourceQuotaStatus{
+						Used: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+					},
+				}},
+			},
+		}
+	})
+
+	build := func(objs ...*corev1.Pod) {
+		c := fake.NewClientBuilder().WithObjects(
+			crq,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "verify-a", Labels: map[string]string{"team": "verify"}}},
+		)
+		for _, o := range objs {
+			c = c.WithObjects(o)
+		}
+		r = NewUsageVerifier(c.Build(), &config.Config{}, zap.NewNop())
+	}
+
+	It("reports no discrepancies when the status matches the cluster", func() {
+		build(runningPod("p1", "verify-a"), runningPod("p2", "verify-a"))
+		discrepancies, err := r.VerifyUsage(context.Background(), "verify-crq")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(discrepancies).To(BeEmpty())
+	})
+
+	It("reports total and per-namespace drift", func() {
+		build(runningPod("p1", "verify-a"), runningPod("p2", "verify-a"), runningPod("p3", "verify-a"))
+		discrepancies, err := r.VerifyUsage(context.Background(), "verify-crq")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(discrepancies).To(HaveLen(2))
+		Expect(discrepancies[0].Namespace).To(BeEmpty())
+		Expect(discrepancies[1].Namespace).To(Equal("verify-a"))
+		Expect(discrepancies[1].String()).To(Equal("verify-a pods: stored 2, actual 3"))
+	})
+
+	It("reports namespaces that are in the status but no longer selected", func() {
+		crq.Status.Namespaces = append(crq.Status.Namespaces, quotav1alpha1.ResourceQuotaStatusByNamespace{
+			Namespace: "verify-gone",
+			Status: quotav1alpha1.ResourceQuotaStatus{
+				Used: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("1")},
+			},
+		})
+		build(runningPod("p1", "verify-a"), runningPod("p2", "verify-a"))
+		discrepancies, err := r.VerifyUsage(context.Background(), "verify-crq")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(discrepancies).To(HaveLen(1))
+		Expect(discrepancies[0].String()).To(Equal("verify-gone pods: stored 1, actual 0"))
+	})
+
+	It("records no usage metrics", func() {
+		build(runningPod("p1", "verify-a"), runningPod("p2", "verify-a"))
+		_, err := r.VerifyUsage(context.Background(), "verify-crq")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics.CRQUsageByOwnerKind.DeletePartialMatch(prometheus.Labels{"crq_name": "verify-crq"})).To(BeZero())
+		Expect(metrics.QuotaAggregationDuration.DeletePartialMatch(prometheus.Labels{"crq_name": "verify-crq"})).To(BeZero())
+	})
+
+	It("fails when the CRQ does not exist", func() {
+		build()
+		_, err := r.VerifyUsage(context.Background(), "missing")
+		Expect(err).To(MatchError(ContainSubstring("failed to get ClusterResourceQuota missing")))
+	})
+})
@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/endpointslices"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/resourceclaims"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+// watchKindsAuto is the --watch-kinds value that derives the watch set from
+// the union of all CRQ hard keys instead of a fixed list.
+const watchKindsAuto = "auto"
+
+// watchableKind is a cross-resource watch keyed by the plural resource name
+// accepted by --watch-kinds.
+type watchableKind struct {
+	kind  string
+	obj   func() client.Object
+	preds []predicate.Predicate
+}
+
+// watchableKinds lists every cross-resource watch. Namespaces are not listed:
+// they are always watched because namespace selection drives every CRQ.
+var watchableKinds = []watchableKind{
+	{"pods", func() client.Object { return &corev1.Pod{} }, []predicate.Predicate{resourceUpdatePredicate{}}},
+	{"persistentvolumeclaims", func() client.Object { return &corev1.PersistentVolumeClaim{} }, nil},
+	{"services", func() client.Object { return &corev1.Service{} }, nil},
+	// Generic object count resources
+	{"configmaps", func() client.Object { return &corev1.ConfigMap{} }, nil},
+	{"secrets", func() client.Object { return &corev1.Secret{} }, nil},
+	{"replicationcontrollers", func() client.Object { return &corev1.ReplicationController{} }, nil},
+	{"deployments", func() client.Object { return &appsv1.Deployment{} }, nil},
+	{"statefulsets", func() client.Object { return &appsv1.StatefulSet{} }, nil},
+	{"daemonsets", func() client.Object { return &appsv1.DaemonSet{} }, nil},
+	{"jobs", func() client.Object { return &batchv1.Job{} }, nil},
+	{"cronjobs", func() client.Object { return &batchv1.CronJob{} }, nil},
+	{"horizontalpodautoscalers", func() client.Object { return &autoscalingv2.HorizontalPodAutoscaler{} }, nil},
+	{"ingresses", func() client.Object { return &networkingv1.Ingress{} }, nil},
+	{"resourceclaims", func() client.Object { return &resourcev1.ResourceClaim{} }, nil},
+	{"endpointslices", func() client.Object { return &discoveryv1.EndpointSlice{} },
+		[]predicate.Predicate{endpointCountPredicate{}}},
+}
+
+// optionalWatchKinds are only watched when --watch-kinds names them or, under
+// "auto", a CRQ quotas them. Not every cluster serves their API, and a watch
+// on a kind the API server does not serve keeps the controller from starting.
+// EndpointSlices are served everywhere but change with every pod rollout, so
+// they are only watched where services.endpoints is wanted.
+var optionalWatchKinds = map[string]bool{"resourceclaims": true, "endpointslices": true}
+
+// endpointCountPredicate drops EndpointSlice updates that keep the number of
+// endpoints, such as readiness changes, since they change no usage.
+type endpointCountPredicate struct {
+	predicate.Funcs
+}
+
+// Update implements the update event filter.
+func (endpointCountPredicate) Update(e event.UpdateEvent) bool {
+	oldSlice, okOld := e.ObjectOld.(*discoveryv1.EndpointSlice)
+	newSlice, okNew := e.ObjectNew.(*discoveryv1.EndpointSlice)
+	if !okOld || !okNew {
+		return false
+	}
+	return len(oldSlice.Endpoints) != len(newSlice.Endpoints)
+}
+
+// legacyWatchObjects are the older API versions watched in place of a kind's
+// version in watchableKinds on clusters that do not serve it.
+var legacyWatchObjects = map[string]func() client.Object{
+	"horizontalpodautoscalers": func() client.Object { return &autoscalingv1.HorizontalPodAutoscaler{} },
+}
+
+// servedWatchObject returns the object to watch for w: the version listed in
+// watchableKinds, or its legacy version when mapper does not know the listed
+// one. Discovery errors other than a missing mapping keep the listed version.
+func servedWatchObject(mapper meta.RESTMapper, scheme *runtime.Scheme, w watchableKind) client.Object {
+	obj := w.obj()
+	newLegacy, ok := legacyWatchObjects[w.kind]
+	if !ok || mapper == nil {
+		return obj
+	}
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return obj
+	}
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); meta.IsNoMatchError(err) {
+		return newLegacy()
+	}
+	return obj
+}
+
+// countedMapFunc records the events of the kind watch that mapFunc maps, the
+// reconciles they request and how long mapping takes.
+func countedMapFunc(kind string, mapFunc handler.MapFunc) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		start := time.Now()
+		requests := mapFunc(ctx, obj)
+		metrics.RecordWatchEvent(kind, len(requests), time.Since(start))
+		return requests
+	}
+}
+
+// watchKindAliases maps accepted shorthands to their plural resource name.
+var watchKindAliases = map[string]string{
+	"pvcs": "persistentvolumeclaims",
+	"hpas": "horizontalpodautoscalers",
+}
+
+func lookupWatchableKind(kind string) (watchableKind, bool) {
+	for _, w := range watchableKinds {
+		if w.kind == kind {
+			return w, true
+		}
+	}
+	return watchableKind{}, false
+}
+
+// enabledWatchKinds resolves --watch-kinds into a set of plural resource names.
+// A nil set means every kind is watched. Unknown kinds are rejected so a typo
+// does not silently stop usage tracking.
+func enabledWatchKinds(requested []string) (map[string]bool, error) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+	enabled := make(map[string]bool, len(requested))
+	for _, kind := range requested {
+		kind = strings.ToLower(kind)
+		if alias, ok := watchKindAliases[kind]; ok {
+			kind = alias
+		}
+		if _, ok := lookupWatchableKind(kind); !ok {
+			return nil, fmt.Errorf("unknown watch kind %q", kind)
+		}
+		enabled[kind] = true
+	}
+	return enabled, nil
+}
+
+// isAutoWatchKinds reports whether --watch-kinds asks for the dynamic watch set.
+func isAutoWatchKinds(requested []string) bool {
+	return len(requested) == 1 && strings.EqualFold(requested[0], watchKindsAuto)
+}
+
+// watchKindsForHard returns the watch kinds whose objects contribute usage to
+// any of the given hard keys. Object-count keys carry the plural resource name
+// before the API group (deployments.apps), and service subtypes share the
+// services watch (services.loadbalancers).
+func (r *ClusterResourceQuotaReconciler) watchKindsForHard(hard quotav1alpha1.ResourceList) map[string]bool {
+	kinds := make(map[string]bool)
+	needed := r.classifyKindsNeeded(hard)
+	if needed.pods {
+		kinds["pods"] = true
+	}
+	if needed.services {
+		kinds["services"] = true
+	}
+	if needed.pvcs {
+		kinds["persistentvolumeclaims"] = true
+	}
+	for resourceName := range hard {
+		// Bare cpu and memory keys only apply to Actual mode, which reads
+		// usage for the pods the quota selects.
+		if _, observed := podmetrics.ObservedResource(resourceName); observed {
+			kinds["pods"] = true
+		}
+		// Device-class keys lead with the class name rather than the kind.
+		if resourceclaims.Supports(resourceName) {
+			kinds["resourceclaims"] = true
+		}
+		// services.endpoints is counted from EndpointSlices, not services.
+		if endpointslices.Supports(resourceName) {
+			kinds["endpointslices"] = true
+			continue
+		}
+		prefix, _, _ := strings.Cut(string(resourceName), ".")
+		if _, ok := lookupWatchableKind(prefix); ok {
+			kinds[prefix] = true
+		}
+	}
+	return kinds
+}
+
+// dynamicWatches tracks the watches started on demand when --watch-kinds=auto
+// or when a QuotaControllerConfig may change the watch kinds at runtime.
+// A kind's watch starts when the first CRQ quotas it and its informer is torn
+// down once no CRQ does, keeping informer memory proportional to what is
+// actually quota'd.
+//
+// controller-runtime cannot remove a source from a running controller, so a
+// stopped kind's source stays registered. Every start therefore bumps the
+// kind's generation and its source only enqueues while that generation is
+// current; a source left over from an earlier start stays silent even if
+// the cached client recreates the informer on a later read.
+type dynamicWatches struct {
+	mu     sync.Mutex
+	active map[string]bool
+	start  func(w watchableKind, live func() bool) error
+	stop   func(ctx context.Context, w watchableKind) error
+	logger *zap.Logger
+
+	genMu      sync.RWMutex
+	generation map[string]uint64
+}
+
+func newDynamicWatches(
+	ctrl ctrlcontroller.Controller,
+	informers cache.Cache,
+	mapFunc handler.MapFunc,
+	objFor func(watchableKind) client.Object,
+	logger *zap.Logger,
+) *dynamicWatches {
+	return &dynamicWatches{
+		active: make(map[string]bool),
+		start: func(w watchableKind, live func() bool) error {
+			counted := countedMapFunc(w.kind, mapFunc)
+			gated := func(ctx context.Context, obj client.Object) []reconcile.Request {
+				if !live() {
+					return nil
+				}
+				return counted(ctx, obj)
+			}
+			return ctrl.Watch(source.Kind(informers, objFor(w), handler.EnqueueRequestsFromMapFunc(gated), w.preds...))
+		},
+		stop: func(ctx context.Context, w watchableKind) error {
+			return informers.RemoveInformer(ctx, objFor(w))
+		},
+		logger: logger,
+	}
+}
+
+// bump starts a new generation for kind, silencing every source started
+// under an earlier one, and returns it.
+func (d *dynamicWatches) bump(kind string) uint64 {
+	d.genMu.Lock()
+	defer d.genMu.Unlock()
+	if d.generation == nil {
+		d.generation = make(map[string]uint64)
+	}
+	d.generation[kind]++
+	return d.generation[kind]
+}
+
+// live reports whether gen is still the current generation of kind.
+func (d *dynamicWatches) live(kind string, gen uint64) func() bool {
+	return func() bool {
+		d.genMu.RLock()
+		defer d.genMu.RUnlock()
+		return d.generation[kind] == gen
+	}
+}
+
+// sync starts watches for newly wanted kinds and stops watches no longer wanted.
+// Failures are logged and retried on the next sync.
+func (d *dynamicWatches) sync(ctx context.Context, wanted map[string]bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, w := range watchableKinds {
+		switch {
+		case wanted[w.kind] && !d.active[w.kind]:
+			if err := d.start(w, d.live(w.kind, d.bump(w.kind))); err != nil {
+				d.logger.Error("Failed to start watch", zap.String("kind", w.kind), zap.Error(err))
+				continue
+			}
+			d.active[w.kind] = true
+			d.logger.Info("Started watch for quota'd kind", zap.String("kind", w.kind))
+		case !wanted[w.kind] && d.active[w.kind]:
+			if err := d.stop(ctx, w); err != nil {
+				d.logger.Error("Failed to stop watch", zap.String("kind", w.kind), zap.Error(err))
+				continue
+			}
+			d.bump(w.kind)
+			delete(d.active, w.kind)
+			d.logger.Info("Stopped watch for kind no longer quota'd", zap.String("kind", w.kind))
+		}
+	}
+}
+
+// quotaSpecChanges resyncs the dynamic watches when a CRQ is created, deleted
+// or has its spec changed, the only events that can change which kinds are
+// worth watching. It enqueues nothing; the CRQ's own watch does that.
+func (r *ClusterResourceQuotaReconciler) quotaSpecChanges() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, _ event.CreateEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.syncDynamicWatches(ctx)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
+				r.syncDynamicWatches(ctx)
+			}
+		},
+		DeleteFunc: func(ctx context.Context, _ event.DeleteEvent, _ workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.syncDynamicWatches(ctx)
+		},
+	}
+}
+
+// syncDynamicWatches reconciles the running watches against the watch kinds
+// currently in effect: the union over every CRQ for "auto", otherwise the
+// fixed list. It is a no-op when every watch was installed statically.
+func (r *ClusterResourceQuotaReconciler) syncDynamicWatches(ctx context.Context) {
+	if r.dynamicWatches == nil {
+		return
+	}
+	requested := r.currentSettings().watchKinds
+	if !isAutoWatchKinds(requested) {
+		enabled, err := enabledWatchKinds(requested)
+		if err != nil {
+			r.logger.Error("Ignoring invalid watch kinds", zap.Strings("watch_kinds", requested), zap.Error(err))
+			return
+		}
+		r.dynamicWatches.sync(ctx, fixedWatchKinds(enabled))
+		return
+	}
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := r.List(ctx, crqList); err != nil {
+		r.logger.Error("Failed to list ClusterResourceQuotas for watch sync", zap.Error(err))
+		return
+	}
+	wanted := make(map[string]bool)
+	for _, crq := range crqList.Items {
+		for kind := range r.watchKindsForHard(crq.Spec.Hard) {
+			wanted[kind] = true
+		}
+	}
+	r.dynamicWatches.sync(ctx, wanted)
+}
+
+// fixedWatchKinds expands the result of enabledWatchKinds into an explicit
+// set, where nil stands for every kind but the optional ones.
+func fixedWatchKinds(enabled map[string]bool) map[string]bool {
+	if enabled != nil {
+		return enabled
+	}
+	all := make(map[string]bool, len(watchableKinds))
+	for _, w := range watchableKinds {
+		if !optionalWatchKinds[w.kind] {
+			all[w.kind] = true
+		}
+	}
+	return all
+}
@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("enabledWatchKinds", func() {
+	It("watches every kind when nothing is requested", func() {
+		enabled, err := enabledWatchKinds(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(enabled).To(BeNil())
+	})
+
+	It("resolves aliases and normalises case", func() {
+		enabled, err := enabledWatchKinds([]string{"Pods", "pvcs", "services"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(enabled).To(Equal(map[string]bool{
+			"pods":                   true,
+			"persistentvolumeclaims": true,
+			"services":               true,
+		}))
+	})
+
+	It("rejects unknown kinds", func() {
+		_, err := enabledWatchKinds([]string{"pods", "widgets"})
+		Expect(err).To(MatchError(ContainSubstring(`unknown watch kind "widgets"`)))
+	})
+})
+
+var _ = Describe("watchKindsForHard", func() {
+	It("maps hard keys to the kinds that contribute usage", func() {
+		r := &ClusterResourceQuotaReconciler{}
+		kinds := r.watchKindsForHard(quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU:                          resource.MustParse("1"),
+			"services.loadbalancers":                            resource.MustParse("1"),
+			"gold.storageclass.storage.k8s.io/requests.storage": resource.MustParse("1Gi"),
+			"deployments.apps":                                  resource.MustParse("1"),
+			"configmaps":                                        resource.MustParse("1"),
+		})
+		Expect(kinds).To(Equal(map[string]bool{
+			"pods":                   true,
+			"services":               true,
+			"persistentvolumeclaims": true,
+			"deployments":            true,
+			"configmaps":             true,
+		}))
+	})
+
+	It("maps bare cpu and memory, which only pods consume, to pods", func() {
+		r := &ClusterResourceQuotaReconciler{}
+		kinds := r.watchKindsForHard(quotav1alpha1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		})
+		Expect(kinds).To(Equal(map[string]bool{"pods": true}))
+	})
+
+	It("maps ResourceClaim counts and device-class keys to resourceclaims", func() {
+		r := &ClusterResourceQuotaReconciler{}
+		kinds := r.watchKindsForHard(quotav1alpha1.ResourceList{
+			"gpu.example.com.deviceclass.resource.k8s.io/devices": resource.MustParse("4"),
+		})
+		Expect(kinds).To(Equal(map[string]bool{"resourceclaims": true}))
+		kinds = r.watchKindsForHard(quotav1alpha1.ResourceList{
+			"resourceclaims.resource.k8s.io": resource.MustParse("4"),
+		})
+		Expect(kinds).To(Equal(map[string]bool{"resourceclaims": true}))
+	})
+
+	It("maps services.endpoints to endpointslices rather than services", func() {
+		r := &ClusterResourceQuotaReconciler{}
+		kinds := r.watchKindsForHard(quotav1alpha1.ResourceList{
+			"services.endpoints": resource.MustParse("1000"),
+		})
+		Expect(kinds).To(Equal(map[string]bool{"endpointslices": true}))
+	})
+})
+
+var _ = Describe("endpointCountPredicate", func() {
+	withEndpoints := func(n int) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{Endpoints: make([]discoveryv1.Endpoint, n)}
+	}
+
+	It("only passes updates that change the number of endpoints", func() {
+		pred := endpointCountPredicate{}
+		Expect(pred.Update(event.UpdateEvent{ObjectOld: withEndpoints(2), ObjectNew: withEndpoints(2)})).To(BeFalse())
+		Expect(pred.Update(event.UpdateEvent{ObjectOld: withEndpoints(2), ObjectNew: withEndpoints(3)})).To(BeTrue())
+		Expect(pred.Create(event.CreateEvent{Object: withEndpoints(1)})).To(BeTrue())
+	})
+})
+
+var _ = Describe("fixedWatchKinds", func() {
+	It("leaves optional kinds out of every kind", func() {
+		kinds := fixedWatchKinds(nil)
+		Expect(kinds).To(HaveKey("pods"))
+		Expect(kinds).NotTo(HaveKey("resourceclaims"))
+	})
+
+	It("keeps optional kinds that are asked for", func() {
+		enabled, err := enabledWatchKinds([]string{"resourceclaims"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fixedWatchKinds(enabled)).To(Equal(map[string]bool{"resourceclaims": true}))
+	})
+})
+
+var _ = Describe("countedMapFunc", func() {
+	It("counts the events of a kind and the reconciles they request", func() {
+		events := testutil.ToFloat64(metrics.WatchEvents.WithLabelValues("configmaps"))
+		enqueues := testutil.ToFloat64(metrics.WatchEnqueues.WithLabelValues("configmaps"))
+		mapFunc := countedMapFunc("configmaps", func(context.Context, client.Object) []reconcile.Request {
+			return []reconcile.Request{{}, {}}
+		})
+
+		Expect(mapFunc(context.Background(), &corev1.ConfigMap{})).To(HaveLen(2))
+		Expect(testutil.ToFloat64(metrics.WatchEvents.WithLabelValues("configmaps"))).To(Equal(events + 1))
+		Expect(testutil.ToFloat64(metrics.WatchEnqueues.WithLabelValues("configmaps"))).To(Equal(enqueues + 2))
+		Expect(testutil.CollectAndCount(metrics.WatchMapDuration)).To(BeNumerically(">=", 1))
+	})
+})
+
+var _ = Describe("servedWatchObject", func() {
+	hpas, _ := lookupWatchableKind("horizontalpodautoscalers")
+	mapperFor := func(versions ...schema.GroupVersion) meta.RESTMapper {
+		mapper := meta.NewDefaultRESTMapper(versions)
+		for _, gv := range versions {
+			mapper.Add(gv.WithKind("HorizontalPodAutoscaler"), meta.RESTScopeNamespace)
+		}
+		return mapper
+	}
+
+	It("watches autoscaling/v2 HorizontalPodAutoscalers when it is served", func() {
+		mapper := mapperFor(autoscalingv1.SchemeGroupVersion, autoscalingv2.SchemeGroupVersion)
+		Expect(servedWatchObject(mapper, clientgoscheme.Scheme, hpas)).
+			To(BeAssignableToTypeOf(&autoscalingv2.HorizontalPodAutoscaler{}))
+	})
+
+	It("falls back to autoscaling/v1 when autoscaling/v2 is not served", func() {
+		mapper := mapperFor(autoscalingv1.SchemeGroupVersion)
+		Expect(servedWatchObject(mapper, clientgoscheme.Scheme, hpas)).
+			To(BeAssignableToTypeOf(&autoscalingv1.HorizontalPodAutoscaler{}))
+	})
+
+	It("leaves kinds without a legacy version alone", func() {
+		pods, _ := lookupWatchableKind("pods")
+		Expect(servedWatchObject(mapperFor(), clientgoscheme.Scheme, pods)).
+			To(BeAssignableToTypeOf(&corev1.Pod{}))
+	})
+})
+
+var _ = Describe("dynamicWatches", func() {
+	var (
+		d       *dynamicWatches
+		started []string
+		stopped []string
+	)
+
+	BeforeEach(func() {
+		started, stopped = nil, nil
+		d = &dynamicWatches{
+			active: make(map[string]bool),
+			start: func(w watchableKind, _ func() bool) error {
+				started = append(started, w.kind)
+				return nil
+			},
+			stop: func(_ context.Context, w watchableKind) error {
+				stopped = append(stopped, w.kind)
+				return nil
+			},
+			logger: zap.NewNop(),
+		}
+	})
+
+	It("starts a watch once when a kind becomes wanted", func() {
+		d.sync(context.Background(), map[string]bool{"configmaps": true})
+		d.sync(context.Background(), map[string]bool{"configmaps": true})
+		Expect(started).To(Equal([]string{"configmaps"}))
+		Expect(stopped).To(BeEmpty())
+	})
+
+	It("stops a watch when no CRQ wants the kind anymore", func() {
+		d.sync(context.Background(), map[string]bool{"configmaps": true, "pods": true})
+		d.sync(context.Background(), map[string]bool{"pods": true})
+		Expect(stopped).To(Equal([]string{"configmaps"}))
+		Expect(d.active).To(Equal(map[string]bool{"pods": true}))
+	})
+
+	It("silences the source of a stopped watch, also after a restart", func() {
+		var lives []func() bool
+		d.start = func(_ watchableKind, live func() bool) error {
+			lives = append(lives, live)
+			return nil
+		}
+		d.sync(context.Background(), map[string]bool{"configmaps": true})
+		Expect(lives[0]()).To(BeTrue())
+
+		d.sync(context.Background(), map[string]bool{})
+		Expect(lives[0]()).To(BeFalse())
+
+		d.sync(context.Background(), map[string]bool{"configmaps": true})
+		Expect(lives[0]()).To(BeFalse())
+		Expect(lives[1]()).To(BeTrue())
+	})
+
+	It("retries a watch that failed to start on the next sync", func() {
+		d.start = func(w watchableKind, _ func() bool) error { return errors.New("boom") }
+		d.sync(context.Background(), map[string]bool{"secrets": true})
+		Expect(d.active).To(BeEmpty())
+
+		d.start = func(w watchableKind, _ func() bool) error {
+			started = append(started, w.kind)
+			return nil
+		}
+		d.sync(context.Background(), map[string]bool{"secrets": true})
+		Expect(started).To(Equal([]string{"secrets"}))
+	})
+})
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+// AnnotationMaxReplicasWithinQuota is set on HorizontalPodAutoscalers to the
+// most replicas of their target that fit the ClusterResourceQuota.
+const AnnotationMaxReplicasWithinQuota = "pac-quota-controller.powerapp.cloud/max-replicas-within-quota"
+
+// HorizontalPodAutoscalerReconciler advises how far each HorizontalPodAutoscaler
+// can scale its target before pods are denied by the ClusterResourceQuota
+// selecting its namespace. It never changes the HPA's spec.
+type HorizontalPodAutoscalerReconciler struct {
+	client.Client
+	// APIReader reads the scale targets, so the controller does not start an
+	// informer for every workload kind an HPA may target.
+	APIReader client.Reader
+	crqClient *quota.CRQClient
+	logger    *zap.Logger
+}
+
+// Reconcile records the replica advisory of one HorizontalPodAutoscaler.
+func (r *HorizontalPodAutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, req.NamespacedName, hpa); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			metrics.DeleteHPAMaxReplicas(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	maxReplicas, crqName, ok, err := r.advise(ctx, hpa)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// The quota selecting the namespace may have changed; drop its series first.
+	metrics.DeleteHPAMaxReplicas(hpa.Namespace, hpa.Name)
+	if !ok {
+		return ctrl.Result{}, r.setAnnotation(ctx, hpa, "")
+	}
+	metrics.HPAMaxReplicasWithinQuota.WithLabelValues(crqName, hpa.Namespace, hpa.Name).Set(float64(maxReplicas))
+	return ctrl.Result{}, r.setAnnotation(ctx, hpa, strconv.FormatInt(maxReplicas, 10))
+}
+
+// advise returns the most replicas of hpa's target that fit its quota. ok is
+// false when no quota selects the namespace, no hard limit constrains the
+// target's pods, or the target has no readable pod template.
+func (r *HorizontalPodAutoscalerReconciler) advise(
+	ctx context.Context,
+	hpa *autoscalingv2.HorizontalPodAutoscaler,
+) (maxReplicas int64, crqName string, ok bool, err error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: hpa.Namespace}, ns); err != nil {
+		return 0, "", false, client.IgnoreNotFound(err)
+	}
+	crq, err := r.crqClient.GetCRQByNamespace(ctx, ns)
+	if err != nil || crq == nil {
+		return 0, "", false, err
+	}
+
+	spec, err := r.targetPodSpec(ctx, hpa)
+	if err != nil {
+		// The target may not exist yet, or may have no pod template; neither
+		// is fixed by retrying.
+		r.logger.Debug("Skipping HorizontalPodAutoscaler without a readable pod template",
+			zap.String("namespace", hpa.Namespace),
+			zap.String("name", hpa.Name),
+			zap.Error(err))
+		return 0, "", false, nil
+	}
+	maxReplicas, ok = maxReplicasWithinQuota(crq, spec, int64(hpa.Status.CurrentReplicas))
+	return maxReplicas, crq.Name, ok, nil
+}
+
+// targetPodSpec reads the pod template of hpa's scale target.
+func (r *HorizontalPodAutoscalerReconciler) targetPodSpec(
+	ctx context.Context,
+	hpa *autoscalingv2.HorizontalPodAutoscaler,
+) (*corev1.PodSpec, error) {
+	ref := hpa.Spec.ScaleTargetRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	target := &unstructured.Unstructured{}
+	target.SetGroupVersionKind(gv.WithKind(ref.Kind))
+	if err := r.APIReader.Get(ctx, client.ObjectKey{Namespace: hpa.Namespace, Name: ref.Name}, target); err != nil {
+		return nil, fmt.Errorf("reading %s %s: %w", ref.Kind, ref.Name, err)
+	}
+	raw, found, err := unstructured.NestedMap(target.Object, "spec", "template")
+	if err != nil || !found {
+		return nil, fmt.Errorf("%s %s has no pod template", ref.Kind, ref.Name)
+	}
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, template); err != nil {
+		return nil, fmt.Errorf("%s %s pod template: %w", ref.Kind, ref.Name, err)
+	}
+	return &template.Spec, nil
+}
+
+// maxReplicasWithinQuota returns current plus the pods of spec that fit the
+// headroom of every hard limit they consume, never below zero. The current
+// replicas are already part of the recorded usage. ok is false when no
+// enforced hard limit applies to the pods.
+func maxReplicasWithinQuota(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	spec *corev1.PodSpec,
+	current int64,
+) (int64, bool) {
+	template := &corev1.Pod{Spec: *spec}
+	best, ok := int64(0), false
+	for resourceName, hard := range crq.Spec.Hard {
+		used, recorded := crq.Status.Total.Used[resourceName]
+		if !recorded || crq.Spec.Enforcement(resourceName) != quotav1alpha1.EnforcementEnforce {
+			continue
+		}
+		// Actual-mode CPU and memory follow observed usage, not the template.
+		_, observed := podmetrics.ObservedResource(resourceName)
+		if observed && crq.Spec.Mode == quotav1alpha1.QuotaModeActual {
+			continue
+		}
+		perPod := int64(1000) // one pod, in millis like every other quantity
+		if resourceName != corev1.ResourcePods {
+			q := pod.CalculatePodUsage(template, resourceName)
+			perPod = q.MilliValue()
+		}
+		if perPod <= 0 {
+			continue
+		}
+		headroom := hard.MilliValue() - used.MilliValue()
+		fit := headroom / perPod
+		if headroom < 0 && headroom%perPod != 0 {
+			fit--
+		}
+		if !ok || current+fit < best {
+			best, ok = current+fit, true
+		}
+	}
+	if ok && best < 0 {
+		best = 0
+	}
+	return best, ok
+}
+
+// setAnnotation sets the advisory annotation to value, removing it when value
+// is empty. It patches only on change.
+func (r *HorizontalPodAutoscalerReconciler) setAnnotation(
+	ctx context.Context,
+	hpa *autoscalingv2.HorizontalPodAutoscaler,
+	value string,
+) error {
+	current, set := hpa.Annotations[AnnotationMaxReplicasWithinQuota]
+	if (value == "" && !set) || (set && current == value) {
+		return nil
+	}
+	updated := hpa.DeepCopy()
+	if value == "" {
+		delete(updated.Annotations, AnnotationMaxReplicasWithinQuota)
+	} else {
+		if updated.Annotations == nil {
+			updated.Annotations = make(map[string]string)
+		}
+		updated.Annotations[AnnotationMaxReplicasWithinQuota] = value
+	}
+	r.logger.Debug("Updating HorizontalPodAutoscaler quota advisory",
+		zap.String("namespace", hpa.Namespace),
+		zap.String("name", hpa.Name),
+		zap.String("max_replicas", value))
+	return r.Patch(ctx, updated, client.MergeFrom(hpa))
+}
+
+// findHPAsForQuota enqueues the HorizontalPodAutoscalers in every namespace a
+// ClusterResourceQuota selects, since a change in its usage moves their advice.
+func (r *HorizontalPodAutoscalerReconciler) findHPAsForQuota(ctx context.Context, obj client.Object) []reconcile.Request {
+	crq, ok := obj.(*quotav1alpha1.ClusterResourceQuota)
+	if !ok {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, nsName := range r.crqClient.GetNamespacesFromStatus(crq) {
+		list := &autoscalingv2.HorizontalPodAutoscalerList{}
+		if err := r.List(ctx, list, client.InNamespace(nsName)); err != nil {
+			r.logger.Error("Failed to list HorizontalPodAutoscalers after quota change",
+				zap.String("namespace", nsName), zap.Error(err))
+			continue
+		}
+		for _, hpa := range list.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: hpa.Namespace, Name: hpa.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager registers the reconciler for HorizontalPodAutoscalers and
+// the ClusterResourceQuotas whose usage they are advised against.
+func (r *HorizontalPodAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.logger == nil {
+		r.logger = zap.L().Named("horizontalpodautoscaler-controller")
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	if r.crqClient == nil {
+		r.crqClient = quota.NewCRQClient(r.Client, r.logger)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Watches(&quotav1alpha1.ClusterResourceQuota{}, handler.EnqueueRequestsFromMapFunc(r.findHPAsForQuota)).
+		Named("horizontalpodautoscaler").
+		Complete(r)
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+)
+
+var _ = Describe("HorizontalPodAutoscalerReconciler", func() {
+	podSpec := func(cpu string) corev1.PodSpec {
+		return corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}}}
+	}
+
+	quotaWith := func(hard, used quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+		return &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				Hard:              hard,
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{Used: used},
+			},
+		}
+	}
+
+	Describe("maxReplicasWithinQuota", func() {
+		It("adds the pods that fit the tightest limit to the current replicas", func() {
+			crq := quotaWith(
+				quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("10"),
+					corev1.ResourcePods:        resource.MustParse("20"),
+					corev1.ResourceServices:    resource.MustParse("1"),
+				},
+				quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("8"),
+					corev1.ResourcePods:        resource.MustParse("19"),
+					corev1.ResourceServices:    resource.MustParse("1"),
+				},
+			)
+			spec := podSpec("500m")
+
+			// 2 CPUs fit four more pods, but only one more pod is allowed.
+			maxReplicas, ok := maxReplicasWithinQuota(crq, &spec, 3)
+			Expect(ok).To(BeTrue())
+			Expect(maxReplicas).To(Equal(int64(4)))
+		})
+
+		It("goes below the current replicas when the quota is already exceeded", func() {
+			crq := quotaWith(
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")},
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("3")},
+			)
+			spec := podSpec("400m")
+
+			maxReplicas, ok := maxReplicasWithinQuota(crq, &spec, 5)
+			Expect(ok).To(BeTrue())
+			Expect(maxReplicas).To(Equal(int64(2)))
+		})
+
+		It("has no advice when no limit applies to the pods", func() {
+			crq := quotaWith(
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("0")},
+			)
+			spec := podSpec("1")
+
+			_, ok := maxReplicasWithinQuota(crq, &spec, 1)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Reconcile", func() {
+		It("annotates the HPA with the replicas that fit", func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+				Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: podSpec("1")}},
+			}
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+						APIVersion: "apps/v1", Kind: "Deployment", Name: "web",
+					},
+					MaxReplicas: 20,
+				},
+				Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2},
+			}
+			crq := quotaWith(
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("5")},
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")},
+			)
+			c := fake.NewClientBuilder().WithObjects(ns, deployment, hpa, crq).Build()
+			r := &HorizontalPodAutoscalerReconciler{
+				Client:    c,
+				APIReader: c,
+				crqClient: quota.NewCRQClient(c, zap.NewNop()),
+				logger:    zap.NewNop(),
+			}
+
+			key := types.NamespacedName{Namespace: "team-a", Name: "web"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			updated := &autoscalingv2.HorizontalPodAutoscaler{}
+			Expect(c.Get(context.Background(), key, updated)).To(Succeed())
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationMaxReplicasWithinQuota, "5"))
+			Expect(updated.Spec.MaxReplicas).To(Equal(int32(20)), "the HPA spec is never changed")
+
+			By("removing the advice once no quota selects the namespace")
+			Expect(c.Delete(context.Background(), crq)).To(Succeed())
+			_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(context.Background(), key, updated)).To(Succeed())
+			Expect(updated.Annotations).NotTo(HaveKey(AnnotationMaxReplicasWithinQuota))
+		})
+
+		It("enqueues the HPAs of every namespace a quota selects", func() {
+			c := fake.NewClientBuilder().WithObjects(
+				&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "team-a"}},
+				&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team-b"}},
+			).Build()
+			r := &HorizontalPodAutoscalerReconciler{
+				Client:    c,
+				crqClient: quota.NewCRQClient(c, zap.NewNop()),
+				logger:    zap.NewNop(),
+			}
+			crq := quotaWith(nil, nil)
+			crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{Namespace: "team-a"}}
+
+			requests := r.findHPAsForQuota(context.Background(), crq)
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Name).To(Equal("a"))
+		})
+	})
+})
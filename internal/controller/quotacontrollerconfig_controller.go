@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+)
+
+const (
+	// ConditionReady reports whether a QuotaControllerConfig spec was applied.
+	ConditionReady = "Ready"
+	// ReasonApplied marks a QuotaControllerConfig whose whole spec is in effect.
+	ReasonApplied = "Applied"
+	// ReasonInvalidSpec marks a QuotaControllerConfig with fields the controller ignores.
+	ReasonInvalidSpec = "InvalidSpec"
+)
+
+// controllerSettings is the behavior the CRQ reconciler can change at runtime:
+// the command-line flags, overridden by the QuotaControllerConfig spec.
+type controllerSettings struct {
+	excludeNamespaceLabelKey string
+	excludedNamespaces       []string
+	watchKinds               []string
+	ownerPolicies            []quotav1alpha1.OwnerPodPolicy
+}
+
+// flagSettings returns the settings given on the command line.
+func (r *ClusterResourceQuotaReconciler) flagSettings() controllerSettings {
+	s := controllerSettings{
+		excludeNamespaceLabelKey: r.ExcludeNamespaceLabelKey,
+		excludedNamespaces:       r.ExcludedNamespaces,
+	}
+	if r.Config != nil {
+		s.watchKinds = r.Config.WatchKinds
+	}
+	return s
+}
+
+// currentSettings returns the settings in effect, safe for concurrent reconciles.
+func (r *ClusterResourceQuotaReconciler) currentSettings() controllerSettings {
+	r.settingsMu.RLock()
+	defer r.settingsMu.RUnlock()
+	if r.settings == nil {
+		return r.flagSettings()
+	}
+	return *r.settings
+}
+
+// ensureSettings loads the settings once if no QuotaControllerConfig event has
+// done so yet.
+func (r *ClusterResourceQuotaReconciler) ensureSettings(ctx context.Context) {
+	r.settingsMu.RLock()
+	loaded := r.settings != nil
+	r.settingsMu.RUnlock()
+	if !loaded {
+		r.loadSettings(ctx)
+	}
+}
+
+// loadSettings re-reads the QuotaControllerConfig from the cache. A missing
+// object restores the flags; a read failure keeps the settings already in effect.
+func (r *ClusterResourceQuotaReconciler) loadSettings(ctx context.Context) {
+	if r.ConfigName == "" {
+		return
+	}
+	cfg := &quotav1alpha1.QuotaControllerConfig{}
+	s := r.flagSettings()
+	if err := r.Get(ctx, types.NamespacedName{Name: r.ConfigName}, cfg); err != nil {
+		if !errors.IsNotFound(err) {
+			r.logger.Error("Failed to read QuotaControllerConfig; keeping current settings",
+				zap.String("name", r.ConfigName), zap.Error(err))
+			return
+		}
+	} else {
+		s = mergeSettings(s, &cfg.Spec)
+	}
+
+	r.settingsMu.Lock()
+	r.settings = &s
+	r.settingsMu.Unlock()
+}
+
+// mergeSettings applies every set and valid spec field over s. Invalid watch
+// kinds are ignored here; the config reconciler reports them on the object.
+func mergeSettings(s controllerSettings, spec *quotav1alpha1.QuotaControllerConfigSpec) controllerSettings {
+	if spec.ExcludeNamespaceLabelKey != nil {
+		s.excludeNamespaceLabelKey = *spec.ExcludeNamespaceLabelKey
+	}
+	// An empty list is set on purpose: it clears the flag's exclusions.
+	if spec.ExcludedNamespaces != nil {
+		s.excludedNamespaces = spec.ExcludedNamespaces
+	}
+	if len(spec.WatchKinds) > 0 && validateWatchKinds(spec.WatchKinds) == nil {
+		s.watchKinds = spec.WatchKinds
+	}
+	// Invalid owner policies are skipped when pods are charged.
+	s.ownerPolicies = spec.OwnerPolicies
+	return s
+}
+
+// validateWatchKinds accepts "auto" or a list of known watch kinds.
+func validateWatchKinds(kinds []string) error {
+	if isAutoWatchKinds(kinds) {
+		return nil
+	}
+	_, err := enabledWatchKinds(kinds)
+	return err
+}
+
+// findAllQuotas applies a QuotaControllerConfig change and enqueues every CRQ,
+// since exclusions apply to all of them.
+func (r *ClusterResourceQuotaReconciler) findAllQuotas(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetName() != r.ConfigName {
+		return nil
+	}
+	r.loadSettings(ctx)
+	r.syncDynamicWatches(ctx)
+
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := r.List(ctx, crqList); err != nil {
+		r.logger.Error("Failed to list ClusterResourceQuotas after config change", zap.Error(err))
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(crqList.Items))
+	for _, crq := range crqList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: crq.Name}})
+	}
+	return requests
+}
+
+// QuotaControllerConfigReconciler validates the QuotaControllerConfig, applies
+// the parts of it that live outside the controller process (the webhook
+// failure policy) and reports the outcome in its status. The CRQ reconciler
+// reads the remaining settings itself.
+type QuotaControllerConfigReconciler struct {
+	client.Client
+	// APIReader reads the ValidatingWebhookConfiguration without starting a
+	// cluster-wide informer for it.
+	APIReader client.Reader
+	// Name is the QuotaControllerConfig to reconcile; others are ignored.
+	Name string
+	// WebhookConfigurationName is the ValidatingWebhookConfiguration to patch.
+	WebhookConfigurationName string
+	// Observe leaves the webhook failure policy alone, as the observe profile
+	// writes to nothing but quota status.
+	Observe bool
+	logger  *zap.Logger
+}
+
+// Reconcile applies the named QuotaControllerConfig.
+func (r *QuotaControllerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cfg := &quotav1alpha1.QuotaControllerConfig{}
+	if err := r.Get(ctx, req.NamespacedName, cfg); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var problems []string
+	if len(cfg.Spec.WatchKinds) > 0 {
+		if err := validateWatchKinds(cfg.Spec.WatchKinds); err != nil {
+			problems = append(problems, fmt.Sprintf("watchKinds ignored: %v", err))
+		}
+	}
+	for i, policy := range cfg.Spec.OwnerPolicies {
+		if err := projection.ValidateOwnerPolicy(policy); err != nil {
+			problems = append(problems, fmt.Sprintf("ownerPolicies[%d] ignored: %v", i, err))
+		}
+	}
+	switch {
+	case cfg.Spec.WebhookFailurePolicy == nil:
+	case r.Observe:
+		problems = append(problems, "webhookFailurePolicy ignored: the controller runs the observe profile")
+	default:
+		if err := r.applyWebhookFailurePolicy(ctx, *cfg.Spec.WebhookFailurePolicy); err != nil {
+			r.logger.Error("Failed to apply webhook failure policy", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonApplied,
+		Message:            "Configuration applied",
+		ObservedGeneration: cfg.Generation,
+	}
+	if len(problems) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonInvalidSpec
+		condition.Message = strings.Join(problems, "; ")
+	}
+
+	updated := cfg.DeepCopy()
+	updated.Status.ObservedGeneration = cfg.Generation
+	meta.SetStatusCondition(&updated.Status.Conditions, condition)
+	if apiequality.Semantic.DeepEqual(cfg.Status, updated.Status) {
+		return ctrl.Result{}, nil
+	}
+	r.logger.Info("Applied QuotaControllerConfig",
+		zap.String("name", cfg.Name),
+		zap.Int64("generation", cfg.Generation),
+		zap.String("reason", condition.Reason))
+	return ctrl.Result{}, r.Status().Patch(ctx, updated, client.MergeFrom(cfg))
+}
+
+// applyWebhookFailurePolicy sets policy on every webhook of the controller's
+// ValidatingWebhookConfiguration, patching only when something changes. The
+// patch carries the resourceVersion so it fails rather than overwrite a
+// concurrent change (a Helm upgrade, the cert injector); the reconcile retries.
+func (r *QuotaControllerConfigReconciler) applyWebhookFailurePolicy(
+	ctx context.Context,
+	policy admissionregistrationv1.FailurePolicyType,
+) error {
+	if r.WebhookConfigurationName == "" {
+		return nil
+	}
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: r.WebhookConfigurationName}, vwc); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.Warn("ValidatingWebhookConfiguration not found; skipping failure policy",
+				zap.String("name", r.WebhookConfigurationName))
+			return nil
+		}
+		return err
+	}
+
+	updated := vwc.DeepCopy()
+	changed := false
+	for i := range updated.Webhooks {
+		if current := updated.Webhooks[i].FailurePolicy; current == nil || *current != policy {
+			updated.Webhooks[i].FailurePolicy = &policy
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	r.logger.Info("Setting webhook failure policy",
+		zap.String("webhook_configuration", vwc.Name),
+		zap.String("failure_policy", string(policy)))
+	return r.Patch(ctx, updated, client.MergeFromWithOptions(vwc, client.MergeFromWithOptimisticLock{}))
+}
+
+// SetupWithManager registers the reconciler for the configured object only.
+func (r *QuotaControllerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.logger == nil {
+		r.logger = zap.L().Named("quotacontrollerconfig-controller")
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&quotav1alpha1.QuotaControllerConfig{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(func(obj client.Object) bool { return obj.GetName() == r.Name }),
+		)).
+		Named("quotacontrollerconfig").
+		Complete(r)
+}
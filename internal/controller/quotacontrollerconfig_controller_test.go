@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+var _ = Describe("ClusterResourceQuotaReconciler settings", func() {
+	var r *ClusterResourceQuotaReconciler
+
+	newReconciler := func(objs ...client.Object) *ClusterResourceQuotaReconciler {
+		return &ClusterResourceQuotaReconciler{
+			Client:                   fake.NewClientBuilder().WithObjects(objs...).Build(),
+			Config:                   &config.Config{WatchKinds: []string{"pods"}},
+			ExcludeNamespaceLabelKey: "flag-exclude",
+			ExcludedNamespaces:       []string{"kube-system"},
+			ConfigName:               "pac-quota-controller",
+			logger:                   zap.NewNop(),
+		}
+	}
+
+	It("uses the flags until a config is loaded", func() {
+		r = newReconciler()
+		Expect(r.currentSettings()).To(Equal(controllerSettings{
+			excludeNamespaceLabelKey: "flag-exclude",
+			excludedNamespaces:       []string{"kube-system"},
+			watchKinds:               []string{"pods"},
+		}))
+	})
+
+	It("overrides the flags with the fields the config sets", func() {
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec: quotav1alpha1.QuotaControllerConfigSpec{
+				ExcludedNamespaces: []string{"monitoring"},
+				WatchKinds:         []string{"auto"},
+			},
+		})
+		r.loadSettings(context.Background())
+
+		Expect(r.currentSettings()).To(Equal(controllerSettings{
+			excludeNamespaceLabelKey: "flag-exclude",
+			excludedNamespaces:       []string{"monitoring"},
+			watchKinds:               []string{"auto"},
+		}))
+		Expect(r.isNamespaceExcluded(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}})).To(BeTrue())
+		Expect(r.isNamespaceExcluded(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})).To(BeFalse())
+	})
+
+	It("clears the flag exclusions with an empty excludedNamespaces", func() {
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{ExcludedNamespaces: []string{}},
+		})
+		r.loadSettings(context.Background())
+
+		Expect(r.currentSettings().excludedNamespaces).To(BeEmpty())
+		Expect(r.isNamespaceExcluded(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})).To(BeFalse())
+	})
+
+	It("keeps excluding system namespaces whatever the config says", func() {
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{ExcludedNamespaces: []string{}},
+		})
+		r.SystemNamespaces = []string{"kube-node-lease"}
+		r.loadSettings(context.Background())
+
+		Expect(r.isNamespaceExcluded(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-node-lease"}})).
+			To(BeTrue())
+	})
+
+	It("keeps the flag exclusions when excludedNamespaces is unset", func() {
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+		})
+		r.loadSettings(context.Background())
+
+		Expect(r.currentSettings().excludedNamespaces).To(Equal([]string{"kube-system"}))
+	})
+
+	It("applies a config change when its watch event is mapped", func() {
+		cfg := &quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{ExcludedNamespaces: []string{"monitoring"}},
+		}
+		crq := &quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+		r = newReconciler(cfg, crq)
+
+		requests := r.findAllQuotas(context.Background(), cfg)
+
+		Expect(requests).To(HaveLen(1))
+		Expect(r.currentSettings().excludedNamespaces).To(Equal([]string{"monitoring"}))
+	})
+
+	It("loads the settings only until the config watch has", func() {
+		cfg := &quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{ExcludedNamespaces: []string{"monitoring"}},
+		}
+		r = newReconciler(cfg)
+		r.ensureSettings(context.Background())
+		Expect(r.currentSettings().excludedNamespaces).To(Equal([]string{"monitoring"}))
+
+		cfg.Spec.ExcludedNamespaces = []string{"logging"}
+		Expect(r.Update(context.Background(), cfg)).To(Succeed())
+		r.ensureSettings(context.Background())
+		Expect(r.currentSettings().excludedNamespaces).To(Equal([]string{"monitoring"}))
+	})
+
+	It("ignores invalid watch kinds", func() {
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{WatchKinds: []string{"widgets"}},
+		})
+		r.loadSettings(context.Background())
+
+		Expect(r.currentSettings().watchKinds).To(Equal([]string{"pods"}))
+	})
+
+	It("takes the owner policies from the config", func() {
+		policies := []quotav1alpha1.OwnerPodPolicy{{Kind: "SparkApplication"}}
+		r = newReconciler(&quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{OwnerPolicies: policies},
+		})
+		r.loadSettings(context.Background())
+
+		Expect(r.currentSettings().ownerPolicies).To(Equal(policies))
+	})
+
+	It("falls back to the flags once the config is deleted", func() {
+		noLabelKey := ""
+		cfg := &quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+			Spec:       quotav1alpha1.QuotaControllerConfigSpec{ExcludeNamespaceLabelKey: &noLabelKey},
+		}
+		r = newReconciler(cfg)
+		r.loadSettings(context.Background())
+		Expect(r.currentSettings().excludeNamespaceLabelKey).To(BeEmpty())
+
+		Expect(r.Delete(context.Background(), cfg)).To(Succeed())
+		r.loadSettings(context.Background())
+		Expect(r.currentSettings().excludeNamespaceLabelKey).To(Equal("flag-exclude"))
+	})
+})
+
+var _ = Describe("QuotaControllerConfigReconciler", func() {
+	const (
+		configName  = "pac-quota-controller"
+		webhookName = "pac-quota-controller-validating-webhook"
+	)
+
+	var (
+		c       client.Client
+		r       *QuotaControllerConfigReconciler
+		vwc     *admissionregistrationv1.ValidatingWebhookConfiguration
+		observe bool
+	)
+
+	BeforeEach(func() {
+		observe = false
+		ignore := admissionregistrationv1.Ignore
+		vwc = &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: webhookName},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "vpod.quota.powerapp.cloud", FailurePolicy: &ignore},
+				{Name: "vservice.quota.powerapp.cloud"},
+			},
+		}
+	})
+
+	reconcileConfig := func(spec quotav1alpha1.QuotaControllerConfigSpec) *quotav1alpha1.QuotaControllerConfig {
+		cfg := &quotav1alpha1.QuotaControllerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: configName, Generation: 3},
+			Spec:       spec,
+		}
+		c = fake.NewClientBuilder().
+			WithObjects(cfg, vwc).
+			WithStatusSubresource(&quotav1alpha1.QuotaControllerConfig{}).
+			Build()
+		r = &QuotaControllerConfigReconciler{
+			Client:                   c,
+			APIReader:                c,
+			Name:                     configName,
+			WebhookConfigurationName: webhookName,
+			Observe:                  observe,
+			logger:                   zap.NewNop(),
+		}
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: configName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &quotav1alpha1.QuotaControllerConfig{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: configName}, updated)).To(Succeed())
+		return updated
+	}
+
+	It("marks a valid config Ready and records the generation", func() {
+		cfg := reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{WatchKinds: []string{"pods", "pvcs"}})
+
+		Expect(cfg.Status.ObservedGeneration).To(Equal(int64(3)))
+		ready := meta.FindStatusCondition(cfg.Status.Conditions, ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.Reason).To(Equal(ReasonApplied))
+	})
+
+	It("reports invalid watch kinds", func() {
+		cfg := reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{WatchKinds: []string{"widgets"}})
+
+		ready := meta.FindStatusCondition(cfg.Status.Conditions, ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(ReasonInvalidSpec))
+		Expect(ready.Message).To(ContainSubstring(`unknown watch kind "widgets"`))
+	})
+
+	It("reports invalid owner policies", func() {
+		zero := resource.MustParse("0")
+		cfg := reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{OwnerPolicies: []quotav1alpha1.OwnerPodPolicy{
+			{Kind: "Workflow"},
+			{Kind: "SparkApplication", ChargeFactor: &zero},
+		}})
+
+		ready := meta.FindStatusCondition(cfg.Status.Conditions, ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(ReasonInvalidSpec))
+		Expect(ready.Message).To(Equal("ownerPolicies[1] ignored: chargeFactor 0 must be positive"))
+	})
+
+	It("applies the webhook failure policy to every webhook", func() {
+		fail := admissionregistrationv1.Fail
+		reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{WebhookFailurePolicy: &fail})
+
+		updated := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: webhookName}, updated)).To(Succeed())
+		for _, wh := range updated.Webhooks {
+			Expect(wh.FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Fail)), wh.Name)
+		}
+	})
+
+	It("leaves the webhook configuration alone when no policy is set", func() {
+		reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{})
+
+		updated := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: webhookName}, updated)).To(Succeed())
+		Expect(updated.Webhooks[1].FailurePolicy).To(BeNil())
+	})
+
+	It("leaves the failure policy alone under the observe profile", func() {
+		observe = true
+		fail := admissionregistrationv1.Fail
+		cfg := reconcileConfig(quotav1alpha1.QuotaControllerConfigSpec{WebhookFailurePolicy: &fail})
+
+		updated := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: webhookName}, updated)).To(Succeed())
+		Expect(updated.Webhooks[1].FailurePolicy).To(BeNil())
+		ready := meta.FindStatusCondition(cfg.Status.Conditions, ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(ReasonInvalidSpec))
+		Expect(ready.Message).To(ContainSubstring("observe profile"))
+	})
+})
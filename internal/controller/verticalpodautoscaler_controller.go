@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/vpa"
+)
+
+// VerticalPodAutoscalerReconciler checks VerticalPodAutoscaler recommendations
+// against the headroom of the ClusterResourceQuota selecting their namespace,
+// and warns on the VPA when applying a recommendation would exceed it.
+type VerticalPodAutoscalerReconciler struct {
+	client.Client
+	// APIReader reads the target workloads, so the controller does not start
+	// an informer for every workload kind a VPA may target.
+	APIReader     client.Reader
+	EventRecorder *events.EventRecorder
+	crqClient     *quota.CRQClient
+	logger        *zap.Logger
+}
+
+// Reconcile compares the recommendation of a VerticalPodAutoscaler with the
+// quota left in its namespace's ClusterResourceQuota.
+func (r *VerticalPodAutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	obj := vpa.New()
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	plan, err := vpa.LoadPlan(ctx, r.APIReader, obj)
+	if err != nil {
+		// The target may not exist yet, or may be a kind without a pod
+		// template; neither is fixed by retrying.
+		r.logger.Debug("Skipping VerticalPodAutoscaler without a readable target",
+			zap.String("namespace", req.Namespace),
+			zap.String("name", req.Name),
+			zap.Error(err))
+		return ctrl.Result{}, nil
+	}
+	if plan == nil {
+		return ctrl.Result{}, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: req.Namespace}, ns); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	crq, err := r.crqClient.GetCRQByNamespace(ctx, ns)
+	if err != nil || crq == nil {
+		return ctrl.Result{}, err
+	}
+
+	headroom := vpa.Headroom(crq)
+	delta := plan.Delta()
+	for _, name := range plan.Exceeded(headroom) {
+		r.logger.Info("VerticalPodAutoscaler recommendation exceeds quota headroom",
+			zap.String("namespace", req.Namespace),
+			zap.String("name", req.Name),
+			zap.String("crq_name", crq.Name),
+			zap.String("resource", string(name)),
+			zap.String("increase", delta.Name(name, "").String()),
+			zap.String("headroom", headroom.Name(name, "").String()))
+		r.EventRecorder.RecommendationExceedsQuota(obj, crq, name, delta[name], headroom[name])
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers the reconciler for VerticalPodAutoscalers. The
+// VPA CRDs must be installed, or the manager fails to start the watch.
+func (r *VerticalPodAutoscalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.logger == nil {
+		r.logger = zap.L().Named("verticalpodautoscaler-controller")
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	if r.crqClient == nil {
+		r.crqClient = quota.NewCRQClient(r.Client, r.logger)
+	}
+	if r.EventRecorder == nil {
+		r.EventRecorder = events.NewEventRecorder(
+			mgr.GetEventRecorder("pac-quota-controller"),
+			r.logger,
+		)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(vpa.New()).
+		Named("verticalpodautoscaler").
+		Complete(r)
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	k8sevents "k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/vpa"
+)
+
+var _ = Describe("VerticalPodAutoscalerReconciler", func() {
+	var fakeRecorder *k8sevents.FakeRecorder
+
+	deployment := func(replicas int64, cpu string) *unstructured.Unstructured {
+		d := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"replicas": replicas,
+				"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{
+						"name":      "app",
+						"resources": map[string]any{"requests": map[string]any{"cpu": cpu}},
+					}},
+				}},
+			},
+		}}
+		d.SetAPIVersion("apps/v1")
+		d.SetKind("Deployment")
+		d.SetNamespace("team-a")
+		d.SetName("web")
+		return d
+	}
+
+	recommendation := func(cpu string) *unstructured.Unstructured {
+		v := vpa.New()
+		v.SetNamespace("team-a")
+		v.SetName("web")
+		v.Object["spec"] = map[string]any{
+			"targetRef": map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		}
+		v.Object["status"] = map[string]any{"recommendation": map[string]any{
+			"containerRecommendations": []any{map[string]any{
+				"containerName": "app",
+				"target":        map[string]any{"cpu": cpu},
+			}},
+		}}
+		return v
+	}
+
+	reconcile := func(objs ...client.Object) {
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				Hard:              quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+			},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("3")},
+				},
+			},
+		}
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}}
+		c := fake.NewClientBuilder().WithObjects(append(objs, crq, ns)...).Build()
+		fakeRecorder = k8sevents.NewFakeRecorder(10)
+		r := &VerticalPodAutoscalerReconciler{
+			Client:        c,
+			APIReader:     c,
+			EventRecorder: events.NewEventRecorder(fakeRecorder, zap.NewNop()),
+			crqClient:     quota.NewCRQClient(c, zap.NewNop()),
+			logger:        zap.NewNop(),
+		}
+		_, err := r.Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "web"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("warns when the recommendation needs more than the quota has left", func() {
+		// Two replicas going from 500m to 1500m need 2 more CPUs; 1 is left.
+		reconcile(deployment(2, "500m"), recommendation("1500m"))
+
+		Expect(fakeRecorder.Events).To(HaveLen(1))
+		event := <-fakeRecorder.Events
+		Expect(event).To(ContainSubstring("Warning RecommendationExceedsQuota"))
+		Expect(event).To(ContainSubstring("needs 2 more requests.cpu than ClusterResourceQuota team-a has left (1)"))
+	})
+
+	It("stays quiet when the recommendation fits", func() {
+		reconcile(deployment(2, "500m"), recommendation("900m"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+
+	It("skips a VPA whose target does not exist", func() {
+		reconcile(recommendation("8"))
+		Expect(fakeRecorder.Events).To(BeEmpty())
+	})
+})
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/resourceclaims"
+)
+
+// SuspendedRulesAnnotation holds, as JSON keyed by webhook name, the rules of
+// the webhooks whose rules the scope reconciler emptied, so they can be
+// restored once a ClusterResourceQuota needs them again.
+const SuspendedRulesAnnotation = "quota.powerapp.cloud/suspended-rules"
+
+// WebhookScopeReconciler empties the rules of the validating webhooks that are
+// disabled by --enable-webhooks or that no ClusterResourceQuota needs, so the
+// apiserver stops calling them, and restores them when a quota needs them.
+// Webhooks are recognized by their service path.
+type WebhookScopeReconciler struct {
+	client.Client
+	// APIReader reads the ValidatingWebhookConfiguration without starting a
+	// cluster-wide informer for it.
+	APIReader client.Reader
+	// WebhookConfigurationName is the ValidatingWebhookConfiguration to patch.
+	WebhookConfigurationName string
+	Config                   *config.Config
+	logger                   *zap.Logger
+}
+
+// Reconcile brings the webhook rules in line with the quotas in the cluster.
+// Every request is for the configuration as a whole.
+func (r *WebhookScopeReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := r.List(ctx, crqList); err != nil {
+		return ctrl.Result{}, err
+	}
+	active := activeWebhooks(r.Config, crqList.Items)
+
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: r.WebhookConfigurationName}, vwc); err != nil {
+		if errors.IsNotFound(err) {
+			r.logger.Warn("ValidatingWebhookConfiguration not found; skipping webhook scope",
+				zap.String("name", r.WebhookConfigurationName))
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	updated, changed, err := scopeWebhooks(vwc, active)
+	if err != nil || !changed {
+		return ctrl.Result{}, err
+	}
+	r.logger.Info("Updating webhook scope",
+		zap.String("webhook_configuration", vwc.Name),
+		zap.Strings("active_webhooks", activeNames(active)))
+	return ctrl.Result{}, r.Patch(ctx, updated, client.MergeFromWithOptions(vwc, client.MergeFromWithOptimisticLock{}))
+}
+
+// activeWebhooks returns the enabled webhooks that have something to enforce.
+// The quota and namespace webhooks are always needed; the others only when
+// some quota sets a limit they check.
+func activeWebhooks(cfg *config.Config, crqs []quotav1alpha1.ClusterResourceQuota) map[string]bool {
+	needed := map[string]bool{
+		config.WebhookClusterResourceQuotas: true,
+		config.WebhookNamespaces:            true,
+		config.WebhookPVCs:                  cfg.PVCDeletionProtection,
+	}
+	classifier := &ClusterResourceQuotaReconciler{}
+	for _, crq := range crqs {
+		kinds := classifier.classifyKindsNeeded(crq.Spec.Hard)
+		needed[config.WebhookPods] = needed[config.WebhookPods] || kinds.pods
+		needed[config.WebhookServices] = needed[config.WebhookServices] || kinds.services
+		needed[config.WebhookPVCs] = needed[config.WebhookPVCs] || kinds.pvcs
+		needed[config.WebhookStatefulSets] = needed[config.WebhookStatefulSets] || kinds.pvcs
+		for resourceName := range crq.Spec.Hard {
+			if objectcount.Supports(resourceName) {
+				needed[config.WebhookObjectCounts] = true
+			}
+			if resourceclaims.Supports(resourceName) {
+				needed[config.WebhookResourceClaims] = true
+			}
+		}
+	}
+
+	active := make(map[string]bool)
+	for name, ok := range needed {
+		if ok && cfg.WebhookEnabled(name) {
+			active[name] = true
+		}
+	}
+	return active
+}
+
+// scopeWebhooks returns a copy of vwc in which inactive webhooks have no rules
+// and active ones have their suspended rules back. It reports whether anything
+// changed.
+func scopeWebhooks(
+	vwc *admissionregistrationv1.ValidatingWebhookConfiguration,
+	active map[string]bool,
+) (*admissionregistrationv1.ValidatingWebhookConfiguration, bool, error) {
+	suspended := map[string][]admissionregistrationv1.RuleWithOperations{}
+	if raw := vwc.Annotations[SuspendedRulesAnnotation]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &suspended); err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s annotation: %w", SuspendedRulesAnnotation, err)
+		}
+	}
+
+	pathNames := make(map[string]string, len(config.WebhookPaths))
+	for name, path := range config.WebhookPaths {
+		pathNames[path] = name
+	}
+
+	updated := vwc.DeepCopy()
+	changed := false
+	for i := range updated.Webhooks {
+		wh := &updated.Webhooks[i]
+		if wh.ClientConfig.Service == nil || wh.ClientConfig.Service.Path == nil {
+			continue
+		}
+		name, ok := pathNames[*wh.ClientConfig.Service.Path]
+		if !ok {
+			continue
+		}
+		switch {
+		case !active[name] && len(wh.Rules) > 0:
+			suspended[name] = wh.Rules
+			wh.Rules = []admissionregistrationv1.RuleWithOperations{}
+			changed = true
+		case active[name] && len(wh.Rules) == 0 && len(suspended[name]) > 0:
+			wh.Rules = suspended[name]
+			delete(suspended, name)
+			changed = true
+		case active[name] && len(wh.Rules) > 0 && suspended[name] != nil:
+			// Rules were put back by someone else, such as a Helm upgrade.
+			delete(suspended, name)
+			changed = true
+		}
+	}
+	if !changed {
+		return vwc, false, nil
+	}
+
+	if len(suspended) == 0 {
+		delete(updated.Annotations, SuspendedRulesAnnotation)
+		return updated, true, nil
+	}
+	raw, err := json.Marshal(suspended)
+	if err != nil {
+		return nil, false, err
+	}
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[SuspendedRulesAnnotation] = string(raw)
+	return updated, true, nil
+}
+
+// activeNames returns the webhooks in set in the order they are served.
+func activeNames(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for _, name := range config.AllWebhooks {
+		if set[name] {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// SetupWithManager reconciles the webhook configuration once on start, so
+// webhooks are scoped even before the first quota exists, and then on every
+// ClusterResourceQuota change.
+func (r *WebhookScopeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.logger == nil {
+		r.logger = zap.L().Named("webhookscope-controller")
+	}
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	vwc.Name = r.WebhookConfigurationName
+	initial := make(chan event.GenericEvent, 1)
+	initial <- event.GenericEvent{Object: vwc}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("webhookscope").
+		WatchesRawSource(source.Channel(initial, &handler.EnqueueRequestForObject{})).
+		Watches(&quotav1alpha1.ClusterResourceQuota{},
+			handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.WebhookConfigurationName}}}
+			})).
+		Complete(r)
+}
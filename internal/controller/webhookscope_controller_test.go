@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+)
+
+var _ = Describe("WebhookScopeReconciler", func() {
+	const webhookName = "pac-quota-controller-validating-webhook"
+
+	var (
+		c   client.Client
+		cfg *config.Config
+	)
+
+	webhook := func(name string, rules ...admissionregistrationv1.RuleWithOperations) admissionregistrationv1.ValidatingWebhook {
+		path := config.WebhookPaths[name]
+		return admissionregistrationv1.ValidatingWebhook{
+			Name:         "v" + name + ".powerapp.cloud",
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{Service: &admissionregistrationv1.ServiceReference{Path: &path}},
+			Rules:        rules,
+		}
+	}
+	rule := func(resource string) admissionregistrationv1.RuleWithOperations {
+		return admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{resource},
+			},
+		}
+	}
+	quotaWith := func(name string, hard quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+		return &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       quotav1alpha1.ClusterResourceQuotaSpec{Hard: hard},
+		}
+	}
+
+	BeforeEach(func() {
+		cfg = &config.Config{}
+	})
+
+	reconcileScope := func(objs ...client.Object) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		r := &WebhookScopeReconciler{
+			WebhookConfigurationName: webhookName,
+			Config:                   cfg,
+			logger:                   zap.NewNop(),
+		}
+		if c == nil {
+			c = fake.NewClientBuilder().WithObjects(&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: webhookName},
+				Webhooks: []admissionregistrationv1.ValidatingWebhook{
+					webhook(config.WebhookNamespaces, rule("namespaces")),
+					webhook(config.WebhookPods, rule("pods")),
+					webhook(config.WebhookServices, rule("services")),
+				},
+			}).Build()
+		}
+		for _, obj := range objs {
+			Expect(c.Create(context.Background(), obj)).To(Succeed())
+		}
+		r.Client, r.APIReader = c, c
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: webhookName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: webhookName}, updated)).To(Succeed())
+		return updated
+	}
+
+	AfterEach(func() {
+		c = nil
+	})
+
+	It("suspends the rules of webhooks no quota needs", func() {
+		vwc := reconcileScope(quotaWith("pods-only", quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("1"),
+		}))
+		Expect(vwc.Webhooks[0].Rules).To(HaveLen(1))
+		Expect(vwc.Webhooks[1].Rules).To(HaveLen(1))
+		Expect(vwc.Webhooks[2].Rules).To(BeEmpty())
+		Expect(vwc.Annotations[SuspendedRulesAnnotation]).To(ContainSubstring(`"services"`))
+	})
+
+	It("restores suspended rules once a quota needs the webhook", func() {
+		reconcileScope()
+		vwc := reconcileScope(quotaWith("services", quotav1alpha1.ResourceList{
+			"services.loadbalancers": resource.MustParse("1"),
+		}))
+		Expect(vwc.Webhooks[1].Rules).To(BeEmpty())
+		Expect(vwc.Webhooks[2].Rules).To(ConsistOf(rule("services")))
+		Expect(vwc.Annotations[SuspendedRulesAnnotation]).NotTo(ContainSubstring(`"services"`))
+	})
+
+	It("suspends webhooks disabled by --enable-webhooks even when a quota needs them", func() {
+		cfg.EnabledWebhooks = []string{config.WebhookNamespaces, config.WebhookServices}
+		vwc := reconcileScope(quotaWith("pods", quotav1alpha1.ResourceList{
+			corev1.ResourcePods: resource.MustParse("10"),
+		}))
+		Expect(vwc.Webhooks[1].Rules).To(BeEmpty())
+	})
+
+	It("keeps the namespace webhook and drops the annotation when nothing is suspended", func() {
+		vwc := reconcileScope(quotaWith("everything", quotav1alpha1.ResourceList{
+			corev1.ResourcePods: resource.MustParse("10"),
+			"services":          resource.MustParse("10"),
+		}))
+		for _, wh := range vwc.Webhooks {
+			Expect(wh.Rules).To(HaveLen(1), wh.Name)
+		}
+		Expect(vwc.Annotations).NotTo(HaveKey(SuspendedRulesAnnotation))
+	})
+
+	It("needs the PVC webhook for deletion protection without PVC quotas", func() {
+		cfg.PVCDeletionProtection = true
+		Expect(activeWebhooks(cfg, nil)).To(HaveKey(config.WebhookPVCs))
+		cfg.PVCDeletionProtection = false
+		Expect(activeWebhooks(cfg, nil)).NotTo(HaveKey(config.WebhookPVCs))
+	})
+
+	It("needs the object count webhook for object count quotas", func() {
+		crq := quotaWith("counts", quotav1alpha1.ResourceList{"configmaps": resource.MustParse("5")})
+		Expect(activeWebhooks(cfg, []quotav1alpha1.ClusterResourceQuota{*crq})).To(HaveKey(config.WebhookObjectCounts))
+	})
+
+	It("needs the StatefulSet webhook for storage quotas", func() {
+		crq := quotaWith("storage", quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsStorage: resource.MustParse("100Gi"),
+		})
+		Expect(activeWebhooks(cfg, []quotav1alpha1.ClusterResourceQuota{*crq})).To(HaveKey(config.WebhookStatefulSets))
+	})
+
+	It("needs the ResourceClaim webhook for device-class quotas", func() {
+		crq := quotaWith("gpus", quotav1alpha1.ResourceList{
+			"gpu.example.com.deviceclass.resource.k8s.io/devices": resource.MustParse("4"),
+		})
+		active := activeWebhooks(cfg, []quotav1alpha1.ClusterResourceQuota{*crq})
+		Expect(active).To(HaveKey(config.WebhookResourceClaims))
+		Expect(active).NotTo(HaveKey(config.WebhookObjectCounts))
+	})
+})
@@ -0,0 +1,110 @@
+// Package admin serves operator actions on the webhook port, guarded by a
+// bearer token, and the client the CLI uses to call them.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// ReconcileAllPath is the route requesting a resync of every ClusterResourceQuota.
+const ReconcileAllPath = "/admin/reconcile-all"
+
+// ReconcileAllResponse is the body returned by ReconcileAllPath.
+type ReconcileAllResponse struct {
+	// Requested is the number of quotas a resync was requested for.
+	Requested int `json:"requested"`
+}
+
+// Handler serves the admin routes.
+type Handler struct {
+	client client.Client
+	logger *zap.Logger
+}
+
+// NewHandler creates a Handler acting on the cluster through c.
+func NewHandler(c client.Client, logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{client: c, logger: logger.Named("admin")}
+}
+
+// Register adds the admin routes to r.
+func (h *Handler) Register(r gin.IRouter) {
+	r.POST(ReconcileAllPath, h.reconcileAll)
+}
+
+func (h *Handler) reconcileAll(c *gin.Context) {
+	requested, err := ResyncAll(c.Request.Context(), h.client)
+	if err != nil {
+		h.logger.Error("Failed to request a resync of all ClusterResourceQuotas",
+			zap.Int("requested", requested), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Info("Requested a resync of all ClusterResourceQuotas", zap.Int("requested", requested))
+	c.JSON(http.StatusOK, ReconcileAllResponse{Requested: requested})
+}
+
+// ResyncAll stamps AnnotationResyncRequested on every ClusterResourceQuota
+// and returns how many were stamped. The webhook server runs on every replica
+// but only the leader reconciles, so the request goes through the API server:
+// the annotation change reaches the leader's watch, which enqueues the quota.
+func ResyncAll(ctx context.Context, c client.Client) (int, error) {
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := c.List(ctx, crqList); err != nil {
+		return 0, fmt.Errorf("failed to list ClusterResourceQuotas: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	requested := 0
+	for i := range crqList.Items {
+		crq := &crqList.Items[i]
+		patch := client.MergeFrom(crq.DeepCopy())
+		if crq.Annotations == nil {
+			crq.Annotations = map[string]string{}
+		}
+		crq.Annotations[quotav1alpha1.AnnotationResyncRequested] = now
+		if err := c.Patch(ctx, crq, patch); err != nil {
+			return requested, fmt.Errorf("failed to request a resync of ClusterResourceQuota %s: %w", crq.Name, err)
+		}
+		requested++
+	}
+	return requested, nil
+}
+
+// RequireToken returns a gin.HandlerFunc that rejects requests whose
+// Authorization header is not "Bearer <token>", with token read from
+// tokenFile. The file is read on every request so a rotated Secret takes
+// effect without a restart; an empty token matches nothing.
+func RequireToken(tokenFile string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			logger.Error("Failed to read admin token file", zap.String("file", tokenFile), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "admin token unavailable"})
+			return
+		}
+		want := strings.TrimSpace(string(data))
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if want == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			logger.Warn("Rejecting admin request without a valid token",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("ip", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "valid bearer token required"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+const testToken = "s3cret"
+
+func newServer(t *testing.T, token string) (*httptest.Server, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, quotav1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&quotav1alpha1.ClusterResourceQuota{ObjectMeta: metav1.ObjectMeta{
+			Name:        "team-b",
+			Annotations: map[string]string{"owner": "platform"},
+		}},
+	).Build()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(token+"\n"), 0o600))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	admin := router.Group("/")
+	admin.Use(RequireToken(tokenFile, zap.NewNop()))
+	NewHandler(c, zap.NewNop()).Register(admin)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, c
+}
+
+func TestReconcileAll(t *testing.T) {
+	srv, c := newServer(t, testToken)
+
+	resp, err := ReconcileAll(context.Background(), srv.Client(), srv.URL+"/", testToken)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Requested)
+
+	for _, name := range []string{"team-a", "team-b"} {
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: name}, crq))
+		assert.NotEmpty(t, crq.Annotations[quotav1alpha1.AnnotationResyncRequested], name)
+	}
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "team-b"}, crq))
+	assert.Equal(t, "platform", crq.Annotations["owner"])
+}
+
+func TestReconcileAllRejectsBadToken(t *testing.T) {
+	srv, c := newServer(t, testToken)
+
+	for _, token := range []string{"", "wrong", testToken + "x"} {
+		_, err := ReconcileAll(context.Background(), srv.Client(), srv.URL, token)
+		require.Error(t, err, token)
+		assert.Contains(t, err.Error(), "401")
+		assert.Contains(t, err.Error(), "valid bearer token required")
+	}
+
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "team-a"}, crq))
+	assert.NotContains(t, crq.Annotations, quotav1alpha1.AnnotationResyncRequested)
+}
+
+func TestRequireTokenRejectsEmptyToken(t *testing.T) {
+	srv, _ := newServer(t, "")
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+ReconcileAllPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer ")
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRequireTokenMissingFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST(ReconcileAllPath, RequireToken(filepath.Join(t.TempDir(), "missing"), zap.NewNop()),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodPost, ReconcileAllPath, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
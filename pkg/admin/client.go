@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReconcileAll asks the webhook server at server (e.g.
+// "https://localhost:9443") to resync every ClusterResourceQuota,
+// authenticating with token.
+func ReconcileAll(ctx context.Context, httpClient *http.Client, server, token string) (*ReconcileAllResponse, error) {
+	url := strings.TrimSuffix(server, "/") + ReconcileAllPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("%s returned %s: %s", url, resp.Status, failure.Error)
+		}
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+
+	out := &ReconcileAllResponse{}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return out, nil
+}
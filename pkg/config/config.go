@@ -1,16 +1,61 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/labels"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
 var setupLog = logf.Log.WithName("setup.config")
 
+// DefaultSystemNamespaces are the Kubernetes system namespaces no
+// ClusterResourceQuota selects unless --system-namespaces says otherwise.
+var DefaultSystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+const (
+	// DefaultWebhookMaxRequestBytes leaves room for an AdmissionReview carrying
+	// both object and oldObject at the apiserver's 3MiB per-object limit.
+	DefaultWebhookMaxRequestBytes int64 = 8 << 20
+	// DefaultWebhookMaxJSONDepth bounds AdmissionReview nesting well above any real object.
+	DefaultWebhookMaxJSONDepth = 100
+	// DefaultStatusMirrorMinInterval spaces out rewrites of a namespace's
+	// quota-status ConfigMap while its usage keeps changing.
+	DefaultStatusMirrorMinInterval = 30 * time.Second
+	// DefaultLogSamplingInitial and DefaultLogSamplingThereafter are zap's
+	// production sampling: 100 entries a second with the same level and
+	// message, then every 100th.
+	DefaultLogSamplingInitial    = 100
+	DefaultLogSamplingThereafter = 100
+	// DefaultWebhookDecisionCacheTTL covers a ReplicaSet creating its pods in
+	// a burst without holding decisions much longer than a status update takes.
+	DefaultWebhookDecisionCacheTTL = 2 * time.Second
+	// DefaultTenantAPIReviewCacheTTL spares a portal polling a namespace a
+	// TokenReview and a SubjectAccessReview per request while keeping revoked
+	// access answered only briefly.
+	DefaultTenantAPIReviewCacheTTL = 10 * time.Second
+)
+
+// Install profiles selectable with --profile.
+const (
+	// ProfileEnforce denies requests exceeding a quota.
+	ProfileEnforce = "enforce"
+	// ProfileObserve admits every workload request, with a warning where it
+	// would have been denied, and writes nothing but ClusterResourceQuota
+	// status and metrics, to measure what enforcement would do.
+	ProfileObserve = "observe"
+)
+
 // Config holds the controller configuration
 type Config struct {
 	MetricsEnable               bool
@@ -19,6 +64,8 @@ type Config struct {
 	EnableLeaderElection        bool
 	ExcludeNamespaceLabelKey    string
 	ExcludedNamespaces          []string
+	KubeAPIQPS                  float32
+	KubeAPIBurst                int
 	LeaderElectionLeaseDuration int
 	LeaderElectionNamespace     string
 	LeaderElectionRenewDeadline int
@@ -30,13 +77,154 @@ type Config struct {
 	WebhookCertKey              string
 	WebhookCertName             string
 	WebhookCertPath             string
+	WebhookClientCAFile         string
+	WebhookMaxRequestBytes      int64
+	WebhookMaxJSONDepth         int
 	WebhookPort                 int
+	// LogSamplingInitial and LogSamplingThereafter sample repeated log
+	// entries: of the entries with the same level and message each second,
+	// the first LogSamplingInitial are logged and then every
+	// LogSamplingThereafter-th, or none when it is zero. A zero
+	// LogSamplingInitial logs every entry.
+	LogSamplingInitial    int
+	LogSamplingThereafter int
+	// WebhookWarmCache lists the usage of quota-selected namespaces into the
+	// cache before the webhook reports ready.
+	WebhookWarmCache bool
+	// WebhookDecisionCacheTTL is how long pod admission decisions are reused
+	// for identical pods under unchanged quota usage. Zero disables the cache.
+	WebhookDecisionCacheTTL time.Duration
+	// PVCDeletionProtection denies deleting retained PVCs and PVCs held by a
+	// quota's storage audit lock.
+	PVCDeletionProtection bool
+	// NamespaceDeletionProtection denies deleting namespaces that still show
+	// usage under a quota unless the deletion is confirmed by annotation.
+	NamespaceDeletionProtection bool
+	// RequireHardLimits denies ClusterResourceQuotas that limit nothing.
+	RequireHardLimits bool
+	// WebhookWarningThreshold is the percentage of a hard limit at which
+	// admitted requests carry an admission warning. Zero disables warnings.
+	WebhookWarningThreshold int
+	// WebhookTimeoutBudgetPercent is the percentage of the API server's
+	// webhook timeout validation may take before the request is left to the
+	// failurePolicy. Zero lets validation run until the API server gives up.
+	WebhookTimeoutBudgetPercent int
+	// EnforcementExemptUntil is an RFC 3339 time until which every
+	// ClusterResourceQuota is exempt from enforcement: requests exceeding a
+	// quota are admitted with a warning. Empty exempts no quota.
+	EnforcementExemptUntil string
+	// MetricsCRQLabels names the labels of the quota.powerapp.cloud/metric-labels
+	// annotation added to the usage metrics of every ClusterResourceQuota.
+	MetricsCRQLabels []string
+	// SystemNamespaces are never selected by any ClusterResourceQuota, in
+	// the controller or the webhooks, whatever a quota's selector or a
+	// QuotaControllerConfig says. It defaults to DefaultSystemNamespaces and
+	// the controller's own namespace.
+	SystemNamespaces []string
+	// NamespaceLabelDenylist lists label selectors, such as pci=true, whose
+	// namespaces no ClusterResourceQuota selects, in the controller or the
+	// webhooks. Unlike the exclusion label, a QuotaControllerConfig cannot
+	// bring them back into scope.
+	NamespaceLabelDenylist []string
+	// ExcludedOwners lists controller owners, as group or group/Kind, whose
+	// objects no ClusterResourceQuota counts or enforces; see
+	// objects.ParseOwners.
+	ExcludedOwners []string
+	// ExcludeHeadlessServices leaves headless services, which are allocated
+	// no cluster IP, out of the services key. services.headless counts them
+	// either way.
+	ExcludeHeadlessServices bool
+	// DenialMessageTemplate, when set, is the Go template quota denial
+	// messages and their events are written with; see ParseDenialMessageTemplate.
+	DenialMessageTemplate string
+	// EnabledWebhooks names the validating webhooks to serve; see AllWebhooks.
+	EnabledWebhooks []string
+	// WebhookAutoScope empties the ValidatingWebhookConfiguration rules of
+	// webhooks that are disabled or that no ClusterResourceQuota needs.
+	WebhookAutoScope bool
+	// Profile is ProfileEnforce or ProfileObserve; see Observing.
+	Profile string
+	// WatchKinds restricts the resource kinds the controller watches. Empty watches all kinds.
+	WatchKinds []string
+	// ReconcileByHeadroom reconciles the ClusterResourceQuotas closest to
+	// their limits first when reconciles back up.
+	ReconcileByHeadroom bool
+	// ControllerConfigName names the QuotaControllerConfig whose spec overrides
+	// these flags at runtime. Empty disables the config object.
+	ControllerConfigName string
+	// WebhookConfigurationName is the ValidatingWebhookConfiguration whose
+	// failure policy the QuotaControllerConfig manages.
+	WebhookConfigurationName string
+	// FederationClusterName names this cluster to the federation hub. Empty
+	// disables federation: spec.federation is then ignored.
+	FederationClusterName string
+	// FederationHubKubeconfig reaches the hub cluster whose ClusterResourceQuotas
+	// collect every cluster's usage. Empty makes this cluster the hub.
+	FederationHubKubeconfig string
+	// Kubeconfig and KubeContext select the cluster to run against from a
+	// kubeconfig file, for running outside the cluster. Both empty use the
+	// in-cluster configuration, or $KUBECONFIG and ~/.kube/config outside it.
+	Kubeconfig  string
+	KubeContext string
+	// WebhookInsecure serves the webhooks over plain HTTP even when
+	// certificates are configured, for local runs behind kwok or envtest.
+	WebhookInsecure bool
+	// UsageAPIEnable serves the metrics.quota.powerapp.cloud aggregated API
+	// from the webhook server.
+	UsageAPIEnable bool
+	// UsageAPIRequestHeaderCAFile is the CA that signs the aggregator's
+	// front-proxy client certificate (the requestheader-client-ca-file of
+	// kube-system/extension-apiserver-authentication). Only certificates it
+	// signs, for one of UsageAPIRequestHeaderAllowedNames, may call the usage API.
+	UsageAPIRequestHeaderCAFile string
+	// UsageAPIRequestHeaderAllowedNames lists the common names accepted on
+	// front-proxy certificates. Empty accepts any certificate the CA signs.
+	UsageAPIRequestHeaderAllowedNames []string
+	// SimulationAPIEnable serves POST /simulate/namespace-move from the
+	// webhook server, to holders of the admin token or, without one, of a
+	// client certificate signed by WebhookClientCAFile.
+	SimulationAPIEnable bool
+	// TenantAPIEnable serves GET /quotas/<namespace> from the webhook server
+	// to callers whose bearer token may read the namespace's resourcequotas.
+	TenantAPIEnable bool
+	// TenantAPIReviewCacheTTL is how long the tenant API reuses the outcome of
+	// reviewing a token's access to a namespace. Zero disables the cache.
+	TenantAPIReviewCacheTTL time.Duration
+	// AdminTokenFile holds the bearer token guarding POST /admin/reconcile-all
+	// on the webhook server. Empty disables the admin routes.
+	AdminTokenFile string
+	// Namespace label mutation: copy standard labels onto new namespaces from
+	// a prefixed annotation or a lookup ConfigMap ("namespace/name").
+	NamespaceLabelsEnable          bool
+	NamespaceLabelKeys             []string
+	NamespaceLabelAnnotationPrefix string
+	NamespaceLabelConfigMap        string
+	// VPAEnable checks VerticalPodAutoscaler recommendations against quota
+	// headroom. The VPA CRDs must be installed.
+	VPAEnable bool
+	// VPACapRecommendations serves the mutating webhook that scales
+	// recommendations down to the quota headroom.
+	VPACapRecommendations bool
+	// HPAAdvisoryEnable annotates HorizontalPodAutoscalers with the most
+	// replicas of their target that fit the quota.
+	HPAAdvisoryEnable bool
+	// StatusMirrorEnable writes each quota's status to a ConfigMap in every
+	// namespace it selects, rewritten at most once per StatusMirrorMinInterval.
+	StatusMirrorEnable      bool
+	StatusMirrorMinInterval time.Duration
+	// QuotaExceededAnnotationEnable annotates every namespace of a quota that
+	// is over a hard limit with the limits it exceeds.
+	QuotaExceededAnnotationEnable bool
 	// Events configuration
 	EventsEnable          bool
 	EventsConfigPath      string
 	EventsTTL             string
 	EventsMaxEventsPerCRQ int
 	EventsCleanupInterval string
+	// EventsUsageChangePercent records a UsageChanged event when a quota's
+	// usage of a resource moves by at least this percent of its hard limit,
+	// or crosses the limit. Zero disables the events.
+	EventsUsageChangePercent int
 }
 
 // setDefaults configures the default values for configuration parameters
@@ -49,23 +237,72 @@ func setDefaults() {
 	viper.SetDefault("leader-election-renew-deadline", 40)
 	viper.SetDefault("leader-election-retry-period", 10)
 	viper.SetDefault("metrics-secure", true)
+	viper.SetDefault("metrics-crq-labels", "")
 	viper.SetDefault("webhook-cert-name", "tls.crt")
 	viper.SetDefault("webhook-cert-key", "tls.key")
 	viper.SetDefault("webhook-port", 9443)
+	viper.SetDefault("webhook-max-request-bytes", DefaultWebhookMaxRequestBytes)
+	viper.SetDefault("webhook-max-json-depth", DefaultWebhookMaxJSONDepth)
+	viper.SetDefault("webhook-warm-cache", true)
+	viper.SetDefault("webhook-decision-cache-ttl", DefaultWebhookDecisionCacheTTL)
+	viper.SetDefault("pvc-deletion-protection", false)
+	viper.SetDefault("namespace-deletion-protection", false)
+	viper.SetDefault("require-hard-limits", false)
+	viper.SetDefault("webhook-warning-threshold", 0)
+	viper.SetDefault("webhook-timeout-budget-percent", 0)
+	viper.SetDefault("enforcement-exempt-until", "")
+	viper.SetDefault("denial-message-template", "")
+	viper.SetDefault("enable-webhooks", strings.Join(AllWebhooks, ","))
+	viper.SetDefault("webhook-auto-scope", false)
 	viper.SetDefault("metrics-cert-name", "tls.crt")
 	viper.SetDefault("metrics-cert-key", "tls.key")
 	viper.SetDefault("enable-http2", false)
 	viper.SetDefault("pprof-bind-address", "0")
 	viper.SetDefault("log-level", "info")
 	viper.SetDefault("log-format", "json")
+	viper.SetDefault("log-sampling-initial", DefaultLogSamplingInitial)
+	viper.SetDefault("log-sampling-thereafter", DefaultLogSamplingThereafter)
 	viper.SetDefault("exclude-namespace-label-key", "pac-quota-controller.powerapp.cloud/exclude")
 	viper.SetDefault("excluded-namespaces", "")
+	viper.SetDefault("namespace-label-denylist", "")
+	viper.SetDefault("excluded-owners", "")
+	viper.SetDefault("exclude-headless-services", false)
+	viper.SetDefault("kube-api-qps", 20)
+	viper.SetDefault("kube-api-burst", 30)
+	viper.SetDefault("profile", ProfileEnforce)
+	viper.SetDefault("watch-kinds", "")
+	viper.SetDefault("reconcile-by-headroom", false)
+	viper.SetDefault("controller-config-name", "")
+	viper.SetDefault("webhook-configuration-name", "pac-quota-controller-validating-webhook")
+	viper.SetDefault("federation-cluster-name", "")
+	viper.SetDefault("federation-hub-kubeconfig", "")
+	viper.SetDefault("kubeconfig", "")
+	viper.SetDefault("context", "")
+	viper.SetDefault("webhook-insecure", false)
+	viper.SetDefault("usage-api-enable", false)
+	viper.SetDefault("usage-api-requestheader-ca-file", "")
+	viper.SetDefault("usage-api-requestheader-allowed-names", "front-proxy-client")
+	viper.SetDefault("simulation-api-enable", false)
+	viper.SetDefault("tenant-api-enable", false)
+	viper.SetDefault("tenant-api-review-cache-ttl", DefaultTenantAPIReviewCacheTTL)
+	viper.SetDefault("admin-token-file", "")
+	viper.SetDefault("namespace-labels-enable", false)
+	viper.SetDefault("namespace-label-keys", "team,env")
+	viper.SetDefault("namespace-label-annotation-prefix", "pac-quota-controller.powerapp.cloud/")
+	viper.SetDefault("namespace-label-configmap", "")
+	viper.SetDefault("vpa-enable", false)
+	viper.SetDefault("vpa-cap-recommendations", false)
+	viper.SetDefault("hpa-advisory-enable", false)
+	viper.SetDefault("status-mirror-enable", false)
+	viper.SetDefault("status-mirror-min-interval", DefaultStatusMirrorMinInterval)
+	viper.SetDefault("quota-exceeded-annotation-enable", false)
 	// Events defaults
 	viper.SetDefault("events-enable", true)
 	viper.SetDefault("events-config-path", "/etc/pac-quota-controller/events/event-config.yaml")
 	viper.SetDefault("events-ttl", "24h")
 	viper.SetDefault("events-max-events-per-crq", 100)
 	viper.SetDefault("events-cleanup-interval", "1h")
+	viper.SetDefault("events-usage-change-percent", 0)
 }
 
 // InitConfig initializes viper configuration with environment variables support
@@ -76,93 +313,497 @@ func InitConfig() *Config {
 	// Define defaults
 	setDefaults()
 
-	var excluded []string
-	if v := viper.GetString("excluded-namespaces"); v != "" {
-		for _, ns := range strings.Split(v, ",") {
-			ns = strings.TrimSpace(ns)
-			if ns != "" {
-				excluded = append(excluded, ns)
-			}
-		}
-	}
+	ownNamespace := os.Getenv("POD_NAMESPACE")
 	return &Config{
 		EnableHTTP2:                 viper.GetBool("enable-http2"),
 		PprofBindAddress:            viper.GetString("pprof-bind-address"),
 		MetricsEnable:               viper.GetBool("metrics-enable"),
+		MetricsCRQLabels:            splitList(viper.GetString("metrics-crq-labels")),
 		EnableLeaderElection:        viper.GetBool("leader-elect"),
 		ExcludeNamespaceLabelKey:    viper.GetString("exclude-namespace-label-key"),
-		ExcludedNamespaces:          excluded,
+		ExcludedNamespaces:          splitList(viper.GetString("excluded-namespaces")),
+		SystemNamespaces:            systemNamespaces(ownNamespace),
+		NamespaceLabelDenylist:      splitList(viper.GetString("namespace-label-denylist")),
+		ExcludedOwners:              splitList(viper.GetString("excluded-owners")),
+		ExcludeHeadlessServices:     viper.GetBool("exclude-headless-services"),
+		KubeAPIQPS:                  float32(viper.GetFloat64("kube-api-qps")),
+		KubeAPIBurst:                viper.GetInt("kube-api-burst"),
 		LeaderElectionLeaseDuration: viper.GetInt("leader-election-lease-duration"),
 		LeaderElectionNamespace:     viper.GetString("leader-election-namespace"),
 		LeaderElectionRenewDeadline: viper.GetInt("leader-election-renew-deadline"),
 		LeaderElectionRetryPeriod:   viper.GetInt("leader-election-retry-period"),
 		LogFormat:                   viper.GetString("log-format"),
 		LogLevel:                    viper.GetString("log-level"),
-		OwnNamespace:                os.Getenv("POD_NAMESPACE"),
+		LogSamplingInitial:          viper.GetInt("log-sampling-initial"),
+		LogSamplingThereafter:       viper.GetInt("log-sampling-thereafter"),
+		OwnNamespace:                ownNamespace,
 		ProbeAddr:                   viper.GetString("health-probe-bind-address"),
 		WebhookCertKey:              viper.GetString("webhook-cert-key"),
 		WebhookCertName:             viper.GetString("webhook-cert-name"),
 		WebhookCertPath:             viper.GetString("webhook-cert-path"),
+		WebhookClientCAFile:         viper.GetString("webhook-client-ca-file"),
+		WebhookMaxRequestBytes:      viper.GetInt64("webhook-max-request-bytes"),
+		WebhookMaxJSONDepth:         viper.GetInt("webhook-max-json-depth"),
 		WebhookPort:                 viper.GetInt("webhook-port"),
+		WebhookWarmCache:            viper.GetBool("webhook-warm-cache"),
+		WebhookDecisionCacheTTL:     viper.GetDuration("webhook-decision-cache-ttl"),
+		PVCDeletionProtection:       viper.GetBool("pvc-deletion-protection"),
+		NamespaceDeletionProtection: viper.GetBool("namespace-deletion-protection"),
+		RequireHardLimits:           viper.GetBool("require-hard-limits"),
+		WebhookWarningThreshold:     viper.GetInt("webhook-warning-threshold"),
+		WebhookTimeoutBudgetPercent: viper.GetInt("webhook-timeout-budget-percent"),
+		EnforcementExemptUntil:      viper.GetString("enforcement-exempt-until"),
+		DenialMessageTemplate:       viper.GetString("denial-message-template"),
+		EnabledWebhooks:             splitList(viper.GetString("enable-webhooks")),
+		WebhookAutoScope:            viper.GetBool("webhook-auto-scope"),
+		Profile:                     viper.GetString("profile"),
+		WatchKinds:                  splitList(viper.GetString("watch-kinds")),
+		ReconcileByHeadroom:         viper.GetBool("reconcile-by-headroom"),
+		ControllerConfigName:        viper.GetString("controller-config-name"),
+		WebhookConfigurationName:    viper.GetString("webhook-configuration-name"),
+		FederationClusterName:       viper.GetString("federation-cluster-name"),
+		FederationHubKubeconfig:     viper.GetString("federation-hub-kubeconfig"),
+		Kubeconfig:                  viper.GetString("kubeconfig"),
+		KubeContext:                 viper.GetString("context"),
+		WebhookInsecure:             viper.GetBool("webhook-insecure"),
+		UsageAPIEnable:              viper.GetBool("usage-api-enable"),
+		SimulationAPIEnable:         viper.GetBool("simulation-api-enable"),
+		TenantAPIEnable:             viper.GetBool("tenant-api-enable"),
+		TenantAPIReviewCacheTTL:     viper.GetDuration("tenant-api-review-cache-ttl"),
+		AdminTokenFile:              viper.GetString("admin-token-file"),
+		// Usage API front-proxy verification
+		UsageAPIRequestHeaderCAFile:       viper.GetString("usage-api-requestheader-ca-file"),
+		UsageAPIRequestHeaderAllowedNames: splitList(viper.GetString("usage-api-requestheader-allowed-names")),
+		// Namespace label mutation
+		NamespaceLabelsEnable:          viper.GetBool("namespace-labels-enable"),
+		NamespaceLabelKeys:             splitList(viper.GetString("namespace-label-keys")),
+		NamespaceLabelAnnotationPrefix: viper.GetString("namespace-label-annotation-prefix"),
+		NamespaceLabelConfigMap:        viper.GetString("namespace-label-configmap"),
+		// Vertical Pod Autoscaler integration
+		VPAEnable:             viper.GetBool("vpa-enable"),
+		VPACapRecommendations: viper.GetBool("vpa-cap-recommendations"),
+		HPAAdvisoryEnable:     viper.GetBool("hpa-advisory-enable"),
+		// Tenant-facing status mirror
+		StatusMirrorEnable:      viper.GetBool("status-mirror-enable"),
+		StatusMirrorMinInterval: viper.GetDuration("status-mirror-min-interval"),
+		// Namespace quota health annotation
+		QuotaExceededAnnotationEnable: viper.GetBool("quota-exceeded-annotation-enable"),
 		// Events configuration
-		EventsEnable:          viper.GetBool("events-enable"),
-		EventsConfigPath:      viper.GetString("events-config-path"),
-		EventsTTL:             viper.GetString("events-ttl"),
-		EventsMaxEventsPerCRQ: viper.GetInt("events-max-events-per-crq"),
-		EventsCleanupInterval: viper.GetString("events-cleanup-interval"),
+		EventsEnable:             viper.GetBool("events-enable"),
+		EventsConfigPath:         viper.GetString("events-config-path"),
+		EventsTTL:                viper.GetString("events-ttl"),
+		EventsMaxEventsPerCRQ:    viper.GetInt("events-max-events-per-crq"),
+		EventsCleanupInterval:    viper.GetString("events-cleanup-interval"),
+		EventsUsageChangePercent: viper.GetInt("events-usage-change-percent"),
 	}
 }
 
-// SetupFlags binds cobra flags to viper
+// Validate rejects flag combinations that cannot work together.
+func (c *Config) Validate() error {
+	// The admin token is a bearer credential: sent over plain HTTP, anyone on
+	// the path could read and replay it.
+	if c.AdminTokenFile != "" && c.WebhookCertPath == "" {
+		return errors.New("--admin-token-file requires --webhook-cert-path: " +
+			"the admin token must not be sent in plaintext")
+	}
+	// Without serving certificates the webhook listens on plain HTTP, where no
+	// client certificate can ever be presented.
+	if c.WebhookClientCAFile != "" && c.WebhookCertPath == "" {
+		return errors.New("--webhook-client-ca-file requires --webhook-cert-path: " +
+			"client certificates can only be verified over TLS")
+	}
+	if c.WebhookInsecure && (c.AdminTokenFile != "" || c.WebhookClientCAFile != "") {
+		return errors.New("--webhook-insecure cannot be combined with --admin-token-file or --webhook-client-ca-file: " +
+			"both need the webhook served over TLS")
+	}
+	// Portals forward their users' own bearer tokens to the tenant API.
+	if c.TenantAPIEnable && (c.WebhookCertPath == "" || c.WebhookInsecure) {
+		return errors.New("--tenant-api-enable requires --webhook-cert-path without --webhook-insecure: " +
+			"tenants' bearer tokens must not be sent in plaintext")
+	}
+	// The usage API trusts the user the aggregator names in its headers, which
+	// is only safe once the aggregator's front-proxy certificate is verified.
+	if c.UsageAPIEnable && c.UsageAPIRequestHeaderCAFile == "" {
+		return errors.New("--usage-api-enable requires --usage-api-requestheader-ca-file: " +
+			"only the aggregator may call the usage API")
+	}
+	if c.UsageAPIRequestHeaderCAFile != "" && (c.WebhookCertPath == "" || c.WebhookInsecure) {
+		return errors.New("--usage-api-requestheader-ca-file requires --webhook-cert-path without --webhook-insecure: " +
+			"front-proxy certificates can only be verified over TLS")
+	}
+	if c.SimulationAPIEnable && c.AdminTokenFile == "" && c.WebhookClientCAFile == "" {
+		return errors.New("--simulation-api-enable requires --admin-token-file or --webhook-client-ca-file: " +
+			"simulations read every namespace's usage and must not be served unauthenticated")
+	}
+	if c.FederationHubKubeconfig != "" && c.FederationClusterName == "" {
+		return errors.New("--federation-hub-kubeconfig requires --federation-cluster-name: " +
+			"the hub keys reported usage by cluster name")
+	}
+	if err := validateWebhookNames(c.EnabledWebhooks); err != nil {
+		return err
+	}
+	if c.WebhookAutoScope && c.WebhookConfigurationName == "" {
+		return errors.New("--webhook-auto-scope requires --webhook-configuration-name: " +
+			"it is the configuration whose rules are scoped")
+	}
+	if c.LogSamplingInitial < 0 || c.LogSamplingThereafter < 0 {
+		return fmt.Errorf("--log-sampling-initial and --log-sampling-thereafter must not be negative, got %d and %d",
+			c.LogSamplingInitial, c.LogSamplingThereafter)
+	}
+	if c.WebhookWarningThreshold < 0 || c.WebhookWarningThreshold > 100 {
+		return fmt.Errorf("--webhook-warning-threshold must be a percentage between 0 and 100, got %d",
+			c.WebhookWarningThreshold)
+	}
+	if c.WebhookTimeoutBudgetPercent < 0 || c.WebhookTimeoutBudgetPercent > 100 {
+		return fmt.Errorf("--webhook-timeout-budget-percent must be a percentage between 0 and 100, got %d",
+			c.WebhookTimeoutBudgetPercent)
+	}
+	if c.EnforcementExemptUntil != "" {
+		if _, err := time.Parse(time.RFC3339, c.EnforcementExemptUntil); err != nil {
+			return fmt.Errorf("--enforcement-exempt-until must be an RFC 3339 time such as 2026-01-02T06:00:00Z: %w", err)
+		}
+	}
+	if _, err := ParseNamespaceLabelDenylist(c.NamespaceLabelDenylist); err != nil {
+		return fmt.Errorf("invalid --namespace-label-denylist: %w", err)
+	}
+	if _, err := objects.ParseOwners(c.ExcludedOwners); err != nil {
+		return fmt.Errorf("invalid --excluded-owners: %w", err)
+	}
+	if err := metrics.ValidateCRQLabelNames(c.MetricsCRQLabels); err != nil {
+		return fmt.Errorf("invalid --metrics-crq-labels: %w", err)
+	}
+	if c.DenialMessageTemplate != "" {
+		if _, err := ParseDenialMessageTemplate(c.DenialMessageTemplate); err != nil {
+			return fmt.Errorf("invalid --denial-message-template: %w", err)
+		}
+	}
+	if c.EventsUsageChangePercent < 0 || c.EventsUsageChangePercent > 100 {
+		return fmt.Errorf("--events-usage-change-percent must be a percentage between 0 and 100, got %d",
+			c.EventsUsageChangePercent)
+	}
+	switch c.Profile {
+	case "", ProfileEnforce:
+	case ProfileObserve:
+		if flags := c.observeConflicts(); len(flags) > 0 {
+			return fmt.Errorf("--profile=%s cannot be combined with %s: they write to objects "+
+				"other than ClusterResourceQuota status", ProfileObserve, strings.Join(flags, ", "))
+		}
+	default:
+		return fmt.Errorf("--profile must be %s or %s, got %q", ProfileEnforce, ProfileObserve, c.Profile)
+	}
+	if c.VPACapRecommendations && !c.VPAEnable {
+		return errors.New("--vpa-cap-recommendations requires --vpa-enable: " +
+			"recommendations are only capped where they are also checked")
+	}
+	return nil
+}
+
+// Observing reports whether the controller runs the observe profile.
+func (c *Config) Observing() bool {
+	return c.Profile == ProfileObserve
+}
+
+// observeConflicts returns the enabled flags that write to objects the
+// observe profile leaves alone.
+func (c *Config) observeConflicts() []string {
+	var flags []string
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"--status-mirror-enable", c.StatusMirrorEnable},
+		{"--quota-exceeded-annotation-enable", c.QuotaExceededAnnotationEnable},
+		{"--hpa-advisory-enable", c.HPAAdvisoryEnable},
+		{"--namespace-labels-enable", c.NamespaceLabelsEnable},
+		{"--vpa-cap-recommendations", c.VPACapRecommendations},
+		{"--webhook-auto-scope", c.WebhookAutoScope},
+	} {
+		if f.enabled {
+			flags = append(flags, f.name)
+		}
+	}
+	return flags
+}
+
+// NamespaceLabelDenylistSelectors returns NamespaceLabelDenylist parsed, nil
+// when it failed Validate.
+func (c *Config) NamespaceLabelDenylistSelectors() []labels.Selector {
+	denylist, err := ParseNamespaceLabelDenylist(c.NamespaceLabelDenylist)
+	if err != nil {
+		return nil
+	}
+	return denylist
+}
+
+// ParseNamespaceLabelDenylist parses entries such as pci=true or legal-hold
+// into label selectors. Each entry is one label selector requirement; beware
+// that tier!=shared also matches namespaces without a tier label.
+func ParseNamespaceLabelDenylist(entries []string) ([]labels.Selector, error) {
+	denylist := make([]labels.Selector, 0, len(entries))
+	for _, entry := range entries {
+		selector, err := labels.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", entry, err)
+		}
+		if selector.Empty() {
+			return nil, fmt.Errorf("label selector %q matches every namespace", entry)
+		}
+		denylist = append(denylist, selector)
+	}
+	return denylist, nil
+}
+
+// ExcludedOwnerKinds returns ExcludedOwners parsed, nil when it failed
+// Validate.
+func (c *Config) ExcludedOwnerKinds() objects.Owners {
+	owners, err := objects.ParseOwners(c.ExcludedOwners)
+	if err != nil {
+		return nil
+	}
+	return owners
+}
+
+// EnforcementExemptUntilTime returns EnforcementExemptUntil as a time, zero
+// when it is empty or, having failed Validate, not an RFC 3339 time.
+func (c *Config) EnforcementExemptUntilTime() time.Time {
+	until, err := time.Parse(time.RFC3339, c.EnforcementExemptUntil)
+	if err != nil {
+		return time.Time{}
+	}
+	return until
+}
+
+// systemNamespaces returns --system-namespaces, or DefaultSystemNamespaces and
+// ownNamespace when it is not set. It has no viper default, which would make
+// an explicitly empty value indistinguishable from an unset one.
+func systemNamespaces(ownNamespace string) []string {
+	if viper.IsSet("system-namespaces") {
+		return splitList(viper.GetString("system-namespaces"))
+	}
+	namespaces := slices.Clone(DefaultSystemNamespaces)
+	if ownNamespace != "" && !slices.Contains(namespaces, ownNamespace) {
+		namespaces = append(namespaces, ownNamespace)
+	}
+	return namespaces
+}
+
+// splitList parses a comma-separated flag value, trimming spaces and skipping empties.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// SetupFlags binds cobra flags to viper. The flags are persistent so
+// subcommands such as verify honor the same settings as the manager.
 func SetupFlags(cmd *cobra.Command) {
-	cmd.Flags().Bool("metrics-enable", true, "Enable the metrics server.")
-	cmd.Flags().String("health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-	cmd.Flags().Bool("leader-elect", false,
+	cmd.PersistentFlags().Bool("metrics-enable", true,
+		"Enable the metrics server. When disabled, build info and reconcile counters are served on /metrics-lite "+
+			"of the webhook port.")
+	cmd.PersistentFlags().String("health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	cmd.PersistentFlags().Bool("leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	cmd.Flags().String("leader-election-namespace", "",
+	cmd.PersistentFlags().String("leader-election-namespace", "",
 		"Namespace to use for leader election. If empty, uses the controller's namespace.")
-	cmd.Flags().Int("leader-election-lease-duration", 15,
+	cmd.PersistentFlags().Int("leader-election-lease-duration", 15,
 		"Duration in seconds that non-leader candidates will wait to force acquire leadership.")
-	cmd.Flags().Int("leader-election-renew-deadline", 10,
+	cmd.PersistentFlags().Int("leader-election-renew-deadline", 10,
 		"Duration in seconds the leader will retry refreshing leadership before giving up.")
-	cmd.Flags().Int("leader-election-retry-period", 2,
+	cmd.PersistentFlags().Int("leader-election-retry-period", 2,
 		"Duration in seconds the leader election clients should wait between tries of actions.")
-	cmd.Flags().Int("metrics-port", 8443, "The port the metrics server listens on.")
-	cmd.Flags().Bool("metrics-secure", true,
+	cmd.PersistentFlags().Int("metrics-port", 8443, "The port the metrics server listens on.")
+	cmd.PersistentFlags().Bool("metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
-	cmd.Flags().String("webhook-cert-path", "", "The directory that contains the webhook certificate.")
-	cmd.Flags().String("webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
-	cmd.Flags().String("webhook-cert-key", "tls.key", "The name of the webhook key file.")
-	cmd.Flags().String("metrics-cert-path", "",
+	cmd.PersistentFlags().String("metrics-crq-labels", "",
+		"Comma-separated label names, such as costcenter, added to the ClusterResourceQuota usage metrics from the "+
+			"quota.powerapp.cloud/metric-labels annotation of each quota. At most 5.")
+	cmd.PersistentFlags().String("webhook-cert-path", "", "The directory that contains the webhook certificate.")
+	cmd.PersistentFlags().String("webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
+	cmd.PersistentFlags().String("webhook-cert-key", "tls.key", "The name of the webhook key file.")
+	cmd.PersistentFlags().String("webhook-client-ca-file", "",
+		"CA bundle used to verify kube-apiserver client certificates. "+
+			"When set, admission endpoints reject requests without a verified client certificate.")
+	cmd.PersistentFlags().String("metrics-cert-path", "",
 		"The directory that contains the metrics server certificate (tls.crt/tls.key).")
-	cmd.Flags().Bool("enable-http2", false,
+	cmd.PersistentFlags().Bool("enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
-	cmd.Flags().String("pprof-bind-address", "0",
+	cmd.PersistentFlags().String("pprof-bind-address", "0",
 		"The address the pprof endpoint binds to (e.g. ':6060'). Use '0' to disable.")
-	cmd.Flags().String("log-level", "info", "Log level (debug, info, warn, error)")
-	cmd.Flags().String("log-format", "json", "Log format (json or console)")
-	cmd.Flags().Int("webhook-port", 9443, "The port the webhook server listens on.")
-	cmd.Flags().String(
+	cmd.PersistentFlags().Float32("kube-api-qps", 20,
+		"Client-side QPS limit for Kubernetes API requests, shared by the manager and every clientset. "+
+			"Zero or negative disables client-side rate limiting.")
+	cmd.PersistentFlags().Int("kube-api-burst", 30,
+		"Client-side burst limit for Kubernetes API requests, shared by the manager and every clientset.")
+	cmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error)")
+	cmd.PersistentFlags().String("log-format", "json", "Log format (json or console)")
+	cmd.PersistentFlags().Int("log-sampling-initial", DefaultLogSamplingInitial,
+		"Log the first this many entries with the same level and message each second before sampling them. "+
+			"Zero disables sampling.")
+	cmd.PersistentFlags().Int("log-sampling-thereafter", DefaultLogSamplingThereafter,
+		"Once --log-sampling-initial is reached, log every this-many-th entry with the same level and message "+
+			"for the rest of the second. Zero drops them.")
+	cmd.PersistentFlags().Int("webhook-port", 9443, "The port the webhook server listens on.")
+	cmd.PersistentFlags().Int64("webhook-max-request-bytes", DefaultWebhookMaxRequestBytes,
+		"Maximum size in bytes of an AdmissionReview body; larger requests are rejected with 413.")
+	cmd.PersistentFlags().Int("webhook-max-json-depth", DefaultWebhookMaxJSONDepth,
+		"Maximum JSON nesting depth of an AdmissionReview body; deeper requests are rejected with 400.")
+	cmd.PersistentFlags().Bool("webhook-warm-cache", true,
+		"List the pods, PVCs and services of namespaces selected by existing quotas before the webhook reports ready.")
+	cmd.PersistentFlags().Duration("webhook-decision-cache-ttl", DefaultWebhookDecisionCacheTTL,
+		"How long a pod admission decision is reused for identical pods while quota usage is unchanged. "+
+			"Zero disables the cache.")
+	cmd.PersistentFlags().Bool("pvc-deletion-protection", false,
+		"Validate PersistentVolumeClaim deletions: deny them for claims annotated quota.powerapp.cloud/retain=true "+
+			"and in namespaces whose ClusterResourceQuota sets spec.storageAuditLock.")
+	cmd.PersistentFlags().Bool("namespace-deletion-protection", false,
+		"Validate Namespace deletions: deny them while the namespace shows usage under a ClusterResourceQuota, "+
+			"unless it is annotated quota.powerapp.cloud/confirm-delete=true.")
+	cmd.PersistentFlags().Bool("require-hard-limits", false,
+		"Deny ClusterResourceQuotas that limit nothing: no spec.hard key left on and no spec.topologyHard limit.")
+	cmd.PersistentFlags().Int("webhook-warning-threshold", 0,
+		"Warn, in the admission response, when an admitted request takes a ClusterResourceQuota to this percentage "+
+			"of a hard limit or more. Zero disables the warnings.")
+	cmd.PersistentFlags().String("enforcement-exempt-until", "",
+		"RFC 3339 time until which no ClusterResourceQuota is enforced: requests exceeding a quota are admitted "+
+			"with a warning, e.g. during a node pool rotation. Enforcement resumes by itself once it passes.")
+	cmd.PersistentFlags().Int("webhook-timeout-budget-percent", 0,
+		"Answer a request with an error, so the API server applies the failurePolicy, when validating it takes "+
+			"longer than this percentage of the timeout the API server gives the webhook, instead of waiting for "+
+			"the API server to time out. Requests are never admitted unvalidated. Zero disables the budget.")
+	cmd.PersistentFlags().String("denial-message-template", "",
+		"Go template for quota denial messages and their events, e.g. to link a runbook. It can use "+
+			strings.Join(DenialMessageVariables, ", ")+" as {{.crq}}, {{.message}} and so on. "+
+			"Empty keeps the built-in messages.")
+	cmd.PersistentFlags().String("enable-webhooks", strings.Join(AllWebhooks, ","),
+		"Comma-separated validating webhooks to serve: "+strings.Join(AllWebhooks, ",")+".")
+	cmd.PersistentFlags().Bool("webhook-auto-scope", false,
+		"Empty the ValidatingWebhookConfiguration rules of webhooks that are disabled "+
+			"or that no ClusterResourceQuota needs, and restore them when one does.")
+	cmd.PersistentFlags().String(
 		"exclude-namespace-label-key",
 		"pac-quota-controller.powerapp.cloud/exclude",
 		"The label key used to mark namespaces for exclusion. Any namespace with this label will be ignored.",
 	)
-	cmd.Flags().String(
+	cmd.PersistentFlags().String(
 		"excluded-namespaces",
 		"",
 		"Comma-separated list of namespaces to exclude from reconciliation and webhook validation.",
 	)
+	cmd.PersistentFlags().String(
+		"system-namespaces",
+		strings.Join(DefaultSystemNamespaces, ","),
+		"Comma-separated namespaces no ClusterResourceQuota can select, in the controller or the webhooks. "+
+			"Unset, it also includes the controller's own namespace; set it empty to allow quotas to select every namespace.",
+	)
+	cmd.PersistentFlags().String(
+		"namespace-label-denylist",
+		"",
+		"Comma-separated label selectors, such as pci=true or legal-hold, whose namespaces no ClusterResourceQuota "+
+			"selects, in the controller or the webhooks, whatever the quota's selector says.",
+	)
+	cmd.PersistentFlags().String(
+		"excluded-owners",
+		"",
+		"Comma-separated controller owners, as an API group such as monitoring.coreos.com or a group/Kind such as "+
+			"monitoring.coreos.com/Prometheus, whose objects no ClusterResourceQuota counts or enforces, "+
+			"e.g. platform add-ons deployed into tenant namespaces.",
+	)
+	cmd.PersistentFlags().Bool("exclude-headless-services", false,
+		"Leave headless services (spec.clusterIP: None) out of the services quota key. "+
+			"The services.headless key counts them either way.")
+	cmd.PersistentFlags().String(
+		"watch-kinds",
+		"",
+		"Comma-separated list of resource kinds to watch (e.g. pods,persistentvolumeclaims,services). "+
+			"Namespaces are always watched. Empty watches every kind the controller can quota "+
+			"except resourceclaims and endpointslices, which must be listed; "+
+			"'auto' starts and stops watches as ClusterResourceQuotas add or drop hard keys.",
+	)
+	cmd.PersistentFlags().String("profile", ProfileEnforce,
+		"Install profile: enforce denies requests exceeding a quota; observe admits them with a warning, counts "+
+			"them in pac_quota_controller_webhook_observed_denials_total and writes nothing but ClusterResourceQuota "+
+			"status, to measure what enforcement would do before turning it on.")
+	cmd.PersistentFlags().Bool("reconcile-by-headroom", false,
+		"Reconcile the ClusterResourceQuotas with the least headroom left, as of their last status, first "+
+			"when reconciles back up, so the quotas closest to their limits stay accurate.")
+	cmd.PersistentFlags().String("controller-config-name", "",
+		"Name of the cluster-scoped QuotaControllerConfig whose spec overrides these flags without a restart. "+
+			"Empty disables it.")
+	cmd.PersistentFlags().String("webhook-configuration-name", "pac-quota-controller-validating-webhook",
+		"ValidatingWebhookConfiguration whose failure policy the QuotaControllerConfig manages.")
+	cmd.PersistentFlags().String("federation-cluster-name", "",
+		"Name this cluster reports its usage under for ClusterResourceQuotas with spec.federation. "+
+			"Empty disables federation.")
+	cmd.PersistentFlags().String("federation-hub-kubeconfig", "",
+		"Kubeconfig of the hub cluster that collects federated usage. Empty makes this cluster the hub.")
+	cmd.PersistentFlags().String("kubeconfig", "",
+		"Kubeconfig to run against when outside the cluster. Empty uses the in-cluster configuration, "+
+			"or $KUBECONFIG and ~/.kube/config outside it.")
+	cmd.PersistentFlags().String("context", "",
+		"Kubeconfig context to run against. Empty uses the current context.")
+	cmd.PersistentFlags().Bool("webhook-insecure", false,
+		"Serve the webhooks over plain HTTP even when certificates are configured, "+
+			"for running locally against kwok or envtest. Never use it in a cluster.")
+	cmd.PersistentFlags().Bool("usage-api-enable", false,
+		"Serve per-namespace quota usage as the metrics.quota.powerapp.cloud aggregated API on the webhook port. "+
+			"Requires --usage-api-requestheader-ca-file.")
+	cmd.PersistentFlags().String("usage-api-requestheader-ca-file", "",
+		"CA bundle that signs the aggregator's front-proxy client certificate "+
+			"(requestheader-client-ca-file in kube-system/extension-apiserver-authentication).")
+	cmd.PersistentFlags().String("usage-api-requestheader-allowed-names", "front-proxy-client",
+		"Comma-separated common names accepted on front-proxy client certificates. Empty accepts any the CA signs.")
+	cmd.PersistentFlags().Bool("simulation-api-enable", false,
+		"Serve POST /simulate/namespace-move on the webhook port to preview the quota impact of relabeling a namespace. "+
+			"Requires --admin-token-file or --webhook-client-ca-file.")
+	cmd.PersistentFlags().Bool("tenant-api-enable", false,
+		"Serve GET /quotas/<namespace> on the webhook port to callers whose bearer token "+
+			"may get the namespace's resourcequotas. Requires --webhook-cert-path.")
+	cmd.PersistentFlags().Duration("tenant-api-review-cache-ttl", DefaultTenantAPIReviewCacheTTL,
+		"How long the tenant API reuses the review of a bearer token's access to a namespace. "+
+			"Zero reviews every request.")
+	cmd.PersistentFlags().String("admin-token-file", "",
+		"File holding the bearer token for POST /admin/reconcile-all on the webhook port. Empty disables the admin routes.")
+	// Namespace label mutation flags
+	cmd.PersistentFlags().Bool("namespace-labels-enable", false,
+		"Serve the namespace mutating webhook that copies standard labels onto new namespaces.")
+	cmd.PersistentFlags().String("namespace-label-keys", "team,env",
+		"Comma-separated label keys the namespace mutating webhook fills in.")
+	cmd.PersistentFlags().String("namespace-label-annotation-prefix", "pac-quota-controller.powerapp.cloud/",
+		"Annotation prefix the namespace mutating webhook reads label values from (<prefix><key>).")
+	cmd.PersistentFlags().String("namespace-label-configmap", "",
+		"ConfigMap (namespace/name) mapping namespace names to label values as key=value lists.")
+	// Vertical Pod Autoscaler flags
+	cmd.PersistentFlags().Bool("vpa-enable", false,
+		"Warn on VerticalPodAutoscalers whose recommendation exceeds the quota left. Requires the VPA CRDs.")
+	cmd.PersistentFlags().Bool("vpa-cap-recommendations", false,
+		"Serve the mutating webhook that scales VerticalPodAutoscaler recommendations down to the quota left.")
+	cmd.PersistentFlags().Bool("hpa-advisory-enable", false,
+		"Annotate HorizontalPodAutoscalers with the most replicas of their target that fit the quota left.")
+	cmd.PersistentFlags().Bool("status-mirror-enable", false,
+		"Write each quota's hard, used and remaining totals to a quota-status ConfigMap in every namespace it selects.")
+	cmd.PersistentFlags().Duration("status-mirror-min-interval", DefaultStatusMirrorMinInterval,
+		"Minimum time between two writes of a namespace's quota-status ConfigMap.")
+	cmd.PersistentFlags().Bool("quota-exceeded-annotation-enable", false,
+		"Annotate the namespaces of a quota that is over a hard limit with the limits it exceeds.")
 	// Events configuration flags
-	cmd.Flags().Bool("events-enable", true, "Enable Kubernetes Events recording.")
-	cmd.Flags().String("events-config-path", "/etc/pac-quota-controller/events/event-config.yaml",
+	cmd.PersistentFlags().Bool("events-enable", true, "Enable Kubernetes Events recording.")
+	cmd.PersistentFlags().String("events-config-path", "/etc/pac-quota-controller/events/event-config.yaml",
 		"Path to the events configuration file.")
-	cmd.Flags().String("events-ttl", "24h", "Time-to-live for events before cleanup.")
-	cmd.Flags().Int("events-max-events-per-crq", 100, "Maximum number of events to retain per ClusterResourceQuota.")
-	cmd.Flags().String("events-cleanup-interval", "1h", "Interval for running event cleanup.")
+	cmd.PersistentFlags().String("events-ttl", "24h", "Time-to-live for events before cleanup.")
+	cmd.PersistentFlags().Int("events-max-events-per-crq", 100, "Maximum number of events to retain per ClusterResourceQuota.")
+	cmd.PersistentFlags().String("events-cleanup-interval", "1h", "Interval for running event cleanup.")
+	cmd.PersistentFlags().Int("events-usage-change-percent", 0,
+		"Record a UsageChanged event when a quota's usage of a resource moves by at least this percent of its hard "+
+			"limit, or crosses the limit. 0 disables the events.")
 
 	// Bind flags to viper
-	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+	if err := viper.BindPFlags(cmd.PersistentFlags()); err != nil {
 		setupLog.Error(err, "unable to bind flags to viper")
 		os.Exit(1)
 	}
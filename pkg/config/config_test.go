@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -49,6 +50,8 @@ var _ = Describe("InitConfig", func() {
 		Expect(cfg.PprofBindAddress).To(Equal("0"))
 		Expect(cfg.LogLevel).To(Equal("info"))
 		Expect(cfg.LogFormat).To(Equal("json"))
+		Expect(cfg.LogSamplingInitial).To(Equal(100))
+		Expect(cfg.LogSamplingThereafter).To(Equal(100))
 	})
 
 	It("should read values from environment variables", func() {
@@ -107,6 +110,121 @@ var _ = Describe("InitConfig extended fields", func() {
 		Expect(cfg.ExcludedNamespaces).To(BeEmpty())
 	})
 
+	It("excludes the system namespaces and its own namespace by default", func() {
+		Expect(os.Setenv("POD_NAMESPACE", "pac-quota-controller-system")).To(Succeed())
+		DeferCleanup(func() { _ = os.Unsetenv("POD_NAMESPACE") })
+
+		cfg := InitConfig()
+		Expect(cfg.SystemNamespaces).To(Equal([]string{
+			"kube-system", "kube-public", "kube-node-lease", "pac-quota-controller-system",
+		}))
+	})
+
+	It("replaces the system namespaces with --system-namespaces, even when empty", func() {
+		cmd := &cobra.Command{Use: "test", Run: func(*cobra.Command, []string) {}}
+		SetupFlags(cmd)
+		Expect(cmd.PersistentFlags().Parse([]string{"--system-namespaces="})).To(Succeed())
+
+		cfg := InitConfig()
+		Expect(cfg.SystemNamespaces).To(BeEmpty())
+	})
+
+	It("parses watch-kinds into a list", func() {
+		Expect(os.Setenv("WATCH_KINDS", "pods, services,,configmaps")).To(Succeed())
+		DeferCleanup(func() { _ = os.Unsetenv("WATCH_KINDS") })
+
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.WatchKinds).To(Equal([]string{"pods", "services", "configmaps"}))
+	})
+
+	It("leaves watch-kinds empty when unset", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.WatchKinds).To(BeEmpty())
+	})
+
+	It("reconciles by headroom only when asked to", func() {
+		viper.Reset()
+		Expect(InitConfig().ReconcileByHeadroom).To(BeFalse())
+
+		Expect(os.Setenv("RECONCILE_BY_HEADROOM", "true")).To(Succeed())
+		DeferCleanup(func() { _ = os.Unsetenv("RECONCILE_BY_HEADROOM") })
+		viper.Reset()
+		Expect(InitConfig().ReconcileByHeadroom).To(BeTrue())
+	})
+
+	It("enforces quotas unless the observe profile is selected", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.Profile).To(Equal(ProfileEnforce))
+		Expect(cfg.Observing()).To(BeFalse())
+
+		Expect(os.Setenv("PROFILE", ProfileObserve)).To(Succeed())
+		DeferCleanup(func() { _ = os.Unsetenv("PROFILE") })
+		viper.Reset()
+		Expect(InitConfig().Observing()).To(BeTrue())
+	})
+
+	It("disables the QuotaControllerConfig by default", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.ControllerConfigName).To(BeEmpty())
+		Expect(cfg.WebhookConfigurationName).To(Equal("pac-quota-controller-validating-webhook"))
+	})
+
+	It("disables federation by default", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.FederationClusterName).To(BeEmpty())
+		Expect(cfg.FederationHubKubeconfig).To(BeEmpty())
+	})
+
+	It("enables every webhook and leaves their scope alone by default", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.EnabledWebhooks).To(Equal(AllWebhooks))
+		Expect(cfg.WebhookAutoScope).To(BeFalse())
+		for _, name := range AllWebhooks {
+			Expect(cfg.WebhookEnabled(name)).To(BeTrue(), name)
+		}
+	})
+
+	It("keeps the usage API disabled by default", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.UsageAPIEnable).To(BeFalse())
+		Expect(cfg.UsageAPIRequestHeaderAllowedNames).To(Equal([]string{"front-proxy-client"}))
+	})
+
+	It("keeps namespace label mutation disabled by default with team and env keys", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.NamespaceLabelsEnable).To(BeFalse())
+		Expect(cfg.NamespaceLabelKeys).To(Equal([]string{"team", "env"}))
+		Expect(cfg.NamespaceLabelAnnotationPrefix).To(Equal("pac-quota-controller.powerapp.cloud/"))
+		Expect(cfg.NamespaceLabelConfigMap).To(BeEmpty())
+	})
+
+	It("defaults and reads the Kubernetes API client limits", func() {
+		viper.Reset()
+		cfg := InitConfig()
+		Expect(cfg.KubeAPIQPS).To(Equal(float32(20)))
+		Expect(cfg.KubeAPIBurst).To(Equal(30))
+
+		Expect(os.Setenv("KUBE_API_QPS", "75.5")).To(Succeed())
+		Expect(os.Setenv("KUBE_API_BURST", "150")).To(Succeed())
+		DeferCleanup(func() {
+			_ = os.Unsetenv("KUBE_API_QPS")
+			_ = os.Unsetenv("KUBE_API_BURST")
+		})
+
+		viper.Reset()
+		cfg = InitConfig()
+		Expect(cfg.KubeAPIQPS).To(Equal(float32(75.5)))
+		Expect(cfg.KubeAPIBurst).To(Equal(150))
+	})
+
 	It("defaults the leader-election timings", func() {
 		viper.Reset()
 		cfg := InitConfig()
@@ -163,7 +281,7 @@ var _ = Describe("SetupFlags", func() {
 	})
 
 	It("should register all flags with correct defaults", func() {
-		flags := cmd.Flags()
+		flags := cmd.PersistentFlags()
 		Expect(flags.HasAvailableFlags()).To(BeTrue())
 
 		probeAddr, err := flags.GetString("health-probe-bind-address")
@@ -187,3 +305,176 @@ var _ = Describe("SetupFlags", func() {
 		Expect(pprofBindAddress).To(Equal("0"))
 	})
 })
+
+var _ = Describe("Validate", func() {
+	It("accepts the defaults", func() {
+		Expect((&Config{}).Validate()).To(Succeed())
+	})
+
+	It("rejects a client CA without serving certificates", func() {
+		cfg := &Config{WebhookClientCAFile: "/etc/webhook/ca.crt"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("requires --webhook-cert-path")))
+	})
+
+	It("accepts a client CA with serving certificates", func() {
+		cfg := &Config{WebhookClientCAFile: "/etc/webhook/ca.crt", WebhookCertPath: "/etc/webhook/certs"}
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects --webhook-insecure with settings that need TLS", func() {
+		cfg := &Config{WebhookInsecure: true, WebhookCertPath: "/etc/webhook/certs", AdminTokenFile: "/etc/admin/token"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--webhook-insecure")))
+		cfg = &Config{WebhookInsecure: true, WebhookCertPath: "/etc/webhook/certs", WebhookClientCAFile: "/etc/ca.crt"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--webhook-insecure")))
+		Expect((&Config{WebhookInsecure: true}).Validate()).To(Succeed())
+	})
+
+	It("rejects an admin token without serving certificates", func() {
+		cfg := &Config{AdminTokenFile: "/etc/admin/token"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--admin-token-file requires --webhook-cert-path")))
+		cfg.WebhookCertPath = "/etc/webhook/certs"
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects the tenant API without TLS", func() {
+		cfg := &Config{TenantAPIEnable: true}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--tenant-api-enable requires --webhook-cert-path")))
+		cfg.WebhookCertPath = "/etc/webhook/certs"
+		Expect(cfg.Validate()).To(Succeed())
+		cfg.WebhookInsecure = true
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("without --webhook-insecure")))
+	})
+
+	It("rejects the usage API without the front-proxy CA", func() {
+		cfg := &Config{UsageAPIEnable: true}
+		Expect(cfg.Validate()).To(MatchError(
+			ContainSubstring("--usage-api-enable requires --usage-api-requestheader-ca-file")))
+		cfg.UsageAPIRequestHeaderCAFile = "/etc/front-proxy/ca.crt"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("requires --webhook-cert-path")))
+		cfg.WebhookCertPath = "/etc/webhook/certs"
+		Expect(cfg.Validate()).To(Succeed())
+		cfg.WebhookInsecure = true
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("without --webhook-insecure")))
+	})
+
+	It("rejects the simulation API without a way to authenticate callers", func() {
+		cfg := &Config{SimulationAPIEnable: true, WebhookCertPath: "/etc/webhook/certs"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--simulation-api-enable requires")))
+		cfg.AdminTokenFile = "/etc/admin/token"
+		Expect(cfg.Validate()).To(Succeed())
+		cfg.AdminTokenFile, cfg.WebhookClientCAFile = "", "/etc/webhook/client-ca.crt"
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects a federation hub without a cluster name", func() {
+		cfg := &Config{FederationHubKubeconfig: "/etc/federation/hub.kubeconfig"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("requires --federation-cluster-name")))
+	})
+	It("accepts the install profiles only", func() {
+		Expect((&Config{Profile: ProfileObserve}).Validate()).To(Succeed())
+		Expect((&Config{Profile: ProfileEnforce}).Validate()).To(Succeed())
+		Expect((&Config{Profile: "audit"}).Validate()).To(MatchError(ContainSubstring("--profile must be")))
+	})
+	It("rejects writing to other objects in the observe profile", func() {
+		cfg := &Config{Profile: ProfileObserve, StatusMirrorEnable: true, HPAAdvisoryEnable: true}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--status-mirror-enable, --hpa-advisory-enable")))
+		cfg.Profile = ProfileEnforce
+		Expect(cfg.Validate()).To(Succeed())
+	})
+	It("rejects capping VPA recommendations without checking them", func() {
+		cfg := &Config{VPACapRecommendations: true}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("requires --vpa-enable")))
+		cfg.VPAEnable = true
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects negative log sampling", func() {
+		cfg := &Config{LogSamplingInitial: 100, LogSamplingThereafter: -1}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--log-sampling-thereafter")))
+		cfg.LogSamplingThereafter = 0
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects warning thresholds that are not percentages", func() {
+		cfg := &Config{WebhookWarningThreshold: 120}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("between 0 and 100")))
+		cfg.WebhookWarningThreshold = 80
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects enforcement exemptions that are not RFC 3339 times", func() {
+		cfg := &Config{EnforcementExemptUntil: "tomorrow"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--enforcement-exempt-until")))
+		Expect(cfg.EnforcementExemptUntilTime().IsZero()).To(BeTrue())
+		cfg.EnforcementExemptUntil = "2026-01-02T06:00:00Z"
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.EnforcementExemptUntilTime()).To(Equal(time.Date(2026, 1, 2, 6, 0, 0, 0, time.UTC)))
+	})
+
+	It("rejects metric labels that are not Prometheus label names", func() {
+		cfg := &Config{MetricsCRQLabels: []string{"cost-center"}}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--metrics-crq-labels")))
+		cfg.MetricsCRQLabels = []string{"namespace"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("already a label")))
+		cfg.MetricsCRQLabels = []string{"a", "b", "c", "d", "e", "f"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("at most 5")))
+		cfg.MetricsCRQLabels = []string{"costcenter", "team"}
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects namespace label denylist entries that are not label selectors", func() {
+		cfg := &Config{NamespaceLabelDenylist: []string{"=true"}}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--namespace-label-denylist")))
+		Expect(cfg.NamespaceLabelDenylistSelectors()).To(BeNil())
+		cfg.NamespaceLabelDenylist = []string{"pci=true", "legal-hold"}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.NamespaceLabelDenylistSelectors()).To(HaveLen(2))
+		cfg.NamespaceLabelDenylist = []string{" "}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("matches every namespace")))
+	})
+
+	It("rejects excluded owners without an API group", func() {
+		cfg := &Config{ExcludedOwners: []string{"/ReplicaSet"}}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--excluded-owners")))
+		Expect(cfg.ExcludedOwnerKinds()).To(BeNil())
+		cfg.ExcludedOwners = []string{"monitoring.coreos.com", "mesh.example.com/Injector"}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.ExcludedOwnerKinds()).To(HaveLen(2))
+	})
+
+	It("rejects timeout budgets that are not percentages", func() {
+		cfg := &Config{WebhookTimeoutBudgetPercent: 150}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--webhook-timeout-budget-percent")))
+		cfg.WebhookTimeoutBudgetPercent = 70
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects denial message templates it cannot execute", func() {
+		cfg := &Config{DenialMessageTemplate: "{{.message"}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--denial-message-template")))
+		cfg.DenialMessageTemplate = "{{.message}} ({{.quota}})"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("quota")))
+		cfg.DenialMessageTemplate = "{{.message}}. See https://runbooks.example.com/quota/{{.crq}}"
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects usage change percentages out of range", func() {
+		cfg := &Config{EventsUsageChangePercent: -5}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("--events-usage-change-percent")))
+		cfg.EventsUsageChangePercent = 10
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("rejects unknown webhook names", func() {
+		cfg := &Config{EnabledWebhooks: []string{WebhookPods, "ingresses"}}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`unknown webhook "ingresses"`)))
+		cfg.EnabledWebhooks = []string{WebhookPods, WebhookPVCs}
+		Expect(cfg.Validate()).To(Succeed())
+		Expect(cfg.WebhookEnabled(WebhookServices)).To(BeFalse())
+	})
+
+	It("rejects webhook auto-scope without a webhook configuration", func() {
+		cfg := &Config{WebhookAutoScope: true}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("requires --webhook-configuration-name")))
+	})
+})
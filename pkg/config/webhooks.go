@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Names of the validating webhooks --enable-webhooks selects from.
+const (
+	WebhookClusterResourceQuotas = "clusterresourcequotas"
+	WebhookNamespaces            = "namespaces"
+	WebhookPods                  = "pods"
+	WebhookPVCs                  = "pvcs"
+	WebhookServices              = "services"
+	WebhookObjectCounts          = "objectcounts"
+	WebhookResourceClaims        = "resourceclaims"
+	WebhookStatefulSets          = "statefulsets"
+)
+
+// AllWebhooks lists every validating webhook in the order they are served.
+var AllWebhooks = []string{
+	WebhookClusterResourceQuotas,
+	WebhookNamespaces,
+	WebhookPods,
+	WebhookPVCs,
+	WebhookServices,
+	WebhookObjectCounts,
+	WebhookResourceClaims,
+	WebhookStatefulSets,
+}
+
+// WebhookPaths maps each validating webhook to the path it is served on, which
+// is also how its entry in the ValidatingWebhookConfiguration is recognized.
+var WebhookPaths = map[string]string{
+	WebhookClusterResourceQuotas: "/validate-quota-powerapp-cloud-v1alpha1-clusterresourcequota",
+	WebhookNamespaces:            "/validate--v1-namespace",
+	WebhookPods:                  "/validate--v1-pod",
+	WebhookPVCs:                  "/validate--v1-persistentvolumeclaim",
+	WebhookServices:              "/validate--v1-service",
+	WebhookObjectCounts:          "/validate-objectcount-v1",
+	WebhookResourceClaims:        "/validate-resource-k8s-io-v1-resourceclaim",
+	WebhookStatefulSets:          "/validate-apps-v1-statefulset",
+}
+
+// WebhookEnabled reports whether --enable-webhooks includes name. An empty
+// list, as in a Config built by hand, enables every webhook.
+func (c *Config) WebhookEnabled(name string) bool {
+	return WebhookEnabled(c.EnabledWebhooks, name)
+}
+
+// WebhookEnabled reports whether enabled includes name, an empty list
+// enabling every webhook.
+func WebhookEnabled(enabled []string, name string) bool {
+	return len(enabled) == 0 || slices.Contains(enabled, name)
+}
+
+// validateWebhookNames rejects names that are not in AllWebhooks.
+func validateWebhookNames(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(AllWebhooks, name) {
+			return fmt.Errorf("--enable-webhooks: unknown webhook %q, expected one of %s",
+				name, strings.Join(AllWebhooks, ","))
+		}
+	}
+	return nil
+}
+
+// DenialMessageVariables are the variables a --denial-message-template can
+// use: the quota, namespace and resource of the denied request, the quota's
+// usage and hard limit of the resource, the amount requested, and message,
+// the built-in message. Denials not tied to one limit, such as a pod missing
+// the requests a quota requires, leave the figures empty.
+var DenialMessageVariables = []string{"crq", "namespace", "resource", "used", "hard", "requested", "message"}
+
+// ParseDenialMessageTemplate parses a --denial-message-template. Using a
+// variable that is not one of DenialMessageVariables is an error.
+func ParseDenialMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("denial-message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Parsing does not know the variables; executing with every one set does.
+	data := make(map[string]string, len(DenialMessageVariables))
+	for _, name := range DenialMessageVariables {
+		data[name] = ""
+	}
+	if err := tmpl.Execute(io.Discard, data); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
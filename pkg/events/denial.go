@@ -0,0 +1,47 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// admissionDeniedNoteRegexp matches the notes admissionDeniedNote writes. The
+// namespace is empty for cluster-scoped requests.
+var admissionDeniedNoteRegexp = regexp.MustCompile(`(?s)^Denied (\S+) of (\S+) in namespace (\S*): (.*)$`)
+
+// admissionDeniedNote is the note of an AdmissionDenied event.
+func admissionDeniedNote(namespace string, resourceName corev1.ResourceName,
+	requested resource.Quantity, message string) string {
+	return fmt.Sprintf("Denied %s of %s in namespace %s: %s", requested.String(), resourceName, namespace, message)
+}
+
+// ParseAdmissionDenied returns the denial an AdmissionDenied event on a
+// ClusterResourceQuota records, timed at its latest occurrence, and false
+// for any other event.
+func ParseAdmissionDenied(e *eventsv1.Event) (*quotav1alpha1.AdmissionDenial, bool) {
+	if e.Reason != ReasonAdmissionDenied || e.Regarding.Kind != crqEventKind {
+		return nil, false
+	}
+	match := admissionDeniedNoteRegexp.FindStringSubmatch(e.Note)
+	if match == nil {
+		return nil, false
+	}
+	requested, err := resource.ParseQuantity(match[1])
+	if err != nil {
+		return nil, false
+	}
+	return &quotav1alpha1.AdmissionDenial{
+		Time:      metav1.NewTime(eventTime(e)),
+		Namespace: match[3],
+		Resource:  corev1.ResourceName(match[2]),
+		Requested: requested,
+		Message:   match[4],
+	}, true
+}
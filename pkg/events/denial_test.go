@@ -0,0 +1,62 @@
+package events
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+)
+
+var _ = Describe("AdmissionDenied", func() {
+	denialEvent := func(note string) *eventsv1.Event {
+		return &eventsv1.Event{
+			Regarding: corev1.ObjectReference{Kind: crqEventKind, Name: "team-a"},
+			Reason:    ReasonAdmissionDenied,
+			Note:      note,
+			EventTime: metav1.NewMicroTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		}
+	}
+
+	It("records a note ParseAdmissionDenied reads back", func() {
+		fakeRecorder := events.NewFakeRecorder(1)
+		NewEventRecorder(fakeRecorder, zap.NewNop()).AdmissionDenied("team-a", "web", "requests.cpu",
+			resource.MustParse("500m"), "ClusterResourceQuota 'team-a' requests.cpu limit exceeded: at 90%")
+
+		var recorded string
+		Expect(fakeRecorder.Events).To(Receive(&recorded))
+		note, found := strings.CutPrefix(recorded, EventTypeWarning+" "+ReasonAdmissionDenied+" ")
+		Expect(found).To(BeTrue())
+
+		denial, ok := ParseAdmissionDenied(denialEvent(note))
+		Expect(ok).To(BeTrue())
+		Expect(denial.Time.Time).To(Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+		Expect(denial.Namespace).To(Equal("web"))
+		Expect(denial.Resource).To(Equal(corev1.ResourceName("requests.cpu")))
+		Expect(denial.Requested.Cmp(resource.MustParse("500m"))).To(BeZero())
+		Expect(denial.Message).To(Equal("ClusterResourceQuota 'team-a' requests.cpu limit exceeded: at 90%"))
+	})
+
+	It("reads cluster-scoped denials", func() {
+		denial, ok := ParseAdmissionDenied(denialEvent("Denied 2 of pods in namespace : over the limit"))
+		Expect(ok).To(BeTrue())
+		Expect(denial.Namespace).To(BeEmpty())
+		Expect(denial.Message).To(Equal("over the limit"))
+	})
+
+	It("ignores other events", func() {
+		other := denialEvent("Denied 2 of pods in namespace web: over the limit")
+		other.Reason = ReasonUsageChanged
+		_, ok := ParseAdmissionDenied(other)
+		Expect(ok).To(BeFalse())
+
+		_, ok = ParseAdmissionDenied(denialEvent("Usage changed"))
+		Expect(ok).To(BeFalse())
+	})
+})
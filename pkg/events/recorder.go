@@ -2,9 +2,16 @@ package events
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/events"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
@@ -17,6 +24,20 @@ const (
 	ReasonNamespaceRemoved  = "NamespaceRemoved"
 	ReasonCalculationFailed = "CalculationFailed"
 	ReasonInvalidSelector   = "InvalidSelector"
+	ReasonUsageChanged      = "UsageChanged"
+
+	// ReasonEnforcementExempt and ReasonEnforcementResumed mark the opening
+	// and closing of a quota's enforcement exemption window.
+	ReasonEnforcementExempt  = "EnforcementExempt"
+	ReasonEnforcementResumed = "EnforcementResumed"
+
+	// ReasonAdmissionDenied is recorded by the admission webhook on a
+	// ClusterResourceQuota whose limit a request was denied for.
+	ReasonAdmissionDenied = "AdmissionDenied"
+
+	// ReasonRecommendationExceedsQuota is recorded on a VerticalPodAutoscaler
+	// whose recommendation would take its namespace's quota over a hard limit.
+	ReasonRecommendationExceedsQuota = "RecommendationExceedsQuota"
 
 	// Event types
 	EventTypeNormal  = "Normal"
@@ -24,6 +45,9 @@ const (
 
 	// ActionReconcile is the action field for all CRQ events — they all originate from the reconcile loop.
 	ActionReconcile = "Reconcile"
+
+	// ActionAdmit is the action field of AdmissionDenied events.
+	ActionAdmit = "Admit"
 )
 
 // EventRecorder wraps the Kubernetes event recorder with PAC-specific functionality
@@ -51,16 +75,26 @@ func (r *EventRecorder) QuotaExceeded(crq *quotav1alpha1.ClusterResourceQuota, r
 	r.recordEvent(crq, EventTypeWarning, ReasonQuotaExceeded, message)
 }
 
-// NamespaceAdded records an event when a namespace enters quota scope
-func (r *EventRecorder) NamespaceAdded(crq *quotav1alpha1.ClusterResourceQuota, namespace string) {
-	message := fmt.Sprintf("Namespace %s added to quota scope", namespace)
-	r.recordEvent(crq, EventTypeNormal, ReasonNamespaceAdded, message)
+// NamespaceAdded records a namespace entering quota scope on both the CRQ and
+// the namespace, with the namespace's usage as it joined and the quota's new total.
+func (r *EventRecorder) NamespaceAdded(crq *quotav1alpha1.ClusterResourceQuota, namespace string,
+	used, total quotav1alpha1.ResourceList) {
+	snapshot := usageSnapshot(crq, used, total)
+	r.recordEvent(crq, EventTypeNormal, ReasonNamespaceAdded,
+		fmt.Sprintf("Namespace %s added to quota scope%s", namespace, snapshot))
+	r.recordNamespaceEvent(crq, namespace, ReasonNamespaceAdded,
+		fmt.Sprintf("Namespace entered the scope of ClusterResourceQuota %s%s", crq.Name, snapshot))
 }
 
-// NamespaceRemoved records an event when a namespace leaves quota scope
-func (r *EventRecorder) NamespaceRemoved(crq *quotav1alpha1.ClusterResourceQuota, namespace string) {
-	message := fmt.Sprintf("Namespace %s removed from quota scope", namespace)
-	r.recordEvent(crq, EventTypeNormal, ReasonNamespaceRemoved, message)
+// NamespaceRemoved records a namespace leaving quota scope on both the CRQ and
+// the namespace, with the namespace's last recorded usage and the quota's new total.
+func (r *EventRecorder) NamespaceRemoved(crq *quotav1alpha1.ClusterResourceQuota, namespace string,
+	used, total quotav1alpha1.ResourceList) {
+	snapshot := usageSnapshot(crq, used, total)
+	r.recordEvent(crq, EventTypeNormal, ReasonNamespaceRemoved,
+		fmt.Sprintf("Namespace %s removed from quota scope%s", namespace, snapshot))
+	r.recordNamespaceEvent(crq, namespace, ReasonNamespaceRemoved,
+		fmt.Sprintf("Namespace left the scope of ClusterResourceQuota %s%s", crq.Name, snapshot))
 }
 
 // CalculationFailed records an event when resource calculation fails
@@ -75,9 +109,133 @@ func (r *EventRecorder) InvalidSelector(crq *quotav1alpha1.ClusterResourceQuota,
 	r.recordEvent(crq, EventTypeWarning, ReasonInvalidSelector, message)
 }
 
+// UsageChanged records an event listing the resources whose total usage
+// moved significantly, from their previous to their current usage, each
+// shown against its hard limit.
+func (r *EventRecorder) UsageChanged(crq *quotav1alpha1.ClusterResourceQuota,
+	previous, current quotav1alpha1.ResourceList) {
+	names := make([]string, 0, len(current))
+	for resourceName := range current {
+		names = append(names, string(resourceName))
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		before, after := previous[corev1.ResourceName(name)], current[corev1.ResourceName(name)]
+		part := name + " " + before.String() + " -> " + after.String()
+		if limit, ok := crq.Spec.Hard[corev1.ResourceName(name)]; ok {
+			part += "/" + limit.String()
+		}
+		parts = append(parts, part)
+	}
+	r.recordEvent(crq, EventTypeNormal, ReasonUsageChanged, "Usage changed: "+strings.Join(parts, ", "))
+}
+
+// EnforcementExempt records a warning that crq admits requests exceeding its
+// limits until until.
+func (r *EventRecorder) EnforcementExempt(crq *quotav1alpha1.ClusterResourceQuota, until time.Time) {
+	r.recordEvent(crq, EventTypeWarning, ReasonEnforcementExempt,
+		"Requests exceeding the quota are admitted with a warning until "+until.UTC().Format(time.RFC3339))
+}
+
+// EnforcementResumed records that the enforcement exemption window of crq
+// closed and requests exceeding its limits are denied again.
+func (r *EventRecorder) EnforcementResumed(crq *quotav1alpha1.ClusterResourceQuota) {
+	r.recordEvent(crq, EventTypeNormal, ReasonEnforcementResumed,
+		"Enforcement exemption window closed; requests exceeding the quota are denied again")
+}
+
+// RecommendationExceedsQuota records a warning on a VerticalPodAutoscaler,
+// related to crq, whose recommendation needs more of resourceName than the
+// quota has left, so the pod webhook would deny the resized pods.
+func (r *EventRecorder) RecommendationExceedsQuota(vpa runtime.Object, crq *quotav1alpha1.ClusterResourceQuota,
+	resourceName corev1.ResourceName, increase, headroom resource.Quantity) {
+	message := fmt.Sprintf("Recommendation needs %s more %s than ClusterResourceQuota %s has left (%s); "+
+		"resized pods would be denied", increase.String(), resourceName, crq.Name, headroom.String())
+	r.recorder.Eventf(vpa, crq, EventTypeWarning, ReasonRecommendationExceedsQuota, ActionReconcile,
+		truncateNote(message))
+}
+
+// AdmissionDenied records a warning on the ClusterResourceQuota named crqName
+// that a request in namespace was denied for asking requested of resourceName
+// beyond its limit. The controller reports the latest one in the quota's
+// status.lastDenied; see ParseAdmissionDenied.
+func (r *EventRecorder) AdmissionDenied(crqName, namespace string, resourceName corev1.ResourceName,
+	requested resource.Quantity, message string) {
+	crq := &quotav1alpha1.ClusterResourceQuota{
+		TypeMeta:   metav1.TypeMeta{Kind: crqEventKind, APIVersion: quotav1alpha1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: crqName},
+	}
+	var related runtime.Object
+	if namespace != "" {
+		related = &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		}
+	}
+	r.recorder.Eventf(crq, related, EventTypeWarning, ReasonAdmissionDenied, ActionAdmit, "%s",
+		truncateNote(admissionDeniedNote(namespace, resourceName, requested, message)))
+}
+
 // recordEvent records an event with PAC-specific labels using the current pod as the event target
 func (r *EventRecorder) recordEvent(crq *quotav1alpha1.ClusterResourceQuota,
 	eventType, reason, message string) {
 
-	r.recorder.Eventf(crq, nil, eventType, reason, ActionReconcile, message)
+	r.recorder.Eventf(crq, nil, eventType, reason, ActionReconcile, truncateNote(message))
+}
+
+// recordNamespaceEvent records a Normal event regarding namespace, related to crq.
+func (r *EventRecorder) recordNamespaceEvent(crq *quotav1alpha1.ClusterResourceQuota, namespace, reason, message string) {
+	ns := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+	}
+	r.recorder.Eventf(ns, crq, EventTypeNormal, reason, ActionReconcile, truncateNote(message))
+}
+
+// usageSnapshot renders "; namespace usage: ...; quota total: ..." with
+// resources sorted by name and each total shown against its hard limit.
+func usageSnapshot(crq *quotav1alpha1.ClusterResourceQuota, used, total quotav1alpha1.ResourceList) string {
+	return fmt.Sprintf("; namespace usage: %s; quota total: %s",
+		formatResources(used, nil), formatResources(total, crq.Spec.Hard))
+}
+
+// formatResources renders "name=quantity" pairs sorted by name, adding
+// "/limit" for every resource limits has, or "none" when values is empty.
+func formatResources(values, limits quotav1alpha1.ResourceList) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(values))
+	for resourceName := range values {
+		names = append(names, string(resourceName))
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		q := values[corev1.ResourceName(name)]
+		part := name + "=" + q.String()
+		if limit, ok := limits[corev1.ResourceName(name)]; ok {
+			part += "/" + limit.String()
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxNoteBytes is the longest note the API server accepts on an event.
+const maxNoteBytes = 1024
+
+// truncateNote cuts message to maxNoteBytes so a quota with many resources
+// does not have its events rejected.
+func truncateNote(message string) string {
+	if len(message) <= maxNoteBytes {
+		return message
+	}
+	const ellipsis = "..."
+	cut := maxNoteBytes - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + ellipsis
 }
@@ -2,13 +2,17 @@ package events
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/events"
 
@@ -93,42 +97,72 @@ var _ = Describe("EventRecorder", func() {
 	})
 
 	Describe("NamespaceAdded", func() {
-		It("should record a NamespaceAdded event", func() {
-			eventRecorder.NamespaceAdded(testCRQ, "test-namespace")
-
-			Expect(fakeRecorder.Events).To(HaveLen(1))
+		It("records the namespace's usage and the new total on the CRQ and the namespace", func() {
+			eventRecorder.NamespaceAdded(testCRQ, "test-namespace",
+				quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("500m")},
+				quotav1alpha1.ResourceList{
+					"requests.cpu":    resource.MustParse("1500m"),
+					"requests.memory": resource.MustParse("1Gi"),
+				})
+
+			Expect(fakeRecorder.Events).To(HaveLen(2))
 			event := <-fakeRecorder.Events
-			Expect(event).To(ContainSubstring("NamespaceAdded"))
-			Expect(event).To(ContainSubstring("Namespace test-namespace added to quota scope"))
+			Expect(event).To(ContainSubstring("Normal NamespaceAdded"))
+			Expect(event).To(ContainSubstring("Namespace test-namespace added to quota scope; " +
+				"namespace usage: requests.cpu=500m; quota total: requests.cpu=1500m/2, requests.memory=1Gi/4Gi"))
+			event = <-fakeRecorder.Events
+			Expect(event).To(ContainSubstring("Namespace entered the scope of ClusterResourceQuota test-crq"))
 		})
 
-		It("should record event as Normal type", func() {
-			eventRecorder.NamespaceAdded(testCRQ, "test-namespace")
+		It("reports no usage for an empty namespace", func() {
+			eventRecorder.NamespaceAdded(testCRQ, "test-namespace", nil, nil)
 
-			Expect(fakeRecorder.Events).To(HaveLen(1))
 			event := <-fakeRecorder.Events
-			Expect(event).To(ContainSubstring("Normal"))
-			Expect(event).To(ContainSubstring("NamespaceAdded"))
+			Expect(event).To(ContainSubstring("namespace usage: none; quota total: none"))
 		})
 	})
 
 	Describe("NamespaceRemoved", func() {
-		It("should record a NamespaceRemoved event", func() {
-			eventRecorder.NamespaceRemoved(testCRQ, "test-namespace")
+		It("records the namespace's last usage on the CRQ and the namespace", func() {
+			eventRecorder.NamespaceRemoved(testCRQ, "test-namespace",
+				quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("1")},
+				quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("500m")})
 
-			Expect(fakeRecorder.Events).To(HaveLen(1))
+			Expect(fakeRecorder.Events).To(HaveLen(2))
 			event := <-fakeRecorder.Events
-			Expect(event).To(ContainSubstring("NamespaceRemoved"))
-			Expect(event).To(ContainSubstring("Namespace test-namespace removed from quota scope"))
+			Expect(event).To(ContainSubstring("Normal NamespaceRemoved"))
+			Expect(event).To(ContainSubstring("Namespace test-namespace removed from quota scope; " +
+				"namespace usage: requests.cpu=1; quota total: requests.cpu=500m/2"))
+			event = <-fakeRecorder.Events
+			Expect(event).To(ContainSubstring("Namespace left the scope of ClusterResourceQuota test-crq"))
 		})
+	})
 
-		It("should record event as Normal type", func() {
-			eventRecorder.NamespaceRemoved(testCRQ, "test-namespace")
+	Describe("UsageChanged", func() {
+		It("lists each changed resource against its hard limit", func() {
+			eventRecorder.UsageChanged(testCRQ,
+				quotav1alpha1.ResourceList{"requests.memory": resource.MustParse("1Gi")},
+				quotav1alpha1.ResourceList{
+					"requests.memory": resource.MustParse("3Gi"),
+					"requests.cpu":    resource.MustParse("1500m"),
+					"pods":            resource.MustParse("4"),
+				})
 
 			Expect(fakeRecorder.Events).To(HaveLen(1))
 			event := <-fakeRecorder.Events
-			Expect(event).To(ContainSubstring("Normal"))
-			Expect(event).To(ContainSubstring("NamespaceRemoved"))
+			Expect(event).To(ContainSubstring("Normal UsageChanged"))
+			Expect(event).To(ContainSubstring("Usage changed: pods 0 -> 4, " +
+				"requests.cpu 0 -> 1500m/2, requests.memory 1Gi -> 3Gi/4Gi"))
+		})
+	})
+
+	Describe("truncateNote", func() {
+		It("keeps notes within the API server's limit", func() {
+			Expect(truncateNote("short")).To(Equal("short"))
+			long := truncateNote(strings.Repeat("é", 600))
+			Expect(len(long)).To(BeNumerically("<=", maxNoteBytes))
+			Expect(utf8.ValidString(long)).To(BeTrue())
+			Expect(long).To(HaveSuffix("..."))
 		})
 	})
 
@@ -154,6 +188,20 @@ var _ = Describe("EventRecorder", func() {
 		})
 	})
 
+	Describe("RecommendationExceedsQuota", func() {
+		It("records a warning with the increase and the headroom left", func() {
+			vpa := &unstructured.Unstructured{}
+			vpa.SetName("web-vpa")
+			eventRecorder.RecommendationExceedsQuota(vpa, testCRQ, "requests.cpu",
+				resource.MustParse("3"), resource.MustParse("1"))
+
+			Expect(fakeRecorder.Events).To(HaveLen(1))
+			event := <-fakeRecorder.Events
+			Expect(event).To(ContainSubstring("Warning RecommendationExceedsQuota"))
+			Expect(event).To(ContainSubstring("needs 3 more requests.cpu than ClusterResourceQuota test-crq has left (1)"))
+		})
+	})
+
 	Describe("InvalidSelector", func() {
 		It("should record an InvalidSelector event with error details", func() {
 			testErr := fmt.Errorf("invalid label selector syntax")
@@ -176,6 +224,24 @@ var _ = Describe("EventRecorder", func() {
 		})
 	})
 
+	Describe("EnforcementExempt and EnforcementResumed", func() {
+		It("records the opening of the window as a warning with its end", func() {
+			eventRecorder.EnforcementExempt(testCRQ, time.Date(2026, 1, 2, 6, 0, 0, 0, time.UTC))
+
+			Expect(fakeRecorder.Events).To(HaveLen(1))
+			event := <-fakeRecorder.Events
+			Expect(event).To(ContainSubstring("Warning EnforcementExempt"))
+			Expect(event).To(ContainSubstring("admitted with a warning until 2026-01-02T06:00:00Z"))
+		})
+
+		It("records the closing of the window", func() {
+			eventRecorder.EnforcementResumed(testCRQ)
+
+			Expect(fakeRecorder.Events).To(HaveLen(1))
+			Expect(<-fakeRecorder.Events).To(ContainSubstring("Normal EnforcementResumed"))
+		})
+	})
+
 	Describe("Event Annotations", func() {
 		It("should include PAC-specific annotations on events", func() {
 			// Test with QuotaExceeded as an example
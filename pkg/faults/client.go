@@ -0,0 +1,70 @@
+package faults
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrNotEnabled is returned by the client when the server was built without
+// the faultinject build tag.
+var ErrNotEnabled = errors.New("fault injection is not enabled on the server")
+
+// Inject asks the webhook server at server (e.g. "https://localhost:9443")
+// to set f at point, authenticating with token.
+func Inject(ctx context.Context, httpClient *http.Client, server, token string, point Point, f Fault) error {
+	body, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return call(ctx, httpClient, http.MethodPut, pointURL(server, point), token, body)
+}
+
+// Remove asks the webhook server at server to clear the fault at point.
+func Remove(ctx context.Context, httpClient *http.Client, server, token string, point Point) error {
+	return call(ctx, httpClient, http.MethodDelete, pointURL(server, point), token, nil)
+}
+
+func pointURL(server string, point Point) string {
+	return strings.TrimSuffix(server, "/") + Path + "/" + string(point)
+}
+
+func call(ctx context.Context, httpClient *http.Client, method, url, token string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotEnabled
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(respBody, &failure) == nil && failure.Error != "" {
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, failure.Error)
+	}
+	return fmt.Errorf("%s returned %s", url, resp.Status)
+}
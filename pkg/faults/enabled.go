@@ -0,0 +1,7 @@
+//go:build faultinject
+
+package faults
+
+// Enabled reports whether this binary was built with the faultinject build
+// tag, and so serves the routes setting faults.
+const Enabled = true
@@ -0,0 +1,127 @@
+// Package faults injects failures into a running controller, for end-to-end
+// suites asserting that the controller and its webhooks degrade as designed:
+// failing open or closed, retrying, reporting conditions.
+//
+// Faults are set through the admin routes, which are only served by binaries
+// built with the faultinject build tag; see Enabled. Without it, no fault can
+// be set and every hook is a no-op. Faults are held in memory by the replica
+// that received the request.
+package faults
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Point is where a fault is injected.
+type Point string
+
+const (
+	// KubeAPI faults the requests the controller makes to the Kubernetes API.
+	KubeAPI Point = "kube-api"
+	// Admission faults the admission requests the webhooks serve.
+	Admission Point = "admission"
+	// Certificate fails the TLS handshakes of the webhook server, as an
+	// expired or missing serving certificate would. The admin routes share
+	// the server, so set For to have the fault lift itself.
+	Certificate Point = "certificate"
+)
+
+// Points are the points a fault can be set at.
+var Points = []Point{KubeAPI, Admission, Certificate}
+
+// Fault is a failure injected at a Point.
+type Fault struct {
+	// Delay holds each request this long before it proceeds or fails.
+	Delay metav1.Duration `json:"delay,omitempty"`
+	// Status, when set, answers each request with this HTTP status instead
+	// of serving it. A Certificate fault with any status fails the handshake.
+	Status int `json:"status,omitempty"`
+	// Method limits a KubeAPI fault to requests with this HTTP method.
+	Method string `json:"method,omitempty"`
+	// Path limits a KubeAPI or Admission fault to requests whose URL path
+	// contains it, such as /clusterresourcequotas/.
+	Path string `json:"path,omitempty"`
+	// For clears the fault this long after it is set; zero keeps it until it
+	// is cleared.
+	For metav1.Duration `json:"for,omitempty"`
+}
+
+// Validate rejects a fault that injects nothing or cannot be answered.
+func (f Fault) Validate() error {
+	if f.Delay.Duration < 0 || f.For.Duration < 0 {
+		return fmt.Errorf("delay and for must not be negative")
+	}
+	if f.Status != 0 && (f.Status < 400 || f.Status > 599) {
+		return fmt.Errorf("status must be an HTTP error status between 400 and 599, got %d", f.Status)
+	}
+	if f.Delay.Duration == 0 && f.Status == 0 {
+		return fmt.Errorf("a fault needs a delay or a status")
+	}
+	return nil
+}
+
+// set is a fault and when it clears itself, zero for never.
+type set struct {
+	fault   Fault
+	expires time.Time
+}
+
+var (
+	mu     sync.RWMutex
+	active = map[Point]set{}
+)
+
+// Set injects f at point, replacing the fault set there before.
+func Set(point Point, f Fault) error {
+	if !slices.Contains(Points, point) {
+		return fmt.Errorf("unknown fault point %q", point)
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	s := set{fault: f}
+	if f.For.Duration > 0 {
+		s.expires = time.Now().Add(f.For.Duration)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	active[point] = s
+	return nil
+}
+
+// Clear removes the fault at point, if any.
+func Clear(point Point) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(active, point)
+}
+
+// Active returns the faults set, by point.
+func Active() map[Point]Fault {
+	mu.RLock()
+	defer mu.RUnlock()
+	now := time.Now()
+	faults := make(map[Point]Fault, len(active))
+	for point, s := range active {
+		if s.expires.IsZero() || now.Before(s.expires) {
+			faults[point] = s.fault
+		}
+	}
+	return faults
+}
+
+// lookup returns the fault at point, unless it has expired.
+func lookup(point Point) (Fault, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := active[point]
+	if !ok || (!s.expires.IsZero() && !time.Now().Before(s.expires)) {
+		return Fault{}, false
+	}
+	return s.fault, true
+}
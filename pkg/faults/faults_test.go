@@ -0,0 +1,178 @@
+package faults
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/powerhome/pac-quota-controller/pkg/admin"
+)
+
+const testToken = "s3cret"
+
+func duration(d time.Duration) metav1.Duration { return metav1.Duration{Duration: d} }
+
+func reset(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		for _, point := range Points {
+			Clear(point)
+		}
+	})
+}
+
+func TestSetValidates(t *testing.T) {
+	reset(t)
+
+	for name, f := range map[string]Fault{
+		"empty":          {},
+		"success status": {Status: http.StatusOK},
+		"negative delay": {Delay: duration(-time.Second), Status: http.StatusInternalServerError},
+		"negative for":   {Status: http.StatusInternalServerError, For: duration(-time.Second)},
+	} {
+		assert.Error(t, Set(KubeAPI, f), name)
+	}
+	assert.Error(t, Set("disk", Fault{Status: http.StatusInternalServerError}))
+	assert.Empty(t, Active())
+
+	require.NoError(t, Set(Admission, Fault{Status: http.StatusServiceUnavailable}))
+	assert.Equal(t, map[Point]Fault{Admission: {Status: http.StatusServiceUnavailable}}, Active())
+	Clear(Admission)
+	assert.Empty(t, Active())
+}
+
+func TestSetExpires(t *testing.T) {
+	reset(t)
+
+	require.NoError(t, Set(KubeAPI, Fault{Status: http.StatusInternalServerError, For: duration(50 * time.Millisecond)}))
+	_, ok := lookup(KubeAPI)
+	assert.True(t, ok)
+
+	assert.Eventually(t, func() bool {
+		_, ok := lookup(KubeAPI)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, Active())
+}
+
+func TestTransport(t *testing.T) {
+	reset(t)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"team-a"}}`))
+	}))
+	t.Cleanup(api.Close)
+	cfg := &rest.Config{Host: api.URL}
+	cfg.Wrap(Transport)
+	clientset, err := kubernetes.NewForConfig(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, Set(KubeAPI, Fault{Status: http.StatusServiceUnavailable, Path: "/namespaces/"}))
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{})
+	require.Error(t, err)
+	assert.True(t, apierrors.IsServiceUnavailable(err), err.Error())
+	assert.Contains(t, err.Error(), errInjected)
+
+	require.NoError(t, Set(KubeAPI, Fault{Status: http.StatusServiceUnavailable, Path: "/pods/"}))
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{})
+	assert.NoError(t, err, "a fault limited to another path")
+
+	require.NoError(t, Set(KubeAPI, Fault{Status: http.StatusServiceUnavailable, Method: http.MethodPut}))
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{})
+	assert.NoError(t, err, "a fault limited to another method")
+
+	require.NoError(t, Set(KubeAPI, Fault{Delay: duration(100 * time.Millisecond)}))
+	start := time.Now()
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestMiddleware(t *testing.T) {
+	reset(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.POST("/validate-v1-pod", func(c *gin.Context) { c.Status(http.StatusOK) })
+	serve := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate-v1-pod", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve())
+	require.NoError(t, Set(Admission, Fault{Status: http.StatusInternalServerError}))
+	assert.Equal(t, http.StatusInternalServerError, serve())
+	require.NoError(t, Set(Admission, Fault{Status: http.StatusInternalServerError, Path: "/validate-v1-service"}))
+	assert.Equal(t, http.StatusOK, serve())
+}
+
+func TestCheckCertificate(t *testing.T) {
+	reset(t)
+
+	assert.NoError(t, CheckCertificate(context.Background()))
+	require.NoError(t, Set(Certificate, Fault{Status: http.StatusServiceUnavailable}))
+	assert.Error(t, CheckCertificate(context.Background()))
+
+	require.NoError(t, Set(Certificate, Fault{Delay: duration(time.Minute)}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, CheckCertificate(ctx), context.DeadlineExceeded)
+}
+
+func TestHandler(t *testing.T) {
+	reset(t)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(testToken), 0o600))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adm := router.Group("/")
+	adm.Use(admin.RequireToken(tokenFile, zap.NewNop()))
+	NewHandler(zap.NewNop()).Register(adm)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	f := Fault{Status: http.StatusInternalServerError, Method: http.MethodPatch, Path: "/clusterresourcequotas/"}
+	require.NoError(t, Inject(ctx, srv.Client(), srv.URL, testToken, KubeAPI, f))
+	assert.Equal(t, map[Point]Fault{KubeAPI: f}, Active())
+
+	err := Inject(ctx, srv.Client(), srv.URL, testToken, KubeAPI, Fault{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	err = Inject(ctx, srv.Client(), srv.URL, "wrong", Admission, f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+
+	require.NoError(t, Remove(ctx, srv.Client(), srv.URL, testToken, KubeAPI))
+	assert.Empty(t, Active())
+}
+
+func TestClientNotEnabled(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	err := Inject(context.Background(), srv.Client(), srv.URL, testToken, Admission,
+		Fault{Status: http.StatusInternalServerError})
+	assert.ErrorIs(t, err, ErrNotEnabled)
+}
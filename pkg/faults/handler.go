@@ -0,0 +1,65 @@
+package faults
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Path is the route listing the faults set; a fault is set with PUT and
+// cleared with DELETE on Path/<point>.
+const Path = "/admin/faults"
+
+// Handler serves the fault routes. Register it behind admin.RequireToken,
+// and only when Enabled.
+type Handler struct {
+	logger *zap.Logger
+}
+
+// NewHandler creates a Handler.
+func NewHandler(logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{logger: logger.Named("faults")}
+}
+
+// Register adds the fault routes to r.
+func (h *Handler) Register(r gin.IRouter) {
+	r.GET(Path, h.list)
+	r.PUT(Path+"/:point", h.set)
+	r.DELETE(Path+"/:point", h.clear)
+}
+
+func (h *Handler) list(c *gin.Context) {
+	c.JSON(http.StatusOK, Active())
+}
+
+func (h *Handler) set(c *gin.Context) {
+	point := Point(c.Param("point"))
+	var f Fault
+	if err := c.ShouldBindJSON(&f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := Set(point, f); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Warn("Injecting fault",
+		zap.String("point", string(point)),
+		zap.Duration("delay", f.Delay.Duration),
+		zap.Int("status", f.Status),
+		zap.String("method", f.Method),
+		zap.String("path", f.Path),
+		zap.Duration("for", f.For.Duration))
+	c.JSON(http.StatusOK, f)
+}
+
+func (h *Handler) clear(c *gin.Context) {
+	point := Point(c.Param("point"))
+	Clear(point)
+	h.logger.Info("Cleared fault", zap.String("point", string(point)))
+	c.Status(http.StatusNoContent)
+}
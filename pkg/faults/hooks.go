@@ -0,0 +1,122 @@
+package faults
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// errInjected is the message of every injected failure, so logs tell them
+// from real ones.
+const errInjected = "fault injected"
+
+// Transport wraps next, the transport of the controller's Kubernetes
+// clients, with the KubeAPI fault. An injected status is answered with a
+// Kubernetes Status, so clients see it as an API error.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f, ok := lookup(KubeAPI)
+	if !ok || !f.matches(req.Method, req.URL.Path) {
+		return t.next.RoundTrip(req)
+	}
+	if err := sleep(req.Context(), f.Delay.Duration); err != nil {
+		return nil, err
+	}
+	if f.Status == 0 {
+		return t.next.RoundTrip(req)
+	}
+	body, _ := json.Marshal(metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  fmt.Sprintf("%s: %s %s", errInjected, req.Method, req.URL.Path),
+		Code:     int32(f.Status),
+	})
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Middleware returns middleware applying the Admission fault to the
+// admission requests it serves. An injected status is answered as an HTTP
+// error, which the API server handles with the webhook's failurePolicy.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := lookup(Admission)
+		if !ok || !f.matches(c.Request.Method, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if err := sleep(c.Request.Context(), f.Delay.Duration); err != nil {
+			c.Abort()
+			return
+		}
+		if f.Status != 0 {
+			c.AbortWithStatusJSON(f.Status, gin.H{"error": errInjected})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CheckCertificate applies the Certificate fault to a TLS handshake of the
+// webhook server, returning the error to fail it with.
+func CheckCertificate(ctx context.Context) error {
+	f, ok := lookup(Certificate)
+	if !ok {
+		return nil
+	}
+	if err := sleep(ctx, f.Delay.Duration); err != nil {
+		return err
+	}
+	if f.Status != 0 {
+		return errors.New("serving certificate unavailable: " + errInjected)
+	}
+	return nil
+}
+
+// matches reports whether a request with method and path is subject to f.
+func (f Fault) matches(method, path string) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, method) {
+		return false
+	}
+	return f.Path == "" || strings.Contains(path, f.Path)
+}
+
+// sleep waits d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
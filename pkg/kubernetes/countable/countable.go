@@ -0,0 +1,45 @@
+// Package countable reports which quota keys the controller has a calculator
+// for, so quotas limiting anything else can be rejected before their usage is
+// left incomplete.
+package countable
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/endpointslices"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objectcount"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/resourceclaims"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// Supports reports whether the controller counts the usage of resourceName,
+// by the same dispatch as its reconcile: pod, storage and service keys, their
+// storage-class, volume-attributes-class and OS-scoped forms, extended
+// resources, ResourceClaims, endpoints and object counts.
+func Supports(resourceName corev1.ResourceName) bool {
+	switch resourceName {
+	case corev1.ResourcePods,
+		usage.ResourcePodHostPorts,
+		usage.ResourcePodDensity,
+		corev1.ResourceRequestsStorage,
+		usage.ResourcePersistentVolumeClaims,
+		usage.ResourceServices,
+		usage.ResourceServicesLoadBalancers,
+		usage.ResourceServicesNodePorts,
+		usage.ResourceServicesIPv4,
+		usage.ResourceServicesIPv6,
+		usage.ResourceServicesExternalIPs,
+		usage.ResourceServicesLoadBalancerCost,
+		usage.ResourceServicesHeadless:
+		return true
+	}
+	if key := usage.ParseQuotaKey(resourceName); key.StorageClass != "" || key.VolumeAttributesClass != "" {
+		return true
+	}
+	return pod.IsExtendedQuotaResource(resourceName) ||
+		usage.IsComputeResource(resourceName) ||
+		resourceclaims.Supports(resourceName) ||
+		endpointslices.Supports(resourceName) ||
+		objectcount.Supports(resourceName)
+}
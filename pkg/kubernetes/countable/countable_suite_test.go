@@ -0,0 +1,13 @@
+package countable
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCountable(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Countable Package Suite")
+}
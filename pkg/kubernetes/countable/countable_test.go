@@ -0,0 +1,35 @@
+package countable
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Supports", func() {
+	DescribeTable("keys the controller counts",
+		func(resourceName string) {
+			Expect(Supports(corev1.ResourceName(resourceName))).To(BeTrue())
+		},
+		Entry("pods", "pods"),
+		Entry("container requests", "requests.cpu"),
+		Entry("container limits", "limits.memory"),
+		Entry("hugepages", "requests.hugepages-2Mi"),
+		Entry("extended resources", "requests.nvidia.com/gpu"),
+		Entry("OS-scoped keys", "windows.requests.cpu"),
+		Entry("pod density", "pods.density/per-cpu"),
+		Entry("storage", "requests.storage"),
+		Entry("storage classes", "gold.storageclass.storage.k8s.io/requests.storage"),
+		Entry("services", "services.loadbalancers"),
+		Entry("endpoints", "services.endpoints"),
+		Entry("object counts", "deployments.apps"),
+	)
+
+	DescribeTable("keys no calculator counts",
+		func(resourceName string) {
+			Expect(Supports(corev1.ResourceName(resourceName))).To(BeFalse())
+		},
+		Entry("a typo", "congigmaps"),
+		Entry("an unknown kind", "widgets.example.com"),
+	)
+})
@@ -0,0 +1,52 @@
+// Package endpointslices calculates the endpoint fan-out of services: how
+// many backends the EndpointSlices of a namespace hand to kube-proxy.
+package endpointslices
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// Supports reports whether resourceName is counted from EndpointSlices.
+func Supports(resourceName corev1.ResourceName) bool {
+	return resourceName == usage.ResourceServicesEndpoints
+}
+
+// CalculateUsage lists the EndpointSlices of namespace from src and returns
+// their usage of resourceName. A resource it cannot count fails with
+// usage.ErrUnsupportedResource.
+func CalculateUsage(
+	ctx context.Context,
+	src objects.Source,
+	namespace string,
+	resourceName corev1.ResourceName,
+) (resource.Quantity, error) {
+	if !Supports(resourceName) {
+		return resource.Quantity{}, fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName)
+	}
+	slices := &discoveryv1.EndpointSliceList{}
+	if err := src.List(ctx, slices, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	return CalculateUsageFromSlices(slices.Items), nil
+}
+
+// CalculateUsageFromSlices counts the endpoints of an already loaded
+// EndpointSlice list, ready or not, since kube-proxy programs them all. A
+// dual-stack service has a slice per address family, so its backends count
+// once per family.
+func CalculateUsageFromSlices(slices []discoveryv1.EndpointSlice) resource.Quantity {
+	var count int64
+	for i := range slices {
+		count += int64(len(slices[i].Endpoints))
+	}
+	return *resource.NewQuantity(count, resource.DecimalSI)
+}
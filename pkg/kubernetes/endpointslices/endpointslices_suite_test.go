@@ -0,0 +1,18 @@
+package endpointslices
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+)
+
+func TestEndpointSlices(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EndpointSlices Package Suite")
+}
+
+var _ = BeforeSuite(func() {
+	pkglogger.InitTest()
+})
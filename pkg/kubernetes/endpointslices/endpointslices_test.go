@@ -0,0 +1,54 @@
+package endpointslices
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func sliceWith(name, namespace string, endpoints int) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Namespace: namespace},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for range endpoints {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}})
+	}
+	return slice
+}
+
+var _ = Describe("CalculateUsageFromSlices", func() {
+	It("sums the endpoints of every slice", func() {
+		q := CalculateUsageFromSlices([]discoveryv1.EndpointSlice{
+			*sliceWith("web-a", "team-a", 100), *sliceWith("web-b", "team-a", 3), *sliceWith("empty", "team-a", 0),
+		})
+		Expect(q.Value()).To(Equal(int64(103)))
+	})
+
+	It("returns zero on an empty slice", func() {
+		q := CalculateUsageFromSlices(nil)
+		Expect(q.Value()).To(Equal(int64(0)))
+	})
+})
+
+var _ = Describe("CalculateUsage", func() {
+	src := objects.FromObjects(sliceWith("web", "team-a", 2), sliceWith("api", "team-b", 5))
+
+	It("counts the endpoints of the namespace from a source", func() {
+		q, err := CalculateUsage(context.Background(), src, "team-a", usage.ResourceServicesEndpoints)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Value()).To(Equal(int64(2)))
+	})
+
+	It("rejects resources it cannot count", func() {
+		_, err := CalculateUsage(context.Background(), src, "team-a", corev1.ResourceServices)
+		Expect(errors.Is(err, usage.ErrUnsupportedResource)).To(BeTrue())
+	})
+})
@@ -0,0 +1,14 @@
+package namespace
+
+import corev1 "k8s.io/api/core/v1"
+
+// ConfirmDeleteAnnotation set to "true" on a Namespace allows its deletion
+// while it still shows usage under a ClusterResourceQuota, when namespace
+// deletion protection is enabled.
+const ConfirmDeleteAnnotation = "quota.powerapp.cloud/confirm-delete"
+
+// DeletionConfirmed reports whether ns carries ConfirmDeleteAnnotation set to
+// "true".
+func DeletionConfirmed(ns *corev1.Namespace) bool {
+	return ns != nil && ns.Annotations[ConfirmDeleteAnnotation] == "true"
+}
@@ -34,6 +34,8 @@ type LabelBasedNamespaceSelector struct {
 	client         kubernetes.Interface
 	labelSelector  *metav1.LabelSelector
 	cachedSelector labels.Selector
+	// excludeSelector, when set, drops namespaces that cachedSelector matched.
+	excludeSelector labels.Selector
 }
 
 // NewLabelBasedNamespaceSelector creates a new namespace selector based on label selector
@@ -53,6 +55,22 @@ func NewLabelBasedNamespaceSelector(
 	}, nil
 }
 
+// newCRQNamespaceSelector builds the selector for a CRQ, honoring both
+// namespaceSelector and excludeNamespaceSelector.
+func newCRQNamespaceSelector(
+	k8sClient kubernetes.Interface,
+	crq *quotav1alpha1.ClusterResourceQuota,
+) (*LabelBasedNamespaceSelector, error) {
+	s, err := NewLabelBasedNamespaceSelector(k8sClient, crq.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	if s.excludeSelector, err = quota.ExcludeNamespaceSelector(crq); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 // GetSelectedNamespaces returns namespaces that match the selector
 func (s *LabelBasedNamespaceSelector) GetSelectedNamespaces(ctx context.Context) ([]string, error) {
 	// Get all namespaces
@@ -66,9 +84,14 @@ func (s *LabelBasedNamespaceSelector) GetSelectedNamespaces(ctx context.Context)
 	// Finally add namespaces that match the label selector
 	if s.cachedSelector != nil && !s.cachedSelector.Empty() {
 		for _, ns := range namespaceList.Items {
-			if s.cachedSelector.Matches(labels.Set(ns.Labels)) {
-				selectedNamespaces[ns.Name] = struct{}{}
+			nsLabels := labels.Set(ns.Labels)
+			if !s.cachedSelector.Matches(nsLabels) {
+				continue
+			}
+			if s.excludeSelector != nil && s.excludeSelector.Matches(nsLabels) {
+				continue
 			}
+			selectedNamespaces[ns.Name] = struct{}{}
 		}
 	}
 
@@ -137,7 +160,7 @@ func (v *NamespaceValidator) ValidateCRQNamespaceConflicts(
 	}
 
 	// Use the namespace selector utility to get intended namespaces for this CRQ
-	selector, err := NewLabelBasedNamespaceSelector(v.kubernetesClient, crq.Spec.NamespaceSelector)
+	selector, err := newCRQNamespaceSelector(v.kubernetesClient, crq)
 	if err != nil {
 		return fmt.Errorf("failed to create namespace selector: %w", err)
 	}
@@ -277,7 +300,7 @@ func GetSelectedNamespaces(
 	}
 
 	// Use the namespace selector utility to get intended namespaces for this CRQ
-	selector, err := NewLabelBasedNamespaceSelector(c, crq.Spec.NamespaceSelector)
+	selector, err := newCRQNamespaceSelector(c, crq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create namespace selector: %w", err)
 	}
@@ -181,5 +181,34 @@ var _ = Describe("Namespace CRQ conflict validation", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(selected).To(Equal([]string{"ns-a", "ns-b"}))
 		})
+
+		It("drops namespaces matched by the CRQ excludeNamespaceSelector", func() {
+			client := k8sfake.NewSimpleClientset(
+				namespaceWithLabels("ns-a", map[string]string{"team": "a"}),
+				namespaceWithLabels("ns-sys", map[string]string{"team": "a", "tier": "system"}),
+			)
+			crq := crqSelecting("crq-a", map[string]string{"team": "a"})
+			crq.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tier": "system"},
+			}
+			selected, err := GetSelectedNamespaces(ctx, client, crq)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected).To(Equal([]string{"ns-a"}))
+		})
+	})
+
+	Describe("ValidateCRQNamespaceConflicts with excludeNamespaceSelector", func() {
+		It("allows a broad CRQ that carves out a namespace owned by another CRQ", func() {
+			ns1 := namespaceWithLabels("ns1", map[string]string{"env": "prod", "tier": "system"})
+			validator := NewNamespaceValidator(
+				k8sfake.NewSimpleClientset(ns1),
+				newCRQClient(crqSelecting("crq-system", map[string]string{"tier": "system"})),
+			)
+			crq := crqSelecting("crq-prod", map[string]string{"env": "prod"})
+			crq.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tier": "system"},
+			}
+			Expect(validator.ValidateCRQNamespaceConflicts(ctx, crq)).To(Succeed())
+		})
 	})
 })
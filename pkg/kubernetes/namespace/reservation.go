@@ -0,0 +1,62 @@
+package namespace
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ReserveAnnotation on a Namespace pre-reserves quota for workloads not yet
+// deployed, as a comma-separated list such as
+// "requests.cpu=4,requests.memory=8Gi". Until the namespace's usage of a
+// resource reaches its reservation, the reservation is what counts.
+const ReserveAnnotation = "quota.powerapp.cloud/reserve"
+
+// Reservation returns the quantities reserved by ns's ReserveAnnotation, or
+// nil if it has none.
+func Reservation(ns *corev1.Namespace) (corev1.ResourceList, error) {
+	if ns == nil {
+		return nil, nil
+	}
+	value, ok := ns.Annotations[ReserveAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	reserved, err := ParseReservation(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on namespace %s: %w", ReserveAnnotation, ns.Name, err)
+	}
+	return reserved, nil
+}
+
+// ParseReservation parses a ReserveAnnotation value. Quantities must not be
+// negative and each resource may appear once.
+func ParseReservation(value string) (corev1.ResourceList, error) {
+	reserved := corev1.ResourceList{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, quantity, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not <resource>=<quantity>", item)
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", name, err)
+		}
+		if q.Sign() < 0 {
+			return nil, fmt.Errorf("resource %s: quantity %s is negative", name, q.String())
+		}
+		resourceName := corev1.ResourceName(name)
+		if _, dup := reserved[resourceName]; dup {
+			return nil, fmt.Errorf("resource %s is reserved more than once", name)
+		}
+		reserved[resourceName] = q
+	}
+	return reserved, nil
+}
@@ -0,0 +1,44 @@
+package namespace
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reservation", func() {
+	namespaceWith := func(annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: annotations}}
+	}
+
+	It("parses the reserved quantities", func() {
+		reserved, err := Reservation(namespaceWith(map[string]string{
+			ReserveAnnotation: "requests.cpu=4, requests.memory=8Gi,",
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reserved).To(HaveLen(2))
+		Expect(reserved).To(HaveKeyWithValue(corev1.ResourceRequestsCPU, resource.MustParse("4")))
+		Expect(reserved).To(HaveKeyWithValue(corev1.ResourceRequestsMemory, resource.MustParse("8Gi")))
+	})
+
+	It("returns nil without the annotation", func() {
+		reserved, err := Reservation(namespaceWith(nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reserved).To(BeNil())
+	})
+
+	DescribeTable("rejects malformed values",
+		func(value, message string) {
+			_, err := Reservation(namespaceWith(map[string]string{ReserveAnnotation: value}))
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("missing quantity", "requests.cpu", "is not <resource>=<quantity>"),
+		Entry("missing resource", "=4", "is not <resource>=<quantity>"),
+		Entry("bad quantity", "requests.cpu=four", "resource requests.cpu"),
+		Entry("negative quantity", "requests.cpu=-1", "is negative"),
+		Entry("duplicate resource", "pods=1,pods=2", "reserved more than once"),
+	)
+})
@@ -3,11 +3,15 @@ package objectcount
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -16,18 +20,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// ObjectCountCalculator implements usage.ResourceCalculatorInterface for generic object count resources.
+// ObjectCountCalculator counts generic objects, such as ConfigMaps or
+// Deployments, listed from an objects.Source.
 type ObjectCountCalculator struct {
-	Client client.Client
+	Source objects.Source
 	logger *zap.Logger
 }
 
-func NewObjectCountCalculator(c client.Client, logger *zap.Logger) *ObjectCountCalculator {
+// NewObjectCountCalculator returns a calculator counting the objects of src,
+// which may be a controller-runtime client or cache, or an objects.Static
+// source for offline use.
+func NewObjectCountCalculator(src objects.Source, logger *zap.Logger) *ObjectCountCalculator {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 	return &ObjectCountCalculator{
-		Client: c,
+		Source: src,
 		logger: logger.Named("object-count-calculator"),
 	}
 }
@@ -47,12 +55,28 @@ var listConstructors = map[corev1.ResourceName]func() client.ObjectList{
 	"jobs.batch":             func() client.ObjectList { return &batchv1.JobList{} },
 	"cronjobs.batch":         func() client.ObjectList { return &batchv1.CronJobList{} },
 	"horizontalpodautoscalers.autoscaling": func() client.ObjectList {
-		return &autoscalingv1.HorizontalPodAutoscalerList{}
+		return &autoscalingv2.HorizontalPodAutoscalerList{}
 	},
 	"ingresses.networking.k8s.io": func() client.ObjectList { return &networkingv1.IngressList{} },
 }
 
+// legacyListConstructors are the older API versions listed when the cluster
+// does not serve the version in listConstructors. Every version of a kind
+// lists the same objects, so the count is unchanged.
+var legacyListConstructors = map[corev1.ResourceName]func() client.ObjectList{
+	"horizontalpodautoscalers.autoscaling": func() client.ObjectList {
+		return &autoscalingv1.HorizontalPodAutoscalerList{}
+	},
+}
+
+// Supports reports whether resourceName is an object count the calculator can track.
+func Supports(resourceName corev1.ResourceName) bool {
+	_, ok := listConstructors[resourceName]
+	return ok
+}
+
 // CalculateUsage returns the count of the specified resource in the namespace.
+// A resource it cannot count fails with usage.ErrUnsupportedResource.
 func (c *ObjectCountCalculator) CalculateUsage(
 	ctx context.Context,
 	namespace string,
@@ -61,11 +85,19 @@ func (c *ObjectCountCalculator) CalculateUsage(
 
 	newList, ok := listConstructors[resourceName]
 	if !ok {
-		return resource.Quantity{}, nil
+		return resource.Quantity{}, fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName)
 	}
 
 	list := newList()
-	if err := c.Client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+	err := c.Source.List(ctx, list, client.InNamespace(namespace))
+	if newLegacy, ok := legacyListConstructors[resourceName]; ok && meta.IsNoMatchError(err) {
+		c.logger.Debug("Preferred API version not served, listing the legacy version",
+			zap.String("correlation_id", correlationID),
+			zap.String("resource", string(resourceName)))
+		list = newLegacy()
+		err = c.Source.List(ctx, list, client.InNamespace(namespace))
+	}
+	if err != nil {
 		c.logger.Error("Failed to calculate object count usage",
 			zap.String("correlation_id", correlationID),
 			zap.String("namespace", namespace),
@@ -7,15 +7,20 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 const nsName = "objectcount-test-ns"
@@ -26,6 +31,7 @@ func newObjectCountScheme() *runtime.Scheme {
 	_ = appsv1.AddToScheme(s)
 	_ = batchv1.AddToScheme(s)
 	_ = autoscalingv1.AddToScheme(s)
+	_ = autoscalingv2.AddToScheme(s)
 	_ = networkingv1.AddToScheme(s)
 	return s
 }
@@ -106,7 +112,7 @@ var _ = Describe("ObjectCountCalculator", func() {
 		Entry(
 			"Validate hpa",
 			"horizontalpodautoscalers.autoscaling",
-			&autoscalingv1.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "hpa1", Namespace: nsName}},
+			&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "hpa1", Namespace: nsName}},
 			int64(1),
 		),
 		Entry(
@@ -117,6 +123,30 @@ var _ = Describe("ObjectCountCalculator", func() {
 		),
 	)
 
+	It("falls back to autoscaling/v1 when autoscaling/v2 is not served", func() {
+		hpa := &autoscalingv1.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "hpa1", Namespace: nsName}}
+		client := ctrlclientfake.NewClientBuilder().
+			WithScheme(newObjectCountScheme()).
+			WithObjects(hpa).
+			WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, c ctrlclient.WithWatch, list ctrlclient.ObjectList,
+					opts ...ctrlclient.ListOption) error {
+					if _, ok := list.(*autoscalingv2.HorizontalPodAutoscalerList); ok {
+						return &meta.NoKindMatchError{
+							GroupKind:        autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler").GroupKind(),
+							SearchedVersions: []string{"v2"},
+						}
+					}
+					return c.List(ctx, list, opts...)
+				},
+			}).
+			Build()
+		calc := NewObjectCountCalculator(client, logger)
+		count, err := calc.CalculateUsage(ctx, nsName, "horizontalpodautoscalers.autoscaling")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count.Value()).To(Equal(int64(1)))
+	})
+
 	It("should count multiple resources of the same type", func() {
 		ns := nsName
 		rn := corev1.ResourceName("configmaps")
@@ -146,23 +176,23 @@ var _ = Describe("ObjectCountCalculator", func() {
 		Expect(usageSecret.Value()).To(Equal(int64(1)))
 	})
 
-	It("should return zero for no resources present", func() {
+	It("should fail for a resource it does not count", func() {
 		ns := nsName
 		rn := corev1.ResourceName("pods")
 		client := newObjectCountFakeClient()
 		calc := NewObjectCountCalculator(client, logger)
-		usage, err := calc.CalculateUsage(ctx, ns, rn)
-		Expect(err).ToNot(HaveOccurred())
-		Expect(usage.Value()).To(Equal(int64(0)))
+		got, err := calc.CalculateUsage(ctx, ns, rn)
+		Expect(err).To(MatchError(usage.ErrUnsupportedResource))
+		Expect(got).To(Equal(resource.Quantity{}))
 	})
 
-	It("should return zero for inexistent resource type", func() {
+	It("should fail for inexistent resource type", func() {
 		ns := nsName
 		rn := corev1.ResourceName("nonexistent")
 		client := newObjectCountFakeClient()
 		calc := NewObjectCountCalculator(client, logger)
-		usage, err := calc.CalculateUsage(ctx, ns, rn)
-		Expect(err).ToNot(HaveOccurred())
-		Expect(usage.Value()).To(Equal(int64(0)))
+		_, err := calc.CalculateUsage(ctx, ns, rn)
+		Expect(err).To(MatchError(usage.ErrUnsupportedResource))
+		Expect(err).To(MatchError(ContainSubstring("nonexistent")))
 	})
 })
@@ -0,0 +1,120 @@
+// Package objects abstracts where usage calculators read objects from, so
+// usage can be computed from a live cluster, an informer cache or a static
+// set of manifests alike.
+package objects
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// Source lists the objects usage is computed from. A controller-runtime
+// client or cache satisfies it, as does a Static source.
+type Source interface {
+	List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error
+}
+
+// Scheme holds the kinds a Static source can serve: the built-in Kubernetes
+// kinds and the quota.powerapp.cloud kinds.
+var Scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(Scheme))
+	utilruntime.Must(quotav1alpha1.AddToScheme(Scheme))
+}
+
+// Static is a read-only Source over a fixed set of objects, for computing
+// usage offline or in tests.
+type Static struct {
+	reader client.Reader
+}
+
+// List lists the matching objects of the set.
+func (s *Static) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return s.reader.List(ctx, list, opts...)
+}
+
+// FromObjects returns a Static source serving objs, which must be of kinds
+// registered in Scheme.
+func FromObjects(objs ...client.Object) *Static {
+	return &Static{reader: fake.NewClientBuilder().WithScheme(Scheme).WithObjects(objs...).Build()}
+}
+
+// FromManifests returns a Static source serving the objects of a multi-document
+// YAML or JSON stream, such as the output of `kubectl get -o yaml` or
+// `helm template`. List kinds are flattened. Objects without a namespace,
+// other than Namespaces, are put in defaultNamespace. Kinds Scheme does not
+// know, such as third-party custom resources, are skipped: no calculator
+// counts them.
+func FromManifests(r io.Reader, defaultNamespace string) (*Static, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 4096)
+	var objs []client.Object
+	for {
+		doc := unstructured.Unstructured{}
+		if err := decoder.Decode(&doc.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return FromObjects(objs...), nil
+			}
+			return nil, fmt.Errorf("failed to decode manifests: %w", err)
+		}
+		if len(doc.Object) == 0 {
+			continue
+		}
+		items := []unstructured.Unstructured{doc}
+		if doc.IsList() {
+			items = nil
+			if err := doc.EachListItem(func(item runtime.Object) error {
+				items = append(items, *item.(*unstructured.Unstructured))
+				return nil
+			}); err != nil {
+				return nil, fmt.Errorf("failed to decode list: %w", err)
+			}
+		}
+		for i := range items {
+			obj, err := typed(&items[i], defaultNamespace)
+			if err != nil {
+				return nil, err
+			}
+			if obj != nil {
+				objs = append(objs, obj)
+			}
+		}
+	}
+}
+
+// typed converts u to its registered Go type, or returns nil for kinds Scheme
+// does not know.
+func typed(u *unstructured.Unstructured, defaultNamespace string) (client.Object, error) {
+	gvk := u.GroupVersionKind()
+	if !Scheme.Recognizes(gvk) {
+		return nil, nil
+	}
+	robj, err := Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, robj); err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s: %w", gvk.Kind, u.GetName(), err)
+	}
+	obj, ok := robj.(client.Object)
+	if !ok {
+		return nil, nil
+	}
+	if obj.GetNamespace() == "" && gvk.Kind != "Namespace" {
+		obj.SetNamespace(defaultNamespace)
+	}
+	return obj, nil
+}
@@ -0,0 +1,13 @@
+package objects
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestObjects(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Objects Package Suite")
+}
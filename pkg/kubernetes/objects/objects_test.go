@@ -0,0 +1,141 @@
+package objects
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const manifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-a
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: team-b
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: unknown
+`
+
+var _ = Describe("Static sources", func() {
+	ctx := context.Background()
+
+	It("serves the objects it was built from", func() {
+		src := FromObjects(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "team-a"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team-b"}},
+		)
+		pods := &corev1.PodList{}
+		Expect(src.List(ctx, pods, client.InNamespace("team-a"))).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal("a"))
+	})
+
+	It("reads manifests, flattening lists and defaulting the namespace", func() {
+		src, err := FromManifests(strings.NewReader(manifests), "team-a")
+		Expect(err).NotTo(HaveOccurred())
+
+		pods := &corev1.PodList{}
+		Expect(src.List(ctx, pods, client.InNamespace("team-a"))).To(Succeed())
+		Expect(pods.Items).To(HaveLen(1))
+
+		cms := &corev1.ConfigMapList{}
+		Expect(src.List(ctx, cms, client.InNamespace("team-b"))).To(Succeed())
+		Expect(cms.Items).To(HaveLen(1))
+
+		namespaces := &corev1.NamespaceList{}
+		Expect(src.List(ctx, namespaces)).To(Succeed())
+		Expect(namespaces.Items).To(HaveLen(1))
+		Expect(namespaces.Items[0].Namespace).To(BeEmpty())
+	})
+
+	It("rejects malformed manifests", func() {
+		_, err := FromManifests(strings.NewReader("kind: [unterminated"), "default")
+		Expect(err).To(MatchError(ContainSubstring("failed to decode manifests")))
+	})
+})
+
+var _ = Describe("Excluded owners", func() {
+	ctx := context.Background()
+	owned := func(name, apiVersion, kind string) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"}}
+		if kind != "" {
+			controller := true
+			p.OwnerReferences = []metav1.OwnerReference{
+				{APIVersion: apiVersion, Kind: kind, Name: "owner", UID: "uid", Controller: &controller},
+			}
+		}
+		return p
+	}
+
+	It("parses groups and group kinds", func() {
+		owners, err := ParseOwners([]string{"monitoring.coreos.com", "mesh.example.com/Injector"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(owners).To(Equal(Owners{
+			{Group: "monitoring.coreos.com"},
+			{Group: "mesh.example.com", Kind: "Injector"},
+		}))
+	})
+
+	It("rejects entries without a group or with extra segments", func() {
+		_, err := ParseOwners([]string{"/ReplicaSet"})
+		Expect(err).To(MatchError(ContainSubstring("names no API group")))
+		_, err = ParseOwners([]string{"apps/v1/ReplicaSet"})
+		Expect(err).To(MatchError(ContainSubstring("group or group/Kind")))
+	})
+
+	It("matches the controller owner by group, and by kind when given", func() {
+		owners := Owners{{Group: "monitoring.coreos.com"}, {Group: "apps", Kind: "DaemonSet"}}
+		Expect(owners.Owns(owned("a", "monitoring.coreos.com/v1", "Prometheus"))).To(BeTrue())
+		Expect(owners.Owns(owned("b", "apps/v1", "DaemonSet"))).To(BeTrue())
+		Expect(owners.Owns(owned("c", "apps/v1", "ReplicaSet"))).To(BeFalse())
+		Expect(owners.Owns(owned("d", "", ""))).To(BeFalse())
+
+		notController := owned("e", "monitoring.coreos.com/v1", "Prometheus")
+		notController.OwnerReferences[0].Controller = nil
+		Expect(owners.Owns(notController)).To(BeFalse())
+	})
+
+	It("lists the objects of the source except the excluded ones", func() {
+		src := ExcludeOwned(FromObjects(
+			owned("agent", "monitoring.coreos.com/v1", "Prometheus"),
+			owned("web", "apps/v1", "ReplicaSet"),
+			owned("bare", "", ""),
+		), Owners{{Group: "monitoring.coreos.com"}})
+		pods := &corev1.PodList{}
+		Expect(src.List(ctx, pods, client.InNamespace("team-a"))).To(Succeed())
+		names := []string{}
+		for _, p := range pods.Items {
+			names = append(names, p.Name)
+		}
+		Expect(names).To(ConsistOf("web", "bare"))
+	})
+
+	It("returns the source itself without owners", func() {
+		src := FromObjects()
+		Expect(ExcludeOwned(src, nil)).To(BeIdenticalTo(src))
+	})
+})
@@ -0,0 +1,100 @@
+package objects
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Owner names a controller owner whose objects no quota counts: the kind
+// Kind of the API group Group, or every kind of Group when Kind is empty.
+type Owner struct {
+	Group string
+	Kind  string
+}
+
+// Owners is an allowlist of controller owners, such as the operators of
+// platform add-ons that deploy into tenant namespaces.
+type Owners []Owner
+
+// ParseOwners parses entries such as monitoring.coreos.com, for every kind
+// of the group, or monitoring.coreos.com/Prometheus, for one kind. The core
+// group cannot be named: its kinds are what quotas are for.
+func ParseOwners(entries []string) (Owners, error) {
+	owners := make(Owners, 0, len(entries))
+	for _, entry := range entries {
+		group, kind, _ := strings.Cut(entry, "/")
+		if group == "" {
+			return nil, fmt.Errorf("owner %q names no API group", entry)
+		}
+		if strings.Contains(kind, "/") {
+			return nil, fmt.Errorf("owner %q must be a group or group/Kind", entry)
+		}
+		owners = append(owners, Owner{Group: group, Kind: kind})
+	}
+	return owners, nil
+}
+
+// Owns reports whether the controller owner of obj is in o. Only obj's own
+// owner reference is checked, not the owners of its owner.
+func (o Owners) Owns(obj metav1.Object) bool {
+	if len(o) == 0 {
+		return false
+	}
+	ref := metav1.GetControllerOfNoCopy(obj)
+	if ref == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return false
+	}
+	for _, owner := range o {
+		if owner.Group == gv.Group && (owner.Kind == "" || owner.Kind == ref.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludeOwned returns a Source listing the objects of src except those owned
+// by owners, so every calculator reading it leaves them out. It returns src
+// itself when owners is empty.
+func ExcludeOwned(src Source, owners Owners) Source {
+	if len(owners) == 0 {
+		return src
+	}
+	return &ownerFilter{src: src, owners: owners}
+}
+
+type ownerFilter struct {
+	src    Source
+	owners Owners
+}
+
+func (f *ownerFilter) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := f.src.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	kept := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(metav1.Object); ok && f.owners.Owns(obj) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) == len(items) {
+		return nil
+	}
+	return meta.SetList(list, kept)
+}
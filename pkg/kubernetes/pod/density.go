@@ -0,0 +1,24 @@
+package pod
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// Density returns the usage of a pods.density/per-cpu quota: the pods of pods
+// that count toward quota per CPU core they request, rounded up to the
+// millipod. Pods requesting no CPU between them count as requesting 1m, so a
+// namespace of such pods is as dense as it can be.
+func Density(pods []corev1.Pod) resource.Quantity {
+	count := CalculateUsageFromPods(pods, usage.ResourcePods)
+	if count.IsZero() {
+		return *resource.NewMilliQuantity(0, resource.DecimalSI)
+	}
+	cpu := CalculateUsageFromPods(pods, usage.ResourceRequestsCPU)
+	milliCPU := max(cpu.MilliValue(), 1)
+	// pods per core, in millipods: count * 1000 (milli) * 1000 (m per core).
+	milliPods := (count.Value()*1000*1000 + milliCPU - 1) / milliCPU
+	return *resource.NewMilliQuantity(milliPods, resource.DecimalSI)
+}
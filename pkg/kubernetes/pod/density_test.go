@@ -0,0 +1,39 @@
+package pod
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Density", func() {
+	podRequesting := func(cpu string) corev1.Pod {
+		container := corev1.Container{Name: "app"}
+		if cpu != "" {
+			container.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+		}
+		return corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{container}}}
+	}
+
+	DescribeTable("pods per requested CPU core",
+		func(expected string, pods ...corev1.Pod) {
+			density := Density(pods)
+			Expect(density.Cmp(resource.MustParse(expected))).To(BeZero(), "got %s", density.String())
+		},
+		Entry("no pods", "0"),
+		Entry("one pod per core", "1", podRequesting("1"), podRequesting("1")),
+		Entry("tiny pods", "20", podRequesting("50m"), podRequesting("50m")),
+		Entry("rounded up to the millipod", "1.334", podRequesting("1"), podRequesting("500m")),
+		Entry("pods without requests", "2000", podRequesting(""), podRequesting("")),
+		Entry("a pod without requests beside one with", "2", podRequesting(""), podRequesting("1")),
+	)
+
+	It("ignores pods that no longer count toward quota", func() {
+		done := podRequesting("")
+		done.Status.Phase = corev1.PodSucceeded
+		density := Density([]corev1.Pod{podRequesting("2"), done})
+		Expect(density.String()).To(Equal("500m"))
+	})
+})
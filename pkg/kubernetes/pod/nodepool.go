@@ -0,0 +1,164 @@
+package pod
+
+import (
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TargetsNodeSelectorTerms reports whether pod's scheduling constraints
+// confine it to nodes matching at least one of terms. The constraints are the
+// pod's node selector and required node affinity; the scheduler may satisfy
+// any one required affinity term, so each of them must imply a term on its
+// own. Node labels are not consulted, so a pod bound through spec.nodeName
+// alone does not match. Terms with matchFields never match. Without terms,
+// every pod matches.
+func TargetsNodeSelectorTerms(pod *corev1.Pod, terms []corev1.NodeSelectorTerm) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	for _, allowed := range nodeConstraints(pod) {
+		if !slices.ContainsFunc(terms, allowed.implies) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByNodeSelectorTerms returns the pods of pods that
+// TargetsNodeSelectorTerms, or pods itself without terms.
+func FilterByNodeSelectorTerms(pods []corev1.Pod, terms []corev1.NodeSelectorTerm) []corev1.Pod {
+	if len(terms) == 0 {
+		return pods
+	}
+	var out []corev1.Pod
+	for i := range pods {
+		if TargetsNodeSelectorTerms(&pods[i], terms) {
+			out = append(out, pods[i])
+		}
+	}
+	return out
+}
+
+// labelConstraint is what a pod requires of one node label.
+type labelConstraint struct {
+	// values lists the values the label may take; nil allows any.
+	values []string
+	// excluded lists values the label may not take.
+	excluded []string
+	exists   bool
+	absent   bool
+}
+
+// labelConstraints is what a pod requires of node labels, keyed by label.
+type labelConstraints map[string]*labelConstraint
+
+// nodeConstraints returns one labelConstraints per required node affinity
+// term of pod, each including its node selector, or only the node selector's
+// when pod has no required node affinity.
+func nodeConstraints(pod *corev1.Pod) []labelConstraints {
+	var terms []corev1.NodeSelectorTerm
+	if a := pod.Spec.Affinity; a != nil && a.NodeAffinity != nil &&
+		a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		terms = a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	}
+	if len(terms) == 0 {
+		terms = []corev1.NodeSelectorTerm{{}}
+	}
+	out := make([]labelConstraints, 0, len(terms))
+	for _, term := range terms {
+		c := make(labelConstraints)
+		for key, value := range pod.Spec.NodeSelector {
+			c.get(key).allow([]string{value})
+		}
+		for _, expr := range term.MatchExpressions {
+			lc := c.get(expr.Key)
+			switch expr.Operator {
+			case corev1.NodeSelectorOpIn:
+				lc.allow(expr.Values)
+			case corev1.NodeSelectorOpNotIn:
+				lc.excluded = append(lc.excluded, expr.Values...)
+			case corev1.NodeSelectorOpExists:
+				lc.exists = true
+			case corev1.NodeSelectorOpDoesNotExist:
+				lc.absent = true
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (c labelConstraints) get(key string) *labelConstraint {
+	if c[key] == nil {
+		c[key] = &labelConstraint{}
+	}
+	return c[key]
+}
+
+// allow narrows the values the label may take to those also in values.
+func (lc *labelConstraint) allow(values []string) {
+	lc.exists = true
+	if lc.values == nil {
+		lc.values = slices.Clone(values)
+		return
+	}
+	lc.values = slices.DeleteFunc(lc.values, func(v string) bool { return !slices.Contains(values, v) })
+}
+
+// implies reports whether every node satisfying c matches term.
+func (c labelConstraints) implies(term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchFields) > 0 || len(term.MatchExpressions) == 0 {
+		return false
+	}
+	for _, expr := range term.MatchExpressions {
+		if !c.get(expr.Key).implies(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (lc *labelConstraint) implies(expr corev1.NodeSelectorRequirement) bool {
+	switch expr.Operator {
+	case corev1.NodeSelectorOpIn:
+		return lc.values != nil && allValues(lc.values, func(v string) bool { return slices.Contains(expr.Values, v) })
+	case corev1.NodeSelectorOpNotIn:
+		// Nodes without the label satisfy NotIn too.
+		if lc.absent {
+			return true
+		}
+		if lc.values != nil && allValues(lc.values, func(v string) bool { return !slices.Contains(expr.Values, v) }) {
+			return true
+		}
+		return allValues(expr.Values, func(v string) bool { return slices.Contains(lc.excluded, v) })
+	case corev1.NodeSelectorOpExists:
+		return lc.exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return lc.absent
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if len(expr.Values) != 1 || lc.values == nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(expr.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		return allValues(lc.values, func(v string) bool {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return false
+			}
+			if expr.Operator == corev1.NodeSelectorOpGt {
+				return n > bound
+			}
+			return n < bound
+		})
+	}
+	return false
+}
+
+func allValues(values []string, f func(string) bool) bool {
+	return !slices.ContainsFunc(values, func(v string) bool { return !f(v) })
+}
@@ -0,0 +1,105 @@
+package pod
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("TargetsNodeSelectorTerms", func() {
+	req := func(key string, op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorRequirement {
+		return corev1.NodeSelectorRequirement{Key: key, Operator: op, Values: values}
+	}
+	term := func(exprs ...corev1.NodeSelectorRequirement) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: exprs}
+	}
+	podWith := func(selector map[string]string, affinity ...corev1.NodeSelectorTerm) *corev1.Pod {
+		p := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: selector}}
+		if len(affinity) > 0 {
+			p.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: affinity},
+			}}
+		}
+		return p
+	}
+	gpuPool := []corev1.NodeSelectorTerm{term(req("pool", corev1.NodeSelectorOpIn, "gpu"))}
+
+	It("matches every pod without terms", func() {
+		Expect(TargetsNodeSelectorTerms(podWith(nil), nil)).To(BeTrue())
+	})
+
+	DescribeTable("pods against pool In gpu",
+		func(p *corev1.Pod, expected bool) {
+			Expect(TargetsNodeSelectorTerms(p, gpuPool)).To(Equal(expected))
+		},
+		Entry("unconstrained", podWith(nil), false),
+		Entry("bound by nodeName only", &corev1.Pod{Spec: corev1.PodSpec{NodeName: "gpu-1"}}, false),
+		Entry("node selector", podWith(map[string]string{"pool": "gpu"}), true),
+		Entry("other node selector value", podWith(map[string]string{"pool": "general"}), false),
+		Entry("affinity In the pool", podWith(nil, term(req("pool", corev1.NodeSelectorOpIn, "gpu"))), true),
+		Entry("affinity In the pool and another",
+			podWith(nil, term(req("pool", corev1.NodeSelectorOpIn, "gpu", "general"))), false),
+		Entry("affinity narrowed by the node selector",
+			podWith(map[string]string{"pool": "gpu"}, term(req("pool", corev1.NodeSelectorOpIn, "gpu", "general"))), true),
+		Entry("every affinity term in the pool",
+			podWith(nil,
+				term(req("pool", corev1.NodeSelectorOpIn, "gpu"), req("zone", corev1.NodeSelectorOpIn, "a")),
+				term(req("pool", corev1.NodeSelectorOpIn, "gpu"))), true),
+		Entry("one affinity term outside the pool",
+			podWith(nil,
+				term(req("pool", corev1.NodeSelectorOpIn, "gpu")),
+				term(req("zone", corev1.NodeSelectorOpIn, "a"))), false),
+		Entry("affinity Exists", podWith(nil, term(req("pool", corev1.NodeSelectorOpExists))), false),
+	)
+
+	It("matches any of several terms", func() {
+		terms := []corev1.NodeSelectorTerm{
+			term(req("pool", corev1.NodeSelectorOpIn, "gpu")),
+			term(req("accelerator", corev1.NodeSelectorOpExists)),
+		}
+		Expect(TargetsNodeSelectorTerms(podWith(map[string]string{"accelerator": "a100"}), terms)).To(BeTrue())
+	})
+
+	DescribeTable("other operators",
+		func(nodeTerm corev1.NodeSelectorTerm, p *corev1.Pod, expected bool) {
+			Expect(TargetsNodeSelectorTerms(p, []corev1.NodeSelectorTerm{nodeTerm})).To(Equal(expected))
+		},
+		Entry("NotIn by a disjoint value",
+			term(req("pool", corev1.NodeSelectorOpNotIn, "gpu")), podWith(map[string]string{"pool": "general"}), true),
+		Entry("NotIn by the same exclusion",
+			term(req("pool", corev1.NodeSelectorOpNotIn, "gpu")),
+			podWith(nil, term(req("pool", corev1.NodeSelectorOpNotIn, "gpu", "tpu"))), true),
+		Entry("NotIn by an absent label",
+			term(req("pool", corev1.NodeSelectorOpNotIn, "gpu")),
+			podWith(nil, term(req("pool", corev1.NodeSelectorOpDoesNotExist))), true),
+		Entry("NotIn of an unconstrained pod",
+			term(req("pool", corev1.NodeSelectorOpNotIn, "gpu")), podWith(nil), false),
+		Entry("DoesNotExist",
+			term(req("spot", corev1.NodeSelectorOpDoesNotExist)),
+			podWith(nil, term(req("spot", corev1.NodeSelectorOpDoesNotExist))), true),
+		Entry("Gt",
+			term(req("gpus", corev1.NodeSelectorOpGt, "3")), podWith(map[string]string{"gpus": "8"}), true),
+		Entry("Gt not met",
+			term(req("gpus", corev1.NodeSelectorOpGt, "3")), podWith(map[string]string{"gpus": "2"}), false),
+		Entry("Lt",
+			term(req("gpus", corev1.NodeSelectorOpLt, "3")), podWith(map[string]string{"gpus": "2"}), true),
+		Entry("matchFields",
+			corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+				req("metadata.name", corev1.NodeSelectorOpIn, "gpu-1"),
+			}}, podWith(map[string]string{"pool": "gpu"}), false),
+		Entry("empty term", corev1.NodeSelectorTerm{}, podWith(map[string]string{"pool": "gpu"}), false),
+	)
+
+	It("filters a pod list", func() {
+		pods := []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "train"}, Spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "gpu"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+		}
+		Expect(FilterByNodeSelectorTerms(pods, nil)).To(HaveLen(2))
+		filtered := FilterByNodeSelectorTerms(pods, gpuPool)
+		Expect(filtered).To(HaveLen(1))
+		Expect(filtered[0].Name).To(Equal("train"))
+	})
+})
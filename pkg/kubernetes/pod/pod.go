@@ -1,12 +1,20 @@
 package pod
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
@@ -19,6 +27,56 @@ func IsPodTerminal(pod *corev1.Pod) bool {
 	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
 }
 
+// QuotaState classifies pod for quota accounting as of now, following the
+// core ResourceQuota pod evaluator: terminal pods are released, terminating
+// pods stay charged until their deletion grace period has elapsed.
+func QuotaState(pod *corev1.Pod, now time.Time) usage.PodQuotaState {
+	if IsPodTerminal(pod) {
+		return usage.PodQuotaStateTerminal
+	}
+	if pod.DeletionTimestamp == nil {
+		return usage.PodQuotaStateActive
+	}
+	if deadline, ok := graceDeadline(pod); ok && now.After(deadline) {
+		return usage.PodQuotaStateStuckTerminating
+	}
+	return usage.PodQuotaStateTerminating
+}
+
+// CountsTowardQuota reports whether pod is charged against `pods` and compute
+// quotas as of now. See usage.PodQuotaState.Counted.
+func CountsTowardQuota(pod *corev1.Pod, now time.Time) bool {
+	if pod == nil {
+		return false
+	}
+	return QuotaState(pod, now).Counted()
+}
+
+// NextQuotaRelease returns the earliest moment after now at which one of pods
+// stops counting toward quota on its own, when a terminating pod outlives its
+// deletion grace period. No event marks that moment, so callers must recheck.
+func NextQuotaRelease(pods []corev1.Pod, now time.Time) (time.Time, bool) {
+	var next time.Time
+	for i := range pods {
+		if QuotaState(&pods[i], now) != usage.PodQuotaStateTerminating {
+			continue
+		}
+		if deadline, ok := graceDeadline(&pods[i]); ok && (next.IsZero() || deadline.Before(next)) {
+			next = deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// graceDeadline is the end of a deleted pod's grace period, if it has one.
+func graceDeadline(pod *corev1.Pod) (time.Time, bool) {
+	if pod.DeletionTimestamp == nil || pod.DeletionGracePeriodSeconds == nil {
+		return time.Time{}, false
+	}
+	gracePeriod := time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+	return pod.DeletionTimestamp.Add(gracePeriod), true
+}
+
 // CalculatePodUsage calculates the resource usage for a single pod
 // following the Kubernetes standard: Max(sum(containers), max(initContainers)) + podOverhead.
 // It also excludes terminated containers that are no longer consuming resources.
@@ -26,6 +84,12 @@ func CalculatePodUsage(pod *corev1.Pod, resourceName corev1.ResourceName) resour
 	if pod == nil {
 		return resource.Quantity{}
 	}
+	if os, base, ok := usage.SplitOSResource(resourceName); ok {
+		if OS(pod) != os {
+			return resource.Quantity{}
+		}
+		resourceName = base
+	}
 
 	// 1. Start with Pod Overhead if specified
 	totalUsage := resource.NewQuantity(0, resource.DecimalSI)
@@ -85,11 +149,17 @@ func CalculatePodUsage(pod *corev1.Pod, resourceName corev1.ResourceName) resour
 
 // CalculateUsageFromPods calculates quota usage from an already loaded pod list.
 // It is shared by both prefetched and on-demand code paths to keep semantics aligned.
+// Only pods that CountsTowardQuota are charged, and only pods of the named
+// operating system for OS-scoped keys such as "windows.pods".
 func CalculateUsageFromPods(pods []corev1.Pod, resourceName corev1.ResourceName) resource.Quantity {
+	if os, base, ok := usage.SplitOSResource(resourceName); ok {
+		return CalculateUsageFromPods(FilterByOS(pods, os), base)
+	}
+	now := time.Now()
 	if resourceName == usage.ResourcePods {
 		var podCount int64
 		for i := range pods {
-			if !IsPodTerminal(&pods[i]) {
+			if CountsTowardQuota(&pods[i], now) {
 				podCount++
 			}
 		}
@@ -98,7 +168,7 @@ func CalculateUsageFromPods(pods []corev1.Pod, resourceName corev1.ResourceName)
 
 	totalUsage := resource.NewQuantity(0, resource.DecimalSI)
 	for i := range pods {
-		if IsPodTerminal(&pods[i]) {
+		if !CountsTowardQuota(&pods[i], now) {
 			continue
 		}
 		totalUsage.Add(CalculatePodUsage(&pods[i], resourceName))
@@ -107,8 +177,293 @@ func CalculateUsageFromPods(pods []corev1.Pod, resourceName corev1.ResourceName)
 	return *totalUsage
 }
 
+// IsExtendedResource reports whether a container resource name, such as
+// "nvidia.com/gpu", is an extended resource: one outside the kubernetes.io
+// domain. Extended resources are allocated in whole units.
+func IsExtendedResource(name corev1.ResourceName) bool {
+	s := string(name)
+	return strings.Contains(s, "/") && !strings.Contains(s, corev1.ResourceDefaultNamespacePrefix) &&
+		!strings.HasPrefix(s, corev1.DefaultResourceRequestsPrefix)
+}
+
+// IsExtendedQuotaResource reports whether the quota key resourceName, such as
+// "requests.nvidia.com/gpu" or "windows.requests.nvidia.com/gpu", charges an
+// extended resource.
+func IsExtendedQuotaResource(resourceName corev1.ResourceName) bool {
+	if _, base, ok := usage.SplitOSResource(resourceName); ok {
+		resourceName = base
+	}
+	name, _ := containerField(resourceName)
+	return name != resourceName && IsExtendedResource(name)
+}
+
+// ValidateExtendedResources returns an error naming the first container of
+// pod, init containers included, that requests or limits a fraction of an
+// extended resource. The kubelet only allocates them in whole units.
+func ValidateExtendedResources(pod *corev1.Pod) error {
+	if pod == nil {
+		return nil
+	}
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			if err := validateWholeUnits(container.Name, "requests", container.Resources.Requests); err != nil {
+				return err
+			}
+			if err := validateWholeUnits(container.Name, "limits", container.Resources.Limits); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateWholeUnits checks that the extended resources in list, the requests
+// or limits of container, are whole numbers.
+func validateWholeUnits(container, kind string, list corev1.ResourceList) error {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		if IsExtendedResource(name) {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		q := list[corev1.ResourceName(name)]
+		if _, whole := q.AsInt64(); !whole {
+			return fmt.Errorf("container %q %s %s of extended resource %s: extended resources must be whole numbers",
+				container, kind, q.String(), name)
+		}
+	}
+	return nil
+}
+
+// ValidateHugePages returns an error naming the first container of pod, init
+// containers included, whose hugepages requests differ from their limits or
+// that requests hugepages without a limit. Kubernetes requires them to be
+// equal, whatever the page size.
+func ValidateHugePages(pod *corev1.Pod) error {
+	if pod == nil {
+		return nil
+	}
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			names := make([]string, 0, len(container.Resources.Requests))
+			for name := range container.Resources.Requests {
+				if usage.IsHugePages(name) {
+					names = append(names, string(name))
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				request := container.Resources.Requests[corev1.ResourceName(name)]
+				limit, ok := container.Resources.Limits[corev1.ResourceName(name)]
+				if !ok {
+					return fmt.Errorf("container %q requests %s of %s without a limit: hugepages requests must equal limits",
+						container.Name, request.String(), name)
+				}
+				if request.Cmp(limit) != 0 {
+					return fmt.Errorf("container %q requests %s of %s but limits it to %s: "+
+						"hugepages requests must equal limits", container.Name, request.String(), name, limit.String())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CalculateExtendedUsageFromPods is CalculateUsageFromPods for an extended
+// quota resource. Usage is summed in whole units, like the kubelet allocates
+// it; a pod charging a fraction of a unit is an error rather than being
+// rounded.
+func CalculateExtendedUsageFromPods(pods []corev1.Pod, resourceName corev1.ResourceName) (resource.Quantity, error) {
+	if os, base, ok := usage.SplitOSResource(resourceName); ok {
+		return CalculateExtendedUsageFromPods(FilterByOS(pods, os), base)
+	}
+	now := time.Now()
+	var total int64
+	for i := range pods {
+		if !CountsTowardQuota(&pods[i], now) {
+			continue
+		}
+		q := CalculatePodUsage(&pods[i], resourceName)
+		units, whole := q.AsInt64()
+		if !whole {
+			return resource.Quantity{}, fmt.Errorf("pod %s/%s charges %s of %s: extended resources must be whole numbers",
+				pods[i].Namespace, pods[i].Name, q.String(), resourceName)
+		}
+		total += units
+	}
+	return *resource.NewQuantity(total, resource.DecimalSI), nil
+}
+
+// CalculateUsage lists the pods of namespace from src and returns their usage
+// of resourceName, as the controller counts it.
+func CalculateUsage(
+	ctx context.Context,
+	src objects.Source,
+	namespace string,
+	resourceName corev1.ResourceName,
+) (resource.Quantity, error) {
+	pods := &corev1.PodList{}
+	if err := src.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	switch {
+	case IsExtendedQuotaResource(resourceName):
+		return CalculateExtendedUsageFromPods(pods.Items, resourceName)
+	case resourceName == usage.ResourcePodHostPorts:
+		return *resource.NewQuantity(int64(len(DistinctHostPorts(pods.Items, nil))), resource.DecimalSI), nil
+	case resourceName == usage.ResourcePodDensity:
+		return Density(pods.Items), nil
+	}
+	return CalculateUsageFromPods(pods.Items, resourceName), nil
+}
+
+// OS returns the operating system pod runs on: spec.os.name, else its
+// kubernetes.io/os node selector, else linux, which the scheduler assumes.
+func OS(pod *corev1.Pod) string {
+	if pod.Spec.OS != nil && pod.Spec.OS.Name != "" {
+		return string(pod.Spec.OS.Name)
+	}
+	if os := pod.Spec.NodeSelector[corev1.LabelOSStable]; os != "" {
+		return os
+	}
+	return usage.OSLinux
+}
+
+// FilterByOS returns the pods of pods that run on os.
+func FilterByOS(pods []corev1.Pod, os string) []corev1.Pod {
+	var out []corev1.Pod
+	for i := range pods {
+		if OS(&pods[i]) == os {
+			out = append(out, pods[i])
+		}
+	}
+	return out
+}
+
+// HostPorts returns the host ports pod's containers and init containers bind,
+// as "<port>/<protocol>" strings. The host IP is ignored: a port bound on one
+// address of a node is unavailable to pods that need it on all of them.
+func HostPorts(pod *corev1.Pod) []string {
+	var ports []string
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			for _, port := range container.Ports {
+				if port.HostPort <= 0 {
+					continue
+				}
+				protocol := port.Protocol
+				if protocol == "" {
+					protocol = corev1.ProtocolTCP
+				}
+				ports = append(ports, fmt.Sprintf("%d/%s", port.HostPort, protocol))
+			}
+		}
+	}
+	return ports
+}
+
+// DistinctHostPorts adds the host ports of the pods that count toward quota
+// to seen, allocating it if nil, and returns it.
+func DistinctHostPorts(pods []corev1.Pod, seen map[string]bool) map[string]bool {
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	now := time.Now()
+	for i := range pods {
+		if !CountsTowardQuota(&pods[i], now) {
+			continue
+		}
+		for _, port := range HostPorts(&pods[i]) {
+			seen[port] = true
+		}
+	}
+	return seen
+}
+
+// TopologyValue returns the value of the node label key that pod is bound to.
+// nodeLabels are the labels of the node pod is scheduled on, nil before it is
+// scheduled or when the node is unknown. An unscheduled pod is attributed to
+// the value its node selector sets, or that every required node affinity term
+// pins with a single-valued In expression. ok is false when pod could land on
+// nodes with different values.
+func TopologyValue(pod *corev1.Pod, key string, nodeLabels map[string]string) (value string, ok bool) {
+	if value, ok := nodeLabels[key]; ok {
+		return value, true
+	}
+	if pod.Spec.NodeName != "" && nodeLabels != nil {
+		// Scheduled on a node without the label.
+		return "", false
+	}
+	if value, ok := pod.Spec.NodeSelector[key]; ok {
+		return value, true
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return "", false
+	}
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		termValue, pinned := "", false
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == key && expr.Operator == corev1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				termValue, pinned = expr.Values[0], true
+				break
+			}
+		}
+		if !pinned || (value != "" && termValue != value) {
+			return "", false
+		}
+		value = termValue
+	}
+	return value, value != ""
+}
+
+// OwnerKindBarePod is the owner kind reported for pods without a controller owner.
+const OwnerKindBarePod = "Pod"
+
+// OwnerKind returns the kind of workload that controls the pod. ReplicaSets
+// created by a Deployment are attributed to the Deployment (detected through
+// the pod-template-hash label, so no extra lookup is needed); pods with no
+// controller owner report OwnerKindBarePod.
+func OwnerKind(pod *corev1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return OwnerKindBarePod
+	}
+	if ref.Kind == "ReplicaSet" {
+		if _, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+			return "Deployment"
+		}
+	}
+	return ref.Kind
+}
+
+// GroupPodsByOwnerKind partitions an already loaded pod list by OwnerKind so
+// per-kind usage can be computed with CalculateUsageFromPods without relisting.
+func GroupPodsByOwnerKind(pods []corev1.Pod) map[string][]corev1.Pod {
+	if len(pods) == 0 {
+		return nil
+	}
+	groups := make(map[string][]corev1.Pod, 4)
+	for i := range pods {
+		kind := OwnerKind(&pods[i])
+		groups[kind] = append(groups[kind], pods[i])
+	}
+	return groups
+}
+
 // getContainerResourceUsage extracts the specified resource usage from a container
 func getContainerResourceUsage(container corev1.Container, resourceName corev1.ResourceName) resource.Quantity {
+	// Hugepages requests equal their limits, which requests default to.
+	if name, ok := usage.HugePagesResource(resourceName); ok {
+		if pages, ok := container.Resources.Requests[name]; ok {
+			return pages
+		}
+		return container.Resources.Limits[name]
+	}
 	switch resourceName {
 	case corev1.ResourceRequestsCPU:
 		if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
@@ -157,6 +512,211 @@ func getContainerResourceUsage(container corev1.Container, resourceName corev1.R
 	return resource.Quantity{}
 }
 
+// containerField maps a quota resource name to the container resource it is
+// read from and whether that is a limit: "limits.cpu" is the cpu limit, while
+// "requests.cpu" and bare names such as "hugepages-2Mi" are requests.
+func containerField(resourceName corev1.ResourceName) (corev1.ResourceName, bool) {
+	s := string(resourceName)
+	if name, ok := strings.CutPrefix(s, "limits."); ok {
+		return corev1.ResourceName(name), true
+	}
+	if name, ok := strings.CutPrefix(s, "requests."); ok {
+		return corev1.ResourceName(name), false
+	}
+	return resourceName, false
+}
+
+// containerSets reports whether container sets the request or limit that
+// resourceName is charged from.
+func containerSets(container *corev1.Container, resourceName corev1.ResourceName) bool {
+	name, limit := containerField(resourceName)
+	if limit {
+		_, ok := container.Resources.Limits[name]
+		return ok
+	}
+	_, ok := container.Resources.Requests[name]
+	return ok
+}
+
+// containerResource returns the request or limit of container that
+// resourceName is charged from, zero when unset.
+func containerResource(container *corev1.Container, resourceName corev1.ResourceName) resource.Quantity {
+	name, limit := containerField(resourceName)
+	if limit {
+		return container.Resources.Limits[name]
+	}
+	return container.Resources.Requests[name]
+}
+
+// setContainerResource sets the request or limit of container that
+// resourceName is charged from to q.
+func setContainerResource(container *corev1.Container, resourceName corev1.ResourceName, q resource.Quantity) {
+	name, limit := containerField(resourceName)
+	if limit {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[name] = q.DeepCopy()
+		return
+	}
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	container.Resources.Requests[name] = q.DeepCopy()
+}
+
+// MissingResources returns the first container of pod, init containers
+// included, that leaves any of resourceNames unset, with the names it leaves
+// unset. It returns "" when every container sets them all.
+func MissingResources(pod *corev1.Pod, resourceNames []corev1.ResourceName) (string, []corev1.ResourceName) {
+	if pod == nil {
+		return "", nil
+	}
+	containers := make([]*corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for i := range pod.Spec.InitContainers {
+		containers = append(containers, &pod.Spec.InitContainers[i])
+	}
+	for i := range pod.Spec.Containers {
+		containers = append(containers, &pod.Spec.Containers[i])
+	}
+	for _, container := range containers {
+		var missing []corev1.ResourceName
+		for _, resourceName := range resourceNames {
+			if !containerSets(container, resourceName) {
+				missing = append(missing, resourceName)
+			}
+		}
+		if len(missing) > 0 {
+			return container.Name, missing
+		}
+	}
+	return "", nil
+}
+
+// AssumeResources returns pod with defaults, keyed by quota resource name,
+// filled in for every container that leaves them unset, so usage can be
+// computed as if the pod had asked for them. pod itself is returned, not
+// copied, when no container is missing any.
+func AssumeResources(pod *corev1.Pod, defaults corev1.ResourceList) *corev1.Pod {
+	if pod == nil || len(defaults) == 0 {
+		return pod
+	}
+	names := make([]corev1.ResourceName, 0, len(defaults))
+	for resourceName := range defaults {
+		names = append(names, resourceName)
+	}
+	if container, _ := MissingResources(pod, names); container == "" {
+		return pod
+	}
+
+	assumed := pod.DeepCopy()
+	fill := func(containers []corev1.Container) {
+		for i := range containers {
+			c := &containers[i]
+			for resourceName, q := range defaults {
+				if !containerSets(c, resourceName) {
+					setContainerResource(c, resourceName, q)
+				}
+			}
+		}
+	}
+	fill(assumed.Spec.InitContainers)
+	fill(assumed.Spec.Containers)
+	return assumed
+}
+
+// AssumeResourcesForPods applies AssumeResources to every pod. Only the pods
+// it changes are deep-copied, so cached objects are never modified.
+func AssumeResourcesForPods(pods []corev1.Pod, defaults corev1.ResourceList) []corev1.Pod {
+	if len(pods) == 0 || len(defaults) == 0 {
+		return pods
+	}
+	out := make([]corev1.Pod, len(pods))
+	for i := range pods {
+		out[i] = *AssumeResources(&pods[i], defaults)
+	}
+	return out
+}
+
+// ChargeAtLeast returns pod with the request or limit of every container,
+// init containers included, raised to minimum, keyed by quota resource name,
+// wherever it is unset or below it. pod itself is returned, not copied, when
+// every container already asks for at least minimum.
+func ChargeAtLeast(pod *corev1.Pod, minimum corev1.ResourceList) *corev1.Pod {
+	if pod == nil || len(minimum) == 0 {
+		return pod
+	}
+	var raised *corev1.Pod
+	raise := func(containers func(*corev1.Pod) []corev1.Container) {
+		original := containers(pod)
+		for i := range original {
+			for resourceName, q := range minimum {
+				if current := containerResource(&original[i], resourceName); current.Cmp(q) >= 0 {
+					continue
+				}
+				if raised == nil {
+					raised = pod.DeepCopy()
+				}
+				setContainerResource(&containers(raised)[i], resourceName, q)
+			}
+		}
+	}
+	raise(func(p *corev1.Pod) []corev1.Container { return p.Spec.InitContainers })
+	raise(func(p *corev1.Pod) []corev1.Container { return p.Spec.Containers })
+	if raised == nil {
+		return pod
+	}
+	return raised
+}
+
+// ChargeEphemeralContainers returns pod with charge, keyed by quota resource
+// name, set on every ephemeral container that has not terminated, which then
+// counts like a regular container. Ephemeral containers cannot set requests or
+// limits themselves, so they otherwise count as zero. pod itself is returned,
+// not copied, when it has no running ephemeral container.
+func ChargeEphemeralContainers(pod *corev1.Pod, charge corev1.ResourceList) *corev1.Pod {
+	if pod == nil || len(charge) == 0 || len(pod.Spec.EphemeralContainers) == 0 {
+		return pod
+	}
+	terminated := make(map[string]bool, len(pod.Status.EphemeralContainerStatuses))
+	for _, s := range pod.Status.EphemeralContainerStatuses {
+		terminated[s.Name] = s.State.Terminated != nil
+	}
+
+	var charged *corev1.Pod
+	for _, ec := range pod.Spec.EphemeralContainers {
+		if terminated[ec.Name] {
+			continue
+		}
+		if charged == nil {
+			charged = pod.DeepCopy()
+		}
+		c := corev1.Container{Name: ec.Name}
+		for resourceName, q := range charge {
+			setContainerResource(&c, resourceName, q)
+		}
+		charged.Spec.Containers = append(charged.Spec.Containers, c)
+	}
+	if charged == nil {
+		return pod
+	}
+	return charged
+}
+
+// ChargeEphemeralContainersForPods applies ChargeEphemeralContainers to every
+// pod. Only the pods it changes are deep-copied, so cached objects are never
+// modified.
+func ChargeEphemeralContainersForPods(pods []corev1.Pod, charge corev1.ResourceList) []corev1.Pod {
+	if len(pods) == 0 || len(charge) == 0 {
+		return pods
+	}
+	out := make([]corev1.Pod, len(pods))
+	for i := range pods {
+		out[i] = *ChargeEphemeralContainers(&pods[i], charge)
+	}
+	return out
+}
+
 // SpecEqual compares two pod specs to determine if they are equivalent.
 // This is used to detect if a pod update actually changes the resource requirements.
 func SpecEqual(oldPod, newPod *corev1.Pod) bool {
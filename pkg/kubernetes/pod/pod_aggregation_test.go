@@ -129,3 +129,48 @@ var _ = Describe("CalculatePodUsage extra branches", func() {
 		Expect(CalculatePodUsage(pod, corev1.ResourceRequestsCPU).Equal(resource.MustParse("150m"))).To(BeTrue())
 	})
 })
+
+var _ = Describe("OwnerKind", func() {
+	controllerRef := func(kind string) []metav1.OwnerReference {
+		isController := true
+		return []metav1.OwnerReference{{Kind: kind, Name: "owner", Controller: &isController}}
+	}
+
+	It("reports bare pods as Pod", func() {
+		p := podWithCPU("bare", "100m", corev1.PodRunning)
+		Expect(OwnerKind(&p)).To(Equal(OwnerKindBarePod))
+	})
+
+	It("attributes ReplicaSet pods with a pod-template-hash to the Deployment", func() {
+		p := podWithCPU("web", "100m", corev1.PodRunning)
+		p.OwnerReferences = controllerRef("ReplicaSet")
+		p.Labels = map[string]string{"pod-template-hash": "abc123"}
+		Expect(OwnerKind(&p)).To(Equal("Deployment"))
+	})
+
+	It("keeps standalone ReplicaSet pods as ReplicaSet", func() {
+		p := podWithCPU("rs", "100m", corev1.PodRunning)
+		p.OwnerReferences = controllerRef("ReplicaSet")
+		Expect(OwnerKind(&p)).To(Equal("ReplicaSet"))
+	})
+
+	It("reports the controller kind for other workloads", func() {
+		p := podWithCPU("db-0", "100m", corev1.PodRunning)
+		p.OwnerReferences = controllerRef("StatefulSet")
+		Expect(OwnerKind(&p)).To(Equal("StatefulSet"))
+	})
+
+	It("groups pods by owner kind for per-kind usage", func() {
+		job := podWithCPU("job", "200m", corev1.PodRunning)
+		job.OwnerReferences = controllerRef("Job")
+		groups := GroupPodsByOwnerKind([]corev1.Pod{
+			podWithCPU("bare-a", "100m", corev1.PodRunning),
+			podWithCPU("bare-b", "100m", corev1.PodRunning),
+			job,
+		})
+		Expect(groups).To(HaveLen(2))
+		bare := CalculateUsageFromPods(groups[OwnerKindBarePod], usage.ResourceRequestsCPU)
+		Expect(bare.MilliValue()).To(Equal(int64(200)))
+		Expect(groups["Job"]).To(HaveLen(1))
+	})
+})
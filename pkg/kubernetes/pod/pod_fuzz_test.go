@@ -0,0 +1,66 @@
+package pod
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// FuzzCalculatePodUsage checks that usage math never panics and, for
+// non-negative requests, never goes negative or below any single container.
+func FuzzCalculatePodUsage(f *testing.F) {
+	f.Add("100m", "1", "250m", "10m")
+	f.Add("0", "0", "0", "0")
+	f.Add("9223372036854775807", "9223372036854775807", "1", "1")
+	f.Add("1e300", "1Ei", "0.000000001", "1n")
+	f.Add("123456789012345678901234567890", "1.5Gi", "1k", "2M")
+
+	f.Fuzz(func(t *testing.T, app1, app2, initC, overhead string) {
+		parse := func(s string) (resource.Quantity, bool) {
+			q, err := resource.ParseQuantity(s)
+			return q, err == nil && q.Sign() >= 0
+		}
+		a1, ok1 := parse(app1)
+		a2, ok2 := parse(app2)
+		in, ok3 := parse(initC)
+		oh, ok4 := parse(overhead)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			t.Skip()
+		}
+
+		container := func(name string, q resource.Quantity) corev1.Container {
+			return corev1.Container{
+				Name: name,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: q, corev1.ResourceMemory: q},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: q},
+				},
+			}
+		}
+		p := &corev1.Pod{Spec: corev1.PodSpec{
+			Containers:     []corev1.Container{container("a", a1), container("b", a2)},
+			InitContainers: []corev1.Container{container("init", in)},
+			Overhead:       corev1.ResourceList{corev1.ResourceCPU: oh},
+		}}
+
+		for _, name := range []corev1.ResourceName{
+			usage.ResourceRequestsCPU, usage.ResourceRequestsMemory, usage.ResourceLimitsCPU,
+			usage.ResourceLimitsMemory, corev1.ResourceName("windows.requests.cpu"),
+		} {
+			got := CalculatePodUsage(p, name)
+			if got.Sign() < 0 {
+				t.Fatalf("%s usage %s is negative", name, got.String())
+			}
+		}
+		got := CalculatePodUsage(p, usage.ResourceRequestsCPU)
+		for _, q := range []resource.Quantity{a1, a2, in} {
+			if got.Cmp(q) < 0 {
+				t.Fatalf("requests.cpu usage %s is below container request %s", got.String(), q.String())
+			}
+		}
+		CalculateUsageFromPods([]corev1.Pod{*p, *p}, usage.ResourceRequestsCPU)
+	})
+}
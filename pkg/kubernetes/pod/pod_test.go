@@ -1,12 +1,18 @@
 package pod
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
 var _ = Describe("Pod", func() {
@@ -61,6 +67,276 @@ var _ = Describe("Pod", func() {
 		})
 	})
 
+	Describe("QuotaState", func() {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		deletedAt := func(ago time.Duration, graceSeconds int64) *corev1.Pod {
+			ts := metav1.NewTime(now.Add(-ago))
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					DeletionTimestamp:          &ts,
+					DeletionGracePeriodSeconds: &graceSeconds,
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+		withPhase := func(phase corev1.PodPhase) *corev1.Pod {
+			return &corev1.Pod{Status: corev1.PodStatus{Phase: phase}}
+		}
+
+		DescribeTable("classifies pods like the core ResourceQuota evaluator",
+			func(p *corev1.Pod, state usage.PodQuotaState, counted bool) {
+				Expect(QuotaState(p, now)).To(Equal(state))
+				Expect(CountsTowardQuota(p, now)).To(Equal(counted))
+			},
+			Entry("pending", withPhase(corev1.PodPending), usage.PodQuotaStateActive, true),
+			Entry("unscheduled with no phase", withPhase(""), usage.PodQuotaStateActive, true),
+			Entry("running", withPhase(corev1.PodRunning), usage.PodQuotaStateActive, true),
+			Entry("unknown", withPhase(corev1.PodUnknown), usage.PodQuotaStateActive, true),
+			Entry("succeeded", withPhase(corev1.PodSucceeded), usage.PodQuotaStateTerminal, false),
+			Entry("failed", withPhase(corev1.PodFailed), usage.PodQuotaStateTerminal, false),
+			Entry("terminating within grace", deletedAt(10*time.Second, 30), usage.PodQuotaStateTerminating, true),
+			Entry("terminating past grace", deletedAt(time.Minute, 30), usage.PodQuotaStateStuckTerminating, false),
+		)
+
+		It("does not count a nil pod", func() {
+			Expect(CountsTowardQuota(nil, now)).To(BeFalse())
+		})
+
+	})
+
+	Describe("NextQuotaRelease", func() {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		terminating := func(ago time.Duration, graceSeconds int64) corev1.Pod {
+			ts := metav1.NewTime(now.Add(-ago))
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &ts, DeletionGracePeriodSeconds: &graceSeconds},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+
+		It("returns the earliest grace deadline of the pods still terminating", func() {
+			next, ok := NextQuotaRelease([]corev1.Pod{
+				{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+				terminating(10*time.Second, 60),
+				terminating(10*time.Second, 30),
+				terminating(time.Minute, 30),
+			}, now)
+			Expect(ok).To(BeTrue())
+			Expect(next).To(Equal(now.Add(20 * time.Second)))
+		})
+
+		It("reports nothing when no pod is terminating", func() {
+			_, ok := NextQuotaRelease([]corev1.Pod{{Status: corev1.PodStatus{Phase: corev1.PodRunning}}}, now)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("CalculateUsageFromPods pods count", func() {
+		It("counts pending and terminating pods but not terminal or stuck terminating ones", func() {
+			recent := metav1.NewTime(time.Now())
+			stale := metav1.NewTime(time.Now().Add(-time.Hour))
+			grace := int64(30)
+			pods := []corev1.Pod{
+				{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+				{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+				{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+				{Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+				{
+					ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &recent, DeletionGracePeriodSeconds: &grace},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &stale, DeletionGracePeriodSeconds: &grace},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+				},
+			}
+			count := CalculateUsageFromPods(pods, usage.ResourcePods)
+			Expect(count.Value()).To(Equal(int64(3)))
+		})
+	})
+
+	Describe("OS-scoped usage", func() {
+		cpuPod := func(cpu string) corev1.Pod {
+			return corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "c",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+		}
+
+		It("reads the OS from spec.os, then the node selector, defaulting to linux", func() {
+			byField := cpuPod("1")
+			byField.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+			bySelector := cpuPod("1")
+			bySelector.Spec.NodeSelector = map[string]string{corev1.LabelOSStable: "windows"}
+			Expect(OS(&byField)).To(Equal(usage.OSWindows))
+			Expect(OS(&bySelector)).To(Equal(usage.OSWindows))
+			Expect(OS(&corev1.Pod{})).To(Equal(usage.OSLinux))
+		})
+
+		It("only charges pods of the key's OS", func() {
+			windows := cpuPod("2")
+			windows.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+			pods := []corev1.Pod{windows, cpuPod("1"), cpuPod("500m")}
+
+			windowsCPU := CalculateUsageFromPods(pods, "windows.requests.cpu")
+			linuxCPU := CalculateUsageFromPods(pods, "linux.requests.cpu")
+			windowsPods := CalculateUsageFromPods(pods, "windows.pods")
+			Expect(windowsCPU.Equal(resource.MustParse("2"))).To(BeTrue())
+			Expect(linuxCPU.Equal(resource.MustParse("1500m"))).To(BeTrue())
+			Expect(windowsPods.Value()).To(Equal(int64(1)))
+
+			linuxUsage := CalculatePodUsage(&windows, "linux.requests.cpu")
+			Expect(linuxUsage.IsZero()).To(BeTrue())
+		})
+	})
+
+	Describe("Extended resources", func() {
+		gpuPod := func(name, gpus string) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "c",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(gpus)},
+					},
+				}}},
+			}
+		}
+
+		DescribeTable("recognizes extended quota keys",
+			func(resourceName corev1.ResourceName, extended bool) {
+				Expect(IsExtendedQuotaResource(resourceName)).To(Equal(extended))
+			},
+			Entry("GPU requests", corev1.ResourceName("requests.nvidia.com/gpu"), true),
+			Entry("OS-scoped GPU requests", corev1.ResourceName("windows.requests.nvidia.com/gpu"), true),
+			Entry("CPU requests", corev1.ResourceRequestsCPU, false),
+			Entry("hugepages", corev1.ResourceName("requests.hugepages-2Mi"), false),
+			Entry("kubernetes.io resources", corev1.ResourceName("requests.kubernetes.io/foo"), false),
+			Entry("object counts", corev1.ResourceName("count/deployments.apps"), false),
+		)
+
+		It("sums whole units", func() {
+			used, err := CalculateExtendedUsageFromPods(
+				[]corev1.Pod{gpuPod("a", "1"), gpuPod("b", "2")}, "requests.nvidia.com/gpu")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(used.Value()).To(Equal(int64(3)))
+		})
+
+		It("reports fractional usage instead of rounding it", func() {
+			_, err := CalculateExtendedUsageFromPods(
+				[]corev1.Pod{gpuPod("a", "1"), gpuPod("b", "500m")}, "requests.nvidia.com/gpu")
+			Expect(err).To(MatchError(ContainSubstring("pod ns/b charges 500m")))
+		})
+
+		It("rejects pods asking for a fraction of an extended resource", func() {
+			pod := gpuPod("a", "1")
+			Expect(ValidateExtendedResources(&pod)).To(Succeed())
+			pod.Spec.InitContainers = []corev1.Container{{
+				Name:      "init",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{"example.com/fpga": resource.MustParse("0.5")}},
+			}}
+			Expect(ValidateExtendedResources(&pod)).To(MatchError(ContainSubstring(`container "init" limits 500m`)))
+		})
+	})
+
+	Describe("hugepages", func() {
+		hugePagesPod := func(request, limit string) *corev1.Pod {
+			resources := corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   corev1.ResourceList{},
+			}
+			if request != "" {
+				resources.Requests["hugepages-1Gi"] = resource.MustParse(request)
+			}
+			if limit != "" {
+				resources.Limits["hugepages-1Gi"] = resource.MustParse(limit)
+			}
+			return &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: resources}}}}
+		}
+
+		It("accepts hugepages requests equal to their limits", func() {
+			Expect(ValidateHugePages(hugePagesPod("2Gi", "2Gi"))).To(Succeed())
+			Expect(ValidateHugePages(hugePagesPod("", "2Gi"))).To(Succeed())
+			Expect(ValidateHugePages(nil)).To(Succeed())
+		})
+
+		It("rejects hugepages requests that differ from their limits", func() {
+			Expect(ValidateHugePages(hugePagesPod("1Gi", "2Gi"))).To(MatchError(
+				`container "app" requests 1Gi of hugepages-1Gi but limits it to 2Gi: hugepages requests must equal limits`))
+			Expect(ValidateHugePages(hugePagesPod("1Gi", ""))).To(MatchError(ContainSubstring("without a limit")))
+		})
+
+		DescribeTable("charges hugepages of every size",
+			func(key string, want string) {
+				p := hugePagesPod("", "2Gi")
+				p.Spec.Containers[0].Resources.Limits["hugepages-2Mi"] = resource.MustParse("64Mi")
+				used := CalculatePodUsage(p, corev1.ResourceName(key))
+				Expect(used.Cmp(resource.MustParse(want))).To(BeZero(), "got %s", used.String())
+			},
+			Entry("requested 1Gi pages defaulted from their limit", "requests.hugepages-1Gi", "2Gi"),
+			Entry("bare 1Gi pages", "hugepages-1Gi", "2Gi"),
+			Entry("requested 2Mi pages", "requests.hugepages-2Mi", "64Mi"),
+			Entry("pages the pod does not use", "requests.hugepages-32Mi", "0"),
+		)
+	})
+
+	Describe("TopologyValue", func() {
+		const zoneKey = "topology.kubernetes.io/zone"
+		affinityPod := func(terms ...[]string) *corev1.Pod {
+			var selectorTerms []corev1.NodeSelectorTerm
+			for _, values := range terms {
+				selectorTerms = append(selectorTerms, corev1.NodeSelectorTerm{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: zoneKey, Operator: corev1.NodeSelectorOpIn, Values: values},
+					},
+				})
+			}
+			return &corev1.Pod{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: selectorTerms},
+				},
+			}}}
+		}
+
+		It("prefers the label of the node the pod runs on", func() {
+			p := &corev1.Pod{Spec: corev1.PodSpec{
+				NodeName:     "node-1",
+				NodeSelector: map[string]string{zoneKey: "zone-b"},
+			}}
+			value, ok := TopologyValue(p, zoneKey, map[string]string{zoneKey: "zone-a"})
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("zone-a"))
+		})
+
+		It("falls back to the node selector", func() {
+			p := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{zoneKey: "zone-b"}}}
+			value, ok := TopologyValue(p, zoneKey, nil)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("zone-b"))
+		})
+
+		It("uses required node affinity that pins a single value", func() {
+			value, ok := TopologyValue(affinityPod([]string{"zone-c"}, []string{"zone-c"}), zoneKey, nil)
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("zone-c"))
+
+			_, ok = TopologyValue(affinityPod([]string{"zone-a", "zone-b"}), zoneKey, nil)
+			Expect(ok).To(BeFalse())
+			_, ok = TopologyValue(affinityPod([]string{"zone-a"}, []string{"zone-b"}), zoneKey, nil)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("does not attribute pods that can run anywhere", func() {
+			_, ok := TopologyValue(&corev1.Pod{}, zoneKey, nil)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
 	Describe("CalculateResourceUsage", func() {
 		It("should calculate CPU requests correctly", func() {
 			pod := &corev1.Pod{
@@ -439,6 +715,170 @@ var _ = Describe("Pod", func() {
 		})
 	})
 
+	Describe("MissingResources and AssumeResources", func() {
+		newPod := func() *corev1.Pod {
+			return &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init"}},
+					Containers: []corev1.Container{{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+						},
+					}},
+				},
+			}
+		}
+
+		It("reports the first container leaving a resource unset", func() {
+			container, missing := MissingResources(newPod(),
+				[]corev1.ResourceName{corev1.ResourceRequestsCPU, corev1.ResourceLimitsMemory})
+			Expect(container).To(Equal("init"))
+			Expect(missing).To(ConsistOf(corev1.ResourceRequestsCPU, corev1.ResourceLimitsMemory))
+
+			p := newPod()
+			p.Spec.InitContainers = nil
+			container, missing = MissingResources(p, []corev1.ResourceName{corev1.ResourceRequestsCPU})
+			Expect(container).To(BeEmpty())
+			Expect(missing).To(BeEmpty())
+		})
+
+		It("fills in defaults without touching the original pod", func() {
+			original := newPod()
+			assumed := AssumeResources(original, corev1.ResourceList{
+				corev1.ResourceRequestsCPU:  resource.MustParse("100m"),
+				corev1.ResourceLimitsMemory: resource.MustParse("64Mi"),
+			})
+
+			Expect(original.Spec.InitContainers[0].Resources.Requests).To(BeEmpty())
+			Expect(assumed.Spec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("200m"))
+			Expect(assumed.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("64Mi"))
+			used := CalculatePodUsage(assumed, corev1.ResourceRequestsCPU)
+			Expect(used.String()).To(Equal("200m"))
+		})
+
+		It("returns the pod itself when nothing is missing", func() {
+			p := newPod()
+			p.Spec.InitContainers = nil
+			Expect(AssumeResources(p, corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("100m"),
+			})).To(BeIdenticalTo(p))
+		})
+	})
+
+	Describe("ChargeAtLeast", func() {
+		minimum := corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("100m")}
+		newPod := func(cpu string) *corev1.Pod {
+			return &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init"}},
+					Containers: []corev1.Container{{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+						},
+					}},
+				},
+			}
+		}
+
+		It("raises unset and smaller requests without touching the original pod", func() {
+			original := newPod("10m")
+			raised := ChargeAtLeast(original, minimum)
+
+			Expect(original.Spec.InitContainers[0].Resources.Requests).To(BeEmpty())
+			Expect(raised.Spec.InitContainers[0].Resources.Requests.Cpu().String()).To(Equal("100m"))
+			Expect(raised.Spec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("100m"))
+		})
+
+		It("returns the pod itself when every container asks for more", func() {
+			p := newPod("200m")
+			p.Spec.InitContainers = nil
+			Expect(ChargeAtLeast(p, minimum)).To(BeIdenticalTo(p))
+		})
+	})
+
+	Describe("ChargeEphemeralContainers", func() {
+		charge := corev1.ResourceList{
+			corev1.ResourceRequestsCPU:  resource.MustParse("100m"),
+			corev1.ResourceLimitsMemory: resource.MustParse("128Mi"),
+		}
+		newPod := func() *corev1.Pod {
+			return &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+						},
+					}},
+					EphemeralContainers: []corev1.EphemeralContainer{
+						{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-1"}},
+						{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-2"}},
+					},
+				},
+			}
+		}
+
+		It("charges every running ephemeral container without touching the original pod", func() {
+			original := newPod()
+			charged := ChargeEphemeralContainers(original, charge)
+
+			Expect(original.Spec.Containers).To(HaveLen(1))
+			cpu := CalculatePodUsage(charged, corev1.ResourceRequestsCPU)
+			Expect(cpu.String()).To(Equal("400m"))
+			memory := CalculatePodUsage(charged, corev1.ResourceLimitsMemory)
+			Expect(memory.String()).To(Equal("256Mi"))
+		})
+
+		It("releases the charge of terminated ephemeral containers", func() {
+			p := newPod()
+			p.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "debugger-1",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}},
+			}}
+
+			cpu := CalculatePodUsage(ChargeEphemeralContainers(p, charge), corev1.ResourceRequestsCPU)
+			Expect(cpu.String()).To(Equal("300m"))
+		})
+
+		It("returns the pod itself without a charge or ephemeral containers", func() {
+			p := newPod()
+			Expect(ChargeEphemeralContainers(p, nil)).To(BeIdenticalTo(p))
+			p.Spec.EphemeralContainers = nil
+			Expect(ChargeEphemeralContainers(p, charge)).To(BeIdenticalTo(p))
+		})
+	})
+
+	Describe("HostPorts", func() {
+		hostPortPod := func(phase corev1.PodPhase, ports ...corev1.ContainerPort) corev1.Pod {
+			return corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Ports: ports}},
+				},
+				Status: corev1.PodStatus{Phase: phase},
+			}
+		}
+
+		It("lists bound host ports with TCP as the default protocol", func() {
+			p := hostPortPod(corev1.PodRunning,
+				corev1.ContainerPort{ContainerPort: 8080, HostPort: 80},
+				corev1.ContainerPort{ContainerPort: 53, HostPort: 53, Protocol: corev1.ProtocolUDP},
+				corev1.ContainerPort{ContainerPort: 9090},
+			)
+			Expect(HostPorts(&p)).To(ConsistOf("80/TCP", "53/UDP"))
+		})
+
+		It("counts a port bound by several pods once and skips terminal pods", func() {
+			pods := []corev1.Pod{
+				hostPortPod(corev1.PodRunning, corev1.ContainerPort{HostPort: 80}),
+				hostPortPod(corev1.PodPending, corev1.ContainerPort{HostPort: 80}),
+				hostPortPod(corev1.PodSucceeded, corev1.ContainerPort{HostPort: 443}),
+			}
+			Expect(DistinctHostPorts(pods, nil)).To(Equal(map[string]bool{"80/TCP": true}))
+		})
+	})
+
 	Describe("SpecEqual", func() {
 		It("should return true for identical pod specs", func() {
 			pod1 := &corev1.Pod{
@@ -589,3 +1029,33 @@ var _ = Describe("Pod", func() {
 		})
 	})
 })
+
+var _ = Describe("CalculateUsage", func() {
+	running := func(name, namespace, cpu string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	src := objects.FromObjects(
+		running("a", "team-a", "500m"),
+		running("b", "team-a", "250m"),
+		running("c", "team-b", "4"),
+	)
+
+	It("computes the usage of the namespace from a source", func() {
+		q, err := CalculateUsage(context.Background(), src, "team-a", corev1.ResourceRequestsCPU)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Cmp(resource.MustParse("750m"))).To(Equal(0), q.String())
+
+		q, err = CalculateUsage(context.Background(), src, "team-a", usage.ResourcePods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Value()).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,114 @@
+package podmetrics
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodMetricsListGVK is the metrics-server list kind. It is read as unstructured
+// so the controller does not depend on the metrics client, and so the default
+// client reads it straight from the API server: metrics.k8s.io does not
+// support watch and cannot back an informer.
+var PodMetricsListGVK = schema.GroupVersionKind{
+	Group:   "metrics.k8s.io",
+	Version: "v1beta1",
+	Kind:    "PodMetricsList",
+}
+
+// ObservedResource maps a CRQ hard key to the metrics-server resource it is
+// compared against in Actual mode. Requests, limits and the bare name all
+// measure the same observed usage.
+func ObservedResource(resourceName corev1.ResourceName) (corev1.ResourceName, bool) {
+	switch resourceName {
+	case corev1.ResourceCPU, corev1.ResourceRequestsCPU, corev1.ResourceLimitsCPU:
+		return corev1.ResourceCPU, true
+	case corev1.ResourceMemory, corev1.ResourceRequestsMemory, corev1.ResourceLimitsMemory:
+		return corev1.ResourceMemory, true
+	}
+	return "", false
+}
+
+// NamespaceUsage sums the observed container CPU and memory of the named pods
+// in a namespace. Pods metrics-server has not scraped yet contribute nothing.
+func NamespaceUsage(
+	ctx context.Context,
+	c client.Reader,
+	namespace string,
+	podNames map[string]bool,
+) (corev1.ResourceList, error) {
+	byPod, err := PodUsage(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return SumUsage(byPod, podNames), nil
+}
+
+// SumUsage adds up the observed usage of the named pods. CPU and memory are
+// always present, zero when no named pod was scraped.
+func SumUsage(byPod map[string]corev1.ResourceList, podNames map[string]bool) corev1.ResourceList {
+	total := corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(0, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(0, resource.BinarySI),
+	}
+	for name, used := range byPod {
+		if !podNames[name] {
+			continue
+		}
+		for resourceName, q := range used {
+			sum := total[resourceName]
+			sum.Add(q)
+			total[resourceName] = sum
+		}
+	}
+	return total
+}
+
+// PodUsage returns the observed CPU and memory of every pod metrics-server has
+// scraped in a namespace, summed over each pod's containers.
+func PodUsage(ctx context.Context, c client.Reader, namespace string) (map[string]corev1.ResourceList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(PodMetricsListGVK)
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics in namespace %s: %w", namespace, err)
+	}
+
+	byPod := make(map[string]corev1.ResourceList, len(list.Items))
+	for _, item := range list.Items {
+		containers, _, err := unstructured.NestedSlice(item.Object, "containers")
+		if err != nil {
+			return nil, fmt.Errorf("malformed metrics for pod %s/%s: %w", namespace, item.GetName(), err)
+		}
+		used := corev1.ResourceList{}
+		for _, container := range containers {
+			fields, ok := container.(map[string]any)
+			if !ok {
+				continue
+			}
+			usage, _, err := unstructured.NestedStringMap(fields, "usage")
+			if err != nil {
+				return nil, fmt.Errorf("malformed metrics for pod %s/%s: %w", namespace, item.GetName(), err)
+			}
+			for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				raw, ok := usage[string(resourceName)]
+				if !ok {
+					continue
+				}
+				q, err := resource.ParseQuantity(raw)
+				if err != nil {
+					return nil, fmt.Errorf("malformed %s metric for pod %s/%s: %w", resourceName, namespace, item.GetName(), err)
+				}
+				sum := used[resourceName]
+				sum.Add(q)
+				used[resourceName] = sum
+			}
+		}
+		byPod[item.GetName()] = used
+	}
+	return byPod, nil
+}
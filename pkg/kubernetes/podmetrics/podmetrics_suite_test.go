@@ -0,0 +1,13 @@
+package podmetrics
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPodMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PodMetrics Package Suite")
+}
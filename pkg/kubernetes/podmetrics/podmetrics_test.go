@@ -0,0 +1,129 @@
+package podmetrics
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func podMetrics(name string, usages ...map[string]any) unstructured.Unstructured {
+	containers := make([]any, 0, len(usages))
+	for _, u := range usages {
+		containers = append(containers, map[string]any{"name": "c", "usage": u})
+	}
+	obj := unstructured.Unstructured{Object: map[string]any{"containers": containers}}
+	obj.SetGroupVersionKind(PodMetricsListGVK.GroupVersion().WithKind("PodMetrics"))
+	obj.SetName(name)
+	obj.SetNamespace("team-a")
+	return obj
+}
+
+func metricsClient(items []unstructured.Unstructured, listErr error) client.Client {
+	return interceptor.NewClient(fake.NewClientBuilder().Build(), interceptor.Funcs{
+		List: func(_ context.Context, _ client.WithWatch, list client.ObjectList, _ ...client.ListOption) error {
+			if listErr != nil {
+				return listErr
+			}
+			list.(*unstructured.UnstructuredList).Items = items
+			return nil
+		},
+	})
+}
+
+var _ = Describe("ObservedResource", func() {
+	DescribeTable("maps hard keys to observed resources",
+		func(name corev1.ResourceName, want corev1.ResourceName, ok bool) {
+			got, found := ObservedResource(name)
+			Expect(found).To(Equal(ok))
+			Expect(got).To(Equal(want))
+		},
+		Entry("cpu", corev1.ResourceCPU, corev1.ResourceCPU, true),
+		Entry("requests.cpu", corev1.ResourceRequestsCPU, corev1.ResourceCPU, true),
+		Entry("limits.cpu", corev1.ResourceLimitsCPU, corev1.ResourceCPU, true),
+		Entry("memory", corev1.ResourceMemory, corev1.ResourceMemory, true),
+		Entry("requests.memory", corev1.ResourceRequestsMemory, corev1.ResourceMemory, true),
+		Entry("limits.memory", corev1.ResourceLimitsMemory, corev1.ResourceMemory, true),
+		Entry("pods", corev1.ResourcePods, corev1.ResourceName(""), false),
+		Entry("requests.storage", corev1.ResourceRequestsStorage, corev1.ResourceName(""), false),
+	)
+})
+
+var _ = Describe("NamespaceUsage", func() {
+	It("sums container usage across the named pods", func() {
+		c := metricsClient([]unstructured.Unstructured{
+			podMetrics("web-1",
+				map[string]any{"cpu": "250m", "memory": "128Mi"},
+				map[string]any{"cpu": "50m", "memory": "64Mi"},
+			),
+			podMetrics("web-2", map[string]any{"cpu": "100m", "memory": "256Mi"}),
+		}, nil)
+
+		used, err := NamespaceUsage(context.Background(), c, "team-a", map[string]bool{"web-1": true, "web-2": true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used.Cpu().Cmp(resource.MustParse("400m"))).To(Equal(0))
+		Expect(used.Memory().Cmp(resource.MustParse("448Mi"))).To(Equal(0))
+	})
+
+	It("ignores metrics for pods that are not counted", func() {
+		c := metricsClient([]unstructured.Unstructured{
+			podMetrics("web-1", map[string]any{"cpu": "250m", "memory": "128Mi"}),
+			podMetrics("done-1", map[string]any{"cpu": "1", "memory": "1Gi"}),
+		}, nil)
+
+		used, err := NamespaceUsage(context.Background(), c, "team-a", map[string]bool{"web-1": true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used.Cpu().Cmp(resource.MustParse("250m"))).To(Equal(0))
+		Expect(used.Memory().Cmp(resource.MustParse("128Mi"))).To(Equal(0))
+	})
+
+	It("returns zero usage when no metrics are reported", func() {
+		used, err := NamespaceUsage(context.Background(), metricsClient(nil, nil), "team-a", map[string]bool{"web-1": true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used.Cpu().IsZero()).To(BeTrue())
+		Expect(used.Memory().IsZero()).To(BeTrue())
+	})
+
+	It("rejects unparseable quantities", func() {
+		c := metricsClient([]unstructured.Unstructured{
+			podMetrics("web-1", map[string]any{"cpu": "lots"}),
+		}, nil)
+
+		_, err := NamespaceUsage(context.Background(), c, "team-a", map[string]bool{"web-1": true})
+		Expect(err).To(MatchError(ContainSubstring("malformed cpu metric for pod team-a/web-1")))
+	})
+
+	It("wraps list errors with the namespace", func() {
+		c := metricsClient(nil, errors.New("metrics API unavailable"))
+
+		_, err := NamespaceUsage(context.Background(), c, "team-a", nil)
+		Expect(err).To(MatchError(ContainSubstring("failed to list pod metrics in namespace team-a")))
+	})
+})
+
+var _ = Describe("PodUsage", func() {
+	It("sums each pod's containers separately", func() {
+		c := metricsClient([]unstructured.Unstructured{
+			podMetrics("web-1",
+				map[string]any{"cpu": "250m", "memory": "128Mi"},
+				map[string]any{"cpu": "50m"},
+			),
+			podMetrics("web-2", map[string]any{"cpu": "100m", "memory": "256Mi"}),
+		}, nil)
+
+		byPod, err := PodUsage(context.Background(), c, "team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(byPod).To(HaveLen(2))
+		web1, web2 := byPod["web-1"], byPod["web-2"]
+		Expect(web1.Cpu().Cmp(resource.MustParse("300m"))).To(Equal(0))
+		Expect(web1.Memory().Cmp(resource.MustParse("128Mi"))).To(Equal(0))
+		Expect(web2.Cpu().Cmp(resource.MustParse("100m"))).To(Equal(0))
+	})
+})
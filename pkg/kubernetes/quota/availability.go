@@ -0,0 +1,125 @@
+package quota
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"go.uber.org/zap"
+)
+
+// CRDRediscoveryInterval is how often CRDAvailability.Run asks the API server
+// whether the ClusterResourceQuota CRD is served.
+const CRDRediscoveryInterval = 30 * time.Second
+
+// CRDAvailability tracks whether the API server serves the
+// ClusterResourceQuota CRD. Until the CRD is installed, a CRQClient using it
+// lists no quotas, so the webhooks admit everything instead of failing, and
+// they start enforcing once re-discovery finds the CRD, without a restart.
+// It is safe for concurrent use.
+type CRDAvailability struct {
+	discovery discovery.DiscoveryInterface
+	logger    *zap.Logger
+	// unavailable is inverted so that the zero value, before the first
+	// discovery, assumes the CRD is served.
+	unavailable atomic.Bool
+}
+
+// NewCRDAvailability returns a CRDAvailability asking d about the CRD.
+func NewCRDAvailability(d discovery.DiscoveryInterface, logger *zap.Logger) *CRDAvailability {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CRDAvailability{discovery: d, logger: logger.Named("crd-availability")}
+}
+
+// Available reports whether the CRD was served when last checked. A nil
+// CRDAvailability always reports true.
+func (a *CRDAvailability) Available() bool {
+	return a == nil || !a.unavailable.Load()
+}
+
+// Refresh asks the API server whether the CRD is served and returns the
+// result. When discovery fails for another reason than the group version
+// being unknown, the previous answer is kept.
+func (a *CRDAvailability) Refresh() bool {
+	if a == nil || a.discovery == nil {
+		return true
+	}
+	resources, err := a.discovery.ServerResourcesForGroupVersion(quotav1alpha1.GroupVersion.String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			a.set(false)
+		} else {
+			a.logger.Warn("Failed to discover the ClusterResourceQuota CRD", zap.Error(err))
+		}
+		return a.Available()
+	}
+	served := false
+	for _, r := range resources.APIResources {
+		if r.Name == "clusterresourcequotas" {
+			served = true
+			break
+		}
+	}
+	a.set(served)
+	return served
+}
+
+// MarkUnavailable records that the CRD is no longer served, as when the API
+// server no longer knows the kind, until the next Refresh finds it again.
+func (a *CRDAvailability) MarkUnavailable() {
+	if a != nil {
+		a.set(false)
+	}
+}
+
+// Run refreshes the availability every interval until ctx is done.
+func (a *CRDAvailability) Run(ctx context.Context, interval time.Duration) {
+	a.Refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Refresh()
+		}
+	}
+}
+
+// WaitUntilAvailable refreshes the availability every interval until the CRD
+// is served, and returns false when ctx is done first.
+func (a *CRDAvailability) WaitUntilAvailable(ctx context.Context, interval time.Duration) bool {
+	if a.Refresh() {
+		return true
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			if a.Refresh() {
+				return true
+			}
+		}
+	}
+}
+
+func (a *CRDAvailability) set(available bool) {
+	if a.unavailable.Swap(!available) == !available {
+		return
+	}
+	if available {
+		a.logger.Info("ClusterResourceQuota CRD is served; enforcing quotas")
+	} else {
+		a.logger.Info("ClusterResourceQuota CRD is not installed; admitting every request until it is")
+	}
+}
@@ -0,0 +1,111 @@
+package quota
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// crqResources is the discovery document of an API server serving the CRD.
+var crqResources = &metav1.APIResourceList{
+	GroupVersion: quotav1alpha1.GroupVersion.String(),
+	APIResources: []metav1.APIResource{{Name: "clusterresourcequotas", Kind: "ClusterResourceQuota"}},
+}
+
+var _ = Describe("CRDAvailability", func() {
+	var discovery *fakediscovery.FakeDiscovery
+
+	BeforeEach(func() {
+		discovery = &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	})
+
+	It("assumes the CRD is served until discovery says otherwise", func() {
+		Expect(NewCRDAvailability(discovery, nil).Available()).To(BeTrue())
+		Expect((*CRDAvailability)(nil).Available()).To(BeTrue())
+	})
+
+	It("follows the CRD being installed after the first discovery", func() {
+		crds := NewCRDAvailability(discovery, nil)
+		Expect(crds.Refresh()).To(BeFalse())
+		Expect(crds.Available()).To(BeFalse())
+
+		discovery.Resources = []*metav1.APIResourceList{crqResources}
+		Expect(crds.Refresh()).To(BeTrue())
+		Expect(crds.Available()).To(BeTrue())
+	})
+
+	It("keeps the previous answer when discovery fails", func() {
+		crds := NewCRDAvailability(discovery, nil)
+		Expect(crds.Refresh()).To(BeFalse())
+
+		discovery.Resources = []*metav1.APIResourceList{crqResources}
+		discovery.PrependReactor("get", "resource",
+			func(clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("connection refused")
+			})
+		Expect(crds.Refresh()).To(BeFalse())
+	})
+
+	It("returns from WaitUntilAvailable once the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(NewCRDAvailability(discovery, nil).WaitUntilAvailable(ctx, CRDRediscoveryInterval)).To(BeFalse())
+	})
+
+	Describe("in a CRQClient", func() {
+		newClient := func(listErr error) *CRQClient {
+			sch := runtime.NewScheme()
+			Expect(quotav1alpha1.AddToScheme(sch)).To(Succeed())
+			runtimeClient := fake.NewClientBuilder().WithScheme(sch).
+				WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						if listErr != nil {
+							return listErr
+						}
+						return c.List(ctx, list, opts...)
+					},
+				}).Build()
+			crqClient := NewCRQClient(runtimeClient, nil)
+			crqClient.Availability = NewCRDAvailability(discovery, nil)
+			return crqClient
+		}
+
+		It("lists no quotas while the CRD is not installed", func() {
+			crqClient := newClient(errors.New("must not list"))
+			crqClient.Availability.Refresh()
+
+			crqs, err := crqClient.ListAllCRQs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crqs).To(BeEmpty())
+		})
+
+		It("marks the CRD unavailable when the API server no longer knows the kind", func() {
+			noMatch := &meta.NoKindMatchError{
+				GroupKind: schema.GroupKind{Group: quotav1alpha1.GroupVersion.Group, Kind: "ClusterResourceQuota"},
+			}
+			crqClient := newClient(noMatch)
+
+			crqs, err := crqClient.ListAllCRQs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(crqs).To(BeEmpty())
+			Expect(crqClient.Availability.Available()).To(BeFalse())
+		})
+
+		It("still fails on other list errors", func() {
+			_, err := newClient(errors.New("etcd unavailable")).ListAllCRQs(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("etcd unavailable")))
+		})
+	})
+})
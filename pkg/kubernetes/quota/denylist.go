@@ -0,0 +1,24 @@
+package quota
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceLabelDenylist holds label selectors whose namespaces no
+// ClusterResourceQuota selects, whatever its namespaceSelector says. A
+// namespace is denied when it matches any one of them.
+type NamespaceLabelDenylist []labels.Selector
+
+// Denies returns the entry of d that ns matches, and whether there is one.
+func (d NamespaceLabelDenylist) Denies(ns *corev1.Namespace) (string, bool) {
+	if ns == nil {
+		return "", false
+	}
+	for _, selector := range d {
+		if selector.Matches(labels.Set(ns.Labels)) {
+			return selector.String(), true
+		}
+	}
+	return "", false
+}
@@ -3,8 +3,10 @@ package quota
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,7 +18,16 @@ import (
 // CRQClient encapsulates logic for working with ClusterResourceQuotas
 type CRQClient struct {
 	Client client.Client
-	logger *zap.Logger
+	// ExcludedNamespaces are never selected by any CRQ, such as the system
+	// namespaces of config.SystemNamespaces.
+	ExcludedNamespaces []string
+	// DeniedNamespaceLabels carves the namespaces whose labels it matches out
+	// of every CRQ, such as those of --namespace-label-denylist.
+	DeniedNamespaceLabels NamespaceLabelDenylist
+	// Availability, when set, makes the client list no CRQs while the CRD
+	// is not installed instead of failing.
+	Availability *CRDAvailability
+	logger       *zap.Logger
 }
 
 func NewCRQClient(c client.Client, logger *zap.Logger) *CRQClient {
@@ -34,8 +45,15 @@ func (c *CRQClient) ListAllCRQs(ctx context.Context) ([]quotav1alpha1.ClusterRes
 	if c.Client == nil {
 		return nil, fmt.Errorf("CRQClient is not configured")
 	}
+	if !c.Availability.Available() {
+		return nil, nil
+	}
 	var crqList quotav1alpha1.ClusterResourceQuotaList
 	if err := c.Client.List(ctx, &crqList); err != nil {
+		if c.Availability != nil && meta.IsNoMatchError(err) {
+			c.Availability.MarkUnavailable()
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to list ClusterResourceQuotas: %w", err)
 	}
 	return crqList.Items, nil
@@ -49,6 +67,14 @@ func (c *CRQClient) GetCRQByNamespace(
 ) (*quotav1alpha1.ClusterResourceQuota, error) {
 	correlationID := GetCorrelationID(ctx)
 
+	if entry, denied := c.DeniedNamespaceLabels.Denies(ns); denied {
+		c.logger.Info("Skipping namespace on the label denylist",
+			zap.String("correlation_id", correlationID),
+			zap.String("namespace", ns.Name),
+			zap.String("denylist_entry", entry))
+		return nil, nil
+	}
+
 	crqs, err := c.ListAllCRQs(ctx)
 	if err != nil {
 		c.logger.Error("Failed to list ClusterResourceQuotas",
@@ -100,8 +126,16 @@ func (c *CRQClient) GetCRQByNamespace(
 	return &matches[0], nil
 }
 
-// NamespaceMatchesCRQ returns true if the namespace matches the CRQ's selector.
+// NamespaceMatchesCRQ returns true if the namespace matches the CRQ's selector
+// and is not carved out by its excludeNamespaceSelector, c.ExcludedNamespaces
+// or c.DeniedNamespaceLabels.
 func (c *CRQClient) NamespaceMatchesCRQ(ns *corev1.Namespace, crq *quotav1alpha1.ClusterResourceQuota) (bool, error) {
+	if slices.Contains(c.ExcludedNamespaces, ns.Name) {
+		return false, nil
+	}
+	if _, denied := c.DeniedNamespaceLabels.Denies(ns); denied {
+		return false, nil
+	}
 	if crq.Spec.NamespaceSelector == nil {
 		return false, nil
 	}
@@ -109,7 +143,28 @@ func (c *CRQClient) NamespaceMatchesCRQ(ns *corev1.Namespace, crq *quotav1alpha1
 	if err != nil {
 		return false, err
 	}
-	return selector.Matches(labels.Set(ns.Labels)), nil
+	if !selector.Matches(labels.Set(ns.Labels)) {
+		return false, nil
+	}
+	excludeSelector, err := ExcludeNamespaceSelector(crq)
+	if err != nil {
+		return false, err
+	}
+	return excludeSelector == nil || !excludeSelector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// ExcludeNamespaceSelector converts the CRQ's excludeNamespaceSelector, returning
+// nil when it is unset. It is the single place the controller and the webhooks
+// interpret the field, so namespace selection stays consistent between them.
+func ExcludeNamespaceSelector(crq *quotav1alpha1.ClusterResourceQuota) (labels.Selector, error) {
+	if crq.Spec.ExcludeNamespaceSelector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(crq.Spec.ExcludeNamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid excludeNamespaceSelector: %w", err)
+	}
+	return selector, nil
 }
 
 // GetNamespacesFromStatus extracts the list of namespaces from the CRQ's status.
@@ -10,6 +10,7 @@ import (
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -125,6 +126,33 @@ var _ = Describe("CRQClient", func() {
 			})
 		})
 
+		Context("when the namespace is excluded from every CRQ", func() {
+			It("should return false even though the labels match", func() {
+				excluding := NewCRQClient(runtimeClient, nil)
+				excluding.ExcludedNamespaces = []string{nsDev.Name}
+				matches, err := excluding.NamespaceMatchesCRQ(nsDev, crq1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeFalse())
+			})
+		})
+
+		Context("when the namespace is on the label denylist", func() {
+			It("should return false even though the labels match", func() {
+				denying := NewCRQClient(runtimeClient, nil)
+				denying.DeniedNamespaceLabels = NamespaceLabelDenylist{
+					labels.SelectorFromSet(labels.Set{"pci": "true"}),
+					labels.SelectorFromSet(labels.Set{"env": "development"}),
+				}
+				matches, err := denying.NamespaceMatchesCRQ(nsDev, crq1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeFalse())
+
+				matches, err = denying.NamespaceMatchesCRQ(nsProd, crq2)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeTrue())
+			})
+		})
+
 		Context("when namespace labels match the CRQ selector", func() {
 			It("should return true", func() {
 				matches, err := crqClient.NamespaceMatchesCRQ(nsDev, crq1)
@@ -150,6 +178,41 @@ var _ = Describe("CRQClient", func() {
 			})
 		})
 
+		Context("when CRQ has an excludeNamespaceSelector", func() {
+			It("should return false for namespaces matched by the exclude selector", func() {
+				crqExclude := crq1.DeepCopy()
+				crqExclude.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "system"},
+				}
+				nsSystem := nsDev.DeepCopy()
+				nsSystem.Labels["tier"] = "system"
+				matches, err := crqClient.NamespaceMatchesCRQ(nsSystem, crqExclude)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeFalse())
+			})
+
+			It("should return true for selected namespaces not matched by the exclude selector", func() {
+				crqExclude := crq1.DeepCopy()
+				crqExclude.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "system"},
+				}
+				matches, err := crqClient.NamespaceMatchesCRQ(nsDev, crqExclude)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeTrue())
+			})
+
+			It("should return an error when the exclude selector is invalid", func() {
+				crqExclude := crq1.DeepCopy()
+				crqExclude.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tier", Operator: "InvalidOperator", Values: []string{"system"}},
+					},
+				}
+				_, err := crqClient.NamespaceMatchesCRQ(nsDev, crqExclude)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		Context("when CRQ selector is invalid", func() {
 			It("should return an error", func() {
 				crqInvalidSelector := crq1.DeepCopy()
@@ -177,6 +240,21 @@ var _ = Describe("CRQClient", func() {
 			})
 		})
 
+		Context("when the namespace is on the label denylist", func() {
+			BeforeEach(func() {
+				runtimeClient = fake.NewClientBuilder().WithScheme(sch).WithObjects(crq1, crq2, nsDev, nsProd).Build()
+			})
+			It("should return nil without listing the CRQs", func() {
+				crqClient.DeniedNamespaceLabels = NamespaceLabelDenylist{
+					labels.SelectorFromSet(labels.Set{"env": "development"}),
+				}
+				crqClient.Client = nil // Listing would fail.
+				crq, err := crqClient.GetCRQByNamespace(ctx, nsDev)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(crq).To(BeNil())
+			})
+		})
+
 		Context("when namespace matches multiple CRQs", func() {
 			BeforeEach(func() {
 				crqBoth := &quotav1alpha1.ClusterResourceQuota{
@@ -239,6 +317,22 @@ var _ = Describe("CRQClient", func() {
 		})
 	})
 
+	Describe("NamespaceLabelDenylist", func() {
+		It("returns the entry a namespace matches", func() {
+			denylist := NamespaceLabelDenylist{
+				labels.SelectorFromSet(labels.Set{"pci": "true"}),
+				labels.SelectorFromSet(labels.Set{"env": "production"}),
+			}
+			entry, denied := denylist.Denies(nsProd)
+			Expect(denied).To(BeTrue())
+			Expect(entry).To(Equal("env=production"))
+			_, denied = denylist.Denies(nsDev)
+			Expect(denied).To(BeFalse())
+			_, denied = NamespaceLabelDenylist(nil).Denies(nsDev)
+			Expect(denied).To(BeFalse())
+		})
+	})
+
 	Describe("GetNamespacesFromStatus", func() {
 		BeforeEach(func() {
 			// k8sClient is not strictly needed for this method
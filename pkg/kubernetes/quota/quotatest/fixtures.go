@@ -0,0 +1,104 @@
+package quotatest
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// QuotaBuilder builds a ClusterResourceQuota fixture. Quantities are parsed
+// with resource.MustParse, so a malformed one panics the test.
+type QuotaBuilder struct {
+	crq quotav1alpha1.ClusterResourceQuota
+}
+
+// Quota starts a ClusterResourceQuota fixture named name, selecting no
+// namespace until Selecting is called.
+func Quota(name string) *QuotaBuilder {
+	return &QuotaBuilder{crq: quotav1alpha1.ClusterResourceQuota{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: quotav1alpha1.GroupVersion.String(),
+			Kind:       "ClusterResourceQuota",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}}
+}
+
+// Selecting selects the namespaces carrying labels.
+func (b *QuotaBuilder) Selecting(labels map[string]string) *QuotaBuilder {
+	b.crq.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: labels}
+	return b
+}
+
+// Excluding carves the namespaces carrying labels out of the selection.
+func (b *QuotaBuilder) Excluding(labels map[string]string) *QuotaBuilder {
+	b.crq.Spec.ExcludeNamespaceSelector = &metav1.LabelSelector{MatchLabels: labels}
+	return b
+}
+
+// Hard sets the hard limit of resourceName to quantity.
+func (b *QuotaBuilder) Hard(resourceName corev1.ResourceName, quantity string) *QuotaBuilder {
+	if b.crq.Spec.Hard == nil {
+		b.crq.Spec.Hard = quotav1alpha1.ResourceList{}
+	}
+	b.crq.Spec.Hard[resourceName] = resource.MustParse(quantity)
+	return b
+}
+
+// Used records quantity of resourceName used in namespace, adding it to the
+// total usage in the status as the controller would.
+func (b *QuotaBuilder) Used(namespace string, resourceName corev1.ResourceName, quantity string) *QuotaBuilder {
+	q := resource.MustParse(quantity)
+	status := &b.crq.Status
+	if status.Total.Used == nil {
+		status.Total.Used = quotav1alpha1.ResourceList{}
+	}
+	total := status.Total.Used[resourceName]
+	total.Add(q)
+	status.Total.Used[resourceName] = total
+
+	var ns *quotav1alpha1.ResourceQuotaStatusByNamespace
+	for i := range status.Namespaces {
+		if status.Namespaces[i].Namespace == namespace {
+			ns = &status.Namespaces[i]
+		}
+	}
+	if ns == nil {
+		status.Namespaces = append(status.Namespaces, quotav1alpha1.ResourceQuotaStatusByNamespace{
+			Namespace: namespace,
+			Status:    quotav1alpha1.ResourceQuotaStatus{Used: quotav1alpha1.ResourceList{}},
+		})
+		ns = &status.Namespaces[len(status.Namespaces)-1]
+	}
+	used := ns.Status.Used[resourceName]
+	used.Add(q)
+	ns.Status.Used[resourceName] = used
+	return b
+}
+
+// Annotate sets the annotation key to value.
+func (b *QuotaBuilder) Annotate(key, value string) *QuotaBuilder {
+	if b.crq.Annotations == nil {
+		b.crq.Annotations = map[string]string{}
+	}
+	b.crq.Annotations[key] = value
+	return b
+}
+
+// Build returns the fixture. The total hard limits mirror the spec, as the
+// controller writes them. The builder can keep being used.
+func (b *QuotaBuilder) Build() *quotav1alpha1.ClusterResourceQuota {
+	crq := b.crq.DeepCopy()
+	crq.Status.Total.Hard = crq.Spec.Hard.DeepCopy()
+	return crq
+}
+
+// Namespace returns a Namespace fixture named name carrying labels.
+func Namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+}
@@ -0,0 +1,138 @@
+// Package quotatest provides in-memory fakes of the quota package's client
+// interface and of the object sources usage calculators read, with builders
+// for ClusterResourceQuota and Namespace fixtures, so code written against
+// these types can be unit tested without wiring controller-runtime fakes.
+package quotatest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+)
+
+var _ quota.CRQClientInterface = (*CRQClient)(nil)
+
+// CRQClient is an in-memory quota.CRQClientInterface. It selects namespaces
+// the way quota.CRQClient does, so a fixture that selects a namespace here
+// selects it in the controller and the webhooks too. It is safe for
+// concurrent use.
+type CRQClient struct {
+	mu       sync.Mutex
+	quotas   []quotav1alpha1.ClusterResourceQuota
+	listErr  error
+	excluded []string
+	denied   quota.NamespaceLabelDenylist
+	lists    int
+}
+
+// NewCRQClient returns a CRQClient serving copies of crqs.
+func NewCRQClient(crqs ...*quotav1alpha1.ClusterResourceQuota) *CRQClient {
+	return (&CRQClient{}).WithQuotas(crqs...)
+}
+
+// WithQuotas adds copies of crqs to the quotas c serves.
+func (c *CRQClient) WithQuotas(crqs ...*quotav1alpha1.ClusterResourceQuota) *CRQClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, crq := range crqs {
+		c.quotas = append(c.quotas, *crq.DeepCopy())
+	}
+	return c
+}
+
+// WithListError makes ListAllCRQs, and so GetCRQByNamespace, fail with err.
+// A nil err lists the quotas again.
+func (c *CRQClient) WithListError(err error) *CRQClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listErr = err
+	return c
+}
+
+// WithExcludedNamespaces makes no quota select the named namespaces, like
+// quota.CRQClient.ExcludedNamespaces.
+func (c *CRQClient) WithExcludedNamespaces(names ...string) *CRQClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.excluded = append(c.excluded, names...)
+	return c
+}
+
+// WithNamespaceLabelDenylist makes no quota select the namespaces denylist
+// matches, like quota.CRQClient.DeniedNamespaceLabels.
+func (c *CRQClient) WithNamespaceLabelDenylist(denylist quota.NamespaceLabelDenylist) *CRQClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.denied = append(c.denied, denylist...)
+	return c
+}
+
+// ListCalls returns how many times the quotas were listed.
+func (c *CRQClient) ListCalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lists
+}
+
+// ListAllCRQs returns copies of the quotas c serves.
+func (c *CRQClient) ListAllCRQs(_ context.Context) ([]quotav1alpha1.ClusterResourceQuota, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists++
+	if c.listErr != nil {
+		return nil, c.listErr
+	}
+	crqs := make([]quotav1alpha1.ClusterResourceQuota, len(c.quotas))
+	for i := range c.quotas {
+		c.quotas[i].DeepCopyInto(&crqs[i])
+	}
+	return crqs, nil
+}
+
+// GetCRQByNamespace returns the quota selecting ns, nil when none does, and
+// an error when more than one does, like quota.CRQClient.
+func (c *CRQClient) GetCRQByNamespace(
+	ctx context.Context,
+	ns *corev1.Namespace,
+) (*quotav1alpha1.ClusterResourceQuota, error) {
+	crqs, err := c.ListAllCRQs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	var found *quotav1alpha1.ClusterResourceQuota
+	for i := range crqs {
+		ok, err := c.NamespaceMatchesCRQ(ns, &crqs[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, crqs[i].Name)
+			found = &crqs[i]
+		}
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("multiple ClusterResourceQuotas select namespace %q: %v", ns.Name, matches)
+	}
+	return found, nil
+}
+
+// NamespaceMatchesCRQ reports whether crq selects ns; see
+// quota.CRQClient.NamespaceMatchesCRQ.
+func (c *CRQClient) NamespaceMatchesCRQ(ns *corev1.Namespace, crq *quotav1alpha1.ClusterResourceQuota) (bool, error) {
+	c.mu.Lock()
+	// quota.CRQClient needs no API client to select namespaces.
+	selection := quota.CRQClient{ExcludedNamespaces: c.excluded, DeniedNamespaceLabels: c.denied}
+	c.mu.Unlock()
+	return selection.NamespaceMatchesCRQ(ns, crq)
+}
+
+// GetNamespacesFromStatus returns the namespaces in the status of crq.
+func (c *CRQClient) GetNamespacesFromStatus(crq *quotav1alpha1.ClusterResourceQuota) []string {
+	return (&quota.CRQClient{}).GetNamespacesFromStatus(crq)
+}
@@ -0,0 +1,13 @@
+package quotatest
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestQuotaTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "QuotaTest Package Suite")
+}
@@ -0,0 +1,124 @@
+package quotatest
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+var _ = Describe("CRQClient", func() {
+	ctx := context.Background()
+	team := map[string]string{"team": "a"}
+
+	It("returns the quota selecting a namespace", func() {
+		c := NewCRQClient(
+			Quota("team-a").Selecting(team).Hard(usage.ResourcePods, "10").Build(),
+			Quota("team-b").Selecting(map[string]string{"team": "b"}).Build(),
+		)
+
+		crq, err := c.GetCRQByNamespace(ctx, Namespace("dev", team))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq.Name).To(Equal("team-a"))
+
+		crq, err = c.GetCRQByNamespace(ctx, Namespace("other", nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq).To(BeNil())
+		Expect(c.ListCalls()).To(Equal(2))
+	})
+
+	It("selects namespaces like the quota client", func() {
+		c := NewCRQClient(Quota("team-a").Selecting(team).Excluding(map[string]string{"sandbox": "true"}).Build()).
+			WithExcludedNamespaces("kube-system").
+			WithNamespaceLabelDenylist(quota.NamespaceLabelDenylist{labels.SelectorFromSet(labels.Set{"pci": "true"})})
+
+		crq, err := c.GetCRQByNamespace(ctx, Namespace("kube-system", team))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq).To(BeNil())
+
+		crq, err = c.GetCRQByNamespace(ctx, Namespace("play", map[string]string{"team": "a", "sandbox": "true"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq).To(BeNil())
+
+		crq, err = c.GetCRQByNamespace(ctx, Namespace("payments", map[string]string{"team": "a", "pci": "true"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq).To(BeNil())
+	})
+
+	It("reports namespaces selected by more than one quota", func() {
+		c := NewCRQClient(Quota("team-a").Selecting(team).Build(), Quota("all-teams").Selecting(team).Build())
+
+		_, err := c.GetCRQByNamespace(ctx, Namespace("dev", team))
+		Expect(err).To(MatchError(ContainSubstring("multiple ClusterResourceQuotas select namespace")))
+	})
+
+	It("fails listing with the configured error", func() {
+		c := NewCRQClient(Quota("team-a").Selecting(team).Build()).WithListError(errors.New("boom"))
+
+		_, err := c.GetCRQByNamespace(ctx, Namespace("dev", team))
+		Expect(err).To(MatchError("boom"))
+
+		c.WithListError(nil)
+		Expect(c.ListAllCRQs(ctx)).To(HaveLen(1))
+	})
+
+	It("serves copies that callers cannot change", func() {
+		c := NewCRQClient(Quota("team-a").Selecting(team).Build())
+
+		crqs, err := c.ListAllCRQs(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		crqs[0].Name = "changed"
+
+		crqs, err = c.ListAllCRQs(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crqs[0].Name).To(Equal("team-a"))
+	})
+})
+
+var _ = Describe("QuotaBuilder", func() {
+	It("sums namespace usage into the total", func() {
+		crq := Quota("team-a").
+			Hard(usage.ResourceRequestsCPU, "4").
+			Used("dev", usage.ResourceRequestsCPU, "1").
+			Used("prod", usage.ResourceRequestsCPU, "1500m").
+			Used("dev", usage.ResourceRequestsCPU, "500m").
+			Annotate("quota.powerapp.cloud/paused", "true").
+			Build()
+
+		total := crq.Status.Total.Used[usage.ResourceRequestsCPU]
+		Expect(total.String()).To(Equal("3"))
+		Expect(crq.Status.Total.Hard).To(Equal(crq.Spec.Hard))
+		Expect(NewCRQClient().GetNamespacesFromStatus(crq)).To(Equal([]string{"dev", "prod"}))
+		dev := crq.Status.Namespaces[0].Status.Used[usage.ResourceRequestsCPU]
+		Expect(dev.String()).To(Equal("1500m"))
+		Expect(crq.Paused()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Source", func() {
+	ctx := context.Background()
+
+	It("serves objects to the usage calculators", func() {
+		src := NewSource(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "dev"}})
+
+		used, err := pod.CalculateUsage(ctx, src, "dev", usage.ResourcePods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used.Value()).To(Equal(int64(1)))
+		Expect(src.ListCalls()).To(Equal(1))
+	})
+
+	It("fails listing with the configured error", func() {
+		src := NewSource().WithListError(errors.New("boom"))
+
+		_, err := pod.CalculateUsage(ctx, src, "dev", usage.ResourcePods)
+		Expect(err).To(MatchError("boom"))
+	})
+})
@@ -0,0 +1,68 @@
+package quotatest
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+)
+
+var _ objects.Source = (*Source)(nil)
+
+// Source is an objects.Source over a fixed set of objects for the usage
+// calculators, such as pod.CalculateUsage or an
+// objectcount.ObjectCountCalculator, that can be made to fail. It is safe
+// for concurrent use.
+type Source struct {
+	mu      sync.Mutex
+	objs    []client.Object
+	src     *objects.Static
+	listErr error
+	lists   int
+}
+
+// NewSource returns a Source serving objs, which must be of kinds registered
+// in objects.Scheme.
+func NewSource(objs ...client.Object) *Source {
+	return (&Source{}).WithObjects(objs...)
+}
+
+// WithObjects adds objs to the objects s serves.
+func (s *Source) WithObjects(objs ...client.Object) *Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, obj := range objs {
+		s.objs = append(s.objs, obj.DeepCopyObject().(client.Object))
+	}
+	s.src = objects.FromObjects(s.objs...)
+	return s
+}
+
+// WithListError makes List fail with err. A nil err lists the objects again.
+func (s *Source) WithListError(err error) *Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listErr = err
+	return s
+}
+
+// ListCalls returns how many times objects were listed.
+func (s *Source) ListCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists
+}
+
+// List lists the matching objects s serves.
+func (s *Source) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	s.mu.Lock()
+	s.lists++
+	src, err := s.src, s.listErr
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return src.List(ctx, list, opts...)
+}
@@ -0,0 +1,94 @@
+// Package resourceclaims calculates the quota usage of Dynamic Resource
+// Allocation ResourceClaims: how many there are and how many devices they
+// request from each device class.
+package resourceclaims
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// Supports reports whether resourceName is the ResourceClaim count or a
+// device-class key such as "gpu.example.com.deviceclass.resource.k8s.io/devices".
+func Supports(resourceName corev1.ResourceName) bool {
+	if resourceName == usage.ResourceResourceClaims {
+		return true
+	}
+	_, ok := usage.SplitDeviceClass(resourceName)
+	return ok
+}
+
+// CalculateUsage lists the ResourceClaims of namespace from src and returns
+// their usage of resourceName. A resource it cannot count fails with
+// usage.ErrUnsupportedResource.
+func CalculateUsage(
+	ctx context.Context,
+	src objects.Source,
+	namespace string,
+	resourceName corev1.ResourceName,
+) (resource.Quantity, error) {
+	if !Supports(resourceName) {
+		return resource.Quantity{}, fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName)
+	}
+	claims := &resourcev1.ResourceClaimList{}
+	if err := src.List(ctx, claims, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	return CalculateUsageFromClaims(claims.Items, resourceName), nil
+}
+
+// CalculateUsageFromClaims calculates ResourceClaim quota usage from an
+// already loaded claim list.
+func CalculateUsageFromClaims(claims []resourcev1.ResourceClaim, resourceName corev1.ResourceName) resource.Quantity {
+	var count int64
+	if resourceName == usage.ResourceResourceClaims {
+		count = int64(len(claims))
+	} else if class, ok := usage.SplitDeviceClass(resourceName); ok {
+		for i := range claims {
+			count += DeviceCounts(&claims[i])[class]
+		}
+	}
+	return *resource.NewQuantity(count, resource.DecimalSI)
+}
+
+// DeviceCounts returns how many devices claim requests from each device
+// class, counted as the core ResourceQuota evaluator does: a request for all
+// matching devices counts as the most a claim can be allocated, and a request
+// with alternatives counts the most devices any of them asks of each class.
+func DeviceCounts(claim *resourcev1.ResourceClaim) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, req := range claim.Spec.Devices.Requests {
+		if req.Exactly != nil {
+			counts[req.Exactly.DeviceClassName] += deviceCount(req.Exactly.AllocationMode, req.Exactly.Count)
+			continue
+		}
+		worst := make(map[string]int64)
+		for _, sub := range req.FirstAvailable {
+			worst[sub.DeviceClassName] = max(worst[sub.DeviceClassName], deviceCount(sub.AllocationMode, sub.Count))
+		}
+		for class, n := range worst {
+			counts[class] += n
+		}
+	}
+	return counts
+}
+
+// deviceCount is the number of devices a request in mode for count devices
+// can be allocated.
+func deviceCount(mode resourcev1.DeviceAllocationMode, count int64) int64 {
+	switch {
+	case mode == resourcev1.DeviceAllocationModeAll:
+		return resourcev1.AllocationResultsMaxSize
+	case count > 0:
+		return count
+	}
+	return 1
+}
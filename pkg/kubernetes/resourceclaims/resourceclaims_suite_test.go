@@ -0,0 +1,18 @@
+package resourceclaims
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+)
+
+func TestResourceClaims(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ResourceClaims Package Suite")
+}
+
+var _ = BeforeSuite(func() {
+	pkglogger.InitTest()
+})
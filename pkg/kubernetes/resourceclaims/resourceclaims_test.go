@@ -0,0 +1,76 @@
+package resourceclaims
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func exactly(class string, mode resourcev1.DeviceAllocationMode, count int64) resourcev1.DeviceRequest {
+	return resourcev1.DeviceRequest{Exactly: &resourcev1.ExactDeviceRequest{
+		DeviceClassName: class, AllocationMode: mode, Count: count,
+	}}
+}
+
+func claimWith(name, namespace string, requests ...resourcev1.DeviceRequest) *resourcev1.ResourceClaim {
+	return &resourcev1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       resourcev1.ResourceClaimSpec{Devices: resourcev1.DeviceClaim{Requests: requests}},
+	}
+}
+
+var _ = Describe("DeviceCounts", func() {
+	It("counts exact requests per class", func() {
+		claim := claimWith("train", "ml",
+			exactly("gpu", resourcev1.DeviceAllocationModeExactCount, 2),
+			exactly("gpu", resourcev1.DeviceAllocationModeExactCount, 0),
+			exactly("nic", resourcev1.DeviceAllocationModeExactCount, 1))
+		Expect(DeviceCounts(claim)).To(Equal(map[string]int64{"gpu": 3, "nic": 1}))
+	})
+
+	It("counts a request for all devices as the most a claim can be allocated", func() {
+		claim := claimWith("all", "ml", exactly("gpu", resourcev1.DeviceAllocationModeAll, 0))
+		Expect(DeviceCounts(claim)).To(HaveKeyWithValue("gpu", int64(resourcev1.AllocationResultsMaxSize)))
+	})
+
+	It("counts the worst alternative of a request per class", func() {
+		claim := claimWith("fallback", "ml", resourcev1.DeviceRequest{FirstAvailable: []resourcev1.DeviceSubRequest{
+			{DeviceClassName: "gpu-large", AllocationMode: resourcev1.DeviceAllocationModeExactCount, Count: 1},
+			{DeviceClassName: "gpu-small", AllocationMode: resourcev1.DeviceAllocationModeExactCount, Count: 4},
+			{DeviceClassName: "gpu-small", AllocationMode: resourcev1.DeviceAllocationModeExactCount, Count: 2},
+		}})
+		Expect(DeviceCounts(claim)).To(Equal(map[string]int64{"gpu-large": 1, "gpu-small": 4}))
+	})
+})
+
+var _ = Describe("CalculateUsage", func() {
+	src := objects.FromObjects(
+		claimWith("a", "ml", exactly("gpu", resourcev1.DeviceAllocationModeExactCount, 2)),
+		claimWith("b", "ml", exactly("gpu", resourcev1.DeviceAllocationModeExactCount, 1)),
+		claimWith("c", "other", exactly("gpu", resourcev1.DeviceAllocationModeExactCount, 8)),
+	)
+
+	It("counts the claims of the namespace", func() {
+		q, err := CalculateUsage(context.Background(), src, "ml", usage.ResourceResourceClaims)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Value()).To(Equal(int64(2)))
+	})
+
+	It("sums the devices of a class", func() {
+		q, err := CalculateUsage(context.Background(), src, "ml", usage.DeviceClassFor("gpu"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Value()).To(Equal(int64(3)))
+	})
+
+	It("rejects resources it does not count", func() {
+		_, err := CalculateUsage(context.Background(), src, "ml", corev1.ResourceRequestsCPU)
+		Expect(errors.Is(err, usage.ErrUnsupportedResource)).To(BeTrue())
+	})
+})
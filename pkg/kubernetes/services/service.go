@@ -1,11 +1,48 @@
 package services
 
 import (
-	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
+// LoadBalancerCostAnnotation on a LoadBalancer service sets how many
+// services.loadbalancers/cost-units it is charged, such as "5" for a cloud
+// load balancer five times as expensive as the cheapest one. Without it, a
+// LoadBalancer service costs one unit.
+const LoadBalancerCostAnnotation = "quota.powerapp.cloud/lb-cost-units"
+
+// CalculateUsage lists the services of namespace from src and returns their
+// usage of resourceName. A resource it cannot count fails with
+// usage.ErrUnsupportedResource.
+func CalculateUsage(
+	ctx context.Context,
+	src objects.Source,
+	namespace string,
+	resourceName corev1.ResourceName,
+) (resource.Quantity, error) {
+	switch resourceName {
+	case usage.ResourceServices, usage.ResourceServicesLoadBalancers, usage.ResourceServicesNodePorts,
+		usage.ResourceServicesIPv4, usage.ResourceServicesIPv6,
+		usage.ResourceServicesExternalIPs, usage.ResourceServicesLoadBalancerCost, usage.ResourceServicesHeadless:
+	default:
+		return resource.Quantity{}, fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName)
+	}
+	svcs := &corev1.ServiceList{}
+	if err := src.List(ctx, svcs, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	return CalculateUsageFromServices(svcs.Items, resourceName), nil
+}
+
 // CalculateUsageFromServices calculates service quota usage from an already loaded service list.
 func CalculateUsageFromServices(svcs []corev1.Service, resourceName corev1.ResourceName) resource.Quantity {
 	var count int64
@@ -25,7 +62,123 @@ func CalculateUsageFromServices(svcs []corev1.Service, resourceName corev1.Resou
 				count++
 			}
 		}
+	case usage.ResourceServicesExternalIPs:
+		for i := range svcs {
+			if len(svcs[i].Spec.ExternalIPs) > 0 {
+				count++
+			}
+		}
+	case usage.ResourceServicesHeadless:
+		for i := range svcs {
+			if IsHeadless(&svcs[i]) {
+				count++
+			}
+		}
+	case usage.ResourceServicesLoadBalancerCost:
+		total := resource.NewQuantity(0, resource.DecimalSI)
+		for i := range svcs {
+			cost, err := LoadBalancerCost(&svcs[i])
+			if err != nil {
+				// The webhook rejects such annotations where the cost is
+				// capped; elsewhere the service costs the default unit.
+				cost = *resource.NewQuantity(1, resource.DecimalSI)
+			}
+			total.Add(cost)
+		}
+		return *total
+	case usage.ResourceServicesIPv4, usage.ResourceServicesIPv6:
+		family := ipFamilyFor(resourceName)
+		for i := range svcs {
+			for _, f := range IPFamilies(&svcs[i]) {
+				if f == family {
+					count++
+				}
+			}
+		}
 	}
 
 	return *resource.NewQuantity(count, resource.DecimalSI)
 }
+
+// IsHeadless reports whether svc is a headless service, which sets
+// spec.clusterIP to None and is allocated no cluster IP.
+func IsHeadless(svc *corev1.Service) bool {
+	return svc.Spec.ClusterIP == corev1.ClusterIPNone
+}
+
+// ExcludeHeadless returns svcs without their headless services, for counting
+// the services key with --exclude-headless-services. svcs is left unchanged.
+func ExcludeHeadless(svcs []corev1.Service) []corev1.Service {
+	out := make([]corev1.Service, 0, len(svcs))
+	for i := range svcs {
+		if !IsHeadless(&svcs[i]) {
+			out = append(out, svcs[i])
+		}
+	}
+	return out
+}
+
+// LoadBalancerCost returns the services.loadbalancers/cost-units svc is
+// charged: nothing unless it is a LoadBalancer service, and otherwise the
+// LoadBalancerCostAnnotation quantity, one unit by default. An annotation that
+// is not a non-negative quantity fails, and the service costs one unit.
+func LoadBalancerCost(svc *corev1.Service) (resource.Quantity, error) {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return *resource.NewQuantity(0, resource.DecimalSI), nil
+	}
+	one := *resource.NewQuantity(1, resource.DecimalSI)
+	value, ok := svc.Annotations[LoadBalancerCostAnnotation]
+	if !ok {
+		return one, nil
+	}
+	cost, err := resource.ParseQuantity(strings.TrimSpace(value))
+	if err != nil {
+		return one, fmt.Errorf("invalid %s annotation %q: %w", LoadBalancerCostAnnotation, value, err)
+	}
+	if cost.Sign() < 0 {
+		return one, fmt.Errorf("invalid %s annotation %q: cost must not be negative", LoadBalancerCostAnnotation, value)
+	}
+	return cost, nil
+}
+
+// IPFamilies returns the families of the cluster IPs allocated to svc, read
+// from spec.clusterIPs. Before the API server has allocated them, it falls
+// back to spec.ipFamilies. Headless and ExternalName services have none.
+func IPFamilies(svc *corev1.Service) []corev1.IPFamily {
+	if svc.Spec.Type == corev1.ServiceTypeExternalName || IsHeadless(svc) {
+		return nil
+	}
+	if len(svc.Spec.ClusterIPs) == 0 {
+		return svc.Spec.IPFamilies
+	}
+	families := make([]corev1.IPFamily, 0, len(svc.Spec.ClusterIPs))
+	for _, ip := range svc.Spec.ClusterIPs {
+		parsed := net.ParseIP(ip)
+		switch {
+		case parsed == nil:
+		case parsed.To4() != nil:
+			families = append(families, corev1.IPv4Protocol)
+		default:
+			families = append(families, corev1.IPv6Protocol)
+		}
+	}
+	return families
+}
+
+// FamilyResource returns the quota key counting cluster IPs of family.
+func FamilyResource(family corev1.IPFamily) (corev1.ResourceName, bool) {
+	switch family {
+	case corev1.IPv4Protocol:
+		return usage.ResourceServicesIPv4, true
+	case corev1.IPv6Protocol:
+		return usage.ResourceServicesIPv6, true
+	}
+	return "", false
+}
+
+func ipFamilyFor(resourceName corev1.ResourceName) corev1.IPFamily {
+	if resourceName == usage.ResourceServicesIPv6 {
+		return corev1.IPv6Protocol
+	}
+	return corev1.IPv4Protocol
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("CalculateUsageFromServices", func() {
@@ -32,6 +36,54 @@ var _ = Describe("CalculateUsageFromServices", func() {
 		Expect(q.Value()).To(Equal(int64(1)))
 	})
 
+	It("counts the cluster IPs of each family", func() {
+		svcs := []corev1.Service{
+			{Spec: corev1.ServiceSpec{ClusterIPs: []string{"10.96.0.1"}}},
+			{Spec: corev1.ServiceSpec{ClusterIPs: []string{"10.96.0.2", "fd00::2"}}},
+			{Spec: corev1.ServiceSpec{IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol}}},
+			{Spec: corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone, ClusterIPs: []string{corev1.ClusterIPNone},
+				IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol}}},
+			{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName,
+				IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol}}},
+		}
+		ipv4 := CalculateUsageFromServices(svcs, usage.ResourceServicesIPv4)
+		ipv6 := CalculateUsageFromServices(svcs, usage.ResourceServicesIPv6)
+		Expect(ipv4.Value()).To(Equal(int64(2)))
+		Expect(ipv6.Value()).To(Equal(int64(2)))
+	})
+
+	It("counts the services with external IPs", func() {
+		svcs := makeServices()
+		svcs[0].Spec.ExternalIPs = []string{"203.0.113.10", "203.0.113.11"}
+		q := CalculateUsageFromServices(svcs, usage.ResourceServicesExternalIPs)
+		Expect(q.Value()).To(Equal(int64(1)))
+	})
+
+	It("counts the headless services", func() {
+		svcs := makeServices()
+		svcs[0].Spec.ClusterIP = corev1.ClusterIPNone
+		q := CalculateUsageFromServices(svcs, usage.ResourceServicesHeadless)
+		Expect(q.Value()).To(Equal(int64(1)))
+
+		q = CalculateUsageFromServices(ExcludeHeadless(svcs), usage.ResourceServices)
+		Expect(q.Value()).To(Equal(int64(3)))
+		Expect(svcs).To(HaveLen(4))
+	})
+
+	It("sums the cost units of LoadBalancer services", func() {
+		svcs := makeServices()
+		svcs = append(svcs,
+			corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{LoadBalancerCostAnnotation: "2.5"}},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
+			corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{LoadBalancerCostAnnotation: "lots"}},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
+			corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{LoadBalancerCostAnnotation: "9"}},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort}},
+		)
+		q := CalculateUsageFromServices(svcs, usage.ResourceServicesLoadBalancerCost)
+		Expect(q.String()).To(Equal("4500m"))
+	})
+
 	It("returns zero for unsupported resource names", func() {
 		q := CalculateUsageFromServices(makeServices(), corev1.ResourceName("unsupported"))
 		Expect(q.Value()).To(Equal(int64(0)))
@@ -42,3 +94,60 @@ var _ = Describe("CalculateUsageFromServices", func() {
 		Expect(q.Value()).To(Equal(int64(0)))
 	})
 })
+
+var _ = Describe("CalculateUsage", func() {
+	src := objects.FromObjects(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb", Namespace: "team-a"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"}},
+	)
+
+	It("counts the services of the namespace from a source", func() {
+		q, err := CalculateUsage(context.Background(), src, "team-a", usage.ResourceServicesLoadBalancers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Value()).To(Equal(int64(1)))
+	})
+
+	It("rejects resources it does not count", func() {
+		_, err := CalculateUsage(context.Background(), src, "team-a", usage.ResourceConfigMaps)
+		Expect(err).To(MatchError(usage.ErrUnsupportedResource))
+	})
+})
+
+var _ = Describe("LoadBalancerCost", func() {
+	lb := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		}
+	}
+
+	It("costs a LoadBalancer one unit by default", func() {
+		cost, err := LoadBalancerCost(lb(nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cost.Value()).To(Equal(int64(1)))
+	})
+
+	It("costs other services nothing", func() {
+		svc := lb(map[string]string{LoadBalancerCostAnnotation: "3"})
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		cost, err := LoadBalancerCost(svc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cost.IsZero()).To(BeTrue())
+	})
+
+	It("reads the annotation", func() {
+		cost, err := LoadBalancerCost(lb(map[string]string{LoadBalancerCostAnnotation: " 3 "}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cost.Value()).To(Equal(int64(3)))
+	})
+
+	It("fails on an annotation that is not a non-negative quantity", func() {
+		_, err := LoadBalancerCost(lb(map[string]string{LoadBalancerCostAnnotation: "-2"}))
+		Expect(err).To(MatchError(ContainSubstring("must not be negative")))
+		_, err = LoadBalancerCost(lb(map[string]string{LoadBalancerCostAnnotation: "cheap"}))
+		Expect(err).To(HaveOccurred())
+	})
+})
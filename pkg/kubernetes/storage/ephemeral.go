@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+)
+
+// EphemeralPVCs returns the PVCs the generic ephemeral volumes of p declare,
+// named <pod>-<volume> in the pod's namespace as the ephemeral volume
+// controller creates them.
+func EphemeralPVCs(p *corev1.Pod) []corev1.PersistentVolumeClaim {
+	if p == nil {
+		return nil
+	}
+	var pvcs []corev1.PersistentVolumeClaim
+	for _, volume := range p.Spec.Volumes {
+		if volume.Ephemeral == nil || volume.Ephemeral.VolumeClaimTemplate == nil {
+			continue
+		}
+		template := volume.Ephemeral.VolumeClaimTemplate
+		meta := *template.ObjectMeta.DeepCopy()
+		meta.Name = p.Name + "-" + volume.Name
+		meta.Namespace = p.Namespace
+		pvcs = append(pvcs, corev1.PersistentVolumeClaim{
+			ObjectMeta: meta,
+			Spec:       *template.Spec.DeepCopy(),
+		})
+	}
+	return pvcs
+}
+
+// PendingEphemeralPVCs returns the PVCs the generic ephemeral volumes of pods
+// declare that are not in pvcs yet. Between a pod's admission and the
+// ephemeral volume controller creating its claims, they are charged through
+// these. Pods that no longer count toward quota at now are skipped.
+func PendingEphemeralPVCs(
+	pods []corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+	now time.Time,
+) []corev1.PersistentVolumeClaim {
+	var existing map[string]bool
+	var pending []corev1.PersistentVolumeClaim
+	for i := range pods {
+		if !pod.CountsTowardQuota(&pods[i], now) {
+			continue
+		}
+		for _, claim := range EphemeralPVCs(&pods[i]) {
+			if existing == nil {
+				existing = make(map[string]bool, len(pvcs))
+				for j := range pvcs {
+					existing[pvcs[j].Name] = true
+				}
+			}
+			if !existing[claim.Name] {
+				pending = append(pending, claim)
+			}
+		}
+	}
+	return pending
+}
+
+// IsEphemeralPVC reports whether pvc was created for a generic ephemeral
+// volume: it is controlled by a Pod and named after it.
+func IsEphemeralPVC(pvc *corev1.PersistentVolumeClaim) bool {
+	if pvc == nil {
+		return false
+	}
+	owner := metav1.GetControllerOf(pvc)
+	return owner != nil && owner.APIVersion == "v1" && owner.Kind == "Pod" &&
+		strings.HasPrefix(pvc.Name, owner.Name+"-")
+}
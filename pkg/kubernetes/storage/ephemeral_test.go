@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+)
+
+// ephemeralPod builds a pod in team-a with a generic ephemeral volume per
+// entry of sizes, named after the map key, in storage class gold.
+func ephemeralPod(name string, sizes map[string]string) *corev1.Pod {
+	gold := "gold"
+	p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"}}
+	for volume, size := range sizes {
+		p.Spec.Volumes = append(p.Spec.Volumes, corev1.Volume{
+			Name: volume,
+			VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+				VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &gold,
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+						},
+					},
+				},
+			}},
+		})
+	}
+	p.Spec.Volumes = append(p.Spec.Volumes, corev1.Volume{
+		Name:         "scratch",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	return p
+}
+
+var _ = Describe("Generic ephemeral volumes", func() {
+	now := time.Now()
+
+	It("declares a claim per ephemeral volume, named after the pod and the volume", func() {
+		pvcs := EphemeralPVCs(ephemeralPod("web", map[string]string{"cache": "1Gi"}))
+		Expect(pvcs).To(HaveLen(1))
+		Expect(pvcs[0].Name).To(Equal("web-cache"))
+		Expect(pvcs[0].Namespace).To(Equal("team-a"))
+		Expect(PVCStorageClass(&pvcs[0])).To(Equal("gold"))
+		request := GetPVCStorageRequest(&pvcs[0])
+		Expect(request.String()).To(Equal("1Gi"))
+		Expect(EphemeralPVCs(nil)).To(BeEmpty())
+	})
+
+	It("keeps only the claims not created yet, of pods that count toward quota", func() {
+		done := ephemeralPod("done", map[string]string{"cache": "1Gi"})
+		done.Status.Phase = corev1.PodSucceeded
+		pods := []corev1.Pod{
+			*ephemeralPod("web", map[string]string{"cache": "1Gi", "data": "2Gi"}),
+			*done,
+		}
+		existing := []corev1.PersistentVolumeClaim{pvc("web-cache", "1Gi", "gold")}
+
+		pending := PendingEphemeralPVCs(pods, existing, now)
+		Expect(pending).To(HaveLen(1))
+		Expect(pending[0].Name).To(Equal("web-data"))
+	})
+
+	It("charges pending claims in the namespace usage", func() {
+		src := objects.FromObjects(ephemeralPod("web", map[string]string{"cache": "1Gi"}))
+		q, err := CalculateUsage(context.Background(), src, "team-a", "gold.storageclass.storage.k8s.io/requests.storage")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.String()).To(Equal("1Gi"))
+	})
+
+	It("recognizes the claims the ephemeral volume controller creates", func() {
+		claim := pvc("web-cache", "1Gi", "")
+		Expect(IsEphemeralPVC(&claim)).To(BeFalse())
+
+		controller := true
+		claim.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: "v1", Kind: "Pod", Name: "web", Controller: &controller},
+		}
+		Expect(IsEphemeralPVC(&claim)).To(BeTrue())
+
+		claim.OwnerReferences[0].Name = "api"
+		Expect(IsEphemeralPVC(&claim)).To(BeFalse())
+	})
+})
@@ -1,10 +1,57 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
+// CalculateUsage lists the PersistentVolumeClaims of namespace from src and
+// returns their usage of resourceName, including the claims the generic
+// ephemeral volumes of its pods declare but that do not exist yet. A resource
+// it cannot count fails with usage.ErrUnsupportedResource.
+func CalculateUsage(
+	ctx context.Context,
+	src objects.Source,
+	namespace string,
+	resourceName corev1.ResourceName,
+) (resource.Quantity, error) {
+	key := usage.ParseQuotaKey(resourceName)
+	if key.OS != "" ||
+		(key.Resource != corev1.ResourceRequestsStorage && key.Resource != usage.ResourcePersistentVolumeClaims) {
+		return resource.Quantity{}, fmt.Errorf("%w: %s", usage.ErrUnsupportedResource, resourceName)
+	}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := src.List(ctx, pvcs, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	pods := &corev1.PodList{}
+	if err := src.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		return resource.Quantity{}, err
+	}
+	pvcs.Items = append(pvcs.Items, PendingEphemeralPVCs(pods.Items, pvcs.Items, time.Now())...)
+	if key.VolumeAttributesClass != "" {
+		pvcs.Items = FilterByVolumeAttributesClass(pvcs.Items, key.VolumeAttributesClass)
+	}
+	switch {
+	case key.StorageClass == "" && key.Resource == usage.ResourcePersistentVolumeClaims:
+		return CalculatePVCCountUsageFromPVCs(pvcs.Items), nil
+	case key.StorageClass == "":
+		return CalculateStorageUsageFromPVCs(pvcs.Items, key.Resource), nil
+	case key.Resource == usage.ResourcePersistentVolumeClaims:
+		count := CalculateStorageClassCountFromPVCs(pvcs.Items, key.StorageClass)
+		return *resource.NewQuantity(count, resource.DecimalSI), nil
+	}
+	return CalculateStorageClassUsageFromPVCs(pvcs.Items, key.StorageClass), nil
+}
+
 // CalculateStorageUsageFromPVCs calculates requests.storage usage from an already loaded pvc list.
 func CalculateStorageUsageFromPVCs(
 	pvcs []corev1.PersistentVolumeClaim,
@@ -52,6 +99,15 @@ func CalculateStorageClassCountFromPVCs(pvcs []corev1.PersistentVolumeClaim, sto
 	return count
 }
 
+// RetainAnnotation set to "true" on a PersistentVolumeClaim denies its
+// deletion when PVC deletion protection is enabled.
+const RetainAnnotation = "quota.powerapp.cloud/retain"
+
+// PVCRetained reports whether pvc carries RetainAnnotation set to "true".
+func PVCRetained(pvc *corev1.PersistentVolumeClaim) bool {
+	return pvc != nil && pvc.Annotations[RetainAnnotation] == "true"
+}
+
 // PVCMatchesStorageClass checks storage class name using both spec field and legacy annotation.
 func PVCMatchesStorageClass(pvc *corev1.PersistentVolumeClaim, storageClass string) bool {
 	if pvc == nil {
@@ -82,6 +138,29 @@ func PVCStorageClass(pvc *corev1.PersistentVolumeClaim) string {
 	return ""
 }
 
+// PVCVolumeAttributesClass returns the volume attributes class pvc asks for
+// in spec.volumeAttributesClassName, or "" when it asks for none. A claim
+// being modified is charged to the class it asks for, not the one its volume
+// still has.
+func PVCVolumeAttributesClass(pvc *corev1.PersistentVolumeClaim) string {
+	if pvc == nil || pvc.Spec.VolumeAttributesClassName == nil {
+		return ""
+	}
+	return *pvc.Spec.VolumeAttributesClassName
+}
+
+// FilterByVolumeAttributesClass returns the pvcs of pvcs asking for the volume
+// attributes class class.
+func FilterByVolumeAttributesClass(pvcs []corev1.PersistentVolumeClaim, class string) []corev1.PersistentVolumeClaim {
+	var filtered []corev1.PersistentVolumeClaim
+	for i := range pvcs {
+		if PVCVolumeAttributesClass(&pvcs[i]) == class {
+			filtered = append(filtered, pvcs[i])
+		}
+	}
+	return filtered
+}
+
 // GetPVCStorageRequest extracts the storage request from a PersistentVolumeClaim.
 // If no storage request is specified, it returns a zero quantity.
 // This follows the same logic as Kubernetes ResourceQuota for storage calculation.
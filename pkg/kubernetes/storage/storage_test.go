@@ -1,8 +1,14 @@
 package storage
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -264,4 +270,62 @@ var _ = Describe("Storage pure helpers", func() {
 			Expect(storageRequest.Value()).To(Equal(int64(0)))
 		})
 	})
+
+	Describe("PVCRetained", func() {
+		It("is true only for the annotation set to \"true\"", func() {
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(PVCRetained(pvc)).To(BeFalse())
+			Expect(PVCRetained(nil)).To(BeFalse())
+
+			pvc.Annotations = map[string]string{RetainAnnotation: "false"}
+			Expect(PVCRetained(pvc)).To(BeFalse())
+
+			pvc.Annotations[RetainAnnotation] = "true"
+			Expect(PVCRetained(pvc)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("CalculateUsage", func() {
+	gold := "gold"
+	pvc := func(name, namespace string, class *string, size string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: class,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+		}
+	}
+	fast := "fast"
+	logs := pvc("logs", "team-a", nil, "5Gi")
+	logs.Spec.VolumeAttributesClassName = &fast
+	src := objects.FromObjects(
+		pvc("data", "team-a", &gold, "10Gi"),
+		logs,
+		pvc("other", "team-b", &gold, "100Gi"),
+	)
+
+	DescribeTable("computes the usage of the namespace from a source",
+		func(resourceName corev1.ResourceName, want string) {
+			q, err := CalculateUsage(context.Background(), src, "team-a", resourceName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.Cmp(resource.MustParse(want))).To(Equal(0), q.String())
+		},
+		Entry("requested storage", corev1.ResourceRequestsStorage, "15Gi"),
+		Entry("claims", corev1.ResourceName("persistentvolumeclaims"), "2"),
+		Entry("storage of a class", corev1.ResourceName("gold.storageclass.storage.k8s.io/requests.storage"), "10Gi"),
+		Entry("claims of a class", corev1.ResourceName("gold.storageclass.storage.k8s.io/persistentvolumeclaims"), "1"),
+		Entry("storage of an attributes class",
+			corev1.ResourceName("fast.volumeattributesclass.storage.k8s.io/requests.storage"), "5Gi"),
+		Entry("claims of an attributes class",
+			corev1.ResourceName("fast.volumeattributesclass.storage.k8s.io/persistentvolumeclaims"), "1"),
+	)
+
+	It("rejects resources it does not count", func() {
+		_, err := CalculateUsage(context.Background(), src, "team-a", corev1.ResourceRequestsCPU)
+		Expect(err).To(MatchError(usage.ErrUnsupportedResource))
+	})
 })
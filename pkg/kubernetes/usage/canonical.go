@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Canonical returns q in the one format used for resourceName in status, so
+// the same amount always reads the same whatever arithmetic produced it:
+// cpu in millicores ("1500m", "2"), memory, storage, ephemeral storage and
+// hugepages in bytes with a binary suffix ("1152Mi" rather than "1.125Gi"
+// or "1207959552"), and anything else as a decimal number, rounded up to a
+// thousandth when fractional.
+func Canonical(resourceName corev1.ResourceName, q resource.Quantity) resource.Quantity {
+	base := GetBaseResourceName(ParseQuotaKey(resourceName).Resource)
+	switch {
+	case base == corev1.ResourceCPU:
+		return *resource.NewMilliQuantity(q.MilliValue(), resource.DecimalSI)
+	case base == corev1.ResourceMemory, base == corev1.ResourceStorage, base == corev1.ResourceEphemeralStorage,
+		strings.HasPrefix(string(base), corev1.ResourceHugePagesPrefix):
+		return *resource.NewQuantity(q.Value(), resource.BinarySI)
+	}
+	if q.MilliValue()%1000 != 0 {
+		return *resource.NewMilliQuantity(q.MilliValue(), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(q.Value(), resource.DecimalSI)
+}
+
+// CanonicalList returns a copy of list with every quantity in its Canonical
+// format, or nil for a nil list.
+func CanonicalList(list corev1.ResourceList) corev1.ResourceList {
+	if list == nil {
+		return nil
+	}
+	canonical := make(corev1.ResourceList, len(list))
+	for name, q := range list {
+		canonical[name] = Canonical(name, q)
+	}
+	return canonical
+}
@@ -0,0 +1,47 @@
+package usage
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Canonical", func() {
+	DescribeTable("formats each resource one way",
+		func(name corev1.ResourceName, quantity, expected string) {
+			canonical := Canonical(name, resource.MustParse(quantity))
+			Expect(canonical.String()).To(Equal(expected))
+		},
+		Entry("cpu in millicores", corev1.ResourceRequestsCPU, "1.5", "1500m"),
+		Entry("whole cpus", corev1.ResourceLimitsCPU, "2000m", "2"),
+		Entry("OS-scoped cpu", corev1.ResourceName("windows.requests.cpu"), "0.25", "250m"),
+		Entry("memory from a decimal fraction", corev1.ResourceRequestsMemory, "1.125Gi", "1152Mi"),
+		Entry("memory from bytes", corev1.ResourceLimitsMemory, "1207959552", "1152Mi"),
+		Entry("memory from a decimal suffix", corev1.ResourceRequestsMemory, "1G", "1000000000"),
+		Entry("storage", corev1.ResourceRequestsStorage, "2048Mi", "2Gi"),
+		Entry("class-scoped storage",
+			corev1.ResourceName("gold.storageclass.storage.k8s.io/requests.storage"), "0.5Gi", "512Mi"),
+		Entry("ephemeral storage", corev1.ResourceRequestsEphemeralStorage, "1024Ki", "1Mi"),
+		Entry("hugepages", corev1.ResourceName("requests.hugepages-2Mi"), "4194304", "4Mi"),
+		Entry("counts", corev1.ResourcePods, "1000", "1k"),
+		Entry("fractional extended resources", corev1.ResourceName("requests.example.com/foo"), "1.5", "1500m"),
+	)
+
+	It("gives equal amounts the same form", func() {
+		a := Canonical(corev1.ResourceRequestsMemory, resource.MustParse("1.125Gi"))
+		b := Canonical(corev1.ResourceRequestsMemory, resource.MustParse("1152Mi"))
+		Expect(a).To(Equal(b))
+	})
+
+	It("formats lists", func() {
+		sum := resource.NewQuantity(1207959552, resource.DecimalSI)
+		Expect(sum.String()).To(Equal("1207959552"))
+
+		list := CanonicalList(corev1.ResourceList{corev1.ResourceRequestsMemory: *sum})
+		memory := list[corev1.ResourceRequestsMemory]
+		Expect(memory.String()).To(Equal("1152Mi"))
+		Expect(CanonicalList(nil)).To(BeNil())
+	})
+})
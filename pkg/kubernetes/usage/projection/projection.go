@@ -0,0 +1,221 @@
+// Package projection answers "current usage plus a candidate object: would it
+// exceed the quota?" for both the admission webhooks and the controller, so a
+// request is charged exactly as the controller will later count it.
+package projection
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// Projection is the usage of one resource once a request is admitted.
+type Projection struct {
+	Resource corev1.ResourceName
+	// Used is the usage before the request.
+	Used resource.Quantity
+	// Requested is what the request adds. It is negative for a request that
+	// frees usage, such as a namespace leaving the quota.
+	Requested resource.Quantity
+	Hard      resource.Quantity
+}
+
+// Project returns the projection of requested onto used against hard.
+func Project(resourceName corev1.ResourceName, used, requested, hard resource.Quantity) Projection {
+	return Projection{Resource: resourceName, Used: used, Requested: requested, Hard: hard}
+}
+
+// Total returns the usage once the request is admitted. Usage never drops
+// below zero, whatever the request frees.
+func (p Projection) Total() resource.Quantity {
+	total := p.Used.DeepCopy()
+	total.Add(p.Requested)
+	if total.Sign() < 0 {
+		return resource.Quantity{Format: total.Format}
+	}
+	return total
+}
+
+// Exceeds reports whether the total is over the hard limit.
+func (p Projection) Exceeds() bool {
+	total := p.Total()
+	return total.Cmp(p.Hard) > 0
+}
+
+// Exceeded returns, sorted, the keys of hard that used is over. Keys without
+// usage are never exceeded.
+func Exceeded(hard, used quotav1alpha1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+	for resourceName, limit := range hard {
+		if q, ok := used[resourceName]; ok && q.Cmp(limit) > 0 {
+			exceeded = append(exceeded, resourceName)
+		}
+	}
+	slices.Sort(exceeded)
+	return exceeded
+}
+
+// PodDelta returns what admitting candidate in place of old adds to the usage
+// of resourceName, counted by the same rules the controller aggregates pods
+// with: init containers, overhead, OS-scoped keys and pods that no longer
+// count toward quota. old is nil for a new pod. The delta is negative when
+// candidate charges less than old.
+func PodDelta(candidate, old *corev1.Pod, resourceName corev1.ResourceName) resource.Quantity {
+	if candidate == nil {
+		return resource.Quantity{}
+	}
+	delta := pod.CalculateUsageFromPods([]corev1.Pod{*candidate}, resourceName)
+	if old != nil {
+		delta.Sub(pod.CalculateUsageFromPods([]corev1.Pod{*old}, resourceName))
+	}
+	return delta
+}
+
+// ChargedPods returns pods as crq charges them: only those confined to
+// spec.nodeSelectorTerms, with spec.missingRequests defaults assumed and
+// spec.ephemeralContainers set on running ephemeral containers.
+func ChargedPods(crq *quotav1alpha1.ClusterResourceQuota, pods []corev1.Pod) []corev1.Pod {
+	pods = pod.FilterByNodeSelectorTerms(pods, crq.Spec.NodeSelectorTerms)
+	if policy := crq.Spec.MissingRequests; policy != nil && policy.Action == quotav1alpha1.MissingRequestsAssume {
+		pods = pod.AssumeResourcesForPods(pods, corev1.ResourceList(policy.Defaults))
+	}
+	return pod.ChargeEphemeralContainersForPods(pods, corev1.ResourceList(crq.Spec.EphemeralContainers))
+}
+
+// Weigh returns q, counted for resourceName, as crq charges it: multiplied by
+// the spec.usageWeights entry of resourceName, if any, and rounded up to the
+// milli-unit. Usage in status and requests at admission go through Weigh
+// alike, so both are compared against spec.hard in the same weighted units.
+func Weigh(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	resourceName corev1.ResourceName,
+	q resource.Quantity,
+) resource.Quantity {
+	weight, ok := crq.Spec.UsageWeights[resourceName]
+	if !ok {
+		return q
+	}
+	return multiply(q, weight)
+}
+
+// multiply returns q times factor, rounded up to the milli-unit, or q itself
+// when the product overflows.
+func multiply(q, factor resource.Quantity) resource.Quantity {
+	milli := new(big.Int).Mul(big.NewInt(q.MilliValue()), big.NewInt(factor.MilliValue()))
+	// Euclidean division floors for a positive divisor; negate around it to
+	// round up instead.
+	milli.Neg(milli).Div(milli, big.NewInt(1000)).Neg(milli)
+	if !milli.IsInt64() {
+		return q
+	}
+	return *resource.NewMilliQuantity(milli.Int64(), q.Format)
+}
+
+// ownerMinimumResources are the keys an owner policy's container minimum may
+// set.
+var ownerMinimumResources = []corev1.ResourceName{
+	usage.ResourceRequestsCPU, usage.ResourceRequestsMemory, usage.ResourceRequestsEphemeralStorage,
+	usage.ResourceLimitsCPU, usage.ResourceLimitsMemory, usage.ResourceLimitsEphemeralStorage,
+}
+
+// ValidateOwnerPolicy returns an error describing what is wrong with policy:
+// a missing kind, a charge factor that is not positive, or a container
+// minimum on a key other than a cpu, memory or ephemeral-storage request or
+// limit.
+func ValidateOwnerPolicy(policy quotav1alpha1.OwnerPodPolicy) error {
+	if policy.Kind == "" {
+		return errors.New("kind is required")
+	}
+	if policy.ChargeFactor != nil && policy.ChargeFactor.Sign() <= 0 {
+		return fmt.Errorf("chargeFactor %s must be positive", policy.ChargeFactor.String())
+	}
+	for resourceName, q := range policy.ContainerMinimum {
+		if !slices.Contains(ownerMinimumResources, resourceName) {
+			return fmt.Errorf("containerMinimum %s is not a cpu, memory or ephemeral-storage request or limit",
+				resourceName)
+		}
+		if q.Sign() < 0 {
+			return fmt.Errorf("containerMinimum %s must not be negative", resourceName)
+		}
+	}
+	return nil
+}
+
+// OwnerPolicy returns the first valid policy of policies matching the kind,
+// and the API group when the policy sets one, of p's controller owner. It
+// returns nil for pods without a controller owner or matching no policy.
+func OwnerPolicy(policies []quotav1alpha1.OwnerPodPolicy, p *corev1.Pod) *quotav1alpha1.OwnerPodPolicy {
+	if len(policies) == 0 || p == nil {
+		return nil
+	}
+	owner := metav1.GetControllerOfNoCopy(p)
+	if owner == nil {
+		return nil
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return nil
+	}
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Kind != owner.Kind || (policy.APIGroup != "" && policy.APIGroup != gv.Group) {
+			continue
+		}
+		if ValidateOwnerPolicy(*policy) == nil {
+			return policy
+		}
+	}
+	return nil
+}
+
+// ApplyOwnerPolicy returns p as the policy matching its controller owner
+// charges it: every container request and limit multiplied by the policy's
+// charge factor, then raised to its container minimum, so each container is
+// charged the larger of the two. p itself is returned, not copied, when no
+// policy matches or the policy changes nothing.
+func ApplyOwnerPolicy(policies []quotav1alpha1.OwnerPodPolicy, p *corev1.Pod) *corev1.Pod {
+	policy := OwnerPolicy(policies, p)
+	if policy == nil {
+		return p
+	}
+	if policy.ChargeFactor != nil && policy.ChargeFactor.Cmp(resource.MustParse("1")) != 0 {
+		scaled := p.DeepCopy()
+		for _, containers := range [][]corev1.Container{scaled.Spec.InitContainers, scaled.Spec.Containers} {
+			for i := range containers {
+				scaleResources(containers[i].Resources.Requests, *policy.ChargeFactor)
+				scaleResources(containers[i].Resources.Limits, *policy.ChargeFactor)
+			}
+		}
+		p = scaled
+	}
+	return pod.ChargeAtLeast(p, corev1.ResourceList(policy.ContainerMinimum))
+}
+
+// ApplyOwnerPolicies applies ApplyOwnerPolicy to every pod. Only the pods it
+// changes are deep-copied, so cached objects are never modified.
+func ApplyOwnerPolicies(policies []quotav1alpha1.OwnerPodPolicy, pods []corev1.Pod) []corev1.Pod {
+	if len(pods) == 0 || len(policies) == 0 {
+		return pods
+	}
+	out := make([]corev1.Pod, len(pods))
+	for i := range pods {
+		out[i] = *ApplyOwnerPolicy(policies, &pods[i])
+	}
+	return out
+}
+
+func scaleResources(list corev1.ResourceList, factor resource.Quantity) {
+	for name, q := range list {
+		list[name] = multiply(q, factor)
+	}
+}
@@ -0,0 +1,218 @@
+package projection
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+func newPod(name string, cpu string, initCPU string) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if initCPU != "" {
+		p.Spec.InitContainers = []corev1.Container{{
+			Name: "init",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(initCPU)},
+			},
+		}}
+	}
+	return p
+}
+
+var _ = Describe("Projection", func() {
+	It("adds the request to the usage", func() {
+		p := Project(usage.ResourceRequestsCPU, resource.MustParse("1"), resource.MustParse("500m"),
+			resource.MustParse("2"))
+		total := p.Total()
+		Expect(total.String()).To(Equal("1500m"))
+		Expect(p.Exceeds()).To(BeFalse())
+	})
+
+	It("exceeds only when the total is over the limit", func() {
+		atLimit := Project(usage.ResourcePods, resource.MustParse("9"), resource.MustParse("1"),
+			resource.MustParse("10"))
+		Expect(atLimit.Exceeds()).To(BeFalse())
+		over := Project(usage.ResourcePods, resource.MustParse("10"), resource.MustParse("1"),
+			resource.MustParse("10"))
+		Expect(over.Exceeds()).To(BeTrue())
+	})
+
+	It("never projects usage below zero", func() {
+		p := Project(usage.ResourcePods, resource.MustParse("2"), resource.MustParse("-5"), resource.MustParse("10"))
+		total := p.Total()
+		Expect(total.IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Exceeded", func() {
+	It("lists the sorted keys whose usage is over the limit", func() {
+		hard := quotav1alpha1.ResourceList{
+			usage.ResourcePods:           resource.MustParse("2"),
+			usage.ResourceRequestsCPU:    resource.MustParse("1"),
+			usage.ResourceServices:       resource.MustParse("1"),
+			usage.ResourceLimitsMemory:   resource.MustParse("1Gi"),
+			usage.ResourceRequestsMemory: resource.MustParse("1Gi"),
+		}
+		used := quotav1alpha1.ResourceList{
+			usage.ResourcePods:         resource.MustParse("3"),
+			usage.ResourceRequestsCPU:  resource.MustParse("2"),
+			usage.ResourceServices:     resource.MustParse("1"),
+			usage.ResourceLimitsMemory: resource.MustParse("512Mi"),
+		}
+		Expect(Exceeded(hard, used)).To(Equal([]corev1.ResourceName{usage.ResourcePods, usage.ResourceRequestsCPU}))
+	})
+})
+
+var _ = Describe("PodDelta", func() {
+	It("charges a new pod as the controller counts it", func() {
+		candidate := newPod("web", "100m", "2")
+		delta := PodDelta(candidate, nil, usage.ResourceRequestsCPU)
+		counted := pod.CalculateUsageFromPods([]corev1.Pod{*candidate}, usage.ResourceRequestsCPU)
+		Expect(delta.Cmp(counted)).To(Equal(0))
+		Expect(delta.Cmp(resource.MustParse("2"))).To(Equal(0))
+	})
+
+	It("charges an update the difference from the old pod", func() {
+		delta := PodDelta(newPod("web", "300m", ""), newPod("web", "100m", ""), usage.ResourceRequestsCPU)
+		Expect(delta.Cmp(resource.MustParse("200m"))).To(Equal(0))
+	})
+
+	It("charges a new pod once and an update nothing for the pod count", func() {
+		created := PodDelta(newPod("web", "100m", ""), nil, usage.ResourcePods)
+		Expect(created.Value()).To(Equal(int64(1)))
+		updated := PodDelta(newPod("web", "200m", ""), newPod("web", "100m", ""), usage.ResourcePods)
+		Expect(updated.IsZero()).To(BeTrue())
+	})
+
+	It("charges nothing for a pod that no longer counts toward quota", func() {
+		done := newPod("job", "1", "")
+		done.Status.Phase = corev1.PodSucceeded
+		delta := PodDelta(done, nil, usage.ResourceRequestsCPU)
+		Expect(delta.IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("ChargedPods", func() {
+	It("assumes the missing requests defaults", func() {
+		crq := &quotav1alpha1.ClusterResourceQuota{Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+			MissingRequests: &quotav1alpha1.MissingRequestsPolicy{
+				Action:   quotav1alpha1.MissingRequestsAssume,
+				Defaults: quotav1alpha1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+			},
+		}}
+		bare := newPod("bare", "100m", "")
+		bare.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+		charged := ChargedPods(crq, []corev1.Pod{*bare})
+		used := pod.CalculateUsageFromPods(charged, usage.ResourceRequestsCPU)
+		Expect(used.Cmp(resource.MustParse("250m"))).To(Equal(0))
+	})
+})
+
+var _ = Describe("Weigh", func() {
+	crq := &quotav1alpha1.ClusterResourceQuota{Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+		UsageWeights: quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("500m"),
+			corev1.ResourcePods:        resource.MustParse("2"),
+			"count/jobs.batch":         resource.MustParse("333m"),
+		},
+	}}
+
+	It("scales usage by the weight of its key", func() {
+		cpu := Weigh(crq, corev1.ResourceRequestsCPU, resource.MustParse("3"))
+		Expect(cpu.String()).To(Equal("1500m"))
+		pods := Weigh(crq, corev1.ResourcePods, resource.MustParse("4"))
+		Expect(pods.Value()).To(Equal(int64(8)))
+	})
+
+	It("leaves keys without a weight as counted", func() {
+		memory := Weigh(crq, corev1.ResourceRequestsMemory, resource.MustParse("1Gi"))
+		Expect(memory.String()).To(Equal("1Gi"))
+	})
+
+	It("rounds up, whatever the sign", func() {
+		jobs := Weigh(crq, "count/jobs.batch", resource.MustParse("1"))
+		Expect(jobs.MilliValue()).To(Equal(int64(333)))
+		freed := Weigh(crq, corev1.ResourceRequestsCPU, resource.MustParse("-1m"))
+		Expect(freed.MilliValue()).To(Equal(int64(0)))
+		charged := Weigh(crq, corev1.ResourceRequestsCPU, resource.MustParse("1m"))
+		Expect(charged.MilliValue()).To(Equal(int64(1)))
+	})
+})
+
+var _ = Describe("ApplyOwnerPolicy", func() {
+	owned := func(apiVersion, kind string, cpu string) *corev1.Pod {
+		p := newPod("worker", cpu, "")
+		controller := true
+		p.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: apiVersion, Kind: kind, Name: "job", Controller: &controller,
+		}}
+		return p
+	}
+	half := resource.MustParse("500m")
+	policies := []quotav1alpha1.OwnerPodPolicy{
+		{Kind: "SparkApplication", APIGroup: "sparkoperator.k8s.io", ChargeFactor: &half},
+		{Kind: "Workflow", ContainerMinimum: quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("100m"),
+		}},
+	}
+	cpuOf := func(p *corev1.Pod) string {
+		used := pod.CalculatePodUsage(p, usage.ResourceRequestsCPU)
+		return used.String()
+	}
+
+	It("scales pods of an owner kind by its charge factor", func() {
+		p := owned("sparkoperator.k8s.io/v1beta2", "SparkApplication", "3")
+		Expect(cpuOf(ApplyOwnerPolicy(policies, p))).To(Equal("1500m"))
+		Expect(cpuOf(p)).To(Equal("3"))
+	})
+
+	It("charges tiny containers of an owner kind the container minimum", func() {
+		Expect(cpuOf(ApplyOwnerPolicy(policies, owned("argoproj.io/v1alpha1", "Workflow", "5m")))).To(Equal("100m"))
+		Expect(cpuOf(ApplyOwnerPolicy(policies, owned("argoproj.io/v1alpha1", "Workflow", "2")))).To(Equal("2"))
+	})
+
+	It("leaves pods of other kinds, groups and without a controller alone", func() {
+		other := owned("apps/v1", "ReplicaSet", "1")
+		Expect(ApplyOwnerPolicy(policies, other)).To(BeIdenticalTo(other))
+		otherGroup := owned("example.com/v1", "SparkApplication", "1")
+		Expect(ApplyOwnerPolicy(policies, otherGroup)).To(BeIdenticalTo(otherGroup))
+		bare := newPod("bare", "1", "")
+		Expect(ApplyOwnerPolicy(policies, bare)).To(BeIdenticalTo(bare))
+	})
+
+	It("skips invalid policies", func() {
+		zero := resource.MustParse("0")
+		invalid := []quotav1alpha1.OwnerPodPolicy{{Kind: "SparkApplication", ChargeFactor: &zero}}
+		p := owned("sparkoperator.k8s.io/v1beta2", "SparkApplication", "1")
+		Expect(ApplyOwnerPolicy(invalid, p)).To(BeIdenticalTo(p))
+	})
+})
+
+var _ = Describe("ValidateOwnerPolicy", func() {
+	It("rejects a missing kind, a non-positive factor and minimums on other keys", func() {
+		zero := resource.MustParse("0")
+		Expect(ValidateOwnerPolicy(quotav1alpha1.OwnerPodPolicy{})).To(MatchError(ContainSubstring("kind")))
+		Expect(ValidateOwnerPolicy(quotav1alpha1.OwnerPodPolicy{Kind: "Workflow", ChargeFactor: &zero})).
+			To(MatchError(ContainSubstring("chargeFactor")))
+		podMinimum := quotav1alpha1.OwnerPodPolicy{Kind: "Workflow", ContainerMinimum: quotav1alpha1.ResourceList{
+			usage.ResourcePods: resource.MustParse("1"),
+		}}
+		Expect(ValidateOwnerPolicy(podMinimum)).To(MatchError(ContainSubstring("containerMinimum pods")))
+		Expect(ValidateOwnerPolicy(quotav1alpha1.OwnerPodPolicy{Kind: "Workflow"})).To(Succeed())
+	})
+})
@@ -0,0 +1,162 @@
+package usage
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// storageClassInfix separates the storage class from the resource in a
+// class-scoped quota key, as in "gold.storageclass.storage.k8s.io/requests.storage".
+const storageClassInfix = ".storageclass.storage.k8s.io/"
+
+// volumeAttributesClassInfix separates the volume attributes class from the
+// resource in a class-scoped quota key, as in
+// "fast.volumeattributesclass.storage.k8s.io/requests.storage".
+const volumeAttributesClassInfix = ".volumeattributesclass.storage.k8s.io/"
+
+// QuotaKey is a quota key split into its scope and the resource it limits.
+// At most one of OS, StorageClass and VolumeAttributesClass is set.
+type QuotaKey struct {
+	// OS is the operating system an OS-scoped key such as
+	// "windows.requests.cpu" limits, or "".
+	OS string
+	// StorageClass is the storage class a class-scoped key such as
+	// "gold.storageclass.storage.k8s.io/requests.storage" limits, or "".
+	StorageClass string
+	// VolumeAttributesClass is the volume attributes class a class-scoped key
+	// such as "fast.volumeattributesclass.storage.k8s.io/requests.storage"
+	// limits, or "".
+	VolumeAttributesClass string
+	// Resource is the key without its scope, such as "requests.cpu" or
+	// "persistentvolumeclaims".
+	Resource corev1.ResourceName
+}
+
+// ParseQuotaKey splits resourceName into its OS, storage-class or volume
+// attributes class scope and the resource it limits. Keys without a scope come
+// back with only Resource set.
+func ParseQuotaKey(resourceName corev1.ResourceName) QuotaKey {
+	if class, base, ok := splitClass(resourceName, storageClassInfix); ok {
+		return QuotaKey{StorageClass: class, Resource: base}
+	}
+	if class, base, ok := splitClass(resourceName, volumeAttributesClassInfix); ok {
+		return QuotaKey{VolumeAttributesClass: class, Resource: base}
+	}
+	if os, base, ok := SplitOSResource(resourceName); ok {
+		return QuotaKey{OS: os, Resource: base}
+	}
+	return QuotaKey{Resource: resourceName}
+}
+
+// ResourceName formats k back into a quota key.
+func (k QuotaKey) ResourceName() corev1.ResourceName {
+	switch {
+	case k.StorageClass != "":
+		return StorageClassFor(k.StorageClass, k.Resource)
+	case k.VolumeAttributesClass != "":
+		return VolumeAttributesClassFor(k.VolumeAttributesClass, k.Resource)
+	case k.OS != "":
+		return corev1.ResourceName(k.OS + "." + string(k.Resource))
+	}
+	return k.Resource
+}
+
+// StorageClassFor returns the quota key limiting resourceName, which is
+// requests.storage or persistentvolumeclaims, for claims of class only.
+func StorageClassFor(class string, resourceName corev1.ResourceName) corev1.ResourceName {
+	return corev1.ResourceName(class + storageClassInfix + string(resourceName))
+}
+
+// VolumeAttributesClassFor returns the quota key limiting resourceName, which
+// is requests.storage or persistentvolumeclaims, for claims asking for the
+// volume attributes class only.
+func VolumeAttributesClassFor(class string, resourceName corev1.ResourceName) corev1.ResourceName {
+	return corev1.ResourceName(class + volumeAttributesClassInfix + string(resourceName))
+}
+
+// IsClassScoped reports whether resourceName limits a single storage class or
+// volume attributes class.
+func IsClassScoped(resourceName corev1.ResourceName) bool {
+	if _, _, ok := splitClass(resourceName, storageClassInfix); ok {
+		return true
+	}
+	_, _, ok := splitClass(resourceName, volumeAttributesClassInfix)
+	return ok
+}
+
+// splitClass splits a key scoped by infix into the class and the resource.
+// Only requests.storage and persistentvolumeclaims can be scoped to a class.
+func splitClass(resourceName corev1.ResourceName, infix string) (string, corev1.ResourceName, bool) {
+	class, base, found := strings.Cut(string(resourceName), infix)
+	if !found || class == "" {
+		return "", resourceName, false
+	}
+	switch corev1.ResourceName(base) {
+	case ResourceRequestsStorage, ResourcePersistentVolumeClaims:
+		return class, corev1.ResourceName(base), true
+	}
+	return "", resourceName, false
+}
+
+// IsComputeResource reports whether usage of resourceName is summed from the
+// requests or limits of pod containers: cpu, memory and ephemeral storage,
+// hugepages, extended resources such as "requests.nvidia.com/gpu", and their
+// OS-scoped forms. An OS-scoped pod count such as "windows.pods" also counts.
+func IsComputeResource(resourceName corev1.ResourceName) bool {
+	if _, base, ok := SplitOSResource(resourceName); ok {
+		return base == ResourcePods || IsComputeResource(base)
+	}
+	switch resourceName {
+	case ResourceRequestsCPU, ResourceRequestsMemory, ResourceLimitsCPU, ResourceLimitsMemory,
+		ResourceRequestsEphemeralStorage, ResourceLimitsEphemeralStorage:
+		return true
+	}
+	s := string(resourceName)
+	return strings.HasPrefix(s, corev1.ResourceHugePagesPrefix) ||
+		(strings.HasPrefix(s, corev1.DefaultResourceRequestsPrefix) && resourceName != ResourceRequestsStorage)
+}
+
+// HugePagesResource returns the container resource, such as "hugepages-1Gi",
+// that the quota key resourceName charges when it limits hugepages: the bare
+// "hugepages-<size>" or "requests.hugepages-<size>", for any page size that
+// is a quantity such as 2Mi or 1Gi.
+func HugePagesResource(resourceName corev1.ResourceName) (corev1.ResourceName, bool) {
+	name := strings.TrimPrefix(string(resourceName), corev1.DefaultResourceRequestsPrefix)
+	return corev1.ResourceName(name), IsHugePages(corev1.ResourceName(name))
+}
+
+// IsHugePages reports whether the container resource name is hugepages of a
+// page size that is a positive quantity, such as "hugepages-2Mi".
+func IsHugePages(name corev1.ResourceName) bool {
+	size, ok := strings.CutPrefix(string(name), corev1.ResourceHugePagesPrefix)
+	if !ok {
+		return false
+	}
+	q, err := resource.ParseQuantity(size)
+	return err == nil && q.Sign() > 0
+}
+
+// deviceClassInfix separates the device class from the resource in a
+// device-class quota key, as in "gpu.example.com.deviceclass.resource.k8s.io/devices".
+const deviceClassInfix = ".deviceclass.resource.k8s.io/"
+
+// ResourceDevices is the resource a device-class quota key limits: the
+// devices ResourceClaims request from the class.
+const ResourceDevices = corev1.ResourceName("devices")
+
+// DeviceClassFor returns the quota key limiting the devices ResourceClaims
+// request from class.
+func DeviceClassFor(class string) corev1.ResourceName {
+	return corev1.ResourceName(class + deviceClassInfix + string(ResourceDevices))
+}
+
+// SplitDeviceClass returns the device class a device-class quota key limits.
+func SplitDeviceClass(resourceName corev1.ResourceName) (string, bool) {
+	class, base, found := strings.Cut(string(resourceName), deviceClassInfix)
+	if !found || class == "" || corev1.ResourceName(base) != ResourceDevices {
+		return "", false
+	}
+	return class, true
+}
@@ -0,0 +1,111 @@
+package usage
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Quota keys", func() {
+	DescribeTable("ParseQuotaKey",
+		func(key string, want QuotaKey) {
+			parsed := ParseQuotaKey(corev1.ResourceName(key))
+			Expect(parsed).To(Equal(want))
+			Expect(parsed.ResourceName()).To(Equal(corev1.ResourceName(key)))
+		},
+		Entry("unscoped compute", "requests.cpu", QuotaKey{Resource: ResourceRequestsCPU}),
+		Entry("unscoped count", "count/configmaps", QuotaKey{Resource: "count/configmaps"}),
+		Entry("OS-scoped compute", "windows.requests.cpu", QuotaKey{OS: OSWindows, Resource: ResourceRequestsCPU}),
+		Entry("OS-scoped pods", "linux.pods", QuotaKey{OS: OSLinux, Resource: ResourcePods}),
+		Entry("class-scoped storage", "gold.storageclass.storage.k8s.io/requests.storage",
+			QuotaKey{StorageClass: "gold", Resource: ResourceRequestsStorage}),
+		Entry("class-scoped claims", "gold.storageclass.storage.k8s.io/persistentvolumeclaims",
+			QuotaKey{StorageClass: "gold", Resource: ResourcePersistentVolumeClaims}),
+		Entry("class named after an OS", "linux.storageclass.storage.k8s.io/requests.storage",
+			QuotaKey{StorageClass: "linux", Resource: ResourceRequestsStorage}),
+		Entry("dotted class name", "fast.ssd.storageclass.storage.k8s.io/persistentvolumeclaims",
+			QuotaKey{StorageClass: "fast.ssd", Resource: ResourcePersistentVolumeClaims}),
+		Entry("class-scoped key of another resource", "gold.storageclass.storage.k8s.io/requests.cpu",
+			QuotaKey{Resource: "gold.storageclass.storage.k8s.io/requests.cpu"}),
+		Entry("class-scoped key without a class", ".storageclass.storage.k8s.io/requests.storage",
+			QuotaKey{Resource: ".storageclass.storage.k8s.io/requests.storage"}),
+		Entry("attributes-class-scoped storage", "fast.volumeattributesclass.storage.k8s.io/requests.storage",
+			QuotaKey{VolumeAttributesClass: "fast", Resource: ResourceRequestsStorage}),
+		Entry("attributes-class-scoped claims", "fast.volumeattributesclass.storage.k8s.io/persistentvolumeclaims",
+			QuotaKey{VolumeAttributesClass: "fast", Resource: ResourcePersistentVolumeClaims}),
+	)
+
+	It("formats storage-class keys", func() {
+		Expect(StorageClassFor("gold", ResourceRequestsStorage)).
+			To(Equal(corev1.ResourceName("gold.storageclass.storage.k8s.io/requests.storage")))
+		Expect(StorageClassFor("gold", ResourcePersistentVolumeClaims)).
+			To(Equal(corev1.ResourceName("gold.storageclass.storage.k8s.io/persistentvolumeclaims")))
+	})
+
+	DescribeTable("IsClassScoped",
+		func(key string, want bool) {
+			Expect(IsClassScoped(corev1.ResourceName(key))).To(Equal(want))
+		},
+		Entry("class-scoped storage", "gold.storageclass.storage.k8s.io/requests.storage", true),
+		Entry("class-scoped claims", "gold.storageclass.storage.k8s.io/persistentvolumeclaims", true),
+		Entry("attributes-class-scoped storage", "fast.volumeattributesclass.storage.k8s.io/requests.storage", true),
+		Entry("unscoped storage", "requests.storage", false),
+		Entry("unscoped claims", "persistentvolumeclaims", false),
+		Entry("OS-scoped compute", "windows.requests.cpu", false),
+	)
+
+	It("formats and splits device-class keys", func() {
+		key := DeviceClassFor("gpu.example.com")
+		Expect(key).To(Equal(corev1.ResourceName("gpu.example.com.deviceclass.resource.k8s.io/devices")))
+		class, ok := SplitDeviceClass(key)
+		Expect(ok).To(BeTrue())
+		Expect(class).To(Equal("gpu.example.com"))
+		_, ok = SplitDeviceClass("deviceclass.resource.k8s.io/devices")
+		Expect(ok).To(BeFalse())
+		_, ok = SplitDeviceClass("gpu.deviceclass.resource.k8s.io/requests.cpu")
+		Expect(ok).To(BeFalse())
+		_, _, ok = SplitOSResource(DeviceClassFor("linux"))
+		Expect(ok).To(BeFalse())
+	})
+
+	DescribeTable("IsComputeResource",
+		func(key string, want bool) {
+			Expect(IsComputeResource(corev1.ResourceName(key))).To(Equal(want))
+		},
+		Entry("requests.cpu", "requests.cpu", true),
+		Entry("limits.cpu", "limits.cpu", true),
+		Entry("requests.memory", "requests.memory", true),
+		Entry("limits.memory", "limits.memory", true),
+		Entry("requests.ephemeral-storage", "requests.ephemeral-storage", true),
+		Entry("limits.ephemeral-storage", "limits.ephemeral-storage", true),
+		Entry("hugepages", "hugepages-2Mi", true),
+		Entry("requested hugepages", "requests.hugepages-1Gi", true),
+		Entry("extended resource", "requests.nvidia.com/gpu", true),
+		Entry("OS-scoped compute", "windows.limits.memory", true),
+		Entry("OS-scoped pods", "windows.pods", true),
+		Entry("pods", "pods", false),
+		Entry("requested storage", "requests.storage", false),
+		Entry("class-scoped storage", "gold.storageclass.storage.k8s.io/requests.storage", false),
+		Entry("services", "services", false),
+		Entry("object count", "count/configmaps", false),
+		Entry("bare cpu", "cpu", false),
+	)
+
+	DescribeTable("HugePagesResource",
+		func(key, wantName string, want bool) {
+			name, ok := HugePagesResource(corev1.ResourceName(key))
+			Expect(ok).To(Equal(want))
+			if want {
+				Expect(name).To(Equal(corev1.ResourceName(wantName)))
+			}
+		},
+		Entry("bare 2Mi pages", "hugepages-2Mi", "hugepages-2Mi", true),
+		Entry("requested 1Gi pages", "requests.hugepages-1Gi", "hugepages-1Gi", true),
+		Entry("requested 32Mi pages", "requests.hugepages-32Mi", "hugepages-32Mi", true),
+		Entry("page size that is not a quantity", "requests.hugepages-1GB", "", false),
+		Entry("no page size", "hugepages-", "", false),
+		Entry("memory", "requests.memory", "", false),
+		Entry("OS-scoped pages", "windows.requests.hugepages-2Mi", "", false),
+	)
+})
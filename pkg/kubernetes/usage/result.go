@@ -0,0 +1,34 @@
+package usage
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ErrUnsupportedResource is reported for a quota key no calculator can count.
+var ErrUnsupportedResource = errors.New("no calculator counts this resource")
+
+// Result is the usage of one resource as computed by a calculator. A non-nil
+// Err means the usage could not be fully counted: Used then only holds what
+// was counted, a lower bound that must not be trusted to admit a request.
+type Result struct {
+	Used resource.Quantity
+	Err  error
+}
+
+// Complete returns a Result for usage that was fully counted.
+func Complete(used resource.Quantity) Result {
+	return Result{Used: used}
+}
+
+// Incomplete returns a Result for a calculation that failed with err. Its
+// usage is a typed zero, never a parsed "0" that reads as a real count.
+func Incomplete(err error) Result {
+	return Result{Err: err}
+}
+
+// IsComplete reports whether the usage was fully counted.
+func (r Result) IsComplete() bool {
+	return r.Err == nil
+}
@@ -0,0 +1,26 @@
+package usage
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Result", func() {
+	It("is complete when the usage was counted", func() {
+		r := Complete(resource.MustParse("2"))
+		Expect(r.IsComplete()).To(BeTrue())
+		Expect(r.Used.Value()).To(Equal(int64(2)))
+	})
+
+	It("carries a typed zero and the error of a failed calculation", func() {
+		err := errors.New("list failed")
+		r := Incomplete(err)
+		Expect(r.IsComplete()).To(BeFalse())
+		Expect(r.Err).To(MatchError(err))
+		Expect(r.Used).To(Equal(resource.Quantity{}))
+	})
+})
@@ -42,13 +42,99 @@ var (
 	ResourceCronJobs                 = corev1.ResourceName("cronjobs.batch")
 	ResourceHorizontalPodAutoscalers = corev1.ResourceName("horizontalpodautoscalers.autoscaling")
 	ResourceIngresses                = corev1.ResourceName("ingresses.networking.k8s.io")
+	ResourceResourceClaims           = corev1.ResourceName("resourceclaims.resource.k8s.io")
 
 	// Service-related resources
 	ResourceServices              = corev1.ResourceServices
 	ResourceServicesLoadBalancers = corev1.ResourceServicesLoadBalancers
 	ResourceServicesNodePorts     = corev1.ResourceServicesNodePorts
+
+	// ResourceServicesIPv4 and ResourceServicesIPv6 count the cluster IPs of
+	// each family allocated to services. A dual-stack service has one of each.
+	ResourceServicesIPv4 = corev1.ResourceName("services.ipv4")
+	ResourceServicesIPv6 = corev1.ResourceName("services.ipv6")
+
+	// ResourceServicesExternalIPs counts the services that set
+	// spec.externalIPs, which route node traffic for any address to them.
+	ResourceServicesExternalIPs = corev1.ResourceName("services.externalips")
+
+	// ResourceServicesHeadless counts the headless services, which set
+	// spec.clusterIP to None and take no cluster IP.
+	ResourceServicesHeadless = corev1.ResourceName("services.headless")
+
+	// ResourceServicesEndpoints counts the endpoints of the EndpointSlices of
+	// the selected namespaces, the backends kube-proxy programs for their
+	// services. It is report-only: no webhook admits endpoints.
+	ResourceServicesEndpoints = corev1.ResourceName("services.endpoints")
+
+	// ResourceServicesLoadBalancerCost sums the cost units of LoadBalancer
+	// services, so expensive cloud load balancers can be capped by budget.
+	ResourceServicesLoadBalancerCost = corev1.ResourceName("services.loadbalancers/cost-units")
+
+	// ResourcePodHostPorts counts the distinct host ports bound by pods. A port
+	// bound by several pods, in one namespace or several, counts once.
+	ResourcePodHostPorts = corev1.ResourceName("pods.networking/ports")
+
+	// ResourcePodDensity limits the pods of each namespace per CPU core they
+	// request, guarding against many tiny pods exhausting pod IPs and kubelet
+	// slots. It is a ratio, so it bounds every namespace on its own: the total
+	// is the densest namespace, not a sum.
+	ResourcePodDensity = corev1.ResourceName("pods.density/per-cpu")
 )
 
+// Operating systems an OS-scoped quota key can name, as in "windows.requests.cpu".
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+)
+
+// SplitOSResource splits an OS-scoped quota key such as "windows.requests.cpu"
+// into the operating system and the resource it bounds for pods of that OS
+// only. ok is false for keys without an OS prefix and for storage-class and
+// device-class keys, whose class may be named "linux" or "windows".
+func SplitOSResource(resourceName corev1.ResourceName) (os string, base corev1.ResourceName, ok bool) {
+	if _, ok := SplitDeviceClass(resourceName); ok || IsClassScoped(resourceName) {
+		return "", resourceName, false
+	}
+	for _, prefix := range []string{OSLinux, OSWindows} {
+		if rest, found := strings.CutPrefix(string(resourceName), prefix+"."); found && rest != "" {
+			return prefix, corev1.ResourceName(rest), true
+		}
+	}
+	return "", resourceName, false
+}
+
+// PodQuotaState classifies a pod for `pods` count and compute quota
+// accounting. The rules mirror the core ResourceQuota pod evaluator.
+type PodQuotaState string
+
+const (
+	// PodQuotaStateActive covers Pending, Running and Unknown pods, including
+	// pods that have not been scheduled yet.
+	PodQuotaStateActive PodQuotaState = "Active"
+	// PodQuotaStateTerminating is a pod marked for deletion whose grace period
+	// has not yet elapsed. It still holds its resources.
+	PodQuotaStateTerminating PodQuotaState = "Terminating"
+	// PodQuotaStateStuckTerminating is a pod still present past its deletion
+	// grace period (for example on a lost node). It is released so it cannot
+	// block replacements from scaling up.
+	PodQuotaStateStuckTerminating PodQuotaState = "StuckTerminating"
+	// PodQuotaStateTerminal is a Succeeded or Failed pod.
+	PodQuotaStateTerminal PodQuotaState = "Terminal"
+)
+
+// Counted reports whether a pod in state s is charged against `pods` and
+// compute quotas. Controller aggregation and webhook admission both consult it
+// so their semantics cannot drift.
+func (s PodQuotaState) Counted() bool {
+	switch s {
+	case PodQuotaStateActive, PodQuotaStateTerminating:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetBaseResourceName returns the base resource name for a given resource name.
 // For example, it maps 'requests.cpu' or 'limits.cpu' to 'cpu'.
 func GetBaseResourceName(resourceName corev1.ResourceName) corev1.ResourceName {
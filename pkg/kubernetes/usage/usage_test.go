@@ -52,4 +52,26 @@ var _ = Describe("Usage", func() {
 			Expect(GetBaseResourceName("nvidia.com/gpu")).To(Equal(corev1.ResourceName("nvidia.com/gpu")))
 		})
 	})
+
+	Describe("SplitOSResource", func() {
+		It("should split OS-scoped keys", func() {
+			os, base, ok := SplitOSResource("windows.requests.cpu")
+			Expect(ok).To(BeTrue())
+			Expect(os).To(Equal(OSWindows))
+			Expect(base).To(Equal(corev1.ResourceRequestsCPU))
+
+			os, base, ok = SplitOSResource("linux.pods")
+			Expect(ok).To(BeTrue())
+			Expect(os).To(Equal(OSLinux))
+			Expect(base).To(Equal(corev1.ResourcePods))
+		})
+
+		It("should not split other keys", func() {
+			for _, name := range []corev1.ResourceName{corev1.ResourceRequestsCPU, "windows.", "example.com/windows"} {
+				_, base, ok := SplitOSResource(name)
+				Expect(ok).To(BeFalse())
+				Expect(base).To(Equal(name))
+			}
+		})
+	})
 })
@@ -0,0 +1,293 @@
+// Package vpa reads VerticalPodAutoscaler recommendations and the workloads
+// they target as unstructured objects, so the controller needs no dependency
+// on the VPA API module and runs on clusters without the VPA CRDs installed.
+package vpa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// GVK is the VerticalPodAutoscaler kind this package reads.
+var GVK = schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscaler"}
+
+// quotaNames maps the container resources VPA recommends to the quota keys
+// their requests are charged to.
+var quotaNames = map[corev1.ResourceName]corev1.ResourceName{
+	corev1.ResourceCPU:    corev1.ResourceRequestsCPU,
+	corev1.ResourceMemory: corev1.ResourceRequestsMemory,
+}
+
+// QuotaResources returns the quota keys a recommendation changes, sorted.
+func QuotaResources() []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(quotaNames))
+	for _, name := range quotaNames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// New returns an empty VerticalPodAutoscaler to Get into.
+func New() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(GVK)
+	return u
+}
+
+// Plan is a VerticalPodAutoscaler recommendation next to the workload it
+// would be applied to.
+type Plan struct {
+	// Targets is the recommended target of each container, by container name.
+	Targets map[string]corev1.ResourceList
+	// Current is the requests of each container in the workload's pod template.
+	Current map[string]corev1.ResourceList
+	// Replicas is the number of pods the recommendation applies to.
+	Replicas int64
+}
+
+// LoadPlan reads vpa's recommendation and the workload its targetRef names.
+// It returns nil when there is nothing to check yet: no recommendation, or no
+// target reference.
+func LoadPlan(ctx context.Context, reader client.Reader, vpa *unstructured.Unstructured) (*Plan, error) {
+	targets, err := Targets(vpa)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+	gvk, name, ok := TargetRef(vpa)
+	if !ok {
+		return nil, nil
+	}
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(gvk)
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: vpa.GetNamespace(), Name: name}, workload); err != nil {
+		return nil, fmt.Errorf("reading %s %s: %w", gvk.Kind, name, err)
+	}
+	replicas, current, err := WorkloadRequests(workload)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", gvk.Kind, name, err)
+	}
+	return &Plan{Targets: targets, Current: current, Replicas: replicas}, nil
+}
+
+// Delta is RequestDelta of the plan.
+func (p *Plan) Delta() corev1.ResourceList {
+	return RequestDelta(p.Targets, p.Current, p.Replicas)
+}
+
+// Exceeded returns the quota resources, sorted, whose increase under p is
+// larger than headroom.
+func (p *Plan) Exceeded(headroom corev1.ResourceList) []corev1.ResourceName {
+	delta := p.Delta()
+	var exceeded []corev1.ResourceName
+	for _, name := range QuotaResources() {
+		room, ok := headroom[name]
+		if want := delta[name]; ok && want.Cmp(room) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	return exceeded
+}
+
+// Headroom returns what crq's hard limits leave after its recorded usage, for
+// the quota resources a recommendation changes, never below zero. Resources
+// without an enforced hard limit or recorded usage are left out.
+func Headroom(crq *quotav1alpha1.ClusterResourceQuota) corev1.ResourceList {
+	headroom := make(corev1.ResourceList)
+	for _, name := range QuotaResources() {
+		hard, ok := crq.Spec.Hard[name]
+		used, recorded := crq.Status.Total.Used[name]
+		if !ok || !recorded || crq.Spec.Enforcement(name) != quotav1alpha1.EnforcementEnforce {
+			continue
+		}
+		room := hard.DeepCopy()
+		room.Sub(used)
+		if room.Sign() < 0 {
+			room = *resource.NewQuantity(0, hard.Format)
+		}
+		headroom[name] = room
+	}
+	return headroom
+}
+
+// Targets returns the target of each container recommendation in vpa's
+// status, keyed by container name. It is empty before the recommender has
+// produced a recommendation.
+func Targets(vpa *unstructured.Unstructured) (map[string]corev1.ResourceList, error) {
+	recs, _, err := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+	if err != nil {
+		return nil, fmt.Errorf("reading container recommendations: %w", err)
+	}
+	targets := make(map[string]corev1.ResourceList, len(recs))
+	for i, rec := range recs {
+		m, ok := rec.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("container recommendation %d is not an object", i)
+		}
+		name, _, _ := unstructured.NestedString(m, "containerName")
+		target, err := resourceList(m, "target")
+		if err != nil {
+			return nil, fmt.Errorf("container recommendation %q: %w", name, err)
+		}
+		targets[name] = target
+	}
+	return targets, nil
+}
+
+// TargetRef returns the kind and name of the workload vpa scales.
+func TargetRef(vpa *unstructured.Unstructured) (gvk schema.GroupVersionKind, name string, ok bool) {
+	ref, found, err := unstructured.NestedStringMap(vpa.Object, "spec", "targetRef")
+	if err != nil || !found || ref["kind"] == "" || ref["name"] == "" {
+		return schema.GroupVersionKind{}, "", false
+	}
+	gv, err := schema.ParseGroupVersion(ref["apiVersion"])
+	if err != nil {
+		return schema.GroupVersionKind{}, "", false
+	}
+	return gv.WithKind(ref["kind"]), ref["name"], true
+}
+
+// WorkloadRequests returns the replica count of a workload with a pod
+// template, such as a Deployment or StatefulSet, and the requests of each
+// container in its template. A workload without spec.replicas runs one pod.
+func WorkloadRequests(workload *unstructured.Unstructured) (int64, map[string]corev1.ResourceList, error) {
+	replicas, found, err := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading replicas: %w", err)
+	}
+	if !found {
+		replicas = 1
+	}
+	containers, _, err := unstructured.NestedSlice(workload.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading pod template containers: %w", err)
+	}
+	requests := make(map[string]corev1.ResourceList, len(containers))
+	for i, c := range containers {
+		m, ok := c.(map[string]any)
+		if !ok {
+			return 0, nil, fmt.Errorf("container %d is not an object", i)
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		list, err := resourceList(m, "resources", "requests")
+		if err != nil {
+			return 0, nil, fmt.Errorf("container %q: %w", name, err)
+		}
+		requests[name] = list
+	}
+	return replicas, requests, nil
+}
+
+// RequestDelta returns how much quota usage would change, keyed by quota
+// name, if every replica's containers were given their targets. Containers
+// without a recommendation keep their requests.
+func RequestDelta(targets, current map[string]corev1.ResourceList, replicas int64) corev1.ResourceList {
+	delta := make(corev1.ResourceList, len(quotaNames))
+	for resourceName, quotaName := range quotaNames {
+		var sum resource.Quantity
+		for container, target := range targets {
+			want, ok := target[resourceName]
+			if !ok {
+				continue
+			}
+			sum.Add(want)
+			if have, ok := current[container][resourceName]; ok {
+				sum.Sub(have)
+			}
+		}
+		delta[quotaName] = fromMillis(resourceName, sum.MilliValue()*replicas)
+	}
+	return delta
+}
+
+// CapTargets returns targets with every increase scaled down so the total
+// increase of each resource fits in headroom, keyed by quota name. Decreases
+// and resources without headroom entries are left as recommended.
+func CapTargets(
+	targets, current map[string]corev1.ResourceList,
+	replicas int64,
+	headroom corev1.ResourceList,
+) map[string]corev1.ResourceList {
+	capped := make(map[string]corev1.ResourceList, len(targets))
+	for container, target := range targets {
+		capped[container] = target.DeepCopy()
+	}
+	delta := RequestDelta(targets, current, replicas)
+	for resourceName, quotaName := range quotaNames {
+		room, ok := headroom[quotaName]
+		want := delta[quotaName]
+		if !ok || want.Cmp(room) <= 0 {
+			continue
+		}
+		// Only increases consume headroom, so scale each of them by the share
+		// of the total increase that fits.
+		var increase int64
+		for container, target := range targets {
+			if up := increaseOf(target, current[container], resourceName); up > 0 {
+				increase += up * replicas
+			}
+		}
+		allowed := room.MilliValue() + increase - want.MilliValue()
+		if allowed < 0 {
+			allowed = 0
+		}
+		for container, target := range targets {
+			up := increaseOf(target, current[container], resourceName)
+			if up <= 0 {
+				continue
+			}
+			have := current[container][resourceName]
+			kept := int64(float64(up) * float64(allowed) / float64(increase))
+			capped[container][resourceName] = fromMillis(resourceName, have.MilliValue()+kept)
+		}
+	}
+	return capped
+}
+
+// increaseOf returns how many millis target asks for above current.
+func increaseOf(target, current corev1.ResourceList, resourceName corev1.ResourceName) int64 {
+	want, ok := target[resourceName]
+	if !ok {
+		return 0
+	}
+	have := current[resourceName]
+	return want.MilliValue() - have.MilliValue()
+}
+
+// fromMillis returns millis of resourceName as a quantity, in whole bytes
+// (rounded down) for memory.
+func fromMillis(resourceName corev1.ResourceName, millis int64) resource.Quantity {
+	if resourceName == corev1.ResourceMemory {
+		return *resource.NewQuantity(millis/1000, resource.BinarySI)
+	}
+	return *resource.NewMilliQuantity(millis, resource.DecimalSI)
+}
+
+// resourceList parses the cpu and memory quantities at fields of obj.
+func resourceList(obj map[string]any, fields ...string) (corev1.ResourceList, error) {
+	raw, _, err := unstructured.NestedMap(obj, fields...)
+	if err != nil {
+		return nil, err
+	}
+	list := make(corev1.ResourceList, len(raw))
+	for name, value := range raw {
+		if _, ok := quotaNames[corev1.ResourceName(name)]; !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(fmt.Sprint(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity %v: %w", name, value, err)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
@@ -0,0 +1,18 @@
+package vpa
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+)
+
+func TestVPA(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VPA Package Suite")
+}
+
+var _ = BeforeSuite(func() {
+	pkglogger.InitTest()
+})
@@ -0,0 +1,181 @@
+package vpa
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("VPA", func() {
+	quantityOf := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		q := list[name]
+		return q.String()
+	}
+	cpuMem := func(cpu, memory string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+	}
+
+	Describe("Targets", func() {
+		It("reads the target of each container recommendation", func() {
+			v := New()
+			v.Object["status"] = map[string]any{"recommendation": map[string]any{
+				"containerRecommendations": []any{map[string]any{
+					"containerName": "app",
+					"target":        map[string]any{"cpu": "250m", "memory": "128Mi", "nvidia.com/gpu": "1"},
+				}},
+			}}
+
+			targets, err := Targets(v)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(HaveKey("app"))
+			Expect(targets["app"]).To(HaveLen(2), "only cpu and memory are quota'd through requests")
+			Expect(quantityOf(targets["app"], corev1.ResourceCPU)).To(Equal("250m"))
+		})
+
+		It("is empty before the recommender has run", func() {
+			targets, err := Targets(New())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(BeEmpty())
+		})
+
+		It("rejects an invalid quantity", func() {
+			v := New()
+			v.Object["status"] = map[string]any{"recommendation": map[string]any{
+				"containerRecommendations": []any{map[string]any{
+					"containerName": "app",
+					"target":        map[string]any{"cpu": "lots"},
+				}},
+			}}
+			_, err := Targets(v)
+			Expect(err).To(MatchError(ContainSubstring(`container recommendation "app"`)))
+		})
+	})
+
+	Describe("TargetRef", func() {
+		It("returns the kind and name of the target workload", func() {
+			v := New()
+			v.Object["spec"] = map[string]any{
+				"targetRef": map[string]any{"apiVersion": "apps/v1", "kind": "StatefulSet", "name": "db"},
+			}
+			gvk, name, ok := TargetRef(v)
+			Expect(ok).To(BeTrue())
+			Expect(gvk.Group).To(Equal("apps"))
+			Expect(gvk.Kind).To(Equal("StatefulSet"))
+			Expect(name).To(Equal("db"))
+		})
+
+		It("reports a missing targetRef", func() {
+			_, _, ok := TargetRef(New())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("WorkloadRequests", func() {
+		It("defaults to one replica", func() {
+			w := &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{
+				"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{
+						"name":      "app",
+						"resources": map[string]any{"requests": map[string]any{"memory": "1Gi"}},
+					}},
+				}},
+			}}}
+			replicas, requests, err := WorkloadRequests(w)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicas).To(Equal(int64(1)))
+			Expect(quantityOf(requests["app"], corev1.ResourceMemory)).To(Equal("1Gi"))
+		})
+	})
+
+	Describe("RequestDelta", func() {
+		It("multiplies the per-pod change by the replica count", func() {
+			delta := RequestDelta(
+				map[string]corev1.ResourceList{"app": cpuMem("1", "256Mi")},
+				map[string]corev1.ResourceList{"app": cpuMem("500m", "512Mi")},
+				3,
+			)
+			cpu := delta[corev1.ResourceRequestsCPU]
+			memory := delta[corev1.ResourceRequestsMemory]
+			Expect(cpu.Cmp(resource.MustParse("1500m"))).To(Equal(0))
+			Expect(memory.Cmp(resource.MustParse("-768Mi"))).To(Equal(0))
+		})
+
+		It("counts the whole target of a container without requests", func() {
+			delta := RequestDelta(map[string]corev1.ResourceList{"app": cpuMem("1", "1Gi")}, nil, 1)
+			cpu := delta[corev1.ResourceRequestsCPU]
+			Expect(cpu.Cmp(resource.MustParse("1"))).To(Equal(0))
+		})
+	})
+
+	Describe("CapTargets", func() {
+		It("scales increases down to the headroom and keeps decreases", func() {
+			targets := map[string]corev1.ResourceList{
+				"app":     cpuMem("2", "1Gi"),
+				"sidecar": cpuMem("1", "64Mi"),
+			}
+			current := map[string]corev1.ResourceList{
+				"app":     cpuMem("1", "2Gi"),
+				"sidecar": cpuMem("500m", "64Mi"),
+			}
+			// Two replicas ask for 3 more CPUs; 1500m is left.
+			capped := CapTargets(targets, current, 2, corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("1500m"),
+				corev1.ResourceRequestsMemory: resource.MustParse("0"),
+			})
+
+			Expect(quantityOf(capped["app"], corev1.ResourceCPU)).To(Equal("1500m"))
+			Expect(quantityOf(capped["sidecar"], corev1.ResourceCPU)).To(Equal("750m"))
+			Expect(quantityOf(capped["app"], corev1.ResourceMemory)).To(Equal("1Gi"), "decreases are kept")
+			delta := RequestDelta(capped, current, 2)
+			cpu := delta[corev1.ResourceRequestsCPU]
+			Expect(cpu.Cmp(resource.MustParse("1500m"))).To(Equal(0))
+		})
+
+		It("leaves targets that fit untouched", func() {
+			targets := map[string]corev1.ResourceList{"app": cpuMem("2", "1Gi")}
+			capped := CapTargets(targets, nil, 1, corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("4"),
+			})
+			Expect(capped).To(Equal(targets))
+		})
+	})
+
+	Describe("Headroom", func() {
+		It("returns hard minus used, never below zero", func() {
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+					corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+				}},
+				Status: quotav1alpha1.ClusterResourceQuotaStatus{Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{
+						corev1.ResourceRequestsCPU:    resource.MustParse("3"),
+						corev1.ResourceRequestsMemory: resource.MustParse("2Gi"),
+					},
+				}},
+			}
+			headroom := Headroom(crq)
+			cpu := headroom[corev1.ResourceRequestsCPU]
+			memory := headroom[corev1.ResourceRequestsMemory]
+			Expect(cpu.Cmp(resource.MustParse("1"))).To(Equal(0))
+			Expect(memory.IsZero()).To(BeTrue())
+		})
+
+		It("leaves out resources the quota has not counted", func() {
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{Hard: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("4"),
+				}},
+			}
+			Expect(Headroom(crq)).To(BeEmpty())
+		})
+	})
+})
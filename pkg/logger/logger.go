@@ -4,10 +4,14 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/powerhome/pac-quota-controller/pkg/config"
 )
 
 var (
@@ -16,9 +20,12 @@ var (
 )
 
 // Initialize configures the global zap logger based on provided configuration
+// and routes controller-runtime's logs through it, so both share the level,
+// format and sampling of the flags.
 func Initialize(cfg *config.Config) {
 	once.Do(func() {
 		globalLogger = SetupLogger(cfg)
+		ctrllog.SetLogger(zapr.NewLogger(globalLogger))
 	})
 }
 
@@ -38,7 +45,6 @@ func InitTest() *zap.Logger {
 
 // SetupLogger configures a zap logger based on provided configuration (for non-global use if needed)
 func SetupLogger(cfg *config.Config) *zap.Logger {
-	// ... existing implementation remains mostly same but renamed to SetupLogger for consistency
 	// Set the log level
 	var level zapcore.Level
 	switch strings.ToLower(cfg.LogLevel) {
@@ -66,5 +72,10 @@ func SetupLogger(cfg *config.Config) *zap.Logger {
 	}
 
 	core := zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level)
+	// Sample repeated entries, such as one per admission request, so a burst
+	// cannot flood the log pipeline.
+	if cfg.LogSamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.LogSamplingInitial, cfg.LogSamplingThereafter)
+	}
 	return zap.New(core)
 }
@@ -42,6 +42,23 @@ func TestSetupLoggerConsoleFormat(t *testing.T) {
 	}
 }
 
+func TestSetupLoggerSampling(t *testing.T) {
+	sampled := SetupLogger(&config.Config{LogLevel: "info", LogSamplingInitial: 1, LogSamplingThereafter: 0})
+	if ce := sampled.Check(zapcore.InfoLevel, "repeated"); ce == nil {
+		t.Fatal("expected the first entry to be logged")
+	}
+	if ce := sampled.Check(zapcore.InfoLevel, "repeated"); ce != nil {
+		t.Fatal("expected the second entry of the second to be sampled out")
+	}
+
+	unsampled := SetupLogger(&config.Config{LogLevel: "info"})
+	for range 3 {
+		if ce := unsampled.Check(zapcore.InfoLevel, "repeated"); ce == nil {
+			t.Fatal("expected every entry to be logged without sampling")
+		}
+	}
+}
+
 func TestLAlwaysReturnsLogger(t *testing.T) {
 	if L() == nil {
 		t.Fatal("L() must never return nil")
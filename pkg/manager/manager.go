@@ -8,13 +8,17 @@ import (
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/internal/controller"
 	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 	"go.uber.org/zap"
 
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmanager "sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
 // pkgLogger is the fallback used by SetupControllers when no logger is supplied.
@@ -43,6 +47,11 @@ func SetupManager(
 		LeaderElectionID: "81307769.powerapp.cloud",
 		PprofBindAddress: cfg.PprofBindAddress,
 	}
+	// With the metrics server off, the webhook server serves the build info
+	// and reconcile counters on metrics.LitePath instead.
+	if !cfg.MetricsEnable {
+		options.Metrics = metricsserver.Options{BindAddress: "0"}
+	}
 
 	// Configure leader election timing if enabled
 	if cfg.EnableLeaderElection {
@@ -58,11 +67,24 @@ func SetupManager(
 		options.RetryPeriod = &retryPeriod
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	restConfig, err := RESTConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		return nil, err
 	}
 
+	// Serve the Grafana dashboard next to the metrics it charts.
+	if !cfg.MetricsEnable {
+		return mgr, nil
+	}
+	if err := mgr.AddMetricsServerExtraHandler(metrics.DashboardPath, metrics.DashboardHandler()); err != nil {
+		return nil, err
+	}
+
 	return mgr, nil
 }
 
@@ -89,6 +111,35 @@ func validateLeaderElectionTiming(cfg *config.Config) error {
 	return nil
 }
 
+// SetupControllersWhenServed sets up the controllers right away when crds
+// finds the ClusterResourceQuota CRD served. Otherwise, as in a cluster where
+// the webhook is deployed before the CRD, it adds a runnable that re-discovers
+// the CRD every quota.CRDRediscoveryInterval and sets them up once it is
+// installed, so the manager starts instead of failing on the missing kind.
+func SetupControllersWhenServed(
+	ctx context.Context,
+	mgr ctrl.Manager,
+	cfg *config.Config,
+	crds *quota.CRDAvailability,
+	loggerInstance *zap.Logger,
+) error {
+	if crds.Refresh() {
+		return SetupControllers(ctx, mgr, cfg, loggerInstance)
+	}
+	logger := pkgLogger
+	if loggerInstance != nil {
+		logger = loggerInstance.Named("setup")
+	}
+	logger.Info("ClusterResourceQuota CRD is not installed; deferring the controllers until it is")
+	return mgr.Add(ctrlmanager.RunnableFunc(func(ctx context.Context) error {
+		if !crds.WaitUntilAvailable(ctx, quota.CRDRediscoveryInterval) {
+			return nil
+		}
+		logger.Info("ClusterResourceQuota CRD installed; setting up the controllers")
+		return SetupControllers(ctx, mgr, cfg, loggerInstance)
+	}))
+}
+
 // SetupControllers sets up all controllers with the manager
 func SetupControllers(ctx context.Context, mgr ctrl.Manager, cfg *config.Config, loggerInstance *zap.Logger) error {
 	logger := pkgLogger
@@ -96,16 +147,77 @@ func SetupControllers(ctx context.Context, mgr ctrl.Manager, cfg *config.Config,
 		logger = loggerInstance.Named("setup")
 	}
 
+	hubClient, err := FederationHubClient(cfg, mgr.GetConfig(), mgr.GetScheme())
+	if err != nil {
+		logger.Error("unable to create federation hub client", zap.Error(err))
+		return err
+	}
+
 	if err := (&controller.ClusterResourceQuotaReconciler{
 		Client:                   mgr.GetClient(),
 		Scheme:                   mgr.GetScheme(),
 		Config:                   cfg,
 		ExcludeNamespaceLabelKey: cfg.ExcludeNamespaceLabelKey,
 		ExcludedNamespaces:       cfg.ExcludedNamespaces,
+		SystemNamespaces:         cfg.SystemNamespaces,
+		NamespaceLabelDenylist:   cfg.NamespaceLabelDenylistSelectors(),
+		ExcludedOwners:           cfg.ExcludedOwnerKinds(),
+		ExcludeHeadlessServices:  cfg.ExcludeHeadlessServices,
+		ConfigName:               cfg.ControllerConfigName,
+		ClusterName:              cfg.FederationClusterName,
+		HubClient:                hubClient,
 	}).SetupWithManager(ctx, cfg, mgr); err != nil {
 		logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "ClusterResourceQuota"))
 		return err
 	}
 
+	if err := (&controller.AdmissionDenialReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "AdmissionDenial"))
+		return err
+	}
+
+	if cfg.ControllerConfigName != "" {
+		if err := (&controller.QuotaControllerConfigReconciler{
+			Client:                   mgr.GetClient(),
+			Name:                     cfg.ControllerConfigName,
+			WebhookConfigurationName: cfg.WebhookConfigurationName,
+			Observe:                  cfg.Observing(),
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "QuotaControllerConfig"))
+			return err
+		}
+	}
+
+	if cfg.WebhookAutoScope {
+		if err := (&controller.WebhookScopeReconciler{
+			Client:                   mgr.GetClient(),
+			WebhookConfigurationName: cfg.WebhookConfigurationName,
+			Config:                   cfg,
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "WebhookScope"))
+			return err
+		}
+	}
+
+	if cfg.VPAEnable {
+		if err := (&controller.VerticalPodAutoscalerReconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "VerticalPodAutoscaler"))
+			return err
+		}
+	}
+
+	if cfg.HPAAdvisoryEnable {
+		if err := (&controller.HorizontalPodAutoscalerReconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error("unable to create controller", zap.Error(err), zap.String("controller", "HorizontalPodAutoscaler"))
+			return err
+		}
+	}
+
 	return nil
 }
@@ -1,11 +1,18 @@
 package manager
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
 func TestValidateLeaderElectionTiming(t *testing.T) {
@@ -80,3 +87,88 @@ func TestValidateLeaderElectionTiming(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyRateLimits(t *testing.T) {
+	restConfig := &rest.Config{}
+	applyRateLimits(restConfig, &config.Config{KubeAPIQPS: 50, KubeAPIBurst: 100})
+	assert.Equal(t, float32(50), restConfig.QPS)
+	assert.Equal(t, 100, restConfig.Burst)
+	if assert.NotNil(t, restConfig.RateLimiter) {
+		assert.Equal(t, float32(50), restConfig.RateLimiter.QPS())
+	}
+
+	disabled := &rest.Config{}
+	applyRateLimits(disabled, &config.Config{KubeAPIQPS: 0, KubeAPIBurst: 100})
+	assert.Equal(t, float32(-1), disabled.QPS)
+	assert.Nil(t, disabled.RateLimiter)
+}
+
+func TestThrottleObservingRateLimiterCountsWaits(t *testing.T) {
+	limiter := &throttleObservingRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(10, 1),
+	}
+	before := promtestutil.ToFloat64(metrics.KubeAPIClientThrottled)
+
+	// The first request fits the burst; the second has to wait for a token.
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.Equal(t, before, promtestutil.ToFloat64(metrics.KubeAPIClientThrottled))
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.Equal(t, before+1, promtestutil.ToFloat64(metrics.KubeAPIClientThrottled))
+}
+
+func TestFederationHubClient(t *testing.T) {
+	local := &rest.Config{Host: "https://local.example"}
+
+	hub, err := FederationHubClient(&config.Config{}, local, InitScheme())
+	assert.NoError(t, err)
+	assert.Nil(t, hub, "federation is off without a cluster name")
+
+	hub, err = FederationHubClient(&config.Config{FederationClusterName: "us-east"}, local, InitScheme())
+	assert.NoError(t, err)
+	assert.NotNil(t, hub, "the local cluster is the hub without a kubeconfig")
+
+	_, err = FederationHubClient(&config.Config{
+		FederationClusterName:   "us-east",
+		FederationHubKubeconfig: "/nonexistent/hub.kubeconfig",
+	}, local, InitScheme())
+	assert.ErrorContains(t, err, "federation hub kubeconfig")
+}
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example
+- name: staging
+  cluster:
+    server: https://staging.example
+contexts:
+- name: dev
+  context:
+    cluster: dev
+- name: staging
+  context:
+    cluster: staging
+current-context: dev
+`
+
+func TestRESTConfigFromKubeconfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NoError(t, os.WriteFile(path, []byte(testKubeconfig), 0o600))
+
+	restConfig, err := RESTConfig(&config.Config{Kubeconfig: path, KubeAPIQPS: 5, KubeAPIBurst: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://dev.example", restConfig.Host, "the current context is used without --context")
+	assert.Equal(t, float32(5), restConfig.QPS)
+
+	restConfig, err = RESTConfig(&config.Config{Kubeconfig: path, KubeContext: "staging"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://staging.example", restConfig.Host)
+
+	_, err = RESTConfig(&config.Config{Kubeconfig: path, KubeContext: "prod"})
+	assert.Error(t, err, "an unknown context is an error")
+
+	_, err = RESTConfig(&config.Config{Kubeconfig: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err, "a kubeconfig given explicitly must exist")
+}
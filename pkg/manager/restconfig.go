@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/faults"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+// RESTConfig loads the Kubernetes client configuration and applies the
+// --kube-api-qps / --kube-api-burst limits. The rate limiter is set on the
+// config itself, so the manager and every clientset built from
+// mgr.GetConfig() share one token bucket. Binaries built with the
+// faultinject tag also route every request through faults.Transport.
+func RESTConfig(cfg *config.Config) (*rest.Config, error) {
+	restConfig, err := loadRESTConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	applyRateLimits(restConfig, cfg)
+	if faults.Enabled {
+		restConfig.Wrap(faults.Transport)
+	}
+	return restConfig, nil
+}
+
+// loadRESTConfig reads --kubeconfig and --context, falling back to
+// controller-runtime's lookup when neither is set. --kubeconfig may also come
+// from $KUBECONFIG, so it can be a list of files to merge; a single file must
+// exist.
+func loadRESTConfig(cfg *config.Config) (*rest.Config, error) {
+	if cfg.Kubeconfig == "" && cfg.KubeContext == "" {
+		return ctrl.GetConfig()
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if paths := filepath.SplitList(cfg.Kubeconfig); len(paths) == 1 {
+		rules.ExplicitPath = paths[0]
+	} else if len(paths) > 1 {
+		rules.Precedence = paths
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.KubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// FederationHubClient returns a client for the federation hub, or nil when
+// --federation-cluster-name is unset. The hub is read directly rather than
+// through an informer: each report is a read-modify-write under an optimistic
+// lock, which a lagging cache would keep failing. Without a hub kubeconfig the
+// local cluster is the hub.
+func FederationHubClient(cfg *config.Config, local *rest.Config, scheme *k8sruntime.Scheme) (client.Client, error) {
+	if cfg.FederationClusterName == "" {
+		return nil, nil
+	}
+	hubConfig := rest.CopyConfig(local)
+	if cfg.FederationHubKubeconfig != "" {
+		var err error
+		hubConfig, err = clientcmd.BuildConfigFromFlags("", cfg.FederationHubKubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load federation hub kubeconfig: %w", err)
+		}
+		applyRateLimits(hubConfig, cfg)
+	}
+	return client.New(hubConfig, client.Options{Scheme: scheme})
+}
+
+func applyRateLimits(restConfig *rest.Config, cfg *config.Config) {
+	if cfg.KubeAPIQPS <= 0 {
+		// client-go skips rate limiting entirely for negative QPS.
+		restConfig.QPS = -1
+		restConfig.RateLimiter = nil
+		return
+	}
+	restConfig.QPS = cfg.KubeAPIQPS
+	restConfig.Burst = cfg.KubeAPIBurst
+	restConfig.RateLimiter = &throttleObservingRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(cfg.KubeAPIQPS, cfg.KubeAPIBurst),
+	}
+}
+
+// throttleObservingRateLimiter counts requests that could not be admitted
+// immediately by the token bucket and had to wait.
+type throttleObservingRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+func (r *throttleObservingRateLimiter) Wait(ctx context.Context) error {
+	if r.TryAccept() {
+		return nil
+	}
+	metrics.KubeAPIClientThrottled.Inc()
+	return r.RateLimiter.Wait(ctx)
+}
+
+func (r *throttleObservingRateLimiter) Accept() {
+	if r.TryAccept() {
+		return
+	}
+	metrics.KubeAPIClientThrottled.Inc()
+	r.RateLimiter.Accept()
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MaxCRQLabels bounds the extra labels of the usage metrics: each one
+	// multiplies the series a label value change leaves behind in Prometheus.
+	MaxCRQLabels = 5
+	// maxCRQLabelValueLength truncates extra label values, which come from
+	// annotations any quota author can write.
+	maxCRQLabelValueLength = 64
+)
+
+// crqLabelName is a Prometheus label name that is not reserved.
+var crqLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var (
+	crqLabelsMu sync.Mutex
+	// crqLabelNames are the extra labels of CRQUsage and CRQTotalUsage.
+	crqLabelNames []string
+	// crqLabelValues are the extra label values last exported per quota.
+	crqLabelValues = make(map[string][]string)
+)
+
+// ValidateCRQLabelNames rejects extra usage metric labels that are not
+// Prometheus label names, clash with the labels the metrics already have, or
+// are more than MaxCRQLabels.
+func ValidateCRQLabelNames(names []string) error {
+	if len(names) > MaxCRQLabels {
+		return fmt.Errorf("at most %d extra metric labels are allowed, got %d", MaxCRQLabels, len(names))
+	}
+	for i, name := range names {
+		switch {
+		case !crqLabelName.MatchString(name) || strings.HasPrefix(name, "__"):
+			return fmt.Errorf("%q is not a Prometheus label name", name)
+		case name == labelCRQName || name == labelNamespace || name == labelResource:
+			return fmt.Errorf("%q is already a label of the usage metrics", name)
+		case slices.Contains(names[:i], name):
+			return fmt.Errorf("%q is listed twice", name)
+		}
+	}
+	return nil
+}
+
+// SetCRQLabelNames adds names, which must pass ValidateCRQLabelNames, to the
+// labels of CRQUsage and CRQTotalUsage. It must be called before
+// RegisterWebhookMetrics, since the registry keeps the label names the
+// metrics had when registered.
+func SetCRQLabelNames(names []string) {
+	crqLabelsMu.Lock()
+	defer crqLabelsMu.Unlock()
+	crqLabelNames = slices.Clone(names)
+	clear(crqLabelValues)
+	// Replace the vectors in place so every holder of the variables, such as
+	// the dashboard panels, sees the new labels.
+	*CRQUsage = *newCRQUsage(names)
+	*CRQTotalUsage = *newCRQTotalUsage(names)
+	crqUsageMu.Lock()
+	clear(crqUsageSeries)
+	crqUsageMu.Unlock()
+}
+
+// newCRQUsage returns the CRQUsage vector with the extra labels names.
+func newCRQUsage(names []string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pac_quota_controller_crq_usage",
+			Help: "Current usage of a resource for a ClusterResourceQuota in a namespace.",
+		},
+		append([]string{labelCRQName, labelNamespace, labelResource}, names...),
+	)
+}
+
+// newCRQTotalUsage returns the CRQTotalUsage vector with the extra labels names.
+func newCRQTotalUsage(names []string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pac_quota_controller_crq_total_usage",
+			Help: "Aggregated usage of a resource across all namespaces for a ClusterResourceQuota.",
+		},
+		// The per-namespace breakdown lives on CRQUsage; this metric is a single
+		// total per (crq, resource). Earlier shapes included a comma-joined
+		// `namespaces` label, which churned a new series on every namespace
+		// add/remove and was an unbounded-cardinality bomb at scale.
+		append([]string{labelCRQName, labelResource}, names...),
+	)
+}
+
+// CRQLabelValues returns the values of the extra usage metric labels of the
+// named quota, in the order of SetCRQLabelNames, from declared, the labels its
+// annotation declares. Labels it does not declare are empty, and values are
+// truncated to 64 bytes. When the values differ from those exported
+// last, the quota's usage series are dropped so the old values do not linger.
+func CRQLabelValues(crqName string, declared map[string]string) []string {
+	crqLabelsMu.Lock()
+	defer crqLabelsMu.Unlock()
+	if len(crqLabelNames) == 0 {
+		return nil
+	}
+	values := make([]string, len(crqLabelNames))
+	for i, name := range crqLabelNames {
+		value := declared[name]
+		if len(value) > maxCRQLabelValueLength {
+			value = strings.ToValidUTF8(value[:maxCRQLabelValueLength], "")
+		}
+		values[i] = value
+	}
+	if last, ok := crqLabelValues[crqName]; ok && !slices.Equal(last, values) {
+		deleteCRQUsage(crqName)
+	}
+	crqLabelValues[crqName] = values
+	return values
+}
+
+// deleteCRQUsage drops the CRQUsage and CRQTotalUsage series of a quota.
+func deleteCRQUsage(crqName string) {
+	labels := prometheus.Labels{labelCRQName: crqName}
+	CRQUsage.DeletePartialMatch(labels)
+	CRQTotalUsage.DeletePartialMatch(labels)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCRQLabelValuesFollowTheAnnotation(t *testing.T) {
+	SetCRQLabelNames([]string{"costcenter", "team"})
+	t.Cleanup(func() { SetCRQLabelNames(nil) })
+
+	values := CRQLabelValues("chargeback", map[string]string{"costcenter": "1234", "owner": "ignored"})
+	if got := strings.Join(values, ","); got != "1234," {
+		t.Fatalf("label values = %q, want the costcenter and an empty team", got)
+	}
+	CRQTotalUsage.WithLabelValues(append([]string{"chargeback", "pods"}, values...)...).Set(0.5)
+
+	values = CRQLabelValues("chargeback", map[string]string{"costcenter": strings.Repeat("9", 100)})
+	if len(values[0]) != maxCRQLabelValueLength {
+		t.Fatalf("label value of %d bytes, want it cut to %d", len(values[0]), maxCRQLabelValueLength)
+	}
+	if n := testutil.CollectAndCount(CRQTotalUsage); n != 0 {
+		t.Fatalf("%d series left with the old label values, want none", n)
+	}
+
+	CRQTotalUsage.WithLabelValues(append([]string{"chargeback", "pods"}, values...)...).Set(0.5)
+	ForgetCRQUsage("chargeback")
+	if n := testutil.CollectAndCount(CRQTotalUsage); n != 0 {
+		t.Fatalf("%d series left for a deleted quota, want none", n)
+	}
+}
+
+func TestCRQLabelValuesWithoutLabelNames(t *testing.T) {
+	if values := CRQLabelValues("plain", map[string]string{"costcenter": "1234"}); values != nil {
+		t.Fatalf("label values = %q, want none without --metrics-crq-labels", values)
+	}
+}
+
+func TestValidateCRQLabelNames(t *testing.T) {
+	for _, names := range [][]string{{"cost-center"}, {"__name"}, {"crq_name"}, {"team", "team"}} {
+		if err := ValidateCRQLabelNames(names); err == nil {
+			t.Errorf("ValidateCRQLabelNames(%q) succeeded, want an error", names)
+		}
+	}
+	if err := ValidateCRQLabelNames([]string{"costcenter", "team"}); err != nil {
+		t.Errorf("ValidateCRQLabelNames: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	crqUsageMu sync.Mutex
+	// crqUsageSeries are the usage series last set per quota, so the series a
+	// reconcile no longer sets can be deleted.
+	crqUsageSeries = make(map[string]usageSeries)
+)
+
+// usageSeries are the label values, without the extra labels, of the
+// CRQUsage and CRQTotalUsage series of one quota.
+type usageSeries struct {
+	namespaced map[namespacedSeries]struct{}
+	total      map[string]struct{}
+}
+
+type namespacedSeries struct {
+	namespace string
+	resource  string
+}
+
+// SetCRQUsage sets the CRQUsage series of the named quota from used, its
+// percent of hard by namespace and resource, and its CRQTotalUsage series from
+// total, by resource. extraLabels come from CRQLabelValues. The series set
+// for the quota last time that used and total no longer have, such as those
+// of a namespace that left the quota or a resource it stopped limiting, are
+// deleted.
+func SetCRQUsage(crqName string, extraLabels []string, used map[string]map[string]float64, total map[string]float64) {
+	crqUsageMu.Lock()
+	defer crqUsageMu.Unlock()
+	series := usageSeries{
+		namespaced: make(map[namespacedSeries]struct{}),
+		total:      make(map[string]struct{}, len(total)),
+	}
+	for namespace, byResource := range used {
+		for resourceName, percent := range byResource {
+			labels := append([]string{crqName, namespace, resourceName}, extraLabels...)
+			CRQUsage.WithLabelValues(labels...).Set(percent)
+			series.namespaced[namespacedSeries{namespace: namespace, resource: resourceName}] = struct{}{}
+		}
+	}
+	for resourceName, percent := range total {
+		labels := append([]string{crqName, resourceName}, extraLabels...)
+		CRQTotalUsage.WithLabelValues(labels...).Set(percent)
+		series.total[resourceName] = struct{}{}
+	}
+
+	// Match without the extra labels, which CRQLabelValues already cleans up
+	// after when they change.
+	for s := range crqUsageSeries[crqName].namespaced {
+		if _, ok := series.namespaced[s]; !ok {
+			CRQUsage.DeletePartialMatch(prometheus.Labels{
+				labelCRQName: crqName, labelNamespace: s.namespace, labelResource: s.resource,
+			})
+		}
+	}
+	for resourceName := range crqUsageSeries[crqName].total {
+		if _, ok := series.total[resourceName]; !ok {
+			CRQTotalUsage.DeletePartialMatch(prometheus.Labels{labelCRQName: crqName, labelResource: resourceName})
+		}
+	}
+	crqUsageSeries[crqName] = series
+}
+
+// ForgetCRQUsage drops the usage series and extra label values of a deleted
+// quota.
+func ForgetCRQUsage(crqName string) {
+	crqLabelsMu.Lock()
+	defer crqLabelsMu.Unlock()
+	crqUsageMu.Lock()
+	defer crqUsageMu.Unlock()
+	deleteCRQUsage(crqName)
+	delete(crqLabelValues, crqName)
+	delete(crqUsageSeries, crqName)
+}
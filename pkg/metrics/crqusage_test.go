@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSetCRQUsageDropsStaleSeries(t *testing.T) {
+	t.Cleanup(func() { ForgetCRQUsage("gc"); ForgetCRQUsage("other") })
+
+	SetCRQUsage("other", nil, map[string]map[string]float64{"other-ns": {"pods": 0.1}}, map[string]float64{"pods": 0.1})
+	SetCRQUsage("gc", nil,
+		map[string]map[string]float64{
+			"team-a": {"pods": 0.5, "requests.cpu": 0.25},
+			"team-b": {"pods": 0.25},
+		},
+		map[string]float64{"pods": 0.75, "requests.cpu": 0.25})
+	if n := testutil.CollectAndCount(CRQUsage); n != 4 {
+		t.Fatalf("%d namespace series, want 4", n)
+	}
+
+	// team-b leaves the quota and it stops limiting requests.cpu.
+	SetCRQUsage("gc", nil,
+		map[string]map[string]float64{"team-a": {"pods": 0.5}},
+		map[string]float64{"pods": 0.5})
+	if n := testutil.CollectAndCount(CRQUsage); n != 2 {
+		t.Fatalf("%d namespace series after team-b left, want team-a's and the other quota's", n)
+	}
+	if n := testutil.CollectAndCount(CRQTotalUsage); n != 2 {
+		t.Fatalf("%d total series after requests.cpu was dropped, want 2", n)
+	}
+	if v := testutil.ToFloat64(CRQUsage.WithLabelValues("gc", "team-a", "pods")); v != 0.5 {
+		t.Fatalf("team-a pods usage = %v, want 0.5", v)
+	}
+
+	ForgetCRQUsage("gc")
+	if n := testutil.CollectAndCount(CRQUsage); n != 1 {
+		t.Fatalf("%d namespace series after the quota was deleted, want only the other quota's", n)
+	}
+	if n := testutil.CollectAndCount(CRQTotalUsage); n != 1 {
+		t.Fatalf("%d total series after the quota was deleted, want only the other quota's", n)
+	}
+}
+
+func TestSetCRQUsageWithExtraLabels(t *testing.T) {
+	SetCRQLabelNames([]string{"costcenter"})
+	t.Cleanup(func() { SetCRQLabelNames(nil) })
+
+	values := CRQLabelValues("chargeback", map[string]string{"costcenter": "1234"})
+	SetCRQUsage("chargeback", values,
+		map[string]map[string]float64{"team-a": {"pods": 0.5}, "team-b": {"pods": 0.5}},
+		map[string]float64{"pods": 1})
+	SetCRQUsage("chargeback", values,
+		map[string]map[string]float64{"team-a": {"pods": 0.5}},
+		map[string]float64{"pods": 0.5})
+	if n := testutil.CollectAndCount(CRQUsage); n != 1 {
+		t.Fatalf("%d namespace series after team-b left, want 1", n)
+	}
+
+	ForgetCRQUsage("chargeback")
+	if n := testutil.CollectAndCount(CRQUsage) + testutil.CollectAndCount(CRQTotalUsage); n != 0 {
+		t.Fatalf("%d series left for a deleted quota, want none", n)
+	}
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DashboardPath is where the metrics server serves the Grafana dashboard.
+const DashboardPath = "/dashboards/crq.json"
+
+// dashboardBase holds the dashboard settings and variables; Dashboard fills in
+// the crq variable's query and the panels.
+//
+//go:embed dashboard.json
+var dashboardBase []byte
+
+// dashboardPanel is one time series panel. Expr is a format string whose %s
+// is replaced by the name of Metric, so renaming a metric updates every query
+// that reads it.
+type dashboardPanel struct {
+	Title  string
+	Metric prometheus.Collector
+	Expr   string
+	Legend string
+	Unit   string
+}
+
+var dashboardPanels = []dashboardPanel{
+	{
+		Title:  "Usage by resource",
+		Metric: CRQTotalUsage,
+		Expr:   `%s{crq_name=~"$crq"}`,
+		Legend: "{{crq_name}} {{resource}}",
+		Unit:   "percentunit",
+	},
+	{
+		Title:  "Usage by namespace",
+		Metric: CRQUsage,
+		Expr:   `%s{crq_name=~"$crq"}`,
+		Legend: "{{crq_name}} {{namespace}} {{resource}}",
+		Unit:   "percentunit",
+	},
+	{
+		Title:  "Usage by owner kind",
+		Metric: CRQUsageByOwnerKind,
+		Expr:   `%s{crq_name=~"$crq"}`,
+		Legend: "{{crq_name}} {{owner_kind}} {{resource}}",
+		Unit:   "percentunit",
+	},
+	{
+		Title:  "HPA replicas within quota",
+		Metric: HPAMaxReplicasWithinQuota,
+		Expr:   `%s{crq_name=~"$crq"}`,
+		Legend: "{{namespace}}/{{hpa}}",
+		Unit:   "short",
+	},
+	{
+		Title:  "Admission decisions",
+		Metric: WebhookAdmissionDecision,
+		Expr:   `sum by (webhook, decision) (rate(%s[$__rate_interval]))`,
+		Legend: "{{webhook}} {{decision}}",
+		Unit:   "reqps",
+	},
+	{
+		Title:  "Admission denials by reason",
+		Metric: WebhookAdmissionDenied,
+		Expr:   `sum by (webhook, reason) (rate(%s[$__rate_interval]))`,
+		Legend: "{{webhook}} {{reason}}",
+		Unit:   "reqps",
+	},
+	{
+		Title:  "Admission latency (p99)",
+		Metric: WebhookValidationDuration,
+		Expr:   `histogram_quantile(0.99, sum by (webhook, le) (rate(%s_bucket[$__rate_interval])))`,
+		Legend: "{{webhook}}",
+		Unit:   "s",
+	},
+	{
+		Title:  "Reconciles",
+		Metric: QuotaReconcileTotal,
+		Expr:   `sum by (crq_name, status) (rate(%s{crq_name=~"$crq"}[$__rate_interval]))`,
+		Legend: "{{crq_name}} {{status}}",
+		Unit:   "ops",
+	},
+	{
+		Title:  "Aggregation duration (p99)",
+		Metric: QuotaAggregationDuration,
+		Expr: `histogram_quantile(0.99, ` +
+			`sum by (crq_name, le) (rate(%s_bucket{crq_name=~"$crq"}[$__rate_interval])))`,
+		Legend: "{{crq_name}}",
+		Unit:   "s",
+	},
+}
+
+// descName extracts the fully-qualified name from a prometheus.Desc, whose
+// String is the only place client_golang exposes it.
+var descName = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// metricName returns the name c exports its series under.
+func metricName(c prometheus.Collector) (string, error) {
+	ch := make(chan *prometheus.Desc, 1)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+	var name string
+	for desc := range ch {
+		if m := descName.FindStringSubmatch(desc.String()); m != nil && name == "" {
+			name = m[1]
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("collector %T describes no metric", c)
+	}
+	return name, nil
+}
+
+// Dashboard renders the Grafana dashboard for ClusterResourceQuotas from the
+// names of the metrics this package registers.
+func Dashboard() ([]byte, error) {
+	var dashboard map[string]any
+	if err := json.Unmarshal(dashboardBase, &dashboard); err != nil {
+		return nil, fmt.Errorf("parsing embedded dashboard: %w", err)
+	}
+
+	usage, err := metricName(CRQTotalUsage)
+	if err != nil {
+		return nil, err
+	}
+	templating, _ := dashboard["templating"].(map[string]any)
+	variables, _ := templating["list"].([]any)
+	for _, v := range variables {
+		if variable, ok := v.(map[string]any); ok && variable["name"] == "crq" {
+			variable["query"] = fmt.Sprintf("label_values(%s, crq_name)", usage)
+		}
+	}
+
+	panels := make([]any, 0, len(dashboardPanels))
+	for i, p := range dashboardPanels {
+		name, err := metricName(p.Metric)
+		if err != nil {
+			return nil, fmt.Errorf("panel %q: %w", p.Title, err)
+		}
+		panels = append(panels, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      p.Title,
+			"datasource": map[string]any{"type": "prometheus", "uid": "${datasource}"},
+			// Two panels per row, eight rows high.
+			"gridPos": map[string]any{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults": map[string]any{"unit": p.Unit},
+			},
+			"targets": []any{map[string]any{
+				"refId":        "A",
+				"expr":         fmt.Sprintf(p.Expr, name),
+				"legendFormat": p.Legend,
+			}},
+		})
+	}
+	dashboard["panels"] = panels
+
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// DashboardHandler serves the output of Dashboard as JSON.
+func DashboardHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		body, err := Dashboard()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Every panel must query a metric this package registers, so a renamed metric
+// cannot leave the dashboard charting a series that no longer exists.
+func TestDashboardQueriesRegisteredMetrics(t *testing.T) {
+	body, err := Dashboard()
+	if err != nil {
+		t.Fatalf("Dashboard() error: %v", err)
+	}
+	var dashboard struct {
+		Panels []struct {
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(body, &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+	if len(dashboard.Panels) != len(dashboardPanels) {
+		t.Fatalf("got %d panels, want %d", len(dashboard.Panels), len(dashboardPanels))
+	}
+	for i, panel := range dashboard.Panels {
+		name, err := metricName(dashboardPanels[i].Metric)
+		if err != nil {
+			t.Fatalf("panel %q: %v", panel.Title, err)
+		}
+		if !strings.HasPrefix(name, "pac_quota_controller_") {
+			t.Errorf("panel %q charts %q, outside the controller's namespace", panel.Title, name)
+		}
+		if len(panel.Targets) != 1 || !strings.Contains(panel.Targets[0].Expr, name) {
+			t.Errorf("panel %q does not query %q: %+v", panel.Title, name, panel.Targets)
+		}
+	}
+}
+
+func TestDashboardHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	DashboardHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, DashboardPath, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", ct)
+	}
+	if !strings.Contains(w.Body.String(), `label_values(pac_quota_controller_crq_total_usage, crq_name)`) {
+		t.Errorf("crq variable does not list the quotas reporting usage:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// LitePath is where the webhook server serves LiteHandler when the metrics
+// server is disabled.
+const LitePath = "/metrics-lite"
+
+// liteRegistry holds the metrics kept available with the metrics server off:
+// enough to tell which build is running and whether it reconciles, without the
+// per-namespace usage series.
+var liteRegistry = func() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(BuildInfo, QuotaReconcileTotal, QuotaReconcileErrors)
+	return r
+}()
+
+// LiteHandler serves the build info and reconcile counters in the Prometheus
+// text format.
+func LiteHandler() http.Handler {
+	return promhttp.HandlerFor(liteRegistry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLiteHandlerServesBuildInfoAndReconcileCounters(t *testing.T) {
+	SetBuildInfo("v1.2.3", "abc123")
+	QuotaReconcileTotal.WithLabelValues("lite-crq", "started").Inc()
+	QuotaReconcileErrors.WithLabelValues("lite-crq").Inc()
+	CRQUsage.WithLabelValues("lite-crq", "dev", "pods").Set(0.5)
+	defer CRQUsage.DeleteLabelValues("lite-crq", "dev", "pods")
+
+	rec := httptest.NewRecorder()
+	LiteHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LitePath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`pac_quota_controller_build_info{commit="abc123",go_version=`,
+		`pac_quota_controller_reconcile_total{crq_name="lite-crq",status="started"}`,
+		`pac_quota_controller_reconcile_errors_total{crq_name="lite-crq"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "pac_quota_controller_crq_usage") {
+		t.Errorf("body serves usage gauges:\n%s", body)
+	}
+}
@@ -1,7 +1,9 @@
 package metrics
 
 import (
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -13,27 +15,46 @@ const (
 	labelNamespace = "namespace"
 	labelWebhook   = "webhook"
 	labelResource  = "resource"
+	labelOwnerKind = "owner_kind"
+	labelHPA       = "hpa"
+	labelKind      = "kind"
 )
 
 var (
-	CRQUsage = prometheus.NewGaugeVec(
+	CRQUsage      = newCRQUsage(nil)
+	CRQTotalUsage = newCRQTotalUsage(nil)
+	// CRQUsageByOwnerKind partitions pod-derived usage (compute and pod count)
+	// by the kind of workload owning each pod, e.g. Deployment, StatefulSet,
+	// Job, or Pod for bare pods. Values are fractions of the hard limit, like
+	// CRQUsage, and sum to CRQTotalUsage: in Actual mode CPU and memory come
+	// from metrics-server for both. Series of a deleted CRQ are removed on its
+	// next reconcile.
+	CRQUsageByOwnerKind = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "pac_quota_controller_crq_usage",
-			Help: "Current usage of a resource for a ClusterResourceQuota in a namespace.",
+			Name: "pac_quota_controller_crq_usage_by_owner_kind",
+			Help: "Usage of a pod-derived resource for a ClusterResourceQuota, partitioned by owner kind.",
 		},
-		[]string{labelCRQName, labelNamespace, labelResource},
+		[]string{labelCRQName, labelOwnerKind, labelResource},
 	)
-	CRQTotalUsage = prometheus.NewGaugeVec(
+	// CRQExhaustionETA is how long until a resource of a ClusterResourceQuota
+	// reaches its hard limit at the rate its usage grew over the last hour.
+	// Resources with flat or falling usage have no series.
+	CRQExhaustionETA = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "pac_quota_controller_crq_total_usage",
-			Help: "Aggregated usage of a resource across all namespaces for a ClusterResourceQuota.",
+			Name: "pac_crq_exhaustion_eta_seconds",
+			Help: "Seconds until a resource of a ClusterResourceQuota reaches its hard limit at its recent growth rate.",
 		},
-		// The per-namespace breakdown lives on CRQUsage; this metric is a single
-		// total per (crq, resource). Earlier shapes included a comma-joined
-		// `namespaces` label, which churned a new series on every namespace
-		// add/remove and was an unbounded-cardinality bomb at scale.
 		[]string{labelCRQName, labelResource},
 	)
+	// HPAMaxReplicasWithinQuota is the most replicas of each advised
+	// HorizontalPodAutoscaler's target that fit its ClusterResourceQuota.
+	HPAMaxReplicasWithinQuota = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pac_quota_controller_hpa_max_replicas_within_quota",
+			Help: "Most replicas of a HorizontalPodAutoscaler's target that fit its ClusterResourceQuota.",
+		},
+		[]string{labelCRQName, labelNamespace, labelHPA},
+	)
 	WebhookValidationCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "pac_quota_controller_webhook_validation_total",
@@ -63,7 +84,9 @@ var (
 	)
 	// WebhookAdmissionDenied breaks down denials by reason so operators can
 	// distinguish working-as-intended quota_exceeded from broken-config
-	// signals (bad_request, gvk_mismatch, missing_namespace).
+	// signals (bad_request, gvk_mismatch, missing_namespace), throttled
+	// creations (rate_limited), and failed API server lookups a retry can get
+	// past (transient_error).
 	WebhookAdmissionDenied = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "pac_quota_controller_webhook_admission_denied_total",
@@ -72,7 +95,7 @@ var (
 		[]string{labelWebhook, "reason"},
 	)
 	// WebhookCRQLookup counts CRQ resolution outcomes during admission.
-	// Result values: found, not_found, namespace_error, crq_error, no_client.
+	// Result values: found, not_found, paused, namespace_error, crq_error, no_client.
 	WebhookCRQLookup = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "pac_quota_controller_webhook_crq_lookup_total",
@@ -89,6 +112,64 @@ var (
 		},
 		[]string{labelCRQName, labelResource},
 	)
+	// WebhookIncompleteUsage counts admissions the webhook left to the API
+	// server's failurePolicy because the CRQ usage was not fully counted.
+	WebhookIncompleteUsage = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_incomplete_usage_total",
+			Help: "Number of webhook admissions failed because the CRQ usage was incomplete, leaving the decision to the failurePolicy.",
+		},
+		[]string{"webhook"},
+	)
+	// WebhookTimeoutBudgetExceeded counts admissions left to the failurePolicy
+	// because validation did not finish within --webhook-timeout-budget-percent
+	// of the API server's timeout.
+	WebhookTimeoutBudgetExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_timeout_budget_exceeded_total",
+			Help: "Number of webhook admissions left to the failurePolicy because validation did not finish within its " +
+				"timeout budget.",
+		},
+		[]string{"webhook"},
+	)
+	// WebhookExemptedDenials counts requests admitted with a warning, instead
+	// of denied, because their quota was within an enforcement exemption window.
+	WebhookExemptedDenials = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_exempted_denials_total",
+			Help: "Number of webhook admissions exceeding a quota admitted during its enforcement exemption window.",
+		},
+		[]string{labelCRQName, labelWebhook},
+	)
+	// WebhookCreationsThrottled counts creations denied because their quota's
+	// spec.creationRates allowance was spent.
+	WebhookCreationsThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_creations_throttled_total",
+			Help: "Number of creations denied because they exceeded their ClusterResourceQuota's creation rate.",
+		},
+		[]string{labelCRQName, labelResource},
+	)
+	// WebhookObservedDenials counts requests admitted with a warning, instead
+	// of denied, because the controller runs the observe profile.
+	// Reason values: quota_exceeded, bad_request, rate_limited, transient_error,
+	// incomplete_usage.
+	WebhookObservedDenials = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_observed_denials_total",
+			Help: "Number of webhook admissions that would have been denied, admitted under the observe profile.",
+		},
+		[]string{labelWebhook, "reason"},
+	)
+	// WebhookDecisionCache counts pod admission decision cache lookups.
+	// Result values: hit, miss.
+	WebhookDecisionCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_webhook_decision_cache_total",
+			Help: "Pod admission decision cache lookups, by result.",
+		},
+		[]string{"result"},
+	)
 
 	// New metrics for controller reconciliation
 	QuotaReconcileTotal = prometheus.NewCounterVec(
@@ -105,6 +186,53 @@ var (
 		},
 		[]string{labelCRQName},
 	)
+	// QuotaReconcileDuration, QuotaReconcileNamespaces and QuotaReconcileListCalls
+	// describe each reconcile of a ClusterResourceQuota, to find the quotas that
+	// cost the controller most. Series of a deleted CRQ are removed when the
+	// controller sees it gone.
+	QuotaReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pac_quota_controller_reconcile_duration_seconds",
+			Help:    "Time taken by each reconcile of a ClusterResourceQuota.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{labelCRQName},
+	)
+	QuotaReconcileNamespaces = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pac_quota_controller_reconcile_namespaces",
+			Help:    "Namespaces selected by a ClusterResourceQuota per reconcile.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		},
+		[]string{labelCRQName},
+	)
+	QuotaReconcileListCalls = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pac_quota_controller_reconcile_list_calls",
+			Help:    "List calls made by a reconcile of a ClusterResourceQuota.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+		},
+		[]string{labelCRQName},
+	)
+	// QuotaPaused is 1 while a ClusterResourceQuota is paused by its
+	// quota.powerapp.cloud/paused annotation, and 0 otherwise.
+	QuotaPaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pac_quota_controller_crq_paused",
+			Help: "Whether a ClusterResourceQuota is paused (1) or not (0).",
+		},
+		[]string{labelCRQName},
+	)
+	// QuotaStatusUpdatesSkipped counts reconciles that left a
+	// ClusterResourceQuota's status alone because it already held the computed
+	// usage, compared by value rather than by how each quantity is written.
+	QuotaStatusUpdatesSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_status_updates_skipped_total",
+			Help: "Status updates of a ClusterResourceQuota skipped because nothing changed.",
+		},
+		[]string{labelCRQName},
+	)
 	QuotaAggregationDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name: "pac_quota_controller_aggregation_duration_seconds",
@@ -130,6 +258,43 @@ var (
 		},
 		[]string{labelResource},
 	)
+	// WatchEvents, WatchEnqueues and WatchMapDuration describe the watches
+	// that re-enqueue ClusterResourceQuotas, by the plural resource name of the
+	// watched kind, so pod churn swamping the reconciler shows up before its
+	// queue does. The queue itself is controller-runtime's
+	// workqueue_depth{name="clusterresourcequota"}.
+	WatchEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_watch_events_total",
+			Help: "Watch events mapped to ClusterResourceQuotas, by watched kind.",
+		},
+		[]string{labelKind},
+	)
+	WatchEnqueues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_watch_enqueues_total",
+			Help: "ClusterResourceQuota reconciles requested by watch events, by watched kind.",
+		},
+		[]string{labelKind},
+	)
+	WatchMapDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "pac_quota_controller_watch_map_duration_seconds",
+			Help: "Time taken to map a watch event to the ClusterResourceQuotas it affects, by watched kind.",
+			// Mapping reads the cache, so it should stay well under a millisecond.
+			Buckets: []float64{0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+		[]string{labelKind},
+	)
+	// FederationReportErrors counts reconciles that could not exchange usage
+	// with the federation hub. Admission then uses the last usage read from it.
+	FederationReportErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_federation_report_errors_total",
+			Help: "Reconciles that failed to report usage to the federation hub.",
+		},
+		[]string{labelCRQName},
+	)
 	// EventsCleanedTotal counts events deleted by the cleanup loop.
 	// Going to zero is the signal that cleanup itself has regressed (RBAC, query bug, etc.).
 	EventsCleanedTotal = prometheus.NewCounter(
@@ -138,6 +303,23 @@ var (
 			Help: "PAC quota events deleted by the cleanup loop.",
 		},
 	)
+	// KubeAPIClientThrottled counts Kubernetes API requests delayed by the
+	// client-side rate limiter (--kube-api-qps / --kube-api-burst). A steady
+	// rate means the limits are too low for the cluster's churn.
+	KubeAPIClientThrottled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "pac_quota_controller_kube_api_client_throttled_total",
+			Help: "Kubernetes API requests delayed by client-side rate limiting.",
+		},
+	)
+	// BuildInfo is always 1; its labels identify the running build.
+	BuildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pac_quota_controller_build_info",
+			Help: "Build information of the running controller, as labels.",
+		},
+		[]string{"version", "commit", "go_version"},
+	)
 
 	// Use controller-runtime's global registry
 	registerOnce sync.Once
@@ -148,18 +330,58 @@ func RegisterWebhookMetrics() {
 		crmetrics.Registry.MustRegister(
 			CRQUsage,
 			CRQTotalUsage,
+			CRQUsageByOwnerKind,
+			CRQExhaustionETA,
+			HPAMaxReplicasWithinQuota,
 			WebhookValidationCount,
 			WebhookValidationDuration,
 			WebhookAdmissionDecision,
 			WebhookAdmissionDenied,
 			WebhookCRQLookup,
 			WebhookStatusMissing,
+			WebhookIncompleteUsage,
+			WebhookTimeoutBudgetExceeded,
+			WebhookExemptedDenials,
+			WebhookObservedDenials,
+			WebhookCreationsThrottled,
+			WebhookDecisionCache,
 			QuotaReconcileTotal,
 			QuotaReconcileErrors,
+			QuotaReconcileDuration,
+			QuotaReconcileNamespaces,
+			QuotaReconcileListCalls,
+			QuotaPaused,
+			QuotaStatusUpdatesSkipped,
 			QuotaAggregationDuration,
 			QuotaAggregationStepDuration,
 			QuotaUnsupportedResource,
+			WatchEvents,
+			WatchEnqueues,
+			WatchMapDuration,
+			FederationReportErrors,
 			EventsCleanedTotal,
+			KubeAPIClientThrottled,
+			BuildInfo,
 		)
 	})
 }
+
+// SetBuildInfo records the version and commit of the running build.
+func SetBuildInfo(version, commit string) {
+	BuildInfo.Reset()
+	BuildInfo.WithLabelValues(version, commit, runtime.Version()).Set(1)
+}
+
+// RecordWatchEvent records a watch event of kind, the reconcile requests it
+// enqueued and how long mapping it took.
+func RecordWatchEvent(kind string, enqueued int, took time.Duration) {
+	WatchEvents.WithLabelValues(kind).Inc()
+	WatchEnqueues.WithLabelValues(kind).Add(float64(enqueued))
+	WatchMapDuration.WithLabelValues(kind).Observe(took.Seconds())
+}
+
+// DeleteHPAMaxReplicas removes the advisory series of a HorizontalPodAutoscaler,
+// whichever ClusterResourceQuota it was advised against.
+func DeleteHPAMaxReplicas(namespace, name string) {
+	HPAMaxReplicasWithinQuota.DeletePartialMatch(prometheus.Labels{labelNamespace: namespace, labelHPA: name})
+}
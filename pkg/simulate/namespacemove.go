@@ -0,0 +1,155 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+)
+
+// Quotas answers, by the quota controller's own rules, which quotas select a
+// namespace and what the namespace uses of a quota's resources.
+type Quotas interface {
+	// SelectsNamespace reports whether crq governs ns.
+	SelectsNamespace(crq *quotav1alpha1.ClusterResourceQuota, ns *corev1.Namespace) (bool, error)
+	// NamespaceUsage counts namespace's usage of crq's resources and lists the
+	// resources it could not fully count.
+	NamespaceUsage(
+		ctx context.Context,
+		crq *quotav1alpha1.ClusterResourceQuota,
+		namespace string,
+	) (quotav1alpha1.ResourceList, []corev1.ResourceName, error)
+}
+
+// NamespaceMoveSimulator is the Simulator backed by the cluster: namespaces
+// and quotas are read with a client and counted by Quotas.
+type NamespaceMoveSimulator struct {
+	client client.Reader
+	quotas Quotas
+}
+
+// NewNamespaceMoveSimulator creates a NamespaceMoveSimulator reading from c.
+func NewNamespaceMoveSimulator(c client.Reader, quotas Quotas) *NamespaceMoveSimulator {
+	return &NamespaceMoveSimulator{client: c, quotas: quotas}
+}
+
+// SimulateNamespaceMove reports which CRQs would gain or lose the namespace if
+// its labels were replaced by newLabels, and what each affected CRQ's usage
+// would then be. The namespace's usage is recomputed from the live cluster and
+// added to or taken from the usage in each CRQ's status. Nothing is written.
+func (s *NamespaceMoveSimulator) SimulateNamespaceMove(
+	ctx context.Context,
+	name string,
+	newLabels map[string]string,
+) (*NamespaceMove, error) {
+	ns := &corev1.Namespace{}
+	if err := s.client.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	moved := ns.DeepCopy()
+	moved.Labels = newLabels
+
+	crqList := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := s.client.List(ctx, crqList); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterResourceQuotas: %w", err)
+	}
+	sort.Slice(crqList.Items, func(i, j int) bool { return crqList.Items[i].Name < crqList.Items[j].Name })
+
+	result := &NamespaceMove{Namespace: name, Changes: []QuotaChange{}}
+	for i := range crqList.Items {
+		crq := &crqList.Items[i]
+		before, err := s.quotas.SelectsNamespace(crq, ns)
+		if err != nil {
+			return nil, err
+		}
+		after, err := s.quotas.SelectsNamespace(crq, moved)
+		if err != nil {
+			return nil, err
+		}
+		if before == after {
+			if before {
+				result.Unchanged = append(result.Unchanged, crq.Name)
+			}
+			continue
+		}
+
+		effect := EffectGain
+		if before {
+			effect = EffectLose
+		}
+		change, err := s.quotaChange(ctx, crq, name, effect)
+		if err != nil {
+			return nil, err
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	return result, nil
+}
+
+// quotaChange computes crq's usage once namespace joins or leaves it.
+func (s *NamespaceMoveSimulator) quotaChange(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespace string,
+	effect Effect,
+) (QuotaChange, error) {
+	nsUsage, incomplete, err := s.quotas.NamespaceUsage(ctx, crq, namespace)
+	if err != nil {
+		return QuotaChange{}, fmt.Errorf("failed to compute usage of namespace %s for %s: %w",
+			namespace, crq.Name, err)
+	}
+
+	change := QuotaChange{Name: crq.Name, Effect: effect}
+	hard := crq.Spec.TrackedHard()
+	resourceNames := make([]corev1.ResourceName, 0, len(hard))
+	for resourceName := range hard {
+		resourceNames = append(resourceNames, resourceName)
+	}
+	sort.Slice(resourceNames, func(i, j int) bool { return resourceNames[i] < resourceNames[j] })
+
+	for _, resourceName := range resourceNames {
+		limit := hard[resourceName]
+		used := crq.Status.Total.Used[resourceName]
+		nsUsed := nsUsage[resourceName]
+		requested := nsUsed.DeepCopy()
+		if effect == EffectLose {
+			requested.Neg()
+		}
+		projected := projection.Project(resourceName, used, requested, limit)
+		usedAfter := projected.Total()
+		change.Resources = append(change.Resources, ResourceImpact{
+			Resource:      resourceName,
+			Hard:          limit,
+			NamespaceUsed: nsUsed,
+			Used:          used,
+			UsedAfter:     usedAfter,
+			Ratio:         ratio(used, limit),
+			RatioAfter:    ratio(usedAfter, limit),
+		})
+		if projected.Exceeds() {
+			change.Exceeded = append(change.Exceeded, resourceName)
+		}
+	}
+	for _, resourceName := range incomplete {
+		if _, ok := hard[resourceName]; ok {
+			change.Incomplete = append(change.Incomplete, resourceName)
+		}
+	}
+	sort.Slice(change.Incomplete, func(i, j int) bool { return change.Incomplete[i] < change.Incomplete[j] })
+	return change, nil
+}
+
+// ratio is used/hard, or 0 when hard is not positive.
+func ratio(used, hard resource.Quantity) float64 {
+	if hard.Value() <= 0 {
+		return 0
+	}
+	return used.AsApproximateFloat64() / hard.AsApproximateFloat64()
+}
@@ -0,0 +1,83 @@
+package simulate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+// teamQuotas selects namespaces by their team label and reports a fixed usage.
+type teamQuotas struct {
+	used       quotav1alpha1.ResourceList
+	incomplete []corev1.ResourceName
+}
+
+func (q teamQuotas) SelectsNamespace(crq *quotav1alpha1.ClusterResourceQuota, ns *corev1.Namespace) (bool, error) {
+	return crq.Spec.NamespaceSelector.MatchLabels["team"] == ns.Labels["team"], nil
+}
+
+func (q teamQuotas) NamespaceUsage(
+	context.Context, *quotav1alpha1.ClusterResourceQuota, string,
+) (quotav1alpha1.ResourceList, []corev1.ResourceName, error) {
+	return q.used, q.incomplete, nil
+}
+
+func teamQuota(name, team, hard, used string) *quotav1alpha1.ClusterResourceQuota {
+	return &quotav1alpha1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": team}},
+			Hard:              quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse(hard)},
+		},
+		Status: quotav1alpha1.ClusterResourceQuotaStatus{
+			Total: quotav1alpha1.ResourceQuotaStatus{
+				Used: quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse(used)},
+			},
+		},
+	}
+}
+
+func TestNamespaceMoveSimulator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, quotav1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		teamQuota("team-a", "a", "10", "4"),
+		teamQuota("team-b", "b", "5", "4"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "move-me", Labels: map[string]string{"team": "a"}}},
+	).Build()
+	quotas := teamQuotas{
+		used:       quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+		incomplete: []corev1.ResourceName{corev1.ResourcePods, corev1.ResourceServices},
+	}
+
+	result, err := NewNamespaceMoveSimulator(c, quotas).SimulateNamespaceMove(
+		context.Background(), "move-me", map[string]string{"team": "b"})
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 2)
+
+	lose := result.Changes[0]
+	assert.Equal(t, "team-a", lose.Name)
+	assert.Equal(t, EffectLose, lose.Effect)
+	assert.Equal(t, int64(2), lose.Resources[0].UsedAfter.Value())
+	assert.InDelta(t, 0.2, lose.Resources[0].RatioAfter, 1e-9)
+	assert.Empty(t, lose.Exceeded)
+
+	gain := result.Changes[1]
+	assert.Equal(t, "team-b", gain.Name)
+	assert.Equal(t, EffectGain, gain.Effect)
+	assert.Equal(t, int64(6), gain.Resources[0].UsedAfter.Value())
+	assert.Equal(t, []corev1.ResourceName{corev1.ResourcePods}, gain.Exceeded)
+	assert.Equal(t, []corev1.ResourceName{corev1.ResourcePods}, gain.Incomplete,
+		"only incomplete resources the quota limits are reported")
+}
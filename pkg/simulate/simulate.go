@@ -0,0 +1,140 @@
+// Package simulate serves what-if queries against the quotas in the cluster,
+// so platform admins can see the effect of a change before making it.
+package simulate
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamespaceMovePath is the route serving namespace relabeling simulations.
+const NamespaceMovePath = "/simulate/namespace-move"
+
+// Effect is how a relabeled namespace's membership in a quota changes.
+type Effect string
+
+const (
+	// EffectGain means the quota would start selecting the namespace.
+	EffectGain Effect = "Gain"
+	// EffectLose means the quota would stop selecting the namespace.
+	EffectLose Effect = "Lose"
+)
+
+// NamespaceMoveRequest asks what would happen if Namespace had Labels instead
+// of its current labels.
+type NamespaceMoveRequest struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// NamespaceMove is the outcome of relabeling a namespace.
+type NamespaceMove struct {
+	Namespace string `json:"namespace"`
+	// Changes lists, by quota name, the quotas that would gain or lose the
+	// namespace.
+	Changes []QuotaChange `json:"changes"`
+	// Unchanged names the quotas that select the namespace both before and
+	// after the move.
+	Unchanged []string `json:"unchanged,omitempty"`
+}
+
+// QuotaChange is the effect of a namespace move on one quota.
+type QuotaChange struct {
+	Name      string           `json:"name"`
+	Effect    Effect           `json:"effect"`
+	Resources []ResourceImpact `json:"resources,omitempty"`
+	// Exceeded lists the resources whose usage would go over hard.
+	Exceeded []corev1.ResourceName `json:"exceeded,omitempty"`
+	// Incomplete lists the resources whose namespace usage could not be fully
+	// counted; their UsedAfter is a lower bound when gaining.
+	Incomplete []corev1.ResourceName `json:"incomplete,omitempty"`
+}
+
+// ResourceImpact is one hard limit of a quota before and after a move. Ratios
+// are used/hard, 0 when hard is zero.
+type ResourceImpact struct {
+	Resource corev1.ResourceName `json:"resource"`
+	Hard     resource.Quantity   `json:"hard"`
+	// NamespaceUsed is the moved namespace's own usage of Resource.
+	NamespaceUsed resource.Quantity `json:"namespaceUsed"`
+	Used          resource.Quantity `json:"used"`
+	UsedAfter     resource.Quantity `json:"usedAfter"`
+	Ratio         float64           `json:"ratio"`
+	RatioAfter    float64           `json:"ratioAfter"`
+}
+
+// Simulator computes the effect of relabeling a namespace without changing
+// anything in the cluster.
+type Simulator interface {
+	SimulateNamespaceMove(ctx context.Context, namespace string, labels map[string]string) (*NamespaceMove, error)
+}
+
+// Handler serves Simulator results over HTTP.
+type Handler struct {
+	simulator Simulator
+	logger    *zap.Logger
+}
+
+// NewHandler creates a Handler answering with simulator.
+func NewHandler(simulator Simulator, logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{simulator: simulator, logger: logger.Named("simulate")}
+}
+
+// Register adds the simulation routes to r.
+func (h *Handler) Register(r gin.IRouter) {
+	r.POST(NamespaceMovePath, h.namespaceMove)
+}
+
+func (h *Handler) namespaceMove(c *gin.Context) {
+	var req NamespaceMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if msg := validateRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	result, err := h.simulator.SimulateNamespaceMove(c.Request.Context(), req.Namespace, req.Labels)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Namespace move simulation failed", zap.String("namespace", req.Namespace), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// validateRequest returns why req cannot be simulated, or "" if it can.
+func validateRequest(req *NamespaceMoveRequest) string {
+	if req.Namespace == "" {
+		return "namespace is required"
+	}
+	if errs := validation.IsDNS1123Label(req.Namespace); len(errs) > 0 {
+		return "invalid namespace: " + strings.Join(errs, "; ")
+	}
+	for key, value := range req.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return "invalid label key " + key + ": " + strings.Join(errs, "; ")
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return "invalid label value for " + key + ": " + strings.Join(errs, "; ")
+		}
+	}
+	return ""
+}
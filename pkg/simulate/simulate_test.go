@@ -0,0 +1,88 @@
+package simulate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeSimulator struct {
+	namespace string
+	labels    map[string]string
+	err       error
+}
+
+func (f *fakeSimulator) SimulateNamespaceMove(
+	_ context.Context, namespace string, labels map[string]string,
+) (*NamespaceMove, error) {
+	f.namespace, f.labels = namespace, labels
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &NamespaceMove{
+		Namespace: namespace,
+		Changes:   []QuotaChange{{Name: "team-b", Effect: EffectGain}},
+	}, nil
+}
+
+func post(t *testing.T, sim Simulator, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(sim, zap.NewNop()).Register(router)
+
+	req := httptest.NewRequest(http.MethodPost, NamespaceMovePath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNamespaceMove(t *testing.T) {
+	sim := &fakeSimulator{}
+	w := post(t, sim, `{"namespace":"team-a-dev","labels":{"team":"b"}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, "team-a-dev", sim.namespace)
+	assert.Equal(t, map[string]string{"team": "b"}, sim.labels)
+
+	var result NamespaceMove
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, EffectGain, result.Changes[0].Effect)
+}
+
+func TestNamespaceMoveRejectsInvalidRequests(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed":       `{"namespace":`,
+		"no namespace":    `{"labels":{"team":"b"}}`,
+		"bad namespace":   `{"namespace":"Team_A"}`,
+		"bad label key":   `{"namespace":"team-a","labels":{"-team":"b"}}`,
+		"bad label value": `{"namespace":"team-a","labels":{"team":"b c"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := post(t, &fakeSimulator{}, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestNamespaceMoveErrors(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "gone")
+	w := post(t, &fakeSimulator{err: notFound}, `{"namespace":"gone"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = post(t, &fakeSimulator{err: errors.New("boom")}, `{"namespace":"team-a"}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
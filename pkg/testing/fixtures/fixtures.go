@@ -0,0 +1,147 @@
+// Package fixtures builds what admission tests start from: a fake cluster
+// holding a namespace and the ClusterResourceQuota that selects it,
+// AdmissionReviews for any object, and random pod shapes for property tests
+// that compare webhook decisions with the controller's usage math.
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota/quotatest"
+)
+
+const (
+	// NamespaceName is the namespace of a Cluster.
+	NamespaceName = "fixture-ns"
+	// QuotaName is the ClusterResourceQuota of a Cluster.
+	QuotaName = "fixture-crq"
+)
+
+// Scheme returns a scheme registered with the quota, core and apps types.
+func Scheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = quotav1alpha1.AddToScheme(s)
+	_ = corev1.AddToScheme(s)
+	_ = appsv1.AddToScheme(s)
+	return s
+}
+
+// NewCRQClient returns a quota.CRQClient over a fake client seeded with objs.
+func NewCRQClient(objs ...client.Object) *quota.CRQClient {
+	return quota.NewCRQClient(fake.NewClientBuilder().WithScheme(Scheme()).WithObjects(objs...).Build(), nil)
+}
+
+// Cluster is a fake cluster with one namespace and one quota selecting it.
+type Cluster struct {
+	// Client reads and writes the cluster's objects.
+	Client client.Client
+	// CRQClient is what the webhooks are given to find the quota.
+	CRQClient *quota.CRQClient
+	Namespace *corev1.Namespace
+	// Quota is the quota as last written to the cluster.
+	Quota *quotav1alpha1.ClusterResourceQuota
+}
+
+// NewClusterWithCRQ returns a Cluster with the namespace NamespaceName,
+// labeled with selector, and the quota QuotaName selecting it with hard.
+// The quota's status reports zero usage of every key in hard, as the
+// controller would for an empty namespace. objs are added to the cluster.
+func NewClusterWithCRQ(hard quotav1alpha1.ResourceList, selector map[string]string, objs ...client.Object) *Cluster {
+	ns := quotatest.Namespace(NamespaceName, selector)
+	builder := quotatest.Quota(QuotaName).Selecting(selector)
+	for resourceName, q := range hard {
+		builder.Hard(resourceName, q.String()).Used(NamespaceName, resourceName, "0")
+	}
+	crq := builder.Build()
+	c := fake.NewClientBuilder().WithScheme(Scheme()).WithObjects(append(objs, ns, crq)...).Build()
+	return &Cluster{
+		Client:    c,
+		CRQClient: quota.NewCRQClient(c, nil),
+		Namespace: ns,
+		Quota:     crq,
+	}
+}
+
+// SetUsed replaces the total usage reported in the quota's status, as a
+// reconcile would.
+func (c *Cluster) SetUsed(ctx context.Context, used quotav1alpha1.ResourceList) error {
+	crq := &quotav1alpha1.ClusterResourceQuota{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: QuotaName}, crq); err != nil {
+		return err
+	}
+	crq.Status.Total.Used = used
+	if err := c.Client.Update(ctx, crq); err != nil {
+		return err
+	}
+	c.Quota = crq
+	return nil
+}
+
+// Review returns an AdmissionReview of op on obj, with old as the object it
+// replaces on UPDATE. The kind and resource are looked up in Scheme.
+func Review(op admissionv1.Operation, obj, old client.Object) *admissionv1.AdmissionReview {
+	gvk, _ := apiutil.GVKForObject(obj, Scheme())
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	req := &admissionv1.AdmissionRequest{
+		UID:       types.UID(obj.GetName()),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Operation: op,
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:  metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource},
+	}
+	req.Object.Raw, _ = json.Marshal(obj)
+	if old != nil {
+		req.OldObject.Raw, _ = json.Marshal(old)
+	}
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request:  req,
+	}
+}
+
+// Post sends review to path on engine, as the API server would, and returns
+// the review it answers. An answer that cannot be decoded is returned as a
+// denial.
+func Post(engine *gin.Engine, path string, review *admissionv1.AdmissionReview) *admissionv1.AdmissionReview {
+	body, _ := json.Marshal(review)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var response admissionv1.AdmissionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		return &admissionv1.AdmissionReview{Response: &admissionv1.AdmissionResponse{
+			Result: &metav1.Status{Message: "Failed to parse response"},
+		}}
+	}
+	return &response
+}
+
+// Admit sends review to handler, served alone on a test engine, and returns
+// the review it answers.
+func Admit(handler gin.HandlerFunc, review *admissionv1.AdmissionReview) *admissionv1.AdmissionReview {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/webhook", handler)
+	return Post(engine, "/webhook", review)
+}
@@ -0,0 +1,75 @@
+package fixtures
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+var _ = Describe("NewClusterWithCRQ", func() {
+	hard := quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")}
+	selector := map[string]string{"team": "a"}
+
+	It("serves a quota selecting the namespace, with zero usage of every hard key", func() {
+		cluster := NewClusterWithCRQ(hard, selector)
+
+		crq, err := cluster.CRQClient.GetCRQByNamespace(context.Background(), cluster.Namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq).NotTo(BeNil())
+		Expect(crq.Name).To(Equal(QuotaName))
+		used := crq.Status.Total.Used[corev1.ResourceRequestsCPU]
+		Expect(used.IsZero()).To(BeTrue())
+	})
+
+	It("records the usage set afterwards", func() {
+		cluster := NewClusterWithCRQ(hard, selector)
+		Expect(cluster.SetUsed(context.Background(), quotav1alpha1.ResourceList{
+			corev1.ResourceRequestsCPU: resource.MustParse("3"),
+		})).To(Succeed())
+
+		crq, err := cluster.CRQClient.GetCRQByNamespace(context.Background(), cluster.Namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crq.Status.Total.Used).To(HaveKeyWithValue(corev1.ResourceRequestsCPU, resource.MustParse("3")))
+		Expect(cluster.Quota.Status.Total.Used).To(Equal(crq.Status.Total.Used))
+	})
+})
+
+var _ = Describe("Review and Admit", func() {
+	It("sends the object with its kind and resource to the handler", func() {
+		pod := RandomPod(rand.New(rand.NewPCG(1, 2)), "p")
+		var got *admissionv1.AdmissionRequest
+		resp := Admit(func(c *gin.Context) {
+			review := &admissionv1.AdmissionReview{}
+			Expect(c.ShouldBindJSON(review)).To(Succeed())
+			got = review.Request
+			review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+			c.JSON(200, review)
+		}, Review(admissionv1.Create, pod, nil))
+
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(got.Kind.Kind).To(Equal("Pod"))
+		Expect(got.Resource.Resource).To(Equal("pods"))
+		Expect(got.Namespace).To(Equal(NamespaceName))
+		Expect(got.OldObject.Raw).To(BeEmpty())
+	})
+})
+
+var _ = Describe("RandomPod", func() {
+	It("draws the same pod from the same seed", func() {
+		a := RandomPods(rand.New(rand.NewPCG(7, 7)), "p", 5)
+		b := RandomPods(rand.New(rand.NewPCG(7, 7)), "p", 5)
+		Expect(a).To(Equal(b))
+		for _, p := range a {
+			Expect(p.Spec.Containers).NotTo(BeEmpty())
+			Expect(len(p.Spec.InitContainers)).To(BeNumerically("<=", 2))
+		}
+	})
+})
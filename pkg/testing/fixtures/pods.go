@@ -0,0 +1,75 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RandomPod returns a running pod named name in NamespaceName, of a shape
+// drawn from r: one to four containers and up to two init containers, each
+// setting any of its cpu and memory requests and limits, and sometimes a pod
+// overhead. The same r state always returns the same pod.
+func RandomPod(r *rand.Rand, name string) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: NamespaceName},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	for i := range r.IntN(3) {
+		p.Spec.InitContainers = append(p.Spec.InitContainers, randomContainer(r, fmt.Sprintf("init-%d", i)))
+	}
+	for i := range 1 + r.IntN(4) {
+		p.Spec.Containers = append(p.Spec.Containers, randomContainer(r, fmt.Sprintf("app-%d", i)))
+	}
+	if r.IntN(4) == 0 {
+		p.Spec.Overhead = corev1.ResourceList{
+			corev1.ResourceCPU:    randomCPU(r),
+			corev1.ResourceMemory: randomMemory(r),
+		}
+	}
+	return p
+}
+
+// RandomPods returns n pods of RandomPod, named prefix-0 onwards.
+func RandomPods(r *rand.Rand, prefix string, n int) []corev1.Pod {
+	pods := make([]corev1.Pod, n)
+	for i := range pods {
+		pods[i] = *RandomPod(r, fmt.Sprintf("%s-%d", prefix, i))
+	}
+	return pods
+}
+
+func randomContainer(r *rand.Rand, name string) corev1.Container {
+	c := corev1.Container{Name: name, Image: "busybox"}
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	if r.IntN(2) == 0 {
+		requests[corev1.ResourceCPU] = randomCPU(r)
+	}
+	if r.IntN(2) == 0 {
+		requests[corev1.ResourceMemory] = randomMemory(r)
+	}
+	if r.IntN(2) == 0 {
+		limits[corev1.ResourceCPU] = randomCPU(r)
+	}
+	if r.IntN(2) == 0 {
+		limits[corev1.ResourceMemory] = randomMemory(r)
+	}
+	if len(requests) > 0 {
+		c.Resources.Requests = requests
+	}
+	if len(limits) > 0 {
+		c.Resources.Limits = limits
+	}
+	return c
+}
+
+func randomCPU(r *rand.Rand) resource.Quantity {
+	return *resource.NewMilliQuantity(1+r.Int64N(2000), resource.DecimalSI)
+}
+
+func randomMemory(r *rand.Rand) resource.Quantity {
+	return *resource.NewQuantity((1+r.Int64N(2048))<<20, resource.BinarySI)
+}
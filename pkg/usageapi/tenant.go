@@ -0,0 +1,232 @@
+package usageapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TenantPath is where TenantHandler serves the quotas of a namespace, as
+// TenantPath + "/<namespace>".
+const TenantPath = "/quotas"
+
+// TenantHandler serves the QuotaUsage objects of one namespace to callers
+// that reach the webhook server directly, such as self-service portals.
+// Callers present their own bearer token, which is checked with a
+// TokenReview, and read a namespace's quotas when a SubjectAccessReview lets
+// them get its resourcequotas, so they need no ClusterResourceQuota access.
+type TenantHandler struct {
+	usage      *Handler
+	kubeClient kubernetes.Interface
+	logger     *zap.Logger
+	// reviews caches access reviews; nil unless EnableReviewCache is called.
+	reviews *reviewCache
+}
+
+// NewTenantHandler creates a TenantHandler reading ClusterResourceQuotas
+// through reader and reviewing callers through kubeClient.
+func NewTenantHandler(reader client.Reader, kubeClient kubernetes.Interface, logger *zap.Logger) *TenantHandler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TenantHandler{
+		usage:      NewHandler(reader, logger),
+		kubeClient: kubeClient,
+		logger:     logger.Named("tenant-api"),
+	}
+}
+
+// EnableReviewCache reuses whether a token may read a namespace's quotas for
+// ttl, so portals polling a namespace do not create a TokenReview and a
+// SubjectAccessReview per request. A zero ttl leaves the cache off.
+func (h *TenantHandler) EnableReviewCache(ttl time.Duration) {
+	if ttl > 0 {
+		h.reviews = newReviewCache(ttl)
+	}
+}
+
+// Register adds GET TenantPath/:namespace to r.
+func (h *TenantHandler) Register(r gin.IRouter) {
+	r.GET(TenantPath+"/:namespace", h.get)
+}
+
+func (h *TenantHandler) get(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if !h.authorize(c, namespace) {
+		return
+	}
+	items, err := h.usage.usages(c, namespace, "")
+	if err != nil {
+		h.logger.Error("Failed to list ClusterResourceQuotas", zap.Error(err))
+		writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+	if items == nil {
+		items = []QuotaUsage{}
+	}
+	c.JSON(http.StatusOK, QuotaUsageList{
+		TypeMeta: metav1.TypeMeta{Kind: "QuotaUsageList", APIVersion: groupVersion},
+		Items:    items,
+	})
+}
+
+// authorize reports whether the caller's bearer token may get resourcequotas
+// in namespace, writing the failure when it may not.
+func (h *TenantHandler) authorize(c *gin.Context, namespace string) bool {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		writeStatus(c, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "bearer token required")
+		return false
+	}
+
+	key := reviewKey(token, namespace)
+	var access accessReview
+	cached := false
+	if h.reviews != nil {
+		access, cached = h.reviews.get(key)
+	}
+	if !cached {
+		if access, ok = h.review(c, token, namespace); !ok {
+			return false
+		}
+		if h.reviews != nil {
+			h.reviews.put(key, access)
+		}
+	}
+	if !access.allowed {
+		writeStatus(c, http.StatusForbidden, metav1.StatusReasonForbidden,
+			"user "+access.user+" cannot get resourcequotas in namespace "+namespace)
+		return false
+	}
+	return true
+}
+
+// accessReview is whether the user a token authenticates may get
+// resourcequotas in a namespace.
+type accessReview struct {
+	user    string
+	allowed bool
+}
+
+// review checks token with a TokenReview and its user's access to namespace
+// with a SubjectAccessReview. It writes the failure and returns false when the
+// token does not authenticate or either review fails.
+func (h *TenantHandler) review(c *gin.Context, token, namespace string) (accessReview, bool) {
+	ctx := c.Request.Context()
+	review, err := h.kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		h.logger.Error("Failed to review token", zap.Error(err))
+		writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, "failed to review token")
+		return accessReview{}, false
+	}
+	if !review.Status.Authenticated {
+		h.logger.Debug("Rejecting unauthenticated tenant request",
+			zap.String("namespace", namespace),
+			zap.String("ip", c.ClientIP()),
+			zap.String("error", review.Status.Error))
+		writeStatus(c, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, "invalid bearer token")
+		return accessReview{}, false
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, values := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+	access, err := h.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Resource:  "resourcequotas",
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		h.logger.Error("Failed to review access", zap.String("user", user.Username), zap.Error(err))
+		writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, "failed to review access")
+		return accessReview{}, false
+	}
+	return accessReview{user: user.Username, allowed: access.Status.Allowed}, true
+}
+
+// maxReviewCacheEntries bounds the review cache; a portal's users and the
+// namespaces they open need far fewer.
+const maxReviewCacheEntries = 4096
+
+// reviewCache remembers access reviews for a short TTL, keyed on a hash of the
+// token, never the token itself, and the namespace. Tokens that do not
+// authenticate are not cached, so callers cycling through invalid tokens
+// cannot evict the entries of real users.
+type reviewCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]reviewEntry
+}
+
+type reviewEntry struct {
+	access  accessReview
+	expires time.Time
+}
+
+func newReviewCache(ttl time.Duration) *reviewCache {
+	return &reviewCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]reviewEntry),
+	}
+}
+
+// reviewKey keys the review of token's access to namespace.
+func reviewKey(token, namespace string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:]) + "/" + namespace
+}
+
+// get returns the review cached under key, if it has not expired.
+func (c *reviewCache) get(key string) (accessReview, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return accessReview{}, false
+	}
+	return entry.access, true
+}
+
+// put caches a review under key. When the cache is full, expired entries are
+// dropped first, and everything if that does not make room.
+func (c *reviewCache) put(key string, access accessReview) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxReviewCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxReviewCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = reviewEntry{access: access, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,150 @@
+package usageapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTenantRouter serves the team-a quota to the token "alice-token", whose
+// user may get resourcequotas in team-a-prod only, caching reviews for
+// reviewCacheTTL.
+func newTenantRouter(
+	t *testing.T,
+	reviewCacheTTL time.Duration,
+) (*gin.Engine, *[]authorizationv1.SubjectAccessReviewSpec) {
+	t.Helper()
+	var reviewed []authorizationv1.SubjectAccessReviewSpec
+	kubeClient := kubefake.NewClientset()
+	kubeClient.PrependReactor("create", "tokenreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			if review.Spec.Token == "alice-token" {
+				review.Status = authenticationv1.TokenReviewStatus{
+					Authenticated: true,
+					User: authenticationv1.UserInfo{
+						Username: "alice",
+						Groups:   []string{"team-a"},
+						Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"portal"}},
+					},
+				}
+			}
+			return true, review, nil
+		})
+	kubeClient.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			reviewed = append(reviewed, review.Spec)
+			review.Status.Allowed = review.Spec.User == "alice" &&
+				review.Spec.ResourceAttributes.Namespace == "team-a-prod"
+			return true, review, nil
+		})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewTenantHandler(newReader(t), kubeClient, zap.NewNop())
+	handler.EnableReviewCache(reviewCacheTTL)
+	handler.Register(router)
+	return router, &reviewed
+}
+
+func serveTenant(router *gin.Engine, namespace, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, TenantPath+"/"+namespace, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantHandler(t *testing.T) {
+	t.Run("serves the quotas of a namespace the caller may read", func(t *testing.T) {
+		router, reviewed := newTenantRouter(t, 0)
+		w := serveTenant(router, "team-a-prod", "alice-token")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list QuotaUsageList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, "team-a", list.Items[0].Name)
+		assert.True(t, cpuRequests(list.Items[0].Hard).Equal(resource.MustParse("4")))
+		assert.True(t, cpuRequests(list.Items[0].QuotaUsed).Equal(resource.MustParse("5")))
+		assert.True(t, list.Items[0].Headroom.Pods().Equal(resource.MustParse("7")))
+
+		require.Len(t, *reviewed, 1)
+		spec := (*reviewed)[0]
+		assert.Equal(t, []string{"team-a"}, spec.Groups)
+		assert.Equal(t, authorizationv1.ExtraValue{"portal"}, spec.Extra["scopes"])
+		assert.Equal(t, &authorizationv1.ResourceAttributes{
+			Namespace: "team-a-prod", Verb: "get", Resource: "resourcequotas",
+		}, spec.ResourceAttributes)
+	})
+
+	t.Run("rejects requests without a token", func(t *testing.T) {
+		router, reviewed := newTenantRouter(t, 0)
+		w := serveTenant(router, "team-a-prod", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, *reviewed)
+	})
+
+	t.Run("rejects tokens the API server does not authenticate", func(t *testing.T) {
+		router, reviewed := newTenantRouter(t, 0)
+		w := serveTenant(router, "team-a-prod", "forged")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, *reviewed)
+	})
+
+	t.Run("forbids namespaces the caller may not read", func(t *testing.T) {
+		router, _ := newTenantRouter(t, 0)
+		w := serveTenant(router, "team-a-dev", "alice-token")
+		require.Equal(t, http.StatusForbidden, w.Code)
+		var status metav1.Status
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.Equal(t, metav1.StatusReasonForbidden, status.Reason)
+		assert.NotContains(t, w.Body.String(), "requests.cpu")
+	})
+
+	t.Run("reuses reviews of a token's access to a namespace", func(t *testing.T) {
+		router, reviewed := newTenantRouter(t, time.Minute)
+		for range 3 {
+			assert.Equal(t, http.StatusOK, serveTenant(router, "team-a-prod", "alice-token").Code)
+			assert.Equal(t, http.StatusForbidden, serveTenant(router, "team-a-dev", "alice-token").Code)
+		}
+		assert.Len(t, *reviewed, 2)
+
+		assert.Equal(t, http.StatusUnauthorized, serveTenant(router, "team-a-prod", "forged").Code)
+		assert.Equal(t, http.StatusUnauthorized, serveTenant(router, "team-a-prod", "").Code)
+	})
+}
+
+func TestReviewCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newReviewCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+	key := reviewKey("alice-token", "team-a-prod")
+	assert.NotContains(t, key, "alice-token")
+	assert.NotEqual(t, key, reviewKey("alice-token", "team-a-dev"))
+
+	cache.put(key, accessReview{user: "alice", allowed: true})
+	access, ok := cache.get(key)
+	require.True(t, ok)
+	assert.True(t, access.allowed)
+
+	now = now.Add(10 * time.Second)
+	_, ok = cache.get(key)
+	assert.False(t, ok)
+}
@@ -0,0 +1,305 @@
+// Package usageapi serves the usage recorded in ClusterResourceQuota status as
+// the read-only quotausages resource of an aggregated API, so plain kubectl can
+// list it: kubectl get quotausages -A.
+package usageapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+const (
+	// GroupName is the API group registered with the kube-apiserver aggregator.
+	GroupName = "metrics.quota.powerapp.cloud"
+	// Version is the only served version of GroupName.
+	Version = "v1alpha1"
+	// Resource is the plural name of QuotaUsage objects.
+	Resource = "quotausages"
+
+	groupVersion = GroupName + "/" + Version
+	basePath     = "/apis/" + GroupName
+)
+
+// QuotaUsage is the usage of one namespace under a ClusterResourceQuota that
+// selects it. It is named after the quota.
+type QuotaUsage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// Hard is the quota's hard limits.
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+	// Used is this namespace's usage.
+	Used corev1.ResourceList `json:"used,omitempty"`
+	// QuotaUsed is the usage across every namespace the quota selects.
+	QuotaUsed corev1.ResourceList `json:"quotaUsed,omitempty"`
+	// Headroom is what Hard leaves after QuotaUsed, never below zero.
+	Headroom corev1.ResourceList `json:"headroom,omitempty"`
+}
+
+// QuotaUsageList is a list of QuotaUsage objects.
+type QuotaUsageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []QuotaUsage `json:"items"`
+}
+
+// Handler serves QuotaUsage objects built from the ClusterResourceQuota status
+// in the manager's informer cache. It computes nothing itself, so responses are
+// as current as the last reconcile.
+type Handler struct {
+	reader client.Reader
+	logger *zap.Logger
+}
+
+// NewHandler creates a Handler reading ClusterResourceQuotas through reader.
+func NewHandler(reader client.Reader, logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{reader: reader, logger: logger.Named("usage-api")}
+}
+
+// Register adds the discovery and quotausages routes to r.
+func (h *Handler) Register(r gin.IRouter) {
+	r.GET(basePath, h.group)
+	r.GET(basePath+"/"+Version, h.resources)
+	r.GET(basePath+"/"+Version+"/"+Resource, h.list)
+	r.GET(basePath+"/"+Version+"/namespaces/:namespace/"+Resource, h.list)
+	r.GET(basePath+"/"+Version+"/namespaces/:namespace/"+Resource+"/:name", h.get)
+}
+
+func (h *Handler) group(c *gin.Context) {
+	version := metav1.GroupVersionForDiscovery{GroupVersion: groupVersion, Version: Version}
+	c.JSON(http.StatusOK, metav1.APIGroup{
+		TypeMeta:         metav1.TypeMeta{Kind: "APIGroup", APIVersion: "v1"},
+		Name:             GroupName,
+		Versions:         []metav1.GroupVersionForDiscovery{version},
+		PreferredVersion: version,
+	})
+}
+
+func (h *Handler) resources(c *gin.Context) {
+	c.JSON(http.StatusOK, metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: groupVersion,
+		APIResources: []metav1.APIResource{{
+			Name:         Resource,
+			SingularName: "quotausage",
+			Namespaced:   true,
+			Kind:         "QuotaUsage",
+			Verbs:        metav1.Verbs{"get", "list"},
+		}},
+	})
+}
+
+func (h *Handler) list(c *gin.Context) {
+	if watch := c.Query("watch"); watch == "true" || watch == "1" {
+		writeStatus(c, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed,
+			"watch is not supported for "+Resource)
+		return
+	}
+	if c.Query("labelSelector") != "" || c.Query("fieldSelector") != "" {
+		writeStatus(c, http.StatusBadRequest, metav1.StatusReasonBadRequest,
+			"label and field selectors are not supported for "+Resource)
+		return
+	}
+	items, err := h.usages(c, c.Param("namespace"), "")
+	if err != nil {
+		h.logger.Error("Failed to list ClusterResourceQuotas", zap.Error(err))
+		writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+	h.write(c, items, true)
+}
+
+func (h *Handler) get(c *gin.Context) {
+	items, err := h.usages(c, c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to list ClusterResourceQuotas", zap.Error(err))
+		writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		writeStatus(c, http.StatusNotFound, metav1.StatusReasonNotFound,
+			fmt.Sprintf("%s.%s %q not found", Resource, GroupName, c.Param("name")))
+		return
+	}
+	h.write(c, items, false)
+}
+
+// usages builds the QuotaUsage objects in namespace (all when empty), limited
+// to the quota called name when it is set, sorted by namespace and name.
+func (h *Handler) usages(c *gin.Context, namespace, name string) ([]QuotaUsage, error) {
+	crqs := &quotav1alpha1.ClusterResourceQuotaList{}
+	if err := h.reader.List(c.Request.Context(), crqs); err != nil {
+		return nil, err
+	}
+	var items []QuotaUsage
+	for i := range crqs.Items {
+		crq := &crqs.Items[i]
+		if name != "" && crq.Name != name {
+			continue
+		}
+		for _, ns := range crq.Status.Namespaces {
+			if namespace != "" && ns.Namespace != namespace {
+				continue
+			}
+			items = append(items, newQuotaUsage(crq, &ns))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+func newQuotaUsage(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	ns *quotav1alpha1.ResourceQuotaStatusByNamespace,
+) QuotaUsage {
+	u := QuotaUsage{
+		TypeMeta: metav1.TypeMeta{Kind: "QuotaUsage", APIVersion: groupVersion},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              crq.Name,
+			Namespace:         ns.Namespace,
+			CreationTimestamp: crq.CreationTimestamp,
+		},
+		Hard:      corev1.ResourceList(crq.Status.NamespaceHard(ns)).DeepCopy(),
+		Used:      corev1.ResourceList(ns.Status.Used).DeepCopy(),
+		QuotaUsed: corev1.ResourceList(crq.Status.Total.Used).DeepCopy(),
+		Headroom:  make(corev1.ResourceList, len(crq.Spec.Hard)),
+	}
+	if u.Hard == nil {
+		// Status written before the quota's limits were recorded.
+		u.Hard = corev1.ResourceList(crq.Spec.Hard).DeepCopy()
+	}
+	for resourceName, hard := range u.Hard {
+		headroom := hard.DeepCopy()
+		if used, ok := crq.Status.Total.Used[resourceName]; ok {
+			headroom.Sub(used)
+		}
+		if headroom.Sign() < 0 {
+			headroom = *resource.NewQuantity(0, hard.Format)
+		}
+		u.Headroom[resourceName] = headroom
+	}
+	return u
+}
+
+// write sends items as a QuotaUsageList, as a single object for a get, or as a
+// Table when the client asks for one the way kubectl get does.
+func (h *Handler) write(c *gin.Context, items []QuotaUsage, list bool) {
+	if wantsTable(c.GetHeader("Accept")) {
+		t, err := table(items, time.Now())
+		if err != nil {
+			writeStatus(c, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, t)
+		return
+	}
+	if !list {
+		c.JSON(http.StatusOK, items[0])
+		return
+	}
+	if items == nil {
+		items = []QuotaUsage{}
+	}
+	c.JSON(http.StatusOK, QuotaUsageList{
+		TypeMeta: metav1.TypeMeta{Kind: "QuotaUsageList", APIVersion: groupVersion},
+		Items:    items,
+	})
+}
+
+// wantsTable reports whether the Accept header requests a meta.k8s.io Table.
+func wantsTable(accept string) bool {
+	for _, mediaType := range strings.Split(accept, ",") {
+		if strings.Contains(mediaType, "as=Table") {
+			return true
+		}
+	}
+	return false
+}
+
+// table renders items the way kubectl get prints them. Each row carries its
+// object so kubectl can add the namespace column for -A.
+func table(items []QuotaUsage, now time.Time) (*metav1.Table, error) {
+	t := &metav1.Table{
+		TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: "meta.k8s.io/v1"},
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name", Description: "Name of the ClusterResourceQuota."},
+			{Name: "Used", Type: "string", Description: "Usage in this namespace."},
+			{Name: "Quota Used", Type: "string", Description: "Usage across the quota's namespaces, of the hard limit."},
+			{Name: "Headroom", Type: "string", Description: "What the hard limit leaves."},
+			{Name: "Age", Type: "string", Description: "Age of the ClusterResourceQuota."},
+		},
+		Rows: make([]metav1.TableRow, 0, len(items)),
+	}
+	for i := range items {
+		u := &items[i]
+		raw, err := json.Marshal(u)
+		if err != nil {
+			return nil, err
+		}
+		t.Rows = append(t.Rows, metav1.TableRow{
+			Cells: []any{
+				u.Name,
+				formatResources(u.Used, nil),
+				formatResources(u.QuotaUsed, u.Hard),
+				formatResources(u.Headroom, nil),
+				duration.HumanDuration(now.Sub(u.CreationTimestamp.Time)),
+			},
+			Object: runtime.RawExtension{Raw: raw},
+		})
+	}
+	return t, nil
+}
+
+// formatResources renders "name: quantity" pairs sorted by name, adding
+// "/limit" for every resource limits has.
+func formatResources(values, limits corev1.ResourceList) string {
+	names := make([]string, 0, len(values))
+	for resourceName := range values {
+		names = append(names, string(resourceName))
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		q := values[corev1.ResourceName(name)]
+		part := name + ": " + q.String()
+		if limit, ok := limits[corev1.ResourceName(name)]; ok {
+			part += "/" + limit.String()
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeStatus sends a metav1.Status failure, the error shape kubectl expects.
+func writeStatus(c *gin.Context, code int, reason metav1.StatusReason, message string) {
+	c.JSON(code, metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
@@ -0,0 +1,168 @@
+package usageapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(newReader(t), zap.NewNop()).Register(router)
+	return router
+}
+
+// newReader returns a fake client holding the team-a quota, which selects
+// team-a-prod and team-a-dev and is over its requests.cpu limit.
+func newReader(t *testing.T) client.Reader {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, quotav1alpha1.AddToScheme(scheme))
+
+	crq := &quotav1alpha1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+			Hard: quotav1alpha1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("4"),
+				corev1.ResourcePods:        resource.MustParse("10"),
+			},
+		},
+		Status: quotav1alpha1.ClusterResourceQuotaStatus{
+			Total: quotav1alpha1.ResourceQuotaStatus{
+				Used: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("5"),
+					corev1.ResourcePods:        resource.MustParse("3"),
+				},
+			},
+			Namespaces: []quotav1alpha1.ResourceQuotaStatusByNamespace{
+				{Namespace: "team-a-prod", Status: quotav1alpha1.ResourceQuotaStatus{Used: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("4"),
+					corev1.ResourcePods:        resource.MustParse("2"),
+				}}},
+				{Namespace: "team-a-dev", Status: quotav1alpha1.ResourceQuotaStatus{Used: quotav1alpha1.ResourceList{
+					corev1.ResourceRequestsCPU: resource.MustParse("1"),
+					corev1.ResourcePods:        resource.MustParse("1"),
+				}}},
+			},
+		},
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(crq).Build()
+}
+
+// cpuRequests returns the requests.cpu entry of list.
+func cpuRequests(list corev1.ResourceList) *resource.Quantity {
+	q := list[corev1.ResourceRequestsCPU]
+	return &q
+}
+
+func serve(router *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDiscovery(t *testing.T) {
+	router := newRouter(t)
+
+	w := serve(router, "/apis/metrics.quota.powerapp.cloud", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var group metav1.APIGroup
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+	assert.Equal(t, "metrics.quota.powerapp.cloud/v1alpha1", group.PreferredVersion.GroupVersion)
+
+	w = serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resources metav1.APIResourceList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resources))
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, "quotausages", resources.APIResources[0].Name)
+	assert.True(t, resources.APIResources[0].Namespaced)
+}
+
+func TestList(t *testing.T) {
+	router := newRouter(t)
+
+	t.Run("lists every selected namespace sorted by namespace", func(t *testing.T) {
+		w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var list QuotaUsageList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Items, 2)
+		assert.Equal(t, "team-a-dev", list.Items[0].Namespace)
+		assert.Equal(t, "team-a", list.Items[0].Name)
+		assert.True(t, cpuRequests(list.Items[1].Used).Equal(resource.MustParse("4")))
+	})
+
+	t.Run("floors headroom at zero once the quota is over its limit", func(t *testing.T) {
+		w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/namespaces/team-a-prod/quotausages", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var list QuotaUsageList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Items, 1)
+		assert.True(t, cpuRequests(list.Items[0].Headroom).IsZero())
+		assert.True(t, list.Items[0].Headroom.Pods().Equal(resource.MustParse("7")))
+	})
+
+	t.Run("returns an empty list for a namespace no quota selects", func(t *testing.T) {
+		w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/namespaces/other/quotausages", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"items":[]`)
+	})
+
+	t.Run("rejects watches", func(t *testing.T) {
+		w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages?watch=true", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("renders a table for kubectl", func(t *testing.T) {
+		w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages",
+			"application/json;as=Table;v=v1;g=meta.k8s.io,application/json")
+		require.Equal(t, http.StatusOK, w.Code)
+		var table metav1.Table
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &table))
+		require.Len(t, table.Rows, 2)
+		assert.Equal(t, "pods: 1, requests.cpu: 1", table.Rows[0].Cells[1])
+		assert.Equal(t, "pods: 3/10, requests.cpu: 5/4", table.Rows[0].Cells[2])
+
+		var obj metav1.PartialObjectMetadata
+		require.NoError(t, json.Unmarshal(table.Rows[0].Object.Raw, &obj))
+		assert.Equal(t, "team-a-dev", obj.Namespace)
+	})
+}
+
+func TestGet(t *testing.T) {
+	router := newRouter(t)
+
+	w := serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/namespaces/team-a-prod/quotausages/team-a", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var usage QuotaUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, "QuotaUsage", usage.Kind)
+	assert.True(t, cpuRequests(usage.QuotaUsed).Equal(resource.MustParse("5")))
+
+	w = serve(router, "/apis/metrics.quota.powerapp.cloud/v1alpha1/namespaces/team-a-prod/quotausages/team-b", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	var status metav1.Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, metav1.StatusReasonNotFound, status.Reason)
+}
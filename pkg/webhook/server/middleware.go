@@ -1,12 +1,20 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -75,8 +83,160 @@ func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
 // metrics scrapers — endpoints whose 2xx traffic is uninteresting in logs.
 func isProbePath(path string) bool {
 	switch path {
-	case "/healthz", "/readyz", "/livez", "/metrics":
+	case "/healthz", "/readyz", "/livez", "/version", "/metrics", metrics.LitePath:
 		return true
 	}
 	return false
 }
+
+// trustedCAs holds the roots a route accepts client certificates from. The
+// TLS layer verifies certificates against the union of every route's roots, so
+// each route checks that the verified chain ends in one of its own.
+type trustedCAs struct {
+	roots []*x509.Certificate
+}
+
+// verifiedLeaf returns the client certificate of the first chain the TLS layer
+// verified up to one of the roots, or nil when there is none.
+func (t *trustedCAs) verifiedLeaf(state *tls.ConnectionState) *x509.Certificate {
+	if t == nil || state == nil {
+		return nil
+	}
+	for _, chain := range state.VerifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+		root := chain[len(chain)-1]
+		if slices.ContainsFunc(t.roots, root.Equal) {
+			return chain[0]
+		}
+	}
+	return nil
+}
+
+// RequireVerifiedClientCert returns a gin.HandlerFunc that rejects requests
+// whose TLS connection did not present a client certificate verified against
+// cas. The TLS layer only verifies certificates when given, so probes keep
+// working while admission routes require one.
+func RequireVerifiedClientCert(cas *trustedCAs, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cas.verifiedLeaf(c.Request.TLS) == nil {
+			logger.Warn("Rejecting admission request without a verified client certificate",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("ip", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "verified client certificate required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireFrontProxy returns a gin.HandlerFunc that only lets the API server's
+// aggregator through: the client certificate must chain to cas, carry one of
+// allowedNames as its common name (any, when empty), and the request must name
+// the user the aggregator authenticated in X-Remote-User. The aggregator has
+// already authorized that user for the requested resource.
+func RequireFrontProxy(cas *trustedCAs, allowedNames []string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		leaf := cas.verifiedLeaf(c.Request.TLS)
+		if leaf == nil {
+			logger.Warn("Rejecting aggregated API request without a verified front-proxy certificate",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("ip", c.ClientIP()))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "verified front-proxy certificate required"})
+			return
+		}
+		if len(allowedNames) > 0 && !slices.Contains(allowedNames, leaf.Subject.CommonName) {
+			logger.Warn("Rejecting aggregated API request from a front-proxy certificate with a disallowed name",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("commonName", leaf.Subject.CommonName))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "front-proxy certificate name not allowed"})
+			return
+		}
+		if c.GetHeader("X-Remote-User") == "" {
+			logger.Warn("Rejecting aggregated API request without a remote user",
+				zap.String("path", c.Request.URL.Path))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "X-Remote-User header required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// LimitRequestBody returns a gin.HandlerFunc that buffers the request body up
+// to maxBytes and rejects larger payloads with 413, then rejects bodies nested
+// deeper than maxDepth with 400. Both checks run before any JSON decoding so a
+// crafted AdmissionReview cannot exhaust memory or the decoder's stack.
+func LimitRequestBody(logger *zap.Logger, maxBytes int64, maxDepth int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			rejectOversizedBody(c, logger, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				rejectOversizedBody(c, logger, maxBytes)
+				return
+			}
+			logger.Warn("Failed to read request body", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+
+		if jsonDepthExceeds(body, maxDepth) {
+			logger.Warn("Rejecting request body exceeding maximum JSON depth",
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("max_depth", maxDepth))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "request body exceeds maximum JSON depth"})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func rejectOversizedBody(c *gin.Context, logger *zap.Logger, maxBytes int64) {
+	logger.Warn("Rejecting oversized request body",
+		zap.String("path", c.Request.URL.Path),
+		zap.Int64("content_length", c.Request.ContentLength),
+		zap.Int64("max_bytes", maxBytes))
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+}
+
+// jsonDepthExceeds reports whether the object/array nesting in data goes
+// deeper than maxDepth. It scans bytes without decoding, skipping string
+// contents, so it is cheap enough to run ahead of the real decoder.
+func jsonDepthExceeds(data []byte, maxDepth int) bool {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
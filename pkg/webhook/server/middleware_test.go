@@ -1,16 +1,38 @@
 package server
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// bigPodJSON returns a pod manifest padded to at least size bytes via an annotation.
+func bigPodJSON(size int) []byte {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "big",
+			Annotations: map[string]string{"padding": strings.Repeat("x", size)},
+		},
+	}
+	raw, err := json.Marshal(pod)
+	Expect(err).NotTo(HaveOccurred())
+	return raw
+}
+
 var _ = Describe("Middleware", func() {
 	var (
 		engine *gin.Engine
@@ -69,4 +91,163 @@ var _ = Describe("Middleware", func() {
 			Expect(w.Header().Get("X-Correlation-ID")).To(Equal(requestContextID))
 		})
 	})
+
+	Describe("RequireVerifiedClientCert", func() {
+		root := &x509.Certificate{Raw: []byte("admission-ca")}
+
+		BeforeEach(func() {
+			engine.POST("/admit", RequireVerifiedClientCert(&trustedCAs{roots: []*x509.Certificate{root}}, logger),
+				func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+		})
+
+		It("rejects plain HTTP requests", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", nil)
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("rejects TLS requests without a verified client certificate", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", nil)
+			req.TLS = &tls.ConnectionState{}
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("rejects client certificates verified against another route's CA", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", nil)
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{}, {Raw: []byte("front-proxy-ca")}}},
+			}
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("admits requests with a client certificate chain verified to its CA", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", nil)
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{}, root}},
+			}
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("RequireFrontProxy", func() {
+		root := &x509.Certificate{Raw: []byte("front-proxy-ca")}
+
+		request := func(commonName, user string) *http.Request {
+			req, _ := http.NewRequest("GET", "/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages", nil)
+			leaf := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, root}}}
+			if user != "" {
+				req.Header.Set("X-Remote-User", user)
+			}
+			return req
+		}
+
+		BeforeEach(func() {
+			engine.GET("/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages",
+				RequireFrontProxy(&trustedCAs{roots: []*x509.Certificate{root}}, []string{"front-proxy-client"}, logger),
+				func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+		})
+
+		It("admits the aggregator on behalf of a user", func() {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, request("front-proxy-client", "alice"))
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects requests without a front-proxy certificate", func() {
+			w := httptest.NewRecorder()
+			req := request("front-proxy-client", "alice")
+			req.TLS = nil
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("rejects certificates chained to another CA", func() {
+			w := httptest.NewRecorder()
+			req := request("front-proxy-client", "alice")
+			req.TLS.VerifiedChains[0][1] = &x509.Certificate{Raw: []byte("admission-ca")}
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("rejects certificates whose common name is not allowed", func() {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, request("someone-else", "alice"))
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+		})
+
+		It("rejects requests that name no user", func() {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, request("front-proxy-client", ""))
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("LimitRequestBody", func() {
+		const maxBytes = 1 << 20
+
+		var received []byte
+
+		BeforeEach(func() {
+			received = nil
+			engine.POST("/admit", LimitRequestBody(logger, maxBytes, 10), func(c *gin.Context) {
+				received, _ = io.ReadAll(c.Request.Body)
+				c.Status(http.StatusOK)
+			})
+		})
+
+		It("rejects a multi-megabyte pod with 413 based on Content-Length", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", bytes.NewReader(bigPodJSON(4<<20)))
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(received).To(BeNil())
+		})
+
+		It("rejects a multi-megabyte pod with 413 when Content-Length is unknown", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", io.NopCloser(bytes.NewReader(bigPodJSON(2<<20))))
+			req.ContentLength = -1
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(received).To(BeNil())
+		})
+
+		It("passes bodies within the limit through to the handler", func() {
+			body := bigPodJSON(512 << 10)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", bytes.NewReader(body))
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(received).To(Equal(body))
+		})
+
+		It("rejects bodies nested deeper than the limit with 400", func() {
+			body := strings.Repeat("[", 11) + strings.Repeat("]", 11)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", strings.NewReader(body))
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(received).To(BeNil())
+		})
+
+		It("ignores brackets inside JSON strings when measuring depth", func() {
+			body := `{"a":"` + strings.Repeat("[{", 50) + `\"]"}`
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admit", strings.NewReader(body))
+			engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+	})
 })
@@ -3,22 +3,35 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"net/http"
+	"os"
 	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	k8sevents "k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/powerhome/pac-quota-controller/cmd/version"
+	"github.com/powerhome/pac-quota-controller/pkg/admin"
 	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/events"
+	"github.com/powerhome/pac-quota-controller/pkg/faults"
 	"github.com/powerhome/pac-quota-controller/pkg/health"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
 	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 	"github.com/powerhome/pac-quota-controller/pkg/ready"
+	"github.com/powerhome/pac-quota-controller/pkg/simulate"
+	"github.com/powerhome/pac-quota-controller/pkg/usageapi"
 	"github.com/powerhome/pac-quota-controller/pkg/webhook/certwatcher"
 	"github.com/powerhome/pac-quota-controller/pkg/webhook/v1alpha1"
 )
@@ -30,6 +43,75 @@ type GinWebhookServer struct {
 	logger      *zap.Logger
 	port        int
 	certWatcher *certwatcher.CertWatcher
+	// requireClientCert is set when --webhook-client-ca-file is configured;
+	// admission routes then reject requests without a client cert verified
+	// against admissionCAs.
+	requireClientCert bool
+	admissionCAs      *trustedCAs
+	// frontProxyCAs and frontProxyNames verify the aggregator's certificate
+	// on the usage API; see RequireFrontProxy.
+	frontProxyCAs   *trustedCAs
+	frontProxyNames []string
+	// clientCAs is the union of admissionCAs and frontProxyCAs that the TLS
+	// layer verifies presented certificates against.
+	clientCAs *x509.CertPool
+	// maxRequestBytes and maxJSONDepth bound AdmissionReview bodies before decoding.
+	maxRequestBytes int64
+	maxJSONDepth    int
+	// usageAPI is set when --usage-api-enable is on.
+	usageAPI bool
+	// tenantAPI is set when --tenant-api-enable is on.
+	tenantAPI bool
+	// tenantReviewCacheTTL is --tenant-api-review-cache-ttl; see
+	// TenantHandler.EnableReviewCache.
+	tenantReviewCacheTTL time.Duration
+	// adminTokenFile is --admin-token-file; the admin routes are served when set.
+	adminTokenFile string
+	// namespaceLabels is set when --namespace-labels-enable is on.
+	namespaceLabels *v1alpha1.NamespaceLabelSource
+	// vpaCap is set when --vpa-cap-recommendations is on.
+	vpaCap bool
+	// warmCache is set when --webhook-warm-cache is on; see WarmCache.
+	warmCache bool
+	// decisionCacheTTL is --webhook-decision-cache-ttl; see PodWebhook.EnableDecisionCache.
+	decisionCacheTTL time.Duration
+	// controllerConfigName is --controller-config-name; see PodWebhook.EnableOwnerPolicies.
+	controllerConfigName string
+	// enabledWebhooks is --enable-webhooks; see config.WebhookEnabled.
+	enabledWebhooks []string
+	// pvcDeletionProtection is set when --pvc-deletion-protection is on.
+	pvcDeletionProtection bool
+	// namespaceDeletionProtection is set when --namespace-deletion-protection is on.
+	namespaceDeletionProtection bool
+	// requireHardLimits is set when --require-hard-limits is on.
+	requireHardLimits bool
+	// excludeHeadlessServices is set when --exclude-headless-services is on.
+	excludeHeadlessServices bool
+	// systemNamespaces is --system-namespaces; no CRQ is enforced in them.
+	systemNamespaces []string
+	// namespaceLabelDenylist is --namespace-label-denylist; no CRQ is
+	// enforced in the namespaces it matches.
+	namespaceLabelDenylist quota.NamespaceLabelDenylist
+	// warningThreshold is --webhook-warning-threshold; see v1alpha1.WarnNearQuota.
+	warningThreshold int
+	// timeoutBudgetPercent is --webhook-timeout-budget-percent; see
+	// v1alpha1.TimeoutBudget.
+	timeoutBudgetPercent int
+	// exemptUntil is --enforcement-exempt-until, zero when unset; see
+	// v1alpha1.ExemptEnforcementUntil.
+	exemptUntil time.Time
+	// observe is set under --profile=observe; see v1alpha1.ObserveOnly.
+	observe bool
+	// excludedOwners is --excluded-owners; see v1alpha1.ExcludeOwners.
+	excludedOwners objects.Owners
+	// denialTemplate is the parsed --denial-message-template, or nil.
+	denialTemplate *template.Template
+	// eventBroadcaster sends the AdmissionDenied events of denialRecorder,
+	// from which the controller fills status.lastDenied. Start starts it.
+	eventBroadcaster k8sevents.EventBroadcaster
+	denialRecorder   v1alpha1.DenialRecorder
+	// metricsLite is set when --metrics-enable is off; see metrics.LiteHandler.
+	metricsLite bool
 	// Health and readiness managers
 	healthManager    *health.HealthManager
 	readyManager     *ready.ReadinessManager
@@ -45,6 +127,18 @@ type GinWebhookServer struct {
 	// Object count handler
 	objectCountHandler *v1alpha1.ObjectCountWebhook
 
+	// DRA ResourceClaim handler
+	resourceClaimHandler *v1alpha1.ResourceClaimWebhook
+
+	// StatefulSet volumeClaimTemplates handler
+	statefulSetHandler *v1alpha1.StatefulSetWebhook
+
+	// Namespace label mutation handler, nil unless enabled
+	namespaceLabelHandler *v1alpha1.NamespaceLabelWebhook
+
+	// VPA recommendation capping handler, nil unless enabled
+	vpaHandler *v1alpha1.VerticalPodAutoscalerWebhook
+
 	k8sClient     kubernetes.Interface
 	runtimeClient client.Client
 
@@ -53,6 +147,11 @@ type GinWebhookServer struct {
 	// admission traffic to a webhook whose CRQ lookups would silently fail-open
 	// against a cold cache.
 	cacheSynced atomic.Bool
+
+	// crdAvailability re-discovers the ClusterResourceQuota CRD so the
+	// webhooks admit everything until it is installed, then enforce quotas
+	// without a restart. Nil without a kube client.
+	crdAvailability *quota.CRDAvailability
 }
 
 // NewGinWebhookServer creates a new Gin-based webhook server
@@ -91,6 +190,61 @@ func NewGinWebhookServer(
 		readinessChecker: ready.NewSimpleReadinessChecker("webhook-server"),
 		k8sClient:        kubeClient,
 		runtimeClient:    runtimeClient,
+
+		requireClientCert: cfg.WebhookClientCAFile != "",
+		admissionCAs:      &trustedCAs{},
+		frontProxyCAs:     &trustedCAs{},
+		frontProxyNames:   cfg.UsageAPIRequestHeaderAllowedNames,
+		maxRequestBytes:   cfg.WebhookMaxRequestBytes,
+		maxJSONDepth:      cfg.WebhookMaxJSONDepth,
+		usageAPI:          cfg.UsageAPIEnable,
+		tenantAPI:         cfg.TenantAPIEnable,
+		adminTokenFile:    cfg.AdminTokenFile,
+		vpaCap:            cfg.VPACapRecommendations,
+		warmCache:         cfg.WebhookWarmCache,
+		decisionCacheTTL:  cfg.WebhookDecisionCacheTTL,
+		metricsLite:       !cfg.MetricsEnable,
+
+		enabledWebhooks:             cfg.EnabledWebhooks,
+		pvcDeletionProtection:       cfg.PVCDeletionProtection,
+		namespaceDeletionProtection: cfg.NamespaceDeletionProtection,
+		tenantReviewCacheTTL:        cfg.TenantAPIReviewCacheTTL,
+		requireHardLimits:           cfg.RequireHardLimits,
+		excludeHeadlessServices:     cfg.ExcludeHeadlessServices,
+		systemNamespaces:            cfg.SystemNamespaces,
+		namespaceLabelDenylist:      cfg.NamespaceLabelDenylistSelectors(),
+		warningThreshold:            cfg.WebhookWarningThreshold,
+		timeoutBudgetPercent:        cfg.WebhookTimeoutBudgetPercent,
+		exemptUntil:                 cfg.EnforcementExemptUntilTime(),
+		observe:                     cfg.Observing(),
+		excludedOwners:              cfg.ExcludedOwnerKinds(),
+		controllerConfigName:        cfg.ControllerConfigName,
+	}
+	if cfg.DenialMessageTemplate != "" {
+		tmpl, err := config.ParseDenialMessageTemplate(cfg.DenialMessageTemplate)
+		if err != nil {
+			server.logger.Error("Ignoring invalid denial message template", zap.Error(err))
+		}
+		server.denialTemplate = tmpl
+	}
+	if server.maxRequestBytes <= 0 {
+		server.maxRequestBytes = config.DefaultWebhookMaxRequestBytes
+	}
+	if server.maxJSONDepth <= 0 {
+		server.maxJSONDepth = config.DefaultWebhookMaxJSONDepth
+	}
+	if kubeClient != nil {
+		server.eventBroadcaster = k8sevents.NewBroadcaster(&k8sevents.EventSinkImpl{Interface: kubeClient.EventsV1()})
+		server.denialRecorder = events.NewEventRecorder(
+			server.eventBroadcaster.NewRecorder(clientgoscheme.Scheme, "pac-quota-controller-webhook"), logger)
+		server.crdAvailability = quota.NewCRDAvailability(kubeClient.Discovery(), logger)
+	}
+	if cfg.NamespaceLabelsEnable {
+		server.namespaceLabels = &v1alpha1.NamespaceLabelSource{
+			Keys:             cfg.NamespaceLabelKeys,
+			AnnotationPrefix: cfg.NamespaceLabelAnnotationPrefix,
+			ConfigMap:        cfg.NamespaceLabelConfigMap,
+		}
 	}
 
 	// Setup routes
@@ -123,15 +277,98 @@ func (s *GinWebhookServer) SetupCertificateWatcher(cfg *config.Config) error {
 	}
 
 	// Configure TLS with certificate watcher
-	tlsConfig := &tls.Config{
-		GetCertificate: s.certWatcher.GetCertificate,
-	}
-	s.server.TLSConfig = tlsConfig
+	s.server.TLSConfig = s.tlsConfig()
 
 	s.logger.Info("Certificate watcher configured successfully")
 	return nil
 }
 
+// SetupClientCA loads the CA bundles used to verify kube-apiserver client
+// certificates: --webhook-client-ca-file for the admission routes and
+// --usage-api-requestheader-ca-file for the aggregator's front-proxy
+// certificate. A load failure is returned so startup fails instead of serving
+// routes that reject every request.
+func (s *GinWebhookServer) SetupClientCA(cfg *config.Config) error {
+	pool := x509.NewCertPool()
+	for _, bundle := range []struct {
+		file, flag string
+		cas        *trustedCAs
+	}{
+		{cfg.WebhookClientCAFile, "webhook client CA file", s.admissionCAs},
+		{cfg.UsageAPIRequestHeaderCAFile, "usage API requestheader CA file", s.frontProxyCAs},
+	} {
+		if bundle.file == "" {
+			continue
+		}
+		roots, err := loadCertificates(bundle.file, bundle.flag)
+		if err != nil {
+			return err
+		}
+		bundle.cas.roots = roots
+		for _, root := range roots {
+			pool.AddCert(root)
+		}
+		s.logger.Info("Client certificate verification enabled", zap.String(bundle.flag, bundle.file))
+	}
+	if len(s.admissionCAs.roots)+len(s.frontProxyCAs.roots) > 0 {
+		s.clientCAs = pool
+	}
+	return nil
+}
+
+// loadCertificates parses every certificate of the PEM bundle at path; what
+// names the bundle in errors.
+func loadCertificates(path, what string) ([]*x509.Certificate, error) {
+	rest, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s: %w", what, path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s %s", what, path)
+	}
+	return certs, nil
+}
+
+// tlsConfig builds the server TLS configuration from the certificate watcher
+// and, when configured, the client CA pool. Client certificates are verified
+// if presented rather than required at the handshake so kubelet probes on
+// /healthz and /readyz keep working; RequireVerifiedClientCert enforces them
+// on the admission routes.
+func (s *GinWebhookServer) tlsConfig() *tls.Config {
+	tlsConfig := &tls.Config{
+		GetCertificate: s.certWatcher.GetCertificate,
+	}
+	if faults.Enabled {
+		tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if err := faults.CheckCertificate(hello.Context()); err != nil {
+				return nil, err
+			}
+			return s.certWatcher.GetCertificate(hello)
+		}
+	}
+	if s.clientCAs != nil {
+		tlsConfig.ClientCAs = s.clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig
+}
+
 // setupRoutes configures all webhook routes
 func (s *GinWebhookServer) setupRoutes() {
 	// Health and readiness check endpoints
@@ -150,37 +387,180 @@ func (s *GinWebhookServer) setupRoutes() {
 
 	s.engine.GET("/healthz", s.healthManager.HealthHandler())
 	s.engine.GET("/readyz", s.readyManager.ReadyHandler())
+	// The build of the running controller, for fleet inventories; it matches
+	// the labels of pac_quota_controller_build_info.
+	s.engine.GET("/version", func(c *gin.Context) { c.JSON(http.StatusOK, version.Get()) })
 
 	// Register custom metrics into controller-runtime registry (served by manager metrics server)
 	metrics.RegisterWebhookMetrics()
+	// Without the metrics server, keep the build info and reconcile counters
+	// scrapeable on the probe port rather than losing every series.
+	if s.metricsLite {
+		s.engine.GET(metrics.LitePath, gin.WrapH(metrics.LiteHandler()))
+	}
 
 	// Create CRQ client for custom resource operations
 	var crqClient *quota.CRQClient
 	if s.runtimeClient != nil {
 		crqClient = quota.NewCRQClient(s.runtimeClient, s.logger)
+		crqClient.ExcludedNamespaces = s.systemNamespaces
+		crqClient.DeniedNamespaceLabels = s.namespaceLabelDenylist
+		crqClient.Availability = s.crdAvailability
 		s.logger.Info("CRQ client created successfully for webhook validation")
 	} else {
 		s.logger.Warn("Dynamic client is nil, CRQ operations will not be available")
 	}
 
-	s.crqHandler = v1alpha1.NewClusterResourceQuotaWebhook(s.k8sClient, crqClient, s.logger)
-	s.engine.POST("/validate-quota-powerapp-cloud-v1alpha1-clusterresourcequota", s.crqHandler.Handle)
+	admission := s.engine.Group("/")
+	if s.requireClientCert {
+		admission.Use(RequireVerifiedClientCert(s.admissionCAs, s.logger))
+	}
+	if faults.Enabled {
+		admission.Use(faults.Middleware())
+	}
+	admission.Use(LimitRequestBody(s.logger, s.maxRequestBytes, s.maxJSONDepth))
+	if s.warningThreshold > 0 {
+		admission.Use(v1alpha1.WarnNearQuota(s.warningThreshold))
+	}
+	if s.timeoutBudgetPercent > 0 {
+		admission.Use(v1alpha1.TimeoutBudget(s.timeoutBudgetPercent))
+	}
+	if !s.exemptUntil.IsZero() {
+		admission.Use(v1alpha1.ExemptEnforcementUntil(s.exemptUntil))
+	}
+	if s.denialTemplate != nil {
+		admission.Use(v1alpha1.DenialMessages(s.denialTemplate))
+	}
+	if s.denialRecorder != nil {
+		admission.Use(v1alpha1.RecordDenials(s.denialRecorder))
+	}
 
-	s.namespaceHandler = v1alpha1.NewNamespaceWebhook(s.k8sClient, crqClient, s.logger)
-	s.engine.POST("/validate--v1-namespace", s.namespaceHandler.Handle)
+	// Under the observe profile, workload requests are admitted whatever they
+	// would be denied for, and objects of excluded owners are admitted
+	// unvalidated. Quotas themselves are still validated: nothing can be
+	// measured against an invalid one.
+	workloads := admission
+	if s.observe || len(s.excludedOwners) > 0 {
+		workloads = admission.Group("/")
+	}
+	if s.observe {
+		workloads.Use(v1alpha1.ObserveOnly())
+	}
+	if len(s.excludedOwners) > 0 {
+		workloads.Use(v1alpha1.ExcludeOwners(s.excludedOwners))
+	}
 
-	s.podHandler = v1alpha1.NewPodWebhook(crqClient, s.logger)
-	s.engine.POST("/validate--v1-pod", s.podHandler.Handle)
+	if s.webhookEnabled(config.WebhookClusterResourceQuotas) {
+		s.crqHandler = v1alpha1.NewClusterResourceQuotaWebhook(s.k8sClient, crqClient, s.logger)
+		if s.requireHardLimits {
+			s.crqHandler.RequireHardLimits()
+		}
+		admission.POST(config.WebhookPaths[config.WebhookClusterResourceQuotas], s.crqHandler.Handle)
+	}
 
-	s.serviceHandler = v1alpha1.NewServiceWebhook(crqClient, s.logger)
-	s.engine.POST("/validate--v1-service", s.serviceHandler.Handle)
+	if s.webhookEnabled(config.WebhookNamespaces) {
+		s.namespaceHandler = v1alpha1.NewNamespaceWebhook(s.k8sClient, crqClient, s.logger)
+		if s.namespaceDeletionProtection {
+			s.namespaceHandler.EnableDeletionProtection()
+		}
+		workloads.POST(config.WebhookPaths[config.WebhookNamespaces], s.namespaceHandler.Handle)
+	}
 
-	s.pvcHandler = v1alpha1.NewPersistentVolumeClaimWebhook(crqClient, s.logger)
-	s.engine.POST("/validate--v1-persistentvolumeclaim", s.pvcHandler.Handle)
+	if s.webhookEnabled(config.WebhookPods) {
+		s.podHandler = v1alpha1.NewPodWebhook(crqClient, s.logger)
+		s.podHandler.EnableDecisionCache(s.decisionCacheTTL)
+		s.podHandler.EnableOwnerPolicies(s.controllerConfigName)
+		workloads.POST(config.WebhookPaths[config.WebhookPods], s.podHandler.Handle)
+	}
 
-	s.objectCountHandler = v1alpha1.NewObjectCountWebhook(crqClient, s.logger)
-	s.engine.POST("/validate-objectcount-v1", s.objectCountHandler.Handle)
+	if s.webhookEnabled(config.WebhookServices) {
+		s.serviceHandler = v1alpha1.NewServiceWebhook(crqClient, s.logger)
+		if s.excludeHeadlessServices {
+			s.serviceHandler.ExcludeHeadlessServices()
+		}
+		workloads.POST(config.WebhookPaths[config.WebhookServices], s.serviceHandler.Handle)
+	}
+
+	if s.webhookEnabled(config.WebhookPVCs) {
+		s.pvcHandler = v1alpha1.NewPersistentVolumeClaimWebhook(crqClient, s.logger)
+		if s.pvcDeletionProtection {
+			s.pvcHandler.EnableDeletionProtection()
+		}
+		workloads.POST(config.WebhookPaths[config.WebhookPVCs], s.pvcHandler.Handle)
+	}
+
+	if s.webhookEnabled(config.WebhookObjectCounts) {
+		s.objectCountHandler = v1alpha1.NewObjectCountWebhook(crqClient, s.logger)
+		workloads.POST(config.WebhookPaths[config.WebhookObjectCounts], s.objectCountHandler.Handle)
+	}
+
+	if s.webhookEnabled(config.WebhookResourceClaims) {
+		s.resourceClaimHandler = v1alpha1.NewResourceClaimWebhook(crqClient, s.logger)
+		workloads.POST(config.WebhookPaths[config.WebhookResourceClaims], s.resourceClaimHandler.Handle)
+	}
+
+	if s.webhookEnabled(config.WebhookStatefulSets) {
+		s.statefulSetHandler = v1alpha1.NewStatefulSetWebhook(crqClient, s.logger)
+		workloads.POST(config.WebhookPaths[config.WebhookStatefulSets], s.statefulSetHandler.Handle)
+	}
 
+	if s.namespaceLabels != nil {
+		s.namespaceLabelHandler = v1alpha1.NewNamespaceLabelWebhook(s.k8sClient, *s.namespaceLabels, s.logger)
+		admission.POST("/mutate--v1-namespace", s.namespaceLabelHandler.Handle)
+	}
+
+	if s.vpaCap {
+		s.vpaHandler = v1alpha1.NewVerticalPodAutoscalerWebhook(crqClient, s.logger)
+		admission.POST("/mutate-autoscaling-k8s-io-v1-verticalpodautoscaler", s.vpaHandler.Handle)
+	}
+
+	if s.usageAPI && s.runtimeClient != nil {
+		// The aggregator proxies authorized requests with its front-proxy client
+		// certificate and names the user in headers; there are no bodies to bound.
+		api := s.engine.Group("/")
+		api.Use(RequireFrontProxy(s.frontProxyCAs, s.frontProxyNames, s.logger))
+		usageapi.NewHandler(s.runtimeClient, s.logger).Register(api)
+	}
+
+	if s.tenantAPI && s.runtimeClient != nil {
+		// Portals call the tenant route directly with their users' tokens, which
+		// it reviews itself, so it needs no client certificate.
+		tenant := usageapi.NewTenantHandler(s.runtimeClient, s.k8sClient, s.logger)
+		tenant.EnableReviewCache(s.tenantReviewCacheTTL)
+		tenant.Register(s.engine.Group("/"))
+	}
+
+	if s.adminTokenFile != "" && s.runtimeClient != nil {
+		// Operators call the admin routes directly rather than the API server,
+		// so they authenticate with the admin token instead of a client cert.
+		adm := s.engine.Group("/")
+		adm.Use(admin.RequireToken(s.adminTokenFile, s.logger))
+		admin.NewHandler(s.runtimeClient, s.logger).Register(adm)
+		if faults.Enabled {
+			faults.NewHandler(s.logger).Register(adm)
+		}
+	}
+}
+
+// EnableSimulation serves POST /simulate/namespace-move with simulator. The
+// route answers callers holding the admin token when --admin-token-file is set,
+// since operators reach it through a port-forward, and otherwise requires a
+// client certificate signed by --webhook-client-ca-file. Validate rejects
+// --simulation-api-enable without either.
+func (s *GinWebhookServer) EnableSimulation(simulator simulate.Simulator) {
+	sim := s.engine.Group("/")
+	if s.adminTokenFile != "" {
+		sim.Use(admin.RequireToken(s.adminTokenFile, s.logger))
+	} else {
+		sim.Use(RequireVerifiedClientCert(s.admissionCAs, s.logger))
+	}
+	sim.Use(LimitRequestBody(s.logger, s.maxRequestBytes, s.maxJSONDepth))
+	simulate.NewHandler(simulator, s.logger).Register(sim)
+}
+
+// webhookEnabled reports whether --enable-webhooks includes the named webhook.
+func (s *GinWebhookServer) webhookEnabled(name string) bool {
+	return config.WebhookEnabled(s.enabledWebhooks, name)
 }
 
 // Start starts the webhook server
@@ -192,6 +572,17 @@ func (s *GinWebhookServer) Start(ctx context.Context) error {
 		return err
 	}
 
+	if s.eventBroadcaster != nil {
+		if err := s.eventBroadcaster.StartRecordingToSinkWithContext(ctx); err != nil {
+			return err
+		}
+		defer s.eventBroadcaster.Shutdown()
+	}
+
+	if s.crdAvailability != nil {
+		go s.crdAvailability.Run(ctx, quota.CRDRediscoveryInterval)
+	}
+
 	// Configure the server
 	s.configureServer()
 
@@ -238,9 +629,7 @@ func (s *GinWebhookServer) configureServer() {
 	s.server.Handler = s.engine
 
 	if s.certWatcher != nil {
-		s.server.TLSConfig = &tls.Config{
-			GetCertificate: s.certWatcher.GetCertificate,
-		}
+		s.server.TLSConfig = s.tlsConfig()
 		s.logger.Info("TLS configuration set up using certificate watcher")
 
 	}
@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,8 +20,12 @@ import (
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/cmd/version"
+	"github.com/powerhome/pac-quota-controller/pkg/admin"
 	"github.com/powerhome/pac-quota-controller/pkg/config"
 	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+	"github.com/powerhome/pac-quota-controller/pkg/simulate"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -146,6 +154,86 @@ var _ = Describe("GinWebhookServer", func() {
 			// Test that webhook routes are registered
 			Expect(server.engine).NotTo(BeNil())
 		})
+
+		It("serves only the webhooks --enable-webhooks lists", func() {
+			cfg.EnabledWebhooks = []string{config.WebhookPods, config.WebhookNamespaces}
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			routes := map[string]bool{}
+			for _, route := range s.engine.Routes() {
+				routes[route.Path] = true
+			}
+			Expect(routes).To(HaveKey(config.WebhookPaths[config.WebhookPods]))
+			Expect(routes).To(HaveKey(config.WebhookPaths[config.WebhookNamespaces]))
+			Expect(routes).NotTo(HaveKey(config.WebhookPaths[config.WebhookServices]))
+			Expect(routes).NotTo(HaveKey(config.WebhookPaths[config.WebhookObjectCounts]))
+			Expect(routes).NotTo(HaveKey(config.WebhookPaths[config.WebhookResourceClaims]))
+			Expect(routes).NotTo(HaveKey(config.WebhookPaths[config.WebhookStatefulSets]))
+			Expect(s.serviceHandler).To(BeNil())
+			Expect(s.resourceClaimHandler).To(BeNil())
+			Expect(s.statefulSetHandler).To(BeNil())
+		})
+
+		It("serves the admin routes only with --admin-token-file", func() {
+			hasRoute := func(s *GinWebhookServer) bool {
+				for _, route := range s.engine.Routes() {
+					if route.Method == http.MethodPost && route.Path == admin.ReconcileAllPath {
+						return true
+					}
+				}
+				return false
+			}
+			Expect(hasRoute(server)).To(BeFalse())
+
+			cfg.AdminTokenFile = "/etc/pac-quota-controller/admin-token/token"
+			Expect(hasRoute(NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger))).To(BeTrue())
+		})
+	})
+
+	Describe("EnableSimulation", func() {
+		post := func(s *GinWebhookServer, token string) int {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, simulate.NamespaceMovePath,
+				strings.NewReader(`{"namespace":"team-a"}`))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			s.engine.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		It("requires the admin token when one is configured", func() {
+			tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(tokenFile, []byte("s3cret"), 0o600)).To(Succeed())
+			cfg.AdminTokenFile = tokenFile
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			s.EnableSimulation(simulate.NewNamespaceMoveSimulator(fakeRuntimeClient, nil))
+
+			Expect(post(s, "")).To(Equal(http.StatusUnauthorized))
+			Expect(post(s, "wrong")).To(Equal(http.StatusUnauthorized))
+			Expect(post(s, "s3cret")).NotTo(Equal(http.StatusUnauthorized))
+		})
+
+		It("requires a verified client certificate without an admin token", func() {
+			cfg.WebhookClientCAFile = "/nonexistent/ca.crt"
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			s.EnableSimulation(simulate.NewNamespaceMoveSimulator(fakeRuntimeClient, nil))
+
+			Expect(post(s, "")).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("/version", func() {
+		It("serves the build of the running controller", func() {
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			var info version.Info
+			Expect(json.Unmarshal(w.Body.Bytes(), &info)).To(Succeed())
+			Expect(info).To(Equal(version.Get()))
+		})
 	})
 
 	Describe("/readyz with nil runtime client", func() {
@@ -229,4 +317,133 @@ var _ = Describe("GinWebhookServer", func() {
 		})
 	})
 
+	Describe("webhook client certificate verification", func() {
+		It("rejects admission requests without a client certificate but keeps probes open", func() {
+			cfg.WebhookClientCAFile = "/nonexistent/ca.crt"
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate--v1-pod", nil))
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+
+			w = httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		It("is a no-op when no client CA file is configured", func() {
+			Expect(server.SetupClientCA(cfg)).To(Succeed())
+			Expect(server.requireClientCert).To(BeFalse())
+			Expect(server.clientCAs).To(BeNil())
+		})
+
+		It("fails to load a missing client CA file", func() {
+			cfg.WebhookClientCAFile = "/nonexistent/ca.crt"
+			Expect(server.SetupClientCA(cfg)).To(MatchError(ContainSubstring("failed to read webhook client CA file")))
+		})
+
+		It("fails to load a missing usage API requestheader CA file", func() {
+			cfg.UsageAPIRequestHeaderCAFile = "/nonexistent/front-proxy-ca.crt"
+			Expect(server.SetupClientCA(cfg)).To(MatchError(ContainSubstring("failed to read usage API requestheader CA file")))
+		})
+
+		It("serves the usage API to the aggregator's front-proxy certificate only", func() {
+			cfg.UsageAPIEnable = true
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/apis/metrics.quota.powerapp.cloud/v1alpha1/quotausages", nil)
+			req.Header.Set("X-Remote-User", "alice")
+			s.engine.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("fails to load a client CA file without certificates", func() {
+			caFile := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+			Expect(os.WriteFile(caFile, []byte("not a certificate"), 0o600)).To(Succeed())
+			cfg.WebhookClientCAFile = caFile
+			Expect(server.SetupClientCA(cfg)).To(MatchError(ContainSubstring("no certificates found")))
+		})
+	})
+
+	Describe("metrics lite endpoint", func() {
+		It("serves the build info and reconcile counters while the metrics server is off", func() {
+			metrics.SetBuildInfo("dev", "none")
+
+			w := httptest.NewRecorder()
+			server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics-lite", nil))
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Body.String()).To(ContainSubstring("pac_quota_controller_build_info"))
+		})
+
+		It("is not served alongside the metrics server", func() {
+			cfg.MetricsEnable = true
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics-lite", nil))
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("request body limits", func() {
+		It("rejects an admission request above the configured size with 413", func() {
+			cfg.WebhookMaxRequestBytes = 1 << 20
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			body := strings.NewReader(`{"request":{"object":{"padding":"` + strings.Repeat("x", 3<<20) + `"}}}`)
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate--v1-pod", body))
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+
+		It("falls back to the default limits when unset", func() {
+			Expect(server.maxRequestBytes).To(Equal(config.DefaultWebhookMaxRequestBytes))
+			Expect(server.maxJSONDepth).To(Equal(config.DefaultWebhookMaxJSONDepth))
+		})
+	})
+
+	Describe("namespace label mutation", func() {
+		It("does not serve the mutating route by default", func() {
+			Expect(server.namespaceLabelHandler).To(BeNil())
+
+			w := httptest.NewRecorder()
+			server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mutate--v1-namespace", nil))
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("serves the mutating route when enabled", func() {
+			cfg.NamespaceLabelsEnable = true
+			cfg.NamespaceLabelKeys = []string{"team"}
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(s.namespaceLabelHandler).NotTo(BeNil())
+
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mutate--v1-namespace", strings.NewReader("{}")))
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("VPA recommendation capping", func() {
+		const route = "/mutate-autoscaling-k8s-io-v1-verticalpodautoscaler"
+
+		It("does not serve the mutating route by default", func() {
+			Expect(server.vpaHandler).To(BeNil())
+
+			w := httptest.NewRecorder()
+			server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, route, nil))
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("serves the mutating route when enabled", func() {
+			cfg.VPAEnable = true
+			cfg.VPACapRecommendations = true
+			s := NewGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(s.vpaHandler).NotTo(BeNil())
+
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, route, strings.NewReader("{}")))
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
 })
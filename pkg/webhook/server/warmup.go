@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+)
+
+// warmupLists are the kinds the admission webhooks read usage for. The
+// manager's cache starts an informer for a kind on its first List, so listing
+// them up front moves that wait from the first admission to startup.
+var warmupLists = []func() client.ObjectList{
+	func() client.ObjectList { return &corev1.PodList{} },
+	func() client.ObjectList { return &corev1.PersistentVolumeClaimList{} },
+	func() client.ObjectList { return &corev1.ServiceList{} },
+}
+
+// WarmCache lists the pods, persistent volume claims and services of every
+// namespace selected by an existing ClusterResourceQuota. Callers should invoke
+// it after the informer cache has synced and before MarkCacheSynced, so /readyz
+// only passes once the usage admission reads is cached. A failed list is
+// logged and leaves the rest of the cache to fill on demand.
+func (s *GinWebhookServer) WarmCache(ctx context.Context) {
+	if !s.warmCache || s.runtimeClient == nil {
+		return
+	}
+	start := time.Now()
+	crqClient := quota.NewCRQClient(s.runtimeClient, s.logger)
+	crqClient.Availability = s.crdAvailability
+	crqs, err := crqClient.ListAllCRQs(ctx)
+	if err != nil {
+		s.logger.Warn("Skipping webhook cache warm-up: cannot list ClusterResourceQuotas", zap.Error(err))
+		return
+	}
+
+	namespaces := make(map[string]struct{})
+	for i := range crqs {
+		for _, ns := range crqClient.GetNamespacesFromStatus(&crqs[i]) {
+			namespaces[ns] = struct{}{}
+		}
+	}
+	for ns := range namespaces {
+		for _, newList := range warmupLists {
+			list := newList()
+			if err := s.runtimeClient.List(ctx, list, client.InNamespace(ns)); err != nil {
+				s.logger.Warn("Stopping webhook cache warm-up",
+					zap.String("namespace", ns),
+					zap.String("list", fmt.Sprintf("%T", list)),
+					zap.Error(err))
+				return
+			}
+		}
+	}
+	s.logger.Info("Webhook cache warmed",
+		zap.Int("quotas", len(crqs)),
+		zap.Int("namespaces", len(namespaces)),
+		zap.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WarmCache", func() {
+	var listed []string
+
+	newServer := func(warmCache bool) *GinWebhookServer {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(quotav1alpha1.AddToScheme(scheme)).To(Succeed())
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Status: quotav1alpha1.ClusterResourceQuotaStatus{
+				Namespaces: []quotav1alpha1.ResourceQuotaStatusByNamespace{
+					{Namespace: "team-a-dev"}, {Namespace: "team-a-prod"},
+				},
+			},
+		}
+		runtimeClient := clientfake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(crq).
+			WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					listed = append(listed, fmt.Sprintf("%T/%s", list, listOpts.Namespace))
+					return c.List(ctx, list, opts...)
+				},
+			}).
+			Build()
+		cfg := &config.Config{WebhookPort: 9443, LogLevel: "info", WebhookWarmCache: warmCache}
+		return NewGinWebhookServer(cfg, fake.NewSimpleClientset(), runtimeClient, pkglogger.L())
+	}
+
+	BeforeEach(func() {
+		listed = nil
+	})
+
+	It("lists the usage of every namespace a quota selects", func() {
+		newServer(true).WarmCache(context.Background())
+
+		Expect(listed).To(ConsistOf(
+			"*v1alpha1.ClusterResourceQuotaList/",
+			"*v1.PodList/team-a-dev", "*v1.PersistentVolumeClaimList/team-a-dev", "*v1.ServiceList/team-a-dev",
+			"*v1.PodList/team-a-prod", "*v1.PersistentVolumeClaimList/team-a-prod", "*v1.ServiceList/team-a-prod",
+		))
+	})
+
+	It("does nothing when disabled", func() {
+		newServer(false).WarmCache(context.Background())
+
+		Expect(listed).To(BeEmpty())
+	})
+})
@@ -0,0 +1,106 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// fuzzEngine serves the pod and ClusterResourceQuota webhooks against a quota
+// selecting podWebhookTestNamespace. It has no recovery middleware, so a
+// panic fails the fuzz target.
+func fuzzEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	labels := map[string]string{"team": "fuzz"}
+	crqClient := newTestCRQClient(
+		makeNamespace(podWebhookTestNamespace, labels),
+		makeCRQ("fuzz-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2"), usage.ResourcePods: quantity("10")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1"), usage.ResourcePods: quantity("3")},
+		),
+	)
+	engine := gin.New()
+	engine.POST("/pod", NewPodWebhook(crqClient, zap.NewNop()).Handle)
+	engine.POST("/crq", NewClusterResourceQuotaWebhook(fake.NewClientset(), crqClient, zap.NewNop()).Handle)
+	return engine
+}
+
+func postFuzz(t *testing.T, engine *gin.Engine, path string, body []byte) {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK && w.Code != http.StatusBadRequest {
+		t.Fatalf("%s answered %d to %q", path, w.Code, body)
+	}
+}
+
+// FuzzAdmissionReview posts arbitrary bytes as the admission review body.
+func FuzzAdmissionReview(f *testing.F) {
+	pod, _ := json.Marshal(makePod("p", "100m", "64Mi", "", ""))
+	review, _ := json.Marshal(admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+		UID:       "1",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Operation: admissionv1.Create,
+		Namespace: podWebhookTestNamespace,
+		Object:    runtime.RawExtension{Raw: pod},
+	}})
+	f.Add(review)
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"request":null}`))
+	f.Add([]byte(`{"request":{"object":{"spec":{"containers":[{"resources":{"requests":{"cpu":"1e999999"}}}]}}}}`))
+	f.Add([]byte(`[`))
+
+	engine := fuzzEngine()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		postFuzz(t, engine, "/pod", body)
+		postFuzz(t, engine, "/crq", body)
+	})
+}
+
+// FuzzAdmissionObject wraps arbitrary bytes as the object of a well-formed
+// CREATE review, reaching the object decoding and quota math behind it.
+func FuzzAdmissionObject(f *testing.F) {
+	pod, _ := json.Marshal(makePod("p", "100m", "64Mi", "200m", "128Mi"))
+	f.Add(pod)
+	crq, _ := json.Marshal(makeCRQ("c", map[string]string{"team": "other"},
+		quotav1alpha1.ResourceList{usage.ResourcePods: quantity("5")}, nil))
+	f.Add(crq)
+	f.Add([]byte(`{"spec":{"containers":[{"name":"c","resources":{"requests":{"cpu":"-1"}}}]}}`))
+	f.Add([]byte(`{"spec":{"containers":[{"name":"c","ports":[{"hostPort":80}]}],"overhead":{"cpu":"99999999999999999999"}}}`))
+	f.Add([]byte(`{"spec":{"hard":{"pods":"-5"},"reserved":{"x":{"pods":"1e3"}}}}`))
+
+	engine := fuzzEngine()
+	review := func(kind metav1.GroupVersionKind, namespace string, raw []byte) []byte {
+		body, _ := json.Marshal(admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+			UID:       "1",
+			Kind:      kind,
+			Operation: admissionv1.Create,
+			Namespace: namespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		}})
+		return body
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		if !json.Valid(raw) {
+			t.Skip()
+		}
+		postFuzz(t, engine, "/pod",
+			review(metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}, podWebhookTestNamespace, raw))
+		postFuzz(t, engine, "/crq", review(metav1.GroupVersionKind{
+			Group: "quota.powerapp.cloud", Version: "v1alpha1", Kind: "ClusterResourceQuota",
+		}, "", raw))
+	})
+}
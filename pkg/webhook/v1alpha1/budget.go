@@ -0,0 +1,80 @@
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+type timeoutBudgetKey struct{}
+
+// TimeoutBudget returns middleware giving validation percent of the timeout
+// the API server sends with each admission request, in its timeout query
+// parameter. A request whose validation outlasts the budget is answered with
+// an error, so the API server applies the failurePolicy without waiting for
+// its own timeout. Requests without a timeout are not limited.
+func TimeoutBudget(percent int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), timeoutBudgetKey{}, percent)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// admissionBudget returns how long validating c's request may take, or zero
+// when it is not limited.
+func admissionBudget(c *gin.Context) time.Duration {
+	percent, _ := c.Request.Context().Value(timeoutBudgetKey{}).(int)
+	if percent <= 0 {
+		return 0
+	}
+	timeout, err := time.ParseDuration(c.Query("timeout"))
+	if err != nil || timeout <= 0 {
+		return 0
+	}
+	return timeout * time.Duration(percent) / 100
+}
+
+// admission is the outcome of an admitFn.
+type admission struct {
+	warnings []string
+	patch    []byte
+	err      error
+}
+
+// admitWithinBudget runs admit, giving up once budget has passed; a zero
+// budget waits for it. finished is false when admit was given up on. Its
+// context is then cancelled, so lookups still in flight return early and
+// nothing they decide is kept, such as in the decision cache.
+func admitWithinBudget(
+	ctx context.Context,
+	budget time.Duration,
+	req *admissionv1.AdmissionRequest,
+	admit admitFn,
+) (result admission, finished bool) {
+	if budget <= 0 {
+		warnings, patch, err := admit(ctx, req)
+		return admission{warnings: warnings, patch: patch, err: err}, true
+	}
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	done := make(chan admission, 1)
+	go func() {
+		warnings, patch, err := admit(ctx, req)
+		done <- admission{warnings: warnings, patch: patch, err: err}
+	}()
+	select {
+	case result = <-done:
+		return result, true
+	case <-ctx.Done():
+		// Prefer a result that raced the deadline.
+		select {
+		case result = <-done:
+			return result, true
+		default:
+			return admission{}, false
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+var _ = Describe("Admission timeout budget", func() {
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	// slow waits for its context, as a lookup outrunning the budget would.
+	slow := func(ctx context.Context, _ *admissionv1.AdmissionRequest) ([]string, error) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		return nil, errors.New("quota exceeded")
+	}
+	fast := func(context.Context, *admissionv1.AdmissionRequest) ([]string, error) {
+		return nil, errors.New("quota exceeded")
+	}
+	route := func(validate validateFn) {
+		engine.POST("/webhook", func(c *gin.Context) {
+			runWebhook(c, zap.NewNop(), webhookConfig{name: "t"}, validate)
+		})
+	}
+	send := func(url string) *admissionv1.AdmissionReview {
+		body, _ := json.Marshal(&admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: "1"}})
+		req, _ := http.NewRequest("POST", url, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		var review admissionv1.AdmissionReview
+		Expect(json.Unmarshal(w.Body.Bytes(), &review)).To(Succeed())
+		return &review
+	}
+
+	It("leaves a request whose validation outruns the budget to the failure policy", func() {
+		engine.Use(TimeoutBudget(50))
+		route(slow)
+
+		body, _ := json.Marshal(&admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: "1"}})
+		req, _ := http.NewRequest("POST", "/webhook?timeout=200ms", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		start := time.Now()
+		engine.ServeHTTP(w, req)
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(w.Body.String()).To(ContainSubstring("quota not validated within its time budget of 100ms"))
+	})
+
+	It("decides requests validated within the budget", func() {
+		engine.Use(TimeoutBudget(50))
+		route(fast)
+
+		resp := send("/webhook?timeout=10s")
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(Equal("quota exceeded"))
+	})
+
+	It("waits for validation without a timeout", func() {
+		engine.Use(TimeoutBudget(50))
+		route(fast)
+
+		resp := send("/webhook")
+		Expect(resp.Response.Allowed).To(BeFalse())
+	})
+
+	It("waits for validation without the middleware", func() {
+		route(fast)
+
+		resp := send("/webhook?timeout=1ms")
+		Expect(resp.Response.Allowed).To(BeFalse())
+	})
+})
@@ -3,16 +3,27 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/countable"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/namespace"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
 // ClusterResourceQuotaWebhook handles webhook requests for ClusterResourceQuota resources
@@ -20,6 +31,9 @@ type ClusterResourceQuotaWebhook struct {
 	client    kubernetes.Interface
 	crqClient *quota.CRQClient
 	logger    *zap.Logger
+	// requireHardLimits denies quotas that limit nothing; off unless
+	// RequireHardLimits is called.
+	requireHardLimits bool
 }
 
 // NewClusterResourceQuotaWebhook creates a new ClusterResourceQuotaWebhook
@@ -39,6 +53,12 @@ func NewClusterResourceQuotaWebhook(
 	}
 }
 
+// RequireHardLimits denies ClusterResourceQuotas that limit nothing. Without
+// it they are admitted and only flagged by the NoHardLimits condition.
+func (h *ClusterResourceQuotaWebhook) RequireHardLimits() {
+	h.requireHardLimits = true
+}
+
 // Handle handles the webhook request for ClusterResourceQuota
 func (h *ClusterResourceQuotaWebhook) Handle(c *gin.Context) {
 	runWebhook(c, h.logger, webhookConfig{
@@ -65,7 +85,18 @@ func (h *ClusterResourceQuotaWebhook) validate(
 	}
 
 	switch req.Operation {
-	case admissionv1.Create, admissionv1.Update:
+	case admissionv1.Create:
+		return nil, h.validateOperation(ctx, &crq)
+	case admissionv1.Update:
+		if len(req.OldObject.Raw) > 0 {
+			var oldCRQ quotav1alpha1.ClusterResourceQuota
+			if err := decodeAdmissionObject(req.OldObject.Raw, &oldCRQ, "ClusterResourceQuota"); err != nil {
+				return nil, err
+			}
+			if err := validateSelectorPolicy(&oldCRQ, &crq); err != nil {
+				return nil, err
+			}
+		}
 		return nil, h.validateOperation(ctx, &crq)
 	default:
 		// Unknown operations (e.g. DELETE) are intentionally allowed; the
@@ -86,9 +117,476 @@ func (h *ClusterResourceQuotaWebhook) validateOperation(
 		return fmt.Errorf("CRQ client not available for validation")
 	}
 
+	if err := validateQuantities(crq); err != nil {
+		return err
+	}
+	if h.requireHardLimits && !crq.Spec.HasTrackedLimits() && crq.Spec.HardLimitsFrom == nil {
+		return fmt.Errorf("ClusterResourceQuota %s limits nothing: set at least one spec.hard key that "+
+			"spec.enforcementPolicy does not turn Off, a spec.topologyHard limit, or spec.hardLimitsFrom", crq.Name)
+	}
+	if err := validateObservedKeys(crq); err != nil {
+		return err
+	}
+	if err := validateReserved(crq); err != nil {
+		return err
+	}
+	if err := validateFederationSlices(crq); err != nil {
+		return err
+	}
+	if err := validateMissingRequests(crq); err != nil {
+		return err
+	}
+	if err := validateEphemeralContainers(crq); err != nil {
+		return err
+	}
+	if err := validateOSKeys(crq); err != nil {
+		return err
+	}
+	if err := validateHugePagesKeys(crq); err != nil {
+		return err
+	}
+	if err := validateSupportedKeys(crq); err != nil {
+		return err
+	}
+	if err := validateTopologyHard(crq); err != nil {
+		return err
+	}
+	if err := validateEnforcementPolicy(crq); err != nil {
+		return err
+	}
+	if err := validateUsageWeights(crq); err != nil {
+		return err
+	}
+	if err := validateCreationRates(crq); err != nil {
+		return err
+	}
+	if err := validateHardLimitsFrom(crq); err != nil {
+		return err
+	}
+	if err := validateExemptUntil(crq); err != nil {
+		return err
+	}
+
 	validator := namespace.NewNamespaceValidator(h.client, h.crqClient)
 	if err := validator.ValidateCRQNamespaceConflicts(ctx, crq); err != nil {
 		return err
 	}
 	return nil
 }
+
+// validateSelectorPolicy rejects an update of a quota whose
+// spec.selectorPolicy was Immutable that changes its namespace selector or
+// sets the policy back to Mutable. Selectors are compared by what they
+// select, so reordering their requirements is not a change.
+func validateSelectorPolicy(oldCRQ, crq *quotav1alpha1.ClusterResourceQuota) error {
+	if oldCRQ.Spec.SelectorPolicy != quotav1alpha1.SelectorPolicyImmutable {
+		return nil
+	}
+	if crq.Spec.SelectorPolicy != quotav1alpha1.SelectorPolicyImmutable {
+		return fmt.Errorf("ClusterResourceQuota %s has an Immutable spec.selectorPolicy, which cannot be changed",
+			crq.Name)
+	}
+	if !sameSelector(oldCRQ.Spec.NamespaceSelector, crq.Spec.NamespaceSelector) {
+		return fmt.Errorf("ClusterResourceQuota %s has an Immutable spec.selectorPolicy: "+
+			"spec.namespaceSelector cannot be changed; recreate the quota to retarget it", crq.Name)
+	}
+	return nil
+}
+
+// sameSelector reports whether a and b select the same objects.
+func sameSelector(a, b *metav1.LabelSelector) bool {
+	// A nil selector selects nothing, an empty one everything.
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	selA, errA := metav1.LabelSelectorAsSelector(a)
+	selB, errB := metav1.LabelSelectorAsSelector(b)
+	if errA != nil || errB != nil {
+		return equality.Semantic.DeepEqual(a, b)
+	}
+	return selA.String() == selB.String()
+}
+
+// validateQuantities rejects negative quantities anywhere in the spec with a
+// 400 naming the field. The CRD schema only checks the quantity format, which
+// allows a sign, and every limit and charge is computed assuming none is
+// negative.
+func validateQuantities(crq *quotav1alpha1.ClusterResourceQuota) error {
+	check := func(path string, list quotav1alpha1.ResourceList) error {
+		names := make([]string, 0, len(list))
+		for resourceName := range list {
+			names = append(names, string(resourceName))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if q := list[corev1.ResourceName(name)]; q.Sign() < 0 {
+				return newStatusErrorf(http.StatusBadRequest, "%s[%s]: quantity %s must not be negative",
+					path, name, q.String())
+			}
+		}
+		return nil
+	}
+	checkKeyed := func(path string, lists map[string]quotav1alpha1.ResourceList) error {
+		keys := make([]string, 0, len(lists))
+		for key := range lists {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := check(fmt.Sprintf("%s[%s]", path, key), lists[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	spec := &crq.Spec
+	if err := check("spec.hard", spec.Hard); err != nil {
+		return err
+	}
+	if err := check("spec.ephemeralContainers", spec.EphemeralContainers); err != nil {
+		return err
+	}
+	if spec.MissingRequests != nil {
+		if err := check("spec.missingRequests.defaults", spec.MissingRequests.Defaults); err != nil {
+			return err
+		}
+	}
+	if err := checkKeyed("spec.reserved", spec.Reserved); err != nil {
+		return err
+	}
+	if err := checkKeyed("spec.topologyHard", spec.TopologyHard); err != nil {
+		return err
+	}
+	if spec.Federation != nil {
+		return checkKeyed("spec.federation.slices", spec.Federation.Slices)
+	}
+	return nil
+}
+
+// validateObservedKeys rejects bare cpu and memory hard keys outside Actual
+// mode. Only metrics-server observations give them a meaning; counted against
+// pod specs they would always report zero usage.
+func validateObservedKeys(crq *quotav1alpha1.ClusterResourceQuota) error {
+	if crq.Spec.Mode == quotav1alpha1.QuotaModeActual {
+		return nil
+	}
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := crq.Spec.Hard[resourceName]; ok {
+			return fmt.Errorf("spec.hard[%s] is only supported with mode: Actual; use requests.%s or limits.%s",
+				resourceName, resourceName, resourceName)
+		}
+	}
+	return nil
+}
+
+// validateReserved rejects spec.reserved entries the pod webhook could not
+// honor: headroom for a resource without a hard limit, or more headroom in
+// total across priority classes than the hard limit itself.
+func validateReserved(crq *quotav1alpha1.ClusterResourceQuota) error {
+	classes := make([]string, 0, len(crq.Spec.Reserved))
+	for class := range crq.Spec.Reserved {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	totals := make(map[corev1.ResourceName]resource.Quantity)
+	for _, class := range classes {
+		for resourceName, q := range crq.Spec.Reserved[class] {
+			if _, ok := crq.Spec.Hard[resourceName]; !ok {
+				return fmt.Errorf("spec.reserved[%s] reserves %s, which has no hard limit in spec.hard",
+					class, resourceName)
+			}
+			total := totals[resourceName]
+			total.Add(q)
+			totals[resourceName] = total
+		}
+	}
+
+	resourceNames := make([]string, 0, len(totals))
+	for resourceName := range totals {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		total, limit := totals[corev1.ResourceName(name)], crq.Spec.Hard[corev1.ResourceName(name)]
+		if total.Cmp(limit) > 0 {
+			return fmt.Errorf("spec.reserved reserves %s of %s in total, more than the hard limit %s",
+				total.String(), name, limit.String())
+		}
+	}
+	return nil
+}
+
+// validateFederationSlices rejects per-cluster slices the webhook could not
+// honor: a slice for a resource without a hard limit, or one larger than the
+// shared hard limit itself.
+func validateFederationSlices(crq *quotav1alpha1.ClusterResourceQuota) error {
+	if crq.Spec.Federation == nil {
+		return nil
+	}
+	clusters := make([]string, 0, len(crq.Spec.Federation.Slices))
+	for cluster := range crq.Spec.Federation.Slices {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	for _, cluster := range clusters {
+		slice := crq.Spec.Federation.Slices[cluster]
+		resourceNames := make([]string, 0, len(slice))
+		for resourceName := range slice {
+			resourceNames = append(resourceNames, string(resourceName))
+		}
+		sort.Strings(resourceNames)
+		for _, name := range resourceNames {
+			limit, ok := crq.Spec.Hard[corev1.ResourceName(name)]
+			if !ok {
+				return fmt.Errorf("spec.federation.slices[%s] limits %s, which has no hard limit in spec.hard",
+					cluster, name)
+			}
+			if q := slice[corev1.ResourceName(name)]; q.Cmp(limit) > 0 {
+				return fmt.Errorf("spec.federation.slices[%s] gives %s of %s, more than the hard limit %s",
+					cluster, q.String(), name, limit.String())
+			}
+		}
+	}
+	return nil
+}
+
+// validateMissingRequests rejects a spec.missingRequests the pod webhook could
+// not apply: Assume without defaults, defaults under any other action, or a
+// default for a resource that is not charged from container requests or limits.
+func validateMissingRequests(crq *quotav1alpha1.ClusterResourceQuota) error {
+	policy := crq.Spec.MissingRequests
+	if policy == nil {
+		return nil
+	}
+	if policy.Action != quotav1alpha1.MissingRequestsAssume {
+		if len(policy.Defaults) > 0 {
+			return fmt.Errorf("spec.missingRequests.defaults requires action: %s", quotav1alpha1.MissingRequestsAssume)
+		}
+		return nil
+	}
+	if len(policy.Defaults) == 0 {
+		return fmt.Errorf("spec.missingRequests action %s requires defaults", quotav1alpha1.MissingRequestsAssume)
+	}
+	resourceNames := make([]string, 0, len(policy.Defaults))
+	for resourceName := range policy.Defaults {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if !isPodComputeResource(corev1.ResourceName(name)) {
+			return fmt.Errorf("spec.missingRequests.defaults sets %s, which is not a container request or limit", name)
+		}
+	}
+	return nil
+}
+
+// validateEphemeralContainers rejects a spec.ephemeralContainers charge for a
+// resource that is not charged from container requests or limits.
+func validateEphemeralContainers(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.EphemeralContainers))
+	for resourceName := range crq.Spec.EphemeralContainers {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if !isPodComputeResource(corev1.ResourceName(name)) {
+			return fmt.Errorf("spec.ephemeralContainers sets %s, which is not a container request or limit", name)
+		}
+	}
+	return nil
+}
+
+// validateSupportedKeys rejects spec.hard keys no calculator counts, such as
+// the typo "congigmaps". Their usage would stay incomplete, and the quota
+// could never enforce them. Bare cpu and memory are observed in Actual mode.
+func validateSupportedKeys(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.Hard))
+	for resourceName := range crq.Spec.Hard {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if _, observed := podmetrics.ObservedResource(corev1.ResourceName(name)); observed &&
+			crq.Spec.Mode == quotav1alpha1.QuotaModeActual {
+			continue
+		}
+		if !countable.Supports(corev1.ResourceName(name)) {
+			return newStatusErrorf(http.StatusBadRequest,
+				"spec.hard[%s]: no calculator counts this resource; check the key for typos", name)
+		}
+	}
+	return nil
+}
+
+// validateOSKeys rejects OS-scoped hard keys, such as "windows.requests.cpu",
+// that do not bound pods or a container request or limit the pod webhook charges.
+func validateOSKeys(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.Hard))
+	for resourceName := range crq.Spec.Hard {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		_, base, ok := usage.SplitOSResource(corev1.ResourceName(name))
+		if !ok || base == usage.ResourcePods || isPodComputeResource(base) {
+			continue
+		}
+		return fmt.Errorf("spec.hard[%s]: OS-scoped keys only support pods and container requests or limits, not %s",
+			name, base)
+	}
+	return nil
+}
+
+// validateHugePagesKeys rejects hugepages hard keys whose page size, as in
+// "requests.hugepages-1Gi", is not a quantity. No pod could request them, so
+// the limit would never be charged.
+func validateHugePagesKeys(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.Hard))
+	for resourceName := range crq.Spec.Hard {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		pages := strings.TrimPrefix(name, corev1.DefaultResourceRequestsPrefix)
+		if !strings.HasPrefix(pages, corev1.ResourceHugePagesPrefix) || usage.IsHugePages(corev1.ResourceName(pages)) {
+			continue
+		}
+		return newStatusErrorf(http.StatusBadRequest,
+			"spec.hard[%s]: hugepages page size %q is not a quantity such as 2Mi or 1Gi",
+			name, strings.TrimPrefix(pages, corev1.ResourceHugePagesPrefix))
+	}
+	return nil
+}
+
+// validateTopologyHard rejects spec.topologyHard without a spec.topologyKey to
+// split usage by, and limits on anything but pods and container requests or
+// limits, which are the only usage attributed to a node.
+func validateTopologyHard(crq *quotav1alpha1.ClusterResourceQuota) error {
+	if len(crq.Spec.TopologyHard) == 0 {
+		return nil
+	}
+	if crq.Spec.TopologyKey == "" {
+		return fmt.Errorf("spec.topologyHard requires spec.topologyKey")
+	}
+	values := make([]string, 0, len(crq.Spec.TopologyHard))
+	for value := range crq.Spec.TopologyHard {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		resourceNames := make([]string, 0, len(crq.Spec.TopologyHard[value]))
+		for resourceName := range crq.Spec.TopologyHard[value] {
+			resourceNames = append(resourceNames, string(resourceName))
+		}
+		sort.Strings(resourceNames)
+		for _, name := range resourceNames {
+			resourceName := corev1.ResourceName(name)
+			if resourceName != usage.ResourcePods && !isPodComputeResource(resourceName) {
+				return fmt.Errorf("spec.topologyHard[%s] sets %s; only pods and container requests or limits are supported",
+					value, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEnforcementPolicy rejects spec.enforcementPolicy entries for keys
+// spec.hard does not set, which would silently do nothing. Keys
+// spec.hardLimitsFrom will set count as set: every key when it maps none.
+func validateEnforcementPolicy(crq *quotav1alpha1.ClusterResourceQuota) error {
+	from := crq.Spec.HardLimitsFrom
+	if from != nil && len(from.Items) == 0 {
+		return nil
+	}
+	resourceNames := make([]string, 0, len(crq.Spec.EnforcementPolicy))
+	for resourceName := range crq.Spec.EnforcementPolicy {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if from != nil && slices.ContainsFunc(from.Items, func(item quotav1alpha1.HardLimitItem) bool {
+			return item.Resource == corev1.ResourceName(name)
+		}) {
+			continue
+		}
+		if _, ok := crq.Spec.Hard[corev1.ResourceName(name)]; !ok {
+			return fmt.Errorf("spec.enforcementPolicy[%s] is set but spec.hard has no %s limit", name, name)
+		}
+	}
+	return nil
+}
+
+// validateUsageWeights rejects spec.usageWeights entries that are not
+// positive, which would count usage as nothing, and a weight on
+// pods.density/per-cpu, a ratio rather than an amount.
+func validateUsageWeights(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.UsageWeights))
+	for resourceName := range crq.Spec.UsageWeights {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if corev1.ResourceName(name) == usage.ResourcePodDensity {
+			return fmt.Errorf("spec.usageWeights[%s] is not supported: densities cannot be weighted", name)
+		}
+		if weight := crq.Spec.UsageWeights[corev1.ResourceName(name)]; weight.MilliValue() <= 0 {
+			return fmt.Errorf("spec.usageWeights[%s]: weight %s must be positive", name, weight.String())
+		}
+	}
+	return nil
+}
+
+// validateCreationRates rejects spec.creationRates entries for resources
+// other than pods, whose creations no webhook throttles, and rates that are
+// not positive, which would admit nothing.
+func validateCreationRates(crq *quotav1alpha1.ClusterResourceQuota) error {
+	resourceNames := make([]string, 0, len(crq.Spec.CreationRates))
+	for resourceName := range crq.Spec.CreationRates {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		if corev1.ResourceName(name) != usage.ResourcePods {
+			return fmt.Errorf("spec.creationRates[%s] is not supported: only pods creations are rate limited", name)
+		}
+		if rate := crq.Spec.CreationRates[corev1.ResourceName(name)]; rate.Sign() <= 0 {
+			return fmt.Errorf("spec.creationRates[%s]: rate %s must be positive", name, rate.String())
+		}
+	}
+	return nil
+}
+
+// validateHardLimitsFrom rejects spec.hardLimitsFrom items mapping two
+// ConfigMap keys to the same resource, whose limit would then depend on
+// which key is read last.
+func validateHardLimitsFrom(crq *quotav1alpha1.ClusterResourceQuota) error {
+	if crq.Spec.HardLimitsFrom == nil {
+		return nil
+	}
+	keys := make(map[corev1.ResourceName]string, len(crq.Spec.HardLimitsFrom.Items))
+	for _, item := range crq.Spec.HardLimitsFrom.Items {
+		if other, ok := keys[item.Resource]; ok {
+			return fmt.Errorf("spec.hardLimitsFrom maps both keys %q and %q to %s", other, item.Key, item.Resource)
+		}
+		keys[item.Resource] = item.Key
+	}
+	return nil
+}
+
+// validateExemptUntil rejects an AnnotationEnforcementExemptUntil that is not
+// an RFC 3339 time, which would otherwise be ignored and leave the quota
+// enforced.
+func validateExemptUntil(crq *quotav1alpha1.ClusterResourceQuota) error {
+	value, ok := crq.Annotations[quotav1alpha1.AnnotationEnforcementExemptUntil]
+	if !ok {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return newStatusErrorf(http.StatusBadRequest, "annotation %s must be an RFC 3339 time such as "+
+			"2026-01-02T06:00:00Z, got %q", quotav1alpha1.AnnotationEnforcementExemptUntil, value)
+	}
+	return nil
+}
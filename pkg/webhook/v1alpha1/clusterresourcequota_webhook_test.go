@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -84,8 +86,8 @@ var _ = Describe("ClusterResourceQuotaWebhook", func() {
 				},
 				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
 					Hard: quotav1alpha1.ResourceList{
-						"cpu":    resource.MustParse("4"),
-						"memory": resource.MustParse("8Gi"),
+						"requests.cpu":    resource.MustParse("4"),
+						"requests.memory": resource.MustParse("8Gi"),
 					},
 					NamespaceSelector: &metav1.LabelSelector{
 						MatchLabels: map[string]string{
@@ -100,6 +102,561 @@ var _ = Describe("ClusterResourceQuotaWebhook", func() {
 		})
 	})
 
+	Describe("validateQuantities", func() {
+		newCRQ := func() *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quantities-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard:              quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("10")},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+		}
+
+		It("rejects a negative hard limit with a 400", func() {
+			crq := newCRQ()
+			crq.Spec.Hard["pods"] = resource.MustParse("-1")
+
+			err := webhook.validateOperation(ctx, crq)
+			Expect(err).To(MatchError("spec.hard[pods]: quantity -1 must not be negative"))
+			var se *statusError
+			Expect(errors.As(err, &se)).To(BeTrue())
+			Expect(se.code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("rejects negative quantities in keyed lists", func() {
+			crq := newCRQ()
+			crq.Spec.TopologyHard = map[string]quotav1alpha1.ResourceList{
+				"us-east-1a": {"requests.cpu": resource.MustParse("-500m")},
+			}
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				"spec.topologyHard[us-east-1a][requests.cpu]: quantity -500m must not be negative"))
+		})
+
+		It("accepts zero", func() {
+			crq := newCRQ()
+			crq.Spec.Hard["pods"] = resource.MustParse("0")
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+	})
+
+	Describe("validateObservedKeys", func() {
+		newCRQ := func(mode quotav1alpha1.QuotaMode) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "observed-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Mode:              mode,
+					Hard:              quotav1alpha1.ResourceList{"memory": resource.MustParse("8Gi")},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+		}
+
+		It("rejects bare cpu or memory outside Actual mode", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(""))).To(MatchError(
+				"spec.hard[memory] is only supported with mode: Actual; use requests.memory or limits.memory"))
+		})
+
+		It("accepts bare cpu or memory in Actual mode", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.QuotaModeActual))).To(Succeed())
+		})
+	})
+
+	Describe("validateReserved", func() {
+		newCRQ := func(reserved map[string]quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "reserved-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard: quotav1alpha1.ResourceList{
+						"requests.cpu": resource.MustParse("10"),
+						"pods":         resource.MustParse("50"),
+					},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Reserved:          reserved,
+				},
+			}
+		}
+
+		It("accepts headroom within the hard limits", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"system-cluster-critical": {"requests.cpu": resource.MustParse("2"), "pods": resource.MustParse("5")},
+				"system-node-critical":    {"requests.cpu": resource.MustParse("8")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+
+		It("rejects headroom for a resource without a hard limit", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"system-cluster-critical": {"requests.memory": resource.MustParse("1Gi")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				"spec.reserved[system-cluster-critical] reserves requests.memory, which has no hard limit in spec.hard"))
+		})
+
+		It("rejects more headroom in total than the hard limit", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"system-cluster-critical": {"requests.cpu": resource.MustParse("6")},
+				"system-node-critical":    {"requests.cpu": resource.MustParse("5")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				"spec.reserved reserves 11 of requests.cpu in total, more than the hard limit 10"))
+		})
+	})
+
+	Describe("validateFederationSlices", func() {
+		newCRQ := func(slices map[string]quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "federated-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard:              quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("10")},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Federation:        &quotav1alpha1.FederationSpec{Slices: slices},
+				},
+			}
+		}
+
+		It("accepts slices within the hard limit", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"us-east": {"requests.cpu": resource.MustParse("6")},
+				"us-west": {"requests.cpu": resource.MustParse("6")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+
+		It("rejects a slice for a resource without a hard limit", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"us-east": {"requests.memory": resource.MustParse("1Gi")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				"spec.federation.slices[us-east] limits requests.memory, which has no hard limit in spec.hard"))
+		})
+
+		It("rejects a slice larger than the hard limit", func() {
+			crq := newCRQ(map[string]quotav1alpha1.ResourceList{
+				"us-east": {"requests.cpu": resource.MustParse("12")},
+			})
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				"spec.federation.slices[us-east] gives 12 of requests.cpu, more than the hard limit 10"))
+		})
+	})
+
+	Describe("validateMissingRequests", func() {
+		newCRQ := func(policy *quotav1alpha1.MissingRequestsPolicy) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "missing-requests-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard:              quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("10")},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					MissingRequests:   policy,
+				},
+			}
+		}
+
+		It("accepts Deny and Assume with container defaults", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(&quotav1alpha1.MissingRequestsPolicy{
+				Action: quotav1alpha1.MissingRequestsDeny,
+			}))).To(Succeed())
+			Expect(webhook.validateOperation(ctx, newCRQ(&quotav1alpha1.MissingRequestsPolicy{
+				Action:   quotav1alpha1.MissingRequestsAssume,
+				Defaults: quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("100m")},
+			}))).To(Succeed())
+		})
+
+		It("rejects Assume without defaults", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(&quotav1alpha1.MissingRequestsPolicy{
+				Action: quotav1alpha1.MissingRequestsAssume,
+			}))).To(MatchError("spec.missingRequests action Assume requires defaults"))
+		})
+
+		It("rejects defaults under another action", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(&quotav1alpha1.MissingRequestsPolicy{
+				Action:   quotav1alpha1.MissingRequestsDeny,
+				Defaults: quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("100m")},
+			}))).To(MatchError("spec.missingRequests.defaults requires action: Assume"))
+		})
+
+		It("rejects a default that is not a container request or limit", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(&quotav1alpha1.MissingRequestsPolicy{
+				Action:   quotav1alpha1.MissingRequestsAssume,
+				Defaults: quotav1alpha1.ResourceList{"requests.storage": resource.MustParse("1Gi")},
+			}))).To(MatchError(
+				"spec.missingRequests.defaults sets requests.storage, which is not a container request or limit"))
+		})
+	})
+
+	Describe("validateSupportedKeys", func() {
+		newCRQ := func(mode quotav1alpha1.QuotaMode, hard quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "typo-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Mode:              mode,
+					Hard:              hard,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+		}
+
+		It("rejects a hard key no calculator counts", func() {
+			err := webhook.validateOperation(ctx, newCRQ("", quotav1alpha1.ResourceList{
+				"configmaps": resource.MustParse("10"),
+				"congigmaps": resource.MustParse("10"),
+			}))
+			Expect(err).To(MatchError(ContainSubstring("spec.hard[congigmaps]: no calculator counts this resource")))
+			var se *statusError
+			Expect(errors.As(err, &se)).To(BeTrue())
+			Expect(se.code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("accepts observed cpu and memory in Actual mode", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.QuotaModeActual, quotav1alpha1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("4"),
+			}))).To(Succeed())
+		})
+	})
+
+	Describe("validateOSKeys", func() {
+		newCRQ := func(hard quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "os-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard:              hard,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+		}
+
+		It("accepts OS-scoped pods and container resources", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"windows.pods":         resource.MustParse("10"),
+				"windows.requests.cpu": resource.MustParse("8"),
+				"linux.limits.memory":  resource.MustParse("64Gi"),
+			}))).To(Succeed())
+		})
+
+		It("rejects an OS-scoped key for a resource pods are not charged", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"windows.requests.storage": resource.MustParse("1Ti"),
+			}))).To(MatchError(
+				"spec.hard[windows.requests.storage]: OS-scoped keys only support pods and container requests or limits, " +
+					"not requests.storage"))
+		})
+	})
+
+	Describe("validateHugePagesKeys", func() {
+		newCRQ := func(hard quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "hugepages-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					Hard:              hard,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+		}
+
+		It("accepts hugepages of every page size", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"requests.hugepages-1Gi": resource.MustParse("4Gi"),
+				"requests.hugepages-2Mi": resource.MustParse("1Gi"),
+				"hugepages-32Mi":         resource.MustParse("512Mi"),
+			}))).To(Succeed())
+		})
+
+		It("rejects a page size that is not a quantity", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"requests.hugepages-1GB": resource.MustParse("4Gi"),
+			}))).To(MatchError(ContainSubstring(`spec.hard[requests.hugepages-1GB]: hugepages page size "1GB"`)))
+		})
+	})
+
+	Describe("validateTopologyHard", func() {
+		newCRQ := func(key string, limits quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "topology-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					TopologyKey:       key,
+					TopologyHard:      map[string]quotav1alpha1.ResourceList{"zone-a": limits},
+				},
+			}
+		}
+
+		It("accepts pod and container resource limits per value", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ("topology.kubernetes.io/zone", quotav1alpha1.ResourceList{
+				"pods":         resource.MustParse("10"),
+				"requests.cpu": resource.MustParse("4"),
+			}))).To(Succeed())
+		})
+
+		It("rejects limits without a topology key", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ("", quotav1alpha1.ResourceList{
+				"pods": resource.MustParse("10"),
+			}))).To(MatchError("spec.topologyHard requires spec.topologyKey"))
+		})
+
+		It("rejects limits on resources not attributed to nodes", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ("topology.kubernetes.io/zone", quotav1alpha1.ResourceList{
+				"services": resource.MustParse("10"),
+			}))).To(MatchError(
+				"spec.topologyHard[zone-a] sets services; only pods and container requests or limits are supported"))
+		})
+	})
+
+	Describe("validateEnforcementPolicy", func() {
+		newCRQ := func(policy map[corev1.ResourceName]quotav1alpha1.EnforcementAction) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Hard:              quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("4")},
+					EnforcementPolicy: policy,
+				},
+			}
+		}
+
+		It("accepts a policy for a hard key", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+				"requests.cpu": quotav1alpha1.EnforcementReportOnly,
+			}))).To(Succeed())
+		})
+
+		It("rejects a policy for a key without a hard limit", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+				"configmaps": quotav1alpha1.EnforcementOff,
+			}))).To(MatchError("spec.enforcementPolicy[configmaps] is set but spec.hard has no configmaps limit"))
+		})
+
+		It("accepts a policy for a key hardLimitsFrom sets", func() {
+			crq := newCRQ(map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+				"requests.memory": quotav1alpha1.EnforcementReportOnly,
+			})
+			crq.Spec.HardLimitsFrom = &quotav1alpha1.HardLimitsSource{Namespace: "finance", Name: "limits"}
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+
+			crq.Spec.HardLimitsFrom.Items = []quotav1alpha1.HardLimitItem{{Key: "memory", Resource: "requests.memory"}}
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+
+			crq.Spec.HardLimitsFrom.Items = []quotav1alpha1.HardLimitItem{{Key: "pods", Resource: "pods"}}
+			Expect(webhook.validateOperation(ctx, crq)).To(
+				MatchError(ContainSubstring("spec.enforcementPolicy[requests.memory]")))
+		})
+	})
+
+	Describe("validateUsageWeights", func() {
+		newCRQ := func(weights quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "weights-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Hard:              quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("4")},
+					UsageWeights:      weights,
+				},
+			}
+		}
+
+		It("accepts positive weights", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"requests.cpu": resource.MustParse("500m"),
+				"pods":         resource.MustParse("2"),
+			}))).To(Succeed())
+		})
+
+		It("rejects a weight that is not positive", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"requests.cpu": resource.MustParse("0"),
+			}))).To(MatchError("spec.usageWeights[requests.cpu]: weight 0 must be positive"))
+		})
+
+		It("rejects a weight on pod density", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"pods.density/per-cpu": resource.MustParse("2"),
+			}))).To(MatchError(ContainSubstring("densities cannot be weighted")))
+		})
+	})
+
+	Describe("validateSelectorPolicy", func() {
+		newCRQ := func(
+			policy quotav1alpha1.SelectorPolicy, selector *metav1.LabelSelector,
+		) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: selector,
+					SelectorPolicy:    policy,
+				},
+			}
+		}
+		teamA := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+		teamB := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}
+
+		It("lets mutable selectors change", func() {
+			Expect(validateSelectorPolicy(newCRQ("", teamA), newCRQ("", teamB))).To(Succeed())
+			Expect(validateSelectorPolicy(
+				newCRQ(quotav1alpha1.SelectorPolicyMutable, teamA),
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, teamB),
+			)).To(Succeed())
+		})
+
+		It("rejects changing an immutable selector", func() {
+			Expect(validateSelectorPolicy(
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, teamA),
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, teamB),
+			)).To(MatchError(ContainSubstring("spec.namespaceSelector cannot be changed")))
+		})
+
+		It("rejects making an immutable selector mutable again", func() {
+			Expect(validateSelectorPolicy(
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, teamA),
+				newCRQ(quotav1alpha1.SelectorPolicyMutable, teamA),
+			)).To(MatchError(ContainSubstring("cannot be changed")))
+		})
+
+		It("allows reordering the requirements of an immutable selector", func() {
+			before := &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "a"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"prod", "staging"}},
+				},
+			}
+			after := &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "a"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"staging", "prod"}},
+				},
+			}
+			Expect(validateSelectorPolicy(
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, before),
+				newCRQ(quotav1alpha1.SelectorPolicyImmutable, after),
+			)).To(Succeed())
+		})
+
+		It("is enforced on updates", func() {
+			oldCRQ := newCRQ(quotav1alpha1.SelectorPolicyImmutable, teamA)
+			oldCRQ.Spec.Hard = quotav1alpha1.ResourceList{"pods": resource.MustParse("10")}
+			crq := oldCRQ.DeepCopy()
+			crq.Spec.NamespaceSelector = teamB
+			oldRaw, _ := json.Marshal(oldCRQ)
+			raw, _ := json.Marshal(crq)
+
+			_, err := webhook.validate(ctx, &admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				Object:    runtime.RawExtension{Raw: raw},
+				OldObject: runtime.RawExtension{Raw: oldRaw},
+			})
+			Expect(err).To(MatchError(ContainSubstring("Immutable spec.selectorPolicy")))
+		})
+	})
+
+	Describe("validateCreationRates", func() {
+		newCRQ := func(rates quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "rates-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Hard:              quotav1alpha1.ResourceList{"pods": resource.MustParse("100")},
+					CreationRates:     rates,
+				},
+			}
+		}
+
+		It("accepts a positive pods rate", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"pods": resource.MustParse("50"),
+			}))).To(Succeed())
+		})
+
+		It("rejects a rate that is not positive", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"pods": resource.MustParse("0"),
+			}))).To(MatchError("spec.creationRates[pods]: rate 0 must be positive"))
+		})
+
+		It("rejects a rate on another resource", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"services": resource.MustParse("10"),
+			}))).To(MatchError(ContainSubstring("only pods creations are rate limited")))
+		})
+	})
+
+	Describe("validateHardLimitsFrom", func() {
+		It("rejects two keys mapped to the same resource", func() {
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "from-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					HardLimitsFrom: &quotav1alpha1.HardLimitsSource{
+						Namespace: "finance",
+						Name:      "limits",
+						Items: []quotav1alpha1.HardLimitItem{
+							{Key: "cpu", Resource: "requests.cpu"},
+							{Key: "cpu-q3", Resource: "requests.cpu"},
+						},
+					},
+				},
+			}
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(
+				`spec.hardLimitsFrom maps both keys "cpu" and "cpu-q3" to requests.cpu`))
+
+			crq.Spec.HardLimitsFrom.Items = crq.Spec.HardLimitsFrom.Items[:1]
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+	})
+
+	Describe("validateExemptUntil", func() {
+		It("rejects an exemption annotation that is not a time", func() {
+			crq := &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "exempt-crq",
+					Annotations: map[string]string{quotav1alpha1.AnnotationEnforcementExemptUntil: "friday"},
+				},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				},
+			}
+			Expect(webhook.validateOperation(ctx, crq)).To(MatchError(ContainSubstring("must be an RFC 3339 time")))
+
+			crq.Annotations[quotav1alpha1.AnnotationEnforcementExemptUntil] = "2026-01-02T06:00:00Z"
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+	})
+
+	Describe("RequireHardLimits", func() {
+		newCRQ := func(hard quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			return &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "empty-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Hard:              hard,
+				},
+			}
+		}
+
+		It("admits quotas that limit nothing by default", func() {
+			Expect(webhook.validateOperation(ctx, newCRQ(nil))).To(Succeed())
+		})
+
+		It("denies quotas that limit nothing once required", func() {
+			webhook.RequireHardLimits()
+			Expect(webhook.validateOperation(ctx, newCRQ(nil))).To(MatchError(ContainSubstring("limits nothing")))
+
+			off := newCRQ(quotav1alpha1.ResourceList{"requests.cpu": resource.MustParse("4")})
+			off.Spec.EnforcementPolicy = map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+				"requests.cpu": quotav1alpha1.EnforcementOff,
+			}
+			Expect(webhook.validateOperation(ctx, off)).To(MatchError(ContainSubstring("limits nothing")))
+
+			Expect(webhook.validateOperation(ctx, newCRQ(quotav1alpha1.ResourceList{
+				"requests.cpu": resource.MustParse("4"),
+			}))).To(Succeed())
+		})
+
+		It("admits quotas whose limits come from hardLimitsFrom once required", func() {
+			webhook.RequireHardLimits()
+			crq := newCRQ(nil)
+			crq.Spec.HardLimitsFrom = &quotav1alpha1.HardLimitsSource{Namespace: "finance", Name: "limits"}
+			Expect(webhook.validateOperation(ctx, crq)).To(Succeed())
+		})
+	})
+
 	Describe("validateUpdate", func() {
 		It("should validate cluster resource quota update", func() {
 			crq := &quotav1alpha1.ClusterResourceQuota{
@@ -108,8 +665,8 @@ var _ = Describe("ClusterResourceQuotaWebhook", func() {
 				},
 				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
 					Hard: quotav1alpha1.ResourceList{
-						"cpu":    resource.MustParse("4"),
-						"memory": resource.MustParse("8Gi"),
+						"requests.cpu":    resource.MustParse("4"),
+						"requests.memory": resource.MustParse("8Gi"),
 					},
 					NamespaceSelector: &metav1.LabelSelector{
 						MatchLabels: map[string]string{
@@ -306,7 +863,7 @@ var _ = Describe("ClusterResourceQuotaWebhook", func() {
 // Helper function to create CRQ JSON
 func createCRQJSON(name, cpu, memory string) []byte {
 	jsonTemplate := `{"apiVersion":"quota.powerapp.cloud/v1alpha1","kind":"ClusterResourceQuota",` +
-		`"metadata":{"name":"%s"},"spec":{"hard":{"cpu":"%s","memory":"%s"},` +
+		`"metadata":{"name":"%s"},"spec":{"hard":{"requests.cpu":"%s","requests.memory":"%s"},` +
 		`"namespaceSelector":{"matchLabels":{"environment":"production"}}}}`
 	return []byte(fmt.Sprintf(jsonTemplate, name, cpu, memory))
 }
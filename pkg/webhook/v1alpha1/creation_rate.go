@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// creationLimiter admits the creations spec.creationRates limits from a token
+// bucket per ClusterResourceQuota and resource. A bucket holds a minute's
+// worth of creations and refills continuously, so bursts up to the rate pass
+// and sustained creation is held to it. Buckets live in the webhook replica,
+// so each replica admits the full rate.
+type creationLimiter struct {
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[creationBucketKey]*creationBucket
+}
+
+type creationBucketKey struct {
+	crq      string
+	resource corev1.ResourceName
+}
+
+type creationBucket struct {
+	// perMinute is the rate the bucket was made for; a changed rate starts a
+	// new, full bucket.
+	perMinute float64
+	tokens    float64
+	refilled  time.Time
+}
+
+func newCreationLimiter() *creationLimiter {
+	return &creationLimiter{
+		now:     time.Now,
+		buckets: make(map[creationBucketKey]*creationBucket),
+	}
+}
+
+// take takes one creation of resourceName from the bucket of the quota named
+// crqName, which allows rate creations a minute. It reports false, taking
+// nothing, when the bucket is empty.
+func (l *creationLimiter) take(crqName string, resourceName corev1.ResourceName, rate resource.Quantity) bool {
+	perMinute := float64(rate.MilliValue()) / 1000
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	key := creationBucketKey{crq: crqName, resource: resourceName}
+	bucket, ok := l.buckets[key]
+	if !ok || bucket.perMinute != perMinute {
+		bucket = &creationBucket{perMinute: perMinute, tokens: math.Max(perMinute, 1), refilled: now}
+		l.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.refilled); elapsed > 0 {
+		bucket.tokens = math.Min(math.Max(perMinute, 1), bucket.tokens+elapsed.Minutes()*perMinute)
+		bucket.refilled = now
+	}
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
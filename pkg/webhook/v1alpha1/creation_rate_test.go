@@ -0,0 +1,127 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("creationLimiter", func() {
+	var (
+		now     time.Time
+		limiter *creationLimiter
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		limiter = newCreationLimiter()
+		limiter.now = func() time.Time { return now }
+	})
+
+	takeAll := func(crq string, rate string) int {
+		taken := 0
+		for limiter.take(crq, usage.ResourcePods, quantity(rate)) {
+			taken++
+		}
+		return taken
+	}
+
+	It("admits a burst of a minute's worth, then the rate", func() {
+		Expect(takeAll("a", "60")).To(Equal(60))
+
+		now = now.Add(time.Second)
+		Expect(takeAll("a", "60")).To(Equal(1))
+
+		now = now.Add(time.Hour)
+		Expect(takeAll("a", "60")).To(Equal(60), "the bucket holds no more than a minute's worth")
+	})
+
+	It("keeps a bucket per quota", func() {
+		Expect(takeAll("a", "5")).To(Equal(5))
+		Expect(takeAll("b", "5")).To(Equal(5))
+	})
+
+	It("starts a new bucket when the rate changes", func() {
+		Expect(takeAll("a", "5")).To(Equal(5))
+		Expect(takeAll("a", "10")).To(Equal(10))
+	})
+
+	It("admits one creation at a time under rates below one a minute", func() {
+		Expect(takeAll("a", "500m")).To(Equal(1))
+		now = now.Add(time.Minute)
+		Expect(takeAll("a", "500m")).To(BeZero())
+		now = now.Add(time.Minute)
+		Expect(takeAll("a", "500m")).To(Equal(1))
+	})
+})
+
+var _ = Describe("PodWebhook creation rate", func() {
+	labels := map[string]string{"team": "alpha"}
+	ctx := context.Background()
+
+	var h *PodWebhook
+
+	BeforeEach(func() {
+		crq := makeCRQ("rate-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("0")},
+		)
+		crq.Spec.CreationRates = quotav1alpha1.ResourceList{usage.ResourcePods: quantity("2")}
+		h = NewPodWebhook(newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq), zap.NewNop())
+	})
+
+	create := func(ctx context.Context, name, cpu string) error {
+		_, err := h.validateOperation(ctx, makePod(name, cpu, "", "", ""), nil, admissionv1.Create)
+		return err
+	}
+
+	It("denies creations beyond the rate with 429", func() {
+		throttled := func() float64 {
+			return testutil.ToFloat64(metrics.WebhookCreationsThrottled.WithLabelValues("rate-crq", "pods"))
+		}
+		before := throttled()
+		Expect(create(ctx, "web-1", "100m")).To(Succeed())
+		Expect(create(ctx, "web-2", "100m")).To(Succeed())
+
+		err := create(ctx, "web-3", "100m")
+		Expect(err).To(MatchError(ContainSubstring("at most 2 pods per minute")))
+		se, ok := err.(*statusError)
+		Expect(ok).To(BeTrue())
+		Expect(se.code).To(Equal(http.StatusTooManyRequests))
+		Expect(throttled()).To(Equal(before + 1))
+	})
+
+	It("spends nothing on dry runs or pods denied for their usage", func() {
+		Expect(create(withDryRun(ctx), "web-1", "100m")).To(Succeed())
+		Expect(create(withDryRun(ctx), "web-2", "100m")).To(Succeed())
+		Expect(create(ctx, "huge", "2")).To(MatchError(ContainSubstring("requests.cpu")))
+
+		Expect(create(ctx, "web-3", "100m")).To(Succeed())
+		Expect(create(ctx, "web-4", "100m")).To(Succeed())
+	})
+
+	It("spends the rate on cached decisions too", func() {
+		h.EnableDecisionCache(time.Minute)
+		Expect(create(ctx, "web-1", "100m")).To(Succeed())
+		Expect(create(ctx, "web-2", "100m")).To(Succeed())
+		Expect(create(ctx, "web-3", "100m")).To(MatchError(ContainSubstring("creation rate exceeded")))
+	})
+
+	It("does not limit updates", func() {
+		Expect(create(ctx, "web-1", "100m")).To(Succeed())
+		Expect(create(ctx, "web-2", "100m")).To(Succeed())
+		old := makePod("web-1", "100m", "", "", "")
+		_, err := h.validateOperation(ctx, makePod("web-1", "200m", "", "", ""), old, admissionv1.Update)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
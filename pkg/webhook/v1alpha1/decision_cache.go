@@ -0,0 +1,178 @@
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/storage"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+// maxDecisionCacheEntries bounds the decision cache; bursts of identical
+// pods need a handful of entries, not thousands.
+const maxDecisionCacheEntries = 4096
+
+// decisionCache remembers admission decisions, denials included, for a short
+// TTL. Entries are keyed on the namespace, the ClusterResourceQuota and its
+// resourceVersion, and a hash of everything validation reads from the pod, so
+// a cached decision is the one validation would reach again: any usage change
+// in the quota's namespaces updates its status, which changes the
+// resourceVersion and misses every entry made before it.
+type decisionCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]decisionEntry
+}
+
+type decisionEntry struct {
+	warnings []string
+	err      error
+	// denials are the quota limits a denied decision exceeded, reported again
+	// each time it is reused.
+	denials []quotaExceededError
+	expires time.Time
+}
+
+func newDecisionCache(ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]decisionEntry),
+	}
+}
+
+// get returns the decision cached under key, if it has not expired.
+func (c *decisionCache) get(key string) (decisionEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		metrics.WebhookDecisionCache.WithLabelValues("miss").Inc()
+		return decisionEntry{}, false
+	}
+	metrics.WebhookDecisionCache.WithLabelValues("hit").Inc()
+	return entry, true
+}
+
+// put caches a decision under key. When the cache is full, expired entries are
+// dropped first, and everything if that does not make room.
+func (c *decisionCache) put(key string, warnings []string, err error, denials []quotaExceededError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxDecisionCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxDecisionCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = decisionEntry{warnings: warnings, err: err, denials: denials, expires: now.Add(c.ttl)}
+}
+
+// podShape is what pod validation reads from a pod: the containers' names and
+// resources, the claims of its generic ephemeral volumes, the kind of its
+// controller owner, which selects the quota's owner policy, where it may run
+// and its priority class. Pods of one ReplicaSet share it although their
+// names, labels and other volumes differ.
+type podShape struct {
+	Containers          []containerShape             `json:"containers"`
+	InitContainers      []containerShape             `json:"initContainers,omitempty"`
+	EphemeralContainers []string                     `json:"ephemeralContainers,omitempty"`
+	Overhead            corev1.ResourceList          `json:"overhead,omitempty"`
+	PriorityClassName   string                       `json:"priorityClassName,omitempty"`
+	NodeName            string                       `json:"nodeName,omitempty"`
+	NodeSelector        map[string]string            `json:"nodeSelector,omitempty"`
+	Affinity            *corev1.Affinity             `json:"affinity,omitempty"`
+	OS                  *corev1.PodOS                `json:"os,omitempty"`
+	ContainerStatuses   []corev1.ContainerStatus     `json:"containerStatuses,omitempty"`
+	InitStatuses        []corev1.ContainerStatus     `json:"initContainerStatuses,omitempty"`
+	EphemeralStatuses   []corev1.ContainerStatus     `json:"ephemeralContainerStatuses,omitempty"`
+	Resources           *corev1.ResourceRequirements `json:"resources,omitempty"`
+	EphemeralVolumes    []ephemeralVolumeShape       `json:"ephemeralVolumes,omitempty"`
+	Owner               *schema.GroupKind            `json:"owner,omitempty"`
+}
+
+// ephemeralVolumeShape is what validateEphemeralVolumes charges for the claim
+// of a generic ephemeral volume.
+type ephemeralVolumeShape struct {
+	StorageClass          string            `json:"storageClass,omitempty"`
+	VolumeAttributesClass string            `json:"volumeAttributesClass,omitempty"`
+	Storage               resource.Quantity `json:"storage"`
+}
+
+type containerShape struct {
+	Name          string                         `json:"name"`
+	Resources     corev1.ResourceRequirements    `json:"resources"`
+	RestartPolicy *corev1.ContainerRestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+func containerShapes(containers []corev1.Container) []containerShape {
+	shapes := make([]containerShape, len(containers))
+	for i, c := range containers {
+		shapes[i] = containerShape{Name: c.Name, Resources: c.Resources, RestartPolicy: c.RestartPolicy}
+	}
+	return shapes
+}
+
+// podDecisionKey keys the decision for creating podObj under crq. It is empty,
+// which disables caching, when the pod cannot be hashed, binds host ports or
+// crq limits pod density: those are checked against the live pods of the
+// quota, not its status.
+func podDecisionKey(crq *quotav1alpha1.ClusterResourceQuota, podObj *corev1.Pod) string {
+	if len(pod.HostPorts(podObj)) > 0 {
+		return ""
+	}
+	if _, ok := crq.Spec.Hard[usage.ResourcePodDensity]; ok {
+		return ""
+	}
+	shape := podShape{
+		Containers:        containerShapes(podObj.Spec.Containers),
+		InitContainers:    containerShapes(podObj.Spec.InitContainers),
+		Overhead:          podObj.Spec.Overhead,
+		PriorityClassName: podObj.Spec.PriorityClassName,
+		NodeName:          podObj.Spec.NodeName,
+		NodeSelector:      podObj.Spec.NodeSelector,
+		Affinity:          podObj.Spec.Affinity,
+		OS:                podObj.Spec.OS,
+		ContainerStatuses: podObj.Status.ContainerStatuses,
+		InitStatuses:      podObj.Status.InitContainerStatuses,
+		EphemeralStatuses: podObj.Status.EphemeralContainerStatuses,
+		Resources:         podObj.Spec.Resources,
+	}
+	for _, c := range podObj.Spec.EphemeralContainers {
+		shape.EphemeralContainers = append(shape.EphemeralContainers, c.Name)
+	}
+	if owner := metav1.GetControllerOfNoCopy(podObj); owner != nil {
+		gk := schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind).GroupKind()
+		shape.Owner = &gk
+	}
+	for _, pvc := range storage.EphemeralPVCs(podObj) {
+		shape.EphemeralVolumes = append(shape.EphemeralVolumes, ephemeralVolumeShape{
+			StorageClass:          storage.PVCStorageClass(&pvc),
+			VolumeAttributesClass: storage.PVCVolumeAttributesClass(&pvc),
+			Storage:               storage.GetPVCStorageRequest(&pvc),
+		})
+	}
+	raw, err := json.Marshal(shape)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return podObj.Namespace + "/" + crq.Name + "@" + crq.ResourceVersion + "/" + hex.EncodeToString(sum[:])
+}
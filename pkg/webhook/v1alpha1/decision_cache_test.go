@@ -0,0 +1,194 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("decisionCache", func() {
+	var (
+		now   time.Time
+		cache *decisionCache
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		cache = newDecisionCache(2 * time.Second)
+		cache.now = func() time.Time { return now }
+	})
+
+	It("returns decisions, denials included, until they expire", func() {
+		denied := errors.New("quota exceeded")
+		cache.put("k", nil, denied, nil)
+
+		cached, ok := cache.get("k")
+		Expect(ok).To(BeTrue())
+		Expect(cached.err).To(MatchError(denied))
+
+		now = now.Add(2 * time.Second)
+		_, ok = cache.get("k")
+		Expect(ok).To(BeFalse())
+	})
+
+	Describe("podDecisionKey", func() {
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		crq.Name = "pod-crq"
+		crq.ResourceVersion = "7"
+
+		It("matches pods of one ReplicaSet", func() {
+			a := makePod("web-abc12", "100m", "", "", "")
+			b := makePod("web-def34", "100m", "", "", "")
+			b.Labels = map[string]string{"pod-template-hash": "5d4f"}
+			b.Spec.Volumes = []corev1.Volume{{Name: "kube-api-access-x7k2p"}}
+
+			Expect(podDecisionKey(crq, a)).To(Equal(podDecisionKey(crq, b)))
+		})
+
+		It("differs for other requests, namespaces or quota versions", func() {
+			key := podDecisionKey(crq, makePod("p", "100m", "", "", ""))
+
+			Expect(podDecisionKey(crq, makePod("p", "200m", "", "", ""))).NotTo(Equal(key))
+
+			other := makePod("p", "100m", "", "", "")
+			other.Namespace = "other"
+			Expect(podDecisionKey(crq, other)).NotTo(Equal(key))
+
+			updated := crq.DeepCopy()
+			updated.ResourceVersion = "8"
+			Expect(podDecisionKey(updated, makePod("p", "100m", "", "", ""))).NotTo(Equal(key))
+		})
+
+		It("differs for other ephemeral volume claims", func() {
+			withClaim := func(class, size string) *corev1.Pod {
+				p := makePod("p", "100m", "", "", "")
+				p.Spec.Volumes = []corev1.Volume{{
+					Name: "scratch",
+					VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+						VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+							Spec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: &class,
+								Resources: corev1.VolumeResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+								},
+							},
+						},
+					}},
+				}}
+				return p
+			}
+			key := podDecisionKey(crq, withClaim("fast", "1Gi"))
+
+			Expect(podDecisionKey(crq, makePod("p", "100m", "", "", ""))).NotTo(Equal(key))
+			Expect(podDecisionKey(crq, withClaim("fast", "10Gi"))).NotTo(Equal(key))
+			Expect(podDecisionKey(crq, withClaim("slow", "1Gi"))).NotTo(Equal(key))
+			Expect(podDecisionKey(crq, withClaim("fast", "1Gi"))).To(Equal(key))
+		})
+
+		It("differs for pods of another kind of controller owner", func() {
+			controller := true
+			ownedBy := func(apiVersion, kind, name string) *corev1.Pod {
+				p := makePod("p", "100m", "", "", "")
+				p.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: apiVersion, Kind: kind, Name: name, Controller: &controller,
+				}}
+				return p
+			}
+			key := podDecisionKey(crq, ownedBy("apps/v1", "ReplicaSet", "web-5d4f"))
+
+			Expect(podDecisionKey(crq, ownedBy("apps/v1", "ReplicaSet", "api-7c9b"))).To(Equal(key))
+			Expect(podDecisionKey(crq, ownedBy("batch/v1", "Job", "backup"))).NotTo(Equal(key))
+			Expect(podDecisionKey(crq, ownedBy("example.com/v1", "ReplicaSet", "web-5d4f"))).NotTo(Equal(key))
+			Expect(podDecisionKey(crq, makePod("p", "100m", "", "", ""))).NotTo(Equal(key))
+		})
+
+		It("is empty under a quota limiting pod density", func() {
+			dense := crq.DeepCopy()
+			dense.Spec.Hard = quotav1alpha1.ResourceList{usage.ResourcePodDensity: quantity("10")}
+
+			Expect(podDecisionKey(dense, makePod("p", "100m", "", "", ""))).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("PodWebhook decision cache", func() {
+	labels := map[string]string{"team": "alpha"}
+	ctx := context.Background()
+
+	var (
+		crqClient *quota.CRQClient
+		h         *PodWebhook
+	)
+
+	BeforeEach(func() {
+		crq := makeCRQ("pod-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("950m")},
+		)
+		crqClient = newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq)
+		h = NewPodWebhook(crqClient, zap.NewNop())
+		h.EnableDecisionCache(time.Minute)
+	})
+
+	hits := func() float64 { return testutil.ToFloat64(metrics.WebhookDecisionCache.WithLabelValues("hit")) }
+
+	It("reuses a denial for identical pods", func() {
+		before := hits()
+		_, err := h.validateOperation(ctx, makePod("web-1", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).To(MatchError(ContainSubstring("requests.cpu")))
+
+		_, err = h.validateOperation(ctx, makePod("web-2", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).To(MatchError(ContainSubstring("requests.cpu")))
+		Expect(hits()).To(Equal(before + 1))
+	})
+
+	It("neither reads nor fills the cache for dry runs", func() {
+		lookups := func() float64 {
+			return hits() + testutil.ToFloat64(metrics.WebhookDecisionCache.WithLabelValues("miss"))
+		}
+		before := lookups()
+		dryRun := withDryRun(ctx)
+		_, err := h.validateOperation(dryRun, makePod("web-1", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).To(MatchError(ContainSubstring("requests.cpu")))
+		_, err = h.validateOperation(dryRun, makePod("web-2", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).To(HaveOccurred())
+
+		Expect(lookups()).To(Equal(before))
+		Expect(h.decisions.entries).To(BeEmpty())
+	})
+
+	It("keeps no decision of a validation given up on", func() {
+		abandoned, cancel := context.WithCancel(ctx)
+		cancel()
+		_, _ = h.validateOperation(abandoned, makePod("web-1", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(h.decisions.entries).To(BeEmpty())
+	})
+
+	It("validates again once the quota's usage changes", func() {
+		_, err := h.validateOperation(ctx, makePod("web-1", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).To(HaveOccurred())
+
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		Expect(crqClient.Client.Get(ctx, client.ObjectKey{Name: "pod-crq"}, crq)).To(Succeed())
+		crq.Status.Total.Used[usage.ResourceRequestsCPU] = quantity("500m")
+		Expect(crqClient.Client.Update(ctx, crq)).To(Succeed())
+
+		_, err = h.validateOperation(ctx, makePod("web-2", "100m", "", "", ""), nil, admissionv1.Create)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,103 @@
+package v1alpha1
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DenialRecorder reports that a request in namespace was denied for asking
+// requested of resourceName beyond the limit of the ClusterResourceQuota
+// named crqName. *events.EventRecorder implements it.
+type DenialRecorder interface {
+	AdmissionDenied(crqName, namespace string, resourceName corev1.ResourceName,
+		requested resource.Quantity, message string)
+}
+
+type denialRecorderKey struct{}
+
+type quotaDenialsKey struct{}
+
+// quotaDenials collects the quota limits one admission request exceeds.
+type quotaDenials struct {
+	recorder DenialRecorder
+	template *template.Template
+	denials  []quotaExceededError
+}
+
+// RecordDenials returns middleware reporting to recorder, for each quota a
+// denied request exceeded a limit of, the limit it exceeded. Dry runs are not
+// reported.
+func RecordDenials(recorder DenialRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), denialRecorderKey{}, recorder)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// withQuotaDenials returns a ctx collecting the quota limits a request
+// exceeds. The collector is nil, and collects nothing, without RecordDenials.
+func withQuotaDenials(ctx context.Context) (context.Context, *quotaDenials) {
+	recorder, _ := ctx.Value(denialRecorderKey{}).(DenialRecorder)
+	if recorder == nil {
+		return ctx, nil
+	}
+	d := &quotaDenials{recorder: recorder, template: denialTemplate(ctx)}
+	return context.WithValue(ctx, quotaDenialsKey{}, d), d
+}
+
+// noteDenial adds the limit a request exceeds to ctx's collector.
+func noteDenial(ctx context.Context, exceeded *quotaExceededError) {
+	d, _ := ctx.Value(quotaDenialsKey{}).(*quotaDenials)
+	if d == nil {
+		return
+	}
+	denial := *exceeded
+	denial.requested = exceeded.requested.DeepCopy()
+	d.denials = append(d.denials, denial)
+}
+
+// notedDenials returns the denials ctx's collector holds.
+func notedDenials(ctx context.Context) []quotaExceededError {
+	d, _ := ctx.Value(quotaDenialsKey{}).(*quotaDenials)
+	if d == nil {
+		return nil
+	}
+	return slices.Clone(d.denials)
+}
+
+// renoteDenials adds denials, replayed with a cached decision, to ctx's
+// collector.
+func renoteDenials(ctx context.Context, denials []quotaExceededError) {
+	if d, _ := ctx.Value(quotaDenialsKey{}).(*quotaDenials); d != nil {
+		d.denials = append(d.denials, denials...)
+	}
+}
+
+// report passes the collected denials of a request in namespace to the
+// recorder: for each quota, the exceeded limit of the first resource by name.
+func (d *quotaDenials) report(namespace string) {
+	if d == nil {
+		return
+	}
+	denials := slices.Clone(d.denials)
+	slices.SortStableFunc(denials, func(a, b quotaExceededError) int {
+		if c := strings.Compare(a.crq, b.crq); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.resource), string(b.resource))
+	})
+	for i, denial := range denials {
+		if i > 0 && denials[i-1].crq == denial.crq {
+			continue
+		}
+		d.recorder.AdmissionDenied(denial.crq, namespace, denial.resource, denial.requested,
+			renderDenial(d.template, namespace, &denial))
+	}
+}
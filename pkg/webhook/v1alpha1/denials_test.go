@@ -0,0 +1,99 @@
+package v1alpha1
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+type recordedDenial struct {
+	crq, namespace string
+	resource       corev1.ResourceName
+	requested      string
+}
+
+type fakeDenialRecorder struct {
+	denials []recordedDenial
+}
+
+func (f *fakeDenialRecorder) AdmissionDenied(crqName, namespace string, resourceName corev1.ResourceName,
+	requested resource.Quantity, _ string) {
+	f.denials = append(f.denials, recordedDenial{crqName, namespace, resourceName, requested.String()})
+}
+
+var _ = Describe("Admission denial reporting", func() {
+	labels := map[string]string{"team": "alpha"}
+	var (
+		engine   *gin.Engine
+		recorder *fakeDenialRecorder
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+		recorder = &fakeDenialRecorder{}
+		engine.Use(RecordDenials(recorder))
+	})
+
+	serviceHandler := func(used string) *ServiceWebhook {
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity(used)},
+		)
+		return NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+	}
+
+	It("reports the limit a denied request exceeded", func() {
+		engine.POST("/webhook", serviceHandler("5").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(recorder.denials).To(ConsistOf(
+			recordedDenial{"svc-crq", serviceWebhookTestNamespace, usage.ResourceServices, "1"}))
+	})
+
+	It("does not report admitted requests", func() {
+		engine.POST("/webhook", serviceHandler("1").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(recorder.denials).To(BeEmpty())
+	})
+
+	It("does not report dry runs", func() {
+		engine.POST("/webhook", serviceHandler("5").Handle)
+
+		review := newServiceReview("1", makeService(corev1.ServiceTypeClusterIP))
+		dryRun := true
+		review.Request.DryRun = &dryRun
+		resp := sendWebhookRequest(engine, review)
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(recorder.denials).To(BeEmpty())
+	})
+
+	It("reports cached pod denials again", func() {
+		crq := makeCRQ("pod-crq", labels,
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("10")},
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("10")},
+		)
+		h := NewPodWebhook(newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq), zap.NewNop())
+		h.EnableDecisionCache(time.Minute)
+		engine.POST("/webhook", h.Handle)
+
+		for _, uid := range []string{"1", "2"} {
+			resp := sendWebhookRequest(engine, newPodReview(uid, makePod("p"+uid, "1", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+		}
+		Expect(recorder.denials).To(HaveLen(2))
+		Expect(recorder.denials[1]).To(Equal(
+			recordedDenial{"pod-crq", podWebhookTestNamespace, corev1.ResourceRequestsCPU, "1"}))
+	})
+})
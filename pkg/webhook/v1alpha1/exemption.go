@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+type exemptUntilKey struct{}
+
+type enforcementExemptionKey struct{}
+
+// enforcementExemption is the enforcement exemption window of the quota one
+// admission request is checked against.
+type enforcementExemption struct {
+	// clusterWide is the end of the exemption of every quota, zero for none.
+	clusterWide time.Time
+	// crq is the quota the request was resolved to, empty until it is.
+	crq string
+	// until is when the exemption of crq ends.
+	until time.Time
+}
+
+// ExemptEnforcementUntil returns middleware admitting requests that exceed a
+// quota, with a warning instead of a denial, until deadline. It is the
+// cluster-wide counterpart of AnnotationEnforcementExemptUntil, for windows
+// such as a node pool rotation in which workloads must be able to reschedule
+// whatever their quota says.
+func ExemptEnforcementUntil(deadline time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), exemptUntilKey{}, deadline)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// withEnforcementExemption returns a ctx recording the exemption window of
+// the quota a request is resolved to.
+func withEnforcementExemption(ctx context.Context) (context.Context, *enforcementExemption) {
+	clusterWide, _ := ctx.Value(exemptUntilKey{}).(time.Time)
+	e := &enforcementExemption{clusterWide: clusterWide}
+	return context.WithValue(ctx, enforcementExemptionKey{}, e), e
+}
+
+// noteExemption records the exemption window of crq, the quota the request
+// of ctx is checked against.
+func noteExemption(ctx context.Context, crq *quotav1alpha1.ClusterResourceQuota) {
+	if e, _ := ctx.Value(enforcementExemptionKey{}).(*enforcementExemption); e != nil {
+		e.crq = crq.Name
+		e.until = crq.EnforcementExemptUntil(e.clusterWide)
+	}
+}
+
+// warning returns the admission warning of a request admitted despite
+// exceeding the quota with err, and false when the quota is enforced at now.
+func (e *enforcementExemption) warning(err error, now time.Time) (string, bool) {
+	if e == nil || e.crq == "" || !now.Before(e.until) {
+		return "", false
+	}
+	return fmt.Sprintf("ClusterResourceQuota %s is not enforced until %s; admitted although: %s",
+		e.crq, e.until.UTC().Format(time.RFC3339), err.Error()), true
+}
@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+var _ = Describe("Enforcement exemption windows", func() {
+	labels := map[string]string{"team": "alpha"}
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	// serviceHandler serves a quota whose services limit is already used up,
+	// exempted by annotation until annotated unless it is empty.
+	serviceHandler := func(annotated string) *ServiceWebhook {
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+		)
+		if annotated != "" {
+			crq.Annotations = map[string]string{quotav1alpha1.AnnotationEnforcementExemptUntil: annotated}
+		}
+		return NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+	}
+
+	It("admits a request exceeding a quota annotated as exempt, with a warning", func() {
+		until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		engine.POST("/webhook", serviceHandler(until).Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(ConsistOf(And(
+			HavePrefix("ClusterResourceQuota svc-crq is not enforced until "+until),
+			ContainSubstring("services limit exceeded"),
+		)))
+	})
+
+	It("denies again once the annotated window has closed", func() {
+		engine.POST("/webhook", serviceHandler(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)).Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Warnings).To(BeEmpty())
+	})
+
+	It("ignores an annotation that is not a time", func() {
+		engine.POST("/webhook", serviceHandler("until further notice").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+	})
+
+	It("exempts every quota within the cluster-wide window", func() {
+		engine.Use(ExemptEnforcementUntil(time.Now().Add(time.Hour)))
+		engine.POST("/webhook", serviceHandler("").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(HaveLen(1))
+	})
+
+	It("denies once the cluster-wide window has closed", func() {
+		engine.Use(ExemptEnforcementUntil(time.Now().Add(-time.Minute)))
+		engine.POST("/webhook", serviceHandler("").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+	})
+
+	It("still rejects requests it cannot validate", func() {
+		engine.Use(ExemptEnforcementUntil(time.Now().Add(time.Hour)))
+		engine.POST("/webhook", serviceHandler("").Handle)
+
+		review := newServiceReview("1", makeService(corev1.ServiceTypeClusterIP))
+		review.Request.Operation = admissionv1.Connect
+		resp := sendWebhookRequest(engine, review)
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Code).To(BeEquivalentTo(http.StatusBadRequest))
+	})
+})
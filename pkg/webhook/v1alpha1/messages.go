@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type denialTemplateKey struct{}
+
+// DenialMessages returns middleware writing the message of every quota
+// denial, and of the events reporting it, with tmpl, as parsed by
+// config.ParseDenialMessageTemplate.
+func DenialMessages(tmpl *template.Template) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), denialTemplateKey{}, tmpl)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// denialTemplate returns the template DenialMessages set on ctx, or nil.
+func denialTemplate(ctx context.Context) *template.Template {
+	tmpl, _ := ctx.Value(denialTemplateKey{}).(*template.Template)
+	return tmpl
+}
+
+// quotaExceededError denies a request exceeding a limit of a quota. It keeps
+// the figures of the limit for the denial message template.
+type quotaExceededError struct {
+	crq       string
+	resource  corev1.ResourceName
+	requested resource.Quantity
+	used      resource.Quantity
+	hard      resource.Quantity
+	msg       string
+}
+
+func (e *quotaExceededError) Error() string {
+	return e.msg
+}
+
+// renderDenial returns the message denying a request in namespace for err:
+// tmpl executed with err's figures and its own message, or that message as is
+// without a template or when executing it fails.
+func renderDenial(tmpl *template.Template, namespace string, err error) string {
+	message := err.Error()
+	if tmpl == nil {
+		return message
+	}
+	data := map[string]string{
+		"crq": "", "namespace": namespace, "resource": "", "used": "", "hard": "", "requested": "",
+		"message": message,
+	}
+	var exceeded *quotaExceededError
+	if errors.As(err, &exceeded) {
+		data["crq"] = exceeded.crq
+		data["resource"] = string(exceeded.resource)
+		data["used"] = exceeded.used.String()
+		data["hard"] = exceeded.hard.String()
+		data["requested"] = exceeded.requested.String()
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return message
+	}
+	return b.String()
+}
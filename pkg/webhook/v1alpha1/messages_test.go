@@ -0,0 +1,96 @@
+package v1alpha1
+
+import (
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/config"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+type messageRecorder struct {
+	messages []string
+}
+
+func (m *messageRecorder) AdmissionDenied(_, _ string, _ corev1.ResourceName, _ resource.Quantity, message string) {
+	m.messages = append(m.messages, message)
+}
+
+var _ = Describe("Denial message templates", func() {
+	labels := map[string]string{"team": "alpha"}
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+		tmpl, err := config.ParseDenialMessageTemplate(
+			"{{.crq}} is out of {{.resource}} in {{.namespace}} ({{.used}}/{{.hard}}, asked {{.requested}}). " +
+				"See https://runbooks.example.com/quota")
+		Expect(err).NotTo(HaveOccurred())
+		engine.Use(DenialMessages(tmpl))
+	})
+
+	It("writes a denial with the template", func() {
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+		)
+		h := NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(Equal(
+			"svc-crq is out of services in svc-ns (5/5, asked 1). See https://runbooks.example.com/quota"))
+	})
+
+	It("writes each violation of a request exceeding several limits", func() {
+		crq := makeCRQ("pod-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2"), usage.ResourcePods: quantity("1")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2"), usage.ResourcePods: quantity("1")},
+		)
+		h := NewPodWebhook(newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newPodReview("1", makePod("p1", "1", "", "", "")))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(HavePrefix("2 quota violations: "))
+		Expect(resp.Response.Result.Details.Causes).To(HaveLen(2))
+		Expect(resp.Response.Result.Details.Causes[0].Message).To(HavePrefix("pod-crq is out of requests.cpu"))
+		Expect(resp.Response.Result.Details.Causes[1].Message).To(HavePrefix("pod-crq is out of pods"))
+	})
+
+	It("writes the AdmissionDenied events with the template", func() {
+		recorder := &messageRecorder{}
+		engine.Use(RecordDenials(recorder))
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+		)
+		h := NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(recorder.messages).To(ConsistOf(
+			"svc-crq is out of services in svc-ns (5/5, asked 1). See https://runbooks.example.com/quota"))
+	})
+
+	It("leaves the figures empty for denials not tied to one limit", func() {
+		crq := makeCRQ("pod-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("0")},
+		)
+		crq.Spec.MissingRequests = &quotav1alpha1.MissingRequestsPolicy{Action: quotav1alpha1.MissingRequestsDeny}
+		h := NewPodWebhook(newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newPodReview("1", makePod("p1", "", "", "", "")))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(HavePrefix(" is out of  in pod-ns (/, asked )."))
+	})
+})
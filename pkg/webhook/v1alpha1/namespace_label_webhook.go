@@ -0,0 +1,175 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NamespaceLabelSource configures where NamespaceLabelWebhook reads label values from.
+type NamespaceLabelSource struct {
+	// Keys are the label keys filled in on new namespaces.
+	Keys []string
+	// AnnotationPrefix names the annotation <prefix><key> that supplies label <key>.
+	// Empty disables annotation lookup.
+	AnnotationPrefix string
+	// ConfigMap is the "namespace/name" of a ConfigMap whose data maps namespace
+	// names to comma-separated key=value lists. Empty disables the lookup.
+	ConfigMap string
+}
+
+// NamespaceLabelWebhook mutates new namespaces so they carry the labels CRQ
+// selectors rely on. A label already set on the namespace is never changed;
+// otherwise the annotation wins over the ConfigMap. It never denies a namespace.
+type NamespaceLabelWebhook struct {
+	client kubernetes.Interface
+	source NamespaceLabelSource
+	logger *zap.Logger
+}
+
+// NewNamespaceLabelWebhook creates a new NamespaceLabelWebhook
+func NewNamespaceLabelWebhook(
+	k8sClient kubernetes.Interface,
+	source NamespaceLabelSource,
+	logger *zap.Logger,
+) *NamespaceLabelWebhook {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &NamespaceLabelWebhook{
+		client: k8sClient,
+		source: source,
+		logger: logger.Named("namespace-label-webhook"),
+	}
+}
+
+// Handle handles the mutating webhook request for Namespace
+func (h *NamespaceLabelWebhook) Handle(c *gin.Context) {
+	runMutatingWebhook(c, h.logger, webhookConfig{
+		name:             "namespace-labels",
+		expectedGVK:      &metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"},
+		requireNamespace: false,
+	}, h.mutate)
+}
+
+func (h *NamespaceLabelWebhook) mutate(ctx context.Context, req *admissionv1.AdmissionRequest) ([]byte, error) {
+	if req.Operation != admissionv1.Create {
+		return nil, nil
+	}
+
+	var ns corev1.Namespace
+	if err := decodeAdmissionObject(req.Object.Raw, &ns, "Namespace"); err != nil {
+		return nil, err
+	}
+
+	add := h.labelsFor(ctx, &ns)
+	if len(add) == 0 {
+		return nil, nil
+	}
+	h.logger.Info("Applying labels to new namespace",
+		zap.String("namespace", ns.Name),
+		zap.Any("labels", add))
+	return labelPatch(ns.Labels, add)
+}
+
+// labelsFor returns the configured labels missing from ns and the value each
+// source supplies for them.
+func (h *NamespaceLabelWebhook) labelsFor(ctx context.Context, ns *corev1.Namespace) map[string]string {
+	var lookup map[string]string
+	add := make(map[string]string)
+	for _, key := range h.source.Keys {
+		if _, set := ns.Labels[key]; set {
+			continue
+		}
+		value, ok := "", false
+		if h.source.AnnotationPrefix != "" {
+			value, ok = ns.Annotations[h.source.AnnotationPrefix+key]
+		}
+		if !ok && h.source.ConfigMap != "" {
+			if lookup == nil {
+				lookup = h.configMapLabels(ctx, ns.Name)
+			}
+			value, ok = lookup[key]
+		}
+		if !ok {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			h.logger.Warn("Skipping invalid label value for namespace",
+				zap.String("namespace", ns.Name),
+				zap.String("key", key),
+				zap.String("value", value),
+				zap.Strings("errors", errs))
+			continue
+		}
+		add[key] = value
+	}
+	return add
+}
+
+// configMapLabels reads the lookup ConfigMap entry for nsName. Lookup failures
+// are logged and treated as no entry: labeling is best effort and must not
+// block namespace creation.
+func (h *NamespaceLabelWebhook) configMapLabels(ctx context.Context, nsName string) map[string]string {
+	cmNamespace, cmName, ok := strings.Cut(h.source.ConfigMap, "/")
+	if !ok || h.client == nil {
+		h.logger.Error("Namespace label ConfigMap must be namespace/name",
+			zap.String("configmap", h.source.ConfigMap))
+		return map[string]string{}
+	}
+	cm, err := h.client.CoreV1().ConfigMaps(cmNamespace).Get(ctx, cmName, metav1.GetOptions{})
+	if err != nil {
+		h.logger.Warn("Failed to read namespace label ConfigMap",
+			zap.String("configmap", h.source.ConfigMap),
+			zap.Error(err))
+		return map[string]string{}
+	}
+	entry, ok := cm.Data[nsName]
+	if !ok {
+		return map[string]string{}
+	}
+	parsed, err := labels.ConvertSelectorToLabelsMap(entry)
+	if err != nil {
+		h.logger.Warn("Malformed namespace label ConfigMap entry",
+			zap.String("configmap", h.source.ConfigMap),
+			zap.String("namespace", nsName),
+			zap.Error(err))
+		return map[string]string{}
+	}
+	return parsed
+}
+
+type jsonPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// labelPatch builds the JSONPatch adding labels to an object whose current
+// labels are existing. Without any labels the map has to be created as a
+// whole: an empty map is omitted from the object JSON, so there is no
+// /metadata/labels to add keys under.
+func labelPatch(existing, add map[string]string) ([]byte, error) {
+	if len(existing) == 0 {
+		return json.Marshal([]jsonPatchOp{{Op: "add", Path: "/metadata/labels", Value: add}})
+	}
+	ops := make([]jsonPatchOp, 0, len(add))
+	for key, value := range add {
+		ops = append(ops, jsonPatchOp{Op: "add", Path: "/metadata/labels/" + escapeJSONPointer(key), Value: value})
+	}
+	return json.Marshal(ops)
+}
+
+// escapeJSONPointer escapes a JSON Pointer reference token (RFC 6901).
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,166 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func newNamespaceReview(uid string, op admissionv1.Operation, ns *corev1.Namespace) *admissionv1.AdmissionReview {
+	raw, _ := json.Marshal(ns)
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: "admission.k8s.io/v1",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID(uid),
+			Name:      ns.Name,
+			Operation: op,
+			Kind:      metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"},
+			Resource:  metav1.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+// patchOps decodes an admission patch into path -> value.
+func patchOps(resp *admissionv1.AdmissionResponse) map[string]any {
+	if len(resp.Patch) == 0 {
+		return nil
+	}
+	Expect(resp.PatchType).NotTo(BeNil())
+	Expect(*resp.PatchType).To(Equal(admissionv1.PatchTypeJSONPatch))
+	var ops []jsonPatchOp
+	Expect(json.Unmarshal(resp.Patch, &ops)).To(Succeed())
+	out := make(map[string]any, len(ops))
+	for _, op := range ops {
+		Expect(op.Op).To(Equal("add"))
+		out[op.Path] = op.Value
+	}
+	return out
+}
+
+var _ = Describe("NamespaceLabelWebhook", func() {
+	const prefix = "pac-quota-controller.powerapp.cloud/"
+
+	var (
+		engine *gin.Engine
+		lookup *corev1.ConfigMap
+		source NamespaceLabelSource
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+		lookup = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "namespace-labels", Namespace: "pac-quota-controller-system"},
+			Data: map[string]string{
+				"payments-prod": "team=payments,env=prod",
+				"broken":        "team",
+			},
+		}
+		source = NamespaceLabelSource{
+			Keys:             []string{"team", "env"},
+			AnnotationPrefix: prefix,
+			ConfigMap:        "pac-quota-controller-system/namespace-labels",
+		}
+	})
+
+	handle := func(ns *corev1.Namespace, op admissionv1.Operation) *admissionv1.AdmissionResponse {
+		h := NewNamespaceLabelWebhook(fake.NewSimpleClientset(lookup), source, zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+		resp := sendWebhookRequest(engine, newNamespaceReview("1", op, ns))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		return resp.Response
+	}
+
+	It("creates the label map from annotations when the namespace has none", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Annotations: map[string]string{prefix + "team": "web", prefix + "env": "staging"},
+		}}
+
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(Equal(map[string]any{
+			"/metadata/labels": map[string]any{"team": "web", "env": "staging"},
+		}))
+	})
+
+	It("falls back to the lookup ConfigMap and never overwrites existing labels", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "payments-prod",
+			Labels: map[string]string{"team": "finance"},
+		}}
+
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(Equal(map[string]any{
+			"/metadata/labels/env": "prod",
+		}))
+	})
+
+	It("prefers the annotation over the ConfigMap", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "payments-prod",
+			Labels:      map[string]string{},
+			Annotations: map[string]string{prefix + "team": "payments-core"},
+		}}
+
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(Equal(map[string]any{
+			"/metadata/labels": map[string]any{"team": "payments-core", "env": "prod"},
+		}))
+	})
+
+	It("escapes label keys in the patch path", func() {
+		source.Keys = []string{"example.com/team"}
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Labels:      map[string]string{"existing": "x"},
+			Annotations: map[string]string{prefix + "example.com/team": "web"},
+		}}
+
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(Equal(map[string]any{
+			"/metadata/labels/example.com~1team": "web",
+		}))
+	})
+
+	It("admits unchanged when no source has a value", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}}
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(BeNil())
+	})
+
+	It("admits unchanged when the ConfigMap entry is malformed", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "broken"}}
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(BeNil())
+	})
+
+	It("admits unchanged when the ConfigMap is missing", func() {
+		source.ConfigMap = "pac-quota-controller-system/missing"
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments-prod"}}
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(BeNil())
+	})
+
+	It("skips annotation values that are not valid label values", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Annotations: map[string]string{prefix + "team": "not a label"},
+		}}
+		Expect(patchOps(handle(ns, admissionv1.Create))).To(BeNil())
+	})
+
+	It("only mutates on create", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Annotations: map[string]string{prefix + "team": "web"},
+		}}
+		Expect(patchOps(handle(ns, admissionv1.Update))).To(BeNil())
+	})
+})
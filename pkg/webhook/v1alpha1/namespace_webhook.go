@@ -2,6 +2,10 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -19,6 +23,8 @@ type NamespaceWebhook struct {
 	client    kubernetes.Interface
 	crqClient *quota.CRQClient
 	logger    *zap.Logger
+	// deletionProtection validates DELETE; off unless EnableDeletionProtection is called.
+	deletionProtection bool
 }
 
 // NewNamespaceWebhook creates a new NamespaceWebhook
@@ -38,6 +44,14 @@ func NewNamespaceWebhook(
 	}
 }
 
+// EnableDeletionProtection denies the deletion of namespaces that still show
+// usage under their ClusterResourceQuota, unless they carry
+// namespaceutil.ConfirmDeleteAnnotation. Without it DELETE requests are
+// rejected as unsupported, since the webhook is not registered for them.
+func (h *NamespaceWebhook) EnableDeletionProtection() {
+	h.deletionProtection = true
+}
+
 // Handle handles the webhook request for Namespace
 func (h *NamespaceWebhook) Handle(c *gin.Context) {
 	runWebhook(c, h.logger, webhookConfig{
@@ -53,6 +67,11 @@ func (h *NamespaceWebhook) Handle(c *gin.Context) {
 func (h *NamespaceWebhook) validate(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, error) {
 	switch req.Operation {
 	case admissionv1.Create, admissionv1.Update:
+	case admissionv1.Delete:
+		if !h.deletionProtection {
+			return nil, unsupportedOperationError(req.Operation, "Namespace")
+		}
+		return nil, h.validateDelete(ctx, req)
 	default:
 		return nil, unsupportedOperationError(req.Operation, "Namespace")
 	}
@@ -62,10 +81,104 @@ func (h *NamespaceWebhook) validate(ctx context.Context, req *admissionv1.Admiss
 		return nil, err
 	}
 
+	reserved, err := namespaceutil.Reservation(&ns)
+	if err != nil {
+		return nil, err
+	}
+
 	h.logger.Debug("Validating namespace for CRQ conflicts",
 		zap.String("namespace", ns.Name),
 		zap.String("operation", string(req.Operation)))
-	return nil, h.validateOperation(ctx, &ns)
+	if err := h.validateOperation(ctx, &ns); err != nil {
+		return nil, err
+	}
+	if req.Operation == admissionv1.Create && len(reserved) > 0 {
+		return nil, h.validateReservation(ctx, &ns, reserved)
+	}
+	return nil, nil
+}
+
+// validateDelete denies deleting a namespace whose ClusterResourceQuota
+// status still shows it using something beyond its unused reservation, unless
+// the deletion is confirmed by annotation. DELETE requests carry the
+// namespace in OldObject.
+func (h *NamespaceWebhook) validateDelete(ctx context.Context, req *admissionv1.AdmissionRequest) error {
+	if len(req.OldObject.Raw) == 0 {
+		return newStatusErrorf(http.StatusBadRequest, "Namespace DELETE request has no oldObject")
+	}
+	var ns corev1.Namespace
+	if err := decodeAdmissionObject(req.OldObject.Raw, &ns, "Namespace"); err != nil {
+		return err
+	}
+	if namespaceutil.DeletionConfirmed(&ns) || h.crqClient == nil {
+		return nil
+	}
+	crq, err := h.crqClient.GetCRQByNamespace(ctx, &ns)
+	if err != nil || crq == nil {
+		return err
+	}
+
+	var inUse []string
+	for _, status := range crq.Status.Namespaces {
+		if status.Namespace != ns.Name {
+			continue
+		}
+		for resourceName, used := range status.Status.Used {
+			// An unused reservation counts as usage but has no workloads behind it.
+			if reserved, ok := status.Status.Reserved[resourceName]; ok {
+				used = used.DeepCopy()
+				used.Sub(reserved)
+			}
+			if used.Sign() > 0 {
+				inUse = append(inUse, fmt.Sprintf("%s=%s", resourceName, used.String()))
+			}
+		}
+		break
+	}
+	if len(inUse) == 0 {
+		return nil
+	}
+	sort.Strings(inUse)
+	h.logger.Info("Denying deletion of namespace with quota usage",
+		zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+		zap.String("namespace", ns.Name),
+		zap.String("crq_name", crq.Name),
+		zap.Strings("used", inUse))
+	return fmt.Errorf("namespace '%s' still uses %s under ClusterResourceQuota '%s'; "+
+		"annotate it %s=true to confirm the deletion",
+		ns.Name, strings.Join(inUse, ", "), crq.Name, namespaceutil.ConfirmDeleteAnnotation)
+}
+
+// validateReservation denies creating a namespace whose reservation does not
+// fit in what its ClusterResourceQuota has left. Resources are checked in
+// sorted order so the reported violation is stable. Later changes to the
+// annotation are not checked; they count from the next reconcile on.
+func (h *NamespaceWebhook) validateReservation(
+	ctx context.Context,
+	ns *corev1.Namespace,
+	reserved corev1.ResourceList,
+) error {
+	if h.crqClient == nil {
+		return nil
+	}
+	crq, err := h.crqClient.GetCRQByNamespace(ctx, ns)
+	if err != nil || crq == nil || crq.Paused() {
+		return err
+	}
+
+	names := make([]string, 0, len(reserved))
+	for resourceName := range reserved {
+		names = append(names, string(resourceName))
+	}
+	sort.Strings(names)
+	var violations quotaViolations
+	for _, name := range names {
+		resourceName := corev1.ResourceName(name)
+		if err := validateCRQStatusUsage(ctx, crq, resourceName, reserved[resourceName], h.logger); err != nil {
+			violations.add(fmt.Errorf("namespace reservation of %s validation failed: %w", name, err))
+		}
+	}
+	return violations.err()
 }
 
 // validateOperation checks if the namespace would conflict with existing CRQs
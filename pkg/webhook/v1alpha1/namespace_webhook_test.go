@@ -21,6 +21,7 @@ import (
 	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	namespaceutil "github.com/powerhome/pac-quota-controller/pkg/kubernetes/namespace"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	pkglogger "github.com/powerhome/pac-quota-controller/pkg/logger"
 
@@ -414,6 +415,121 @@ var _ = Describe("NamespaceWebhook", func() {
 			})
 		})
 	})
+
+	Describe("reservations", func() {
+		createRequest := func(reserve string) *admissionv1.AdmissionRequest {
+			raw, err := json.Marshal(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "reserving-ns",
+				Labels:      map[string]string{"team": "reserve"},
+				Annotations: map[string]string{namespaceutil.ReserveAnnotation: reserve},
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			return &admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}
+		}
+
+		BeforeEach(func() {
+			Expect(fakeRuntimeClient.Create(ctx, &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "reserve-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "reserve"}},
+					Hard:              quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("8")},
+				},
+				Status: quotav1alpha1.ClusterResourceQuotaStatus{Total: quotav1alpha1.ResourceQuotaStatus{
+					Used: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("5")},
+				}},
+			})).To(Succeed())
+		})
+
+		It("admits a reservation that fits the quota headroom", func() {
+			_, err := webhook.validate(ctx, createRequest("requests.cpu=3"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("denies a reservation larger than the quota headroom", func() {
+			_, err := webhook.validate(ctx, createRequest("requests.cpu=4"))
+			Expect(err).To(MatchError(ContainSubstring("namespace reservation of requests.cpu validation failed")))
+		})
+
+		It("denies a malformed reservation", func() {
+			_, err := webhook.validate(ctx, createRequest("requests.cpu"))
+			Expect(err).To(MatchError(ContainSubstring(namespaceutil.ReserveAnnotation)))
+		})
+
+		It("does not check the headroom of a later change", func() {
+			req := createRequest("requests.cpu=40")
+			req.Operation = admissionv1.Update
+			_, err := webhook.validate(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("deletion protection", func() {
+		deleteRequest := func(name string, annotations map[string]string) *admissionv1.AdmissionRequest {
+			raw, err := json.Marshal(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{"team": "tenant"},
+				Annotations: annotations,
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			return &admissionv1.AdmissionRequest{
+				Operation: admissionv1.Delete,
+				OldObject: runtime.RawExtension{Raw: raw},
+			}
+		}
+
+		BeforeEach(func() {
+			webhook.EnableDeletionProtection()
+			Expect(fakeRuntimeClient.Create(ctx, &quotav1alpha1.ClusterResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant-crq"},
+				Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "tenant"}},
+					Hard:              quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("8")},
+				},
+				Status: quotav1alpha1.ClusterResourceQuotaStatus{Namespaces: []quotav1alpha1.ResourceQuotaStatusByNamespace{
+					{Namespace: "busy-ns", Status: quotav1alpha1.ResourceQuotaStatus{Used: quotav1alpha1.ResourceList{
+						corev1.ResourceRequestsCPU: resource.MustParse("2"),
+						corev1.ResourcePods:        resource.MustParse("0"),
+					}}},
+					{Namespace: "reserved-ns", Status: quotav1alpha1.ResourceQuotaStatus{
+						Used:     quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+						Reserved: quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+					}},
+				}},
+			})).To(Succeed())
+		})
+
+		It("denies deleting a namespace that still uses its quota", func() {
+			_, err := webhook.validate(ctx, deleteRequest("busy-ns", nil))
+			Expect(err).To(MatchError(ContainSubstring("still uses requests.cpu=2 under ClusterResourceQuota 'tenant-crq'")))
+			Expect(err.Error()).NotTo(ContainSubstring("pods"))
+		})
+
+		It("admits a deletion confirmed by annotation", func() {
+			_, err := webhook.validate(ctx, deleteRequest("busy-ns",
+				map[string]string{namespaceutil.ConfirmDeleteAnnotation: "true"}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("admits deleting a namespace that only holds an unused reservation", func() {
+			_, err := webhook.validate(ctx, deleteRequest("reserved-ns", nil))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("admits deleting a namespace no quota selects", func() {
+			req := deleteRequest("other-ns", nil)
+			req.OldObject.Raw = createNamespaceJSON("other-ns", nil)
+			_, err := webhook.validate(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects deletions as unsupported when protection is off", func() {
+			_, err := NewNamespaceWebhook(fakeClient, crqClient, logger).validate(ctx, deleteRequest("busy-ns", nil))
+			Expect(err).To(MatchError(ContainSubstring("Operation DELETE is not supported")))
+		})
+	})
 })
 
 // Helper function to create namespace JSON
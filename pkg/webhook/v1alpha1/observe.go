@@ -0,0 +1,50 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type observeKey struct{}
+
+// ObserveOnly returns middleware admitting every request its handlers would
+// deny, with a warning instead, for the observe install profile: enforcement
+// can be measured on an existing cluster before it is turned on.
+func ObserveOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), observeKey{}, true)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// observing reports whether the request of ctx is served by ObserveOnly.
+func observing(ctx context.Context) bool {
+	observe, _ := ctx.Value(observeKey{}).(bool)
+	return observe
+}
+
+// observedDenial returns the metric reason and admission warning of a request
+// admitted under the observe profile although err denies it.
+func observedDenial(err error) (reason, warning string) {
+	reason = "quota_exceeded"
+	var incomplete *incompleteUsageError
+	var se *statusError
+	switch {
+	case errors.As(err, &incomplete):
+		reason = "incomplete_usage"
+	case errors.As(err, &se) && se.code == http.StatusBadRequest:
+		reason = "bad_request"
+	case errors.As(err, &se) && se.code == http.StatusTooManyRequests:
+		reason = "rate_limited"
+	default:
+		if _, transient := transientError(err); transient {
+			reason = "transient_error"
+		}
+	}
+	return reason, fmt.Sprintf("quota enforcement is in observe mode; admitted although: %s", err.Error())
+}
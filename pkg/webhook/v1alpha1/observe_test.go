@@ -0,0 +1,56 @@
+package v1alpha1
+
+import (
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
+)
+
+var _ = Describe("Observe profile", func() {
+	labels := map[string]string{"team": "alpha"}
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+		engine.Use(ObserveOnly())
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServicesLoadBalancerCost: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServicesLoadBalancerCost: quantity("5")},
+		)
+		h := NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+	})
+
+	It("admits a request exceeding a quota, with a warning, and counts it", func() {
+		observed := metrics.WebhookObservedDenials.WithLabelValues("service", "quota_exceeded")
+		before := testutil.ToFloat64(observed)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeLoadBalancer)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(ConsistOf(And(
+			HavePrefix("quota enforcement is in observe mode; admitted although: "),
+			ContainSubstring("services.loadbalancers/cost-units limit exceeded"),
+		)))
+		Expect(testutil.ToFloat64(observed)).To(Equal(before + 1))
+	})
+
+	It("admits a request it would reject as malformed", func() {
+		observed := metrics.WebhookObservedDenials.WithLabelValues("service", "bad_request")
+		before := testutil.ToFloat64(observed)
+
+		svc := makeService(corev1.ServiceTypeLoadBalancer)
+		svc.Annotations = map[string]string{"quota.powerapp.cloud/lb-cost-units": "free"}
+		resp := sendWebhookRequest(engine, newServiceReview("2", svc))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(HaveLen(1))
+		Expect(testutil.ToFloat64(observed)).To(Equal(before + 1))
+	})
+})
@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+)
+
+type excludedOwnersKey struct{}
+
+// ExcludeOwners returns middleware admitting, without validating them, the
+// objects whose controller owner is in owners, as --excluded-owners
+// configures. The controller does not count them either, and they are left
+// out of the pods the webhooks list to validate other objects.
+func ExcludeOwners(owners objects.Owners) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), excludedOwnersKey{}, owners)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// excludedOwners returns the owners ExcludeOwners set on ctx, or nil.
+func excludedOwners(ctx context.Context) objects.Owners {
+	owners, _ := ctx.Value(excludedOwnersKey{}).(objects.Owners)
+	return owners
+}
+
+// ownedByExcludedOwner reports whether the object of req, or the object it
+// deletes, has a controller owner excluded on ctx. Cluster-scoped objects,
+// such as Namespaces, are never excluded: they are not counted but select
+// what is. An object that cannot be decoded is left to its webhook to reject.
+func ownedByExcludedOwner(ctx context.Context, req *admissionv1.AdmissionRequest) bool {
+	owners := excludedOwners(ctx)
+	if len(owners) == 0 {
+		return false
+	}
+	raw := req.Object.Raw
+	if len(raw) == 0 {
+		raw = req.OldObject.Raw
+	}
+	obj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(raw, obj); err != nil || obj.Namespace == "" {
+		return false
+	}
+	return owners.Owns(obj)
+}
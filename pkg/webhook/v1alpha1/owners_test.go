@@ -0,0 +1,71 @@
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/testing/fixtures"
+)
+
+var _ = Describe("Excluded owners", func() {
+	var engine *gin.Engine
+
+	ownedPod := func(name, apiVersion, kind string) *corev1.Pod {
+		controller := true
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: fixtures.NamespaceName,
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: apiVersion, Kind: kind, Name: "owner", UID: "uid", Controller: &controller},
+				},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "busybox"}}},
+		}
+	}
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+		engine.Use(ExcludeOwners(objects.Owners{{Group: "monitoring.coreos.com"}}))
+		cluster := fixtures.NewClusterWithCRQ(
+			quotav1alpha1.ResourceList{usage.ResourcePods: quantity("0")},
+			map[string]string{"team": "alpha"},
+		)
+		engine.POST("/webhook", NewPodWebhook(cluster.CRQClient, nil).Handle)
+	})
+
+	It("admits objects of an excluded owner without validating them", func() {
+		pod := ownedPod("agent", "monitoring.coreos.com/v1", "Prometheus")
+		resp := fixtures.Post(engine, "/webhook", fixtures.Review(admissionv1.Create, pod, nil))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("validates objects of other owners", func() {
+		pod := ownedPod("web", "apps/v1", "ReplicaSet")
+		resp := fixtures.Post(engine, "/webhook", fixtures.Review(admissionv1.Create, pod, nil))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("pods"))
+	})
+
+	It("validates cluster-scoped objects whatever their owner", func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}}
+		controller := true
+		ns.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: "monitoring.coreos.com/v1", Kind: "Prometheus", Name: "owner", UID: "uid", Controller: &controller},
+		}
+		review := fixtures.Review(admissionv1.Create, ns, nil)
+		review.Request.Namespace = ns.Name
+		ctx := context.WithValue(context.Background(), excludedOwnersKey{}, objects.Owners{{Group: "monitoring.coreos.com"}})
+		Expect(ownedByExcludedOwner(ctx, review.Request)).To(BeFalse())
+	})
+})
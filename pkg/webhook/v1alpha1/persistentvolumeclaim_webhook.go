@@ -3,6 +3,8 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -11,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/storage"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
@@ -20,6 +23,8 @@ import (
 type PersistentVolumeClaimWebhook struct {
 	crqClient *quota.CRQClient
 	logger    *zap.Logger
+	// deletionProtection validates DELETE; off unless EnableDeletionProtection is called.
+	deletionProtection bool
 }
 
 // NewPersistentVolumeClaimWebhook creates a new PersistentVolumeClaimWebhook
@@ -37,6 +42,14 @@ func NewPersistentVolumeClaimWebhook(
 	}
 }
 
+// EnableDeletionProtection denies the deletion of PVCs annotated with
+// storage.RetainAnnotation and of every PVC in namespaces whose quota sets
+// spec.storageAuditLock. Without it DELETE requests are rejected as
+// unsupported, since the webhook is not registered for them.
+func (h *PersistentVolumeClaimWebhook) EnableDeletionProtection() {
+	h.deletionProtection = true
+}
+
 // Handle handles the webhook request for PersistentVolumeClaim
 func (h *PersistentVolumeClaimWebhook) Handle(c *gin.Context) {
 	runWebhook(c, h.logger, webhookConfig{
@@ -55,6 +68,11 @@ func (h *PersistentVolumeClaimWebhook) validate(
 ) ([]string, error) {
 	switch req.Operation {
 	case admissionv1.Create, admissionv1.Update:
+	case admissionv1.Delete:
+		if !h.deletionProtection {
+			return nil, unsupportedOperationError(req.Operation, "PersistentVolumeClaim")
+		}
+		return nil, h.validateDelete(ctx, req)
 	default:
 		return nil, unsupportedOperationError(req.Operation, "PersistentVolumeClaim")
 	}
@@ -76,12 +94,60 @@ func (h *PersistentVolumeClaimWebhook) validate(
 	return nil, h.validateOperation(ctx, &pvc, oldPVC, req.Operation)
 }
 
+// validateDelete denies deleting a PVC that is retained by annotation or held
+// by its quota's storage audit lock. DELETE requests carry the claim in
+// OldObject.
+func (h *PersistentVolumeClaimWebhook) validateDelete(ctx context.Context, req *admissionv1.AdmissionRequest) error {
+	if len(req.OldObject.Raw) == 0 {
+		return newStatusErrorf(http.StatusBadRequest, "PersistentVolumeClaim DELETE request has no oldObject")
+	}
+	var pvc corev1.PersistentVolumeClaim
+	if err := decodeAdmissionObject(req.OldObject.Raw, &pvc, "PersistentVolumeClaim"); err != nil {
+		return err
+	}
+	if storage.PVCRetained(&pvc) {
+		h.logger.Info("Denying deletion of retained PVC",
+			zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+			zap.String("pvc", pvc.Name),
+			zap.String("namespace", req.Namespace))
+		return fmt.Errorf(
+			"PersistentVolumeClaim '%s' is retained by the %s annotation; remove it before deleting the claim",
+			pvc.Name, storage.RetainAnnotation)
+	}
+
+	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, req.Namespace)
+	if crq == nil || !crq.Spec.StorageAuditLock {
+		return nil
+	}
+	h.logger.Info("Denying deletion of PVC under a storage audit lock",
+		zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+		zap.String("pvc", pvc.Name),
+		zap.String("namespace", req.Namespace),
+		zap.String("crq_name", crq.Name))
+	return fmt.Errorf("ClusterResourceQuota '%s' holds the storage of namespace '%s' under an audit lock: "+
+		"PersistentVolumeClaim '%s' cannot be deleted", crq.Name, req.Namespace, pvc.Name)
+}
+
+// validateOperation charges the PVC against the matching CRQ. On Update the
+// unscoped requests.storage key is charged only the resize delta. When the
+// storage class changes (e.g. the legacy class annotation is backfilled or
+// rewritten), the whole claim moves between class-scoped quotas: the old class
+// releases its usage, so only the new class is validated, for the full request
+// and one PVC count. The claims of generic ephemeral volumes were charged with
+// their pod, so creating them is not charged again.
 func (h *PersistentVolumeClaimWebhook) validateOperation(
 	ctx context.Context,
 	pvc *corev1.PersistentVolumeClaim,
 	oldPVC *corev1.PersistentVolumeClaim,
 	op admissionv1.Operation,
 ) error {
+	if oldPVC == nil && storage.IsEphemeralPVC(pvc) {
+		h.logger.Debug("Skipping CRQ validation for ephemeral volume PVC charged with its pod",
+			zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+			zap.String("pvc", pvc.Name),
+			zap.String("namespace", pvc.Namespace))
+		return nil
+	}
 	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, pvc.Namespace)
 	if crq == nil {
 		return nil
@@ -93,6 +159,37 @@ func (h *PersistentVolumeClaimWebhook) validateOperation(
 		storageDelta.Sub(storage.GetPVCStorageRequest(oldPVC))
 	}
 
+	storageClass := storage.PVCStorageClass(pvc)
+	classChanged := false
+	if oldPVC != nil {
+		oldClass := storage.PVCStorageClass(oldPVC)
+		classChanged = oldClass != storageClass
+		if classChanged {
+			h.logger.Debug("PVC storage class changed; moving usage between class-scoped quotas",
+				zap.String("correlation_id", correlationID),
+				zap.String("pvc", pvc.Name),
+				zap.String("old_storage_class", oldClass),
+				zap.String("new_storage_class", storageClass))
+		}
+	}
+	classStorage := storageDelta
+	if classChanged {
+		classStorage = storage.GetPVCStorageRequest(pvc)
+	}
+	// Modifying a claim's volume attributes class, as to raise its IOPS, moves
+	// it between attributes-class quotas like a storage class change.
+	attributesClass := storage.PVCVolumeAttributesClass(pvc)
+	attributesClassChanged := oldPVC != nil && storage.PVCVolumeAttributesClass(oldPVC) != attributesClass
+	attributesClassStorage := storageDelta
+	if attributesClassChanged {
+		h.logger.Debug("PVC volume attributes class changed; moving usage between class-scoped quotas",
+			zap.String("correlation_id", correlationID),
+			zap.String("pvc", pvc.Name),
+			zap.String("old_volume_attributes_class", storage.PVCVolumeAttributesClass(oldPVC)),
+			zap.String("new_volume_attributes_class", attributesClass))
+		attributesClassStorage = storage.GetPVCStorageRequest(pvc)
+	}
+
 	type check struct {
 		resource corev1.ResourceName
 		quantity resource.Quantity
@@ -101,43 +198,183 @@ func (h *PersistentVolumeClaimWebhook) validateOperation(
 	checks := []check{
 		{usage.ResourceRequestsStorage, storageDelta, "ClusterResourceQuota storage validation failed: %w"},
 	}
-	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
-		storageClass := *pvc.Spec.StorageClassName
+	if storageClass != "" {
 		checks = append(checks, check{
-			corev1.ResourceName(fmt.Sprintf("%s.storageclass.storage.k8s.io/requests.storage", storageClass)),
-			storageDelta,
+			usage.StorageClassFor(storageClass, usage.ResourceRequestsStorage),
+			classStorage,
 			fmt.Sprintf("ClusterResourceQuota storage class '%s' storage validation failed: %%w", storageClass),
 		})
 	}
-	// Count checks only apply on Create; Update never adds or removes a PVC.
+	// The unscoped count only applies on Create; Update never adds or removes a PVC.
 	if oldPVC == nil {
 		checks = append(checks, check{
 			usage.ResourcePersistentVolumeClaims, oneQuantity,
 			"ClusterResourceQuota PVC count validation failed: %w",
 		})
-		if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
-			storageClass := *pvc.Spec.StorageClassName
+	}
+	// The class-scoped count applies on Create and whenever the claim moves into a new class.
+	if storageClass != "" && (oldPVC == nil || classChanged) {
+		checks = append(checks, check{
+			usage.StorageClassFor(storageClass, usage.ResourcePersistentVolumeClaims),
+			oneQuantity,
+			fmt.Sprintf("ClusterResourceQuota storage class '%s' PVC count validation failed: %%w", storageClass),
+		})
+	}
+	if attributesClass != "" {
+		checks = append(checks, check{
+			usage.VolumeAttributesClassFor(attributesClass, usage.ResourceRequestsStorage),
+			attributesClassStorage,
+			fmt.Sprintf("ClusterResourceQuota volume attributes class '%s' storage validation failed: %%w",
+				attributesClass),
+		})
+		if oldPVC == nil || attributesClassChanged {
 			checks = append(checks, check{
-				corev1.ResourceName(fmt.Sprintf("%s.storageclass.storage.k8s.io/persistentvolumeclaims", storageClass)),
+				usage.VolumeAttributesClassFor(attributesClass, usage.ResourcePersistentVolumeClaims),
 				oneQuantity,
-				fmt.Sprintf("ClusterResourceQuota storage class '%s' PVC count validation failed: %%w", storageClass),
+				fmt.Sprintf("ClusterResourceQuota volume attributes class '%s' PVC count validation failed: %%w",
+					attributesClass),
 			})
 		}
 	}
 
+	var violations quotaViolations
 	for _, c := range checks {
 		// Skip zero-or-negative deltas: API rejects PVC shrink in practice, but
 		// tests can inject one and we don't want to charge negative quota.
 		if c.quantity.Sign() <= 0 {
 			continue
 		}
-		if err := validateCRQStatusUsage(crq, c.resource, c.quantity, h.logger, correlationID); err != nil {
-			return fmt.Errorf(c.errFmt, err)
+		if err := validateNamespaceUsage(ctx, crq, pvc.Namespace, c.resource, c.quantity, h.logger); err != nil {
+			violations.add(fmt.Errorf(c.errFmt, err))
 		}
 	}
+	if err := violations.err(); err != nil {
+		return err
+	}
 
 	logValidationPassed(h.logger, "PVC", pvc.Namespace, op,
 		zap.String("pvc", pvc.Name),
 		zap.String("storage_delta", storageDelta.String()))
 	return nil
 }
+
+// pvcCharge is what PVCs about to be created by a controller, rather than
+// admitted one by one, add to the storage and PVC count usage, in total and
+// per storage class.
+type pvcCharge struct {
+	count        int64
+	storage      resource.Quantity
+	classCount   map[string]int64
+	classStorage map[string]resource.Quantity
+	// attributesClassCount and attributesClassStorage are per volume
+	// attributes class.
+	attributesClassCount   map[string]int64
+	attributesClassStorage map[string]resource.Quantity
+}
+
+func newPVCCharge() pvcCharge {
+	return pvcCharge{
+		classCount:             make(map[string]int64),
+		classStorage:           make(map[string]resource.Quantity),
+		attributesClassCount:   make(map[string]int64),
+		attributesClassStorage: make(map[string]resource.Quantity),
+	}
+}
+
+// add charges one more pvc.
+func (c *pvcCharge) add(pvc *corev1.PersistentVolumeClaim) {
+	request := storage.GetPVCStorageRequest(pvc)
+	c.count++
+	c.storage.Add(request)
+	if class := storage.PVCStorageClass(pvc); class != "" {
+		c.classCount[class]++
+		classStorage := c.classStorage[class]
+		classStorage.Add(request)
+		c.classStorage[class] = classStorage
+	}
+	if class := storage.PVCVolumeAttributesClass(pvc); class != "" {
+		c.attributesClassCount[class]++
+		classStorage := c.attributesClassStorage[class]
+		classStorage.Add(request)
+		c.attributesClassStorage[class] = classStorage
+	}
+}
+
+// validatePVCCharge checks added against the unscoped and the class-scoped
+// storage and PVC count quotas of crq. source names what declares the PVCs in
+// the denial messages.
+func validatePVCCharge(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespace string,
+	source string,
+	added pvcCharge,
+	logger *zap.Logger,
+) error {
+	type check struct {
+		resource corev1.ResourceName
+		quantity resource.Quantity
+		errFmt   string
+	}
+	checks := []check{
+		{
+			usage.ResourceRequestsStorage, added.storage,
+			"ClusterResourceQuota " + source + " storage validation failed: %w",
+		},
+		{
+			usage.ResourcePersistentVolumeClaims, *resource.NewQuantity(added.count, resource.DecimalSI),
+			"ClusterResourceQuota " + source + " PVC count validation failed: %w",
+		},
+	}
+	classes := make([]string, 0, len(added.classCount))
+	for class := range added.classCount {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	for _, class := range classes {
+		checks = append(checks,
+			check{
+				usage.StorageClassFor(class, usage.ResourceRequestsStorage),
+				added.classStorage[class],
+				fmt.Sprintf("ClusterResourceQuota %s storage class '%s' storage validation failed: %%w", source, class),
+			},
+			check{
+				usage.StorageClassFor(class, usage.ResourcePersistentVolumeClaims),
+				*resource.NewQuantity(added.classCount[class], resource.DecimalSI),
+				fmt.Sprintf("ClusterResourceQuota %s storage class '%s' PVC count validation failed: %%w", source, class),
+			},
+		)
+	}
+	attributesClasses := make([]string, 0, len(added.attributesClassCount))
+	for class := range added.attributesClassCount {
+		attributesClasses = append(attributesClasses, class)
+	}
+	slices.Sort(attributesClasses)
+	for _, class := range attributesClasses {
+		checks = append(checks,
+			check{
+				usage.VolumeAttributesClassFor(class, usage.ResourceRequestsStorage),
+				added.attributesClassStorage[class],
+				fmt.Sprintf("ClusterResourceQuota %s volume attributes class '%s' storage validation failed: %%w",
+					source, class),
+			},
+			check{
+				usage.VolumeAttributesClassFor(class, usage.ResourcePersistentVolumeClaims),
+				*resource.NewQuantity(added.attributesClassCount[class], resource.DecimalSI),
+				fmt.Sprintf("ClusterResourceQuota %s volume attributes class '%s' PVC count validation failed: %%w",
+					source, class),
+			},
+		)
+	}
+
+	var violations quotaViolations
+	for _, c := range checks {
+		if c.quantity.Sign() <= 0 {
+			continue
+		}
+		if err := validateNamespaceUsage(ctx, crq, namespace, c.resource, c.quantity, logger); err != nil {
+			violations.add(fmt.Errorf(c.errFmt, err))
+		}
+	}
+	return violations.err()
+}
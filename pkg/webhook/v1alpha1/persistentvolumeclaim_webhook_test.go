@@ -158,6 +158,24 @@ var _ = Describe("PersistentVolumeClaimWebhook", func() {
 			Expect(resp.Response.Result.Message).To(ContainSubstring("persistentvolumeclaims limit exceeded"))
 		})
 
+		It("does not charge again the claim of a generic ephemeral volume charged with its pod", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("2")},
+			)
+			h := NewPersistentVolumeClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			controller := true
+			pvc := makePVC("web-scratch", "1Gi", "")
+			pvc.OwnerReferences = []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "Pod", Name: "web", UID: "uid", Controller: &controller},
+			}
+			resp := sendWebhookRequest(engine, newPVCReview("3e", pvc))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
 		It("validates storage-class-prefixed keys when StorageClassName is set", func() {
 			ns := makeNamespace(nsName, labels)
 			scStorageKey := corev1.ResourceName("fast.storageclass.storage.k8s.io/requests.storage")
@@ -212,6 +230,62 @@ var _ = Describe("PersistentVolumeClaimWebhook", func() {
 			Expect(resp.Response.Result.Message).To(ContainSubstring("Operation DELETE is not supported"))
 		})
 
+		Context("with deletion protection", func() {
+			newDeleteReview := func(uid string, pvc *corev1.PersistentVolumeClaim) *admissionv1.AdmissionReview {
+				review := newPVCReview(uid, pvc)
+				review.Request.Operation = admissionv1.Delete
+				review.Request.OldObject = review.Request.Object
+				review.Request.Object = runtime.RawExtension{}
+				return review
+			}
+			newHandler := func(storageAuditLock bool) *PersistentVolumeClaimWebhook {
+				ns := makeNamespace(nsName, labels)
+				crq := makeCRQ(crqName, labels,
+					quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("10Gi")},
+					quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("5Gi")},
+				)
+				crq.Spec.StorageAuditLock = storageAuditLock
+				h := NewPersistentVolumeClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+				h.EnableDeletionProtection()
+				return h
+			}
+
+			It("admits deleting an unretained PVC", func() {
+				engine.POST("/webhook", newHandler(false).Handle)
+
+				resp := sendWebhookRequest(engine, newDeleteReview("d1", makePVC("p1", "1Gi", "")))
+				Expect(resp.Response.Allowed).To(BeTrue())
+			})
+
+			It("denies deleting a PVC annotated for retention", func() {
+				engine.POST("/webhook", newHandler(false).Handle)
+				pvc := makePVC("p1", "1Gi", "")
+				pvc.Annotations = map[string]string{storage.RetainAnnotation: "true"}
+
+				resp := sendWebhookRequest(engine, newDeleteReview("d2", pvc))
+				Expect(resp.Response.Allowed).To(BeFalse())
+				Expect(resp.Response.Result.Message).To(ContainSubstring("retained by the quota.powerapp.cloud/retain annotation"))
+			})
+
+			It("denies deleting any PVC under a storage audit lock", func() {
+				engine.POST("/webhook", newHandler(true).Handle)
+
+				resp := sendWebhookRequest(engine, newDeleteReview("d3", makePVC("p1", "1Gi", "")))
+				Expect(resp.Response.Allowed).To(BeFalse())
+				Expect(resp.Response.Result.Message).To(ContainSubstring("audit lock"))
+			})
+
+			It("rejects a DELETE without the old object", func() {
+				engine.POST("/webhook", newHandler(false).Handle)
+				review := newDeleteReview("d4", makePVC("p1", "1Gi", ""))
+				review.Request.OldObject = runtime.RawExtension{}
+
+				resp := sendWebhookRequest(engine, review)
+				Expect(resp.Response.Allowed).To(BeFalse())
+				Expect(resp.Response.Result.Code).To(Equal(int32(400)))
+			})
+		})
+
 		It("admits an Update when storage grows within quota (resize)", func() {
 			ns := makeNamespace(nsName, labels)
 			crq := makeCRQ(crqName, labels,
@@ -284,5 +358,100 @@ var _ = Describe("PersistentVolumeClaimWebhook", func() {
 			resp := sendWebhookRequest(engine, review)
 			Expect(resp.Response.Allowed).To(BeTrue())
 		})
+
+		It("charges the full claim to the new class when the storage class annotation changes", func() {
+			fastStorage := corev1.ResourceName("fast.storageclass.storage.k8s.io/requests.storage")
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage:        quantity("100Gi"),
+					usage.ResourcePersistentVolumeClaims: quantity("1"),
+					fastStorage:                          quantity("8Gi"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage:        quantity("5Gi"),
+					usage.ResourcePersistentVolumeClaims: quantity("1"),
+					fastStorage:                          quantity("4Gi"),
+				},
+			)
+			h := NewPersistentVolumeClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			oldPVC := makePVC("p1", "5Gi", "")
+			oldPVC.Annotations = map[string]string{"volume.beta.kubernetes.io/storage-class": "slow"}
+			newPVC := makePVC("p1", "5Gi", "")
+			newPVC.Annotations = map[string]string{"volume.beta.kubernetes.io/storage-class": "fast"}
+			review := newPVCReview("11", newPVC)
+			oldRaw, _ := json.Marshal(oldPVC)
+			review.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+			review.Request.Operation = admissionv1.Update
+
+			resp := sendWebhookRequest(engine, review)
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("storage class 'fast' storage validation failed"))
+		})
+
+		It("admits a storage class change without charging the unscoped PVC count", func() {
+			fastCount := corev1.ResourceName("fast.storageclass.storage.k8s.io/persistentvolumeclaims")
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourcePersistentVolumeClaims: quantity("1"),
+					fastCount:                            quantity("1"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourcePersistentVolumeClaims: quantity("1"),
+					fastCount:                            quantity("0"),
+				},
+			)
+			h := NewPersistentVolumeClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			oldPVC := makePVC("p1", "5Gi", "")
+			newPVC := makePVC("p1", "5Gi", "")
+			newPVC.Annotations = map[string]string{"volume.beta.kubernetes.io/storage-class": "fast"}
+			review := newPVCReview("12", newPVC)
+			oldRaw, _ := json.Marshal(oldPVC)
+			review.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+			review.Request.Operation = admissionv1.Update
+
+			resp := sendWebhookRequest(engine, review)
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("denies modifying a claim into a full volume attributes class", func() {
+			iopsStorage := usage.VolumeAttributesClassFor("high-iops", usage.ResourceRequestsStorage)
+			iopsCount := usage.VolumeAttributesClassFor("high-iops", usage.ResourcePersistentVolumeClaims)
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage: quantity("100Gi"),
+					iopsStorage:                   quantity("100Gi"),
+					iopsCount:                     quantity("1"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage: quantity("5Gi"),
+					iopsStorage:                   quantity("10Gi"),
+					iopsCount:                     quantity("1"),
+				},
+			)
+			h := NewPersistentVolumeClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			oldPVC := makePVC("p1", "5Gi", "")
+			newPVC := makePVC("p1", "5Gi", "")
+			highIOPS := "high-iops"
+			newPVC.Spec.VolumeAttributesClassName = &highIOPS
+			review := newPVCReview("13", newPVC)
+			oldRaw, _ := json.Marshal(oldPVC)
+			review.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+			review.Request.Operation = admissionv1.Update
+
+			resp := sendWebhookRequest(engine, review)
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).
+				To(ContainSubstring("volume attributes class 'high-iops' PVC count validation failed"))
+			Expect(resp.Response.Result.Message).NotTo(ContainSubstring("'high-iops' storage validation failed"))
+		})
 	})
 })
@@ -3,22 +3,42 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/objects"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/podmetrics"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/storage"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
 // PodWebhook handles webhook requests for Pod resources
 type PodWebhook struct {
 	crqClient *quota.CRQClient
 	logger    *zap.Logger
+	// decisions caches CREATE decisions; nil unless EnableDecisionCache is called.
+	decisions *decisionCache
+	// configName names the QuotaControllerConfig whose spec.ownerPolicies
+	// pods are charged by; empty unless EnableOwnerPolicies is called.
+	configName string
+	// creations holds the allowances of spec.creationRates.
+	creations *creationLimiter
 }
 
 // NewPodWebhook creates a new PodWebhook
@@ -33,9 +53,43 @@ func NewPodWebhook(
 	return &PodWebhook{
 		crqClient: crqClient,
 		logger:    logger,
+		creations: newCreationLimiter(),
 	}
 }
 
+// EnableDecisionCache reuses the decision on a pod for identical pods created
+// under the same quota usage within ttl, so bursts of ReplicaSet pods are
+// validated once. A zero ttl leaves the cache off.
+func (h *PodWebhook) EnableDecisionCache(ttl time.Duration) {
+	if ttl > 0 {
+		h.decisions = newDecisionCache(ttl)
+	}
+}
+
+// EnableOwnerPolicies charges pods by the spec.ownerPolicies of the named
+// QuotaControllerConfig, as the controller counts them. An empty name leaves
+// them off.
+func (h *PodWebhook) EnableOwnerPolicies(configName string) {
+	h.configName = configName
+}
+
+// ownerPolicies returns the owner policies in effect. A config that is
+// missing or cannot be read charges pods as they ask.
+func (h *PodWebhook) ownerPolicies(ctx context.Context) []quotav1alpha1.OwnerPodPolicy {
+	if h.configName == "" || h.crqClient == nil {
+		return nil
+	}
+	cfg := &quotav1alpha1.QuotaControllerConfig{}
+	if err := h.crqClient.Client.Get(ctx, types.NamespacedName{Name: h.configName}, cfg); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			h.logger.Warn("Failed to read QuotaControllerConfig - charging pods without owner policies",
+				zap.String("name", h.configName), zap.Error(err))
+		}
+		return nil
+	}
+	return cfg.Spec.OwnerPolicies
+}
+
 // Handle handles the webhook request for Pod.
 //
 // DRA: when resource.k8s.io stabilizes, enforce resourceClaim quota via a
@@ -74,9 +128,9 @@ func (h *PodWebhook) validate(ctx context.Context, req *admissionv1.AdmissionReq
 }
 
 // validateOperation is shared between create and update validation.
-// For UPDATE (pods/resize) the current namespace usage already includes the
-// pre-resize pod, so we charge only the positive delta (new - old) per compute
-// resource and skip the +1 pod-count charge.
+// For UPDATE (pods/resize, pods/ephemeralcontainers) the current namespace
+// usage already includes the pod as it was, so we charge only the positive
+// delta (new - old) per compute resource and skip the +1 pod-count charge.
 func (h *PodWebhook) validateOperation(
 	ctx context.Context,
 	podObj *corev1.Pod,
@@ -87,45 +141,585 @@ func (h *PodWebhook) validateOperation(
 		h.logger.Info("Skipping CRQ validation for nil pod on " + string(op))
 		return nil, nil
 	}
+	if err := pod.ValidateExtendedResources(podObj); err != nil {
+		return nil, err
+	}
+	if err := pod.ValidateHugePages(podObj); err != nil {
+		return nil, newStatusErrorf(http.StatusBadRequest, "Pod %s is invalid: %v", podObj.Name, err)
+	}
+
+	// Pods that no longer count toward quota (terminal, stuck terminating) are
+	// never charged, matching how the controller aggregates usage.
+	if !pod.CountsTowardQuota(podObj, time.Now()) {
+		h.logger.Debug("Skipping CRQ validation for pod that does not count toward quota",
+			zap.String("pod", podObj.Name),
+			zap.String("namespace", podObj.Namespace))
+		return nil, nil
+	}
 
 	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, podObj.Namespace)
 	if crq == nil {
 		return nil, nil
 	}
+	// A quota reserved for a node pool only charges the pods confined to it.
+	if !pod.TargetsNodeSelectorTerms(podObj, crq.Spec.NodeSelectorTerms) {
+		h.logger.Debug("Skipping CRQ validation for pod outside the quota's node pool",
+			zap.String("pod", podObj.Name),
+			zap.String("namespace", podObj.Namespace),
+			zap.String("crq", crq.Name))
+		return nil, nil
+	}
+	if oldPod != nil && !pod.TargetsNodeSelectorTerms(oldPod, crq.Spec.NodeSelectorTerms) {
+		// A scheduling-gated pod can gain the pool's node affinity; charge it in full.
+		oldPod = nil
+	}
+
+	warnings, err := h.decide(ctx, crq, podObj, oldPod, op)
+	// The creation rate is taken last, so pods denied for their usage do not
+	// spend it, and outside the decision cache, whose hits spend it too.
+	if err == nil && op == admissionv1.Create {
+		err = h.validateCreationRate(ctx, crq, podObj)
+	}
+	return warnings, err
+}
 
+// decide returns the decision of validateAgainstQuota on podObj, reusing a
+// cached one for identical pods when the decision cache is on.
+func (h *PodWebhook) decide(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	oldPod *corev1.Pod,
+	op admissionv1.Operation,
+) ([]string, error) {
+	// Dry runs neither read nor fill the cache, so they leave its hit rate alone.
+	if h.decisions == nil || op != admissionv1.Create || isDryRun(ctx) {
+		return h.validateAgainstQuota(ctx, crq, podObj, oldPod, op)
+	}
+	key := podDecisionKey(crq, podObj)
+	if key == "" {
+		return h.validateAgainstQuota(ctx, crq, podObj, oldPod, op)
+	}
+	if cached, ok := h.decisions.get(key); ok {
+		h.logger.Debug("Reusing cached admission decision",
+			zap.String("pod", podObj.Name),
+			zap.String("namespace", podObj.Namespace),
+			zap.Bool("allowed", cached.err == nil))
+		renoteDenials(ctx, cached.denials)
+		return cached.warnings, cached.err
+	}
+	warnings, err := h.validateAgainstQuota(ctx, crq, podObj, oldPod, op)
+	// A validation given up on by its timeout budget may have decided on
+	// lookups cut short; its decision is not kept for other pods.
+	if ctx.Err() == nil {
+		h.decisions.put(key, warnings, err, notedDenials(ctx))
+	}
+	return warnings, err
+}
+
+// validateCreationRate takes the creation of podObj from the allowance of
+// crq's spec.creationRates, denying it with 429 Too Many Requests once the
+// allowance is spent. Dry runs are checked without spending it.
+func (h *PodWebhook) validateCreationRate(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+) error {
+	rate, ok := crq.Spec.CreationRates[usage.ResourcePods]
+	if !ok || isDryRun(ctx) {
+		return nil
+	}
+	if h.creations.take(crq.Name, usage.ResourcePods, rate) {
+		return nil
+	}
+	metrics.WebhookCreationsThrottled.WithLabelValues(crq.Name, string(usage.ResourcePods)).Inc()
+	return newStatusErrorf(http.StatusTooManyRequests,
+		"ClusterResourceQuota %s creation rate exceeded: namespace %s cannot create pod %s, "+
+			"the quota's namespaces may create at most %s pods per minute",
+		crq.Name, podObj.Namespace, podObj.Name, rate.String())
+}
+
+// validateAgainstQuota charges podObj, less oldPod on UPDATE, against crq.
+// It returns its near-quota warnings so cached decisions repeat them.
+func (h *PodWebhook) validateAgainstQuota(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	oldPod *corev1.Pod,
+	op admissionv1.Operation,
+) ([]string, error) {
 	correlationID := quota.GetCorrelationID(ctx)
+	ctx, nearQuota := withNearQuotaWarnings(ctx, podObj.Namespace)
 
-	computeResources := []struct {
-		resource corev1.ResourceName
-		label    string
-	}{
-		{usage.ResourceRequestsCPU, "CPU requests"},
-		{usage.ResourceRequestsMemory, "memory requests"},
-		{usage.ResourceLimitsCPU, "CPU limits"},
-		{usage.ResourceLimitsMemory, "memory limits"},
-		{usage.ResourceRequestsEphemeralStorage, "ephemeral-storage requests"},
-		{usage.ResourceLimitsEphemeralStorage, "ephemeral-storage limits"},
+	// Pods of operators with unusual shapes are charged by their owner's policy.
+	policies := h.ownerPolicies(ctx)
+	podObj, oldPod = projection.ApplyOwnerPolicy(policies, podObj), projection.ApplyOwnerPolicy(policies, oldPod)
+	podObj, oldPod, err := applyMissingRequests(crq, podObj, oldPod, op)
+	if err != nil {
+		return nil, err
 	}
+	// Ephemeral containers added through pods/ephemeralcontainers make the
+	// update charge the quota's spec.ephemeralContainers per new container.
+	charge := corev1.ResourceList(crq.Spec.EphemeralContainers)
+	podObj, oldPod = pod.ChargeEphemeralContainers(podObj, charge), pod.ChargeEphemeralContainers(oldPod, charge)
 
-	for _, c := range computeResources {
-		delta := pod.CalculatePodUsage(podObj, c.resource)
-		if oldPod != nil {
-			delta.Sub(pod.CalculatePodUsage(oldPod, c.resource))
+	// Every limit is checked so a denial lists all the pod exceeds.
+	var violations quotaViolations
+	for _, c := range podComputeResources {
+		if !chargedAtAdmission(crq, c.resource) {
+			continue
 		}
+		delta := projection.PodDelta(podObj, oldPod, c.resource)
 		if delta.Sign() <= 0 {
 			continue
 		}
-		if err := validateCRQStatusUsage(crq, c.resource, delta, h.logger, correlationID); err != nil {
-			return nil, fmt.Errorf("ClusterResourceQuota %s validation failed: %w", c.label, err)
+		if err := validateNamespaceUsage(ctx, crq, podObj.Namespace, c.resource, delta, h.logger); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota %s validation failed: %w", c.label, err))
+			continue
+		}
+		if err := h.validateReservedHeadroom(crq, podObj, c.resource, delta, correlationID); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota %s validation failed: %w", c.label, err))
+		}
+	}
+
+	for _, resourceName := range extendedQuotaResources(crq) {
+		delta := projection.PodDelta(podObj, oldPod, resourceName)
+		if delta.Sign() <= 0 {
+			continue
+		}
+		if err := validateNamespaceUsage(ctx, crq, podObj.Namespace, resourceName, delta, h.logger); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota %s validation failed: %w", resourceName, err))
+		}
+	}
+
+	for _, resourceName := range hugePagesQuotaResources(crq) {
+		delta := projection.PodDelta(podObj, oldPod, resourceName)
+		if delta.Sign() <= 0 {
+			continue
+		}
+		if err := validateNamespaceUsage(ctx, crq, podObj.Namespace, resourceName, delta, h.logger); err != nil {
+			pages, _ := usage.HugePagesResource(resourceName)
+			violations.add(fmt.Errorf("ClusterResourceQuota %s requests validation failed: %w", pages, err))
 		}
 	}
 
 	if op == admissionv1.Create {
-		if err := validateCRQStatusUsage(crq, usage.ResourcePods, oneQuantity, h.logger, correlationID); err != nil {
-			return nil, fmt.Errorf("ClusterResourceQuota pod count validation failed: %w", err)
+		err := validateNamespaceUsage(ctx, crq, podObj.Namespace, usage.ResourcePods, oneQuantity, h.logger)
+		if err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota pod count validation failed: %w", err))
+		} else if err := h.validateReservedHeadroom(crq, podObj, usage.ResourcePods, oneQuantity, correlationID); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota pod count validation failed: %w", err))
+		}
+		if err := h.validateHostPorts(ctx, crq, podObj, correlationID); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota host port validation failed: %w", err))
 		}
+		// Volumes cannot change after creation, so neither can their claims.
+		violations.add(validateEphemeralVolumes(ctx, crq, podObj, h.logger))
+	}
+
+	if err := h.validatePodDensity(ctx, crq, podObj, correlationID); err != nil {
+		violations.add(fmt.Errorf("ClusterResourceQuota pod density validation failed: %w", err))
+	}
+	violations.add(validateOSResources(ctx, crq, podObj, oldPod, op, h.logger))
+	violations.add(h.validateTopology(ctx, crq, podObj, oldPod, op))
+	if err := violations.err(); err != nil {
+		return nil, err
 	}
 
 	logValidationPassed(h.logger, "Pod", podObj.Namespace, op, zap.String("pod", podObj.Name))
-	return nil, nil
+	return nearQuota.list(), nil
+}
+
+// validateEphemeralVolumes charges the PVCs the generic ephemeral volumes of
+// podObj make the ephemeral volume controller create, which are not charged
+// again when they are created.
+func validateEphemeralVolumes(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	logger *zap.Logger,
+) error {
+	added := newPVCCharge()
+	for _, pvc := range storage.EphemeralPVCs(podObj) {
+		added.add(&pvc)
+	}
+	if added.count == 0 {
+		return nil
+	}
+	return validatePVCCharge(ctx, crq, podObj.Namespace, "ephemeral volumes", added, logger)
+}
+
+// validateHostPorts charges podObj one pods.networking/ports per host port
+// that no pod in crq's namespaces binds yet. Ports cannot change after
+// creation, so only CREATE is checked. Failing to list pods fails open.
+func (h *PodWebhook) validateHostPorts(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	correlationID string,
+) error {
+	if _, ok := crq.Spec.Hard[usage.ResourcePodHostPorts]; !ok || !enforced(crq, usage.ResourcePodHostPorts) {
+		return nil
+	}
+	ports := pod.HostPorts(podObj)
+	if len(ports) == 0 {
+		return nil
+	}
+
+	inUse := make(map[string]bool)
+	src := objects.ExcludeOwned(h.crqClient.Client, excludedOwners(ctx))
+	for _, ns := range h.crqClient.GetNamespacesFromStatus(crq) {
+		pods := &corev1.PodList{}
+		if err := src.List(ctx, pods, client.InNamespace(ns)); err != nil {
+			h.logger.Warn("Failed to list pods for host port validation - allowing operation",
+				zap.String("correlation_id", correlationID),
+				zap.String("namespace", ns),
+				zap.String("crq_name", crq.Name),
+				zap.Error(err))
+			return nil
+		}
+		pod.DistinctHostPorts(pods.Items, inUse)
+	}
+	var added int64
+	for _, port := range ports {
+		if !inUse[port] {
+			inUse[port] = true
+			added++
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+	return validateCRQStatusUsage(ctx, crq, usage.ResourcePodHostPorts,
+		*resource.NewQuantity(added, resource.DecimalSI), h.logger)
+}
+
+// validatePodDensity denies podObj when it would raise the pods per requested
+// CPU core of its namespace above crq's pods.density/per-cpu limit. It checks
+// resizes too, since lowering a pod's CPU raises the density. A pod that does
+// not raise the density is admitted even in a namespace over the limit.
+// Failing to list pods fails open.
+func (h *PodWebhook) validatePodDensity(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	correlationID string,
+) error {
+	limit, ok := crq.Spec.Hard[usage.ResourcePodDensity]
+	if !ok || !enforced(crq, usage.ResourcePodDensity) {
+		return nil
+	}
+	pods := &corev1.PodList{}
+	src := objects.ExcludeOwned(h.crqClient.Client, excludedOwners(ctx))
+	if err := src.List(ctx, pods, client.InNamespace(podObj.Namespace)); err != nil {
+		h.logger.Warn("Failed to list pods for pod density validation - allowing operation",
+			zap.String("correlation_id", correlationID),
+			zap.String("namespace", podObj.Namespace),
+			zap.String("crq_name", crq.Name),
+			zap.Error(err))
+		return nil
+	}
+	charged := projection.ChargedPods(crq, projection.ApplyOwnerPolicies(h.ownerPolicies(ctx), pods.Items))
+	// The pod replaces its current version on UPDATE.
+	after := make([]corev1.Pod, 0, len(charged)+1)
+	for _, p := range charged {
+		if p.Name != podObj.Name {
+			after = append(after, p)
+		}
+	}
+	after = append(after, *podObj)
+
+	current, density := pod.Density(charged), pod.Density(after)
+	if density.Cmp(limit) <= 0 || density.Cmp(current) <= 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"ClusterResourceQuota '%s' %s limit exceeded in namespace %s: pods per requested CPU core "+
+			"would be %s, currently %s, limit %s",
+		crq.Name, usage.ResourcePodDensity, podObj.Namespace, density.String(), current.String(), limit.String())
+}
+
+// validateOSResources charges podObj against crq's OS-scoped hard limits, such
+// as "windows.requests.cpu" or "windows.pods", when the pod runs on that OS.
+func validateOSResources(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj, oldPod *corev1.Pod,
+	op admissionv1.Operation,
+	logger *zap.Logger,
+) error {
+	var names []string
+	for resourceName := range crq.Spec.Hard {
+		if _, _, ok := usage.SplitOSResource(resourceName); ok {
+			names = append(names, string(resourceName))
+		}
+	}
+	sort.Strings(names)
+	podOS := pod.OS(podObj)
+	var violations quotaViolations
+	for _, name := range names {
+		resourceName := corev1.ResourceName(name)
+		os, base, _ := usage.SplitOSResource(resourceName)
+		if os != podOS {
+			continue
+		}
+		var delta resource.Quantity
+		switch {
+		case base == usage.ResourcePods:
+			if op != admissionv1.Create {
+				continue
+			}
+			delta = oneQuantity
+		case isPodComputeResource(base) && chargedAtAdmission(crq, base):
+			delta = projection.PodDelta(podObj, oldPod, resourceName)
+		default:
+			continue
+		}
+		if delta.Sign() <= 0 {
+			continue
+		}
+		if err := validateCRQStatusUsage(ctx, crq, resourceName, delta, logger); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota %s validation failed: %w", name, err))
+		}
+	}
+	return violations.err()
+}
+
+// validateTopology charges podObj against the spec.topologyHard limits of the
+// spec.topologyKey value it is attributed to. Pods that are not yet pinned to
+// a single value are admitted; the controller attributes them once scheduled.
+// A value without status usage fails open, matching validateCRQStatusUsage.
+func (h *PodWebhook) validateTopology(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj, oldPod *corev1.Pod,
+	op admissionv1.Operation,
+) error {
+	key := crq.Spec.TopologyKey
+	if key == "" || len(crq.Spec.TopologyHard) == 0 {
+		return nil
+	}
+	var nodeLabels map[string]string
+	if podObj.Spec.NodeName != "" {
+		node := &corev1.Node{}
+		if err := h.crqClient.Client.Get(ctx, client.ObjectKey{Name: podObj.Spec.NodeName}, node); err != nil {
+			h.logger.Debug("Failed to get node for topology attribution",
+				zap.String("node", podObj.Spec.NodeName), zap.Error(err))
+		} else {
+			nodeLabels = node.Labels
+		}
+	}
+	value, ok := pod.TopologyValue(podObj, key, nodeLabels)
+	if !ok {
+		return nil
+	}
+	limits := crq.Spec.TopologyHard[value]
+	var used quotav1alpha1.ResourceList
+	for _, entry := range crq.Status.Topology {
+		if entry.Value == value {
+			used = entry.Used
+		}
+	}
+
+	resourceNames := make([]string, 0, len(limits))
+	for resourceName := range limits {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	var violations quotaViolations
+	for _, name := range resourceNames {
+		resourceName := corev1.ResourceName(name)
+		var delta resource.Quantity
+		if resourceName == usage.ResourcePods {
+			if op != admissionv1.Create {
+				continue
+			}
+			delta = oneQuantity
+		} else {
+			delta = projection.PodDelta(podObj, oldPod, resourceName)
+		}
+		delta = projection.Weigh(crq, resourceName, delta)
+		current, ok := used[resourceName]
+		if delta.Sign() <= 0 || !ok {
+			continue
+		}
+		if p := projection.Project(resourceName, current, delta, limits[resourceName]); p.Exceeds() {
+			total := p.Total()
+			violations.add(&quotaExceededError{crq: crq.Name, resource: resourceName, requested: delta,
+				used: current, hard: p.Hard, msg: fmt.Sprintf(
+					"ClusterResourceQuota '%s' %s limit for %s=%s exceeded: requested %s, current usage %s, "+
+						"limit %s, total would be %s",
+					crq.Name, resourceName, key, value, delta.String(), current.String(),
+					p.Hard.String(), total.String())})
+		}
+	}
+	return violations.err()
+}
+
+// podComputeResources are the compute resources the pod webhook charges.
+var podComputeResources = []struct {
+	resource corev1.ResourceName
+	label    string
+}{
+	{usage.ResourceRequestsCPU, "CPU requests"},
+	{usage.ResourceRequestsMemory, "memory requests"},
+	{usage.ResourceLimitsCPU, "CPU limits"},
+	{usage.ResourceLimitsMemory, "memory limits"},
+	{usage.ResourceRequestsEphemeralStorage, "ephemeral-storage requests"},
+	{usage.ResourceLimitsEphemeralStorage, "ephemeral-storage limits"},
+}
+
+// extendedQuotaResources returns the extended resources, such as
+// "requests.nvidia.com/gpu", that crq limits for pods of any OS, in order.
+func extendedQuotaResources(crq *quotav1alpha1.ClusterResourceQuota) []corev1.ResourceName {
+	var names []corev1.ResourceName
+	for resourceName := range crq.Spec.Hard {
+		if _, _, scoped := usage.SplitOSResource(resourceName); !scoped && pod.IsExtendedQuotaResource(resourceName) {
+			names = append(names, resourceName)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// hugePagesQuotaResources returns the hugepages keys, such as
+// "requests.hugepages-1Gi" or "hugepages-2Mi", that crq limits for pods of any
+// OS, in order.
+func hugePagesQuotaResources(crq *quotav1alpha1.ClusterResourceQuota) []corev1.ResourceName {
+	var names []corev1.ResourceName
+	for resourceName := range crq.Spec.Hard {
+		if _, ok := usage.HugePagesResource(resourceName); ok {
+			names = append(names, resourceName)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// isPodComputeResource reports whether resourceName is one of podComputeResources.
+func isPodComputeResource(resourceName corev1.ResourceName) bool {
+	for _, c := range podComputeResources {
+		if c.resource == resourceName {
+			return true
+		}
+	}
+	return false
+}
+
+// chargedAtAdmission reports whether pods are charged for resourceName at
+// admission. Actual-mode quotas compare observed usage, not requests, so there
+// is nothing to charge; the controller reports violations.
+func chargedAtAdmission(crq *quotav1alpha1.ClusterResourceQuota, resourceName corev1.ResourceName) bool {
+	_, observed := podmetrics.ObservedResource(resourceName)
+	return !observed || crq.Spec.Mode != quotav1alpha1.QuotaModeActual
+}
+
+// applyMissingRequests enforces crq's spec.missingRequests. Under Deny a new
+// pod must set every compute resource the quota limits in every container;
+// under Assume the defaults are filled in on both pods so they are charged the
+// same way the controller counts them.
+func applyMissingRequests(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj, oldPod *corev1.Pod,
+	op admissionv1.Operation,
+) (*corev1.Pod, *corev1.Pod, error) {
+	policy := crq.Spec.MissingRequests
+	if policy == nil {
+		return podObj, oldPod, nil
+	}
+	switch policy.Action {
+	case quotav1alpha1.MissingRequestsDeny:
+		if op != admissionv1.Create {
+			return podObj, oldPod, nil
+		}
+		var required []corev1.ResourceName
+		for _, c := range podComputeResources {
+			if _, ok := crq.Spec.Hard[c.resource]; ok && chargedAtAdmission(crq, c.resource) && enforced(crq, c.resource) {
+				required = append(required, c.resource)
+			}
+		}
+		if container, missing := pod.MissingResources(podObj, required); container != "" {
+			names := make([]string, len(missing))
+			for i, name := range missing {
+				names[i] = string(name)
+			}
+			return nil, nil, fmt.Errorf(
+				"ClusterResourceQuota '%s' requires every container to set the resources it limits: "+
+					"container %q does not set %s",
+				crq.Name, container, strings.Join(names, ", "))
+		}
+	case quotav1alpha1.MissingRequestsAssume:
+		defaults := corev1.ResourceList(policy.Defaults)
+		return pod.AssumeResources(podObj, defaults), pod.AssumeResources(oldPod, defaults), nil
+	}
+	return podObj, oldPod, nil
+}
+
+// validateReservedHeadroom denies a pod whose admission would eat into headroom
+// reserved for other priority classes via spec.reserved. Headroom reserved for
+// the pod's own priority class is available to it. Missing hard limits or
+// status usage fail open, matching validateCRQStatusUsage.
+func (h *PodWebhook) validateReservedHeadroom(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	podObj *corev1.Pod,
+	resourceName corev1.ResourceName,
+	requested resource.Quantity,
+	correlationID string,
+) error {
+	reserved := reservedForOtherClasses(crq, podObj.Spec.PriorityClassName, resourceName)
+	if reserved.IsZero() {
+		return nil
+	}
+	quotaLimit, ok := crq.Spec.Hard[resourceName]
+	if !ok || !enforced(crq, resourceName) {
+		return nil
+	}
+	currentUsage, ok := crq.Status.Total.Used[resourceName]
+	if !ok {
+		return nil
+	}
+
+	requested = projection.Weigh(crq, resourceName, requested)
+	remaining := quotaLimit.DeepCopy()
+	remaining.Sub(currentUsage)
+	remaining.Sub(requested)
+	if remaining.Cmp(reserved) >= 0 {
+		return nil
+	}
+
+	h.logger.Info("Reserved headroom would be consumed",
+		zap.String("correlation_id", correlationID),
+		zap.String("resource", string(resourceName)),
+		zap.String("priority_class", podObj.Spec.PriorityClassName),
+		zap.String("requested_quantity", requested.String()),
+		zap.String("current_usage", currentUsage.String()),
+		zap.String("reserved", reserved.String()),
+		zap.String("quota_limit", quotaLimit.String()),
+		zap.String("crq_name", crq.Name))
+
+	return &quotaExceededError{crq: crq.Name, resource: resourceName, requested: requested,
+		used: currentUsage, hard: quotaLimit, msg: fmt.Sprintf(
+			"ClusterResourceQuota '%s' %s reserved headroom exceeded: requested %s, current usage %s, "+
+				"quota limit %s, reserved for other priority classes %s",
+			crq.Name, resourceName, requested.String(), currentUsage.String(),
+			quotaLimit.String(), reserved.String())}
+}
+
+// reservedForOtherClasses sums the headroom reserved for resourceName by every
+// priority class other than priorityClass.
+func reservedForOtherClasses(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	priorityClass string,
+	resourceName corev1.ResourceName,
+) resource.Quantity {
+	var total resource.Quantity
+	for class, list := range crq.Spec.Reserved {
+		if class == priorityClass {
+			continue
+		}
+		if q, ok := list[resourceName]; ok {
+			total.Add(q)
+		}
+	}
+	return total
 }
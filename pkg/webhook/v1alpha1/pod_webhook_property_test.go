@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	"context"
+	"math/rand/v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/pod"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
+	"github.com/powerhome/pac-quota-controller/pkg/testing/fixtures"
+)
+
+// propertyRuns is how many random quotas and pods each property is checked on.
+const propertyRuns = 200
+
+var _ = Describe("PodWebhook properties", func() {
+	propertyKeys := []corev1.ResourceName{
+		usage.ResourceRequestsCPU, usage.ResourceRequestsMemory,
+		usage.ResourceLimitsCPU, usage.ResourceLimitsMemory,
+		usage.ResourcePods,
+	}
+
+	// reconciledUsage is the usage the controller reports for pods.
+	reconciledUsage := func(crq *quotav1alpha1.ClusterResourceQuota, pods []corev1.Pod) quotav1alpha1.ResourceList {
+		used := quotav1alpha1.ResourceList{}
+		charged := projection.ChargedPods(crq, pods)
+		for _, resourceName := range propertyKeys {
+			used[resourceName] = pod.CalculateUsageFromPods(charged, resourceName)
+		}
+		return used
+	}
+
+	It("admits a pod exactly when the reconciled usage with it stays within hard", func() {
+		r := rand.New(rand.NewPCG(uint64(GinkgoRandomSeed()), 3706))
+		for run := range propertyRuns {
+			hard := quotav1alpha1.ResourceList{
+				usage.ResourceRequestsCPU:    *resource.NewMilliQuantity(r.Int64N(8000), resource.DecimalSI),
+				usage.ResourceRequestsMemory: *resource.NewQuantity(r.Int64N(8<<10)<<20, resource.BinarySI),
+				usage.ResourceLimitsCPU:      *resource.NewMilliQuantity(r.Int64N(16000), resource.DecimalSI),
+				usage.ResourceLimitsMemory:   *resource.NewQuantity(r.Int64N(16<<10)<<20, resource.BinarySI),
+				usage.ResourcePods:           *resource.NewQuantity(r.Int64N(8), resource.DecimalSI),
+			}
+			cluster := fixtures.NewClusterWithCRQ(hard, map[string]string{"team": "property"})
+			existing := fixtures.RandomPods(r, "existing", r.IntN(5))
+			before := reconciledUsage(cluster.Quota, existing)
+			Expect(cluster.SetUsed(context.Background(), before)).To(Succeed())
+
+			candidate := fixtures.RandomPod(r, "candidate")
+			after := reconciledUsage(cluster.Quota, append(existing, *candidate))
+			fits := true
+			for _, resourceName := range propertyKeys {
+				grown, limit := after[resourceName], hard[resourceName]
+				if grown.Cmp(before[resourceName]) > 0 && grown.Cmp(limit) > 0 {
+					fits = false
+				}
+			}
+
+			h := NewPodWebhook(cluster.CRQClient, nil)
+			resp := fixtures.Admit(h.Handle, fixtures.Review(admissionv1.Create, candidate, nil))
+			Expect(resp.Response.Allowed).To(Equal(fits),
+				"run %d: hard %v, usage before %v, after %v: %v", run, hard, before, after, resp.Response.Result)
+		}
+	})
+
+	It("charges an admitted pod what the controller then counts", func() {
+		r := rand.New(rand.NewPCG(uint64(GinkgoRandomSeed()), 3707))
+		for run := range propertyRuns {
+			crq := &quotav1alpha1.ClusterResourceQuota{}
+			existing := fixtures.RandomPods(r, "existing", r.IntN(5))
+			candidate := fixtures.RandomPod(r, "candidate")
+			before := reconciledUsage(crq, existing)
+			after := reconciledUsage(crq, append(existing, *candidate))
+			for _, resourceName := range propertyKeys {
+				delta := projection.PodDelta(candidate, nil, resourceName)
+				total := before[resourceName]
+				total.Add(delta)
+				Expect(total.Cmp(after[resourceName])).To(BeZero(), "run %d: %s", run, resourceName)
+			}
+		}
+	})
+})
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo/v2"
@@ -175,6 +176,69 @@ var _ = Describe("PodWebhook", func() {
 			Expect(resp.Response.Result.Message).To(ContainSubstring("requests.cpu limit exceeded"))
 		})
 
+		It("charges pods by the owner policy of the QuotaControllerConfig", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1500m")},
+			)
+			half := quantity("500m")
+			cfg := &quotav1alpha1.QuotaControllerConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "pac-quota-controller"},
+				Spec: quotav1alpha1.QuotaControllerConfigSpec{OwnerPolicies: []quotav1alpha1.OwnerPodPolicy{
+					{Kind: "SparkApplication", ChargeFactor: &half},
+				}},
+			}
+			h := NewPodWebhook(newTestCRQClient(ns, crq, cfg), zap.NewNop())
+			h.EnableOwnerPolicies(cfg.Name)
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "1", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("1", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+
+			controller := true
+			pod.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: "sparkoperator.k8s.io/v1beta2", Kind: "SparkApplication", Name: "job", Controller: &controller,
+			}}
+			resp = sendWebhookRequest(engine, newPodReview("2", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("reports every exceeded limit in one denial, in a stable order", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU:    quantity("2"),
+					usage.ResourceRequestsMemory: quantity("1Gi"),
+					usage.ResourcePods:           quantity("1"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU:    quantity("2"),
+					usage.ResourceRequestsMemory: quantity("1Gi"),
+					usage.ResourcePods:           quantity("1"),
+				},
+			)
+			h := NewPodWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("2", makePod("p1", "1", "1Gi", "", "")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(HavePrefix("3 quota violations: "))
+			Expect(resp.Response.Result.Details.Causes).To(HaveLen(3))
+			var messages []string
+			for _, cause := range resp.Response.Result.Details.Causes {
+				Expect(cause.Type).To(Equal(causeQuotaExceeded))
+				messages = append(messages, cause.Message)
+			}
+			Expect(messages[0]).To(ContainSubstring("CPU requests"))
+			Expect(messages[1]).To(ContainSubstring("memory requests"))
+			Expect(messages[2]).To(ContainSubstring("pod count"))
+
+			again := sendWebhookRequest(engine, newPodReview("3", makePod("p1", "1", "1Gi", "", "")))
+			Expect(again.Response.Result.Message).To(Equal(resp.Response.Result.Message))
+		})
+
 		It("denies when memory requests would exceed the quota", func() {
 			ns := makeNamespace(nsName, labels)
 			crq := makeCRQ(crqName, labels,
@@ -357,6 +421,21 @@ var _ = Describe("PodWebhook", func() {
 			Expect(resp.Response.Result.Message).To(ContainSubstring("Operation DELETE is not supported"))
 		})
 
+		It("admits a terminal pod without charging the pod count", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourcePods: quantity("1")},
+				quotav1alpha1.ResourceList{usage.ResourcePods: quantity("1")},
+			)
+			h := NewPodWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "", "", "", "")
+			pod.Status.Phase = corev1.PodSucceeded
+			resp := sendWebhookRequest(engine, newPodReview("terminal", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
 		It("admits (fail-open) when CRQ status is missing the requested resource", func() {
 			ns := makeNamespace(nsName, labels)
 			crq := makeCRQ(crqName, labels,
@@ -376,6 +455,541 @@ var _ = Describe("PodWebhook", func() {
 		})
 	})
 
+	Describe("Reserved headroom", func() {
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU: quantity("10"),
+					usage.ResourcePods:        quantity("10"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU: quantity("6"),
+					usage.ResourcePods:        quantity("3"),
+				},
+			)
+			crq.Spec.Reserved = map[string]quotav1alpha1.ResourceList{
+				"system-cluster-critical": {usage.ResourceRequestsCPU: quantity("2")},
+			}
+		})
+
+		It("admits a non-critical pod that leaves the reserved headroom free", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "2", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("r1", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("denies a non-critical pod that would consume reserved headroom", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "3", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("r2", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("requests.cpu reserved headroom exceeded"))
+		})
+
+		It("admits a pod of the reserved priority class into the reserved headroom", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "4", "", "", "")
+			pod.Spec.PriorityClassName = "system-cluster-critical"
+			resp := sendWebhookRequest(engine, newPodReview("r3", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("denies a pod when reserved pod slots would be consumed", func() {
+			crq.Spec.Reserved["system-node-critical"] = quotav1alpha1.ResourceList{
+				usage.ResourcePods: quantity("7"),
+			}
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("r4", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("pod count"))
+		})
+	})
+
+	Describe("Actual mode", func() {
+		It("does not charge CPU requests against an Actual-mode quota", func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2")},
+			)
+			crq.Spec.Mode = quotav1alpha1.QuotaModeActual
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "4", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("a1", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("still enforces the pod count", func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourcePods: quantity("1")},
+				quotav1alpha1.ResourceList{usage.ResourcePods: quantity("1")},
+			)
+			crq.Spec.Mode = quotav1alpha1.QuotaModeActual
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "", "", "", "")
+			resp := sendWebhookRequest(engine, newPodReview("a2", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("pod count"))
+		})
+	})
+
+	Describe("Missing requests", func() {
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU: quantity("2"),
+					usage.ResourcePods:        quantity("10"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsCPU: quantity("1500m"),
+					usage.ResourcePods:        quantity("1"),
+				},
+			)
+		})
+
+		It("admits a pod without requests by default", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("m1", makePod("p1", "", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("denies a pod without requests under Deny", func() {
+			crq.Spec.MissingRequests = &quotav1alpha1.MissingRequestsPolicy{Action: quotav1alpha1.MissingRequestsDeny}
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("m2", makePod("p1", "", "1Gi", "", "")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring(`container "c" does not set requests.cpu`))
+
+			resp = sendWebhookRequest(engine, newPodReview("m3", makePod("p2", "100m", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("charges the assumed defaults under Assume", func() {
+			crq.Spec.MissingRequests = &quotav1alpha1.MissingRequestsPolicy{
+				Action:   quotav1alpha1.MissingRequestsAssume,
+				Defaults: quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1")},
+			}
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("m4", makePod("p1", "", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("CPU requests"))
+
+			resp = sendWebhookRequest(engine, newPodReview("m5", makePod("p2", "500m", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Extended resources", func() {
+		gpuPod := func(name, gpus string) *corev1.Pod {
+			pod := makePod(name, "", "", "", "")
+			pod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse(gpus)
+			pod.Spec.Containers[0].Resources.Limits["nvidia.com/gpu"] = resource.MustParse(gpus)
+			return pod
+		}
+
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{"requests.nvidia.com/gpu": quantity("2")},
+				quotav1alpha1.ResourceList{"requests.nvidia.com/gpu": quantity("1")},
+			)
+		})
+
+		It("denies a pod requesting a fraction of a GPU", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("e1", gpuPod("p1", "500m")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("extended resources must be whole numbers"))
+		})
+
+		It("charges whole GPUs against the quota", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			Expect(sendWebhookRequest(engine, newPodReview("e2", gpuPod("p1", "1"))).Response.Allowed).To(BeTrue())
+			resp := sendWebhookRequest(engine, newPodReview("e3", gpuPod("p2", "2")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("requests.nvidia.com/gpu"))
+		})
+	})
+
+	Describe("Hugepages", func() {
+		hugePagesPod := func(name, request, limit string) *corev1.Pod {
+			pod := makePod(name, "", "128Mi", "", "")
+			pod.Spec.Containers[0].Resources.Requests["hugepages-1Gi"] = resource.MustParse(request)
+			pod.Spec.Containers[0].Resources.Limits["hugepages-1Gi"] = resource.MustParse(limit)
+			return pod
+		}
+
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{"requests.hugepages-1Gi": quantity("4Gi")},
+				quotav1alpha1.ResourceList{"requests.hugepages-1Gi": quantity("2Gi")},
+			)
+		})
+
+		It("rejects hugepages requests that differ from their limits", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("h1", hugePagesPod("p1", "1Gi", "2Gi")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Code).To(BeEquivalentTo(http.StatusBadRequest))
+			Expect(resp.Response.Result.Message).To(ContainSubstring("hugepages requests must equal limits"))
+		})
+
+		It("charges 1Gi pages against the quota", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("h2", hugePagesPod("p1", "2Gi", "2Gi")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+			resp = sendWebhookRequest(engine, newPodReview("h3", hugePagesPod("p2", "3Gi", "3Gi")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("hugepages-1Gi requests validation failed"))
+		})
+	})
+
+	Describe("Generic ephemeral volumes", func() {
+		ephemeralVolumePod := func(name, size string) *corev1.Pod {
+			fast := "fast"
+			pod := makePod(name, "", "", "", "")
+			pod.Spec.Volumes = []corev1.Volume{{
+				Name: "scratch",
+				VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &fast,
+							Resources: corev1.VolumeResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+							},
+						},
+					},
+				}},
+			}}
+			return pod
+		}
+
+		It("charges the claims of ephemeral volumes against the storage quotas", func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage:                                       quantity("10Gi"),
+					usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("2"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceRequestsStorage:                                       quantity("8Gi"),
+					usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("1"),
+				},
+			)
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("g1", ephemeralVolumePod("p1", "2Gi")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+			resp = sendWebhookRequest(engine, newPodReview("g2", ephemeralVolumePod("p2", "3Gi")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("ephemeral volumes storage validation failed"))
+		})
+
+		It("charges the claim count of the volumes' storage class", func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("2")},
+				quotav1alpha1.ResourceList{usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("2")},
+			)
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("g3", ephemeralVolumePod("p1", "1Gi")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(
+				ContainSubstring("ephemeral volumes storage class 'fast' PVC count validation failed"))
+		})
+	})
+
+	Describe("OS-scoped quotas", func() {
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					"windows.requests.cpu": quantity("2"),
+					"windows.pods":         quantity("1"),
+				},
+				quotav1alpha1.ResourceList{
+					"windows.requests.cpu": quantity("1500m"),
+					"windows.pods":         quantity("0"),
+				},
+			)
+		})
+
+		windowsPod := func(name, cpuReq string) *corev1.Pod {
+			pod := makePod(name, cpuReq, "", "", "")
+			pod.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+			return pod
+		}
+
+		It("denies a Windows pod over the Windows CPU quota", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("o1", windowsPod("p1", "1")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("windows.requests.cpu"))
+		})
+
+		It("enforces the Windows pod count", func() {
+			crq.Status.Total.Used["windows.pods"] = quantity("1")
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("o2", windowsPod("p1", "100m")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("windows.pods"))
+		})
+
+		It("does not charge Linux pods against Windows quotas", func() {
+			crq.Status.Total.Used["windows.pods"] = quantity("1")
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("o3", makePod("p1", "4", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Topology-scoped quotas", func() {
+		const zoneKey = "topology.kubernetes.io/zone"
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("10")},
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("3")},
+			)
+			crq.Spec.TopologyKey = zoneKey
+			crq.Spec.TopologyHard = map[string]quotav1alpha1.ResourceList{
+				"zone-a": {usage.ResourceRequestsCPU: quantity("2")},
+			}
+			crq.Status.Topology = []quotav1alpha1.TopologyUsage{
+				{Value: "zone-a", Used: quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1500m")}},
+			}
+		})
+
+		It("denies a pod pinned to a zone over its limit", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "1", "", "", "")
+			pod.Spec.NodeSelector = map[string]string{zoneKey: "zone-a"}
+			resp := sendWebhookRequest(engine, newPodReview("t1", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("requests.cpu limit for " + zoneKey + "=zone-a"))
+		})
+
+		It("attributes a scheduled pod to its node's zone", func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{zoneKey: "zone-a"}}}
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq, node), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "1", "", "", "")
+			pod.Spec.NodeName = "node-1"
+			resp := sendWebhookRequest(engine, newPodReview("t2", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+		})
+
+		It("admits pods not pinned to a zone and pods in zones without a limit", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("t3", makePod("p1", "1", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+
+			pod := makePod("p2", "1", "", "", "")
+			pod.Spec.NodeSelector = map[string]string{zoneKey: "zone-b"}
+			resp = sendWebhookRequest(engine, newPodReview("t4", pod))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Node pool quotas", func() {
+		var crq *quotav1alpha1.ClusterResourceQuota
+
+		BeforeEach(func() {
+			crq = makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1500m")},
+			)
+			crq.Spec.NodeSelectorTerms = []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu"}},
+				},
+			}}
+		})
+
+		It("charges pods confined to the pool", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			pod := makePod("p1", "1", "", "", "")
+			pod.Spec.NodeSelector = map[string]string{"pool": "gpu"}
+			resp := sendWebhookRequest(engine, newPodReview("np1", pod))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("requests.cpu"))
+		})
+
+		It("does not charge general workloads", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, newPodReview("np2", makePod("p1", "4", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Host port quota (CREATE)", func() {
+		hostPortPod := func(name string, port int32) *corev1.Pod {
+			p := makePod(name, "", "", "", "")
+			p.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: port, HostPort: port}}
+			return p
+		}
+
+		BeforeEach(func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourcePodHostPorts: quantity("1")},
+				quotav1alpha1.ResourceList{usage.ResourcePodHostPorts: quantity("1")},
+			)
+			crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{Namespace: nsName}}
+			running := hostPortPod("ingress-1", 80)
+			running.Status.Phase = corev1.PodRunning
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq, running), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+		})
+
+		It("denies a pod binding a new host port over the limit", func() {
+			resp := sendWebhookRequest(engine, newPodReview("hp1", hostPortPod("ingress-2", 443)))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("pods.networking/ports"))
+		})
+
+		It("admits a pod binding a host port the quota already counts", func() {
+			resp := sendWebhookRequest(engine, newPodReview("hp2", hostPortPod("ingress-2", 80)))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Pod density quota", func() {
+		BeforeEach(func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourcePodDensity: quantity("3")},
+				quotav1alpha1.ResourceList{usage.ResourcePodDensity: quantity("2")},
+			)
+			running := makePod("web-1", "500m", "", "", "")
+			running.Status.Phase = corev1.PodRunning
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq, running), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+		})
+
+		It("admits a pod keeping the namespace under the limit", func() {
+			resp := sendWebhookRequest(engine, newPodReview("pd1", makePod("web-2", "200m", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("denies a tiny pod raising the density over the limit", func() {
+			resp := sendWebhookRequest(engine, newPodReview("pd2", makePod("tiny", "10m", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("pods.density/per-cpu"))
+			Expect(resp.Response.Result.Message).To(ContainSubstring("would be 3922m"))
+		})
+
+		It("denies a resize lowering the CPU of the namespace's pods", func() {
+			oldPod := makePod("web-1", "500m", "", "", "")
+			newPod := makePod("web-1", "100m", "", "", "")
+			review := newPodReview("pd3", newPod)
+			review.Request.Operation = admissionv1.Update
+			raw, _ := json.Marshal(oldPod)
+			review.Request.OldObject = runtime.RawExtension{Raw: raw}
+			resp := sendWebhookRequest(engine, review)
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("would be 10"))
+		})
+	})
+
+	Describe("Ephemeral container (UPDATE) Quota Validation", func() {
+		// debugReview builds the review the apiserver sends when kubectl debug
+		// adds an ephemeral container through pods/ephemeralcontainers.
+		debugReview := func(uid string, oldPod *corev1.Pod) *admissionv1.AdmissionReview {
+			newPod := oldPod.DeepCopy()
+			newPod.Spec.EphemeralContainers = append(newPod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+			})
+			r := newPodReview(uid, newPod)
+			r.Request.Operation = admissionv1.Update
+			r.Request.SubResource = "ephemeralcontainers"
+			oldRaw, _ := json.Marshal(oldPod)
+			r.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+			return r
+		}
+
+		newCRQ := func(used string) *quotav1alpha1.ClusterResourceQuota {
+			return makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("1")},
+				quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity(used)},
+			)
+		}
+
+		It("admits debug containers for free without spec.ephemeralContainers", func() {
+			h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), newCRQ("1")), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine, debugReview("e1", makePod("p1", "100m", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("charges spec.ephemeralContainers for each new debug container", func() {
+			for _, tc := range []struct {
+				used    string
+				allowed bool
+			}{{"900m", true}, {"950m", false}} {
+				crq := newCRQ(tc.used)
+				crq.Spec.EphemeralContainers = quotav1alpha1.ResourceList{usage.ResourceRequestsCPU: quantity("100m")}
+				engine = gin.New()
+				h := NewPodWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+				engine.POST("/webhook", h.Handle)
+
+				resp := sendWebhookRequest(engine, debugReview("e2", makePod("p1", "100m", "", "", "")))
+				Expect(resp.Response.Allowed).To(Equal(tc.allowed), "with %s used", tc.used)
+			}
+		})
+	})
+
 	Describe("Pod Resize (UPDATE) Quota Validation", func() {
 		// resizeReview builds a review matching what the apiserver sends for the
 		// pods/resize subresource: Operation=UPDATE, SubResource="resize", and
@@ -0,0 +1,104 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/resourceclaims"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+// ResourceClaimWebhook handles webhook requests for Dynamic Resource
+// Allocation ResourceClaims. It enforces the ResourceClaim count and the
+// per-device-class device quotas.
+type ResourceClaimWebhook struct {
+	crqClient *quota.CRQClient
+	logger    *zap.Logger
+}
+
+// NewResourceClaimWebhook creates a new ResourceClaimWebhook
+func NewResourceClaimWebhook(
+	crqClient *quota.CRQClient,
+	logger *zap.Logger,
+) *ResourceClaimWebhook {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	logger = logger.Named("resourceclaim-webhook")
+	return &ResourceClaimWebhook{
+		crqClient: crqClient,
+		logger:    logger,
+	}
+}
+
+// Handle handles the webhook request for ResourceClaim
+func (h *ResourceClaimWebhook) Handle(c *gin.Context) {
+	runWebhook(c, h.logger, webhookConfig{
+		name:             "resourceclaim",
+		expectedGVK:      &metav1.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "ResourceClaim"},
+		requireNamespace: true,
+	}, h.validate)
+}
+
+func (h *ResourceClaimWebhook) validate(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, error) {
+	// The claim spec is immutable, so only CREATE can change usage; the chart
+	// subscribes to nothing else.
+	if req.Operation != admissionv1.Create {
+		return nil, unsupportedOperationError(req.Operation, "ResourceClaim")
+	}
+
+	var claim resourcev1.ResourceClaim
+	if err := decodeAdmissionObject(req.Object.Raw, &claim, "ResourceClaim"); err != nil {
+		return nil, err
+	}
+	return h.validateOperation(ctx, &claim, req.Operation)
+}
+
+// validateOperation charges one claim and the devices it requests from each
+// device class.
+func (h *ResourceClaimWebhook) validateOperation(
+	ctx context.Context,
+	claim *resourcev1.ResourceClaim,
+	op admissionv1.Operation,
+) ([]string, error) {
+	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, claim.Namespace)
+	if crq == nil {
+		return nil, nil
+	}
+
+	var violations quotaViolations
+	if err := validateNamespaceUsage(
+		ctx, crq, claim.Namespace, usage.ResourceResourceClaims, oneQuantity, h.logger,
+	); err != nil {
+		violations.add(fmt.Errorf("ClusterResourceQuota ResourceClaim count validation failed: %w", err))
+	}
+
+	devices := resourceclaims.DeviceCounts(claim)
+	classes := make([]string, 0, len(devices))
+	for class := range devices {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	for _, class := range classes {
+		resourceName := usage.DeviceClassFor(class)
+		requested := *resource.NewQuantity(devices[class], resource.DecimalSI)
+		if err := validateNamespaceUsage(ctx, crq, claim.Namespace, resourceName, requested, h.logger); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota device validation failed for %s: %w", resourceName, err))
+		}
+	}
+	if err := violations.err(); err != nil {
+		return nil, err
+	}
+
+	logValidationPassed(h.logger, "ResourceClaim", claim.Namespace, op, zap.String("resourceclaim", claim.Name))
+	return nil, nil
+}
@@ -0,0 +1,143 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+const resourceClaimWebhookTestNamespace = "claim-ns"
+
+func newResourceClaimReview(
+	uid string, op admissionv1.Operation, claim *resourcev1.ResourceClaim,
+) *admissionv1.AdmissionReview {
+	raw, _ := json.Marshal(claim)
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionReview",
+			APIVersion: "admission.k8s.io/v1",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID(uid),
+			Namespace: resourceClaimWebhookTestNamespace,
+			Operation: op,
+			Kind:      metav1.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "ResourceClaim"},
+			Resource:  metav1.GroupVersionResource{Group: "resource.k8s.io", Version: "v1", Resource: "resourceclaims"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func makeResourceClaim(class string, count int64) *resourcev1.ResourceClaim {
+	return &resourcev1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: resourceClaimWebhookTestNamespace},
+		Spec: resourcev1.ResourceClaimSpec{Devices: resourcev1.DeviceClaim{
+			Requests: []resourcev1.DeviceRequest{{Name: "devices", Exactly: &resourcev1.ExactDeviceRequest{
+				DeviceClassName: class,
+				AllocationMode:  resourcev1.DeviceAllocationModeExactCount,
+				Count:           count,
+			}}},
+		}},
+	}
+}
+
+var _ = Describe("ResourceClaimWebhook", func() {
+	const (
+		nsName  = resourceClaimWebhookTestNamespace
+		crqName = "claim-crq"
+	)
+	var (
+		engine *gin.Engine
+		labels = map[string]string{"team": "ml"}
+		gpus   = usage.DeviceClassFor("gpu.example.com")
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	It("uses a no-op logger when nil is passed", func() {
+		h := NewResourceClaimWebhook(nil, nil)
+		Expect(h).NotTo(BeNil())
+		Expect(h.logger).NotTo(BeNil())
+	})
+
+	It("admits a claim whose devices fit the device-class quota", func() {
+		ns := makeNamespace(nsName, labels)
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{gpus: quantity("4")},
+			quotav1alpha1.ResourceList{gpus: quantity("2")},
+		)
+		h := NewResourceClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newResourceClaimReview("1", admissionv1.Create, makeResourceClaim("gpu.example.com", 2)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("denies a claim requesting more devices than the class has left", func() {
+		ns := makeNamespace(nsName, labels)
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{gpus: quantity("4")},
+			quotav1alpha1.ResourceList{gpus: quantity("3")},
+		)
+		h := NewResourceClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newResourceClaimReview("2", admissionv1.Create, makeResourceClaim("gpu.example.com", 2)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring(string(gpus)))
+	})
+
+	It("denies a claim over the ResourceClaim count", func() {
+		ns := makeNamespace(nsName, labels)
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourceResourceClaims: quantity("1")},
+			quotav1alpha1.ResourceList{usage.ResourceResourceClaims: quantity("1")},
+		)
+		h := NewResourceClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newResourceClaimReview("3", admissionv1.Create, makeResourceClaim("gpu.example.com", 1)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("resourceclaims.resource.k8s.io limit exceeded"))
+	})
+
+	It("does not charge device classes the quota does not limit", func() {
+		ns := makeNamespace(nsName, labels)
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{gpus: quantity("0")},
+			quotav1alpha1.ResourceList{gpus: quantity("0")},
+		)
+		h := NewResourceClaimWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newResourceClaimReview("4", admissionv1.Create, makeResourceClaim("nic.example.com", 1)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("rejects operations other than CREATE", func() {
+		h := NewResourceClaimWebhook(newTestCRQClient(), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newResourceClaimReview("5", admissionv1.Update, makeResourceClaim("gpu.example.com", 1)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+	})
+})
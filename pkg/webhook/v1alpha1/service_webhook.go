@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -10,7 +11,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/services"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
 )
 
@@ -19,6 +22,9 @@ import (
 type ServiceWebhook struct {
 	crqClient *quota.CRQClient
 	logger    *zap.Logger
+	// excludeHeadless leaves headless services out of the services key; off
+	// unless ExcludeHeadlessServices is called.
+	excludeHeadless bool
 }
 
 // NewServiceWebhook creates a new ServiceWebhook
@@ -36,6 +42,13 @@ func NewServiceWebhook(
 	}
 }
 
+// ExcludeHeadlessServices stops charging headless services to the services
+// key, as the controller does with --exclude-headless-services. They are still
+// charged to services.headless.
+func (h *ServiceWebhook) ExcludeHeadlessServices() {
+	h.excludeHeadless = true
+}
+
 // Handle handles the webhook request for Service
 func (h *ServiceWebhook) Handle(c *gin.Context) {
 	runWebhook(c, h.logger, webhookConfig{
@@ -70,7 +83,8 @@ func (h *ServiceWebhook) validate(ctx context.Context, req *admissionv1.Admissio
 }
 
 // validateOperation runs per-resource count checks. On Update, charges +1
-// only for resources the new service belongs to that the old service did not.
+// only for resources the new service belongs to that the old service did not,
+// and only the load balancer cost units added to the old service's.
 func (h *ServiceWebhook) validateOperation(
 	ctx context.Context,
 	svc *corev1.Service,
@@ -82,35 +96,87 @@ func (h *ServiceWebhook) validateOperation(
 		return nil, nil
 	}
 
-	correlationID := quota.GetCorrelationID(ctx)
+	if _, ok := crq.Spec.Hard[usage.ResourceServicesLoadBalancerCost]; ok {
+		// Where the quota caps the cost, an annotation that is not a cost is
+		// rejected rather than charged the default unit.
+		if _, err := services.LoadBalancerCost(svc); err != nil {
+			return nil, newStatusErrorf(http.StatusBadRequest, "%v", err)
+		}
+	}
 
 	already := map[corev1.ResourceName]bool{}
 	if oldSvc != nil {
-		for _, r := range serviceQuotaResources(oldSvc) {
+		for _, r := range h.quotaResources(oldSvc) {
 			already[r] = true
 		}
 	}
 
-	for _, r := range serviceQuotaResources(svc) {
+	var violations quotaViolations
+	for _, r := range h.quotaResources(svc) {
 		if already[r] {
 			continue
 		}
-		if err := validateCRQStatusUsage(crq, r, oneQuantity, h.logger, correlationID); err != nil {
-			return nil, fmt.Errorf("ClusterResourceQuota service count validation failed for %s: %w", r, err)
+		if err := validateNamespaceUsage(ctx, crq, svc.Namespace, r, oneQuantity, h.logger); err != nil {
+			violations.add(fmt.Errorf("ClusterResourceQuota service count validation failed for %s: %w", r, err))
 		}
 	}
+	if err := h.validateLoadBalancerCost(ctx, crq, svc, oldSvc); err != nil {
+		violations.add(err)
+	}
+	if err := violations.err(); err != nil {
+		return nil, err
+	}
 
 	logValidationPassed(h.logger, "Service", svc.Namespace, op, zap.String("service", svc.Name))
 	return nil, nil
 }
 
-func serviceQuotaResources(svc *corev1.Service) []corev1.ResourceName {
-	out := []corev1.ResourceName{usage.ResourceServices}
+// validateLoadBalancerCost charges the services.loadbalancers/cost-units svc
+// adds over oldSvc.
+func (h *ServiceWebhook) validateLoadBalancerCost(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	svc *corev1.Service,
+	oldSvc *corev1.Service,
+) error {
+	// A bad annotation costs the default unit, as the controller counts it.
+	cost, _ := services.LoadBalancerCost(svc)
+	if oldSvc != nil {
+		oldCost, _ := services.LoadBalancerCost(oldSvc)
+		cost.Sub(oldCost)
+	}
+	if cost.Sign() <= 0 {
+		return nil
+	}
+	r := usage.ResourceServicesLoadBalancerCost
+	if err := validateNamespaceUsage(ctx, crq, svc.Namespace, r, cost, h.logger); err != nil {
+		return fmt.Errorf("ClusterResourceQuota service cost validation failed for %s: %w", r, err)
+	}
+	return nil
+}
+
+// quotaResources returns the count keys svc is charged one of.
+func (h *ServiceWebhook) quotaResources(svc *corev1.Service) []corev1.ResourceName {
+	var out []corev1.ResourceName
+	if !h.excludeHeadless || !services.IsHeadless(svc) {
+		out = append(out, usage.ResourceServices)
+	}
+	if services.IsHeadless(svc) {
+		out = append(out, usage.ResourceServicesHeadless)
+	}
 	switch svc.Spec.Type {
 	case corev1.ServiceTypeLoadBalancer:
 		out = append(out, usage.ResourceServicesLoadBalancers)
 	case corev1.ServiceTypeNodePort:
 		out = append(out, usage.ResourceServicesNodePorts)
 	}
+	if len(svc.Spec.ExternalIPs) > 0 {
+		out = append(out, usage.ResourceServicesExternalIPs)
+	}
+	for _, family := range services.IPFamilies(svc) {
+		if r, ok := services.FamilyResource(family); ok {
+			out = append(out, r)
+		}
+	}
 	return out
 }
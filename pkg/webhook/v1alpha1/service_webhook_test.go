@@ -104,6 +104,21 @@ var _ = Describe("ServiceWebhook", func() {
 			Expect(resp.Response.Result.Message).To(ContainSubstring("services limit exceeded"))
 		})
 
+		It("admits a service over the quota while the quota is paused", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceServices: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourceServices: quantity("2")},
+			)
+			crq.Annotations = map[string]string{quotav1alpha1.AnnotationPaused: "true"}
+			h := NewServiceWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			resp := sendWebhookRequest(engine,
+				newServiceReview("2", makeService(corev1.ServiceTypeClusterIP)))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
 		It("denies a LoadBalancer service when over the LB quota", func() {
 			ns := makeNamespace(nsName, labels)
 			crq := makeCRQ(crqName, labels,
@@ -319,5 +334,152 @@ var _ = Describe("ServiceWebhook", func() {
 			Expect(resp.Response.Allowed).To(BeFalse())
 			Expect(resp.Response.Result.Message).To(ContainSubstring("services.loadbalancers limit exceeded"))
 		})
+
+		It("denies turning a service dual-stack when the IPv6 quota is full", func() {
+			ns := makeNamespace(nsName, labels)
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceServicesIPv4: quantity("10"),
+					usage.ResourceServicesIPv6: quantity("1"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceServicesIPv4: quantity("1"),
+					usage.ResourceServicesIPv6: quantity("1"),
+				},
+			)
+			h := NewServiceWebhook(newTestCRQClient(ns, crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+
+			old := makeService(corev1.ServiceTypeClusterIP)
+			old.Spec.ClusterIPs = []string{"10.96.0.10"}
+			new := old.DeepCopy()
+			new.Spec.ClusterIPs = []string{"10.96.0.10", "fd00::10"}
+			resp := sendWebhookRequest(engine, updateReview("u6", new, old))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("services.ipv6 limit exceeded"))
+		})
+	})
+
+	Describe("IP family quotas", func() {
+		BeforeEach(func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceServicesIPv4: quantity("1")},
+				quotav1alpha1.ResourceList{usage.ResourceServicesIPv4: quantity("1")},
+			)
+			h := NewServiceWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+		})
+
+		It("denies a service allocated an IPv4 cluster IP over the limit", func() {
+			svc := makeService(corev1.ServiceTypeClusterIP)
+			svc.Spec.ClusterIPs = []string{"10.96.0.11"}
+			resp := sendWebhookRequest(engine, newServiceReview("f1", svc))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("services.ipv4 limit exceeded"))
+		})
+
+		It("admits an IPv6-only service", func() {
+			svc := makeService(corev1.ServiceTypeClusterIP)
+			svc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol}
+			resp := sendWebhookRequest(engine, newServiceReview("f2", svc))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+
+		It("admits a headless service", func() {
+			svc := makeService(corev1.ServiceTypeClusterIP)
+			svc.Spec.ClusterIP = corev1.ClusterIPNone
+			svc.Spec.ClusterIPs = []string{corev1.ClusterIPNone}
+			svc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol}
+			resp := sendWebhookRequest(engine, newServiceReview("f3", svc))
+			Expect(resp.Response.Allowed).To(BeTrue())
+		})
+	})
+
+	Describe("external IP and load balancer cost quotas", func() {
+		costly := func(cost string) *corev1.Service {
+			svc := makeService(corev1.ServiceTypeLoadBalancer)
+			svc.Annotations = map[string]string{"quota.powerapp.cloud/lb-cost-units": cost}
+			return svc
+		}
+
+		BeforeEach(func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{
+					usage.ResourceServicesExternalIPs:      quantity("1"),
+					usage.ResourceServicesLoadBalancerCost: quantity("10"),
+				},
+				quotav1alpha1.ResourceList{
+					usage.ResourceServicesExternalIPs:      quantity("1"),
+					usage.ResourceServicesLoadBalancerCost: quantity("6"),
+				},
+			)
+			h := NewServiceWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+		})
+
+		It("denies a service with external IPs over the limit", func() {
+			svc := makeService(corev1.ServiceTypeClusterIP)
+			svc.Spec.ExternalIPs = []string{"203.0.113.10"}
+			resp := sendWebhookRequest(engine, newServiceReview("x1", svc))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("services.externalips limit exceeded"))
+		})
+
+		It("charges a LoadBalancer the cost units of its annotation", func() {
+			resp := sendWebhookRequest(engine, newServiceReview("x2", costly("4")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+
+			resp = sendWebhookRequest(engine, newServiceReview("x3", costly("5")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("services.loadbalancers/cost-units limit exceeded"))
+		})
+
+		It("charges an update only the cost units it adds", func() {
+			r := newServiceReview("x4", costly("8"))
+			r.Request.Operation = admissionv1.Update
+			oldRaw, _ := json.Marshal(costly("4"))
+			r.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+			Expect(sendWebhookRequest(engine, r).Response.Allowed).To(BeTrue())
+		})
+
+		It("rejects a cost annotation that is not a quantity", func() {
+			resp := sendWebhookRequest(engine, newServiceReview("x5", costly("expensive")))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Code).To(Equal(int32(400)))
+			Expect(resp.Response.Result.Message).To(ContainSubstring("quota.powerapp.cloud/lb-cost-units"))
+		})
+	})
+
+	Describe("headless services", func() {
+		headless := func() *corev1.Service {
+			svc := makeService(corev1.ServiceTypeClusterIP)
+			svc.Spec.ClusterIP = corev1.ClusterIPNone
+			return svc
+		}
+		var h *ServiceWebhook
+
+		BeforeEach(func() {
+			crq := makeCRQ(crqName, labels,
+				quotav1alpha1.ResourceList{usage.ResourceServices: quantity("1"), usage.ResourceServicesHeadless: quantity("2")},
+				quotav1alpha1.ResourceList{usage.ResourceServices: quantity("1"), usage.ResourceServicesHeadless: quantity("1")},
+			)
+			h = NewServiceWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+			engine.POST("/webhook", h.Handle)
+		})
+
+		It("charges a headless service to services and services.headless", func() {
+			resp := sendWebhookRequest(engine, newServiceReview("h1", headless()))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Message).To(ContainSubstring("services limit exceeded"))
+		})
+
+		It("charges a headless service only to services.headless when they are excluded", func() {
+			h.ExcludeHeadlessServices()
+			resp := sendWebhookRequest(engine, newServiceReview("h2", headless()))
+			Expect(resp.Response.Allowed).To(BeTrue())
+
+			resp = sendWebhookRequest(engine, newServiceReview("h3", makeService(corev1.ServiceTypeClusterIP)))
+			Expect(resp.Response.Allowed).To(BeFalse())
+		})
 	})
 })
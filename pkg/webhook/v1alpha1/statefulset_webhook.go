@@ -0,0 +1,206 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+)
+
+// StatefulSetWebhook handles webhook requests for StatefulSets and their scale
+// subresource. The StatefulSet controller creates the PVCs of its
+// volumeClaimTemplates one replica at a time, so a quota hit mid-scale leaves
+// some replicas without their volumes. The webhook charges every PVC a create
+// or scale-up will add against the storage and PVC count quotas up front and
+// denies the change whole when they cannot hold it.
+type StatefulSetWebhook struct {
+	crqClient *quota.CRQClient
+	logger    *zap.Logger
+}
+
+// NewStatefulSetWebhook creates a new StatefulSetWebhook
+func NewStatefulSetWebhook(
+	crqClient *quota.CRQClient,
+	logger *zap.Logger,
+) *StatefulSetWebhook {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	logger = logger.Named("statefulset-webhook")
+	return &StatefulSetWebhook{
+		crqClient: crqClient,
+		logger:    logger,
+	}
+}
+
+// Handle handles the webhook request for StatefulSet. Requests on the scale
+// subresource carry an autoscaling/v1 Scale, so the kind is checked here
+// rather than by runWebhook.
+func (h *StatefulSetWebhook) Handle(c *gin.Context) {
+	runWebhook(c, h.logger, webhookConfig{
+		name:             "statefulset",
+		requireNamespace: true,
+	}, h.validate)
+}
+
+func (h *StatefulSetWebhook) validate(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, error) {
+	switch req.Operation {
+	case admissionv1.Create, admissionv1.Update:
+	default:
+		return nil, unsupportedOperationError(req.Operation, "StatefulSet")
+	}
+
+	switch {
+	case req.SubResource == "scale" && req.Kind.Kind == "Scale":
+		return nil, h.validateScale(ctx, req)
+	case req.SubResource == "" && req.Kind.Group == "apps" && req.Kind.Kind == "StatefulSet":
+	default:
+		return nil, newStatusErrorf(http.StatusBadRequest, "Expected StatefulSet resource, got %s", req.Kind.Kind)
+	}
+
+	var sts appsv1.StatefulSet
+	if err := decodeAdmissionObject(req.Object.Raw, &sts, "StatefulSet"); err != nil {
+		return nil, err
+	}
+	var fromReplicas int32
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		var old appsv1.StatefulSet
+		if err := decodeAdmissionObject(req.OldObject.Raw, &old, "StatefulSet"); err != nil {
+			return nil, err
+		}
+		fromReplicas = statefulSetReplicas(&old)
+	}
+	return nil, h.validateOperation(ctx, &sts, fromReplicas, statefulSetReplicas(&sts), req.Operation)
+}
+
+// validateScale validates a write to the scale subresource, which carries the
+// replica counts but not the volumeClaimTemplates: those are read from the
+// StatefulSet itself.
+func (h *StatefulSetWebhook) validateScale(ctx context.Context, req *admissionv1.AdmissionRequest) error {
+	var scale autoscalingv1.Scale
+	if err := decodeAdmissionObject(req.Object.Raw, &scale, "Scale"); err != nil {
+		return err
+	}
+	if h.crqClient == nil {
+		return nil
+	}
+	sts := &appsv1.StatefulSet{}
+	key := types.NamespacedName{Namespace: req.Namespace, Name: req.Name}
+	if err := h.crqClient.Client.Get(ctx, key, sts); err != nil {
+		h.logger.Error("Failed to get StatefulSet for scale - allowing operation",
+			zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+			zap.String("statefulset", req.Name),
+			zap.String("namespace", req.Namespace),
+			zap.Error(err))
+		return nil
+	}
+
+	fromReplicas := statefulSetReplicas(sts)
+	if len(req.OldObject.Raw) > 0 {
+		var old autoscalingv1.Scale
+		if err := decodeAdmissionObject(req.OldObject.Raw, &old, "Scale"); err != nil {
+			return err
+		}
+		fromReplicas = old.Spec.Replicas
+	}
+	return h.validateOperation(ctx, sts, fromReplicas, scale.Spec.Replicas, req.Operation)
+}
+
+// validateOperation charges the PVCs the volumeClaimTemplates of sts add when
+// it goes from fromReplicas to toReplicas against the matching CRQ. PVCs that
+// already exist, kept from an earlier scale-down, are not charged again.
+func (h *StatefulSetWebhook) validateOperation(
+	ctx context.Context,
+	sts *appsv1.StatefulSet,
+	fromReplicas, toReplicas int32,
+	op admissionv1.Operation,
+) error {
+	if toReplicas <= fromReplicas || len(sts.Spec.VolumeClaimTemplates) == 0 {
+		return nil
+	}
+	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, sts.Namespace)
+	if crq == nil {
+		return nil
+	}
+
+	existing, err := h.existingPVCs(ctx, sts.Namespace)
+	if err != nil {
+		h.logger.Error("Failed to list PVCs - allowing operation",
+			zap.String("correlation_id", quota.GetCorrelationID(ctx)),
+			zap.String("statefulset", sts.Name),
+			zap.String("namespace", sts.Namespace),
+			zap.Error(err))
+		return nil
+	}
+	added := newStatefulSetPVCs(sts, fromReplicas, toReplicas, existing)
+	if added.count == 0 {
+		return nil
+	}
+
+	if err := validatePVCCharge(ctx, crq, sts.Namespace, "volumeClaimTemplates", added, h.logger); err != nil {
+		return err
+	}
+
+	logValidationPassed(h.logger, "StatefulSet", sts.Namespace, op,
+		zap.String("statefulset", sts.Name),
+		zap.Int64("new_pvcs", added.count),
+		zap.String("storage_delta", added.storage.String()))
+	return nil
+}
+
+// existingPVCs returns the names of the PVCs in namespace.
+func (h *StatefulSetWebhook) existingPVCs(ctx context.Context, namespace string) (map[string]bool, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := h.crqClient.Client.List(ctx, pvcs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(pvcs.Items))
+	for _, pvc := range pvcs.Items {
+		names[pvc.Name] = true
+	}
+	return names, nil
+}
+
+// newStatefulSetPVCs sums the PVCs sts creates for the replicas from
+// fromReplicas up to toReplicas, skipping those named in existing. PVCs are
+// named <template>-<statefulset>-<ordinal>, as the StatefulSet controller
+// names them.
+func newStatefulSetPVCs(
+	sts *appsv1.StatefulSet,
+	fromReplicas, toReplicas int32,
+	existing map[string]bool,
+) pvcCharge {
+	added := newPVCCharge()
+	var start int32
+	if sts.Spec.Ordinals != nil {
+		start = sts.Spec.Ordinals.Start
+	}
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		pvc := corev1.PersistentVolumeClaim{ObjectMeta: template.ObjectMeta, Spec: template.Spec}
+		for replica := max(fromReplicas, 0); replica < toReplicas; replica++ {
+			if !existing[fmt.Sprintf("%s-%s-%d", template.Name, sts.Name, start+replica)] {
+				added.add(&pvc)
+			}
+		}
+	}
+	return added
+}
+
+// statefulSetReplicas returns the desired replicas of sts, which default to
+// one.
+func statefulSetReplicas(sts *appsv1.StatefulSet) int32 {
+	if sts.Spec.Replicas == nil {
+		return 1
+	}
+	return *sts.Spec.Replicas
+}
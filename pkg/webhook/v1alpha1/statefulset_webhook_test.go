@@ -0,0 +1,244 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+const statefulSetWebhookTestNamespace = "sts-ns"
+
+func newStatefulSetReview(
+	uid string, op admissionv1.Operation, sts, old *appsv1.StatefulSet,
+) *admissionv1.AdmissionReview {
+	raw, _ := json.Marshal(sts)
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID(uid),
+			Name:      sts.Name,
+			Namespace: statefulSetWebhookTestNamespace,
+			Operation: op,
+			Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+			Resource:  metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	if old != nil {
+		oldRaw, _ := json.Marshal(old)
+		review.Request.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+	return review
+}
+
+func newStatefulSetScaleReview(uid, name string, from, to int32) *admissionv1.AdmissionReview {
+	scale := func(replicas int32) []byte {
+		raw, _ := json.Marshal(&autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: statefulSetWebhookTestNamespace},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		})
+		return raw
+	}
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:         types.UID(uid),
+			Name:        name,
+			Namespace:   statefulSetWebhookTestNamespace,
+			Operation:   admissionv1.Update,
+			Kind:        metav1.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"},
+			Resource:    metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"},
+			SubResource: "scale",
+			Object:      runtime.RawExtension{Raw: scale(to)},
+			OldObject:   runtime.RawExtension{Raw: scale(from)},
+		},
+	}
+}
+
+// makeStatefulSet returns a StatefulSet named db with one volumeClaimTemplate
+// named data per entry of storageRequests.
+func makeStatefulSet(replicas int32, storageClass string, storageRequests ...string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: statefulSetWebhookTestNamespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}
+	for i, request := range storageRequests {
+		template := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: quantity(request)},
+				},
+			},
+		}
+		if i > 0 {
+			template.Name = "wal"
+		}
+		if storageClass != "" {
+			template.Spec.StorageClassName = &storageClass
+		}
+		sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, template)
+	}
+	return sts
+}
+
+var _ = Describe("StatefulSetWebhook", func() {
+	const (
+		nsName  = statefulSetWebhookTestNamespace
+		crqName = "sts-crq"
+	)
+	var (
+		engine *gin.Engine
+		labels = map[string]string{"team": "data"}
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	It("uses a no-op logger when nil is passed", func() {
+		h := NewStatefulSetWebhook(nil, nil)
+		Expect(h).NotTo(BeNil())
+		Expect(h.logger).NotTo(BeNil())
+	})
+
+	It("admits a StatefulSet whose claims fit the storage quota", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("100Gi")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("60Gi")},
+		)
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newStatefulSetReview("1", admissionv1.Create, makeStatefulSet(2, "", "10Gi", "10Gi"), nil))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("denies a StatefulSet whose claims for every replica exceed the storage quota", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("100Gi")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("60Gi")},
+		)
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newStatefulSetReview("2", admissionv1.Create, makeStatefulSet(3, "", "10Gi", "5Gi"), nil))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("volumeClaimTemplates storage validation failed"))
+	})
+
+	It("charges the PVC count and storage-class keys", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{
+				usage.ResourcePersistentVolumeClaims:                                quantity("10"),
+				usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("3"),
+			},
+			quotav1alpha1.ResourceList{
+				usage.ResourcePersistentVolumeClaims:                                quantity("2"),
+				usage.StorageClassFor("fast", usage.ResourcePersistentVolumeClaims): quantity("0"),
+			},
+		)
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newStatefulSetReview("3", admissionv1.Create, makeStatefulSet(2, "fast", "1Gi", "1Gi"), nil))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("storage class 'fast' PVC count validation failed"))
+	})
+
+	It("charges only the replicas an update adds", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("3")},
+		)
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newStatefulSetReview("4", admissionv1.Update,
+			makeStatefulSet(5, "", "1Gi"), makeStatefulSet(3, "", "1Gi")))
+		Expect(resp.Response.Allowed).To(BeTrue())
+
+		resp = sendWebhookRequest(engine, newStatefulSetReview("5", admissionv1.Update,
+			makeStatefulSet(6, "", "1Gi"), makeStatefulSet(3, "", "1Gi")))
+		Expect(resp.Response.Allowed).To(BeFalse())
+
+		resp = sendWebhookRequest(engine, newStatefulSetReview("6", admissionv1.Update,
+			makeStatefulSet(1, "", "1Gi"), makeStatefulSet(6, "", "1Gi")))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("does not charge claims kept from an earlier scale-down", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("3")},
+			quotav1alpha1.ResourceList{usage.ResourcePersistentVolumeClaims: quantity("3")},
+		)
+		kept := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-db-2", Namespace: nsName}}
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq, kept), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newStatefulSetReview("7", admissionv1.Update,
+			makeStatefulSet(3, "", "1Gi"), makeStatefulSet(2, "", "1Gi")))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("validates the scale subresource against the StatefulSet's templates", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("50Gi")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("20Gi")},
+		)
+		sts := makeStatefulSet(2, "", "10Gi")
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq, sts), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newStatefulSetScaleReview("8", sts.Name, 2, 5))
+		Expect(resp.Response.Allowed).To(BeTrue())
+
+		resp = sendWebhookRequest(engine, newStatefulSetScaleReview("9", sts.Name, 2, 6))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("requests.storage"))
+	})
+
+	It("admits the scale of a StatefulSet it cannot read", func() {
+		crq := makeCRQ(crqName, labels,
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("1Gi")},
+			quotav1alpha1.ResourceList{usage.ResourceRequestsStorage: quantity("1Gi")},
+		)
+		h := NewStatefulSetWebhook(newTestCRQClient(makeNamespace(nsName, labels), crq), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine, newStatefulSetScaleReview("10", "missing", 0, 3))
+		Expect(resp.Response.Allowed).To(BeTrue())
+	})
+
+	It("rejects DELETE and other kinds", func() {
+		h := NewStatefulSetWebhook(newTestCRQClient(), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		resp := sendWebhookRequest(engine,
+			newStatefulSetReview("11", admissionv1.Delete, makeStatefulSet(1, "", "1Gi"), nil))
+		Expect(resp.Response.Allowed).To(BeFalse())
+
+		review := newStatefulSetReview("12", admissionv1.Create, makeStatefulSet(1, "", "1Gi"), nil)
+		review.Request.Kind = metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+		resp = sendWebhookRequest(engine, review)
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Result.Message).To(ContainSubstring("Expected StatefulSet"))
+	})
+})
@@ -0,0 +1,36 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// defaultRetryAfterSeconds is the retry hint of a transient failure the API
+// server suggested no delay for.
+const defaultRetryAfterSeconds = 1
+
+// transientError reports whether err is a failure of the webhook's own API
+// server requests that a retry can get past, such as a timeout or an
+// overloaded API server, rather than a verdict on the request. It returns the
+// seconds a client should wait before retrying.
+func transientError(err error) (retryAfterSeconds int32, ok bool) {
+	if err == nil {
+		return 0, false
+	}
+	transient := apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsUnexpectedServerError(err) ||
+		errors.Is(err, context.DeadlineExceeded)
+	if !transient {
+		return 0, false
+	}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return int32(seconds), true
+	}
+	return defaultRetryAfterSeconds, true
+}
@@ -0,0 +1,126 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/vpa"
+)
+
+// VerticalPodAutoscalerWebhook mutates VerticalPodAutoscaler status updates so
+// the recommended targets fit in the headroom of the ClusterResourceQuota
+// selecting the VPA's namespace. Lower bounds, upper bounds and uncapped
+// targets are left as the recommender wrote them. It never denies an update.
+type VerticalPodAutoscalerWebhook struct {
+	crqClient *quota.CRQClient
+	logger    *zap.Logger
+}
+
+// NewVerticalPodAutoscalerWebhook creates a new VerticalPodAutoscalerWebhook
+func NewVerticalPodAutoscalerWebhook(c *quota.CRQClient, logger *zap.Logger) *VerticalPodAutoscalerWebhook {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &VerticalPodAutoscalerWebhook{
+		crqClient: c,
+		logger:    logger.Named("vpa-webhook"),
+	}
+}
+
+// Handle handles the mutating webhook request for VerticalPodAutoscaler
+func (h *VerticalPodAutoscalerWebhook) Handle(c *gin.Context) {
+	runMutatingWebhook(c, h.logger, webhookConfig{
+		name: "verticalpodautoscaler",
+		expectedGVK: &metav1.GroupVersionKind{
+			Group: vpa.GVK.Group, Version: vpa.GVK.Version, Kind: vpa.GVK.Kind,
+		},
+		requireNamespace: true,
+	}, h.mutate)
+}
+
+func (h *VerticalPodAutoscalerWebhook) mutate(ctx context.Context, req *admissionv1.AdmissionRequest) ([]byte, error) {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := decodeAdmissionObject(req.Object.Raw, obj, "VerticalPodAutoscaler"); err != nil {
+		return nil, err
+	}
+	crq := resolveCRQForNamespace(ctx, h.crqClient, h.logger, req.Namespace)
+	if crq == nil {
+		return nil, nil
+	}
+	headroom := vpa.Headroom(crq)
+	if len(headroom) == 0 {
+		return nil, nil
+	}
+
+	// Capping is best effort: a recommendation we cannot relate to its
+	// workload is admitted as written, and the controller still warns on it.
+	plan, err := vpa.LoadPlan(ctx, h.crqClient.Client, obj)
+	if err != nil {
+		h.logger.Debug("Not capping VerticalPodAutoscaler without a readable target",
+			zap.String("namespace", req.Namespace),
+			zap.String("name", req.Name),
+			zap.Error(err))
+		return nil, nil
+	}
+	if plan == nil || len(plan.Exceeded(headroom)) == 0 {
+		return nil, nil
+	}
+
+	capped := vpa.CapTargets(plan.Targets, plan.Current, plan.Replicas, headroom)
+	ops, err := targetPatch(obj, plan.Targets, capped)
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+	h.logger.Info("Capping VerticalPodAutoscaler recommendation to quota headroom",
+		zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name),
+		zap.String("crq_name", crq.Name),
+		zap.Int("patched_targets", len(ops)))
+	return json.Marshal(ops)
+}
+
+// targetPatch builds the JSONPatch replacing every target in obj's container
+// recommendations that capped changed.
+func targetPatch(
+	obj *unstructured.Unstructured,
+	targets, capped map[string]corev1.ResourceList,
+) ([]jsonPatchOp, error) {
+	recs, _, err := unstructured.NestedSlice(obj.Object, "status", "recommendation", "containerRecommendations")
+	if err != nil {
+		return nil, err
+	}
+	var ops []jsonPatchOp
+	for i, rec := range recs {
+		m, ok := rec.(map[string]any)
+		if !ok {
+			continue
+		}
+		container, _, _ := unstructured.NestedString(m, "containerName")
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			was, ok := targets[container][resourceName]
+			now := capped[container][resourceName]
+			if !ok || was.Cmp(now) == 0 {
+				continue
+			}
+			ops = append(ops, jsonPatchOp{
+				Op:    "replace",
+				Path:  fmt.Sprintf("/status/recommendation/containerRecommendations/%d/target/%s", i, resourceName),
+				Value: now.String(),
+			})
+		}
+	}
+	return ops, nil
+}
@@ -0,0 +1,104 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/vpa"
+)
+
+var _ = Describe("VerticalPodAutoscalerWebhook", func() {
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	deployment := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web", "namespace": "team-a"},
+		"spec": map[string]any{
+			"replicas": int64(2),
+			"template": map[string]any{"spec": map[string]any{
+				"containers": []any{map[string]any{
+					"name":      "app",
+					"resources": map[string]any{"requests": map[string]any{"cpu": "500m", "memory": "1Gi"}},
+				}},
+			}},
+		},
+	}}
+
+	recommendation := func(cpu string) *unstructured.Unstructured {
+		v := vpa.New()
+		v.SetNamespace("team-a")
+		v.SetName("web")
+		v.Object["spec"] = map[string]any{
+			"targetRef": map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		}
+		v.Object["status"] = map[string]any{"recommendation": map[string]any{
+			"containerRecommendations": []any{map[string]any{
+				"containerName": "app",
+				"target":        map[string]any{"cpu": cpu, "memory": "512Mi"},
+			}},
+		}}
+		return v
+	}
+
+	handle := func(v *unstructured.Unstructured) *admissionv1.AdmissionResponse {
+		crq := makeCRQ("team-a", map[string]string{"team": "a"},
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("4")},
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("3")})
+		ns := makeNamespace("team-a", map[string]string{"team": "a"})
+		h := NewVerticalPodAutoscalerWebhook(newTestCRQClient(crq, ns, deployment.DeepCopy()), zap.NewNop())
+		engine.POST("/webhook", h.Handle)
+
+		raw, _ := json.Marshal(v.Object)
+		resp := sendWebhookRequest(engine, &admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+			Request: &admissionv1.AdmissionRequest{
+				UID:         "1",
+				Name:        v.GetName(),
+				Namespace:   v.GetNamespace(),
+				Operation:   admissionv1.Update,
+				Kind:        metav1.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscaler"},
+				Resource:    metav1.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"},
+				SubResource: "status",
+				Object:      runtime.RawExtension{Raw: raw},
+			},
+		})
+		Expect(resp.Response.Allowed).To(BeTrue())
+		return resp.Response
+	}
+
+	It("scales a target increase down to the quota left", func() {
+		// Two replicas going from 500m to 1500m need 2 more CPUs; 1 is left,
+		// so each pod may grow by 500m.
+		resp := handle(recommendation("1500m"))
+
+		Expect(resp.PatchType).NotTo(BeNil())
+		var ops []jsonPatchOp
+		Expect(json.Unmarshal(resp.Patch, &ops)).To(Succeed())
+		Expect(ops).To(Equal([]jsonPatchOp{{
+			Op:    "replace",
+			Path:  "/status/recommendation/containerRecommendations/0/target/cpu",
+			Value: "1",
+		}}))
+	})
+
+	It("admits a recommendation that fits unchanged", func() {
+		Expect(handle(recommendation("900m")).Patch).To(BeEmpty())
+	})
+})
@@ -0,0 +1,123 @@
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxDenialMessageLength bounds the violations listed in the message of a
+// request denied for several of them. The API server returns the message to
+// the client and records it in audit events, so a request exceeding every
+// limit of a large quota must not produce an unbounded one.
+const maxDenialMessageLength = 1024
+
+// causeQuotaExceeded is the StatusCause type of each violation a denial reports.
+const causeQuotaExceeded metav1.CauseType = "QuotaExceeded"
+
+// quotaViolations collects the quota checks one admission request fails, so a
+// request exceeding several limits is told about all of them at once instead
+// of one per attempt.
+type quotaViolations struct {
+	errs       []error
+	incomplete error
+}
+
+// add records err, if any. The violations of a violationsError are added one
+// by one. An incompleteUsageError is only kept to decide the request when no
+// limit is exceeded.
+func (v *quotaViolations) add(err error) {
+	if err == nil {
+		return
+	}
+	var incomplete *incompleteUsageError
+	if errors.As(err, &incomplete) {
+		if v.incomplete == nil {
+			v.incomplete = err
+		}
+		return
+	}
+	var multiple *violationsError
+	if errors.As(err, &multiple) && len(multiple.errs) > 1 {
+		v.errs = append(v.errs, multiple.errs...)
+		return
+	}
+	v.errs = append(v.errs, err)
+}
+
+// err returns a violationsError when a limit is exceeded, otherwise the first
+// incomplete-usage error, or nil when every check passed.
+func (v *quotaViolations) err() error {
+	if len(v.errs) == 0 {
+		return v.incomplete
+	}
+	errs := slices.Clone(v.errs)
+	slices.SortStableFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	errs = slices.CompactFunc(errs, func(a, b error) bool { return a.Error() == b.Error() })
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return &violationsError{errs: errs, messages: messages}
+}
+
+// violationsError denies a request that exceeds one or more quota limits. Its
+// violations are sorted, so the same request is always denied with the same
+// message whatever order the checks ran in.
+type violationsError struct {
+	errs []error
+	// messages holds the message of each of errs, as rendered by render.
+	messages []string
+}
+
+// render returns e with the message of each violation written by fn. The
+// violations keep their order.
+func (e *violationsError) render(fn func(error) string) *violationsError {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = fn(err)
+	}
+	return &violationsError{errs: e.errs, messages: messages}
+}
+
+// Error returns the only violation as is. With several, it lists as many as
+// fit in maxDenialMessageLength, always at least one, and counts the rest.
+func (e *violationsError) Error() string {
+	if len(e.messages) == 1 {
+		return e.messages[0]
+	}
+	reported := e.reported()
+	msg := fmt.Sprintf("%d quota violations: %s", len(e.messages), strings.Join(e.messages[:reported], "; "))
+	if omitted := len(e.messages) - reported; omitted > 0 {
+		msg += fmt.Sprintf("; and %d more", omitted)
+	}
+	return msg
+}
+
+// reported returns how many violations the message lists.
+func (e *violationsError) reported() int {
+	length := 0
+	for i, msg := range e.messages {
+		if i > 0 {
+			length += len("; ")
+		}
+		length += len(msg)
+		if i > 0 && length > maxDenialMessageLength {
+			return i
+		}
+	}
+	return len(e.messages)
+}
+
+// causes returns a StatusCause for each violation the message lists.
+func (e *violationsError) causes() []metav1.StatusCause {
+	reported := e.reported()
+	causes := make([]metav1.StatusCause, 0, reported)
+	for _, msg := range e.messages[:reported] {
+		causes = append(causes, metav1.StatusCause{Type: causeQuotaExceeded, Message: msg})
+	}
+	return causes
+}
@@ -0,0 +1,74 @@
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("quotaViolations", func() {
+	It("passes without violations", func() {
+		var v quotaViolations
+		v.add(nil)
+		Expect(v.err()).NotTo(HaveOccurred())
+	})
+
+	It("returns a single violation unchanged", func() {
+		var v quotaViolations
+		v.add(errors.New("pods limit exceeded"))
+		Expect(v.err()).To(MatchError("pods limit exceeded"))
+	})
+
+	It("sorts violations and drops duplicates", func() {
+		var v quotaViolations
+		v.add(errors.New("b exceeded"))
+		v.add(errors.New("a exceeded"))
+		v.add(errors.New("b exceeded"))
+		Expect(v.err()).To(MatchError("2 quota violations: a exceeded; b exceeded"))
+	})
+
+	It("flattens the violations of a nested check", func() {
+		var nested quotaViolations
+		nested.add(errors.New("c exceeded"))
+		nested.add(errors.New("a exceeded"))
+		var v quotaViolations
+		v.add(errors.New("b exceeded"))
+		v.add(nested.err())
+		Expect(v.err()).To(MatchError("3 quota violations: a exceeded; b exceeded; c exceeded"))
+	})
+
+	It("lets an exceeded limit decide over incomplete usage", func() {
+		var v quotaViolations
+		v.add(fmt.Errorf("wrapped: %w", &incompleteUsageError{msg: "usage is incomplete"}))
+		var incomplete *incompleteUsageError
+		Expect(errors.As(v.err(), &incomplete)).To(BeTrue())
+
+		v.add(errors.New("pods limit exceeded"))
+		Expect(v.err()).To(MatchError("pods limit exceeded"))
+	})
+
+	It("truncates long lists and counts the omitted violations", func() {
+		var v quotaViolations
+		for i := range 20 {
+			v.add(fmt.Errorf("violation %02d %s", i, strings.Repeat("x", 200)))
+		}
+		err := v.err()
+		Expect(err.Error()).To(HavePrefix("20 quota violations: violation 00"))
+		Expect(err.Error()).To(HaveSuffix("; and 16 more"))
+		Expect(len(err.Error())).To(BeNumerically("<", maxDenialMessageLength+100))
+
+		var violations *violationsError
+		Expect(errors.As(err, &violations)).To(BeTrue())
+		Expect(violations.causes()).To(HaveLen(4))
+	})
+
+	It("always reports the first violation, however long", func() {
+		var v quotaViolations
+		v.add(errors.New(strings.Repeat("a", 2*maxDenialMessageLength)))
+		v.add(errors.New("b"))
+		Expect(v.err().Error()).To(HaveSuffix("; and 1 more"))
+	})
+})
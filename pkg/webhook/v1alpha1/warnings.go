@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+)
+
+type warningThresholdKey struct{}
+
+type nearQuotaKey struct{}
+
+// nearQuotaWarnings collects the admission warnings of one request whose
+// admission takes a quota to its warning threshold or past it.
+type nearQuotaWarnings struct {
+	// threshold is the percentage of a hard limit that warns.
+	threshold int
+	// namespace is where the request is admitted, named in the warnings.
+	namespace string
+	warnings  []string
+}
+
+// WarnNearQuota returns middleware making admission responses carry a warning,
+// shown by kubectl, for each hard limit an admitted request takes to percent
+// of its value or more. Requests that exceed a limit are denied instead.
+func WarnNearQuota(percent int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), warningThresholdKey{}, percent)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// withNearQuotaWarnings returns a ctx collecting the near-quota warnings of a
+// request in namespace, replacing any collector ctx already holds. The
+// collector is nil, and collects nothing, without WarnNearQuota.
+func withNearQuotaWarnings(ctx context.Context, namespace string) (context.Context, *nearQuotaWarnings) {
+	threshold, _ := ctx.Value(warningThresholdKey{}).(int)
+	if threshold <= 0 {
+		return ctx, nil
+	}
+	w := &nearQuotaWarnings{threshold: threshold, namespace: namespace}
+	return context.WithValue(ctx, nearQuotaKey{}, w), w
+}
+
+// list returns the warnings collected so far.
+func (w *nearQuotaWarnings) list() []string {
+	if w == nil {
+		return nil
+	}
+	return w.warnings
+}
+
+// warnNearQuota adds a warning to ctx's collector when used, the usage of
+// resourceName with the request admitted, is at or past the warning
+// threshold of limit.
+func warnNearQuota(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	resourceName corev1.ResourceName,
+	used, limit resource.Quantity,
+) {
+	w, _ := ctx.Value(nearQuotaKey{}).(*nearQuotaWarnings)
+	if w == nil || limit.Sign() <= 0 {
+		return
+	}
+	percent := int(used.AsApproximateFloat64() / limit.AsApproximateFloat64() * 100)
+	if percent < w.threshold {
+		return
+	}
+	warning := fmt.Sprintf("ClusterResourceQuota %s is at %d%% of %s (%s of %s)",
+		crq.Name, percent, resourceName, used.String(), limit.String())
+	if w.namespace != "" {
+		warning = fmt.Sprintf("namespace %s is at %d%% of %s for ClusterResourceQuota %s (%s of %s)",
+			w.namespace, percent, resourceName, crq.Name, used.String(), limit.String())
+	}
+	w.warnings = append(w.warnings, warning)
+}
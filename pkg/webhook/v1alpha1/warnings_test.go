@@ -0,0 +1,86 @@
+package v1alpha1
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage"
+)
+
+var _ = Describe("Near-quota admission warnings", func() {
+	labels := map[string]string{"team": "alpha"}
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	serviceHandler := func(used string) *ServiceWebhook {
+		crq := makeCRQ("svc-crq", labels,
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity("5")},
+			quotav1alpha1.ResourceList{usage.ResourceServices: quantity(used)},
+		)
+		return NewServiceWebhook(newTestCRQClient(makeNamespace(serviceWebhookTestNamespace, labels), crq), zap.NewNop())
+	}
+
+	It("warns when an admitted request reaches the threshold", func() {
+		engine.Use(WarnNearQuota(80))
+		engine.POST("/webhook", serviceHandler("3").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(ConsistOf(
+			"namespace svc-ns is at 80% of services for ClusterResourceQuota svc-crq (4 of 5)"))
+	})
+
+	It("does not warn below the threshold", func() {
+		engine.Use(WarnNearQuota(80))
+		engine.POST("/webhook", serviceHandler("2").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(BeEmpty())
+	})
+
+	It("does not warn without the middleware", func() {
+		engine.POST("/webhook", serviceHandler("4").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeTrue())
+		Expect(resp.Response.Warnings).To(BeEmpty())
+	})
+
+	It("does not warn about denied requests", func() {
+		engine.Use(WarnNearQuota(80))
+		engine.POST("/webhook", serviceHandler("5").Handle)
+
+		resp := sendWebhookRequest(engine, newServiceReview("1", makeService(corev1.ServiceTypeClusterIP)))
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(resp.Response.Warnings).To(BeEmpty())
+	})
+
+	It("repeats the warnings of cached pod decisions", func() {
+		crq := makeCRQ("pod-crq", labels,
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("10")},
+			quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("8")},
+		)
+		h := NewPodWebhook(newTestCRQClient(makeNamespace(podWebhookTestNamespace, labels), crq), zap.NewNop())
+		h.EnableDecisionCache(time.Minute)
+		engine.Use(WarnNearQuota(90))
+		engine.POST("/webhook", h.Handle)
+
+		for _, uid := range []string{"1", "2"} {
+			resp := sendWebhookRequest(engine, newPodReview(uid, makePod("p"+uid, "1", "", "", "")))
+			Expect(resp.Response.Allowed).To(BeTrue())
+			Expect(resp.Response.Warnings).To(ConsistOf(
+				"namespace pod-ns is at 90% of requests.cpu for ClusterResourceQuota pod-crq (9 of 10)"))
+		}
+	})
+})
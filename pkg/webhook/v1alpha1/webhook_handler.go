@@ -2,14 +2,18 @@ package v1alpha1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,6 +22,7 @@ import (
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
+	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/usage/projection"
 	"github.com/powerhome/pac-quota-controller/pkg/metrics"
 )
 
@@ -34,6 +39,15 @@ func newStatusErrorf(code int, format string, args ...any) error {
 	return &statusError{code: code, msg: fmt.Sprintf(format, args...)}
 }
 
+// incompleteUsageError marks a request the webhook cannot decide because the
+// quota's usage was not fully counted. runAdmission answers it with an HTTP
+// error so the API server applies the webhook's failurePolicy.
+type incompleteUsageError struct {
+	msg string
+}
+
+func (e *incompleteUsageError) Error() string { return e.msg }
+
 // webhookConfig parameterizes runWebhook for each concrete webhook.
 type webhookConfig struct {
 	// name is the value used for the "webhook" metric label.
@@ -48,9 +62,32 @@ type webhookConfig struct {
 // validateFn is the per-request callback invoked by runWebhook after structural checks.
 type validateFn func(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, error)
 
-// runWebhook is the shared entry point for every admission handler: JSON
-// binding, request validation, metrics, GVK check, and response writing.
+// mutateFn is the per-request callback invoked by runMutatingWebhook. It
+// returns a JSONPatch to apply, or nil to admit the object unchanged.
+type mutateFn func(ctx context.Context, req *admissionv1.AdmissionRequest) ([]byte, error)
+
+// admitFn is the callback shared by validating and mutating handlers.
+type admitFn func(ctx context.Context, req *admissionv1.AdmissionRequest) (warnings []string, patch []byte, err error)
+
+// runWebhook is the shared entry point for every validating admission handler:
+// JSON binding, request validation, metrics, GVK check, and response writing.
 func runWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, validate validateFn) {
+	runAdmission(c, logger, cfg, func(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, []byte, error) {
+		warnings, err := validate(ctx, req)
+		return warnings, nil, err
+	})
+}
+
+// runMutatingWebhook is runWebhook for mutating handlers: an allowed response
+// carries the patch returned by mutate.
+func runMutatingWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, mutate mutateFn) {
+	runAdmission(c, logger, cfg, func(ctx context.Context, req *admissionv1.AdmissionRequest) ([]string, []byte, error) {
+		patch, err := mutate(ctx, req)
+		return nil, patch, err
+	})
+}
+
+func runAdmission(c *gin.Context, logger *zap.Logger, cfg webhookConfig, admit admitFn) {
 	var review admissionv1.AdmissionReview
 	if err := c.ShouldBindJSON(&review); err != nil {
 		logger.Error("Failed to bind admission review", zap.Error(err))
@@ -65,6 +102,9 @@ func runWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, validate
 	}
 
 	review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID}
+	// Server-side dry runs (kubectl --dry-run=server) are validated like any
+	// request but leave no trace: no metrics, no cached decisions.
+	dryRun := review.Request.DryRun != nil && *review.Request.DryRun
 
 	if cfg.requireNamespace && review.Request.Namespace == "" {
 		logger.Info("Admission review request namespace is empty")
@@ -73,16 +113,20 @@ func runWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, validate
 			Code:    http.StatusBadRequest,
 			Message: fmt.Sprintf("Namespace is required for %s validation", cfg.name),
 		}
-		metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, "missing_namespace").Inc()
+		if !dryRun {
+			metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, "missing_namespace").Inc()
+		}
 		c.JSON(http.StatusOK, review)
 		return
 	}
 
 	op := string(review.Request.Operation)
 	ns := review.Request.Namespace
-	metrics.WebhookValidationCount.WithLabelValues(cfg.name, op, ns).Inc()
-	timer := prometheus.NewTimer(metrics.WebhookValidationDuration.WithLabelValues(cfg.name, op, ns))
-	defer timer.ObserveDuration()
+	if !dryRun {
+		metrics.WebhookValidationCount.WithLabelValues(cfg.name, op, ns).Inc()
+		timer := prometheus.NewTimer(metrics.WebhookValidationDuration.WithLabelValues(cfg.name, op, ns))
+		defer timer.ObserveDuration()
+	}
 
 	if cfg.expectedGVK != nil && review.Request.Kind != *cfg.expectedGVK {
 		logger.Error("Unexpected resource type",
@@ -93,20 +137,127 @@ func runWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, validate
 			Code:    http.StatusBadRequest,
 			Message: fmt.Sprintf("Expected %s resource, got %s", cfg.expectedGVK.Kind, review.Request.Kind.Kind),
 		}
-		metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, "gvk_mismatch").Inc()
+		if !dryRun {
+			metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, "gvk_mismatch").Inc()
+		}
 		c.JSON(http.StatusOK, review)
 		return
 	}
 
-	warnings, err := validate(c.Request.Context(), review.Request)
+	ctx := c.Request.Context()
+	if ownedByExcludedOwner(ctx, review.Request) {
+		logger.Debug("Admission of an object of an excluded owner skipped",
+			zap.String("webhook", cfg.name),
+			zap.String("operation", op),
+			zap.String("kind", review.Request.Kind.Kind),
+			zap.String("namespace", review.Request.Namespace),
+			zap.String("name", review.Request.Name))
+		if !dryRun {
+			metrics.WebhookAdmissionDecision.WithLabelValues(cfg.name, op, "allowed", ns).Inc()
+		}
+		review.Response.Allowed = true
+		c.JSON(http.StatusOK, review)
+		return
+	}
+	if dryRun {
+		ctx = withDryRun(ctx)
+	}
+	ctx, nearQuota := withNearQuotaWarnings(ctx, review.Request.Namespace)
+	ctx, denials := withQuotaDenials(ctx)
+	ctx, exemption := withEnforcementExemption(ctx)
+	budget := admissionBudget(c)
+	result, finished := admitWithinBudget(ctx, budget, review.Request, admit)
+	if !finished {
+		// An unvalidated request is never admitted: answering with an error
+		// before the API server's timeout applies the failurePolicy at once,
+		// with a reason, instead of after the full timeout.
+		logger.Warn("Admission outran its timeout budget; left to the failure policy",
+			zap.String("webhook", cfg.name),
+			zap.String("operation", op),
+			zap.String("kind", review.Request.Kind.Kind),
+			zap.String("namespace", review.Request.Namespace),
+			zap.String("name", review.Request.Name),
+			zap.Duration("budget", budget),
+			zap.Bool("dry_run", dryRun))
+		if !dryRun {
+			metrics.WebhookTimeoutBudgetExceeded.WithLabelValues(cfg.name).Inc()
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("quota not validated within its time budget of %s", budget),
+		})
+		return
+	}
+	warnings, patch, err := result.warnings, result.patch, result.err
+	warnings = append(warnings, nearQuota.list()...)
+	if err != nil && observing(ctx) {
+		reason, warning := observedDenial(err)
+		logger.Info("Admission denial observed; admitting",
+			zap.String("webhook", cfg.name),
+			zap.String("operation", op),
+			zap.String("kind", review.Request.Kind.Kind),
+			zap.String("namespace", review.Request.Namespace),
+			zap.String("name", review.Request.Name),
+			zap.String("reason", reason),
+			zap.Bool("dry_run", dryRun),
+			zap.Error(err))
+		if !dryRun {
+			metrics.WebhookObservedDenials.WithLabelValues(cfg.name, reason).Inc()
+		}
+		warnings = append(warnings, warning)
+		err = nil
+	}
+	var incomplete *incompleteUsageError
+	if errors.As(err, &incomplete) {
+		logger.Warn("Admission left to the failure policy",
+			zap.String("webhook", cfg.name),
+			zap.String("operation", op),
+			zap.String("kind", review.Request.Kind.Kind),
+			zap.String("namespace", review.Request.Namespace),
+			zap.String("name", review.Request.Name),
+			zap.Bool("dry_run", dryRun),
+			zap.Error(err))
+		if !dryRun {
+			metrics.WebhookIncompleteUsage.WithLabelValues(cfg.name).Inc()
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, isStatus := err.(*statusError); err != nil && !isStatus {
+		if warning, exempt := exemption.warning(err, time.Now()); exempt {
+			logger.Info("Admission exceeding an exempt quota admitted",
+				zap.String("webhook", cfg.name),
+				zap.String("operation", op),
+				zap.String("kind", review.Request.Kind.Kind),
+				zap.String("namespace", review.Request.Namespace),
+				zap.String("name", review.Request.Name),
+				zap.String("crq_name", exemption.crq),
+				zap.Time("exempt_until", exemption.until),
+				zap.Bool("dry_run", dryRun),
+				zap.Error(err))
+			if !dryRun {
+				metrics.WebhookExemptedDenials.WithLabelValues(exemption.crq, cfg.name).Inc()
+			}
+			warnings = append(warnings, warning)
+			err = nil
+		}
+	}
 	if err != nil {
 		code := http.StatusForbidden
 		reason := "quota_exceeded"
+		// A failed lookup is no verdict on the request: answer 500 with a
+		// retry hint so clients retry instead of treating it as over quota.
+		retryAfter, transient := transientError(err)
 		if se, ok := err.(*statusError); ok {
 			code = se.code
-			if code == http.StatusBadRequest {
+			switch code {
+			case http.StatusBadRequest:
 				reason = "bad_request"
+			case http.StatusTooManyRequests:
+				reason = "rate_limited"
 			}
+		} else if transient {
+			code = http.StatusInternalServerError
+			reason = "transient_error"
 		}
 		logger.Info("Admission denied",
 			zap.String("webhook", cfg.name),
@@ -116,25 +267,70 @@ func runWebhook(c *gin.Context, logger *zap.Logger, cfg webhookConfig, validate
 			zap.String("namespace", review.Request.Namespace),
 			zap.String("name", review.Request.Name),
 			zap.Int("code", code),
+			zap.Bool("dry_run", dryRun),
 			zap.Error(err))
+		message := err.Error()
+		var violations *violationsError
+		errors.As(err, &violations)
+		if tmpl := denialTemplate(ctx); tmpl != nil && reason == "quota_exceeded" {
+			render := func(err error) string { return renderDenial(tmpl, ns, err) }
+			if violations != nil {
+				violations = violations.render(render)
+				message = violations.Error()
+			} else {
+				message = render(err)
+			}
+		}
 		review.Response.Allowed = false
 		review.Response.Result = &metav1.Status{
 			Code:    int32(code),
-			Message: err.Error(),
+			Message: message,
+		}
+		if reason == "transient_error" {
+			review.Response.Result.Reason = metav1.StatusReasonInternalError
+			review.Response.Result.Message = "quota could not be validated, retry the request: " + message
+			review.Response.Result.Details = &metav1.StatusDetails{RetryAfterSeconds: retryAfter}
+		}
+		if violations != nil {
+			review.Response.Result.Details = &metav1.StatusDetails{Causes: violations.causes()}
+		}
+		if !dryRun {
+			metrics.WebhookAdmissionDecision.WithLabelValues(cfg.name, op, "denied", ns).Inc()
+			metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, reason).Inc()
+			denials.report(ns)
 		}
-		metrics.WebhookAdmissionDecision.WithLabelValues(cfg.name, op, "denied", ns).Inc()
-		metrics.WebhookAdmissionDenied.WithLabelValues(cfg.name, reason).Inc()
 	} else {
 		review.Response.Allowed = true
 		if len(warnings) > 0 {
 			review.Response.Warnings = warnings
 		}
-		metrics.WebhookAdmissionDecision.WithLabelValues(cfg.name, op, "allowed", ns).Inc()
+		if len(patch) > 0 {
+			patchType := admissionv1.PatchTypeJSONPatch
+			review.Response.Patch = patch
+			review.Response.PatchType = &patchType
+		}
+		if !dryRun {
+			metrics.WebhookAdmissionDecision.WithLabelValues(cfg.name, op, "allowed", ns).Inc()
+		}
 	}
 
 	c.JSON(http.StatusOK, review)
 }
 
+type dryRunKey struct{}
+
+// withDryRun marks ctx as serving a dry-run admission request.
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun reports whether ctx serves a dry-run admission request, whose
+// validation must not change any state kept between requests.
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
 // decodeAdmissionObject decodes raw bytes into obj, returning a 400-coded
 // statusError on failure.
 func decodeAdmissionObject(raw []byte, into runtime.Object, kind string) error {
@@ -186,19 +382,67 @@ func validateAgainstCRQ(
 	if crq == nil {
 		return nil
 	}
-	return validateCRQStatusUsage(crq, resourceName, requested, logger, quota.GetCorrelationID(ctx))
+	return validateNamespaceUsage(ctx, crq, namespaceName, resourceName, requested, logger)
+}
+
+// validateNamespaceUsage is validateCRQStatusUsage for a request in namespace,
+// charging only the part of requested that the namespace's unused
+// quota.powerapp.cloud/reserve reservation does not cover: that part is
+// already counted in the status usage.
+func validateNamespaceUsage(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	namespace string,
+	resourceName corev1.ResourceName,
+	requested resource.Quantity,
+	logger *zap.Logger,
+) error {
+	correlationID := quota.GetCorrelationID(ctx)
+	charged := projection.Weigh(crq, resourceName, requested)
+	for _, ns := range crq.Status.Namespaces {
+		if ns.Namespace != namespace {
+			continue
+		}
+		if unused, ok := ns.Status.Reserved[resourceName]; ok {
+			charged.Sub(unused)
+		}
+		break
+	}
+	if charged.Sign() <= 0 {
+		logger.Debug("Request is covered by the namespace reservation",
+			zap.String("correlation_id", correlationID),
+			zap.String("namespace", namespace),
+			zap.String("resource", string(resourceName)),
+			zap.String("crq_name", crq.Name))
+		return nil
+	}
+	return validateChargedUsage(ctx, crq, resourceName, charged, logger)
 }
 
 // validateCRQStatusUsage compares an in-memory CRQ status against a request.
 // Split from validateAgainstCRQ so multi-resource handlers can resolve the
-// CRQ once. crq must be non-nil.
+// CRQ once. crq must be non-nil. An admitted request that takes usage past
+// the --webhook-warning-threshold adds an admission warning to ctx.
 func validateCRQStatusUsage(
+	ctx context.Context,
 	crq *quotav1alpha1.ClusterResourceQuota,
 	resourceName corev1.ResourceName,
 	requested resource.Quantity,
 	logger *zap.Logger,
-	correlationID string,
 ) error {
+	return validateChargedUsage(ctx, crq, resourceName, projection.Weigh(crq, resourceName, requested), logger)
+}
+
+// validateChargedUsage is validateCRQStatusUsage for a request already
+// weighted by spec.usageWeights, in the units of the status usage.
+func validateChargedUsage(
+	ctx context.Context,
+	crq *quotav1alpha1.ClusterResourceQuota,
+	resourceName corev1.ResourceName,
+	requested resource.Quantity,
+	logger *zap.Logger,
+) error {
+	correlationID := quota.GetCorrelationID(ctx)
 	quotaLimit, exists := crq.Spec.Hard[resourceName]
 	if !exists {
 		logger.Debug("No quota limit defined for resource, allowing operation",
@@ -207,6 +451,14 @@ func validateCRQStatusUsage(
 			zap.String("crq_name", crq.Name))
 		return nil
 	}
+	if !enforced(crq, resourceName) {
+		logger.Debug("Quota limit is not enforced for resource, allowing operation",
+			zap.String("correlation_id", correlationID),
+			zap.String("resource", string(resourceName)),
+			zap.String("enforcement", string(crq.Spec.Enforcement(resourceName))),
+			zap.String("crq_name", crq.Name))
+		return nil
+	}
 
 	currentUsage, ok := crq.Status.Total.Used[resourceName]
 	if !ok {
@@ -219,8 +471,20 @@ func validateCRQStatusUsage(
 		return nil
 	}
 
-	totalUsage := currentUsage.DeepCopy()
-	totalUsage.Add(requested)
+	projected := projection.Project(resourceName, currentUsage, requested, quotaLimit)
+	totalUsage := projected.Total()
+
+	if err := validateFederatedUsage(crq, resourceName, requested, totalUsage, quotaLimit); err != nil {
+		logger.Info("Federated resource quota would be exceeded",
+			zap.String("correlation_id", correlationID),
+			zap.String("resource", string(resourceName)),
+			zap.String("crq_name", crq.Name),
+			zap.Error(err))
+		exceeded := &quotaExceededError{crq: crq.Name, resource: resourceName, requested: requested,
+			used: currentUsage, hard: quotaLimit, msg: err.Error()}
+		noteDenial(ctx, exceeded)
+		return exceeded
+	}
 
 	logger.Debug("Quota validation check",
 		zap.String("correlation_id", correlationID),
@@ -231,7 +495,7 @@ func validateCRQStatusUsage(
 		zap.String("quota_limit", quotaLimit.String()),
 		zap.String("crq_name", crq.Name))
 
-	if totalUsage.Cmp(quotaLimit) > 0 {
+	if projected.Exceeds() {
 		logger.Info("Resource quota would be exceeded",
 			zap.String("correlation_id", correlationID),
 			zap.String("resource", string(resourceName)),
@@ -241,13 +505,31 @@ func validateCRQStatusUsage(
 			zap.String("quota_limit", quotaLimit.String()),
 			zap.String("crq_name", crq.Name))
 
-		return fmt.Errorf(
-			"ClusterResourceQuota '%s' %s limit exceeded: requested %s, current usage %s, "+
-				"quota limit %s, total would be %s",
-			crq.Name, resourceName, requested.String(), currentUsage.String(),
-			quotaLimit.String(), totalUsage.String())
+		exceeded := &quotaExceededError{crq: crq.Name, resource: resourceName, requested: requested,
+			used: currentUsage, hard: quotaLimit, msg: fmt.Sprintf(
+				"ClusterResourceQuota '%s' %s limit exceeded: requested %s, current usage %s, "+
+					"quota limit %s, total would be %s",
+				crq.Name, resourceName, requested.String(), currentUsage.String(),
+				quotaLimit.String(), totalUsage.String())}
+		noteDenial(ctx, exceeded)
+		return exceeded
+	}
+
+	// The request fits the counted usage, but that is only a lower bound for a
+	// resource IncompleteUsage lists: the API server's failurePolicy decides.
+	condition := meta.FindStatusCondition(crq.Status.Conditions, quotav1alpha1.ConditionIncompleteUsage)
+	if condition != nil && condition.Status == metav1.ConditionTrue && usageIncomplete(crq, resourceName) {
+		logger.Info("CRQ usage is incomplete - leaving the decision to the failure policy",
+			zap.String("correlation_id", correlationID),
+			zap.String("resource", string(resourceName)),
+			zap.String("crq_name", crq.Name),
+			zap.String("reason", condition.Reason))
+		return &incompleteUsageError{msg: fmt.Sprintf(
+			"ClusterResourceQuota '%s' usage is incomplete, cannot validate %s %s: %s",
+			crq.Name, requested.String(), resourceName, condition.Message)}
 	}
 
+	warnNearQuota(ctx, crq, resourceName, totalUsage, quotaLimit)
 	logger.Debug("CRQ validation passed",
 		zap.String("correlation_id", correlationID),
 		zap.String("resource", string(resourceName)),
@@ -256,8 +538,70 @@ func validateCRQStatusUsage(
 	return nil
 }
 
+// usageIncomplete reports whether the usage of resourceName in crq's status is
+// only a lower bound. A status written before status.incompleteResources
+// existed lists nothing, so every resource is taken as incomplete.
+func usageIncomplete(crq *quotav1alpha1.ClusterResourceQuota, resourceName corev1.ResourceName) bool {
+	return len(crq.Status.IncompleteResources) == 0 || slices.Contains(crq.Status.IncompleteResources, resourceName)
+}
+
+// enforced reports whether crq denies requests exceeding its resourceName
+// limit, i.e. spec.enforcementPolicy leaves the key at Enforce.
+func enforced(crq *quotav1alpha1.ClusterResourceQuota, resourceName corev1.ResourceName) bool {
+	return crq.Spec.Enforcement(resourceName) == quotav1alpha1.EnforcementEnforce
+}
+
+// validateFederatedUsage applies the limits of a federated CRQ: this
+// cluster's slice, and the hard limit against the usage of every cluster.
+// clusterUsage is this cluster's usage with the request added. Usage reported
+// by other clusters comes from the CRQ status, so it is as fresh as the last
+// exchange with the hub. Reports older than spec.federation.reportTTL are left
+// out: their cluster stopped reporting, and its usage may be long gone.
+func validateFederatedUsage(
+	crq *quotav1alpha1.ClusterResourceQuota,
+	resourceName corev1.ResourceName,
+	requested, clusterUsage, quotaLimit resource.Quantity,
+) error {
+	if crq.Spec.Federation == nil || crq.Status.Federation == nil {
+		return nil
+	}
+	cluster := crq.Status.Federation.Cluster
+
+	if slice, ok := crq.Spec.Federation.Slices[cluster][resourceName]; ok && clusterUsage.Cmp(slice) > 0 {
+		return fmt.Errorf(
+			"ClusterResourceQuota '%s' %s slice for cluster '%s' exceeded: requested %s, "+
+				"cluster usage would be %s, slice %s",
+			crq.Name, resourceName, cluster, requested.String(), clusterUsage.String(), slice.String())
+	}
+
+	var remoteUsage resource.Quantity
+	ttl, now := crq.Spec.Federation.EffectiveReportTTL(), time.Now()
+	for _, reported := range crq.Status.Federation.Clusters {
+		if reported.Name == cluster || reported.Stale(ttl, now) {
+			continue
+		}
+		if used, ok := reported.Used[resourceName]; ok {
+			remoteUsage.Add(used)
+		}
+	}
+	if remoteUsage.IsZero() {
+		return nil
+	}
+	global := projection.Project(resourceName, clusterUsage, remoteUsage, quotaLimit)
+	if global.Exceeds() {
+		globalUsage := global.Total()
+		return fmt.Errorf(
+			"ClusterResourceQuota '%s' %s limit exceeded across clusters: requested %s, "+
+				"usage in other clusters %s, quota limit %s, total would be %s",
+			crq.Name, resourceName, requested.String(), remoteUsage.String(),
+			quotaLimit.String(), globalUsage.String())
+	}
+	return nil
+}
+
 // resolveCRQForNamespace returns the matching CRQ from the cache or nil on
-// any miss/error (fail-open). Lookup outcomes are tracked via WebhookCRQLookup.
+// any miss/error (fail-open) or when the CRQ is paused. Lookup outcomes are
+// tracked via WebhookCRQLookup.
 func resolveCRQForNamespace(
 	ctx context.Context,
 	crqClient *quota.CRQClient,
@@ -300,7 +644,16 @@ func resolveCRQForNamespace(
 		metrics.WebhookCRQLookup.WithLabelValues("not_found").Inc()
 		return nil
 	}
+	if crq.Paused() {
+		logger.Debug("CRQ is paused - allowing operation",
+			zap.String("correlation_id", correlationID),
+			zap.String("namespace", ns.Name),
+			zap.String("crq_name", crq.Name))
+		metrics.WebhookCRQLookup.WithLabelValues("paused").Inc()
+		return nil
+	}
 
 	metrics.WebhookCRQLookup.WithLabelValues("found").Inc()
+	noteExemption(ctx, crq)
 	return crq
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo/v2"
@@ -17,6 +19,7 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -130,6 +133,27 @@ var _ = Describe("runWebhook", func() {
 		Expect(resp.Response.Allowed).To(BeFalse())
 		Expect(resp.Response.Result.Code).To(Equal(int32(http.StatusForbidden)))
 	})
+
+	It("fails the call when usage is incomplete so the failure policy decides", func() {
+		engine.POST("/webhook", func(c *gin.Context) {
+			runWebhook(c, logger, webhookConfig{name: "incomplete", requireNamespace: true},
+				func(context.Context, *admissionv1.AdmissionRequest) ([]string, error) {
+					return nil, &incompleteUsageError{msg: "usage is incomplete"}
+				})
+		})
+		body, _ := json.Marshal(admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{
+				UID: "1", Operation: admissionv1.Create, Namespace: "ns",
+			},
+		})
+		failed := metrics.WebhookIncompleteUsage.WithLabelValues("incomplete")
+		before := promtestutil.ToFloat64(failed)
+
+		code, resp := postReview(engine, body)
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Response).To(BeNil())
+		Expect(promtestutil.ToFloat64(failed) - before).To(Equal(float64(1)))
+	})
 })
 
 var _ = Describe("WebhookAdmissionDenied reason emission", func() {
@@ -179,6 +203,48 @@ var _ = Describe("WebhookAdmissionDenied reason emission", func() {
 		Expect(delta).To(Equal(float64(1)))
 	})
 
+	It("labels rate_limited when the validator returns a statusError with code 429", func() {
+		engine.POST("/webhook", func(c *gin.Context) {
+			runWebhook(c, logger, webhookConfig{name: "t", requireNamespace: true},
+				func(context.Context, *admissionv1.AdmissionRequest) ([]string, error) {
+					return nil, newStatusErrorf(http.StatusTooManyRequests, "creation rate exceeded")
+				})
+		})
+		body, _ := json.Marshal(admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{UID: "1", Operation: admissionv1.Create, Namespace: "ns"},
+		})
+		var code int32
+		delta := deltaFor("rate_limited", func() {
+			_, resp := postReview(engine, body)
+			code = resp.Response.Result.Code
+		})
+		Expect(delta).To(Equal(float64(1)))
+		Expect(code).To(Equal(int32(http.StatusTooManyRequests)))
+	})
+
+	It("labels transient_error and asks for a retry when an API server lookup fails", func() {
+		lookupErr := apierrors.NewTooManyRequests("the server is overloaded", 5)
+		engine.POST("/webhook", func(c *gin.Context) {
+			runWebhook(c, logger, webhookConfig{name: "t", requireNamespace: true},
+				func(context.Context, *admissionv1.AdmissionRequest) ([]string, error) {
+					return nil, fmt.Errorf("failed to list CRQs: %w", lookupErr)
+				})
+		})
+		body, _ := json.Marshal(admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{UID: "1", Operation: admissionv1.Create, Namespace: "ns"},
+		})
+		var result *metav1.Status
+		delta := deltaFor("transient_error", func() {
+			_, resp := postReview(engine, body)
+			result = resp.Response.Result
+		})
+		Expect(delta).To(Equal(float64(1)))
+		Expect(result.Code).To(Equal(int32(http.StatusInternalServerError)))
+		Expect(result.Reason).To(Equal(metav1.StatusReasonInternalError))
+		Expect(result.Message).To(HavePrefix("quota could not be validated, retry the request: "))
+		Expect(result.Details.RetryAfterSeconds).To(Equal(int32(5)))
+	})
+
 	It("labels gvk_mismatch on the early GVK-check denial path", func() {
 		expected := metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
 		engine.POST("/webhook", func(c *gin.Context) {
@@ -208,6 +274,41 @@ var _ = Describe("WebhookAdmissionDenied reason emission", func() {
 	})
 })
 
+var _ = Describe("dry-run admission requests", func() {
+	var engine *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		engine = gin.New()
+	})
+
+	It("are validated without touching the admission metrics", func() {
+		var sawDryRun bool
+		engine.POST("/webhook", func(c *gin.Context) {
+			runWebhook(c, zap.NewNop(), webhookConfig{name: "dry", requireNamespace: true},
+				func(ctx context.Context, _ *admissionv1.AdmissionRequest) ([]string, error) {
+					sawDryRun = isDryRun(ctx)
+					return nil, fmt.Errorf("quota exceeded")
+				})
+		})
+		dryRun := true
+		body, _ := json.Marshal(admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{
+				UID: "1", Operation: admissionv1.Create, Namespace: "ns", DryRun: &dryRun,
+			},
+		})
+		denied := metrics.WebhookAdmissionDecision.WithLabelValues("dry", "CREATE", "denied", "ns")
+		validations := metrics.WebhookValidationCount.WithLabelValues("dry", "CREATE", "ns")
+
+		_, resp := postReview(engine, body)
+
+		Expect(resp.Response.Allowed).To(BeFalse())
+		Expect(sawDryRun).To(BeTrue())
+		Expect(promtestutil.ToFloat64(denied)).To(BeZero())
+		Expect(promtestutil.ToFloat64(validations)).To(BeZero())
+	})
+})
+
 var _ = Describe("logValidationPassed", func() {
 	It("emits a Debug entry with the standard fields and any extras", func() {
 		core, recorded := observer.New(zapcore.DebugLevel)
@@ -393,15 +494,59 @@ var _ = Describe("resolveCRQForNamespace", func() {
 	})
 })
 
+var _ = Describe("validateNamespaceUsage", func() {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	// reservedCRQ is full, with 2 of its cpu reserved and unused in "planned".
+	reservedCRQ := func() *quotav1alpha1.ClusterResourceQuota {
+		crq := makeCRQ("c", nil,
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("4")},
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("4")},
+		)
+		crq.Status.Namespaces = []quotav1alpha1.ResourceQuotaStatusByNamespace{{
+			Namespace: "planned",
+			Status: quotav1alpha1.ResourceQuotaStatus{
+				Used:     quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("3")},
+				Reserved: quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
+			},
+		}}
+		return crq
+	}
+
+	It("admits requests covered by the namespace's unused reservation", func() {
+		Expect(validateNamespaceUsage(ctx, reservedCRQ(), "planned", corev1.ResourceCPU, quantity("2"), logger)).
+			To(Succeed())
+	})
+
+	It("charges the part of a request beyond the reservation", func() {
+		err := validateNamespaceUsage(ctx, reservedCRQ(), "planned", corev1.ResourceCPU, quantity("3"), logger)
+		Expect(err).To(MatchError(ContainSubstring("limit exceeded")))
+	})
+
+	It("does not credit other namespaces' reservations", func() {
+		err := validateNamespaceUsage(ctx, reservedCRQ(), "other", corev1.ResourceCPU, quantity("1"), logger)
+		Expect(err).To(MatchError(ContainSubstring("limit exceeded")))
+	})
+
+	It("weighs the request before crediting the reservation", func() {
+		crq := reservedCRQ()
+		crq.Spec.UsageWeights = quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("500m")}
+		Expect(validateNamespaceUsage(ctx, crq, "planned", corev1.ResourceCPU, quantity("4"), logger)).
+			To(Succeed())
+	})
+})
+
 var _ = Describe("validateCRQStatusUsage", func() {
 	logger := zap.NewNop()
+	ctx := context.Background()
 
 	It("returns nil when the resource is not in spec.hard", func() {
 		crq := makeCRQ("c", nil,
 			quotav1alpha1.ResourceList{corev1.ResourceMemory: quantity("1Gi")},
 			quotav1alpha1.ResourceList{corev1.ResourceMemory: quantity("0")},
 		)
-		Expect(validateCRQStatusUsage(crq, corev1.ResourceCPU, quantity("1"), logger, "")).To(Succeed())
+		Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
 	})
 
 	It("returns nil (fail-open) when status is missing the resource", func() {
@@ -409,7 +554,7 @@ var _ = Describe("validateCRQStatusUsage", func() {
 			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
 			quotav1alpha1.ResourceList{corev1.ResourceMemory: quantity("0")},
 		)
-		Expect(validateCRQStatusUsage(crq, corev1.ResourceCPU, quantity("1"), logger, "")).To(Succeed())
+		Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
 	})
 
 	It("returns an error when over the hard limit", func() {
@@ -417,16 +562,148 @@ var _ = Describe("validateCRQStatusUsage", func() {
 			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
 			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
 		)
-		err := validateCRQStatusUsage(crq, corev1.ResourceCPU, quantity("1"), logger, "")
+		err := validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("limit exceeded"))
 	})
 
+	It("charges the request weighted by spec.usageWeights", func() {
+		crq := makeCRQ("c", nil,
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("4")},
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
+		)
+		crq.Spec.UsageWeights = quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("500m")}
+		Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("4"), logger)).To(Succeed())
+		err := validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("5"), logger)
+		Expect(err).To(MatchError(ContainSubstring("requested 2500m")))
+	})
+
+	It("admits usage over the limit for a key that is only reported", func() {
+		crq := makeCRQ("c", nil,
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
+			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
+		)
+		crq.Spec.EnforcementPolicy = map[corev1.ResourceName]quotav1alpha1.EnforcementAction{
+			corev1.ResourceCPU: quotav1alpha1.EnforcementReportOnly,
+		}
+		Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
+	})
+
+	Context("while the usage is incomplete", func() {
+		newIncompleteCRQ := func(used string) *quotav1alpha1.ClusterResourceQuota {
+			crq := makeCRQ("c", nil,
+				quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("2")},
+				quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity(used)},
+			)
+			crq.Status.Conditions = []metav1.Condition{{
+				Type:    quotav1alpha1.ConditionIncompleteUsage,
+				Status:  metav1.ConditionTrue,
+				Reason:  "CalculationFailed",
+				Message: "Usage could not be fully counted for cpu: boom",
+			}}
+			return crq
+		}
+
+		It("leaves a request that fits the counted usage to the failure policy", func() {
+			err := validateCRQStatusUsage(ctx, newIncompleteCRQ("1"), corev1.ResourceCPU, quantity("1"), logger)
+			var incomplete *incompleteUsageError
+			Expect(errors.As(err, &incomplete)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("usage is incomplete"))
+			Expect(err.Error()).To(ContainSubstring("boom"))
+		})
+
+		It("denies a request the counted usage already rules out", func() {
+			err := validateCRQStatusUsage(ctx, newIncompleteCRQ("2"), corev1.ResourceCPU, quantity("1"), logger)
+			Expect(err).To(MatchError(ContainSubstring("limit exceeded")))
+			var incomplete *incompleteUsageError
+			Expect(errors.As(err, &incomplete)).To(BeFalse())
+		})
+
+		It("validates normally once the condition is False", func() {
+			crq := newIncompleteCRQ("1")
+			crq.Status.Conditions[0].Status = metav1.ConditionFalse
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
+		})
+
+		It("validates resources the status does not list as incomplete", func() {
+			crq := newIncompleteCRQ("1")
+			crq.Status.IncompleteResources = []corev1.ResourceName{"congigmaps"}
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
+
+			crq.Status.IncompleteResources = append(crq.Status.IncompleteResources, corev1.ResourceCPU)
+			var incomplete *incompleteUsageError
+			Expect(errors.As(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger),
+				&incomplete)).To(BeTrue())
+		})
+	})
+
 	It("returns nil when zero usage + requested exactly equals limit", func() {
 		crq := makeCRQ("c", nil,
 			quotav1alpha1.ResourceList{corev1.ResourceCPU: quantity("1")},
 			quotav1alpha1.ResourceList{corev1.ResourceCPU: *resource.NewQuantity(0, resource.DecimalSI)},
 		)
-		Expect(validateCRQStatusUsage(crq, corev1.ResourceCPU, quantity("1"), logger, "")).To(Succeed())
+		Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceCPU, quantity("1"), logger)).To(Succeed())
+	})
+
+	Context("with a federated quota", func() {
+		newFederatedCRQ := func(local string, slices map[string]quotav1alpha1.ResourceList) *quotav1alpha1.ClusterResourceQuota {
+			crq := makeCRQ("shared", nil,
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("10")},
+				quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity(local)},
+			)
+			crq.Spec.Federation = &quotav1alpha1.FederationSpec{Slices: slices}
+			crq.Status.Federation = &quotav1alpha1.FederationStatus{
+				Cluster: "us-east",
+				Clusters: []quotav1alpha1.ClusterUsage{
+					{
+						Name:           "us-east",
+						Used:           quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity(local)},
+						LastReportTime: metav1.Now(),
+					},
+					{
+						Name:           "us-west",
+						Used:           quotav1alpha1.ResourceList{corev1.ResourceRequestsCPU: quantity("6")},
+						LastReportTime: metav1.Now(),
+					},
+				},
+			}
+			return crq
+		}
+
+		It("counts the usage reported by other clusters", func() {
+			crq := newFederatedCRQ("3", nil)
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("1"), logger)).To(Succeed())
+
+			err := validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("2"), logger)
+			Expect(err).To(MatchError(ContainSubstring("limit exceeded across clusters")))
+			Expect(err.Error()).To(ContainSubstring("usage in other clusters 6"))
+		})
+
+		It("enforces this cluster's slice", func() {
+			crq := newFederatedCRQ("1", map[string]quotav1alpha1.ResourceList{
+				"us-east": {corev1.ResourceRequestsCPU: quantity("2")},
+				"us-west": {corev1.ResourceRequestsCPU: quantity("8")},
+			})
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("1"), logger)).To(Succeed())
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("2"), logger)).To(
+				MatchError(ContainSubstring("slice for cluster 'us-east' exceeded")))
+		})
+
+		It("leaves out clusters that stopped reporting", func() {
+			crq := newFederatedCRQ("3", nil)
+			crq.Status.Federation.Clusters[1].LastReportTime = metav1.NewTime(
+				time.Now().Add(-quotav1alpha1.DefaultFederationReportTTL - time.Minute))
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("2"), logger)).To(Succeed())
+
+			crq.Spec.Federation.ReportTTL = &metav1.Duration{Duration: time.Hour}
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("2"), logger)).To(
+				MatchError(ContainSubstring("limit exceeded across clusters")))
+		})
+
+		It("ignores the federation status when the spec is not federated", func() {
+			crq := newFederatedCRQ("3", nil)
+			crq.Spec.Federation = nil
+			Expect(validateCRQStatusUsage(ctx, crq, corev1.ResourceRequestsCPU, quantity("2"), logger)).To(Succeed())
+		})
 	})
 })
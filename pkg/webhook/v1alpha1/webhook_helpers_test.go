@@ -1,74 +1,39 @@
 package v1alpha1
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"net/http"
-	"net/http/httptest"
 
 	"github.com/gin-gonic/gin"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
 	"github.com/powerhome/pac-quota-controller/pkg/kubernetes/quota"
-	"github.com/powerhome/pac-quota-controller/pkg/logger"
+	"github.com/powerhome/pac-quota-controller/pkg/testing/fixtures"
 )
 
 var errFakeList = errors.New("fake list error")
 
 // sendWebhookRequest drives the gin engine via HTTP round-trip and decodes the AdmissionReview.
 func sendWebhookRequest(engine *gin.Engine, admissionReview *admissionv1.AdmissionReview) *admissionv1.AdmissionReview {
-	body, _ := json.Marshal(admissionReview)
-	req, _ := http.NewRequest("POST", "/webhook", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	engine.ServeHTTP(w, req)
-
-	var response admissionv1.AdmissionReview
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		response = admissionv1.AdmissionReview{
-			Response: &admissionv1.AdmissionResponse{
-				Allowed: false,
-				Result: &metav1.Status{
-					Message: "Failed to parse response",
-				},
-			},
-		}
-	}
-	return &response
-}
-
-// testScheme returns a scheme registered with CRQ + corev1.
-func testScheme() *runtime.Scheme {
-	s := runtime.NewScheme()
-	_ = quotav1alpha1.AddToScheme(s)
-	_ = corev1.AddToScheme(s)
-	return s
+	return fixtures.Post(engine, "/webhook", admissionReview)
 }
 
 // newTestCRQClient builds an in-memory CRQClient seeded with the given objects.
 func newTestCRQClient(objs ...ctrlclient.Object) *quota.CRQClient {
-	cl := ctrlclientfake.NewClientBuilder().
-		WithScheme(testScheme()).
-		WithObjects(objs...).
-		Build()
-	return quota.NewCRQClient(cl, logger.L())
+	return fixtures.NewCRQClient(objs...)
 }
 
 // newTestCRQClientWithListError returns a CRQClient whose List operations fail (exercises CRQ-lookup fail-open).
 func newTestCRQClientWithListError(seed ...ctrlclient.Object) *quota.CRQClient {
 	cl := ctrlclientfake.NewClientBuilder().
-		WithScheme(testScheme()).
+		WithScheme(fixtures.Scheme()).
 		WithObjects(seed...).
 		WithInterceptorFuncs(interceptor.Funcs{
 			List: func(_ context.Context, _ ctrlclient.WithWatch, _ ctrlclient.ObjectList, _ ...ctrlclient.ListOption) error {
@@ -76,7 +41,7 @@ func newTestCRQClientWithListError(seed ...ctrlclient.Object) *quota.CRQClient {
 			},
 		}).
 		Build()
-	return quota.NewCRQClient(cl, logger.L())
+	return quota.NewCRQClient(cl, nil)
 }
 
 // makeNamespace returns a Namespace object with the requested labels.
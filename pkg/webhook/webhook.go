@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"crypto/tls"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -13,15 +14,29 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// SetupGinWebhookServer configures the Gin-based webhook server with certificate watching
+// SetupGinWebhookServer configures the Gin-based webhook server with certificate watching.
+// Certificate problems degrade the server to plain HTTP, except when a client CA
+// is configured: verification is then impossible, so the error is returned.
+// With --webhook-insecure no certificates are loaded at all.
 func SetupGinWebhookServer(
 	cfg *config.Config,
 	k8sClient kubernetes.Interface,
 	runtimeClient client.Client,
 	log *zap.Logger,
-) (*server.GinWebhookServer, *certwatcher.CertWatcher) {
+) (*server.GinWebhookServer, *certwatcher.CertWatcher, error) {
 	// Create the Gin webhook server
 	webhookServer := server.NewGinWebhookServer(cfg, k8sClient, runtimeClient, log)
+	if cfg.WebhookInsecure {
+		log.Warn("Serving webhooks over plain HTTP: --webhook-insecure is set")
+		return webhookServer, nil, nil
+	}
+
+	// Load the client CA before TLS is configured so the handshake verifies
+	// kube-apiserver client certificates.
+	if err := webhookServer.SetupClientCA(cfg); err != nil {
+		return nil, nil, err
+	}
+	requireTLS := cfg.WebhookClientCAFile != ""
 
 	// Setup certificate watcher if certificates are provided
 	if len(cfg.WebhookCertPath) > 0 {
@@ -35,28 +50,42 @@ func SetupGinWebhookServer(
 		keyFile := filepath.Join(cfg.WebhookCertPath, cfg.WebhookCertKey)
 
 		if !isValidCertificatePair(certFile, keyFile, log) {
+			if requireTLS {
+				return nil, nil, fmt.Errorf("webhook client CA is set but the certificate pair in %s is not valid",
+					cfg.WebhookCertPath)
+			}
 			log.Info("Certificate files are not valid or don't exist - continuing without certificate watcher")
-			return webhookServer, nil
+			return webhookServer, nil, nil
 		}
 
 		var err error
 		webhookCertWatcher, err := certwatcher.NewCertWatcher(certFile, keyFile, log)
 		if err != nil {
+			if requireTLS {
+				return nil, nil, fmt.Errorf("failed to initialize webhook certificate watcher: %w", err)
+			}
 			log.Error("Failed to initialize webhook certificate watcher", zap.Error(err))
 			log.Info("Continuing without certificate watcher - server will run without TLS")
-			return webhookServer, nil
+			return webhookServer, nil, nil
 		}
 
 		// Configure the server with certificate watcher
 		if err := webhookServer.SetupCertificateWatcher(cfg); err != nil {
+			if requireTLS {
+				return nil, nil, err
+			}
 			log.Error("Failed to setup certificate watcher", zap.Error(err))
 		}
 
-		return webhookServer, webhookCertWatcher
+		return webhookServer, webhookCertWatcher, nil
+	}
+
+	if requireTLS {
+		return nil, nil, fmt.Errorf("webhook client CA is set but no certificate path is configured")
 	}
 
 	// No certificates provided, return server without certificate watcher
-	return webhookServer, nil
+	return webhookServer, nil, nil
 }
 
 // isValidCertificatePair checks if the certificate and key files exist and are valid
@@ -1,6 +1,13 @@
 package webhook
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"os"
 	"path/filepath"
 	"time"
@@ -58,7 +65,8 @@ var _ = Describe("Webhook", func() {
 
 	Describe("SetupGinWebhookServer", func() {
 		It("should setup webhook server without certificates", func() {
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
@@ -76,18 +84,31 @@ var _ = Describe("Webhook", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			cfg.WebhookCertPath = tempDir
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			// Certificate watcher should be nil since dummy files can't be decoded
 			Expect(certWatcher).To(BeNil())
 		})
 
+		It("serves plain HTTP with --webhook-insecure even with certificates configured", func() {
+			cfg.WebhookCertPath = tempDir
+			cfg.WebhookInsecure = true
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server).NotTo(BeNil())
+			Expect(certWatcher).To(BeNil())
+			Expect(server.GetCertWatcher()).To(BeNil())
+		})
+
 		It("should handle certificate watcher setup failure", func() {
 			// Set certificate path to non-existent directory
 			cfg.WebhookCertPath = "/non/existent/path"
 
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
@@ -95,21 +116,24 @@ var _ = Describe("Webhook", func() {
 
 		It("should handle debug log level", func() {
 			cfg.LogLevel = debugLevel
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
 		})
 
 		It("should handle nil client", func() {
-			server, certWatcher := SetupGinWebhookServer(cfg, nil, nil, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, nil, nil, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
 		})
 
 		It("should handle nil logger", func() {
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, nil)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, nil)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
@@ -117,7 +141,8 @@ var _ = Describe("Webhook", func() {
 
 		It("should handle empty certificate path", func() {
 			cfg.WebhookCertPath = ""
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
@@ -136,14 +161,32 @@ var _ = Describe("Webhook", func() {
 
 			cfg.WebhookCertPath = tempDir
 
-			server, certWatcher := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, certWatcher, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 			Expect(certWatcher).To(BeNil())
 		})
 
+		It("should fail when the client CA file cannot be loaded", func() {
+			cfg.WebhookClientCAFile = filepath.Join(tempDir, "missing-ca.crt")
+
+			_, _, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			Expect(err).To(MatchError(ContainSubstring("failed to read webhook client CA file")))
+		})
+
+		It("should fail when a client CA is set but the server would run without TLS", func() {
+			cfg.WebhookClientCAFile = writeTestCA(tempDir)
+
+			_, _, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+
+			Expect(err).To(MatchError(ContainSubstring("no certificate path is configured")))
+		})
+
 		It("should configure webhook initialization with proper timing", func() {
-			server, _ := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			server, _, err := SetupGinWebhookServer(cfg, fakeClient, fakeRuntimeClient, logger)
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(server).NotTo(BeNil())
 
@@ -158,3 +201,23 @@ var _ = Describe("Webhook", func() {
 		})
 	})
 })
+
+// writeTestCA writes a self-signed CA certificate to dir and returns its path.
+func writeTestCA(dir string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	caFile := filepath.Join(dir, "ca.crt")
+	Expect(os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	return caFile
+}
@@ -0,0 +1,133 @@
+package conformance
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	testutils "github.com/powerhome/pac-quota-controller/test/utils"
+)
+
+const (
+	settleTimeout  = 60 * time.Second
+	settleInterval = 500 * time.Millisecond
+)
+
+var _ = Describe("ResourceQuota conformance", func() {
+	for _, sc := range scenarios {
+		It(sc.name, func() {
+			run(sc)
+		})
+	}
+})
+
+// run creates each step's object under a ResourceQuota and under a CRQ, and
+// checks after every step that both decided it alike and agree on usage.
+func run(sc scenario) {
+	suffix := testutils.GenerateTestSuffix()
+	nativeNS := createNamespace("conformance-rq-"+suffix, nil)
+	crqLabels := map[string]string{"conformance-test": suffix}
+	crqNS := createNamespace("conformance-crq-"+suffix, crqLabels)
+
+	rq := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance", Namespace: nativeNS},
+		Spec:       corev1.ResourceQuotaSpec{Hard: sc.hard},
+	}
+	Expect(k8sClient.Create(ctx, rq)).To(Succeed())
+	crqName := testutils.GenerateResourceName("conformance-crq-" + suffix)
+	crq, err := testutils.CreateClusterResourceQuota(ctx, k8sClient, crqName,
+		&metav1.LabelSelector{MatchLabels: crqLabels}, quotav1alpha1.ResourceList(sc.hard))
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() { _ = k8sClient.Delete(ctx, crq) })
+
+	By("waiting for both quotas to report usage")
+	expectUsageToAgree(sc, nativeNS, crqName)
+
+	for _, st := range sc.steps {
+		By(st.name)
+		nativeDenied := create(st.object(nativeNS)) != nil
+		Expect(nativeDenied).To(Equal(st.denied), "ResourceQuota decided %q unexpectedly", st.name)
+
+		expectDenied := nativeDenied
+		if d, ok := sc.admissionDivergence(st.name); ok {
+			By(fmt.Sprintf("expecting the documented divergence: %s", d.Reason))
+			expectDenied = !expectDenied
+		}
+		Expect(createExpecting(st.object(crqNS), expectDenied)).To(Equal(expectDenied),
+			"ClusterResourceQuota decided %q unlike ResourceQuota", st.name)
+
+		expectUsageToAgree(sc, nativeNS, crqName)
+	}
+}
+
+// createNamespace creates a namespace cleaned up after the spec.
+func createNamespace(prefix string, labels map[string]string) string {
+	name := testutils.GenerateResourceName(prefix)
+	ns, err := testutils.CreateNamespace(ctx, k8sClient, name, labels)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() { _ = k8sClient.Delete(ctx, ns) })
+	return name
+}
+
+// create creates obj and returns its admission error, failing the spec on any
+// other error.
+func create(obj client.Object) error {
+	err := k8sClient.Create(ctx, obj)
+	if err != nil {
+		Expect(apierrors.IsForbidden(err)).To(BeTrue(), "unexpected error creating %s: %v", obj.GetName(), err)
+	}
+	return err
+}
+
+// createExpecting creates obj under the CRQ and reports whether it was
+// denied. A denial is retried for a while, as the webhook's view of usage may
+// lag the quota status; any object admitted meanwhile is deleted.
+func createExpecting(obj client.Object, denied bool) bool {
+	if !denied {
+		return create(obj) != nil
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		attempt := obj.DeepCopyObject().(client.Object)
+		if create(attempt) != nil {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		_ = k8sClient.Delete(ctx, attempt)
+		Eventually(func() bool {
+			return apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(attempt), attempt))
+		}, settleTimeout, settleInterval).Should(BeTrue())
+		time.Sleep(settleInterval)
+	}
+}
+
+// expectUsageToAgree waits until the CRQ reports, for every hard limit, the
+// usage the ResourceQuota reports, except where a UsageDivergence applies.
+func expectUsageToAgree(sc scenario, nativeNS, crqName string) {
+	Eventually(func(g Gomega) {
+		rq := &corev1.ResourceQuota{}
+		g.Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: nativeNS, Name: "conformance"}, rq)).To(Succeed())
+		crq := &quotav1alpha1.ClusterResourceQuota{}
+		g.Expect(k8sClient.Get(ctx, client.ObjectKey{Name: crqName}, crq)).To(Succeed())
+		for name := range sc.hard {
+			if !sc.comparesUsage(name) {
+				continue
+			}
+			native, ok := rq.Status.Used[name]
+			g.Expect(ok).To(BeTrue(), "ResourceQuota reports no usage of %s", name)
+			used, ok := crq.Status.Total.Used[name]
+			g.Expect(ok).To(BeTrue(), "ClusterResourceQuota reports no usage of %s", name)
+			g.Expect(used.Cmp(native)).To(BeZero(),
+				"usage of %s: ClusterResourceQuota %s, ResourceQuota %s", name, used.String(), native.String())
+		}
+	}).WithTimeout(settleTimeout).WithPolling(settleInterval).Should(Succeed())
+}
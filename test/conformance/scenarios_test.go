@@ -0,0 +1,216 @@
+package conformance
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const pauseImage = "registry.k8s.io/pause:3.10"
+
+// DivergenceKind names what a Divergence exempts from the comparison.
+type DivergenceKind string
+
+const (
+	// AdmissionDivergence expects the CRQ to decide a step the other way.
+	AdmissionDivergence DivergenceKind = "Admission"
+	// UsageDivergence skips comparing one resource's usage.
+	UsageDivergence DivergenceKind = "Usage"
+)
+
+// Divergence documents a known, intended difference from ResourceQuota. An
+// AdmissionDivergence is asserted, not just tolerated, so the suite fails once
+// the behaviors agree again and the exception can be dropped.
+type Divergence struct {
+	Kind DivergenceKind
+	// Step is the step an AdmissionDivergence applies to.
+	Step string
+	// Resource is the usage key a UsageDivergence applies to.
+	Resource corev1.ResourceName
+	// Reason explains why the CRQ behaves differently.
+	Reason string
+}
+
+// step creates one object in both namespaces.
+type step struct {
+	name string
+	// object returns the object to create in namespace.
+	object func(namespace string) client.Object
+	// denied is ResourceQuota's expected decision, checked so that both
+	// quotas agreeing on a wrong answer still fails.
+	denied bool
+}
+
+// scenario runs its steps in order against a ResourceQuota and a CRQ with the
+// same hard limits, each in a namespace of its own.
+type scenario struct {
+	name        string
+	hard        corev1.ResourceList
+	steps       []step
+	divergences []Divergence
+}
+
+// admissionDivergence returns the AdmissionDivergence of the named step, if any.
+func (s scenario) admissionDivergence(stepName string) (Divergence, bool) {
+	for _, d := range s.divergences {
+		if d.Kind == AdmissionDivergence && d.Step == stepName {
+			return d, true
+		}
+	}
+	return Divergence{}, false
+}
+
+// comparesUsage reports whether the usage of name is expected to match.
+func (s scenario) comparesUsage(name corev1.ResourceName) bool {
+	for _, d := range s.divergences {
+		if d.Kind == UsageDivergence && d.Resource == name {
+			return false
+		}
+	}
+	return true
+}
+
+func pod(name string, requests, limits corev1.ResourceList) func(string) client.Object {
+	return func(namespace string) client.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:      "pause",
+					Image:     pauseImage,
+					Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits},
+				}},
+			},
+		}
+	}
+}
+
+func configMap(name string) func(string) client.Object {
+	return func(namespace string) client.Object {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+}
+
+func secret(name string) func(string) client.Object {
+	return func(namespace string) client.Object {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+}
+
+func service(name string, serviceType corev1.ServiceType) func(string) client.Object {
+	return func(namespace string) client.Object {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Type:  serviceType,
+				Ports: []corev1.ServicePort{{Port: 80}},
+			},
+		}
+	}
+}
+
+func pvc(name, size string) func(string) client.Object {
+	return func(namespace string) client.Object {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+		}
+	}
+}
+
+func cpuMemory(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+var scenarios = []scenario{
+	{
+		name: "pod count",
+		hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("2")},
+		steps: []step{
+			{name: "first pod", object: pod("p1", nil, nil)},
+			{name: "second pod", object: pod("p2", nil, nil)},
+			{name: "pod over the limit", object: pod("p3", nil, nil), denied: true},
+		},
+	},
+	{
+		name: "compute requests and limits",
+		hard: corev1.ResourceList{
+			corev1.ResourceRequestsCPU:    resource.MustParse("1"),
+			corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+			corev1.ResourceLimitsCPU:      resource.MustParse("2"),
+			corev1.ResourceLimitsMemory:   resource.MustParse("2Gi"),
+		},
+		steps: []step{
+			{name: "first pod", object: pod("p1", cpuMemory("400m", "256Mi"), cpuMemory("800m", "512Mi"))},
+			{name: "second pod", object: pod("p2", cpuMemory("400m", "256Mi"), cpuMemory("800m", "512Mi"))},
+			{
+				name:   "pod over the cpu request limit",
+				object: pod("p3", cpuMemory("400m", "256Mi"), cpuMemory("400m", "256Mi")),
+				denied: true,
+			},
+			{name: "pod that still fits", object: pod("p4", cpuMemory("200m", "256Mi"), cpuMemory("400m", "512Mi"))},
+		},
+	},
+	{
+		name: "pod without requests under a compute quota",
+		hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
+		steps: []step{
+			{name: "pod without requests", object: pod("p1", nil, nil), denied: true},
+		},
+		divergences: []Divergence{{
+			Kind: AdmissionDivergence,
+			Step: "pod without requests",
+			Reason: "ResourceQuota requires every pod to declare the resources it limits; " +
+				"the CRQ charges missing requests as zero so unrelated workloads keep scheduling",
+		}},
+	},
+	{
+		name: "object counts",
+		hard: corev1.ResourceList{
+			// kube-root-ca.crt already takes one.
+			corev1.ResourceConfigMaps: resource.MustParse("2"),
+			corev1.ResourceSecrets:    resource.MustParse("1"),
+		},
+		steps: []step{
+			{name: "configmap", object: configMap("cm1")},
+			{name: "configmap over the limit", object: configMap("cm2"), denied: true},
+			{name: "secret", object: secret("s1")},
+			{name: "secret over the limit", object: secret("s2"), denied: true},
+		},
+	},
+	{
+		name: "services",
+		hard: corev1.ResourceList{
+			corev1.ResourceServices:          resource.MustParse("3"),
+			corev1.ResourceServicesNodePorts: resource.MustParse("1"),
+		},
+		steps: []step{
+			{name: "cluster IP service", object: service("svc1", corev1.ServiceTypeClusterIP)},
+			{name: "node port service", object: service("svc2", corev1.ServiceTypeNodePort)},
+			{name: "node port over the limit", object: service("svc3", corev1.ServiceTypeNodePort), denied: true},
+			{name: "cluster IP service that still fits", object: service("svc4", corev1.ServiceTypeClusterIP)},
+		},
+	},
+	{
+		name: "persistent volume claims",
+		hard: corev1.ResourceList{
+			corev1.ResourcePersistentVolumeClaims: resource.MustParse("2"),
+			corev1.ResourceRequestsStorage:        resource.MustParse("1Gi"),
+		},
+		steps: []step{
+			{name: "claim", object: pvc("c1", "600Mi")},
+			{name: "claim over the storage limit", object: pvc("c2", "600Mi"), denied: true},
+			{name: "claim that still fits", object: pvc("c3", "400Mi")},
+			{name: "claim over the count limit", object: pvc("c4", "1Mi"), denied: true},
+		},
+	},
+}
@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/internal/controller"
+	testutils "github.com/powerhome/pac-quota-controller/test/utils"
+)
+
+var _ = Describe("ClusterResourceQuota deletion policy", func() {
+	var (
+		suffix string
+		team   string
+		ns1    *corev1.Namespace
+		ns2    *corev1.Namespace
+	)
+
+	BeforeEach(func() {
+		suffix = testutils.GenerateTestSuffix()
+		team = "deletion-" + suffix
+
+		var err error
+		ns1, err = testutils.CreateNamespace(ctx, k8sClient, "deletion-ns1-"+suffix, map[string]string{"team": team})
+		Expect(err).NotTo(HaveOccurred())
+		ns2, err = testutils.CreateNamespace(ctx, k8sClient, "deletion-ns2-"+suffix, map[string]string{"team": team})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = k8sClient.Delete(ctx, ns1)
+		_ = k8sClient.Delete(ctx, ns2)
+	})
+
+	// createMirroredQuota creates a quota with the given deletion policy and
+	// waits for its status mirror in both namespaces.
+	createMirroredQuota := func(policy quotav1alpha1.DeletionPolicy) *quotav1alpha1.ClusterResourceQuota {
+		GinkgoHelper()
+		crq := &quotav1alpha1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "deletion-crq-" + suffix},
+			Spec: quotav1alpha1.ClusterResourceQuotaSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": team}},
+				Hard:              quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+				DeletionPolicy:    policy,
+			},
+		}
+		Expect(k8sClient.Create(ctx, crq)).To(Succeed())
+
+		By("waiting for the finalizer and the status mirrors")
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(crq), crq)).To(Succeed())
+			g.Expect(crq.Finalizers).To(ContainElement(controller.ProjectedObjectsFinalizer))
+			for _, ns := range []string{ns1.Name, ns2.Name} {
+				cm := &corev1.ConfigMap{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: ns, Name: controller.StatusMirrorName}, cm)).
+					To(Succeed())
+			}
+		}, Timeout, Interval).Should(Succeed())
+		return crq
+	}
+
+	waitQuotaGone := func(crq *quotav1alpha1.ClusterResourceQuota) {
+		GinkgoHelper()
+		Eventually(func() bool {
+			return apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(crq), crq))
+		}, Timeout, Interval).Should(BeTrue())
+	}
+
+	It("deletes the status mirrors with the quota by default", func() {
+		crq := createMirroredQuota("")
+
+		Expect(k8sClient.Delete(ctx, crq)).To(Succeed())
+		waitQuotaGone(crq)
+
+		By("finding no status mirror left in either namespace")
+		for _, ns := range []string{ns1.Name, ns2.Name} {
+			err := k8sClient.Get(ctx, client.ObjectKey{Namespace: ns, Name: controller.StatusMirrorName},
+				&corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), ns)
+		}
+	})
+
+	It("keeps the status mirrors, detached, under Orphan", func() {
+		crq := createMirroredQuota(quotav1alpha1.DeletionPolicyOrphan)
+
+		Expect(k8sClient.Delete(ctx, crq)).To(Succeed())
+		waitQuotaGone(crq)
+
+		By("finding the mirrors without owner reference or controller label")
+		// Give the garbage collector time to act on a stray owner reference.
+		Consistently(func(g Gomega) {
+			for _, ns := range []string{ns1.Name, ns2.Name} {
+				cm := &corev1.ConfigMap{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: ns, Name: controller.StatusMirrorName}, cm)).
+					To(Succeed())
+				g.Expect(cm.OwnerReferences).To(BeEmpty())
+				g.Expect(cm.Labels).NotTo(HaveKey(controller.LabelStatusMirror))
+			}
+		}, 10*Interval, Interval).Should(Succeed())
+	})
+})
@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha1 "github.com/powerhome/pac-quota-controller/api/v1alpha1"
+	"github.com/powerhome/pac-quota-controller/pkg/faults"
+	testutils "github.com/powerhome/pac-quota-controller/test/utils"
+)
+
+// faultsPort is the local port forwarded to the webhook Service.
+const faultsPort = 19443
+
+// Faults are process-wide in the controller, so these specs run serially.
+var _ = Describe("Fault injection", Ordered, Serial, func() {
+	var (
+		suffix     string
+		ns         *corev1.Namespace
+		crq        *quotav1alpha1.ClusterResourceQuota
+		server     = fmt.Sprintf("https://localhost:%d", faultsPort)
+		httpClient = &http.Client{
+			Timeout: 10 * time.Second,
+			// The serving certificate names the Service, not localhost.
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+	)
+
+	inject := func(point faults.Point, f faults.Fault) {
+		Expect(faults.Inject(ctx, httpClient, server, testutils.E2EAdminToken, point, f)).To(Succeed())
+		DeferCleanup(func() {
+			_ = faults.Remove(ctx, httpClient, server, testutils.E2EAdminToken, point)
+		})
+	}
+
+	BeforeAll(func() {
+		pfCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+		Expect(e2eConfig.PortForwardWebhook(pfCtx, faultsPort)).To(Succeed())
+
+		err := faults.Remove(ctx, httpClient, server, testutils.E2EAdminToken, faults.Admission)
+		if errors.Is(err, faults.ErrNotEnabled) {
+			Skip("the controller image was built without the faultinject tag")
+		}
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	BeforeEach(func() {
+		suffix = testutils.GenerateTestSuffix()
+		team := "faults-" + suffix
+
+		var err error
+		ns, err = testutils.CreateNamespace(ctx, k8sClient, "faults-ns-"+suffix, map[string]string{"team": team})
+		Expect(err).NotTo(HaveOccurred())
+		crq, err = testutils.CreateClusterResourceQuota(ctx, k8sClient, "faults-crq-"+suffix,
+			&metav1.LabelSelector{MatchLabels: map[string]string{"team": team}},
+			quotav1alpha1.ResourceList{corev1.ResourcePods: resource.MustParse("1")})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = k8sClient.Delete(ctx, crq)
+		_ = k8sClient.Delete(ctx, ns)
+	})
+
+	// fillQuota creates the one pod the quota allows and waits for the
+	// controller to record it, so the webhook would deny the next pod.
+	fillQuota := func() {
+		p1, err := testutils.CreatePod(ctx, k8sClient, ns.Name, "pod-1-"+suffix,
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { _ = k8sClient.Delete(ctx, p1) })
+		Eventually(func() error {
+			usage := testutils.GetRefreshedCRQStatusUsage(ctx, k8sClient, crq.Name)
+			return testutils.ExpectCRQUsageToMatch(usage, map[string]string{"pods": "1"})
+		}, Timeout, Interval).Should(Succeed())
+	}
+
+	createSecondPod := func() error {
+		p2, err := testutils.CreatePod(ctx, k8sClient, ns.Name, "pod-2-"+suffix,
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}, nil)
+		if err == nil {
+			DeferCleanup(func() { _ = k8sClient.Delete(ctx, p2) })
+		}
+		return err
+	}
+
+	It("fails open when the webhook answers admission with errors", func() {
+		fillQuota()
+		Expect(createSecondPod()).NotTo(Succeed(), "the quota is enforced before the fault")
+
+		By("failing every admission request (failurePolicy: Ignore)")
+		inject(faults.Admission, faults.Fault{Status: http.StatusInternalServerError, Path: "/validate-v1-pod"})
+		Expect(createSecondPod()).To(Succeed())
+	})
+
+	It("fails open while the serving certificate is unavailable", func() {
+		fillQuota()
+
+		By("failing TLS handshakes; the fault lifts itself as the admin routes fail too")
+		inject(faults.Certificate, faults.Fault{
+			Status: http.StatusServiceUnavailable,
+			For:    metav1.Duration{Duration: 20 * time.Second},
+		})
+		Expect(createSecondPod()).To(Succeed())
+
+		By("serving again once the fault lifts")
+		Eventually(func() error {
+			_, err := httpClient.Get(server + "/healthz")
+			return err
+		}, Timeout, Interval).Should(Succeed())
+	})
+
+	It("retries status writes the API server fails until they succeed", func() {
+		By("failing the status writes of this quota")
+		inject(faults.KubeAPI, faults.Fault{
+			Status: http.StatusInternalServerError,
+			Method: http.MethodPatch,
+			Path:   "/clusterresourcequotas/" + crq.Name + "/status",
+		})
+		p1, err := testutils.CreatePod(ctx, k8sClient, ns.Name, "pod-1-"+suffix,
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { _ = k8sClient.Delete(ctx, p1) })
+		Consistently(func() error {
+			usage := testutils.GetRefreshedCRQStatusUsage(ctx, k8sClient, crq.Name)
+			return testutils.ExpectCRQUsageToMatch(usage, map[string]string{"pods": "1"})
+		}, 10*time.Second, Interval).ShouldNot(Succeed())
+
+		By("recording the usage once the API server recovers")
+		Expect(faults.Remove(ctx, httpClient, server, testutils.E2EAdminToken, faults.KubeAPI)).To(Succeed())
+		Eventually(func() error {
+			usage := testutils.GetRefreshedCRQStatusUsage(ctx, k8sClient, crq.Name)
+			return testutils.ExpectCRQUsageToMatch(usage, map[string]string{"pods": "1"})
+		}, Timeout, Interval).Should(Succeed())
+	})
+})
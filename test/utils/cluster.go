@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -36,8 +38,13 @@ const (
 	defaultCertManagerNS = "cert-manager"
 	chartPath            = "./charts/pac-quota-controller"
 	controllerDeployment = "pac-quota-controller-manager"
+	webhookService       = "pac-quota-controller-service"
+	adminTokenSecret     = "pac-quota-controller-e2e-admin"
 )
 
+// E2EAdminToken is the admin API token the deployed chart accepts.
+const E2EAdminToken = "pac-quota-controller-e2e"
+
 // E2EConfig holds the e2e environment settings, read from the environment.
 type E2EConfig struct {
 	KindCluster   string
@@ -119,11 +126,14 @@ func (c E2EConfig) EnsureCluster(ctx context.Context) error {
 }
 
 // BuildAndLoadImage builds the manager image and loads it into the kind cluster.
+// The image is built with the faultinject tag so the resilience suites can
+// inject failures.
 func (c E2EConfig) BuildAndLoadImage(ctx context.Context, root string) error {
 	if c.SkipBuild {
 		return nil
 	}
-	if err := runCmd(ctx, root, "docker", "build", "-t", c.Image, "."); err != nil {
+	if err := runCmd(ctx, root, "docker", "build", "-t", c.Image,
+		"--build-arg", "GO_BUILD_TAGS=faultinject", "."); err != nil {
 		return err
 	}
 	return runCmd(ctx, "", "kind", "load", "docker-image", c.Image, "--name", c.KindCluster)
@@ -147,12 +157,20 @@ func (c E2EConfig) InstallCertManager(ctx context.Context) error {
 
 // DeployChart installs/upgrades the controller Helm chart with the loaded image.
 func (c E2EConfig) DeployChart(ctx context.Context, root string) error {
+	// Ignore errors: both fail if they already exist.
+	_ = runCmd(ctx, "", "kubectl", "create", "namespace", c.HelmNamespace)
+	_ = runCmd(ctx, "", "kubectl", "-n", c.HelmNamespace, "create", "secret", "generic", adminTokenSecret,
+		"--from-literal=token="+E2EAdminToken)
 	repo, tag := splitImage(c.Image)
 	return runCmd(ctx, root, "helm", "upgrade", "--install", c.HelmRelease, chartPath,
 		"--namespace", c.HelmNamespace, "--create-namespace",
 		"--set", "controllerManager.container.image.repository="+repo,
 		"--set", "controllerManager.container.image.tag="+tag,
 		"--set", "controllerManager.container.image.pullPolicy=Never",
+		// Project status mirrors so the deletion policy tests have objects to clean up.
+		"--set", "statusMirror.enable=true",
+		// Serve the admin routes, which set faults in faultinject images.
+		"--set", "adminAPI.tokenSecretName="+adminTokenSecret,
 		"--wait", "--timeout", "10m0s")
 }
 
@@ -261,6 +279,35 @@ func repoRoot() (string, error) {
 	}
 }
 
+// PortForwardWebhook forwards localPort to the webhook Service until ctx is
+// done, returning once the port accepts connections. The Service picks one
+// replica, and faults are set per replica, so the suites run one.
+func (c E2EConfig) PortForwardWebhook(ctx context.Context, localPort int) error {
+	args := []string{"-n", c.HelmNamespace, "port-forward",
+		"svc/" + webhookService, fmt.Sprintf("%d:443", localPort)}
+	_, _ = fmt.Fprintf(os.Stdout, "[e2e] $ kubectl %s\n", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start port-forward: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+
+	addr := fmt.Sprintf("localhost:%d", localPort)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("port-forward to %s not ready: %w", webhookService, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
 func runCmd(ctx context.Context, dir, name string, args ...string) error {
 	_, _ = fmt.Fprintf(os.Stdout, "[e2e] $ %s %s\n", name, strings.Join(args, " "))
 	cmd := exec.CommandContext(ctx, name, args...)